@@ -20,16 +20,29 @@ import (
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/vcto/mcp-adapters/internal/admin"
+	"github.com/vcto/mcp-adapters/internal/audit"
 	"github.com/vcto/mcp-adapters/internal/auth"
+	"github.com/vcto/mcp-adapters/internal/changelog"
+	"github.com/vcto/mcp-adapters/internal/deadline"
 	"github.com/vcto/mcp-adapters/internal/debug"
+	"github.com/vcto/mcp-adapters/internal/health"
+	"github.com/vcto/mcp-adapters/internal/kv"
+	"github.com/vcto/mcp-adapters/internal/lazy"
+	"github.com/vcto/mcp-adapters/internal/lint"
+	"github.com/vcto/mcp-adapters/internal/manifest"
 	"github.com/vcto/mcp-adapters/internal/middleware"
+	"github.com/vcto/mcp-adapters/internal/residency"
 	"github.com/vcto/mcp-adapters/internal/rtm"
+	"github.com/vcto/mcp-adapters/internal/security"
+	"github.com/vcto/mcp-adapters/internal/tooldocs"
+	"github.com/vcto/mcp-adapters/internal/webhooks"
 )
 
 // Version information
 const (
 	serverName    = "cowpilot-everything"
-	serverVersion = "1.0.0"
+	serverVersion = "1.1.0"
 )
 
 // Tiny example image (1x1 transparent PNG)
@@ -56,42 +69,123 @@ func main() {
 		}
 	}()
 
-	// Create MCP server
-	s := server.NewMCPServer(
-		serverName,
-		serverVersion,
+	// Record which stores and regions hold each subject's data
+	ledger := residency.NewLedger(kv.OpenFromEnv(), residency.Region())
+	residency.SetDefault(ledger)
+
+	// Publish permission descriptors and tool groups from the adapter manifests
+	manifests := []*manifest.Manifest{rtm.Manifest(), coreManifest()}
+	hooks := &server.Hooks{}
+	manifest.AttachPermissions(hooks, manifests...)
+	manifest.AttachGroups(hooks, manifests...)
+	manifest.AttachRetryPolicies(hooks, manifests...)
+
+	// Adapters initialize on first use of their tools unless MCP_LAZY_INIT=false
+	inits := lazy.NewRegistry()
+
+	// Latency of recent calls, reported in docs://tools/{name}
+	toolStats := tooldocs.NewStats()
+
+	serverOptions := []server.ServerOption{
 		server.WithToolCapabilities(false),
 		server.WithResourceCapabilities(true, true),
 		server.WithPromptCapabilities(true),
-	)
+		server.WithHooks(hooks),
+		server.WithToolHandlerMiddleware(deadline.ToolMiddleware()),
+		server.WithToolHandlerMiddleware(toolStats.Middleware()),
+		server.WithToolHandlerMiddleware(inits.Middleware()),
+		server.WithToolHandlerMiddleware(manifest.RetryMiddleware(manifests...)),
+		server.WithToolHandlerMiddleware(manifest.ScopeMiddleware(manifests...)),
+	}
+	if manifest.GatewayEnabled() {
+		// Hide grouped tools behind list_groups/call_grouped
+		serverOptions = append(serverOptions, server.WithToolFilter(manifest.GatewayToolFilter(manifests...)))
+	}
+
+	// Create MCP server
+	s := server.NewMCPServer(serverName, serverVersion, serverOptions...)
+	if manifest.GatewayEnabled() {
+		manifest.SetupGateway(s, manifests...)
+	}
 
 	// Add all tools
 	setupTools(s)
 
 	// Add RTM tools if credentials available
 	var rtmHandler *rtm.Handler
+	var rtmInit *lazy.Init
 	if rtmHandler = rtm.NewHandler(); rtmHandler != nil {
 		log.Println("RTM: Registering RTM tools (API credentials found)")
 		rtmHandler.SetupTools(s)
+		rtmHandler.SetupPrompts(s)
+		rtmInit = lazy.New("rtm", rtmHandler.Warmup)
+		inits.Add(rtmInit, rtm.Manifest().AdapterTools()...)
 	} else {
 		log.Println("RTM: Skipping RTM tools (no API credentials)")
 	}
 
+	// Report health of whichever adapters are enabled
+	var reporters []health.Reporter
+	var outageTargets []health.OutageTarget
+	if rtmHandler != nil {
+		reporters = append(reporters, rtmInit.Report(rtmHandler))
+		outageTargets = append(outageTargets, rtmHandler)
+	}
+	health.SetupStatusTool(s, reporters...)
+
+	// Inbound webhooks that call tools, from WEBHOOKS_CONFIG
+	webhookRegistry, err := webhooks.LoadFromEnv(s)
+	if err != nil {
+		log.Fatalf("Webhooks: %v", err)
+	}
+	if webhookRegistry != nil {
+		webhooks.SetupAuditTool(s, webhookRegistry)
+		log.Printf("Webhooks: Serving %s", strings.Join(webhookRegistry.Names(), ", "))
+	}
+	if health.OutageSimulationEnabled() {
+		health.SetupOutageTool(s, outageTargets...)
+	}
+	if !lazy.Enabled() {
+		if err := inits.InitAll(context.Background(), lazy.InitTimeoutFromEnv()); err != nil {
+			log.Printf("Startup init: %v (will retry on first use)", err)
+		}
+	}
+
+	// Report tool and flag changes since the previous release
+	changelog.SetupResource(s, serverName, serverVersion, manifests...)
+
+	// Per-tool schemas, examples and latency for client self-discovery
+	tooldocs.Setup(s, toolStats, rtm.ToolDocs(), manifests...)
+
 	// Add native resources
 	setupResources(s)
 
 	// Add RTM resources if handler available
 	if rtmHandler != nil {
 		setupRTMResources(s, rtmHandler)
+
+		// Tell connected clients when rtm:// resources change in RTM
+		watcher := rtm.NewResourceWatcher(rtmHandler, s, rtm.ResourcePollIntervalFromEnv())
+		watcher.Attach(hooks)
+		go watcher.Run(context.Background())
 	}
 
 	// Add native prompts
 	setupPrompts(s)
 
+	// Refuse to start with tools, prompts or resources connector clients would reject
+	if err := lint.EnforceFromEnv(context.Background(), s); err != nil {
+		log.Fatalf("Registry lint: %v", err)
+	}
+
 	// Check if we're running on Fly.io or locally
 	if os.Getenv("FLY_APP_NAME") != "" {
 		// Run HTTP server for Fly.io, passing the auth flag
-		runHTTPServer(s, debugStorage, debugConfig, *disableAuth, rtmHandler)
+		// Dependencies are checked against OSV as SECURITY_SCAN configures
+		scanner := security.ScannerFromEnv()
+		scanner.Start()
+		adminService := admin.NewService(admin.Config{Reporters: reporters, Security: scanner, AuthEvents: audit.Default(), Residency: ledger})
+		runHTTPServer(s, debugStorage, debugConfig, *disableAuth, rtmHandler, webhookRegistry, adminService, scanner)
 	} else {
 		// Run stdio server for local development
 		if debugConfig.Enabled {
@@ -103,7 +197,7 @@ func main() {
 	}
 }
 
-func runHTTPServer(mcpServer *server.MCPServer, debugStorage debug.Storage, debugConfig *debug.DebugConfig, authDisabled bool, rtmHandler *rtm.Handler) {
+func runHTTPServer(mcpServer *server.MCPServer, debugStorage debug.Storage, debugConfig *debug.DebugConfig, authDisabled bool, rtmHandler *rtm.Handler, webhookRegistry *webhooks.Registry, adminService *admin.Service, scanner *security.Scanner) {
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
@@ -142,9 +236,14 @@ func runHTTPServer(mcpServer *server.MCPServer, debugStorage debug.Storage, debu
 		rtmAPIKey := os.Getenv("RTM_API_KEY")
 		rtmSecret := os.Getenv("RTM_API_SECRET")
 
-		if rtmAPIKey != "" && rtmSecret != "" {
+		if staticKeys := auth.StaticKeysFromEnv(); staticKeys != nil {
+			// Static API keys replace the browser flow entirely
+			handler = auth.StaticKeyMiddleware(staticKeys)(handler)
+			log.Printf("Auth: Enabled %d static API key(s), OAuth endpoints off", staticKeys.Len())
+		} else if rtmAPIKey != "" && rtmSecret != "" {
 			// Use RTM OAuth adapter
 			rtmAdapter := rtm.NewOAuthAdapter(rtmAPIKey, rtmSecret, serverURL)
+			rtmAdapter.UseAuditLog(audit.Default())
 			rtmSetup := rtm.NewSetupHandler()
 
 			// OAuth endpoints for RTM (claude.ai compatibility)
@@ -152,36 +251,21 @@ func runHTTPServer(mcpServer *server.MCPServer, debugStorage debug.Storage, debu
 			mux.HandleFunc("/token", rtmAdapter.HandleToken)
 			mux.HandleFunc("/oauth/authorize", rtmAdapter.HandleAuthorize)
 			mux.HandleFunc("/oauth/token", rtmAdapter.HandleToken)
+			mux.HandleFunc("/oauth/revoke", rtmAdapter.HandleRevoke)
+			mux.HandleFunc("/oauth/introspect", rtmAdapter.HandleIntrospect)
+			mux.HandleFunc("/oauth/register", rtmAdapter.HandleRegister)
+			mux.HandleFunc("/oauth/register/", rtmAdapter.HandleClientConfiguration)
 			mux.HandleFunc("/rtm/callback", rtmAdapter.HandleCallback)
 			mux.HandleFunc("/rtm/check-auth", rtmAdapter.HandleCheckAuth)
 			mux.HandleFunc("/rtm/setup", rtmSetup.HandleSetup)
+			mux.HandleFunc("/health/oauth", rtmAdapter.Guard().HandleMetrics)
 
 			// OAuth discovery endpoints (RFC 9728 + Claude compatibility)
-			mux.HandleFunc("/.well-known/oauth-protected-resource", func(w http.ResponseWriter, r *http.Request) {
-				w.Header().Set("Content-Type", "application/json")
-				if err := json.NewEncoder(w).Encode(map[string]interface{}{
-					"authorization_servers": []string{serverURL},
-					"resource":              serverURL + "/mcp",
-				}); err != nil {
-					log.Printf("Failed to encode OAuth metadata: %v", err)
-				}
-			})
-			mux.HandleFunc("/.well-known/oauth-authorization-server", func(w http.ResponseWriter, r *http.Request) {
-				w.Header().Set("Content-Type", "application/json")
-				if err := json.NewEncoder(w).Encode(map[string]interface{}{
-					"issuer":                           serverURL,
-					"authorization_endpoint":           serverURL + "/authorize",
-					"token_endpoint":                   serverURL + "/token",
-					"response_types_supported":         []string{"code"},
-					"grant_types_supported":            []string{"authorization_code"},
-					"code_challenge_methods_supported": []string{"S256"},
-				}); err != nil {
-					log.Printf("Failed to encode auth server metadata: %v", err)
-				}
-			})
+			mux.HandleFunc("/.well-known/oauth-protected-resource", rtmAdapter.HandleProtectedResourceMetadata)
+			mux.HandleFunc("/.well-known/oauth-authorization-server", rtmAdapter.HandleAuthServerMetadata)
 
 			// Add auth middleware that accepts RTM tokens
-			handler = rtmAuthMiddleware(rtmAdapter, rtmHandler, serverURL)(handler)
+			handler = rtmAuthMiddleware(rtmAdapter, serverURL)(handler)
 
 			log.Printf("OAuth: Enabled RTM OAuth adapter")
 		} else {
@@ -193,6 +277,7 @@ func runHTTPServer(mcpServer *server.MCPServer, debugStorage debug.Storage, debu
 				}
 			}
 			oauthAdapter := auth.NewOAuthAdapter(serverURL, callbackPort)
+			oauthAdapter.UseAuditLog(audit.Default())
 
 			// Add auth middleware to the MCP handler
 			handler = auth.Middleware(oauthAdapter)(handler)
@@ -200,24 +285,45 @@ func runHTTPServer(mcpServer *server.MCPServer, debugStorage debug.Storage, debu
 			// OAuth endpoints
 			mux.HandleFunc("/.well-known/oauth-protected-resource", oauthAdapter.HandleProtectedResourceMetadata)
 			mux.HandleFunc("/.well-known/oauth-authorization-server", oauthAdapter.HandleAuthServerMetadata)
+			mux.HandleFunc("/.well-known/jwks.json", oauthAdapter.HandleJWKS)
 			mux.HandleFunc("/oauth/authorize", oauthAdapter.HandleAuthorize)
 			mux.HandleFunc("/oauth/token", oauthAdapter.HandleToken)
+			mux.HandleFunc("/oauth/revoke", oauthAdapter.HandleRevoke)
+			mux.HandleFunc("/oauth/introspect", oauthAdapter.HandleIntrospect)
 			mux.HandleFunc("/oauth/register", oauthAdapter.HandleRegister)
+			mux.HandleFunc("/oauth/register/", oauthAdapter.HandleClientConfiguration)
+			mux.HandleFunc("/oauth/oidc/callback", oauthAdapter.HandleOIDCCallback)
+			mux.HandleFunc("/oauth/oidc/link", oauthAdapter.HandleOIDCLink)
 			// Also add endpoints without /oauth/ prefix for compatibility
 			mux.HandleFunc("/authorize", oauthAdapter.HandleAuthorize)
 			mux.HandleFunc("/token", oauthAdapter.HandleToken)
+			mux.HandleFunc("/health/oauth", oauthAdapter.Guard().HandleMetrics)
 			log.Printf("OAuth: Enabled generic OAuth adapter")
 		}
 	} else {
 		log.Println("OAuth: DISABLED via --disable-auth flag")
 	}
 
-	// Health check
-	mux.HandleFunc("/health", handleHealth)
+	// Health check, with the dependency scan summary at /health?security=true
+	mux.HandleFunc("/health", security.HealthHandler(scanner, handleHealth))
+
+	// Operator control plane, including the data residency report
+	if token := admin.TokenFromEnv(); token != "" {
+		mux.Handle(admin.PathPrefix, admin.NewHTTPHandler(adminService, token))
+		log.Printf("Admin: HTTP API at %s%s", serverURL, admin.PathPrefix)
+	}
 
 	// Logo for Claude.ai connector display
 	mux.HandleFunc("/logo", handleLogo)
 
+	// Inbound webhooks verify their own secrets, so they sit outside OAuth
+	if webhookRegistry != nil {
+		mux.Handle(webhooks.PathPrefix, webhookRegistry)
+	}
+
+	// Client deadlines bound everything below, auth included
+	handler = deadline.Middleware(handler)
+
 	// MCP server handles requests at /mcp endpoint
 	mux.Handle("/mcp", handler)
 	mux.Handle("/mcp/", handler)
@@ -234,6 +340,14 @@ func runHTTPServer(mcpServer *server.MCPServer, debugStorage debug.Storage, debu
 		Handler: finalHandler,
 	}
 
+	// Mutual TLS authenticates clients at the transport, before any of the above
+	if mtlsConfig, ok := auth.MTLSConfigFromEnv(); ok {
+		if err := mtlsConfig.Apply(srv); err != nil {
+			log.Fatalf("Failed to enable mutual TLS: %v", err)
+		}
+		log.Printf("Auth: Enabled mutual TLS client authentication")
+	}
+
 	log.Printf("Starting MCP server with StreamableHTTP transport on port %s", port)
 	log.Printf("Protocol: StreamableHTTP (VERIFIED: Works with MCP Inspector CLI)")
 	log.Printf("CORS: Enabled for %v", corsConfig.AllowOrigins)
@@ -252,7 +366,13 @@ func runHTTPServer(mcpServer *server.MCPServer, debugStorage debug.Storage, debu
 	serverErr := make(chan error, 1)
 	go func() {
 		log.Printf("Server starting on :%s", port)
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if srv.TLSConfig != nil {
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			serverErr <- err
 		}
 	}()
@@ -431,6 +551,28 @@ func setupPrompts(s *server.MCPServer) {
 	})
 }
 
+// coreManifest groups the built-in demo tools; none of them touch an external account
+func coreManifest() *manifest.Manifest {
+	tools := map[string]manifest.ToolAccess{
+		"adapter_status":  {Group: manifest.GroupAdmin},
+		"simulate_outage": {Group: manifest.GroupAdmin},
+		"webhook_audit":   {Group: manifest.GroupAdmin},
+	}
+	for _, name := range []string{
+		"hello", "echo", "add", "get_time", "base64_encode", "base64_decode",
+		"string_operation", "format_json", "long_running_operation",
+		"get_test_image", "get_resource_content",
+	} {
+		tools[name] = manifest.ToolAccess{}
+	}
+
+	return &manifest.Manifest{
+		Adapter: "demo",
+		Account: "None",
+		Tools:   tools,
+	}
+}
+
 func setupTools(s *server.MCPServer) {
 	// Hello tool (existing)
 	helloTool := mcp.NewTool("hello",
@@ -838,7 +980,7 @@ func protocolDetectionMiddleware(next http.Handler) http.Handler {
 }
 
 // rtmAuthMiddleware validates RTM bearer tokens
-func rtmAuthMiddleware(adapter *rtm.OAuthAdapter, rtmHandler *rtm.Handler, serverURL string) func(http.Handler) http.Handler {
+func rtmAuthMiddleware(adapter *rtm.OAuthAdapter, serverURL string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Skip auth for OAuth endpoints
@@ -864,22 +1006,25 @@ func rtmAuthMiddleware(adapter *rtm.OAuthAdapter, rtmHandler *rtm.Handler, serve
 			// Extract bearer token
 			const bearerPrefix = "Bearer "
 			if !strings.HasPrefix(authHeader, bearerPrefix) {
+				adapter.Auditor().Record(r, auth.AuditValidationFailed, auth.AuditFailure, "", "invalid authorization format")
 				http.Error(w, "Invalid Authorization format", http.StatusUnauthorized)
 				return
 			}
 
 			token := strings.TrimPrefix(authHeader, bearerPrefix)
 			if !adapter.ValidateBearer(token) {
+				adapter.Auditor().Record(r, auth.AuditValidationFailed, auth.AuditFailure, "", "invalid token")
 				http.Error(w, "Invalid token", http.StatusUnauthorized)
 				return
 			}
 
-			// Set token on the registered RTM handler instance
-			if rtmHandler != nil {
-				rtmHandler.SetAuthToken(token)
+			// Tools act as the token's RTM user for this request only, limited
+			// to the scopes it was granted
+			ctx := rtm.WithAuthToken(r.Context(), token)
+			if scopes := adapter.TokenScopes(token); scopes != nil {
+				ctx = auth.WithScopes(ctx, scopes)
 			}
-
-			next.ServeHTTP(w, r)
+			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
@@ -891,21 +1036,26 @@ func setupRTMResources(s *server.MCPServer, handler *rtm.Handler) {
 		mcp.WithResourceDescription("Tasks due today, sorted by priority"),
 		mcp.WithMIMEType("application/json"),
 	), func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
-		if handler.GetClient().AuthToken == "" {
+		if handler.ClientFor(ctx).GetAuthToken() == "" {
 			return nil, fmt.Errorf("RTM authentication required")
 		}
 
-		tasks, err := handler.GetClient().GetTasks("due:today", "")
+		tasks, stale, err := handler.TodayTasks(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get today's tasks: %v", err)
 		}
 
-		data, err := json.MarshalIndent(map[string]interface{}{
+		payload := map[string]interface{}{
 			"title": "Today's Tasks",
 			"date":  time.Now().Format("2006-01-02"),
 			"tasks": tasks,
 			"count": len(tasks),
-		}, "", "  ")
+		}
+		if stale != nil {
+			payload["stale"] = stale
+		}
+
+		data, err := json.MarshalIndent(payload, "", "  ")
 		if err != nil {
 			return nil, err
 		}
@@ -925,11 +1075,11 @@ func setupRTMResources(s *server.MCPServer, handler *rtm.Handler) {
 		mcp.WithResourceDescription("Tasks in the default inbox"),
 		mcp.WithMIMEType("application/json"),
 	), func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
-		if handler.GetClient().AuthToken == "" {
+		if handler.ClientFor(ctx).GetAuthToken() == "" {
 			return nil, fmt.Errorf("RTM authentication required")
 		}
 
-		tasks, err := handler.GetClient().GetTasks("list:Inbox", "")
+		tasks, err := handler.ClientFor(ctx).GetTasks("list:Inbox", "")
 		if err != nil {
 			return nil, fmt.Errorf("failed to get inbox tasks: %v", err)
 		}
@@ -958,11 +1108,11 @@ func setupRTMResources(s *server.MCPServer, handler *rtm.Handler) {
 		mcp.WithResourceDescription("Tasks past their due date"),
 		mcp.WithMIMEType("application/json"),
 	), func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
-		if handler.GetClient().AuthToken == "" {
+		if handler.ClientFor(ctx).GetAuthToken() == "" {
 			return nil, fmt.Errorf("RTM authentication required")
 		}
 
-		tasks, err := handler.GetClient().GetTasks("dueBefore:today", "")
+		tasks, err := handler.ClientFor(ctx).GetTasks("dueBefore:today", "")
 		if err != nil {
 			return nil, fmt.Errorf("failed to get overdue tasks: %v", err)
 		}
@@ -991,11 +1141,11 @@ func setupRTMResources(s *server.MCPServer, handler *rtm.Handler) {
 		mcp.WithResourceDescription("Tasks due in the next 7 days"),
 		mcp.WithMIMEType("application/json"),
 	), func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
-		if handler.GetClient().AuthToken == "" {
+		if handler.ClientFor(ctx).GetAuthToken() == "" {
 			return nil, fmt.Errorf("RTM authentication required")
 		}
 
-		tasks, err := handler.GetClient().GetTasks("due:within 1 week", "")
+		tasks, err := handler.ClientFor(ctx).GetTasks("due:within 1 week", "")
 		if err != nil {
 			return nil, fmt.Errorf("failed to get week's tasks: %v", err)
 		}
@@ -1018,26 +1168,64 @@ func setupRTMResources(s *server.MCPServer, handler *rtm.Handler) {
 		}, nil
 	})
 
+	// Eisenhower priority matrix
+	s.AddResource(mcp.NewResource(rtm.MatrixURI,
+		"Priority Matrix",
+		mcp.WithResourceDescription("Incomplete tasks sorted into urgent/important quadrants: urgent when overdue or due by tomorrow, important at priority 1 or 2"),
+		mcp.WithMIMEType("application/json"),
+	), func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		if handler.ClientFor(ctx).GetAuthToken() == "" {
+			return nil, fmt.Errorf("RTM authentication required")
+		}
+
+		tasks, err := handler.ClientFor(ctx).GetTasks(rtm.MatrixFilter, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tasks: %v", err)
+		}
+
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"title":     "Priority Matrix",
+			"quadrants": rtm.PriorityMatrix(tasks, time.Now()),
+			"count":     len(tasks),
+		}, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      rtm.MatrixURI,
+				MIMEType: "application/json",
+				Text:     string(data),
+			},
+		}, nil
+	})
+
 	// All lists
 	s.AddResource(mcp.NewResource("rtm://lists",
 		"All Lists",
 		mcp.WithResourceDescription("All lists with task counts"),
 		mcp.WithMIMEType("application/json"),
 	), func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
-		if handler.GetClient().AuthToken == "" {
+		if handler.ClientFor(ctx).GetAuthToken() == "" {
 			return nil, fmt.Errorf("RTM authentication required")
 		}
 
-		lists, err := handler.GetClient().GetLists()
+		lists, stale, err := handler.Lists(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get lists: %v", err)
 		}
 
-		data, err := json.MarshalIndent(map[string]interface{}{
+		payload := map[string]interface{}{
 			"title": "All Lists",
 			"lists": lists,
 			"count": len(lists),
-		}, "", "  ")
+		}
+		if stale != nil {
+			payload["stale"] = stale
+		}
+
+		data, err := json.MarshalIndent(payload, "", "  ")
 		if err != nil {
 			return nil, err
 		}
@@ -1051,11 +1239,67 @@ func setupRTMResources(s *server.MCPServer, handler *rtm.Handler) {
 		}, nil
 	})
 
+	// Mutations queued while RTM was unreachable
+	s.AddResource(mcp.NewResource("rtm://intents/pending",
+		"Pending Changes",
+		mcp.WithResourceDescription("Changes queued during RTM outages that have not synced yet, plus recent conflicts"),
+		mcp.WithMIMEType("application/json"),
+	), func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		if handler.ClientFor(ctx).GetAuthToken() == "" {
+			return nil, fmt.Errorf("RTM authentication required")
+		}
+
+		pending, conflicts, enabled := handler.PendingIntents(ctx)
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"title":     "Pending Changes",
+			"enabled":   enabled,
+			"pending":   pending,
+			"conflicts": conflicts,
+			"count":     len(pending),
+		}, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      "rtm://intents/pending",
+				MIMEType: "application/json",
+				Text:     string(data),
+			},
+		}, nil
+	})
+
+	// Upcoming tasks as an iCalendar feed
+	calendarDays := rtm.CalendarDaysFromEnv()
+	s.AddResource(mcp.NewResource(rtm.CalendarURI,
+		"Task Calendar",
+		mcp.WithResourceDescription(fmt.Sprintf("Incomplete tasks due in the next %d days as an iCalendar (.ics) feed", calendarDays)),
+		mcp.WithMIMEType(rtm.CalendarMIMEType),
+	), func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		if handler.ClientFor(ctx).GetAuthToken() == "" {
+			return nil, fmt.Errorf("RTM authentication required")
+		}
+
+		tasks, err := handler.ClientFor(ctx).GetCalendarTasks(calendarDays)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get upcoming tasks: %v", err)
+		}
+
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      rtm.CalendarURI,
+				MIMEType: rtm.CalendarMIMEType,
+				Text:     rtm.RenderICS(tasks, time.Now()),
+			},
+		}, nil
+	})
+
 	// Template: Tasks in specific list
 	s.AddResourceTemplate(mcp.NewResourceTemplate("rtm://lists/{list_name}",
 		"List Tasks",
 	), func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
-		if handler.GetClient().AuthToken == "" {
+		if handler.ClientFor(ctx).GetAuthToken() == "" {
 			return nil, fmt.Errorf("RTM authentication required")
 		}
 
@@ -1064,7 +1308,7 @@ func setupRTMResources(s *server.MCPServer, handler *rtm.Handler) {
 			return nil, fmt.Errorf("invalid list URI format")
 		}
 
-		tasks, err := handler.GetClient().GetTasks("list:"+listName, "")
+		tasks, err := handler.ClientFor(ctx).GetTasks("list:"+listName, "")
 		if err != nil {
 			return nil, fmt.Errorf("failed to get list tasks: %v", err)
 		}
@@ -1092,7 +1336,7 @@ func setupRTMResources(s *server.MCPServer, handler *rtm.Handler) {
 	s.AddResourceTemplate(mcp.NewResourceTemplate("rtm://smart/{list_name}",
 		"Smart List",
 	), func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
-		if handler.GetClient().AuthToken == "" {
+		if handler.ClientFor(ctx).GetAuthToken() == "" {
 			return nil, fmt.Errorf("RTM authentication required")
 		}
 
@@ -1101,7 +1345,7 @@ func setupRTMResources(s *server.MCPServer, handler *rtm.Handler) {
 			return nil, fmt.Errorf("invalid smart list URI format")
 		}
 
-		lists, err := handler.GetClient().GetLists()
+		lists, err := handler.ClientFor(ctx).GetLists()
 		if err != nil {
 			return nil, fmt.Errorf("failed to get lists: %v", err)
 		}
@@ -1118,7 +1362,7 @@ func setupRTMResources(s *server.MCPServer, handler *rtm.Handler) {
 			return nil, fmt.Errorf("smart list '%s' not found", smartListName)
 		}
 
-		tasks, err := handler.GetClient().GetTasks("", smartListID)
+		tasks, err := handler.ClientFor(ctx).GetTasks("", smartListID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get smart list tasks: %v", err)
 		}
@@ -1142,6 +1386,85 @@ func setupRTMResources(s *server.MCPServer, handler *rtm.Handler) {
 			},
 		}, nil
 	})
+
+	// Template: Single task details
+	s.AddResourceTemplate(mcp.NewResourceTemplate(rtm.TaskURITemplate,
+		"Task Details",
+		mcp.WithTemplateDescription("One task's full details (notes, tags, estimate, recurrence), including completed tasks. Use series_id and id from any task listing."),
+		mcp.WithTemplateMIMEType("application/json"),
+	), func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		if handler.ClientFor(ctx).GetAuthToken() == "" {
+			return nil, fmt.Errorf("RTM authentication required")
+		}
+
+		seriesID, taskID, err := rtm.ParseTaskURI(request.Params.URI)
+		if err != nil {
+			return nil, err
+		}
+
+		task, err := handler.ClientFor(ctx).GetTask(seriesID, taskID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get task: %v", err)
+		}
+
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"title": task.Name,
+			"task":  task,
+		}, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      request.Params.URI,
+				MIMEType: "application/json",
+				Text:     string(data),
+			},
+		}, nil
+	})
+
+	// Template: Tasks changed since a time
+	s.AddResourceTemplate(mcp.NewResourceTemplate(rtm.ChangesURITemplate,
+		"Task Changes",
+		mcp.WithTemplateDescription("Tasks added, changed, completed or deleted since a time, e.g. rtm://changes?since=1h. since takes an RFC 3339 time (URL-encoded), Unix seconds or a duration. Poll the returned next URI to see only what changed after this read."),
+		mcp.WithTemplateMIMEType("application/json"),
+	), func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		if handler.ClientFor(ctx).GetAuthToken() == "" {
+			return nil, fmt.Errorf("RTM authentication required")
+		}
+
+		since, err := rtm.ParseChangesURI(request.Params.URI, time.Now())
+		if err != nil {
+			return nil, err
+		}
+
+		changes, err := handler.ClientFor(ctx).GetChanges(since)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get changes: %v", err)
+		}
+
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"title":     "Changes since " + since.Format(time.RFC3339),
+			"since":     changes.Since,
+			"synced_at": changes.SyncedAt,
+			"next":      rtm.ChangesURI(changes.SyncedAt),
+			"tasks":     changes.Tasks,
+			"deleted":   changes.Deleted,
+			"count":     len(changes.Tasks) + len(changes.Deleted),
+		}, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      request.Params.URI,
+				MIMEType: "application/json",
+				Text:     string(data),
+			},
+		}, nil
+	})
 }
 
 func extractListNameFromURI(uri string) string {
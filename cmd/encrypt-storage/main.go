@@ -0,0 +1,85 @@
+// Command encrypt-storage encrypts existing plaintext values in the server's
+// SQLite databases, or re-encrypts values sealed with a retired key.
+//
+// It uses the same STORAGE_ENCRYPTION_* variables as the servers:
+//
+//	STORAGE_ENCRYPTION_KEY=new-key STORAGE_ENCRYPTION_OLD_KEYS=old-key \
+//	  encrypt-storage -store tokens -db /data/tokens.db
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/vcto/mcp-adapters/internal/atrest"
+	"github.com/vcto/mcp-adapters/internal/auth"
+	"github.com/vcto/mcp-adapters/internal/debug"
+	"github.com/vcto/mcp-adapters/internal/kv"
+)
+
+// targets maps -store names to the encrypted columns of each database
+var targets = map[string]atrest.Target{
+	"debug":  debug.EncryptionTarget,
+	"tokens": auth.TokenEncryptionTarget,
+	"kv":     kv.EncryptionTarget,
+}
+
+func main() {
+	store := flag.String("store", "", "Database kind: "+strings.Join(targetNames(), ", "))
+	dbPath := flag.String("db", "", "Path to the SQLite database")
+	dryRun := flag.Bool("dry-run", false, "Report what would change without writing")
+	flag.Parse()
+
+	target, ok := targets[*store]
+	if !ok || *dbPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if _, err := os.Stat(*dbPath); err != nil {
+		log.Fatalf("Database not found: %v", err)
+	}
+
+	keyring, err := atrest.KeyringFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid encryption key configuration: %v", err)
+	}
+	if keyring == nil {
+		log.Fatal("STORAGE_ENCRYPTION_KEY or STORAGE_ENCRYPTION_KEY_FILE is required")
+	}
+
+	db, err := sql.Open("sqlite3", *dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Printf("Failed to close database: %v", err)
+		}
+	}()
+
+	result, err := atrest.Migrate(db, target, keyring, *dryRun)
+	if err != nil {
+		log.Fatalf("Migration failed: %v", err)
+	}
+
+	verb := "Encrypted"
+	if *dryRun {
+		verb = "Would encrypt"
+	}
+	fmt.Printf("%s %d values in %d rows of %s\n", verb, result.Values, result.Rows, target.Table)
+}
+
+func targetNames() []string {
+	names := make([]string, 0, len(targets))
+	for name := range targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -16,6 +16,7 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/vcto/mcp-adapters/internal/canary"
 	"github.com/vcto/mcp-adapters/internal/debug"
 )
 
@@ -31,6 +32,10 @@ type ProxyConfig struct {
 	TargetArgs   []string
 	TargetPort   int
 	DebugConfig  *debug.DebugConfig
+	// Canary mirrors traffic to this URL when set
+	Canary canary.Config
+	// CanaryReport is where the canary report is written on shutdown
+	CanaryReport string
 }
 
 func main() {
@@ -73,8 +78,18 @@ func main() {
 		log.Fatalf("Target server did not start within timeout")
 	}
 
+	// Mirror traffic to a canary when one is configured
+	var mirror *canary.Mirror
+	if config.Canary.Target != "" {
+		mirror, err = canary.New(config.Canary)
+		if err != nil {
+			log.Fatalf("Canary: %v", err)
+		}
+		log.Printf("Canary: mirroring to %s (writes mirrored: %v)", config.Canary.Target, config.Canary.MirrorWrites)
+	}
+
 	// Create proxy server with runtime debug config
-	proxy := createProxy(config, storage, debugConfig)
+	proxy := createProxy(config, storage, debugConfig, mirror)
 
 	server := &http.Server{
 		Addr:    fmt.Sprintf(":%d", config.Port),
@@ -102,6 +117,20 @@ func main() {
 		log.Printf("Server shutdown error: %v", err)
 	}
 
+	if mirror != nil {
+		mirror.Close()
+		report := mirror.Report()
+		log.Printf("Canary: verdict %s: %d mirrored, %d matched, %d status and %d body mismatches, %d errors",
+			report.Verdict, report.Mirrored, report.Matched, report.StatusMismatches, report.BodyMismatches, report.Errors)
+		if config.CanaryReport != "" {
+			if err := mirror.WriteReport(config.CanaryReport); err != nil {
+				log.Printf("Canary: failed to write report: %v", err)
+			} else {
+				log.Printf("Canary: report written to %s", config.CanaryReport)
+			}
+		}
+	}
+
 	log.Println("Proxy server stopped")
 }
 
@@ -111,6 +140,10 @@ func parseFlags() *ProxyConfig {
 		port         = flag.Int("port", getEnvInt("MCP_PROXY_PORT", 8080), "Proxy server port")
 		targetBinary = flag.String("target", getEnvDefault("MCP_TARGET_BINARY", "./bin/cowpilot"), "Target MCP server binary")
 		targetPort   = flag.Int("target-port", getEnvInt("MCP_TARGET_PORT", 8081), "Target MCP server port")
+		canaryURL    = flag.String("canary", os.Getenv("MCP_CANARY_URL"), "Canary base URL to mirror traffic to")
+		canaryReport = flag.String("canary-report", os.Getenv("MCP_CANARY_REPORT"), "File the canary report is written to on shutdown")
+		canaryWrites = flag.Bool("canary-writes", os.Getenv("MCP_CANARY_MIRROR_WRITES") == "true", "Also mirror calls to tools not marked read-only")
+		maxMismatch  = flag.Float64("canary-max-mismatch", getEnvFloat("MCP_CANARY_MAX_MISMATCH", 0), "Share of mirrored requests allowed to differ before the verdict fails")
 		help         = flag.Bool("help", false, "Show help message")
 	)
 
@@ -133,6 +166,10 @@ ENVIRONMENT VARIABLES:
     MCP_PROXY_PORT=8080             Proxy server port
     MCP_TARGET_BINARY=./bin/cowpilot Target binary path
     MCP_TARGET_PORT=8081            Target server port
+    MCP_CANARY_URL=https://...      Mirror traffic to this canary
+    MCP_CANARY_REPORT=canary.json   Canary report file, written on shutdown
+    MCP_CANARY_MIRROR_WRITES=true   Also mirror tools not marked read-only
+    MCP_CANARY_MAX_MISMATCH=0.01    Mismatch rate allowed for a passing verdict
 
 EXAMPLES:
     # Basic usage
@@ -140,7 +177,10 @@ EXAMPLES:
 
     # With debug enabled
     MCP_DEBUG=true MCP_DEBUG_STORAGE=file %s
-`, appName, appName)
+
+    # Compare a canary deploy against live traffic
+    %s --canary https://canary.example.com --canary-report canary.json
+`, appName, appName, appName)
 	}
 
 	flag.Parse()
@@ -157,6 +197,12 @@ EXAMPLES:
 		TargetBinary: *targetBinary,
 		TargetArgs:   targetArgs,
 		TargetPort:   *targetPort,
+		Canary: canary.Config{
+			Target:          *canaryURL,
+			MirrorWrites:    *canaryWrites,
+			MaxMismatchRate: *maxMismatch,
+		},
+		CanaryReport: *canaryReport,
 	}
 }
 
@@ -219,8 +265,9 @@ func isServerReady(port int) bool {
 	return resp.StatusCode == http.StatusOK
 }
 
-// createProxy creates the HTTP proxy with debug middleware
-func createProxy(config *ProxyConfig, storage debug.Storage, debugConfig *debug.DebugConfig) http.Handler {
+// createProxy creates the HTTP proxy with debug middleware, mirroring to
+// the canary when mirror is set
+func createProxy(config *ProxyConfig, storage debug.Storage, debugConfig *debug.DebugConfig, mirror *canary.Mirror) http.Handler {
 	// Create target URL
 	targetURL := &url.URL{
 		Scheme: "http",
@@ -248,6 +295,9 @@ func createProxy(config *ProxyConfig, storage debug.Storage, debugConfig *debug.
 	// Wrap with debug middleware
 	debugMiddleware := debug.DebugMiddleware(storage, debugConfig)
 	handler := debugMiddleware(proxy)
+	if mirror != nil {
+		handler = mirror.Middleware(handler)
+	}
 
 	// Add health check endpoint for the proxy itself
 	mux := http.NewServeMux()
@@ -259,6 +309,10 @@ func createProxy(config *ProxyConfig, storage debug.Storage, debugConfig *debug.
 		}
 	})
 
+	if mirror != nil {
+		mux.HandleFunc("/debug/canary", mirror.ServeReport)
+	}
+
 	// Add debug stats endpoint
 	if storage != nil {
 		mux.HandleFunc("/debug/stats", func(w http.ResponseWriter, r *http.Request) {
@@ -316,6 +370,15 @@ func getEnvDefault(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
 func getEnvInt(key string, defaultValue int) int {
 	if value := os.Getenv(key); value != "" {
 		if intValue, err := strconv.Atoi(value); err == nil {
@@ -0,0 +1,63 @@
+// Command oauth-conformance checks a deployed MCP server's OAuth endpoints
+// against RFC 9728, RFC 8414, RFC 7591, RFC 7636 and RFC 8707 and writes a
+// text, JSON or JUnit report. It exits 1 when any check fails.
+//
+//	oauth-conformance -url https://rtm.fly.dev -format junit -out oauth.xml
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/vcto/mcp-adapters/internal/conformance"
+)
+
+func main() {
+	target := flag.String("url", "", "Server base URL, e.g. https://rtm.fly.dev")
+	mcpPath := flag.String("mcp-path", "/mcp", "Path of the MCP endpoint")
+	format := flag.String("format", conformance.FormatText, "Report format: text, json or junit")
+	out := flag.String("out", "", "Write the report to this file instead of stdout")
+	timeout := flag.Duration("timeout", 2*time.Minute, "Time limit for the whole run")
+	flag.Parse()
+
+	if *target == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	suite := conformance.NewSuite(*target)
+	suite.MCPPath = *mcpPath
+	report := suite.Run(ctx)
+	cancel()
+
+	if err := writeReport(report, *format, *out); err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+
+	if report.Failed() {
+		_, failed, _ := report.Counts()
+		fmt.Fprintf(os.Stderr, "%d OAuth conformance check(s) failed\n", failed)
+		os.Exit(1)
+	}
+}
+
+// writeReport writes to path, or to stdout when path is empty
+func writeReport(report *conformance.Report, format, path string) error {
+	if path == "" {
+		return report.Write(os.Stdout, format)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := report.Write(file, format); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
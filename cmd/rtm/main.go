@@ -15,15 +15,30 @@ import (
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/vcto/mcp-adapters/internal/admin"
+	"github.com/vcto/mcp-adapters/internal/audit"
+	"github.com/vcto/mcp-adapters/internal/auth"
+	"github.com/vcto/mcp-adapters/internal/changelog"
 	"github.com/vcto/mcp-adapters/internal/core"
+	"github.com/vcto/mcp-adapters/internal/deadline"
 	"github.com/vcto/mcp-adapters/internal/debug"
+	"github.com/vcto/mcp-adapters/internal/exclusive"
+	"github.com/vcto/mcp-adapters/internal/health"
+	"github.com/vcto/mcp-adapters/internal/kv"
+	"github.com/vcto/mcp-adapters/internal/lazy"
+	"github.com/vcto/mcp-adapters/internal/lint"
 	"github.com/vcto/mcp-adapters/internal/longrunning"
+	"github.com/vcto/mcp-adapters/internal/manifest"
+	"github.com/vcto/mcp-adapters/internal/residency"
 	"github.com/vcto/mcp-adapters/internal/rtm"
+	"github.com/vcto/mcp-adapters/internal/security"
+	"github.com/vcto/mcp-adapters/internal/tooldocs"
+	"github.com/vcto/mcp-adapters/internal/webhooks"
 )
 
 const (
 	serverName    = "rtm-server"
-	serverVersion = "1.0.0"
+	serverVersion = "1.1.0"
 )
 
 var (
@@ -45,14 +60,51 @@ func main() {
 		}
 	}()
 
-	// Create MCP server
-	s := server.NewMCPServer(
-		serverName,
-		serverVersion,
+	// Record which stores and regions hold each subject's data
+	store := kv.OpenFromEnv()
+	ledger := residency.NewLedger(store, residency.Region())
+	residency.SetDefault(ledger)
+
+	// Publish permission descriptors and tool groups from the adapter manifests
+	manifests := []*manifest.Manifest{rtm.Manifest()}
+	hooks := &server.Hooks{}
+	manifest.AttachPermissions(hooks, manifests...)
+	manifest.AttachGroups(hooks, manifests...)
+	manifest.AttachRetryPolicies(hooks, manifests...)
+	// Connected sessions are listed in admin diagnostics snapshots
+	sessions := admin.NewSessionTracker()
+	sessions.Attach(hooks)
+
+	// Adapters initialize on first use of their tools unless MCP_LAZY_INIT=false
+	inits := lazy.NewRegistry()
+	// Tools declaring an exclusive scope never overlap for the same user
+	exclusions := exclusive.NewRegistry()
+
+	// Latency of recent calls, reported in docs://tools/{name}
+	toolStats := tooldocs.NewStats()
+
+	serverOptions := []server.ServerOption{
 		server.WithToolCapabilities(true),
 		server.WithResourceCapabilities(true, true),
-		server.WithPromptCapabilities(false),
-	)
+		server.WithPromptCapabilities(true),
+		server.WithHooks(hooks),
+		server.WithToolHandlerMiddleware(deadline.ToolMiddleware()),
+		server.WithToolHandlerMiddleware(toolStats.Middleware()),
+		server.WithToolHandlerMiddleware(inits.Middleware()),
+		server.WithToolHandlerMiddleware(manifest.RetryMiddleware(manifests...)),
+		server.WithToolHandlerMiddleware(manifest.ScopeMiddleware(manifests...)),
+		server.WithToolHandlerMiddleware(exclusions.Middleware()),
+	}
+	if manifest.GatewayEnabled() {
+		// Hide grouped tools behind list_groups/call_grouped
+		serverOptions = append(serverOptions, server.WithToolFilter(manifest.GatewayToolFilter(manifests...)))
+	}
+
+	// Create MCP server
+	s := server.NewMCPServer(serverName, serverVersion, serverOptions...)
+	if manifest.GatewayEnabled() {
+		manifest.SetupGateway(s, manifests...)
+	}
 
 	// Create task manager for long-running operations
 	taskManager := longrunning.NewManager(s)
@@ -80,21 +132,89 @@ func main() {
 
 	// Setup enhanced atomic tools
 	enhancedHandler := rtm.NewEnhancedHandler(rtmHandler)
+	enhancedHandler.SetStore(store)
+	enhancedHandler.SetTaskManager(taskManager)
 	enhancedHandler.SetupAtomicTools(s)
-	log.Printf("RTM: Registered %d enhanced tools", 11)
+	log.Printf("RTM: Registered %d enhanced tools", 14)
 
 	// Setup batch tools with progress support
 	rtmHandler.SetupBatchTools(s, taskManager)
 	log.Printf("RTM: Registered 5 batch tools with progress support")
 
+	// Setup RTM prompts
+	rtmHandler.SetupPrompts(s)
+
+	// Setup adapter health reporting
+	rtmInit := lazy.New("rtm", rtmHandler.Warmup)
+	health.SetupStatusTool(s, rtmInit.Report(rtmHandler))
+
+	// Inbound webhooks that call tools, from WEBHOOKS_CONFIG
+	webhookRegistry, err := webhooks.LoadFromEnv(s)
+	if err != nil {
+		log.Fatalf("Webhooks: %v", err)
+	}
+	if webhookRegistry != nil {
+		webhooks.SetupAuditTool(s, webhookRegistry)
+		log.Printf("Webhooks: Serving %s", strings.Join(webhookRegistry.Names(), ", "))
+	}
+	if health.OutageSimulationEnabled() {
+		health.SetupOutageTool(s, rtmHandler)
+	}
+
+	// Operator control plane over the same health, job and token state
+	tokens, err := auth.NewTokenRegistry(store)
+	if err != nil {
+		log.Fatalf("Admin: %v", err)
+	}
+	// Dependencies are checked against OSV as SECURITY_SCAN configures
+	scanner := security.ScannerFromEnv()
+	scanner.Start()
+	adminService := admin.NewService(admin.Config{
+		Reporters:  []health.Reporter{rtmInit.Report(rtmHandler)},
+		Jobs:       enhancedHandler.Jobs(),
+		Tokens:     tokens,
+		Security:   scanner,
+		AuthEvents: audit.Default(),
+		Sessions:   sessions,
+		Tasks:      taskManager,
+		Residency:  ledger,
+	})
+	if webhookRegistry != nil {
+		adminService.AddReloader("webhooks", webhookRegistry.Reload)
+	}
+
+	inits.Add(rtmInit, rtm.Manifest().AdapterTools()...)
+	exclusions.Add(rtmHandler.LockSubject, rtm.Manifest().ExclusiveScopes())
+	if !lazy.Enabled() {
+		if err := inits.InitAll(context.Background(), lazy.InitTimeoutFromEnv()); err != nil {
+			log.Printf("Startup init: %v (will retry on first use)", err)
+		}
+	}
+
 	log.Printf("RTM: Total tools should be: %d", 24)
 
+	// Report tool and flag changes since the previous release
+	changelog.SetupResource(s, serverName, serverVersion, manifests...)
+
+	// Per-tool schemas, examples and latency for client self-discovery
+	tooldocs.Setup(s, toolStats, rtm.ToolDocs(), manifests...)
+
 	// Setup RTM resources
 	setupRTMResources(s, rtmHandler)
 
+	// Tell connected clients when rtm:// resources change in RTM
+	watcher := rtm.NewResourceWatcher(rtmHandler, s, rtm.ResourcePollIntervalFromEnv())
+	watcher.Attach(hooks)
+	go watcher.Run(context.Background())
+
+	// Refuse to start with tools, prompts or resources connector clients would reject
+	if err := lint.EnforceFromEnv(context.Background(), s); err != nil {
+		log.Fatalf("Registry lint: %v", err)
+	}
+
 	// Run server
 	if os.Getenv("FLY_APP_NAME") != "" {
-		runHTTPServer(s, debugStorage, debugConfig, *disableAuth, rtmHandler)
+		runHTTPServer(s, debugStorage, debugConfig, *disableAuth, rtmHandler, webhookRegistry, tokens, adminService, scanner)
 	} else {
 		if debugConfig.Enabled {
 			log.Printf("Debug mode enabled for stdio server")
@@ -105,7 +225,7 @@ func main() {
 	}
 }
 
-func runHTTPServer(mcpServer *server.MCPServer, debugStorage debug.Storage, debugConfig *debug.DebugConfig, authDisabled bool, rtmHandler *rtm.Handler) {
+func runHTTPServer(mcpServer *server.MCPServer, debugStorage debug.Storage, debugConfig *debug.DebugConfig, authDisabled bool, rtmHandler *rtm.Handler, webhookRegistry *webhooks.Registry, tokens *auth.TokenRegistry, adminService *admin.Service, scanner *security.Scanner) {
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8081" // Different port from everything server
@@ -127,11 +247,14 @@ func runHTTPServer(mcpServer *server.MCPServer, debugStorage debug.Storage, debu
 		ServerURL:      serverURL,
 		Port:           port,
 		AuthDisabled:   authDisabled,
-		RTMHandler:     rtmHandler,
 		DebugStorage:   debugStorage,
 		DebugConfig:    debugConfig,
 		ServerName:     serverName,
 		AllowedOrigins: allowedOrigins,
+		Webhooks:       webhookRegistry,
+		Tokens:         tokens,
+		Admin:          adminService,
+		Security:       scanner,
 	}
 
 	// Setup infrastructure using shared core
@@ -152,22 +275,27 @@ func setupRTMResources(s *server.MCPServer, handler *rtm.Handler) {
 		mcp.WithResourceDescription("Tasks due today, sorted by priority"),
 		mcp.WithMIMEType("application/json"),
 	), func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
-		if handler.GetClient().AuthToken == "" {
+		if handler.ClientFor(ctx).GetAuthToken() == "" {
 			return nil, fmt.Errorf("RTM authentication required")
 		}
 
 		// Get today's tasks
-		tasks, err := handler.GetClient().GetTasks("due:today", "")
+		tasks, stale, err := handler.TodayTasks(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get today's tasks: %v", err)
 		}
 
-		data, err := json.MarshalIndent(map[string]interface{}{
+		payload := map[string]interface{}{
 			"title": "Today's Tasks",
 			"date":  time.Now().Format("2006-01-02"),
 			"tasks": tasks,
 			"count": len(tasks),
-		}, "", "  ")
+		}
+		if stale != nil {
+			payload["stale"] = stale
+		}
+
+		data, err := json.MarshalIndent(payload, "", "  ")
 		if err != nil {
 			return nil, err
 		}
@@ -187,11 +315,11 @@ func setupRTMResources(s *server.MCPServer, handler *rtm.Handler) {
 		mcp.WithResourceDescription("Tasks in the default inbox"),
 		mcp.WithMIMEType("application/json"),
 	), func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
-		if handler.GetClient().AuthToken == "" {
+		if handler.ClientFor(ctx).GetAuthToken() == "" {
 			return nil, fmt.Errorf("RTM authentication required")
 		}
 
-		tasks, err := handler.GetClient().GetTasks("list:Inbox", "")
+		tasks, err := handler.ClientFor(ctx).GetTasks("list:Inbox", "")
 		if err != nil {
 			return nil, fmt.Errorf("failed to get inbox tasks: %v", err)
 		}
@@ -220,11 +348,11 @@ func setupRTMResources(s *server.MCPServer, handler *rtm.Handler) {
 		mcp.WithResourceDescription("Tasks past their due date"),
 		mcp.WithMIMEType("application/json"),
 	), func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
-		if handler.GetClient().AuthToken == "" {
+		if handler.ClientFor(ctx).GetAuthToken() == "" {
 			return nil, fmt.Errorf("RTM authentication required")
 		}
 
-		tasks, err := handler.GetClient().GetTasks("dueBefore:today", "")
+		tasks, err := handler.ClientFor(ctx).GetTasks("dueBefore:today", "")
 		if err != nil {
 			return nil, fmt.Errorf("failed to get overdue tasks: %v", err)
 		}
@@ -253,11 +381,11 @@ func setupRTMResources(s *server.MCPServer, handler *rtm.Handler) {
 		mcp.WithResourceDescription("Tasks due in the next 7 days"),
 		mcp.WithMIMEType("application/json"),
 	), func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
-		if handler.GetClient().AuthToken == "" {
+		if handler.ClientFor(ctx).GetAuthToken() == "" {
 			return nil, fmt.Errorf("RTM authentication required")
 		}
 
-		tasks, err := handler.GetClient().GetTasks("due:within 1 week", "")
+		tasks, err := handler.ClientFor(ctx).GetTasks("due:within 1 week", "")
 		if err != nil {
 			return nil, fmt.Errorf("failed to get week's tasks: %v", err)
 		}
@@ -280,26 +408,64 @@ func setupRTMResources(s *server.MCPServer, handler *rtm.Handler) {
 		}, nil
 	})
 
+	// Eisenhower priority matrix
+	s.AddResource(mcp.NewResource(rtm.MatrixURI,
+		"Priority Matrix",
+		mcp.WithResourceDescription("Incomplete tasks sorted into urgent/important quadrants: urgent when overdue or due by tomorrow, important at priority 1 or 2"),
+		mcp.WithMIMEType("application/json"),
+	), func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		if handler.ClientFor(ctx).GetAuthToken() == "" {
+			return nil, fmt.Errorf("RTM authentication required")
+		}
+
+		tasks, err := handler.ClientFor(ctx).GetTasks(rtm.MatrixFilter, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tasks: %v", err)
+		}
+
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"title":     "Priority Matrix",
+			"quadrants": rtm.PriorityMatrix(tasks, time.Now()),
+			"count":     len(tasks),
+		}, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      rtm.MatrixURI,
+				MIMEType: "application/json",
+				Text:     string(data),
+			},
+		}, nil
+	})
+
 	// All lists
 	s.AddResource(mcp.NewResource("rtm://lists",
 		"All Lists",
 		mcp.WithResourceDescription("All lists with task counts"),
 		mcp.WithMIMEType("application/json"),
 	), func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
-		if handler.GetClient().AuthToken == "" {
+		if handler.ClientFor(ctx).GetAuthToken() == "" {
 			return nil, fmt.Errorf("RTM authentication required")
 		}
 
-		lists, err := handler.GetClient().GetLists()
+		lists, stale, err := handler.Lists(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get lists: %v", err)
 		}
 
-		data, err := json.MarshalIndent(map[string]interface{}{
+		payload := map[string]interface{}{
 			"title": "All Lists",
 			"lists": lists,
 			"count": len(lists),
-		}, "", "  ")
+		}
+		if stale != nil {
+			payload["stale"] = stale
+		}
+
+		data, err := json.MarshalIndent(payload, "", "  ")
 		if err != nil {
 			return nil, err
 		}
@@ -313,11 +479,67 @@ func setupRTMResources(s *server.MCPServer, handler *rtm.Handler) {
 		}, nil
 	})
 
+	// Mutations queued while RTM was unreachable
+	s.AddResource(mcp.NewResource("rtm://intents/pending",
+		"Pending Changes",
+		mcp.WithResourceDescription("Changes queued during RTM outages that have not synced yet, plus recent conflicts"),
+		mcp.WithMIMEType("application/json"),
+	), func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		if handler.ClientFor(ctx).GetAuthToken() == "" {
+			return nil, fmt.Errorf("RTM authentication required")
+		}
+
+		pending, conflicts, enabled := handler.PendingIntents(ctx)
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"title":     "Pending Changes",
+			"enabled":   enabled,
+			"pending":   pending,
+			"conflicts": conflicts,
+			"count":     len(pending),
+		}, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      "rtm://intents/pending",
+				MIMEType: "application/json",
+				Text:     string(data),
+			},
+		}, nil
+	})
+
+	// Upcoming tasks as an iCalendar feed
+	calendarDays := rtm.CalendarDaysFromEnv()
+	s.AddResource(mcp.NewResource(rtm.CalendarURI,
+		"Task Calendar",
+		mcp.WithResourceDescription(fmt.Sprintf("Incomplete tasks due in the next %d days as an iCalendar (.ics) feed", calendarDays)),
+		mcp.WithMIMEType(rtm.CalendarMIMEType),
+	), func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		if handler.ClientFor(ctx).GetAuthToken() == "" {
+			return nil, fmt.Errorf("RTM authentication required")
+		}
+
+		tasks, err := handler.ClientFor(ctx).GetCalendarTasks(calendarDays)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get upcoming tasks: %v", err)
+		}
+
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      rtm.CalendarURI,
+				MIMEType: rtm.CalendarMIMEType,
+				Text:     rtm.RenderICS(tasks, time.Now()),
+			},
+		}, nil
+	})
+
 	// Template: Tasks in specific list
 	s.AddResourceTemplate(mcp.NewResourceTemplate("rtm://lists/{list_name}",
 		"List Tasks",
 	), func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
-		if handler.GetClient().AuthToken == "" {
+		if handler.ClientFor(ctx).GetAuthToken() == "" {
 			return nil, fmt.Errorf("RTM authentication required")
 		}
 
@@ -328,7 +550,7 @@ func setupRTMResources(s *server.MCPServer, handler *rtm.Handler) {
 		}
 
 		// Search for tasks in this list
-		tasks, err := handler.GetClient().GetTasks("list:"+listName, "")
+		tasks, err := handler.ClientFor(ctx).GetTasks("list:"+listName, "")
 		if err != nil {
 			return nil, fmt.Errorf("failed to get list tasks: %v", err)
 		}
@@ -356,7 +578,7 @@ func setupRTMResources(s *server.MCPServer, handler *rtm.Handler) {
 	s.AddResourceTemplate(mcp.NewResourceTemplate("rtm://smart/{list_name}",
 		"Smart List",
 	), func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
-		if handler.GetClient().AuthToken == "" {
+		if handler.ClientFor(ctx).GetAuthToken() == "" {
 			return nil, fmt.Errorf("RTM authentication required")
 		}
 
@@ -367,7 +589,7 @@ func setupRTMResources(s *server.MCPServer, handler *rtm.Handler) {
 		}
 
 		// Get all lists to find the smart list
-		lists, err := handler.GetClient().GetLists()
+		lists, err := handler.ClientFor(ctx).GetLists()
 		if err != nil {
 			return nil, fmt.Errorf("failed to get lists: %v", err)
 		}
@@ -385,7 +607,7 @@ func setupRTMResources(s *server.MCPServer, handler *rtm.Handler) {
 		}
 
 		// Get tasks from smart list
-		tasks, err := handler.GetClient().GetTasks("", smartListID)
+		tasks, err := handler.ClientFor(ctx).GetTasks("", smartListID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get smart list tasks: %v", err)
 		}
@@ -409,6 +631,85 @@ func setupRTMResources(s *server.MCPServer, handler *rtm.Handler) {
 			},
 		}, nil
 	})
+
+	// Template: Single task details
+	s.AddResourceTemplate(mcp.NewResourceTemplate(rtm.TaskURITemplate,
+		"Task Details",
+		mcp.WithTemplateDescription("One task's full details (notes, tags, estimate, recurrence), including completed tasks. Use series_id and id from any task listing."),
+		mcp.WithTemplateMIMEType("application/json"),
+	), func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		if handler.ClientFor(ctx).GetAuthToken() == "" {
+			return nil, fmt.Errorf("RTM authentication required")
+		}
+
+		seriesID, taskID, err := rtm.ParseTaskURI(request.Params.URI)
+		if err != nil {
+			return nil, err
+		}
+
+		task, err := handler.ClientFor(ctx).GetTask(seriesID, taskID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get task: %v", err)
+		}
+
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"title": task.Name,
+			"task":  task,
+		}, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      request.Params.URI,
+				MIMEType: "application/json",
+				Text:     string(data),
+			},
+		}, nil
+	})
+
+	// Template: Tasks changed since a time
+	s.AddResourceTemplate(mcp.NewResourceTemplate(rtm.ChangesURITemplate,
+		"Task Changes",
+		mcp.WithTemplateDescription("Tasks added, changed, completed or deleted since a time, e.g. rtm://changes?since=1h. since takes an RFC 3339 time (URL-encoded), Unix seconds or a duration. Poll the returned next URI to see only what changed after this read."),
+		mcp.WithTemplateMIMEType("application/json"),
+	), func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		if handler.ClientFor(ctx).GetAuthToken() == "" {
+			return nil, fmt.Errorf("RTM authentication required")
+		}
+
+		since, err := rtm.ParseChangesURI(request.Params.URI, time.Now())
+		if err != nil {
+			return nil, err
+		}
+
+		changes, err := handler.ClientFor(ctx).GetChanges(since)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get changes: %v", err)
+		}
+
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"title":     "Changes since " + since.Format(time.RFC3339),
+			"since":     changes.Since,
+			"synced_at": changes.SyncedAt,
+			"next":      rtm.ChangesURI(changes.SyncedAt),
+			"tasks":     changes.Tasks,
+			"deleted":   changes.Deleted,
+			"count":     len(changes.Tasks) + len(changes.Deleted),
+		}, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      request.Params.URI,
+				MIMEType: "application/json",
+				Text:     string(data),
+			},
+		}, nil
+	})
 }
 
 func extractListNameFromURI(uri string) string {
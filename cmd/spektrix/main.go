@@ -15,14 +15,26 @@ import (
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/vcto/mcp-adapters/internal/admin"
+	"github.com/vcto/mcp-adapters/internal/auth"
+	"github.com/vcto/mcp-adapters/internal/changelog"
+	"github.com/vcto/mcp-adapters/internal/deadline"
 	"github.com/vcto/mcp-adapters/internal/debug"
+	"github.com/vcto/mcp-adapters/internal/health"
+	"github.com/vcto/mcp-adapters/internal/kv"
+	"github.com/vcto/mcp-adapters/internal/lazy"
+	"github.com/vcto/mcp-adapters/internal/lint"
+	"github.com/vcto/mcp-adapters/internal/manifest"
 	"github.com/vcto/mcp-adapters/internal/middleware"
+	"github.com/vcto/mcp-adapters/internal/residency"
+	"github.com/vcto/mcp-adapters/internal/security"
 	"github.com/vcto/mcp-adapters/internal/spektrix"
+	"github.com/vcto/mcp-adapters/internal/tooldocs"
 )
 
 const (
 	serverName    = "spektrix-server"
-	serverVersion = "1.0.0"
+	serverVersion = "1.1.0"
 )
 
 var (
@@ -44,14 +56,43 @@ func main() {
 		}
 	}()
 
-	// Create MCP server
-	s := server.NewMCPServer(
-		serverName,
-		serverVersion,
+	// Record which stores and regions hold each subject's data
+	ledger := residency.NewLedger(kv.OpenFromEnv(), residency.Region())
+	residency.SetDefault(ledger)
+
+	// Publish permission descriptors and tool groups from the adapter manifests
+	manifests := []*manifest.Manifest{spektrix.Manifest()}
+	hooks := &server.Hooks{}
+	manifest.AttachPermissions(hooks, manifests...)
+	manifest.AttachGroups(hooks, manifests...)
+	manifest.AttachRetryPolicies(hooks, manifests...)
+
+	// Adapters initialize on first use of their tools unless MCP_LAZY_INIT=false
+	inits := lazy.NewRegistry()
+
+	// Latency of recent calls, reported in docs://tools/{name}
+	toolStats := tooldocs.NewStats()
+
+	serverOptions := []server.ServerOption{
 		server.WithToolCapabilities(false),
 		server.WithResourceCapabilities(true, true),
 		server.WithPromptCapabilities(false),
-	)
+		server.WithHooks(hooks),
+		server.WithToolHandlerMiddleware(deadline.ToolMiddleware()),
+		server.WithToolHandlerMiddleware(toolStats.Middleware()),
+		server.WithToolHandlerMiddleware(inits.Middleware()),
+		server.WithToolHandlerMiddleware(manifest.RetryMiddleware(manifests...)),
+	}
+	if manifest.GatewayEnabled() {
+		// Hide grouped tools behind list_groups/call_grouped
+		serverOptions = append(serverOptions, server.WithToolFilter(manifest.GatewayToolFilter(manifests...)))
+	}
+
+	// Create MCP server
+	s := server.NewMCPServer(serverName, serverVersion, serverOptions...)
+	if manifest.GatewayEnabled() {
+		manifest.SetupGateway(s, manifests...)
+	}
 
 	// Check Spektrix credentials
 	spektrixHandler := spektrix.NewHandler()
@@ -63,13 +104,50 @@ func main() {
 
 	// Setup Spektrix tools
 	spektrixHandler.SetupTools(s)
+	spektrixHandler.AttachSessions(hooks)
+	spektrixInit := lazy.New("spektrix", spektrixHandler.Warmup)
+	health.SetupStatusTool(s, spektrixInit.Report(spektrixHandler))
+	if health.OutageSimulationEnabled() {
+		health.SetupOutageTool(s, spektrixHandler)
+	}
+
+	inits.Add(spektrixInit, spektrix.Manifest().AdapterTools()...)
+	if !lazy.Enabled() {
+		if err := inits.InitAll(context.Background(), lazy.InitTimeoutFromEnv()); err != nil {
+			log.Printf("Startup init: %v (will retry on first use)", err)
+		}
+	}
+
+	// Report tool and flag changes since the previous release
+	changelog.SetupResource(s, serverName, serverVersion, manifests...)
+
+	// Per-tool schemas, examples and latency for client self-discovery
+	tooldocs.Setup(s, toolStats, spektrix.ToolDocs(), manifests...)
 
 	// Setup Spektrix resources
 	setupSpektrixResources(s, spektrixHandler)
 
+	// Tell connected clients when customers and orders change in Spektrix
+	watcher := spektrix.NewResourceWatcher(spektrixHandler, s, spektrix.ResourcePollIntervalFromEnv())
+	watcher.Attach(hooks)
+	go watcher.Run(context.Background())
+
+	// Refuse to start with tools, prompts or resources connector clients would reject
+	if err := lint.EnforceFromEnv(context.Background(), s); err != nil {
+		log.Fatalf("Registry lint: %v", err)
+	}
+
 	// Run server
 	if os.Getenv("FLY_APP_NAME") != "" {
-		runHTTPServer(s, debugStorage, debugConfig, *disableAuth, spektrixHandler)
+		// Dependencies are checked against OSV as SECURITY_SCAN configures
+		scanner := security.ScannerFromEnv()
+		scanner.Start()
+		adminService := admin.NewService(admin.Config{
+			Reporters: []health.Reporter{spektrixInit.Report(spektrixHandler)},
+			Security:  scanner,
+			Residency: ledger,
+		})
+		runHTTPServer(s, debugStorage, debugConfig, *disableAuth, spektrixHandler, adminService, scanner)
 	} else {
 		if debugConfig.Enabled {
 			log.Printf("Debug mode enabled for stdio server")
@@ -82,29 +160,37 @@ func main() {
 
 func setupSpektrixResources(s *server.MCPServer, handler *spektrix.Handler) {
 	// Customer search results
-	s.AddResource(mcp.NewResource("spektrix://customers/search",
+	s.AddResource(mcp.NewResource(spektrix.CustomerSearchURI,
 		"Customer Search Results",
-		mcp.WithResourceDescription("Last customer search results with details"),
+		mcp.WithResourceDescription("This conversation's last spektrix_search_customers results, with the query and when it ran"),
 		mcp.WithMIMEType("application/json"),
 	), func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
 		if !handler.IsAuthenticated() {
 			return nil, fmt.Errorf("spektrix authentication required")
 		}
 
-		// This would contain the last search results
-		// For now, return placeholder structure
-		data, err := json.MarshalIndent(map[string]interface{}{
-			"title":       "Customer Search Results",
-			"last_search": "Available via spektrix_search_customers tool",
-			"note":        "Use the search tool to populate this resource",
-		}, "", "  ")
+		payload := map[string]interface{}{
+			"title": "Customer Search Results",
+		}
+		if search, ok := handler.LastSearch(ctx); ok {
+			payload["query"] = search.Query
+			payload["searched_at"] = search.SearchedAt
+			payload["customers"] = search.Customers
+			payload["count"] = len(search.Customers)
+		} else {
+			payload["customers"] = []spektrix.Customer{}
+			payload["count"] = 0
+			payload["note"] = "No search yet. Use spektrix_search_customers to populate this resource."
+		}
+
+		data, err := json.MarshalIndent(payload, "", "  ")
 		if err != nil {
 			return nil, err
 		}
 
 		return []mcp.ResourceContents{
 			mcp.TextResourceContents{
-				URI:      "spektrix://customers/search",
+				URI:      spektrix.CustomerSearchURI,
 				MIMEType: "application/json",
 				Text:     string(data),
 			},
@@ -121,16 +207,21 @@ func setupSpektrixResources(s *server.MCPServer, handler *spektrix.Handler) {
 			return nil, fmt.Errorf("spektrix authentication required")
 		}
 
-		tags, err := handler.GetClient().GetTags()
+		tags, stale, err := handler.Tags()
 		if err != nil {
 			return nil, fmt.Errorf("failed to get tags: %v", err)
 		}
 
-		data, err := json.MarshalIndent(map[string]interface{}{
+		payload := map[string]interface{}{
 			"title": "Available Tags",
 			"tags":  tags,
 			"count": len(tags),
-		}, "", "  ")
+		}
+		if stale != nil {
+			payload["stale"] = stale
+		}
+
+		data, err := json.MarshalIndent(payload, "", "  ")
 		if err != nil {
 			return nil, err
 		}
@@ -153,7 +244,7 @@ func setupSpektrixResources(s *server.MCPServer, handler *spektrix.Handler) {
 		}
 
 		// Extract customer ID from URI
-		customerID := extractCustomerIDFromURI(request.Params.URI)
+		customerID := extractIDFromURI(request.Params.URI)
 		if customerID == "" {
 			return nil, fmt.Errorf("invalid customer URI format")
 		}
@@ -180,9 +271,90 @@ func setupSpektrixResources(s *server.MCPServer, handler *spektrix.Handler) {
 			},
 		}, nil
 	})
+
+	// Template: A customer's recent orders with their tickets
+	s.AddResourceTemplate(mcp.NewResourceTemplate(spektrix.CustomerOrdersURITemplate,
+		"Customer Orders",
+		mcp.WithTemplateDescription("A customer's most recent orders, newest first, with tickets by event and performance; spektrix_customer_orders pages through the rest"),
+		mcp.WithTemplateMIMEType("application/json"),
+	), func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		if !handler.IsAuthenticated() {
+			return nil, fmt.Errorf("spektrix authentication required")
+		}
+
+		customerID := extractIDFromURI(strings.TrimSuffix(request.Params.URI, "/orders"))
+		if customerID == "" {
+			return nil, fmt.Errorf("invalid customer orders URI format")
+		}
+
+		orders, page, err := handler.RecentOrders(customerID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get orders: %v", err)
+		}
+
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"title":       fmt.Sprintf("Orders: %s", customerID),
+			"customer_id": customerID,
+			"orders":      orders,
+			"total":       page.Total,
+			"has_more":    page.HasMore,
+		}, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      request.Params.URI,
+				MIMEType: "application/json",
+				Text:     string(data),
+			},
+		}, nil
+	})
+
+	// Template: Event details, upcoming instances and availability by ID
+	s.AddResourceTemplate(mcp.NewResourceTemplate(spektrix.EventURITemplate,
+		"Event Details",
+		mcp.WithTemplateDescription("An event with its next 10 instances and their seat availability"),
+		mcp.WithTemplateMIMEType("application/json"),
+	), func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		if !handler.IsAuthenticated() {
+			return nil, fmt.Errorf("spektrix authentication required")
+		}
+
+		eventID := extractIDFromURI(request.Params.URI)
+		if eventID == "" {
+			return nil, fmt.Errorf("invalid event URI format")
+		}
+
+		details, err := handler.EventDetails(eventID, time.Now())
+		if err != nil {
+			return nil, fmt.Errorf("failed to get event: %v", err)
+		}
+
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"title":     fmt.Sprintf("Event: %s", details.Event.Name),
+			"event_id":  eventID,
+			"event":     details.Event,
+			"instances": details.Instances,
+			"upcoming":  details.Upcoming,
+			"summary":   details.Availability,
+		}, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      request.Params.URI,
+				MIMEType: "application/json",
+				Text:     string(data),
+			},
+		}, nil
+	})
 }
 
-func runHTTPServer(mcpServer *server.MCPServer, debugStorage debug.Storage, debugConfig *debug.DebugConfig, authDisabled bool, spektrixHandler *spektrix.Handler) {
+func runHTTPServer(mcpServer *server.MCPServer, debugStorage debug.Storage, debugConfig *debug.DebugConfig, authDisabled bool, spektrixHandler *spektrix.Handler, adminService *admin.Service, scanner *security.Scanner) {
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8082" // Different port from RTM (8081) and everything (8080)
@@ -213,14 +385,26 @@ func runHTTPServer(mcpServer *server.MCPServer, debugStorage debug.Storage, debu
 		// API credentials are validated on each request
 		handler = spektrixAuthMiddleware(spektrixHandler)(handler)
 		log.Printf("Auth: Enabled Spektrix HMAC authentication")
+		if staticKeys := auth.StaticKeysFromEnv(); staticKeys != nil {
+			// The HMAC check above is about upstream credentials; static
+			// keys are what keep /mcp itself closed
+			handler = auth.StaticKeyMiddleware(staticKeys)(handler)
+			log.Printf("Auth: Enabled %d static API key(s)", staticKeys.Len())
+		}
 	} else {
 		log.Println("Auth: DISABLED via --disable-auth flag")
 	}
 
-	mux.HandleFunc("/health", handleHealth)
+	mux.HandleFunc("/health", security.HealthHandler(scanner, handleHealth))
 	mux.Handle("/mcp", handler)
 	mux.Handle("/mcp/", handler)
 
+	// Operator control plane, including the data residency report
+	if token := admin.TokenFromEnv(); token != "" {
+		mux.Handle(admin.PathPrefix, admin.NewHTTPHandler(adminService, token))
+		log.Printf("Admin: HTTP API at %s%s", serverURL, admin.PathPrefix)
+	}
+
 	corsConfig := middleware.DefaultCORSConfig()
 	if allowedOrigins := os.Getenv("CORS_ALLOWED_ORIGINS"); allowedOrigins != "" {
 		corsConfig.AllowOrigins = append(corsConfig.AllowOrigins, strings.Split(allowedOrigins, ",")...)
@@ -232,13 +416,27 @@ func runHTTPServer(mcpServer *server.MCPServer, debugStorage debug.Storage, debu
 		Handler: finalHandler,
 	}
 
+	// Mutual TLS authenticates clients at the transport, before any of the above
+	if mtlsConfig, ok := auth.MTLSConfigFromEnv(); ok {
+		if err := mtlsConfig.Apply(srv); err != nil {
+			log.Fatalf("Failed to enable mutual TLS: %v", err)
+		}
+		log.Printf("Auth: Enabled mutual TLS client authentication")
+	}
+
 	log.Printf("Starting Spektrix MCP server on port %s", port)
 	log.Printf("Endpoint: %s/mcp", serverURL)
 
 	// Start server
 	serverErr := make(chan error, 1)
 	go func() {
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if srv.TLSConfig != nil {
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			serverErr <- err
 		}
 	}()
@@ -288,7 +486,8 @@ func spektrixAuthMiddleware(spektrixHandler *spektrix.Handler) func(http.Handler
 	}
 }
 
-func extractCustomerIDFromURI(uri string) string {
+// extractIDFromURI returns the ID a resource URI ends with
+func extractIDFromURI(uri string) string {
 	// Extract from "spektrix://customers/12345" -> "12345"
 	parts := strings.Split(uri, "/")
 	if len(parts) < 3 {
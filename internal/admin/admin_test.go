@@ -0,0 +1,399 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/vcto/mcp-adapters/internal/admin/adminpb"
+	"github.com/vcto/mcp-adapters/internal/audit"
+	"github.com/vcto/mcp-adapters/internal/auth"
+	"github.com/vcto/mcp-adapters/internal/health"
+	"github.com/vcto/mcp-adapters/internal/kv"
+	"github.com/vcto/mcp-adapters/internal/residency"
+	"github.com/vcto/mcp-adapters/internal/rtm"
+	"github.com/vcto/mcp-adapters/internal/security"
+)
+
+const testToken = "admin-secret"
+
+type fakeReporter health.AdapterStatus
+
+func (f fakeReporter) AdapterStatus() health.AdapterStatus { return health.AdapterStatus(f) }
+
+type fakeJobs []rtm.BatchJob
+
+func (f fakeJobs) Jobs() []rtm.BatchJob { return f }
+
+func (f fakeJobs) Cancel(id string) (rtm.BatchJob, error) {
+	for _, job := range f {
+		if job.ID != id {
+			continue
+		}
+		if job.Finished() {
+			return job, rtm.ErrJobFinished
+		}
+		job.CancelRequested = true
+		return job, nil
+	}
+	return rtm.BatchJob{}, rtm.ErrJobNotFound
+}
+
+// fakeAuthEvents returns one event and remembers the last query
+type fakeAuthEvents struct {
+	query audit.Query
+}
+
+func (f *fakeAuthEvents) GetAuthEvents(query audit.Query) ([]audit.Record, error) {
+	f.query = query
+	return []audit.Record{{ID: 1, Event: "validation_failed", Outcome: "failure", IP: "203.0.113.7"}}, nil
+}
+
+// newTestService has one healthy adapter, two jobs, a seen token and a
+// reloader that counts its calls
+func newTestService(t *testing.T) (*Service, *int) {
+	t.Helper()
+	tokens, err := auth.NewTokenRegistry(kv.NewMemoryStore())
+	if err != nil {
+		t.Fatalf("NewTokenRegistry failed: %v", err)
+	}
+	tokens.Seen("rtm-token")
+
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	s := NewService(Config{
+		Reporters: []health.Reporter{fakeReporter{Name: "rtm", Authenticated: true, Circuit: health.BreakerState{State: health.StateClosed}}},
+		Jobs: fakeJobs{
+			{ID: "job-2", Type: "batch_move", Status: rtm.JobStatusPending, CreatedAt: created.Add(time.Minute)},
+			{ID: "job-1", Type: "batch_complete", Status: rtm.JobStatusCompleted, CreatedAt: created, TotalTasks: 3, Completed: 3},
+		},
+		Tokens: tokens,
+	})
+
+	reloads := 0
+	s.AddReloader("webhooks", func() error {
+		reloads++
+		return nil
+	})
+	return s, &reloads
+}
+
+func TestService(t *testing.T) {
+	t.Logf("Importance: HTTP and gRPC both delegate to the service, so its filtering and errors define the control plane's behavior.")
+	s, reloads := newTestService(t)
+	ctx := context.Background()
+
+	t.Run("health degrades on an open circuit", func(t *testing.T) {
+		t.Logf("  > Why it's important: Fleet managers route traffic away from servers reporting degraded.")
+		if report := s.Health(ctx); report.Status != StatusOK {
+			t.Errorf("Expected ok, got %s", report.Status)
+		}
+		degraded := NewService(Config{Reporters: []health.Reporter{fakeReporter{Name: "rtm", Authenticated: true, Circuit: health.BreakerState{State: health.StateOpen}}}})
+		if report := degraded.Health(ctx); report.Status != StatusDegraded {
+			t.Errorf("Expected degraded, got %s", report.Status)
+		}
+		cold := NewService(Config{Reporters: []health.Reporter{fakeReporter{Name: "rtm", Authenticated: true, Circuit: health.BreakerState{State: health.StateClosed}, Warmup: health.WarmupNotWarmed}}})
+		if report := cold.Health(ctx); report.Status != StatusDegraded {
+			t.Errorf("Expected degraded before warmup could run, got %s", report.Status)
+		}
+	})
+
+	t.Run("jobs filter by status", func(t *testing.T) {
+		jobs, err := s.ListJobs(ctx, "pending")
+		if err != nil || len(jobs) != 1 || jobs[0].ID != "job-2" {
+			t.Errorf("Expected job-2, got %v %v", jobs, err)
+		}
+		if _, err := s.ListJobs(ctx, "stuck"); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Expected ErrInvalid, got %v", err)
+		}
+		if _, err := s.GetJob(ctx, "job-9"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("reload targets", func(t *testing.T) {
+		reloaded, err := s.Reload(ctx, "")
+		if err != nil || len(reloaded) != 1 || *reloads != 1 {
+			t.Errorf("Expected webhooks reloaded once, got %v %v (%d calls)", reloaded, err, *reloads)
+		}
+		if _, err := s.Reload(ctx, "flags"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound for unknown target, got %v", err)
+		}
+	})
+
+	t.Run("unconfigured features", func(t *testing.T) {
+		t.Logf("  > Why it's important: Servers without a job queue or token tracking should say so rather than report nothing.")
+		empty := NewService(Config{})
+		if _, err := empty.ListJobs(ctx, ""); !errors.Is(err, ErrUnavailable) {
+			t.Errorf("Expected ErrUnavailable, got %v", err)
+		}
+		if _, err := empty.RevokeToken(ctx, "abc"); !errors.Is(err, ErrUnavailable) {
+			t.Errorf("Expected ErrUnavailable, got %v", err)
+		}
+		if _, err := empty.SecurityReport(ctx, false); !errors.Is(err, ErrUnavailable) {
+			t.Errorf("Expected ErrUnavailable, got %v", err)
+		}
+	})
+}
+
+func adminRequest(h http.Handler, method, path, token string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	var body map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &body)
+	return w, body
+}
+
+func TestHTTPHandler(t *testing.T) {
+	t.Logf("Importance: The admin API can revoke tokens and reload config, so it must refuse callers without the admin token.")
+	s, _ := newTestService(t)
+	h := NewHTTPHandler(s, testToken)
+
+	t.Run("requires the admin token", func(t *testing.T) {
+		if w, _ := adminRequest(h, "GET", "/admin/health", ""); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected 401 without token, got %d", w.Code)
+		}
+		if w, _ := adminRequest(h, "GET", "/admin/health", "wrong"); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected 401 with wrong token, got %d", w.Code)
+		}
+	})
+
+	t.Run("health and jobs", func(t *testing.T) {
+		if w, body := adminRequest(h, "GET", "/admin/health", testToken); w.Code != http.StatusOK || body["status"] != StatusOK {
+			t.Errorf("Expected ok health, got %d %v", w.Code, body)
+		}
+		if w, body := adminRequest(h, "GET", "/admin/jobs/job-1", testToken); w.Code != http.StatusOK || body["type"] != "batch_complete" {
+			t.Errorf("Expected job-1, got %d %v", w.Code, body)
+		}
+		if w, _ := adminRequest(h, "GET", "/admin/jobs/job-9", testToken); w.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 for unknown job, got %d", w.Code)
+		}
+		if w, _ := adminRequest(h, "GET", "/admin/jobs?status=stuck", testToken); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for unknown status, got %d", w.Code)
+		}
+	})
+
+	t.Run("cancel jobs", func(t *testing.T) {
+		t.Logf("  > Why it's important: Operators stop runaway batches; a finished job must report a conflict, not pretend to stop.")
+		if w, body := adminRequest(h, "POST", "/admin/jobs/job-2/cancel", testToken); w.Code != http.StatusOK || body["cancel_requested"] != true {
+			t.Errorf("Expected job-2 cancel requested, got %d %v", w.Code, body)
+		}
+		if w, _ := adminRequest(h, "POST", "/admin/jobs/job-1/cancel", testToken); w.Code != http.StatusConflict {
+			t.Errorf("Expected 409 for finished job, got %d", w.Code)
+		}
+		if w, _ := adminRequest(h, "POST", "/admin/jobs/job-9/cancel", testToken); w.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 for unknown job, got %d", w.Code)
+		}
+	})
+
+	t.Run("reload and revoke", func(t *testing.T) {
+		t.Logf("  > Why it's important: These are the operations operators reach for during an incident.")
+		if w, _ := adminRequest(h, "GET", "/admin/reload", testToken); w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("Expected 405 for GET reload, got %d", w.Code)
+		}
+		if w, body := adminRequest(h, "POST", "/admin/reload?target=webhooks", testToken); w.Code != http.StatusOK {
+			t.Errorf("Expected reload to succeed, got %d %v", w.Code, body)
+		}
+
+		subject := residency.SubjectID("rtm-token")
+		w, body := adminRequest(h, "DELETE", "/admin/tokens/"+subject, testToken)
+		if w.Code != http.StatusOK || body["known"] != true {
+			t.Errorf("Expected known subject revoked, got %d %v", w.Code, body)
+		}
+		_, body = adminRequest(h, "GET", "/admin/tokens", testToken)
+		tokens, _ := body["tokens"].([]interface{})
+		if len(tokens) != 1 || tokens[0].(map[string]interface{})["revoked"] != true {
+			t.Errorf("Expected revoked token listed, got %v", body)
+		}
+	})
+
+	t.Run("security report", func(t *testing.T) {
+		t.Logf("  > Why it's important: Operators need the SBOM even when OSV is down, flagged as unknown rather than clean.")
+		if w, _ := adminRequest(h, "GET", "/admin/security", testToken); w.Code != http.StatusNotImplemented {
+			t.Errorf("Expected 501 without a scanner, got %d", w.Code)
+		}
+
+		osv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer osv.Close()
+		scanned := NewHTTPHandler(NewService(Config{Security: security.NewScanner(osv.URL, time.Hour)}), testToken)
+		w, body := adminRequest(scanned, "GET", "/admin/security?refresh=true", testToken)
+		if w.Code != http.StatusOK || body["status"] != security.StatusUnknown || body["sbom"] == nil {
+			t.Errorf("Expected unknown status with SBOM, got %d %v", w.Code, body)
+		}
+	})
+
+	t.Run("auth events", func(t *testing.T) {
+		t.Logf("  > Why it's important: Operators investigating suspicious access filter the audit log by client, address and time.")
+		if w, _ := adminRequest(h, "GET", "/admin/auth-events", testToken); w.Code != http.StatusNotImplemented {
+			t.Errorf("Expected 501 without an auth event log, got %d", w.Code)
+		}
+
+		events := &fakeAuthEvents{}
+		audited := NewHTTPHandler(NewService(Config{AuthEvents: events}), testToken)
+		w, body := adminRequest(audited, "GET", "/admin/auth-events?ip=203.0.113.7&outcome=failure&since=1h&limit=20", testToken)
+		if list, _ := body["events"].([]interface{}); w.Code != http.StatusOK || len(list) != 1 {
+			t.Errorf("Expected one event, got %d %v", w.Code, body)
+		}
+		if events.query.IP != "203.0.113.7" || events.query.Outcome != "failure" || events.query.Limit != 20 ||
+			time.Since(events.query.Since) < 59*time.Minute || time.Since(events.query.Since) > 61*time.Minute {
+			t.Errorf("Expected the filters passed through, got %+v", events.query)
+		}
+		for _, path := range []string{"/admin/auth-events?since=yesterday", "/admin/auth-events?limit=0", "/admin/auth-events?limit=5000"} {
+			if w, _ := adminRequest(audited, "GET", path, testToken); w.Code != http.StatusBadRequest {
+				t.Errorf("Expected 400 for %s, got %d", path, w.Code)
+			}
+		}
+	})
+}
+
+func TestGRPCServer(t *testing.T) {
+	t.Logf("Importance: Fleet tooling drives the gRPC service; it must share the HTTP API's state and authentication.")
+	s, _ := newTestService(t)
+
+	listener := bufconn.Listen(1 << 20)
+	srv := NewGRPCServer(s, testToken, nil)
+	go func() { _ = srv.Serve(listener) }()
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer conn.Close()
+	client := adminpb.NewControlPlaneClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	authed := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+testToken)
+
+	t.Run("rejects calls without the admin token", func(t *testing.T) {
+		_, err := client.Health(ctx, &adminpb.HealthRequest{})
+		if status.Code(err) != codes.Unauthenticated {
+			t.Errorf("Expected Unauthenticated, got %v", err)
+		}
+	})
+
+	t.Run("serves the shared state", func(t *testing.T) {
+		health, err := client.Health(authed, &adminpb.HealthRequest{})
+		if err != nil || health.GetStatus() != StatusOK || len(health.GetAdapters()) != 1 {
+			t.Errorf("Expected ok health with one adapter, got %v %v", health, err)
+		}
+
+		jobs, err := client.ListJobs(authed, &adminpb.ListJobsRequest{})
+		if err != nil || len(jobs.GetJobs()) != 2 || jobs.GetJobs()[0].GetId() != "job-2" {
+			t.Fatalf("Expected two jobs newest first, got %v %v", jobs, err)
+		}
+		if jobs.GetJobs()[0].GetStartedAt() != nil {
+			t.Error("Expected unset start time for a pending job")
+		}
+
+		job, err := client.GetJob(authed, &adminpb.GetJobRequest{Id: "job-1"})
+		if err != nil || job.GetCompleted() != 3 || !job.GetCreatedAt().AsTime().Equal(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)) {
+			t.Errorf("Expected job-1 details, got %v %v", job, err)
+		}
+	})
+
+	t.Run("maps service errors to codes", func(t *testing.T) {
+		t.Logf("  > Why it's important: Callers branch on status codes, not error text.")
+		if _, err := client.GetJob(authed, &adminpb.GetJobRequest{Id: "job-9"}); status.Code(err) != codes.NotFound {
+			t.Errorf("Expected NotFound, got %v", err)
+		}
+		if _, err := client.RevokeToken(authed, &adminpb.RevokeTokenRequest{}); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Expected InvalidArgument, got %v", err)
+		}
+		if _, err := client.CancelJob(authed, &adminpb.CancelJobRequest{Id: "job-1"}); status.Code(err) != codes.FailedPrecondition {
+			t.Errorf("Expected FailedPrecondition for finished job, got %v", err)
+		}
+	})
+
+	t.Run("diagnostics snapshot", func(t *testing.T) {
+		snapshot, err := client.DiagnosticsSnapshot(authed, &adminpb.DiagnosticsSnapshotRequest{})
+		if err != nil {
+			t.Fatalf("DiagnosticsSnapshot failed: %v", err)
+		}
+		var artifact map[string]interface{}
+		if err := json.Unmarshal(snapshot.GetArtifact(), &artifact); err != nil || artifact["id"] != snapshot.GetId() || snapshot.GetGoroutines() == 0 {
+			t.Errorf("Expected the artifact for %s, got %v", snapshot.GetId(), err)
+		}
+	})
+
+	t.Run("revocations are shared with HTTP", func(t *testing.T) {
+		subject := residency.SubjectID("rtm-token")
+		if _, err := client.RevokeToken(authed, &adminpb.RevokeTokenRequest{Subject: subject}); err != nil {
+			t.Fatalf("RevokeToken failed: %v", err)
+		}
+		_, body := adminRequest(NewHTTPHandler(s, testToken), "GET", "/admin/tokens", testToken)
+		tokens, _ := body["tokens"].([]interface{})
+		if len(tokens) != 1 || tokens[0].(map[string]interface{})["revoked"] != true {
+			t.Errorf("Expected HTTP API to show the gRPC revocation, got %v", body)
+		}
+	})
+}
+
+func TestServeGRPC(t *testing.T) {
+	t.Logf("Importance: Without TLS the admin token crosses the network in cleartext, so an insecure control plane must stay on this host.")
+
+	t.Run("refuses cleartext off loopback", func(t *testing.T) {
+		for _, addr := range []string{":0", "0.0.0.0:0", "192.0.2.1:0"} {
+			if err := ServeGRPC(NewGRPCServer(&Service{}, testToken, nil), addr, false); err == nil {
+				t.Errorf("Expected %s refused without TLS", addr)
+			}
+		}
+	})
+
+	t.Run("serves cleartext on loopback", func(t *testing.T) {
+		for _, addr := range []string{"127.0.0.1:0", "localhost:0"} {
+			srv := NewGRPCServer(&Service{}, testToken, nil)
+			if err := ServeGRPC(srv, addr, false); err != nil {
+				t.Errorf("Expected %s served, got %v", addr, err)
+			}
+			srv.Stop()
+		}
+	})
+}
+
+func TestResidencyReport(t *testing.T) {
+	t.Logf("Importance: The residency report lists every subject's stores and regions, so it must only be served behind the admin token.")
+	ledger := residency.NewLedger(kv.NewMemoryStore(), "ams")
+	ledger.Record("subject-a", residency.StoreTokens)
+	ledger.Record("subject-b", residency.StoreDebug)
+	h := NewHTTPHandler(NewService(Config{Residency: ledger}), testToken)
+
+	if w, _ := adminRequest(h, "GET", "/admin/residency", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without the admin token, got %d", w.Code)
+	}
+
+	w, body := adminRequest(h, "GET", "/admin/residency?subject=subject-b", testToken)
+	subjects, _ := body["subjects"].([]interface{})
+	if w.Code != http.StatusOK || body["region"] != "ams" || len(subjects) != 1 {
+		t.Fatalf("Expected subject-b's report from ams, got %d %v", w.Code, body)
+	}
+	if subject := subjects[0].(map[string]interface{}); subject["subject"] != "subject-b" {
+		t.Errorf("Expected only subject-b, got %v", subject)
+	}
+
+	if w, _ := adminRequest(NewHTTPHandler(NewService(Config{}), testToken), "GET", "/admin/residency", testToken); w.Code != http.StatusNotImplemented {
+		t.Errorf("Expected 501 without a ledger, got %d", w.Code)
+	}
+}
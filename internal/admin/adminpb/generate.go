@@ -0,0 +1,6 @@
+// Package adminpb holds the generated gRPC ControlPlane service. Regenerate
+// with protoc, protoc-gen-go and protoc-gen-go-grpc on the PATH after
+// editing admin.proto.
+package adminpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative admin.proto
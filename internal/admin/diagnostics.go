@@ -0,0 +1,262 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/vcto/mcp-adapters/internal/config"
+	"github.com/vcto/mcp-adapters/internal/longrunning"
+	"github.com/vcto/mcp-adapters/internal/rtm"
+)
+
+// defaultSnapshotTTL is how long a diagnostics snapshot stays available
+const defaultSnapshotTTL = time.Hour
+
+// Tasks is implemented by longrunning.Manager
+type Tasks interface {
+	Tasks() []longrunning.TaskInfo
+}
+
+// DiagnosticsSnapshot is the state of the process at one moment, stored as
+// a JSON artifact to attach to bug reports. Session IDs are hashed so the
+// artifact cannot be used to join a session. The stacks show what every
+// goroutine was doing, so snapshots are only served behind the admin token.
+type DiagnosticsSnapshot struct {
+	ID         string    `json:"id"`
+	CapturedAt time.Time `json:"captured_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+
+	GoVersion  string        `json:"go_version"`
+	Goroutines int           `json:"goroutines"`
+	Heap       HeapStats     `json:"heap"`
+	Sessions   []SessionInfo `json:"sessions"`
+	Tasks      []TaskInfo    `json:"tasks"`
+	Queues     []QueueDepth  `json:"queues"`
+	// Stacks is every goroutine's stack, as in a panic
+	Stacks string `json:"goroutine_stacks"`
+}
+
+// HeapStats are the runtime memory statistics in a snapshot
+type HeapStats struct {
+	AllocBytes      uint64     `json:"alloc_bytes"`
+	TotalAllocBytes uint64     `json:"total_alloc_bytes"`
+	SysBytes        uint64     `json:"sys_bytes"`
+	HeapInuseBytes  uint64     `json:"heap_inuse_bytes"`
+	HeapObjects     uint64     `json:"heap_objects"`
+	NumGC           uint32     `json:"num_gc"`
+	PauseTotal      string     `json:"gc_pause_total"`
+	LastGC          *time.Time `json:"last_gc,omitempty"`
+}
+
+// SessionInfo is one connected MCP session
+type SessionInfo struct {
+	Session      string    `json:"session"`
+	RegisteredAt time.Time `json:"registered_at"`
+}
+
+// TaskInfo is one running long-running task
+type TaskInfo struct {
+	ID        string    `json:"id"`
+	Session   string    `json:"session"`
+	Progress  float64   `json:"progress"`
+	Total     float64   `json:"total,omitempty"`
+	Message   string    `json:"message,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	Cancelled bool      `json:"cancelled,omitempty"`
+}
+
+// QueueDepth counts the work waiting in, and taken from, one queue
+type QueueDepth struct {
+	Name       string `json:"name"`
+	Pending    int    `json:"pending"`
+	Processing int    `json:"processing"`
+}
+
+// SessionTracker records MCP sessions as they register and unregister
+type SessionTracker struct {
+	mu       sync.Mutex
+	sessions map[string]time.Time
+}
+
+// NewSessionTracker creates a tracker; Attach it to the server's hooks
+func NewSessionTracker() *SessionTracker {
+	return &SessionTracker{sessions: make(map[string]time.Time)}
+}
+
+// Attach registers the tracker's session hooks
+func (t *SessionTracker) Attach(hooks *server.Hooks) {
+	hooks.AddOnRegisterSession(func(ctx context.Context, session server.ClientSession) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.sessions[session.SessionID()] = time.Now()
+	})
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		delete(t.sessions, session.SessionID())
+	})
+}
+
+// Sessions returns the connected sessions, oldest first
+func (t *SessionTracker) Sessions() []SessionInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	sessions := make([]SessionInfo, 0, len(t.sessions))
+	for id, at := range t.sessions {
+		sessions = append(sessions, SessionInfo{Session: sessionRef(id), RegisteredAt: at})
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].RegisteredAt.Before(sessions[j].RegisteredAt) })
+	return sessions
+}
+
+// snapshotStore keeps snapshots until they expire
+type snapshotStore struct {
+	mu        sync.Mutex
+	snapshots map[string]storedSnapshot
+}
+
+type storedSnapshot struct {
+	data      []byte
+	expiresAt time.Time
+}
+
+// DiagnosticsSnapshot records goroutine stacks, heap statistics, sessions,
+// running tasks and queue depths. The snapshot can be fetched again by ID
+// until it expires.
+func (s *Service) DiagnosticsSnapshot(ctx context.Context) (DiagnosticsSnapshot, error) {
+	now := time.Now()
+	ttl := config.DurationFromEnv("DIAGNOSTICS_SNAPSHOT_TTL", defaultSnapshotTTL)
+	if ttl <= 0 {
+		return DiagnosticsSnapshot{}, fmt.Errorf("diagnostics snapshots: %w", ErrUnavailable)
+	}
+
+	snapshot := DiagnosticsSnapshot{
+		ID:         snapshotID(),
+		CapturedAt: now,
+		ExpiresAt:  now.Add(ttl),
+		GoVersion:  runtime.Version(),
+		Goroutines: runtime.NumGoroutine(),
+		Heap:       heapStats(),
+		Sessions:   []SessionInfo{},
+		Tasks:      []TaskInfo{},
+		Queues:     []QueueDepth{},
+	}
+	if s.config.Sessions != nil {
+		snapshot.Sessions = s.config.Sessions.Sessions()
+	}
+	if s.config.Tasks != nil {
+		for _, task := range s.config.Tasks.Tasks() {
+			snapshot.Tasks = append(snapshot.Tasks, TaskInfo{
+				ID:        task.ID,
+				Session:   sessionRef(task.SessionID),
+				Progress:  task.Progress,
+				Total:     task.Total,
+				Message:   task.Message,
+				StartedAt: task.StartedAt,
+				Cancelled: task.Cancelled,
+			})
+		}
+	}
+	if s.config.Jobs != nil {
+		snapshot.Queues = append(snapshot.Queues, jobQueueDepth(s.config.Jobs.Jobs()))
+	}
+
+	var stacks bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&stacks, 2); err != nil {
+		return DiagnosticsSnapshot{}, fmt.Errorf("capturing goroutine stacks: %w", err)
+	}
+	snapshot.Stacks = stacks.String()
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return DiagnosticsSnapshot{}, fmt.Errorf("encoding snapshot: %w", err)
+	}
+	s.storeSnapshot(snapshot, data, ttl)
+	return snapshot, nil
+}
+
+// DiagnosticsArtifact returns a stored snapshot as JSON
+func (s *Service) DiagnosticsArtifact(ctx context.Context, id string) ([]byte, error) {
+	s.snapshots.mu.Lock()
+	defer s.snapshots.mu.Unlock()
+	stored, ok := s.snapshots.snapshots[id]
+	if !ok || time.Now().After(stored.expiresAt) {
+		return nil, fmt.Errorf("%w: diagnostics snapshot %q", ErrNotFound, id)
+	}
+	return stored.data, nil
+}
+
+// storeSnapshot keeps the snapshot for ttl
+func (s *Service) storeSnapshot(snapshot DiagnosticsSnapshot, data []byte, ttl time.Duration) {
+	s.snapshots.mu.Lock()
+	if s.snapshots.snapshots == nil {
+		s.snapshots.snapshots = make(map[string]storedSnapshot)
+	}
+	s.snapshots.snapshots[snapshot.ID] = storedSnapshot{data: data, expiresAt: snapshot.ExpiresAt}
+	s.snapshots.mu.Unlock()
+
+	time.AfterFunc(ttl, func() {
+		s.snapshots.mu.Lock()
+		delete(s.snapshots.snapshots, snapshot.ID)
+		s.snapshots.mu.Unlock()
+	})
+}
+
+func heapStats() HeapStats {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	stats := HeapStats{
+		AllocBytes:      m.Alloc,
+		TotalAllocBytes: m.TotalAlloc,
+		SysBytes:        m.Sys,
+		HeapInuseBytes:  m.HeapInuse,
+		HeapObjects:     m.HeapObjects,
+		NumGC:           m.NumGC,
+		PauseTotal:      time.Duration(m.PauseTotalNs).String(),
+	}
+	if m.LastGC != 0 {
+		lastGC := time.Unix(0, int64(m.LastGC))
+		stats.LastGC = &lastGC
+	}
+	return stats
+}
+
+func jobQueueDepth(jobs []rtm.BatchJob) QueueDepth {
+	depth := QueueDepth{Name: "rtm_batch_jobs"}
+	for _, job := range jobs {
+		switch job.Status {
+		case rtm.JobStatusPending:
+			depth.Pending++
+		case rtm.JobStatusProcessing:
+			depth.Processing++
+		}
+	}
+	return depth
+}
+
+// sessionRef identifies a session in a snapshot without revealing its ID
+func sessionRef(id string) string {
+	if id == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:6])
+}
+
+func snapshotID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,119 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/vcto/mcp-adapters/internal/longrunning"
+	"github.com/vcto/mcp-adapters/internal/rtm"
+)
+
+type fakeSession string
+
+func (f fakeSession) Initialize()                                         {}
+func (f fakeSession) Initialized() bool                                   { return true }
+func (f fakeSession) NotificationChannel() chan<- mcp.JSONRPCNotification { return nil }
+func (f fakeSession) SessionID() string                                   { return string(f) }
+
+type fakeTasks []longrunning.TaskInfo
+
+func (f fakeTasks) Tasks() []longrunning.TaskInfo { return f }
+
+func TestDiagnosticsSnapshot(t *testing.T) {
+	t.Logf("Importance: A hung or leaking server is diagnosed from one artifact; it must hold everything needed without leaking session IDs.")
+
+	hooks := &server.Hooks{}
+	sessions := NewSessionTracker()
+	sessions.Attach(hooks)
+	hooks.RegisterSession(context.Background(), fakeSession("session-secret"))
+	hooks.RegisterSession(context.Background(), fakeSession("session-gone"))
+	hooks.UnregisterSession(context.Background(), fakeSession("session-gone"))
+
+	s := NewService(Config{
+		Jobs: fakeJobs{
+			{ID: "job-1", Status: rtm.JobStatusPending},
+			{ID: "job-2", Status: rtm.JobStatusProcessing},
+			{ID: "job-3", Status: rtm.JobStatusPending},
+			{ID: "job-4", Status: rtm.JobStatusCompleted},
+		},
+		Sessions: sessions,
+		Tasks:    fakeTasks{{ID: "progress-1", SessionID: "session-secret", Progress: 2, Total: 5}},
+	})
+
+	snapshot, err := s.DiagnosticsSnapshot(context.Background())
+	if err != nil {
+		t.Fatalf("DiagnosticsSnapshot failed: %v", err)
+	}
+	if snapshot.Goroutines == 0 || !strings.Contains(snapshot.Stacks, "goroutine ") || snapshot.Heap.SysBytes == 0 {
+		t.Errorf("Expected goroutines, stacks and heap stats, got %d goroutines, %d bytes of stacks", snapshot.Goroutines, len(snapshot.Stacks))
+	}
+	if len(snapshot.Queues) != 1 || snapshot.Queues[0].Pending != 2 || snapshot.Queues[0].Processing != 1 {
+		t.Errorf("Expected 2 pending and 1 processing job, got %+v", snapshot.Queues)
+	}
+	if len(snapshot.Sessions) != 1 || len(snapshot.Tasks) != 1 || snapshot.Tasks[0].Session != snapshot.Sessions[0].Session {
+		t.Errorf("Expected one session owning one task, got %+v and %+v", snapshot.Sessions, snapshot.Tasks)
+	}
+
+	artifact, err := s.DiagnosticsArtifact(context.Background(), snapshot.ID)
+	if err != nil {
+		t.Fatalf("DiagnosticsArtifact failed: %v", err)
+	}
+	if strings.Contains(string(artifact), "session-secret") {
+		t.Error("Expected session IDs to be hashed in the artifact")
+	}
+
+	t.Run("expires", func(t *testing.T) {
+		t.Logf("  > Why it's important: Stacks and session details must not stay exposed after the bug report is filed.")
+		t.Setenv("DIAGNOSTICS_SNAPSHOT_TTL", "20ms")
+		short, err := s.DiagnosticsSnapshot(context.Background())
+		if err != nil {
+			t.Fatalf("DiagnosticsSnapshot failed: %v", err)
+		}
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			if _, err := s.DiagnosticsArtifact(context.Background(), short.ID); err != nil {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("Expected the snapshot to be removed")
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	})
+
+	t.Run("over HTTP", func(t *testing.T) {
+		h := NewHTTPHandler(s, testToken)
+		w, body := adminRequest(h, "POST", "/admin/diagnostics", testToken)
+		if w.Code != http.StatusCreated || body["id"] == nil {
+			t.Fatalf("Expected a new snapshot, got %d %v", w.Code, body)
+		}
+		w, fetched := adminRequest(h, "GET", "/admin/diagnostics/"+body["id"].(string), testToken)
+		if w.Code != http.StatusOK || fetched["id"] != body["id"] || fetched["goroutine_stacks"] == "" {
+			t.Errorf("Expected the stored artifact, got %d", w.Code)
+		}
+		if w, _ := adminRequest(h, "GET", "/admin/diagnostics/unknown", testToken); w.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 for an unknown snapshot, got %d", w.Code)
+		}
+	})
+}
+
+func TestDiagnosticsArtifactJSON(t *testing.T) {
+	t.Logf("Importance: Bug report tooling reads the artifact as JSON, so it must round-trip.")
+	s := NewService(Config{})
+	snapshot, err := s.DiagnosticsSnapshot(context.Background())
+	if err != nil {
+		t.Fatalf("DiagnosticsSnapshot failed: %v", err)
+	}
+	artifact, _ := s.DiagnosticsArtifact(context.Background(), snapshot.ID)
+	var decoded DiagnosticsSnapshot
+	if err := json.Unmarshal(artifact, &decoded); err != nil || decoded.ID != snapshot.ID {
+		t.Errorf("Expected the artifact to decode, got %v", err)
+	}
+}
@@ -0,0 +1,277 @@
+package admin
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/vcto/mcp-adapters/internal/admin/adminpb"
+	"github.com/vcto/mcp-adapters/internal/auth"
+	"github.com/vcto/mcp-adapters/internal/rtm"
+)
+
+// GRPCAddrFromEnv returns the listen address for the gRPC control plane,
+// such as ":9091". The gRPC server is not started when it is unset.
+func GRPCAddrFromEnv() string {
+	return os.Getenv("ADMIN_GRPC_ADDR")
+}
+
+// GRPCTLSConfig is the transport security for the gRPC control plane: the
+// server certificate and client CAs of mtls, with a client certificate
+// demanded in every handshake
+func GRPCTLSConfig(mtls auth.MTLSConfig) (*tls.Config, error) {
+	tlsConfig, err := mtls.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	return tlsConfig, nil
+}
+
+// NewGRPCServer serves the service as the gRPC ControlPlane, over TLS when
+// tlsConfig is set. Calls must carry "authorization: Bearer <token>"
+// metadata.
+func NewGRPCServer(s *Service, token string, tlsConfig *tls.Config) *grpc.Server {
+	options := []grpc.ServerOption{grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if values := md.Get("authorization"); len(values) == 0 || !authorized(values[0], token) {
+			return nil, status.Error(codes.Unauthenticated, "admin token required")
+		}
+		return handler(ctx, req)
+	})}
+	if tlsConfig != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	srv := grpc.NewServer(options...)
+	adminpb.RegisterControlPlaneServer(srv, &grpcService{service: s})
+	return srv
+}
+
+// ServeGRPC listens on addr and serves srv in the background. Without TLS
+// the admin token would cross the network in cleartext, so an insecure
+// server may only listen on a loopback address.
+func ServeGRPC(srv *grpc.Server, addr string, secure bool) error {
+	if !secure && !isLoopback(addr) {
+		return fmt.Errorf("refusing to serve the control plane in cleartext on %s; configure TLS (MTLS_CLIENT_CA_FILE, TLS_CERT_FILE, TLS_KEY_FILE) or listen on a loopback address", addr)
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listening on %s: %w", addr, err)
+	}
+	go func() {
+		if err := srv.Serve(listener); err != nil {
+			log.Printf("Admin: gRPC server stopped: %v", err)
+		}
+	}()
+	return nil
+}
+
+// isLoopback reports whether addr only accepts connections from this host
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// grpcService adapts Service to the generated ControlPlaneServer
+type grpcService struct {
+	adminpb.UnimplementedControlPlaneServer
+	service *Service
+}
+
+func (g *grpcService) Health(ctx context.Context, _ *adminpb.HealthRequest) (*adminpb.HealthResponse, error) {
+	report := g.service.Health(ctx)
+	response := &adminpb.HealthResponse{Status: report.Status}
+	for _, a := range report.Adapters {
+		response.Adapters = append(response.Adapters, &adminpb.AdapterHealth{
+			Name:                a.Name,
+			Authenticated:       a.Authenticated,
+			AuthDetail:          a.AuthDetail,
+			CircuitState:        a.Circuit.State,
+			ConsecutiveFailures: int32(a.Circuit.ConsecutiveFailures),
+			LastError:           a.Circuit.LastError,
+			LastErrorAt:         timestamp(a.Circuit.LastErrorAt),
+		})
+	}
+	return response, nil
+}
+
+func (g *grpcService) Reload(ctx context.Context, req *adminpb.ReloadRequest) (*adminpb.ReloadResponse, error) {
+	reloaded, err := g.service.Reload(ctx, req.GetTarget())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &adminpb.ReloadResponse{Reloaded: reloaded}, nil
+}
+
+func (g *grpcService) ListJobs(ctx context.Context, req *adminpb.ListJobsRequest) (*adminpb.ListJobsResponse, error) {
+	jobs, err := g.service.ListJobs(ctx, req.GetStatus())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	response := &adminpb.ListJobsResponse{}
+	for _, job := range jobs {
+		response.Jobs = append(response.Jobs, jobMessage(job))
+	}
+	return response, nil
+}
+
+func (g *grpcService) GetJob(ctx context.Context, req *adminpb.GetJobRequest) (*adminpb.Job, error) {
+	job, err := g.service.GetJob(ctx, req.GetId())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return jobMessage(job), nil
+}
+
+func (g *grpcService) CancelJob(ctx context.Context, req *adminpb.CancelJobRequest) (*adminpb.Job, error) {
+	job, err := g.service.CancelJob(ctx, req.GetId())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return jobMessage(job), nil
+}
+
+func (g *grpcService) ListTokens(ctx context.Context, _ *adminpb.ListTokensRequest) (*adminpb.ListTokensResponse, error) {
+	tokens, err := g.service.ListTokens(ctx)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	response := &adminpb.ListTokensResponse{}
+	for _, token := range tokens {
+		response.Tokens = append(response.Tokens, tokenMessage(token))
+	}
+	return response, nil
+}
+
+func (g *grpcService) RevokeToken(ctx context.Context, req *adminpb.RevokeTokenRequest) (*adminpb.RevokeTokenResponse, error) {
+	known, err := g.service.RevokeToken(ctx, req.GetSubject())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &adminpb.RevokeTokenResponse{Known: known}, nil
+}
+
+func (g *grpcService) SecurityReport(ctx context.Context, req *adminpb.SecurityReportRequest) (*adminpb.SecurityReportResponse, error) {
+	report, err := g.service.SecurityReport(ctx, req.GetRefresh())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	response := &adminpb.SecurityReportResponse{
+		Status:      report.Status,
+		ScannedAt:   timestamp(&report.ScannedAt),
+		MainModule:  report.SBOM.MainModule,
+		GoVersion:   report.SBOM.GoVersion,
+		VcsRevision: report.SBOM.Revision,
+		Error:       report.Error,
+	}
+	for _, c := range report.SBOM.Components {
+		response.Components = append(response.Components, &adminpb.Component{
+			Path:       c.Path,
+			Version:    c.Version,
+			Sum:        c.Sum,
+			ReplacedBy: c.ReplacedBy,
+		})
+	}
+	for _, v := range report.Vulnerabilities {
+		response.Vulnerabilities = append(response.Vulnerabilities, &adminpb.Vulnerability{
+			Id:      v.ID,
+			Aliases: v.Aliases,
+			Summary: v.Summary,
+			Module:  v.Module,
+			Version: v.Version,
+			FixedIn: v.FixedIn,
+		})
+	}
+	return response, nil
+}
+
+func (g *grpcService) DiagnosticsSnapshot(ctx context.Context, _ *adminpb.DiagnosticsSnapshotRequest) (*adminpb.DiagnosticsSnapshotResponse, error) {
+	snapshot, err := g.service.DiagnosticsSnapshot(ctx)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	artifact, err := g.service.DiagnosticsArtifact(ctx, snapshot.ID)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &adminpb.DiagnosticsSnapshotResponse{
+		Id:             snapshot.ID,
+		CapturedAt:     timestamp(&snapshot.CapturedAt),
+		ExpiresAt:      timestamp(&snapshot.ExpiresAt),
+		Goroutines:     int32(snapshot.Goroutines),
+		HeapAllocBytes: snapshot.Heap.AllocBytes,
+		Sessions:       int32(len(snapshot.Sessions)),
+		Tasks:          int32(len(snapshot.Tasks)),
+		Artifact:       artifact,
+	}, nil
+}
+
+// grpcError maps service errors to gRPC status codes
+func grpcError(err error) error {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, ErrInvalid):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, ErrConflict):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, ErrUnavailable):
+		return status.Error(codes.Unimplemented, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func jobMessage(job rtm.BatchJob) *adminpb.Job {
+	return &adminpb.Job{
+		Id:              job.ID,
+		Type:            job.Type,
+		Status:          string(job.Status),
+		CreatedAt:       timestamp(&job.CreatedAt),
+		StartedAt:       timestamp(job.StartedAt),
+		CompletedAt:     timestamp(job.CompletedAt),
+		TotalTasks:      int32(job.TotalTasks),
+		Completed:       int32(job.Completed),
+		Failed:          job.Failed,
+		Error:           job.Error,
+		Owner:           job.Owner,
+		CancelRequested: job.CancelRequested,
+	}
+}
+
+func tokenMessage(token auth.TokenInfo) *adminpb.Token {
+	return &adminpb.Token{
+		Subject:   token.Subject,
+		FirstSeen: timestamp(&token.FirstSeen),
+		LastSeen:  timestamp(&token.LastSeen),
+		Requests:  token.Requests,
+		Revoked:   token.Revoked,
+	}
+}
+
+// timestamp converts an optional time, leaving nil and zero times unset
+func timestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
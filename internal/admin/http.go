@@ -0,0 +1,212 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/vcto/mcp-adapters/internal/audit"
+)
+
+// PathPrefix is where the HTTP admin API is served
+const PathPrefix = "/admin/"
+
+// NewHTTPHandler serves the service as JSON under PathPrefix. Requests must
+// carry "Authorization: Bearer <token>".
+//
+//	GET    /admin/health
+//	POST   /admin/reload[?target=name]
+//	GET    /admin/jobs[?status=pending]
+//	GET    /admin/jobs/{id}
+//	POST   /admin/jobs/{id}/cancel
+//	GET    /admin/tokens
+//	DELETE /admin/tokens/{subject}
+//	GET    /admin/security[?refresh=true]
+//	POST   /admin/diagnostics
+//	GET    /admin/diagnostics/{id}
+//	GET    /admin/auth-events[?event=&outcome=&client_id=&ip=&since=&limit=]
+//	GET    /admin/residency[?subject=]
+func NewHTTPHandler(s *Service, token string) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /admin/health", func(w http.ResponseWriter, r *http.Request) {
+		report := s.Health(r.Context())
+		status := http.StatusOK
+		if report.Status != StatusOK {
+			status = http.StatusServiceUnavailable
+		}
+		writeJSON(w, status, report)
+	})
+
+	mux.HandleFunc("POST /admin/reload", func(w http.ResponseWriter, r *http.Request) {
+		reloaded, err := s.Reload(r.Context(), r.URL.Query().Get("target"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"reloaded": reloaded})
+	})
+
+	mux.HandleFunc("GET /admin/jobs", func(w http.ResponseWriter, r *http.Request) {
+		jobs, err := s.ListJobs(r.Context(), r.URL.Query().Get("status"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"jobs": jobs})
+	})
+
+	mux.HandleFunc("GET /admin/jobs/{id}", func(w http.ResponseWriter, r *http.Request) {
+		job, err := s.GetJob(r.Context(), r.PathValue("id"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, job)
+	})
+
+	mux.HandleFunc("POST /admin/jobs/{id}/cancel", func(w http.ResponseWriter, r *http.Request) {
+		job, err := s.CancelJob(r.Context(), r.PathValue("id"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, job)
+	})
+
+	mux.HandleFunc("GET /admin/tokens", func(w http.ResponseWriter, r *http.Request) {
+		tokens, err := s.ListTokens(r.Context())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"tokens": tokens})
+	})
+
+	mux.HandleFunc("DELETE /admin/tokens/{subject}", func(w http.ResponseWriter, r *http.Request) {
+		known, err := s.RevokeToken(r.Context(), r.PathValue("subject"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"revoked": true, "known": known})
+	})
+
+	mux.HandleFunc("GET /admin/security", func(w http.ResponseWriter, r *http.Request) {
+		report, err := s.SecurityReport(r.Context(), r.URL.Query().Get("refresh") == "true")
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, report)
+	})
+
+	mux.HandleFunc("POST /admin/diagnostics", func(w http.ResponseWriter, r *http.Request) {
+		snapshot, err := s.DiagnosticsSnapshot(r.Context())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, snapshot)
+	})
+
+	mux.HandleFunc("GET /admin/diagnostics/{id}", func(w http.ResponseWriter, r *http.Request) {
+		data, err := s.DiagnosticsArtifact(r.Context(), r.PathValue("id"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", `attachment; filename="diagnostics-`+r.PathValue("id")+`.json"`)
+		_, _ = w.Write(data)
+	})
+
+	mux.HandleFunc("GET /admin/auth-events", func(w http.ResponseWriter, r *http.Request) {
+		query, err := authEventQuery(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		events, err := s.AuthEvents(r.Context(), query)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"events": events})
+	})
+
+	mux.HandleFunc("GET /admin/residency", func(w http.ResponseWriter, r *http.Request) {
+		report, err := s.Residency(r.Context(), r.URL.Query().Get("subject"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, report)
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(r.Header.Get("Authorization"), token) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "admin token required"})
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// writeError maps service errors to HTTP statuses
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrInvalid):
+		status = http.StatusBadRequest
+	case errors.Is(err, ErrConflict):
+		status = http.StatusConflict
+	case errors.Is(err, ErrUnavailable):
+		status = http.StatusNotImplemented
+	}
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// authEventQuery reads an auth event query from r's parameters. since is
+// an RFC 3339 time or a duration back from now, such as 24h.
+func authEventQuery(r *http.Request) (audit.Query, error) {
+	params := r.URL.Query()
+	query := audit.Query{
+		Event:    params.Get("event"),
+		Outcome:  params.Get("outcome"),
+		ClientID: params.Get("client_id"),
+		IP:       params.Get("ip"),
+	}
+	if since := params.Get("since"); since != "" {
+		if t, err := time.Parse(time.RFC3339, since); err == nil {
+			query.Since = t
+		} else if d, err := time.ParseDuration(since); err == nil && d > 0 {
+			query.Since = time.Now().Add(-d)
+		} else {
+			return query, fmt.Errorf("%w: since must be an RFC 3339 time or a duration", ErrInvalid)
+		}
+	}
+	if limit := params.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			return query, fmt.Errorf("%w: limit must be a positive number", ErrInvalid)
+		}
+		query.Limit = n
+	}
+	return query, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Admin: failed to write response: %v", err)
+	}
+}
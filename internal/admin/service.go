@@ -0,0 +1,295 @@
+// Package admin is the operator control plane for a running server: health,
+// configuration reload, batch job control, bearer token revocation,
+// dependency vulnerability reports, diagnostics snapshots, the
+// authentication audit log and the data residency report.
+// The same Service backs a JSON API under /admin/ on the HTTP port and an
+// optional gRPC ControlPlane service, defined in adminpb/admin.proto, for
+// fleets that manage servers over gRPC.
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vcto/mcp-adapters/internal/audit"
+	"github.com/vcto/mcp-adapters/internal/auth"
+	"github.com/vcto/mcp-adapters/internal/health"
+	"github.com/vcto/mcp-adapters/internal/residency"
+	"github.com/vcto/mcp-adapters/internal/rtm"
+	"github.com/vcto/mcp-adapters/internal/security"
+)
+
+// Errors returned by Service, mapped to HTTP statuses and gRPC codes
+var (
+	// ErrNotFound means the job, token or reload target does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrUnavailable means the server was started without the feature
+	ErrUnavailable = errors.New("not available on this server")
+	// ErrInvalid means the request is malformed
+	ErrInvalid = errors.New("invalid request")
+	// ErrConflict means the request does not fit the current state, such
+	// as cancelling a finished job
+	ErrConflict = errors.New("conflict")
+)
+
+// Health statuses
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+)
+
+// HealthReport is the state of every adapter
+type HealthReport struct {
+	Status   string                 `json:"status"`
+	Adapters []health.AdapterStatus `json:"adapters"`
+}
+
+// Jobs is implemented by rtm.JobQueue
+type Jobs interface {
+	Jobs() []rtm.BatchJob
+	Cancel(id string) (rtm.BatchJob, error)
+}
+
+// AuthEvents is implemented by audit.Log
+type AuthEvents interface {
+	GetAuthEvents(query audit.Query) ([]audit.Record, error)
+}
+
+// Config lists what a Service manages. Nil fields disable the matching
+// operations, which then return ErrUnavailable.
+type Config struct {
+	Reporters []health.Reporter
+	Jobs      Jobs
+	Tokens    *auth.TokenRegistry
+	Security  *security.Scanner
+	// AuthEvents is the audit log of authorization attempts, issued
+	// tokens, refused bearer tokens and revocations
+	AuthEvents AuthEvents
+	// Sessions and Tasks feed diagnostics snapshots, which leave out what
+	// is missing
+	Sessions *SessionTracker
+	Tasks    Tasks
+	// Residency records which stores and regions hold each subject's data
+	Residency *residency.Ledger
+}
+
+// Service implements the control plane operations
+type Service struct {
+	config Config
+
+	mu        sync.RWMutex
+	reloaders map[string]func() error
+	snapshots snapshotStore
+}
+
+// NewService creates a control plane service
+func NewService(config Config) *Service {
+	return &Service{config: config, reloaders: make(map[string]func() error)}
+}
+
+// AddReloader registers reload under name as a Reload target
+func (s *Service) AddReloader(name string, reload func() error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reloaders[name] = reload
+}
+
+// Health reports each adapter's state. The status is degraded when any
+// adapter is unauthenticated, its circuit is not closed, or its warmup
+// failed or found nothing to warm with.
+func (s *Service) Health(ctx context.Context) HealthReport {
+	report := HealthReport{Status: StatusOK, Adapters: []health.AdapterStatus{}}
+	for _, r := range s.config.Reporters {
+		status := r.AdapterStatus()
+		warm := status.Warmup != health.WarmupNotWarmed && status.Warmup != health.WarmupFailed
+		if !status.Authenticated || status.Circuit.State != health.StateClosed || !warm {
+			report.Status = StatusDegraded
+		}
+		report.Adapters = append(report.Adapters, status)
+	}
+	return report
+}
+
+// Reload runs the named reloader, or all of them when target is empty, and
+// returns the names reloaded. It stops at the first failure.
+func (s *Service) Reload(ctx context.Context, target string) ([]string, error) {
+	s.mu.RLock()
+	names := make([]string, 0, len(s.reloaders))
+	for name := range s.reloaders {
+		names = append(names, name)
+	}
+	s.mu.RUnlock()
+	sort.Strings(names)
+
+	if target != "" {
+		if !slices.Contains(names, target) {
+			return nil, fmt.Errorf("%w: reload target %q, available: %s", ErrNotFound, target, strings.Join(names, ", "))
+		}
+		names = []string{target}
+	}
+
+	reloaded := []string{}
+	for _, name := range names {
+		s.mu.RLock()
+		reload := s.reloaders[name]
+		s.mu.RUnlock()
+		if err := reload(); err != nil {
+			return reloaded, fmt.Errorf("reloading %s: %w", name, err)
+		}
+		reloaded = append(reloaded, name)
+	}
+	return reloaded, nil
+}
+
+// ListJobs returns batch jobs, newest first, optionally only those with
+// the given status
+func (s *Service) ListJobs(ctx context.Context, status string) ([]rtm.BatchJob, error) {
+	if s.config.Jobs == nil {
+		return nil, fmt.Errorf("batch jobs: %w", ErrUnavailable)
+	}
+	switch rtm.JobStatus(status) {
+	case "", rtm.JobStatusPending, rtm.JobStatusProcessing, rtm.JobStatusCompleted, rtm.JobStatusFailed, rtm.JobStatusCancelled:
+	default:
+		return nil, fmt.Errorf("%w: unknown job status %q", ErrInvalid, status)
+	}
+
+	jobs := []rtm.BatchJob{}
+	for _, job := range s.config.Jobs.Jobs() {
+		if status == "" || string(job.Status) == status {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, nil
+}
+
+// GetJob returns one batch job
+func (s *Service) GetJob(ctx context.Context, id string) (rtm.BatchJob, error) {
+	jobs, err := s.ListJobs(ctx, "")
+	if err != nil {
+		return rtm.BatchJob{}, err
+	}
+	for _, job := range jobs {
+		if job.ID == id {
+			return job, nil
+		}
+	}
+	return rtm.BatchJob{}, fmt.Errorf("%w: job %q", ErrNotFound, id)
+}
+
+// CancelJob stops a batch job, returning it as it was when cancelled. A
+// running job stops after the item in progress.
+func (s *Service) CancelJob(ctx context.Context, id string) (rtm.BatchJob, error) {
+	if s.config.Jobs == nil {
+		return rtm.BatchJob{}, fmt.Errorf("batch jobs: %w", ErrUnavailable)
+	}
+	job, err := s.config.Jobs.Cancel(id)
+	switch {
+	case errors.Is(err, rtm.ErrJobNotFound):
+		return job, fmt.Errorf("%w: job %q", ErrNotFound, id)
+	case errors.Is(err, rtm.ErrJobFinished):
+		return job, fmt.Errorf("%w: job %q is already %s", ErrConflict, id, job.Status)
+	}
+	return job, err
+}
+
+// ListTokens returns the bearer tokens seen since startup and revoked
+// subjects
+func (s *Service) ListTokens(ctx context.Context) ([]auth.TokenInfo, error) {
+	if s.config.Tokens == nil {
+		return nil, fmt.Errorf("token management: %w", ErrUnavailable)
+	}
+	return s.config.Tokens.List(), nil
+}
+
+// RevokeToken rejects the subject's bearer token from now on, reporting
+// whether the subject had been seen since startup
+func (s *Service) RevokeToken(ctx context.Context, subject string) (bool, error) {
+	if s.config.Tokens == nil {
+		return false, fmt.Errorf("token management: %w", ErrUnavailable)
+	}
+	if subject == "" {
+		return false, fmt.Errorf("%w: subject is required", ErrInvalid)
+	}
+	return s.config.Tokens.Revoke(subject)
+}
+
+// SecurityReport returns the binary's SBOM and known vulnerabilities,
+// scanning now when refresh is set or the cached report is stale
+func (s *Service) SecurityReport(ctx context.Context, refresh bool) (security.Report, error) {
+	if s.config.Security == nil {
+		return security.Report{}, fmt.Errorf("security reports: %w", ErrUnavailable)
+	}
+	return s.config.Security.Report(ctx, refresh), nil
+}
+
+// maxAuthEvents bounds how many events one query returns
+const maxAuthEvents = 1000
+
+// AuthEvents returns the authentication events query selects, newest
+// first
+func (s *Service) AuthEvents(ctx context.Context, query audit.Query) ([]audit.Record, error) {
+	if s.config.AuthEvents == nil {
+		return nil, fmt.Errorf("auth event log: %w", ErrUnavailable)
+	}
+	if query.Limit < 0 || query.Limit > maxAuthEvents {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalid, maxAuthEvents)
+	}
+	events, err := s.config.AuthEvents.GetAuthEvents(query)
+	if err != nil {
+		return nil, err
+	}
+	if events == nil {
+		events = []audit.Record{}
+	}
+	return events, nil
+}
+
+// ResidencyReport is where each subject's data lives
+type ResidencyReport struct {
+	Region      string                    `json:"region"`
+	Routing     bool                      `json:"routing"`
+	Subjects    []residency.SubjectReport `json:"subjects"`
+	GeneratedAt time.Time                 `json:"generated_at"`
+}
+
+// Residency reports which stores and regions hold each subject's data, or
+// only subject's when it is set. Subjects are hashed IDs; entries cover the
+// machines sharing this server's ledger.
+func (s *Service) Residency(ctx context.Context, subject string) (ResidencyReport, error) {
+	if s.config.Residency == nil {
+		return ResidencyReport{}, fmt.Errorf("residency report: %w", ErrUnavailable)
+	}
+	subjects, err := s.config.Residency.Report(subject)
+	if err != nil {
+		return ResidencyReport{}, fmt.Errorf("reading residency ledger: %w", err)
+	}
+	return ResidencyReport{
+		Region:      s.config.Residency.Region(),
+		Routing:     residency.RoutingEnabled(),
+		Subjects:    subjects,
+		GeneratedAt: time.Now().UTC(),
+	}, nil
+}
+
+// TokenFromEnv returns the ADMIN_TOKEN operators authenticate with. The
+// control plane is disabled when it is unset.
+func TokenFromEnv() string {
+	return os.Getenv("ADMIN_TOKEN")
+}
+
+// authorized reports whether an Authorization value carries token
+func authorized(value, token string) bool {
+	presented, ok := strings.CutPrefix(value, "Bearer ")
+	if !ok || token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(presented), []byte(token)) == 1
+}
@@ -0,0 +1,303 @@
+// Package atrest encrypts values kept in the server's embedded databases
+// (debug log, token store, kv store) with AES-256-GCM.
+//
+// Encrypted values are self-describing: they carry a fingerprint of the key
+// that sealed them, so keys can be rotated by configuring the new key as
+// primary and listing old keys for decryption until a migration rewraps the
+// data. Values without the prefix are treated as legacy plaintext.
+package atrest
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// Value prefixes. v1 values are sealed with a raw key; v2 values are sealed
+// with a key derived from a passphrase with scrypt and carry the salt they
+// used.
+const (
+	prefix   = "enc:v1:"
+	prefixV2 = "enc:v2:"
+)
+
+// scrypt parameters for passphrase keys, as recommended for interactive
+// logins in 2017 (N=2^15, r=8, p=1)
+const (
+	scryptN    = 1 << 15
+	scryptR    = 8
+	scryptP    = 1
+	saltLength = 16
+)
+
+// keyIDSalt derives the fingerprint identifying a passphrase key, so the
+// fingerprint is as costly to guess from as the data itself
+var keyIDSalt = []byte("atrest key id")
+
+// ErrNoKey is returned when an encrypted value is read without a usable key
+var ErrNoKey = errors.New("atrest: no key configured for encrypted value")
+
+// Keyring holds the primary key used for encryption and older keys that
+// are still accepted for decryption. A nil *Keyring leaves values unencrypted.
+type Keyring struct {
+	primary *key
+	keys    map[string]*key
+}
+
+// key is one configured key. Raw keys seal with aead; passphrase keys
+// derive an AEAD per salt, sealing new values with the salt chosen when the
+// keyring was created.
+type key struct {
+	id   string
+	aead cipher.AEAD
+
+	passphrase []byte
+	salt       []byte
+	mu         sync.Mutex
+	salted     map[string]cipher.AEAD
+}
+
+// NewKeyring creates a keyring from key material. Keys are either base64
+// encoded 32-byte keys or passphrases, which are stretched with scrypt and
+// a random salt stored with each value.
+func NewKeyring(primary string, old ...string) (*Keyring, error) {
+	if primary == "" {
+		return nil, errors.New("atrest: primary key is empty")
+	}
+
+	k := &Keyring{keys: make(map[string]*key)}
+	for i, material := range append([]string{primary}, old...) {
+		material = strings.TrimSpace(material)
+		if material == "" {
+			continue
+		}
+		key, err := newKey(material)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			k.primary = key
+		}
+		k.keys[key.id] = key
+	}
+	return k, nil
+}
+
+// newKey returns the key for material
+func newKey(material string) (*key, error) {
+	raw, err := base64.StdEncoding.DecodeString(material)
+	if err == nil && len(raw) == 32 {
+		aead, err := newAEAD(raw)
+		if err != nil {
+			return nil, err
+		}
+		return &key{id: fingerprint(raw), aead: aead}, nil
+	}
+
+	passphrase := []byte(material)
+	idKey, err := scrypt.Key(passphrase, keyIDSalt, scryptN, scryptR, scryptP, 32)
+	if err != nil {
+		return nil, fmt.Errorf("atrest: %w", err)
+	}
+	salt := make([]byte, saltLength)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, fmt.Errorf("atrest: %w", err)
+	}
+	return &key{id: fingerprint(idKey), passphrase: passphrase, salt: salt, salted: make(map[string]cipher.AEAD)}, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("atrest: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("atrest: %w", err)
+	}
+	return aead, nil
+}
+
+// fingerprint identifies a key in the values it seals
+func fingerprint(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:4])
+}
+
+// aeadFor returns the AEAD sealing values with salt, deriving it once per
+// salt for passphrase keys
+func (k *key) aeadFor(salt []byte) (cipher.AEAD, error) {
+	if k.passphrase == nil {
+		return k.aead, nil
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if aead, ok := k.salted[string(salt)]; ok {
+		return aead, nil
+	}
+	derived, err := scrypt.Key(k.passphrase, salt, scryptN, scryptR, scryptP, 32)
+	if err != nil {
+		return nil, fmt.Errorf("atrest: %w", err)
+	}
+	aead, err := newAEAD(derived)
+	if err != nil {
+		return nil, err
+	}
+	k.salted[string(salt)] = aead
+	return aead, nil
+}
+
+// valuePrefix starts every value the key seals
+func (k *key) valuePrefix() string {
+	if k.passphrase != nil {
+		return prefixV2 + k.id + ":"
+	}
+	return prefix + k.id + ":"
+}
+
+// KeyringFromEnv builds a keyring from STORAGE_ENCRYPTION_KEY, or from the
+// file named by STORAGE_ENCRYPTION_KEY_FILE for keys delivered by a KMS or
+// secret manager, plus comma-separated STORAGE_ENCRYPTION_OLD_KEYS. It
+// returns nil when encryption is not configured.
+func KeyringFromEnv() (*Keyring, error) {
+	primary := os.Getenv("STORAGE_ENCRYPTION_KEY")
+	if path := os.Getenv("STORAGE_ENCRYPTION_KEY_FILE"); primary == "" && path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("atrest: reading key file: %w", err)
+		}
+		primary = strings.TrimSpace(string(data))
+	}
+	if primary == "" {
+		return nil, nil
+	}
+
+	var old []string
+	if value := os.Getenv("STORAGE_ENCRYPTION_OLD_KEYS"); value != "" {
+		old = strings.Split(value, ",")
+	}
+	return NewKeyring(primary, old...)
+}
+
+var (
+	defaultOnce    sync.Once
+	defaultKeyring *Keyring
+	defaultErr     error
+)
+
+// Default returns the keyring configured in the environment, loading it once
+func Default() (*Keyring, error) {
+	defaultOnce.Do(func() {
+		defaultKeyring, defaultErr = KeyringFromEnv()
+	})
+	return defaultKeyring, defaultErr
+}
+
+// Enabled reports whether values will be encrypted
+func (k *Keyring) Enabled() bool {
+	return k != nil
+}
+
+// Encrypt seals plaintext with the primary key. With a nil keyring the
+// plaintext is returned unchanged.
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	if k == nil {
+		return plaintext, nil
+	}
+
+	aead, err := k.primary.aeadFor(k.primary.salt)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("atrest: %w", err)
+	}
+
+	sealed := base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(plaintext), []byte(k.primary.id)))
+	if k.primary.passphrase != nil {
+		sealed = base64.StdEncoding.EncodeToString(k.primary.salt) + ":" + sealed
+	}
+	return k.primary.valuePrefix() + sealed, nil
+}
+
+// Decrypt opens a sealed value with whichever configured key sealed it.
+// Values that are not sealed are returned unchanged.
+func (k *Keyring) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	salted := strings.HasPrefix(value, prefixV2)
+	rest := strings.TrimPrefix(strings.TrimPrefix(value, prefix), prefixV2)
+	id, encoded, ok := strings.Cut(rest, ":")
+	if !ok {
+		return "", errors.New("atrest: malformed encrypted value")
+	}
+	var salt []byte
+	if salted {
+		var encodedSalt string
+		encodedSalt, encoded, ok = strings.Cut(encoded, ":")
+		var err error
+		if salt, err = base64.StdEncoding.DecodeString(encodedSalt); !ok || err != nil || len(salt) == 0 {
+			return "", errors.New("atrest: malformed encrypted value")
+		}
+	}
+	if k == nil {
+		return "", ErrNoKey
+	}
+	key, ok := k.keys[id]
+	if !ok || salted != (key.passphrase != nil) {
+		return "", fmt.Errorf("%w (key %s)", ErrNoKey, id)
+	}
+	aead, err := key.aeadFor(salt)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", errors.New("atrest: malformed encrypted value")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(id))
+	if err != nil {
+		return "", fmt.Errorf("atrest: decrypting with key %s: %w", id, err)
+	}
+	return string(plaintext), nil
+}
+
+// NeedsRewrap reports whether value is plaintext or sealed with a key other
+// than the primary, so a migration should re-encrypt it
+func (k *Keyring) NeedsRewrap(value string) bool {
+	if k == nil {
+		return false
+	}
+	return !strings.HasPrefix(value, k.primary.valuePrefix())
+}
+
+// Rewrap decrypts value and encrypts it again with the primary key
+func (k *Keyring) Rewrap(value string) (string, error) {
+	plaintext, err := k.Decrypt(value)
+	if err != nil {
+		return "", err
+	}
+	return k.Encrypt(plaintext)
+}
+
+// IsEncrypted reports whether value was sealed by this package
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix) || strings.HasPrefix(value, prefixV2)
+}
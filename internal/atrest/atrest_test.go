@@ -0,0 +1,177 @@
+package atrest
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestKeyring(t *testing.T) {
+	t.Logf("Importance: Tokens and debug logs on disk must be unreadable without the key, yet readable across key rotations.")
+
+	t.Run("round trips values", func(t *testing.T) {
+		t.Logf("  > Why it's important: Stores must get back exactly what they wrote.")
+		k, err := NewKeyring("passphrase")
+		if err != nil {
+			t.Fatalf("NewKeyring failed: %v", err)
+		}
+
+		sealed, err := k.Encrypt("rtm-api-key")
+		if err != nil {
+			t.Fatalf("Encrypt failed: %v", err)
+		}
+		if !IsEncrypted(sealed) || strings.Contains(sealed, "rtm-api-key") {
+			t.Fatalf("Expected sealed value, got %q", sealed)
+		}
+
+		opened, err := k.Decrypt(sealed)
+		if err != nil || opened != "rtm-api-key" {
+			t.Errorf("Expected rtm-api-key, got %q (err=%v)", opened, err)
+		}
+	})
+
+	t.Run("passes plaintext through", func(t *testing.T) {
+		t.Logf("  > Why it's important: Databases written before encryption was enabled must stay readable until migrated.")
+		k, _ := NewKeyring("passphrase")
+		if opened, err := k.Decrypt(`{"legacy":true}`); err != nil || opened != `{"legacy":true}` {
+			t.Errorf("Expected plaintext unchanged, got %q (err=%v)", opened, err)
+		}
+
+		var disabled *Keyring
+		if sealed, _ := disabled.Encrypt("value"); sealed != "value" {
+			t.Errorf("Expected nil keyring to leave values unencrypted, got %q", sealed)
+		}
+	})
+
+	t.Run("decrypts with old keys after rotation", func(t *testing.T) {
+		t.Logf("  > Why it's important: Rotating the key must not lock the server out of existing data.")
+		oldKeyring, _ := NewKeyring("old-key")
+		sealed, _ := oldKeyring.Encrypt("secret")
+
+		rotated, err := NewKeyring("new-key", "old-key")
+		if err != nil {
+			t.Fatalf("NewKeyring failed: %v", err)
+		}
+		if opened, err := rotated.Decrypt(sealed); err != nil || opened != "secret" {
+			t.Errorf("Expected old value to decrypt, got %q (err=%v)", opened, err)
+		}
+		if !rotated.NeedsRewrap(sealed) {
+			t.Error("Expected value sealed with old key to need rewrapping")
+		}
+
+		rewrapped, err := rotated.Rewrap(sealed)
+		if err != nil || rotated.NeedsRewrap(rewrapped) {
+			t.Errorf("Expected rewrap onto the primary key, got %q (err=%v)", rewrapped, err)
+		}
+
+		newOnly, _ := NewKeyring("new-key")
+		if _, err := newOnly.Decrypt(sealed); !errors.Is(err, ErrNoKey) {
+			t.Errorf("Expected ErrNoKey once the old key is retired, got %v", err)
+		}
+	})
+
+	t.Run("salts passphrase keys", func(t *testing.T) {
+		t.Logf("  > Why it's important: An unsalted hash of a passphrase can be attacked with precomputed tables; scrypt with a stored salt cannot.")
+		first, _ := NewKeyring("passphrase")
+		second, _ := NewKeyring("passphrase")
+		sealed, _ := first.Encrypt("secret")
+		other, _ := second.Encrypt("secret")
+		if !strings.HasPrefix(sealed, prefixV2) || strings.SplitN(sealed, ":", 5)[3] == strings.SplitN(other, ":", 5)[3] {
+			t.Fatalf("Expected salted values with a salt per keyring, got %q and %q", sealed, other)
+		}
+		if opened, err := second.Decrypt(sealed); err != nil || opened != "secret" {
+			t.Errorf("Expected the stored salt to rederive the key, got %q (err=%v)", opened, err)
+		}
+		if second.NeedsRewrap(sealed) {
+			t.Error("Expected a value under another salt of the primary passphrase not to need rewrapping")
+		}
+	})
+
+	t.Run("rejects tampered values", func(t *testing.T) {
+		t.Logf("  > Why it's important: GCM authentication must catch modified ciphertext.")
+		k, _ := NewKeyring("passphrase")
+		sealed, _ := k.Encrypt("secret")
+		tampered := sealed[:len(sealed)-4] + "AAA="
+		if _, err := k.Decrypt(tampered); err == nil {
+			t.Error("Expected tampered value to fail decryption")
+		}
+	})
+}
+
+func TestKeyringFromEnv(t *testing.T) {
+	t.Logf("Importance: Keys can come straight from env or from a file written by a KMS or secret manager.")
+
+	t.Setenv("STORAGE_ENCRYPTION_KEY", "")
+	t.Setenv("STORAGE_ENCRYPTION_KEY_FILE", "")
+	if k, err := KeyringFromEnv(); k != nil || err != nil {
+		t.Errorf("Expected encryption disabled without a key, got %v (err=%v)", k, err)
+	}
+
+	t.Setenv("STORAGE_ENCRYPTION_KEY_FILE", filepath.Join(t.TempDir(), "missing"))
+	if _, err := KeyringFromEnv(); err == nil {
+		t.Error("Expected error for unreadable key file")
+	}
+
+	t.Setenv("STORAGE_ENCRYPTION_KEY", "primary")
+	t.Setenv("STORAGE_ENCRYPTION_OLD_KEYS", "old-1, old-2")
+	k, err := KeyringFromEnv()
+	if err != nil || k == nil || len(k.keys) != 3 {
+		t.Errorf("Expected primary plus two old keys, got %+v (err=%v)", k, err)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	t.Logf("Importance: Existing plaintext databases must be encryptable in place, and rotations completed so old keys can be retired.")
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "tokens.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	if _, err := db.Exec(`CREATE TABLE tokens (token TEXT PRIMARY KEY, api_key TEXT, note TEXT)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	oldKeyring, _ := NewKeyring("old-key")
+	oldSealed, _ := oldKeyring.Encrypt("key-b")
+	if _, err := db.Exec(`INSERT INTO tokens VALUES ('a', 'key-a', NULL), ('b', ?, ''), ('c', '', NULL)`, oldSealed); err != nil {
+		t.Fatalf("Failed to insert rows: %v", err)
+	}
+
+	k, _ := NewKeyring("new-key", "old-key")
+	target := Target{Table: "tokens", Columns: []string{"api_key", "note"}}
+
+	dry, err := Migrate(db, target, k, true)
+	if err != nil || dry.Rows != 2 || dry.Values != 2 {
+		t.Fatalf("Expected dry run to find 2 values in 2 rows, got %+v (err=%v)", dry, err)
+	}
+	var unchanged string
+	_ = db.QueryRow(`SELECT api_key FROM tokens WHERE token = 'a'`).Scan(&unchanged)
+	if unchanged != "key-a" {
+		t.Fatalf("Expected dry run to leave data untouched, got %q", unchanged)
+	}
+
+	if _, err := Migrate(db, target, k, false); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+
+	newOnly, _ := NewKeyring("new-key")
+	for token, want := range map[string]string{"a": "key-a", "b": "key-b"} {
+		var sealed string
+		_ = db.QueryRow(`SELECT api_key FROM tokens WHERE token = ?`, token).Scan(&sealed)
+		if opened, err := newOnly.Decrypt(sealed); err != nil || opened != want || !IsEncrypted(sealed) {
+			t.Errorf("token %s: expected %q sealed with the new key, got %q (err=%v)", token, want, sealed, err)
+		}
+	}
+
+	again, err := Migrate(db, target, k, false)
+	if err != nil || again.Values != 0 {
+		t.Errorf("Expected a second migration to be a no-op, got %+v (err=%v)", again, err)
+	}
+}
@@ -0,0 +1,112 @@
+package atrest
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Target names a table and the columns in it that hold sensitive values
+type Target struct {
+	Table   string
+	Columns []string
+}
+
+// MigrateResult counts what a migration touched
+type MigrateResult struct {
+	Rows   int `json:"rows"`
+	Values int `json:"values"`
+}
+
+// Migrate encrypts plaintext values in the target's columns and rewraps
+// values sealed with old keys, so old keys can then be retired. Rows are
+// updated in one transaction. With dryRun nothing is written.
+func Migrate(db *sql.DB, target Target, k *Keyring, dryRun bool) (MigrateResult, error) {
+	var result MigrateResult
+	if k == nil {
+		return result, ErrNoKey
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return result, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// Table and column names come from Target values defined in code, not user input
+	query := fmt.Sprintf("SELECT rowid, %s FROM %s", strings.Join(target.Columns, ", "), target.Table)
+	rows, err := tx.Query(query)
+	if err != nil {
+		return result, fmt.Errorf("reading %s: %w", target.Table, err)
+	}
+
+	type update struct {
+		rowid  int64
+		values []interface{}
+	}
+	var updates []update
+	for rows.Next() {
+		var rowid int64
+		values := make([]sql.NullString, len(target.Columns))
+		dest := []interface{}{&rowid}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			_ = rows.Close()
+			return result, fmt.Errorf("reading %s: %w", target.Table, err)
+		}
+
+		changed := false
+		newValues := make([]interface{}, len(values))
+		for i, value := range values {
+			newValues[i] = value
+			if !value.Valid || value.String == "" || !k.NeedsRewrap(value.String) {
+				continue
+			}
+			sealed, err := k.Rewrap(value.String)
+			if err != nil {
+				_ = rows.Close()
+				return result, fmt.Errorf("%s row %d: %w", target.Table, rowid, err)
+			}
+			newValues[i] = sealed
+			changed = true
+			result.Values++
+		}
+		if changed {
+			updates = append(updates, update{rowid: rowid, values: newValues})
+		}
+	}
+	if err := rows.Close(); err != nil {
+		return result, err
+	}
+	if err := rows.Err(); err != nil {
+		return result, err
+	}
+	result.Rows = len(updates)
+
+	if dryRun || len(updates) == 0 {
+		return result, nil
+	}
+
+	assignments := make([]string, len(target.Columns))
+	for i, column := range target.Columns {
+		assignments[i] = column + " = ?"
+	}
+	stmt, err := tx.Prepare(fmt.Sprintf("UPDATE %s SET %s WHERE rowid = ?", target.Table, strings.Join(assignments, ", ")))
+	if err != nil {
+		return result, err
+	}
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	for _, u := range updates {
+		if _, err := stmt.Exec(append(u.values, u.rowid)...); err != nil {
+			return result, fmt.Errorf("updating %s row %d: %w", target.Table, u.rowid, err)
+		}
+	}
+	return result, tx.Commit()
+}
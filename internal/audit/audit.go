@@ -0,0 +1,340 @@
+// Package audit keeps the trail of authentication events: authorization
+// attempts, issued tokens, refused bearer tokens and revocations. Unlike
+// the debug storage it is always on, and it is bounded so a flood of bad
+// requests can't grow it without limit.
+package audit
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// DefaultMaxEvents is how many events are kept when AUDIT_MAX_EVENTS is unset
+const DefaultMaxEvents = 10000
+
+// aggregateWindow is how long repeated failures from one unauthenticated
+// address are folded into a single event
+const aggregateWindow = time.Minute
+
+// maxAggregates bounds how many addresses are tracked at once; failures
+// from further addresses share one event
+const maxAggregates = 1000
+
+// otherAddresses stands in for the IP when failures are folded together
+// because too many addresses are being tracked
+const otherAddresses = "*"
+
+// Record is one authentication event. Count is how many identical failures
+// from an unauthenticated client it stands for.
+type Record struct {
+	ID        int64     `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Event     string    `json:"event"`
+	Outcome   string    `json:"outcome"`
+	ClientID  string    `json:"client_id,omitempty"`
+	IP        string    `json:"ip,omitempty"`
+	Detail    string    `json:"detail,omitempty"`
+	Count     int       `json:"count"`
+}
+
+// Query selects events. Empty fields match everything.
+type Query struct {
+	Event    string
+	Outcome  string
+	ClientID string
+	IP       string
+	Since    time.Time
+	Limit    int // Defaults to 100
+}
+
+func (q Query) matches(r Record) bool {
+	return (q.Event == "" || r.Event == q.Event) &&
+		(q.Outcome == "" || r.Outcome == q.Outcome) &&
+		(q.ClientID == "" || r.ClientID == q.ClientID) &&
+		(q.IP == "" || r.IP == q.IP) &&
+		(q.Since.IsZero() || !r.Timestamp.Before(q.Since))
+}
+
+// backend stores records for a Log
+type backend interface {
+	insert(r Record) (int64, error)
+	addCount(id int64, n int) error
+	query(q Query) ([]Record, error)
+	trim(max int) error
+	close() error
+}
+
+// aggregate is the event currently collecting repeats of one failure
+type aggregate struct {
+	id    int64
+	start time.Time
+}
+
+// Log stores authentication events in memory, or in SQLite when opened
+// with a path, keeping at most maxEvents of them. Failures without a client
+// ID, which come from requests nobody has authenticated, are aggregated:
+// repeats of the same failure from the same address within a minute bump
+// the count of one event instead of adding more.
+type Log struct {
+	mu         sync.Mutex
+	store      backend
+	maxEvents  int
+	aggregates map[string]aggregate
+	now        func() time.Time
+}
+
+// Open returns a log in SQLite at path, or in memory when path is empty
+func Open(path string, maxEvents int) (*Log, error) {
+	if maxEvents <= 0 {
+		maxEvents = DefaultMaxEvents
+	}
+	var store backend = &memoryBackend{}
+	if path != "" {
+		var err error
+		if store, err = openSQLite(path); err != nil {
+			return nil, err
+		}
+	}
+	return &Log{store: store, maxEvents: maxEvents, aggregates: make(map[string]aggregate), now: time.Now}, nil
+}
+
+var (
+	defaultOnce sync.Once
+	defaultLog  *Log
+)
+
+// Default returns the log configured by AUDIT_DB_PATH and AUDIT_MAX_EVENTS,
+// opening it once. It falls back to memory when the database can't be
+// opened, so events are never silently dropped.
+func Default() *Log {
+	defaultOnce.Do(func() {
+		maxEvents := DefaultMaxEvents
+		if value := os.Getenv("AUDIT_MAX_EVENTS"); value != "" {
+			if n, err := strconv.Atoi(value); err == nil && n > 0 {
+				maxEvents = n
+			} else {
+				log.Printf("Invalid AUDIT_MAX_EVENTS %q, using default %d", value, DefaultMaxEvents)
+			}
+		}
+		var err error
+		if defaultLog, err = Open(os.Getenv("AUDIT_DB_PATH"), maxEvents); err != nil {
+			log.Printf("[AUDIT] Failed to open audit log: %v, keeping events in memory", err)
+			defaultLog, _ = Open("", maxEvents)
+		}
+	})
+	return defaultLog
+}
+
+// LogAuthEvent records an event, implementing auth.AuditLog
+func (l *Log) LogAuthEvent(event, outcome, clientID, ip, detail string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now().UTC()
+	record := Record{Timestamp: now, Event: event, Outcome: outcome, ClientID: clientID, IP: ip, Detail: detail, Count: 1}
+	if outcome != "failure" || clientID != "" {
+		_, err := l.insert(record)
+		return err
+	}
+
+	l.expireAggregates(now)
+	if _, tracked := l.aggregates[aggregateKey(record)]; !tracked && len(l.aggregates) >= maxAggregates {
+		record.IP = otherAddresses
+	}
+	key := aggregateKey(record)
+	if current, ok := l.aggregates[key]; ok {
+		return l.store.addCount(current.id, 1)
+	}
+	id, err := l.insert(record)
+	if err != nil {
+		return err
+	}
+	l.aggregates[key] = aggregate{id: id, start: now}
+	return nil
+}
+
+// GetAuthEvents returns the events query selects, newest first
+func (l *Log) GetAuthEvents(query Query) ([]Record, error) {
+	if query.Limit <= 0 {
+		query.Limit = 100
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.store.query(query)
+}
+
+// Close releases the log's database
+func (l *Log) Close() error {
+	return l.store.close()
+}
+
+// insert stores record, returning its ID, and drops the oldest events over
+// the limit. Callers hold l.mu.
+func (l *Log) insert(record Record) (int64, error) {
+	id, err := l.store.insert(record)
+	if err != nil {
+		return 0, err
+	}
+	return id, l.store.trim(l.maxEvents)
+}
+
+// expireAggregates forgets aggregates older than the window, so the next
+// failure starts a new event. Callers hold l.mu.
+func (l *Log) expireAggregates(now time.Time) {
+	for key, current := range l.aggregates {
+		if now.Sub(current.start) >= aggregateWindow {
+			delete(l.aggregates, key)
+		}
+	}
+}
+
+func aggregateKey(r Record) string {
+	return r.Event + "\x00" + r.IP + "\x00" + r.Detail
+}
+
+// memoryBackend keeps records in a slice, oldest first
+type memoryBackend struct {
+	records []Record
+	nextID  int64
+}
+
+func (m *memoryBackend) insert(r Record) (int64, error) {
+	m.nextID++
+	r.ID = m.nextID
+	m.records = append(m.records, r)
+	return r.ID, nil
+}
+
+func (m *memoryBackend) addCount(id int64, n int) error {
+	// Aggregates are recent, so search from the newest end
+	for i := len(m.records) - 1; i >= 0; i-- {
+		if m.records[i].ID == id {
+			m.records[i].Count += n
+			return nil
+		}
+	}
+	return nil
+}
+
+func (m *memoryBackend) query(q Query) ([]Record, error) {
+	records := []Record{}
+	for i := len(m.records) - 1; i >= 0 && len(records) < q.Limit; i-- {
+		if q.matches(m.records[i]) {
+			records = append(records, m.records[i])
+		}
+	}
+	return records, nil
+}
+
+func (m *memoryBackend) trim(max int) error {
+	if extra := len(m.records) - max; extra > 0 {
+		// Reslicing keeps trimming cheap; append reallocates as it grows
+		m.records = m.records[extra:]
+	}
+	return nil
+}
+
+func (m *memoryBackend) close() error {
+	return nil
+}
+
+// sqliteBackend keeps records in an auth_events table
+type sqliteBackend struct {
+	db *sql.DB
+}
+
+func openSQLite(path string) (*sqliteBackend, error) {
+	if dir := filepath.Dir(path); dir != "." && dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("audit: create db directory: %w", err)
+		}
+	}
+	db, err := sql.Open("sqlite3", path+"?_journal_mode=WAL&_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("audit: %w", err)
+	}
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS auth_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp DATETIME NOT NULL,
+			event TEXT NOT NULL,
+			outcome TEXT NOT NULL,
+			client_id TEXT,
+			ip TEXT,
+			detail TEXT,
+			count INTEGER NOT NULL DEFAULT 1
+		);
+		CREATE INDEX IF NOT EXISTS idx_auth_events_timestamp ON auth_events(timestamp);
+		CREATE INDEX IF NOT EXISTS idx_auth_events_client ON auth_events(client_id);
+		CREATE INDEX IF NOT EXISTS idx_auth_events_ip ON auth_events(ip);
+	`)
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("audit: create auth_events table: %w", err)
+	}
+	return &sqliteBackend{db: db}, nil
+}
+
+func (s *sqliteBackend) insert(r Record) (int64, error) {
+	result, err := s.db.Exec(
+		`INSERT INTO auth_events (timestamp, event, outcome, client_id, ip, detail, count) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		r.Timestamp, r.Event, r.Outcome, r.ClientID, r.IP, r.Detail, r.Count,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("audit: insert: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+func (s *sqliteBackend) addCount(id int64, n int) error {
+	if _, err := s.db.Exec(`UPDATE auth_events SET count = count + ? WHERE id = ?`, n, id); err != nil {
+		return fmt.Errorf("audit: update: %w", err)
+	}
+	return nil
+}
+
+func (s *sqliteBackend) query(q Query) ([]Record, error) {
+	rows, err := s.db.Query(`
+		SELECT id, timestamp, event, outcome, COALESCE(client_id, ''), COALESCE(ip, ''), COALESCE(detail, ''), count
+		FROM auth_events
+		WHERE (? = '' OR event = ?) AND (? = '' OR outcome = ?) AND (? = '' OR client_id = ?) AND (? = '' OR ip = ?)
+			AND timestamp >= ?
+		ORDER BY id DESC LIMIT ?`,
+		q.Event, q.Event, q.Outcome, q.Outcome, q.ClientID, q.ClientID, q.IP, q.IP, q.Since.UTC(), q.Limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("audit: query: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	records := []Record{}
+	for rows.Next() {
+		var r Record
+		if err := rows.Scan(&r.ID, &r.Timestamp, &r.Event, &r.Outcome, &r.ClientID, &r.IP, &r.Detail, &r.Count); err != nil {
+			return nil, fmt.Errorf("audit: query: %w", err)
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+func (s *sqliteBackend) trim(max int) error {
+	if _, err := s.db.Exec(`DELETE FROM auth_events WHERE id <= (SELECT MAX(id) FROM auth_events) - ?`, max); err != nil {
+		return fmt.Errorf("audit: trim: %w", err)
+	}
+	return nil
+}
+
+func (s *sqliteBackend) close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,144 @@
+package audit
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testLogs returns a memory and a SQLite log keeping maxEvents, with a
+// controllable clock
+func testLogs(t *testing.T, maxEvents int, now *time.Time) map[string]*Log {
+	t.Helper()
+	memory, err := Open("", maxEvents)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	sqlite, err := Open(filepath.Join(t.TempDir(), "audit", "audit.db"), maxEvents)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = sqlite.Close()
+	})
+	logs := map[string]*Log{"memory": memory, "sqlite": sqlite}
+	for _, l := range logs {
+		l.now = func() time.Time { return *now }
+	}
+	return logs
+}
+
+func TestLog(t *testing.T) {
+	t.Logf("Importance: The audit log is what operators read when investigating suspicious access, so it must keep and filter events faithfully.")
+
+	now := time.Now()
+	for name, l := range testLogs(t, 100, &now) {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("  > Why it's important: Setting AUDIT_DB_PATH must not change what is recorded.")
+			for _, event := range [][5]string{
+				{"authorize", "success", "client-a", "198.51.100.1", ""},
+				{"token_issued", "success", "client-a", "198.51.100.1", "authorization_code"},
+				{"validation_failed", "failure", "", "203.0.113.7", "invalid token"},
+				{"validation_failed", "failure", "client-b", "203.0.113.7", "invalid token"},
+			} {
+				now = now.Add(time.Second)
+				if err := l.LogAuthEvent(event[0], event[1], event[2], event[3], event[4]); err != nil {
+					t.Fatalf("LogAuthEvent failed: %v", err)
+				}
+			}
+
+			events, err := l.GetAuthEvents(Query{IP: "203.0.113.7", Outcome: "failure"})
+			if err != nil || len(events) != 2 || events[0].ClientID != "client-b" {
+				t.Errorf("Expected two failures from 203.0.113.7, newest first, got %v %v", events, err)
+			}
+			events, _ = l.GetAuthEvents(Query{ClientID: "client-a", Event: "token_issued"})
+			if len(events) != 1 || events[0].Detail != "authorization_code" || events[0].Count != 1 {
+				t.Errorf("Expected one token issued to client-a, got %v", events)
+			}
+			if events, _ := l.GetAuthEvents(Query{Since: now.Add(time.Hour)}); len(events) != 0 {
+				t.Errorf("Expected no events in the future, got %v", events)
+			}
+			if events, _ := l.GetAuthEvents(Query{Limit: 3}); len(events) != 3 || events[2].Event != "token_issued" {
+				t.Errorf("Expected the three newest events, got %v", events)
+			}
+		})
+	}
+}
+
+func TestLogBounds(t *testing.T) {
+	t.Logf("Importance: Anyone can send bad bearer tokens, so the audit log must not grow with them.")
+
+	t.Run("unauthenticated failures are aggregated", func(t *testing.T) {
+		t.Logf("  > Why it's important: A flood from one address becomes one counted event a minute, not one row per request.")
+		now := time.Now()
+		for name, l := range testLogs(t, 100, &now) {
+			for i := 0; i < 50; i++ {
+				_ = l.LogAuthEvent("validation_failed", "failure", "", "203.0.113.7", "invalid token")
+			}
+			_ = l.LogAuthEvent("validation_failed", "failure", "", "203.0.113.8", "invalid token")
+			events, _ := l.GetAuthEvents(Query{})
+			if len(events) != 2 || events[1].Count != 50 || events[0].Count != 1 {
+				t.Errorf("%s: expected one event per address with its count, got %v", name, events)
+			}
+
+			now = now.Add(aggregateWindow)
+			_ = l.LogAuthEvent("validation_failed", "failure", "", "203.0.113.7", "invalid token")
+			if events, _ := l.GetAuthEvents(Query{IP: "203.0.113.7"}); len(events) != 2 || events[0].Count != 1 {
+				t.Errorf("%s: expected a new event once the window passed, got %v", name, events)
+			}
+		}
+	})
+
+	t.Run("many addresses share an event", func(t *testing.T) {
+		t.Logf("  > Why it's important: Spreading a flood over many addresses must not defeat the aggregation.")
+		now := time.Now()
+		l := testLogs(t, 10*maxAggregates, &now)["memory"]
+		for i := 0; i < maxAggregates+20; i++ {
+			_ = l.LogAuthEvent("validation_failed", "failure", "", fmt.Sprintf("ip-%d", i), "invalid token")
+		}
+		events, _ := l.GetAuthEvents(Query{IP: otherAddresses})
+		if len(events) != 1 || events[0].Count != 20 {
+			t.Errorf("Expected the overflow folded into one event, got %v", events)
+		}
+	})
+
+	t.Run("oldest events are dropped", func(t *testing.T) {
+		t.Logf("  > Why it's important: Even authenticated traffic must not grow the log past AUDIT_MAX_EVENTS.")
+		now := time.Now()
+		for name, l := range testLogs(t, 5, &now) {
+			for i := 0; i < 12; i++ {
+				_ = l.LogAuthEvent("token_issued", "success", fmt.Sprintf("client-%d", i), "", "")
+			}
+			events, _ := l.GetAuthEvents(Query{})
+			if len(events) != 5 || events[0].ClientID != "client-11" || events[4].ClientID != "client-7" {
+				t.Errorf("%s: expected the five newest events kept, got %v", name, events)
+			}
+		}
+	})
+}
+
+func TestLogPersists(t *testing.T) {
+	t.Logf("Importance: With AUDIT_DB_PATH set the trail must survive restarts and deploys.")
+
+	path := filepath.Join(t.TempDir(), "audit.db")
+	l, err := Open(path, 0)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if err := l.LogAuthEvent("revoked", "success", "client-a", "198.51.100.1", ""); err != nil {
+		t.Fatalf("LogAuthEvent failed: %v", err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	reopened, err := Open(path, 0)
+	if err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	defer reopened.Close()
+	if events, _ := reopened.GetAuthEvents(Query{}); len(events) != 1 || events[0].Event != "revoked" {
+		t.Errorf("Expected the event kept across restarts, got %v", events)
+	}
+}
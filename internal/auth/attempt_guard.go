@@ -0,0 +1,376 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxAuditEntries is how many recent lockouts are kept for reporting
+const maxAuditEntries = 100
+
+// GuardLimits configures brute-force protection for code-checking endpoints
+type GuardLimits struct {
+	// IPFailures is how many failed attempts one client IP may make per window
+	IPFailures int
+	// CodeFailures is how many failed attempts may target one code per window
+	CodeFailures int
+	// Window is how long failed attempts are counted
+	Window time.Duration
+	// Lockout is the first lockout; each repeat lockout doubles it
+	Lockout time.Duration
+	// MaxLockout caps the doubled lockout
+	MaxLockout time.Duration
+	// RequestsPerMinute is how many requests one client IP may make to each
+	// guarded endpoint a minute, whether they succeed or not; 0 for no limit
+	RequestsPerMinute int
+}
+
+// DefaultGuardLimits allow ordinary retries while making code guessing
+// impractically slow
+func DefaultGuardLimits() GuardLimits {
+	return GuardLimits{
+		IPFailures:   10,
+		CodeFailures: 5,
+		Window:       10 * time.Minute,
+		Lockout:      time.Minute,
+		MaxLockout:   time.Hour,
+		// Room for the sign-in page polling every 2 seconds alongside a client
+		// polling the token endpoint
+		RequestsPerMinute: 60,
+	}
+}
+
+// GuardLimitsFromEnv reads OAUTH_MAX_FAILED_ATTEMPTS, OAUTH_LOCKOUT and
+// OAUTH_RATE_LIMIT over the defaults
+func GuardLimitsFromEnv() GuardLimits {
+	limits := DefaultGuardLimits()
+	if value := os.Getenv("OAUTH_MAX_FAILED_ATTEMPTS"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			limits.IPFailures = n
+		} else {
+			log.Printf("Invalid OAUTH_MAX_FAILED_ATTEMPTS %q, using %d", value, limits.IPFailures)
+		}
+	}
+	if value := os.Getenv("OAUTH_LOCKOUT"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			limits.Lockout = d
+		} else {
+			log.Printf("Invalid OAUTH_LOCKOUT %q, using %s", value, limits.Lockout)
+		}
+	}
+	if value := os.Getenv("OAUTH_RATE_LIMIT"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			limits.RequestsPerMinute = n
+		} else {
+			log.Printf("Invalid OAUTH_RATE_LIMIT %q, using %d", value, limits.RequestsPerMinute)
+		}
+	}
+	return limits
+}
+
+// GuardMetrics counts what the guard has seen since startup
+type GuardMetrics struct {
+	Failures       int64 `json:"failures"`
+	Rejected       int64 `json:"rejected"`
+	Lockouts       int64 `json:"lockouts"`
+	ActiveLockouts int   `json:"active_lockouts"`
+	Throttled      int64 `json:"throttled"`
+	Replays        int64 `json:"replays"`
+}
+
+// LockoutEvent is the audit entry written when a client IP or code is locked out
+type LockoutEvent struct {
+	Time     time.Time     `json:"time"`
+	Endpoint string        `json:"endpoint"`
+	Kind     string        `json:"kind"` // "ip" or "code"
+	Subject  string        `json:"subject"`
+	Failures int           `json:"failures"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// attemptRecord tracks failures for one IP or code
+type attemptRecord struct {
+	failures    int
+	windowStart time.Time
+	lockouts    int
+	lockedUntil time.Time
+}
+
+// throttleBucket is a token bucket for one IP at one endpoint
+type throttleBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// AttemptGuard limits failed attempts against endpoints that check
+// authorization codes. Once an IP or a code reaches its limit within the
+// window it is locked out, with the lockout doubling on each repeat. It
+// also limits how fast each IP may call guarded endpoints at all.
+type AttemptGuard struct {
+	limits GuardLimits
+
+	mu        sync.Mutex
+	ips       map[string]*attemptRecord
+	codes     map[string]*attemptRecord
+	throttles map[string]*throttleBucket
+	metrics   GuardMetrics
+	audit     []LockoutEvent
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+// NewAttemptGuard creates a guard with the given limits
+func NewAttemptGuard(limits GuardLimits) *AttemptGuard {
+	return &AttemptGuard{
+		limits:    limits,
+		ips:       make(map[string]*attemptRecord),
+		codes:     make(map[string]*attemptRecord),
+		throttles: make(map[string]*throttleBucket),
+		now:       time.Now,
+	}
+}
+
+// Throttle takes one request from ip's allowance at endpoint, returning how
+// long to wait when it has none left. Bursts of a third of a minute's
+// requests are allowed, refilled at the per-minute rate.
+func (g *AttemptGuard) Throttle(endpoint, ip string) time.Duration {
+	perMinute := g.limits.RequestsPerMinute
+	if perMinute <= 0 {
+		return 0
+	}
+	burst := math.Max(float64(perMinute/3), 1)
+	perSecond := float64(perMinute) / 60
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+	key := endpoint + " " + ip
+	bucket, ok := g.throttles[key]
+	if !ok {
+		bucket = &throttleBucket{tokens: burst, last: now}
+		g.throttles[key] = bucket
+	}
+	bucket.tokens = math.Min(burst, bucket.tokens+now.Sub(bucket.last).Seconds()*perSecond)
+	bucket.last = now
+	g.prune(now)
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return 0
+	}
+	g.metrics.Throttled++
+	return time.Duration((1 - bucket.tokens) / perSecond * float64(time.Second))
+}
+
+// RejectThrottled answers a request over its rate limit with 429 and a
+// Retry-After header
+func (g *AttemptGuard) RejectThrottled(w http.ResponseWriter, r *http.Request, wait time.Duration) {
+	seconds := int((wait + time.Second - 1) / time.Second)
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	WriteJSONError(w, r, http.StatusTooManyRequests, "slow_down",
+		fmt.Sprintf("Too many requests. Try again in %d seconds.", seconds), "")
+}
+
+// Replay records an authorization code presented again after it was
+// redeemed, which suggests it leaked
+func (g *AttemptGuard) Replay(endpoint, ip, code string) {
+	g.mu.Lock()
+	g.metrics.Replays++
+	g.mu.Unlock()
+	log.Printf("[AUDIT] oauth_code_replay endpoint=%s ip=%s code=%s", endpoint, ip, redactCode(code))
+	g.Failure(endpoint, ip, code)
+}
+
+// Check returns how long the IP or code remains locked out, zero when the
+// attempt may proceed
+func (g *AttemptGuard) Check(ip, code string) time.Duration {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	wait := g.remaining(g.ips[ip], now)
+	if code != "" {
+		if codeWait := g.remaining(g.codes[code], now); codeWait > wait {
+			wait = codeWait
+		}
+	}
+	if wait > 0 {
+		g.metrics.Rejected++
+	}
+	return wait
+}
+
+// Failure records a failed attempt from ip, against code when it is known
+func (g *AttemptGuard) Failure(endpoint, ip, code string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	g.metrics.Failures++
+	g.fail(g.ips, ip, g.limits.IPFailures, endpoint, "ip", ip, now)
+	if code != "" {
+		g.fail(g.codes, code, g.limits.CodeFailures, endpoint, "code", redactCode(code), now)
+	}
+	g.prune(now)
+}
+
+// Success clears the failures counted for ip and code. Earlier lockouts
+// still count toward the next lockout's length.
+func (g *AttemptGuard) Success(ip, code string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if record, ok := g.ips[ip]; ok {
+		record.failures = 0
+	}
+	delete(g.codes, code)
+}
+
+// Reject answers a locked-out request with 429 and a Retry-After header
+func (g *AttemptGuard) Reject(w http.ResponseWriter, r *http.Request, wait time.Duration) {
+	seconds := int((wait + time.Second - 1) / time.Second)
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	WriteJSONError(w, r, http.StatusTooManyRequests, "temporarily_unavailable",
+		fmt.Sprintf("Too many failed attempts. Try again in %d seconds.", seconds), "")
+}
+
+// Metrics returns the guard's counters
+func (g *AttemptGuard) Metrics() GuardMetrics {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	metrics := g.metrics
+	now := g.now()
+	for _, records := range []map[string]*attemptRecord{g.ips, g.codes} {
+		for _, record := range records {
+			if now.Before(record.lockedUntil) {
+				metrics.ActiveLockouts++
+			}
+		}
+	}
+	return metrics
+}
+
+// Lockouts returns recent lockout audit entries, oldest first
+func (g *AttemptGuard) Lockouts() []LockoutEvent {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]LockoutEvent{}, g.audit...)
+}
+
+// HandleMetrics serves the guard's counters as JSON. It reports counts
+// only, never IPs or codes, so it is safe to expose alongside /health.
+func (g *AttemptGuard) HandleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(g.Metrics()); err != nil {
+		log.Printf("Failed to encode guard metrics: %v", err)
+	}
+}
+
+// fail counts one failure for key and locks it out at the limit; callers
+// must hold g.mu
+func (g *AttemptGuard) fail(records map[string]*attemptRecord, key string, limit int, endpoint, kind, subject string, now time.Time) {
+	record, ok := records[key]
+	if !ok {
+		record = &attemptRecord{}
+		records[key] = record
+	}
+	if now.Sub(record.windowStart) > g.limits.Window {
+		record.failures = 0
+		record.windowStart = now
+	}
+	record.failures++
+	if record.failures < limit {
+		return
+	}
+
+	duration := g.limits.Lockout << record.lockouts
+	if duration > g.limits.MaxLockout || duration <= 0 {
+		duration = g.limits.MaxLockout
+	}
+	record.lockouts++
+	record.lockedUntil = now.Add(duration)
+	g.metrics.Lockouts++
+
+	event := LockoutEvent{
+		Time:     now.UTC(),
+		Endpoint: endpoint,
+		Kind:     kind,
+		Subject:  subject,
+		Failures: record.failures,
+		Duration: duration,
+	}
+	record.failures = 0
+	g.audit = append(g.audit, event)
+	if len(g.audit) > maxAuditEntries {
+		g.audit = g.audit[len(g.audit)-maxAuditEntries:]
+	}
+	if data, err := json.Marshal(event); err == nil {
+		log.Printf("[AUDIT] oauth_lockout %s", data)
+	}
+}
+
+// remaining returns how long a record stays locked; callers must hold g.mu
+func (g *AttemptGuard) remaining(record *attemptRecord, now time.Time) time.Duration {
+	if record == nil || !now.Before(record.lockedUntil) {
+		return 0
+	}
+	return record.lockedUntil.Sub(now)
+}
+
+// prune drops records that are neither locked nor counting failures, at
+// most once a minute; callers must hold g.mu
+func (g *AttemptGuard) prune(now time.Time) {
+	if now.Sub(g.lastPrune) < time.Minute {
+		return
+	}
+	g.lastPrune = now
+	for _, records := range []map[string]*attemptRecord{g.ips, g.codes} {
+		for key, record := range records {
+			idle := now.Sub(record.windowStart) > g.limits.Window && !now.Before(record.lockedUntil)
+			// Keep lockout history until a full max lockout has passed
+			if idle && now.Sub(record.lockedUntil) > g.limits.MaxLockout {
+				delete(records, key)
+			}
+		}
+	}
+	// A bucket idle for a minute has refilled, so it can be recreated
+	for key, bucket := range g.throttles {
+		if now.Sub(bucket.last) > time.Minute {
+			delete(g.throttles, key)
+		}
+	}
+}
+
+// ClientIP returns the requesting client's IP. On Fly.io the edge sets
+// Fly-Client-IP; elsewhere forwarded headers are ignored because clients
+// could use them to dodge per-IP limits.
+func ClientIP(r *http.Request) string {
+	if os.Getenv("FLY_APP_NAME") != "" {
+		if ip := r.Header.Get("Fly-Client-IP"); ip != "" {
+			return ip
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// redactCode keeps enough of a code to correlate audit entries without
+// recording a usable code
+func redactCode(code string) string {
+	if len(code) <= 8 {
+		return "****"
+	}
+	return code[:8] + "..."
+}
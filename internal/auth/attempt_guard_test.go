@@ -0,0 +1,338 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestGuard(now *time.Time) *AttemptGuard {
+	guard := NewAttemptGuard(GuardLimits{
+		IPFailures:   3,
+		CodeFailures: 2,
+		Window:       10 * time.Minute,
+		Lockout:      time.Minute,
+		MaxLockout:   4 * time.Minute,
+	})
+	guard.now = func() time.Time { return *now }
+	return guard
+}
+
+func TestAttemptGuard(t *testing.T) {
+	t.Logf("Importance: Authorization codes are bearer secrets; without attempt limits an attacker can keep guessing them against the token and check-auth endpoints.")
+
+	t.Run("locks out an IP after repeated failures", func(t *testing.T) {
+		t.Logf("  > Why it's important: Guessing many different codes from one client is the main brute-force pattern.")
+		now := time.Unix(1_700_000_000, 0)
+		guard := newTestGuard(&now)
+
+		for i, code := range []string{"a", "b", "c"} {
+			if wait := guard.Check("198.51.100.7", code); wait != 0 {
+				t.Fatalf("Attempt %d rejected early", i+1)
+			}
+			guard.Failure("token", "198.51.100.7", code)
+		}
+
+		if wait := guard.Check("198.51.100.7", "d"); wait != time.Minute {
+			t.Errorf("Expected a 1m lockout, got %s", wait)
+		}
+		if wait := guard.Check("203.0.113.9", "d"); wait != 0 {
+			t.Errorf("Expected other IPs to be unaffected, got %s", wait)
+		}
+
+		now = now.Add(time.Minute)
+		if wait := guard.Check("198.51.100.7", "d"); wait != 0 {
+			t.Errorf("Expected lockout to expire, got %s", wait)
+		}
+	})
+
+	t.Run("locks out a code attacked from many IPs", func(t *testing.T) {
+		t.Logf("  > Why it's important: Guessing a PKCE verifier for one stolen code from rotating IPs must still be stopped.")
+		now := time.Unix(1_700_000_000, 0)
+		guard := newTestGuard(&now)
+
+		guard.Failure("token", "198.51.100.1", "stolen-code")
+		guard.Failure("token", "198.51.100.2", "stolen-code")
+
+		if wait := guard.Check("198.51.100.3", "stolen-code"); wait == 0 {
+			t.Error("Expected the code to be locked for a fresh IP")
+		}
+		if wait := guard.Check("198.51.100.3", "other-code"); wait != 0 {
+			t.Errorf("Expected other codes to be unaffected, got %s", wait)
+		}
+	})
+
+	t.Run("doubles repeat lockouts up to the cap", func(t *testing.T) {
+		t.Logf("  > Why it's important: Backoff makes sustained guessing slower with every round instead of resuming at full speed.")
+		now := time.Unix(1_700_000_000, 0)
+		guard := newTestGuard(&now)
+
+		var waits []time.Duration
+		for round := 0; round < 4; round++ {
+			for i := 0; i < 3; i++ {
+				guard.Failure("check-auth", "198.51.100.7", "")
+			}
+			wait := guard.Check("198.51.100.7", "")
+			waits = append(waits, wait)
+			now = now.Add(wait)
+		}
+
+		want := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 4 * time.Minute}
+		for i := range want {
+			if waits[i] != want[i] {
+				t.Errorf("Round %d: expected %s lockout, got %s", i+1, want[i], waits[i])
+			}
+		}
+	})
+
+	t.Run("success clears counted failures", func(t *testing.T) {
+		t.Logf("  > Why it's important: A user who mistypes a few times and then succeeds must not be locked out later.")
+		now := time.Unix(1_700_000_000, 0)
+		guard := newTestGuard(&now)
+
+		guard.Failure("token", "198.51.100.7", "x")
+		guard.Failure("token", "198.51.100.7", "y")
+		guard.Success("198.51.100.7", "good")
+		guard.Failure("token", "198.51.100.7", "z")
+
+		if wait := guard.Check("198.51.100.7", ""); wait != 0 {
+			t.Errorf("Expected no lockout after a success, got %s", wait)
+		}
+	})
+
+	t.Run("records metrics and redacted audit entries", func(t *testing.T) {
+		t.Logf("  > Why it's important: Operators need to see lockouts without the audit trail leaking usable codes.")
+		now := time.Unix(1_700_000_000, 0)
+		guard := newTestGuard(&now)
+
+		guard.Failure("token", "198.51.100.7", "0123456789abcdef")
+		guard.Failure("token", "198.51.100.7", "0123456789abcdef")
+		guard.Check("198.51.100.7", "0123456789abcdef")
+
+		metrics := guard.Metrics()
+		if metrics.Failures != 2 || metrics.Lockouts != 1 || metrics.Rejected != 1 || metrics.ActiveLockouts != 1 {
+			t.Errorf("Unexpected metrics %+v", metrics)
+		}
+		events := guard.Lockouts()
+		if len(events) != 1 || events[0].Kind != "code" || events[0].Endpoint != "token" {
+			t.Fatalf("Expected one code lockout, got %+v", events)
+		}
+		if events[0].Subject != "01234567..." {
+			t.Errorf("Expected redacted code, got %q", events[0].Subject)
+		}
+
+		w := httptest.NewRecorder()
+		guard.HandleMetrics(w, httptest.NewRequest("GET", "/health/oauth", nil))
+		if strings.Contains(w.Body.String(), "198.51.100.7") || strings.Contains(w.Body.String(), "0123456789") {
+			t.Errorf("Metrics endpoint leaked a subject: %s", w.Body.String())
+		}
+	})
+}
+
+func TestTokenEndpointLockout(t *testing.T) {
+	t.Logf("Importance: The protection only helps if /oauth/token actually consults it before checking codes.")
+	adapter := NewOAuthAdapter("http://localhost:8080", 9091)
+	t.Cleanup(func() {
+		if err := adapter.Close(); err != nil {
+			t.Logf("Failed to close adapter: %v", err)
+		}
+	})
+	adapter.guard = NewAttemptGuard(GuardLimits{IPFailures: 2, CodeFailures: 5, Window: time.Minute, Lockout: time.Minute, MaxLockout: time.Hour})
+
+	post := func(code string) *httptest.ResponseRecorder {
+		form := url.Values{"grant_type": {"authorization_code"}, "code": {code}}
+		req := httptest.NewRequest("POST", "/oauth/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		adapter.HandleToken(w, req)
+		return w
+	}
+
+	for _, code := range []string{"guess-1", "guess-2"} {
+		if w := post(code); w.Code != http.StatusBadRequest {
+			t.Fatalf("Expected 400 for a wrong code, got %d", w.Code)
+		}
+	}
+
+	w := post("guess-3")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429 after the limit, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") != "60" {
+		t.Errorf("Expected Retry-After 60, got %q", w.Header().Get("Retry-After"))
+	}
+	var body ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Error != "temporarily_unavailable" {
+		t.Errorf("Expected JSON temporarily_unavailable error, got %s", w.Body.String())
+	}
+}
+
+func TestRequestThrottling(t *testing.T) {
+	t.Logf("Importance: Polling and guessing clients must not be able to hammer the token endpoint, even with valid-looking requests.")
+
+	t.Run("allows a burst then throttles", func(t *testing.T) {
+		t.Logf("  > Why it's important: Normal polling fits in the allowance, a flood does not.")
+		now := time.Unix(1_700_000_000, 0)
+		guard := newTestGuard(&now)
+		guard.limits.RequestsPerMinute = 30 // A burst of 10, one more every 2 seconds
+
+		for i := 0; i < 10; i++ {
+			if wait := guard.Throttle("token", "198.51.100.7"); wait != 0 {
+				t.Fatalf("Request %d throttled early", i+1)
+			}
+		}
+		if wait := guard.Throttle("token", "198.51.100.7"); wait != 2*time.Second {
+			t.Errorf("Expected a 2s wait, got %s", wait)
+		}
+		if wait := guard.Throttle("check-auth", "198.51.100.7"); wait != 0 {
+			t.Errorf("Expected other endpoints to be unaffected, got %s", wait)
+		}
+		if wait := guard.Throttle("token", "203.0.113.9"); wait != 0 {
+			t.Errorf("Expected other IPs to be unaffected, got %s", wait)
+		}
+
+		now = now.Add(2 * time.Second)
+		if wait := guard.Throttle("token", "198.51.100.7"); wait != 0 {
+			t.Errorf("Expected the allowance to refill, got %s", wait)
+		}
+		if guard.Metrics().Throttled != 1 {
+			t.Errorf("Expected 1 throttled request counted, got %d", guard.Metrics().Throttled)
+		}
+	})
+
+	t.Run("token endpoint answers 429", func(t *testing.T) {
+		t.Logf("  > Why it's important: Clients need Retry-After to know when to come back.")
+		t.Setenv("GO_TEST", "1")
+		t.Setenv("OAUTH_RATE_LIMIT", "3")
+		adapter := NewOAuthAdapter("http://localhost:8080", 9091)
+		defer adapter.Close()
+
+		var w *httptest.ResponseRecorder
+		for i := 0; i < 2; i++ {
+			w = httptest.NewRecorder()
+			adapter.HandleToken(w, httptest.NewRequest("POST", "/oauth/token", nil))
+		}
+		if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "20" {
+			t.Errorf("Expected 429 with Retry-After 20, got %d %q", w.Code, w.Header().Get("Retry-After"))
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Logf("  > Why it's important: Deployments behind their own rate limiter can turn this one off.")
+		t.Setenv("OAUTH_RATE_LIMIT", "0")
+		guard := NewAttemptGuard(GuardLimitsFromEnv())
+		for i := 0; i < 100; i++ {
+			if wait := guard.Throttle("token", "198.51.100.7"); wait != 0 {
+				t.Fatalf("Expected no limit, throttled at request %d", i+1)
+			}
+		}
+	})
+}
+
+func TestCodeReplay(t *testing.T) {
+	t.Logf("Importance: An authorization code seen twice has leaked; the second use must fail and be reported.")
+	t.Setenv("GO_TEST", "1")
+	t.Setenv("TOKEN_DB_PATH", "")
+	t.Setenv("OAUTH_DB_PATH", "")
+	adapter := NewOAuthAdapter("http://localhost:8080", 9091)
+	defer adapter.Close()
+
+	post := func(code string) *httptest.ResponseRecorder {
+		form := url.Values{"grant_type": {"authorization_code"}, "code": {code}}
+		req := httptest.NewRequest("POST", "/oauth/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		adapter.HandleToken(w, req)
+		return w
+	}
+
+	t.Run("second use is a replay", func(t *testing.T) {
+		t.Logf("  > Why it's important: Replays are told apart from typos so they show up in metrics and the audit log.")
+		adapter.saveCode(&AuthCode{Code: "once", RTMAPIKey: "rtm-key", ExpiresAt: time.Now().Add(time.Minute)})
+		if w := post("once"); w.Code != http.StatusOK {
+			t.Fatalf("Expected the first use to succeed, got %d", w.Code)
+		}
+		w := post("once")
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "already been used") {
+			t.Errorf("Expected the replay refused, got %d %s", w.Code, w.Body.String())
+		}
+		if adapter.Guard().Metrics().Replays != 1 {
+			t.Errorf("Expected 1 replay counted, got %d", adapter.Guard().Metrics().Replays)
+		}
+	})
+
+	t.Run("revokes tokens issued from the code", func(t *testing.T) {
+		t.Logf("  > Why it's important: Whoever holds the leaked code may already hold its tokens; RFC 6749 4.1.2 says to revoke them.")
+		adapter.saveCode(&AuthCode{Code: "leaked", RTMAPIKey: "rtm-key", ExpiresAt: time.Now().Add(time.Minute)})
+		var first, renewed TokenResponse
+		_ = json.NewDecoder(post("leaked").Body).Decode(&first)
+
+		form := url.Values{"grant_type": {"refresh_token"}, "refresh_token": {first.RefreshToken}}
+		req := httptest.NewRequest("POST", "/oauth/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		adapter.HandleToken(w, req)
+		_ = json.NewDecoder(w.Body).Decode(&renewed)
+		if renewed.AccessToken == "" || renewed.RefreshToken == "" {
+			t.Fatalf("Expected the refresh to succeed, got %d", w.Code)
+		}
+
+		if w := post("leaked"); w.Code != http.StatusBadRequest {
+			t.Fatalf("Expected the replay refused, got %d", w.Code)
+		}
+		for _, token := range []string{first.AccessToken, renewed.AccessToken} {
+			if _, err := adapter.ValidateToken("Bearer " + token); err == nil {
+				t.Errorf("Expected access token %s... revoked", token[:8])
+			}
+		}
+		if _, ok, _ := adapter.refresh.Redeem(renewed.RefreshToken); ok {
+			t.Error("Expected the renewed refresh token revoked")
+		}
+	})
+
+	t.Run("concurrent use", func(t *testing.T) {
+		t.Logf("  > Why it's important: Two requests racing with one code must not both get tokens.")
+		adapter.saveCode(&AuthCode{Code: "raced", RTMAPIKey: "rtm-key", ExpiresAt: time.Now().Add(time.Minute)})
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		succeeded := 0
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if post("raced").Code == http.StatusOK {
+					mu.Lock()
+					succeeded++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+		if succeeded != 1 {
+			t.Errorf("Expected exactly one exchange to succeed, got %d", succeeded)
+		}
+	})
+}
+
+func TestClientIP(t *testing.T) {
+	t.Logf("Importance: Per-IP limits are only as good as the IP; spoofable headers must not be trusted off Fly.io.")
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "198.51.100.7:54321"
+	req.Header.Set("Fly-Client-IP", "203.0.113.1")
+
+	t.Setenv("FLY_APP_NAME", "")
+	if ip := ClientIP(req); ip != "198.51.100.7" {
+		t.Errorf("Expected remote address off Fly, got %s", ip)
+	}
+
+	t.Setenv("FLY_APP_NAME", "cowpilot")
+	if ip := ClientIP(req); ip != "203.0.113.1" {
+		t.Errorf("Expected Fly-Client-IP on Fly, got %s", ip)
+	}
+}
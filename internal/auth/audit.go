@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"log"
+	"net/http"
+)
+
+// Authentication events kept in the audit log
+const (
+	AuditAuthorize        = "authorize"         // A sign-in form was submitted or completed
+	AuditTokenIssued      = "token_issued"      // An access token was granted
+	AuditValidationFailed = "validation_failed" // A bearer token was refused
+	AuditRevoked          = "revoked"           // A token was revoked
+)
+
+// Audit outcomes
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// AuditLog stores authentication events for operators investigating
+// suspicious access. audit.Log implements it.
+type AuditLog interface {
+	LogAuthEvent(event, outcome, clientID, ip, detail string) error
+}
+
+// Auditor records authentication events in an AuditLog as well as the
+// process log. A nil Auditor only logs.
+type Auditor struct {
+	log AuditLog
+}
+
+// NewAuditor records events in auditLog
+func NewAuditor(auditLog AuditLog) *Auditor {
+	return &Auditor{log: auditLog}
+}
+
+// Record notes event for the request r, which may be nil outside a
+// request. Tokens and keys must never be passed in detail.
+func (a *Auditor) Record(r *http.Request, event, outcome, clientID, detail string) {
+	ip := ""
+	if r != nil {
+		ip = ClientIP(r)
+	}
+	log.Printf("[AUDIT] auth_event event=%s outcome=%s client_id=%s ip=%s detail=%q", event, outcome, clientID, ip, detail)
+	if a == nil || a.log == nil {
+		return
+	}
+	if err := a.log.LogAuthEvent(event, outcome, clientID, ip, detail); err != nil {
+		log.Printf("[AUDIT] Failed to store auth event: %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordedEvent is one call to fakeAuditLog.LogAuthEvent
+type recordedEvent struct {
+	event, outcome, clientID, ip, detail string
+}
+
+type fakeAuditLog struct {
+	events []recordedEvent
+}
+
+func (f *fakeAuditLog) LogAuthEvent(event, outcome, clientID, ip, detail string) error {
+	f.events = append(f.events, recordedEvent{event, outcome, clientID, ip, detail})
+	return nil
+}
+
+// last returns the most recent event of kind, if any
+func (f *fakeAuditLog) last(kind string) (recordedEvent, bool) {
+	for i := len(f.events) - 1; i >= 0; i-- {
+		if f.events[i].event == kind {
+			return f.events[i], true
+		}
+	}
+	return recordedEvent{}, false
+}
+
+func TestAuditLog(t *testing.T) {
+	t.Logf("Importance: Operators investigate suspicious access from the audit log, so every sign-in, token and refusal must reach it.")
+
+	t.Setenv("GO_TEST", "1")
+	t.Setenv("TOKEN_DB_PATH", "")
+	t.Setenv("OAUTH_DB_PATH", "")
+	adapter := NewOAuthAdapter("http://localhost:8080", 9090)
+	defer adapter.Close()
+	auditLog := &fakeAuditLog{}
+	adapter.UseAuditLog(auditLog)
+
+	req := httptest.NewRequest("POST", "/oauth/register", strings.NewReader(`{"redirect_uris":["https://app.example.com/cb"]}`))
+	w := httptest.NewRecorder()
+	adapter.HandleRegister(w, req)
+	var registration map[string]interface{}
+	_ = json.NewDecoder(w.Body).Decode(&registration)
+	clientID, _ := registration["client_id"].(string)
+	secret, _ := registration["client_secret"].(string)
+
+	var accessToken string
+
+	t.Run("authorize attempts", func(t *testing.T) {
+		t.Logf("  > Why it's important: Repeated failed sign-ins are the first sign of someone probing the form.")
+		form := url.Values{"api_key": {"rtm-key"}, "csrf_state": {"forged"}}
+		req := httptest.NewRequest("POST", "/oauth/authorize", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(&http.Cookie{Name: "csrf_token", Value: "real"})
+		req.RemoteAddr = "203.0.113.7:4000"
+		adapter.HandleAuthorize(httptest.NewRecorder(), req)
+		event, ok := auditLog.last(AuditAuthorize)
+		if !ok || event.outcome != AuditFailure || event.ip != "203.0.113.7" {
+			t.Errorf("Expected a failed authorize attempt from 203.0.113.7, got %+v", event)
+		}
+	})
+
+	t.Run("token issued", func(t *testing.T) {
+		adapter.saveCode(&AuthCode{Code: "audit-code", RTMAPIKey: "rtm-key", ClientID: clientID, ExpiresAt: time.Now().Add(time.Minute)})
+		form := url.Values{"grant_type": {"authorization_code"}, "code": {"audit-code"}, "client_id": {clientID}, "client_secret": {secret}}
+		req := httptest.NewRequest("POST", "/oauth/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		adapter.HandleToken(w, req)
+		var tokens TokenResponse
+		_ = json.NewDecoder(w.Body).Decode(&tokens)
+		accessToken = tokens.AccessToken
+
+		event, ok := auditLog.last(AuditTokenIssued)
+		if !ok || event.outcome != AuditSuccess || event.clientID != clientID || event.detail != "authorization_code" {
+			t.Errorf("Expected a token issued to %s, got %+v", clientID, event)
+		}
+	})
+
+	t.Run("validation failures", func(t *testing.T) {
+		t.Logf("  > Why it's important: Guessed or stolen-and-revoked tokens show up only as refused bearer tokens.")
+		handler := Middleware(adapter)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		req := httptest.NewRequest("POST", "/mcp", nil)
+		req.Header.Set("Authorization", "Bearer guessed-token")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		if event, ok := auditLog.last(AuditValidationFailed); !ok || event.outcome != AuditFailure {
+			t.Errorf("Expected a refused bearer token recorded, got %+v", event)
+		}
+		for _, event := range auditLog.events {
+			if strings.Contains(event.detail, "guessed-token") {
+				t.Errorf("Expected tokens kept out of the audit log, got %+v", event)
+			}
+		}
+	})
+
+	t.Run("revocation", func(t *testing.T) {
+		form := url.Values{"token": {accessToken}}
+		req := httptest.NewRequest("POST", "/oauth/revoke", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		adapter.HandleRevoke(httptest.NewRecorder(), req)
+		if event, ok := auditLog.last(AuditRevoked); !ok || event.outcome != AuditSuccess {
+			t.Errorf("Expected the revocation recorded, got %+v", event)
+		}
+	})
+
+	t.Run("no audit log", func(t *testing.T) {
+		t.Logf("  > Why it's important: Servers without debug storage must still authenticate, logging events only.")
+		var auditor *Auditor
+		auditor.Record(httptest.NewRequest("GET", "/", nil), AuditAuthorize, AuditFailure, "", "")
+	})
+}
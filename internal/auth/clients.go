@@ -0,0 +1,410 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vcto/mcp-adapters/internal/kv"
+)
+
+// clientBucket is the kv bucket holding registered clients
+const clientBucket = "oauth_clients"
+
+// Token endpoint authentication methods (RFC 7591 section 2)
+const (
+	AuthMethodNone        = "none"
+	AuthMethodSecretPost  = "client_secret_post"
+	AuthMethodSecretBasic = "client_secret_basic"
+)
+
+// RegisteredClient is a client registered through dynamic client
+// registration (RFC 7591). Its secret and registration access token are
+// kept as hashes.
+type RegisteredClient struct {
+	ClientID                string    `json:"client_id"`
+	ClientName              string    `json:"client_name,omitempty"`
+	RedirectURIs            []string  `json:"redirect_uris"`
+	GrantTypes              []string  `json:"grant_types"`
+	TokenEndpointAuthMethod string    `json:"token_endpoint_auth_method"`
+	SecretHash              string    `json:"secret_hash,omitempty"`
+	RegistrationTokenHash   string    `json:"registration_token_hash"`
+	IssuedAt                time.Time `json:"issued_at"`
+}
+
+// Confidential reports whether the client authenticates with a secret
+func (c *RegisteredClient) Confidential() bool {
+	return c.TokenEndpointAuthMethod != AuthMethodNone
+}
+
+// AllowsRedirect reports whether redirectURI was registered for the client
+func (c *RegisteredClient) AllowsRedirect(redirectURI string) bool {
+	for _, registered := range c.RedirectURIs {
+		if registered == redirectURI {
+			return true
+		}
+	}
+	return false
+}
+
+// clientMetadata is what a registration or update request may set
+type clientMetadata struct {
+	ClientName              string   `json:"client_name"`
+	RedirectURIs            []string `json:"redirect_uris"`
+	GrantTypes              []string `json:"grant_types"`
+	ResponseTypes           []string `json:"response_types"`
+	TokenEndpointAuthMethod string   `json:"token_endpoint_auth_method"`
+}
+
+// ClientError is an OAuth error about the client making a request, with the
+// HTTP status to answer with
+type ClientError struct {
+	Status      int
+	Code        string
+	Description string
+}
+
+func (e *ClientError) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+// Clients registers OAuth clients and checks them at the authorize and token
+// endpoints. Registrations are kept in a kv store so they survive restarts.
+// Client IDs that were never registered are let through unless Require is
+// set, since some clients are configured with a client_id and never
+// register.
+type Clients struct {
+	clients   *kv.Bucket[RegisteredClient]
+	serverURL string
+	idPrefix  string
+	// Require rejects client IDs that were never registered
+	Require bool
+}
+
+// NewClients keeps registrations in store, minting client IDs starting with
+// idPrefix
+func NewClients(store kv.Store, serverURL, idPrefix string) *Clients {
+	return &Clients{
+		clients:   kv.NewBucket[RegisteredClient](store, clientBucket),
+		serverURL: serverURL,
+		idPrefix:  idPrefix,
+		Require:   os.Getenv("OAUTH_REQUIRE_REGISTERED_CLIENTS") == "true",
+	}
+}
+
+// Lookup returns the registered client with clientID, if there is one
+func (c *Clients) Lookup(clientID string) (*RegisteredClient, bool, error) {
+	if clientID == "" {
+		return nil, false, nil
+	}
+	client, ok, err := c.clients.Get(clientID)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	return &client, true, nil
+}
+
+// CheckAuthorize checks an authorization request's client and redirect URI.
+// A registered client may only be sent back to a URI it registered. Errors
+// must be shown to the user rather than sent to the redirect URI.
+func (c *Clients) CheckAuthorize(clientID, redirectURI string) *ClientError {
+	client, ok, err := c.Lookup(clientID)
+	if err != nil {
+		log.Printf("[OAuth] Failed to read client %s: %v", clientID, err)
+		return &ClientError{http.StatusServiceUnavailable, "temporarily_unavailable", "The client registration could not be checked. Try again."}
+	}
+	if !ok {
+		if c.Require {
+			return &ClientError{http.StatusBadRequest, "invalid_client", "This app is not registered with the server. Ask its developer to register it, then try again."}
+		}
+		return nil
+	}
+	if redirectURI == "" && len(client.RedirectURIs) == 1 {
+		return nil
+	}
+	if !client.AllowsRedirect(redirectURI) {
+		return &ClientError{http.StatusBadRequest, "invalid_request", "The redirect_uri was not registered for this app, so you will not be sent back to it."}
+	}
+	return nil
+}
+
+// Authenticate checks the client credentials of a token request, sent in
+// the form or with HTTP Basic authentication, and returns the client ID.
+// Registered confidential clients must present their secret.
+func (c *Clients) Authenticate(r *http.Request) (string, *ClientError) {
+	clientID, secret, basic := r.BasicAuth()
+	if basic {
+		// RFC 6749 section 2.3.1: credentials are form-encoded before Basic encoding
+		clientID, _ = url.QueryUnescape(clientID)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		clientID = r.FormValue("client_id")
+		secret = r.FormValue("client_secret")
+	}
+
+	client, ok, err := c.Lookup(clientID)
+	if err != nil {
+		log.Printf("[OAuth] Failed to read client %s: %v", clientID, err)
+		return "", &ClientError{http.StatusServiceUnavailable, "temporarily_unavailable", "The client registration could not be checked. Try again."}
+	}
+	if !ok {
+		if c.Require {
+			return "", &ClientError{http.StatusUnauthorized, "invalid_client", "Unknown client"}
+		}
+		return clientID, nil
+	}
+	if client.Confidential() {
+		presented := TokenKey(secret)
+		if secret == "" || subtle.ConstantTimeCompare([]byte(presented), []byte(client.SecretHash)) != 1 {
+			return "", &ClientError{http.StatusUnauthorized, "invalid_client", "Client authentication failed"}
+		}
+	}
+	return clientID, nil
+}
+
+// MayRedeem reports whether the client authenticated as clientID may redeem
+// a code or refresh token issued to issuedTo. Requests that name no client
+// are allowed for public and unregistered clients, as before clients were
+// registered, but a confidential client's grants need its secret.
+func (c *Clients) MayRedeem(issuedTo, clientID string) bool {
+	if issuedTo == "" || clientID == issuedTo {
+		return true
+	}
+	if clientID != "" {
+		return false
+	}
+	client, ok, err := c.Lookup(issuedTo)
+	if err != nil {
+		log.Printf("[OAuth] Failed to read client %s: %v", issuedTo, err)
+		return false
+	}
+	return !ok || !client.Confidential()
+}
+
+// HandleRegister implements dynamic client registration (RFC 7591)
+func (c *Clients) HandleRegister(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		WriteJSONError(w, r, http.StatusMethodNotAllowed, "invalid_request", "Registration requires POST", "")
+		return
+	}
+
+	var metadata clientMetadata
+	if err := json.NewDecoder(r.Body).Decode(&metadata); err != nil {
+		WriteJSONError(w, r, http.StatusBadRequest, "invalid_client_metadata", "Registration request is not valid JSON", "")
+		return
+	}
+
+	client := RegisteredClient{
+		ClientID: c.idPrefix + strings.ReplaceAll(uuid.New().String(), "-", ""),
+		IssuedAt: time.Now().UTC(),
+	}
+	if cerr := applyMetadata(&client, metadata); cerr != nil {
+		WriteJSONError(w, r, cerr.Status, cerr.Code, cerr.Description, "")
+		return
+	}
+
+	secret := ""
+	if client.Confidential() {
+		secret = uuid.New().String()
+		client.SecretHash = TokenKey(secret)
+	}
+	registrationToken := uuid.New().String()
+	client.RegistrationTokenHash = TokenKey(registrationToken)
+
+	if err := c.clients.Put(client.ClientID, client, 0); err != nil {
+		log.Printf("[OAuth] Failed to save client registration: %v", err)
+		WriteJSONError(w, r, http.StatusServiceUnavailable, "temporarily_unavailable", "The registration could not be saved. Try again.", "")
+		return
+	}
+	log.Printf("[AUDIT] OAuth client registered client_id=%s name=%q", client.ClientID, client.ClientName)
+
+	response := c.describe(&client)
+	response["registration_access_token"] = registrationToken
+	if secret != "" {
+		response["client_secret"] = secret
+		response["client_secret_expires_at"] = 0 // Never expires
+	}
+	writeClientJSON(w, http.StatusCreated, response)
+}
+
+// HandleConfiguration implements client configuration (RFC 7592) at
+// /oauth/register/{client_id}: GET reads the registration, PUT replaces its
+// metadata and DELETE removes it. Requests must carry the registration
+// access token issued at registration.
+func (c *Clients) HandleConfiguration(w http.ResponseWriter, r *http.Request) {
+	clientID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/oauth/register/"), "/")
+	client, ok, err := c.Lookup(clientID)
+	if err != nil {
+		log.Printf("[OAuth] Failed to read client %s: %v", clientID, err)
+		WriteJSONError(w, r, http.StatusServiceUnavailable, "temporarily_unavailable", "The registration could not be read. Try again.", "")
+		return
+	}
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" || subtle.ConstantTimeCompare([]byte(TokenKey(token)), []byte(client.RegistrationTokenHash)) != 1 {
+		// Unknown clients and wrong tokens look the same, per RFC 7592 section 2
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		WriteJSONError(w, r, http.StatusUnauthorized, "invalid_token", "Missing or invalid registration access token", "")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeClientJSON(w, http.StatusOK, c.describe(client))
+
+	case http.MethodPut:
+		var metadata clientMetadata
+		if err := json.NewDecoder(r.Body).Decode(&metadata); err != nil {
+			WriteJSONError(w, r, http.StatusBadRequest, "invalid_client_metadata", "Update request is not valid JSON", "")
+			return
+		}
+		wasConfidential := client.Confidential()
+		if cerr := applyMetadata(client, metadata); cerr != nil {
+			WriteJSONError(w, r, cerr.Status, cerr.Code, cerr.Description, "")
+			return
+		}
+
+		// A client becoming confidential needs a secret; one becoming
+		// public has no further use for its old one
+		secret := ""
+		switch {
+		case client.Confidential() && !wasConfidential:
+			secret = uuid.New().String()
+			client.SecretHash = TokenKey(secret)
+		case !client.Confidential():
+			client.SecretHash = ""
+		}
+
+		if err := c.clients.Put(client.ClientID, *client, 0); err != nil {
+			log.Printf("[OAuth] Failed to save client registration: %v", err)
+			WriteJSONError(w, r, http.StatusServiceUnavailable, "temporarily_unavailable", "The registration could not be saved. Try again.", "")
+			return
+		}
+		log.Printf("[AUDIT] OAuth client updated client_id=%s", client.ClientID)
+
+		response := c.describe(client)
+		if secret != "" {
+			response["client_secret"] = secret
+			response["client_secret_expires_at"] = 0
+		}
+		writeClientJSON(w, http.StatusOK, response)
+
+	case http.MethodDelete:
+		if err := c.clients.Delete(client.ClientID); err != nil {
+			log.Printf("[OAuth] Failed to delete client registration: %v", err)
+			WriteJSONError(w, r, http.StatusServiceUnavailable, "temporarily_unavailable", "The registration could not be deleted. Try again.", "")
+			return
+		}
+		log.Printf("[AUDIT] OAuth client deleted client_id=%s", client.ClientID)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		WriteJSONError(w, r, http.StatusMethodNotAllowed, "invalid_request", "Use GET, PUT or DELETE", "")
+	}
+}
+
+// describe is the registration response for client, without credentials
+func (c *Clients) describe(client *RegisteredClient) map[string]interface{} {
+	response := map[string]interface{}{
+		"client_id":                  client.ClientID,
+		"client_id_issued_at":        client.IssuedAt.Unix(),
+		"redirect_uris":              client.RedirectURIs,
+		"grant_types":                client.GrantTypes,
+		"response_types":             []string{"code"},
+		"token_endpoint_auth_method": client.TokenEndpointAuthMethod,
+		"registration_client_uri":    c.serverURL + "/oauth/register/" + client.ClientID,
+	}
+	if client.ClientName != "" {
+		response["client_name"] = client.ClientName
+	}
+	return response
+}
+
+// applyMetadata validates metadata and sets it on client, filling in the
+// RFC 7591 defaults
+func applyMetadata(client *RegisteredClient, metadata clientMetadata) *ClientError {
+	if len(metadata.RedirectURIs) == 0 {
+		return &ClientError{http.StatusBadRequest, "invalid_redirect_uri", "At least one redirect_uri is required"}
+	}
+	for _, redirectURI := range metadata.RedirectURIs {
+		if err := validateRedirectURI(redirectURI); err != nil {
+			return &ClientError{http.StatusBadRequest, "invalid_redirect_uri", err.Error()}
+		}
+	}
+
+	grantTypes := metadata.GrantTypes
+	if len(grantTypes) == 0 {
+		grantTypes = []string{"authorization_code"}
+	}
+	for _, grantType := range grantTypes {
+		if grantType != "authorization_code" && grantType != "refresh_token" {
+			return &ClientError{http.StatusBadRequest, "invalid_client_metadata", fmt.Sprintf("Unsupported grant_type %q", grantType)}
+		}
+	}
+	for _, responseType := range metadata.ResponseTypes {
+		if responseType != "code" {
+			return &ClientError{http.StatusBadRequest, "invalid_client_metadata", fmt.Sprintf("Unsupported response_type %q", responseType)}
+		}
+	}
+
+	method := metadata.TokenEndpointAuthMethod
+	switch method {
+	case "":
+		method = AuthMethodSecretBasic
+	case AuthMethodNone, AuthMethodSecretPost, AuthMethodSecretBasic:
+	default:
+		return &ClientError{http.StatusBadRequest, "invalid_client_metadata", fmt.Sprintf("Unsupported token_endpoint_auth_method %q", method)}
+	}
+
+	client.ClientName = metadata.ClientName
+	client.RedirectURIs = metadata.RedirectURIs
+	client.GrantTypes = grantTypes
+	client.TokenEndpointAuthMethod = method
+	return nil
+}
+
+// validateRedirectURI accepts absolute URIs without fragments, allowing
+// plain http only for loopback addresses used by native apps
+func validateRedirectURI(redirectURI string) error {
+	u, err := url.Parse(redirectURI)
+	if err != nil || u.Scheme == "" || (u.Host == "" && u.Opaque == "" && u.Path == "") {
+		return fmt.Errorf("redirect_uri %q is not an absolute URI", redirectURI)
+	}
+	if u.Fragment != "" {
+		return fmt.Errorf("redirect_uri %q must not have a fragment", redirectURI)
+	}
+	if u.Scheme == "http" {
+		switch u.Hostname() {
+		case "localhost", "127.0.0.1", "::1":
+		default:
+			return fmt.Errorf("redirect_uri %q must use https", redirectURI)
+		}
+	}
+	return nil
+}
+
+// writeClientJSON writes a registration response
+func writeClientJSON(w http.ResponseWriter, status int, response map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to encode registration response: %v", err)
+	}
+}
+
+// WriteClientError answers a token request whose client was refused,
+// challenging for Basic credentials as RFC 6749 section 5.2 asks
+func WriteClientError(w http.ResponseWriter, r *http.Request, cerr *ClientError) {
+	if cerr.Status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+	WriteJSONError(w, r, cerr.Status, cerr.Code, cerr.Description, "")
+}
@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vcto/mcp-adapters/internal/kv"
+)
+
+func TestClientRegistration(t *testing.T) {
+	t.Logf("Importance: Registered clients must be remembered, so their secrets and redirect URIs can be checked.")
+
+	register := func(clients *Clients, body string) (int, map[string]interface{}) {
+		req := httptest.NewRequest("POST", "/oauth/register", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		clients.HandleRegister(w, req)
+		var response map[string]interface{}
+		_ = json.NewDecoder(w.Body).Decode(&response)
+		return w.Code, response
+	}
+	configure := func(clients *Clients, method, clientID, token, body string) (int, map[string]interface{}) {
+		req := httptest.NewRequest(method, "/oauth/register/"+clientID, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		clients.HandleConfiguration(w, req)
+		var response map[string]interface{}
+		_ = json.NewDecoder(w.Body).Decode(&response)
+		return w.Code, response
+	}
+	tokenRequest := func(form url.Values, user, password string) *http.Request {
+		req := httptest.NewRequest("POST", "/oauth/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if user != "" {
+			req.SetBasicAuth(url.QueryEscape(user), url.QueryEscape(password))
+		}
+		return req
+	}
+
+	t.Run("registration persists", func(t *testing.T) {
+		t.Logf("  > Why it's important: A client registered before a restart must still be recognised after it.")
+		store := kv.NewMemoryStore()
+		status, response := register(NewClients(store, "https://example.com", "test_"),
+			`{"client_name":"Claude","redirect_uris":["https://claude.ai/api/mcp/auth_callback"]}`)
+		if status != http.StatusCreated {
+			t.Fatalf("Expected 201, got %d", status)
+		}
+		clientID, _ := response["client_id"].(string)
+		if !strings.HasPrefix(clientID, "test_") || response["client_secret"] == nil || response["registration_access_token"] == nil {
+			t.Fatalf("Expected an ID, secret and registration token, got %v", response)
+		}
+		if response["registration_client_uri"] != "https://example.com/oauth/register/"+clientID {
+			t.Errorf("Unexpected registration_client_uri %v", response["registration_client_uri"])
+		}
+
+		client, ok, err := NewClients(store, "https://example.com", "test_").Lookup(clientID)
+		if err != nil || !ok {
+			t.Fatalf("Expected the client found by a new instance, ok=%v err=%v", ok, err)
+		}
+		if client.SecretHash == response["client_secret"] || client.SecretHash == "" {
+			t.Error("Expected the secret stored hashed")
+		}
+	})
+
+	t.Run("invalid metadata", func(t *testing.T) {
+		t.Logf("  > Why it's important: Codes must never be sent to a redirect URI an attacker could intercept.")
+		clients := NewClients(kv.NewMemoryStore(), "https://example.com", "")
+		for _, body := range []string{
+			`{"redirect_uris":[]}`,
+			`{"redirect_uris":["http://evil.example.com/callback"]}`,
+			`{"redirect_uris":["https://example.com/callback#frag"]}`,
+			`{"redirect_uris":["/relative"]}`,
+			`{"redirect_uris":["https://example.com/cb"],"grant_types":["password"]}`,
+			`{"redirect_uris":["https://example.com/cb"],"token_endpoint_auth_method":"private_key_jwt"}`,
+		} {
+			if status, _ := register(clients, body); status != http.StatusBadRequest {
+				t.Errorf("Expected %s rejected, got %d", body, status)
+			}
+		}
+		if status, _ := register(clients, `{"redirect_uris":["http://127.0.0.1:9/callback"],"token_endpoint_auth_method":"none"}`); status != http.StatusCreated {
+			t.Errorf("Expected a loopback redirect accepted, got %d", status)
+		}
+	})
+
+	t.Run("authorize checks redirect", func(t *testing.T) {
+		t.Logf("  > Why it's important: A registered client's codes may only go to the URIs it registered.")
+		clients := NewClients(kv.NewMemoryStore(), "https://example.com", "")
+		_, response := register(clients, `{"redirect_uris":["https://app.example.com/cb"]}`)
+		clientID := response["client_id"].(string)
+
+		if cerr := clients.CheckAuthorize(clientID, "https://app.example.com/cb"); cerr != nil {
+			t.Errorf("Expected the registered URI accepted, got %v", cerr)
+		}
+		if cerr := clients.CheckAuthorize(clientID, "https://evil.example.com/cb"); cerr == nil {
+			t.Error("Expected an unregistered URI rejected")
+		}
+		if cerr := clients.CheckAuthorize("never-registered", "http://localhost:3000/cb"); cerr != nil {
+			t.Errorf("Expected unregistered clients allowed by default, got %v", cerr)
+		}
+		clients.Require = true
+		if cerr := clients.CheckAuthorize("never-registered", "http://localhost:3000/cb"); cerr == nil || cerr.Code != "invalid_client" {
+			t.Errorf("Expected unregistered clients rejected when required, got %v", cerr)
+		}
+	})
+
+	t.Run("token endpoint authentication", func(t *testing.T) {
+		t.Logf("  > Why it's important: Only the holder of a confidential client's secret may act as that client.")
+		clients := NewClients(kv.NewMemoryStore(), "https://example.com", "")
+		_, response := register(clients, `{"redirect_uris":["https://app.example.com/cb"]}`)
+		clientID := response["client_id"].(string)
+		secret := response["client_secret"].(string)
+
+		if id, cerr := clients.Authenticate(tokenRequest(url.Values{}, clientID, secret)); cerr != nil || id != clientID {
+			t.Errorf("Expected Basic credentials accepted, got %q %v", id, cerr)
+		}
+		if id, cerr := clients.Authenticate(tokenRequest(url.Values{"client_id": {clientID}, "client_secret": {secret}}, "", "")); cerr != nil || id != clientID {
+			t.Errorf("Expected form credentials accepted, got %q %v", id, cerr)
+		}
+		if _, cerr := clients.Authenticate(tokenRequest(url.Values{"client_id": {clientID}, "client_secret": {"wrong"}}, "", "")); cerr == nil || cerr.Status != http.StatusUnauthorized {
+			t.Errorf("Expected a wrong secret rejected with 401, got %v", cerr)
+		}
+		if _, cerr := clients.Authenticate(tokenRequest(url.Values{"client_id": {clientID}}, "", "")); cerr == nil {
+			t.Error("Expected a missing secret rejected")
+		}
+		if clients.MayRedeem(clientID, "") {
+			t.Error("Expected a confidential client's grant to need its credentials")
+		}
+		if !clients.MayRedeem("never-registered", "") || clients.MayRedeem(clientID, "someone-else") {
+			t.Error("Unexpected MayRedeem answer")
+		}
+	})
+
+	t.Run("configuration", func(t *testing.T) {
+		t.Logf("  > Why it's important: Clients must be able to update or delete their registration, and only they can.")
+		clients := NewClients(kv.NewMemoryStore(), "https://example.com", "")
+		_, response := register(clients, `{"redirect_uris":["https://app.example.com/cb"],"token_endpoint_auth_method":"none"}`)
+		clientID := response["client_id"].(string)
+		token := response["registration_access_token"].(string)
+
+		if status, _ := configure(clients, "GET", clientID, "wrong-token", ""); status != http.StatusUnauthorized {
+			t.Errorf("Expected a wrong registration token rejected, got %d", status)
+		}
+		if status, response := configure(clients, "GET", clientID, token, ""); status != http.StatusOK || response["client_id"] != clientID {
+			t.Errorf("Expected the registration read, got %d %v", status, response)
+		}
+
+		status, response := configure(clients, "PUT", clientID, token,
+			`{"client_name":"Renamed","redirect_uris":["https://app.example.com/new"],"token_endpoint_auth_method":"client_secret_post"}`)
+		if status != http.StatusOK || response["client_name"] != "Renamed" || response["client_secret"] == nil {
+			t.Fatalf("Expected the update applied with a new secret, got %d %v", status, response)
+		}
+		if cerr := clients.CheckAuthorize(clientID, "https://app.example.com/cb"); cerr == nil {
+			t.Error("Expected the replaced redirect URI rejected")
+		}
+
+		if status, _ := configure(clients, "DELETE", clientID, token, ""); status != http.StatusNoContent {
+			t.Errorf("Expected 204 from delete, got %d", status)
+		}
+		if _, ok, _ := clients.Lookup(clientID); ok {
+			t.Error("Expected the client gone after delete")
+		}
+	})
+
+	t.Run("adapter binds codes to clients", func(t *testing.T) {
+		t.Logf("  > Why it's important: A stolen code must not be redeemable without the client's secret.")
+		t.Setenv("GO_TEST", "1")
+		t.Setenv("TOKEN_DB_PATH", "")
+		t.Setenv("OAUTH_DB_PATH", "")
+		adapter := NewOAuthAdapter("http://localhost:8080", 9090)
+		defer adapter.Close()
+		_, response := register(adapter.Clients(), `{"redirect_uris":["https://app.example.com/cb"]}`)
+		clientID := response["client_id"].(string)
+		secret := response["client_secret"].(string)
+
+		adapter.saveCode(&AuthCode{Code: "bound-code", RTMAPIKey: "rtm-key", ClientID: clientID,
+			RedirectURI: "https://app.example.com/cb", ExpiresAt: time.Now().Add(time.Minute)})
+		form := url.Values{"grant_type": {"authorization_code"}, "code": {"bound-code"}}
+
+		w := httptest.NewRecorder()
+		adapter.HandleToken(w, tokenRequest(form, "", ""))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected the code refused without credentials, got %d", w.Code)
+		}
+
+		w = httptest.NewRecorder()
+		adapter.HandleToken(w, tokenRequest(form, clientID, secret))
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected the code redeemed by its client, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+}
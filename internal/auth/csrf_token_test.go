@@ -99,7 +99,7 @@ func TestTokenStore(t *testing.T) {
 	// to the user's permanent API key. If this component fails, no user would ever be able to
 	// successfully complete the login flow.
 
-	store := NewTokenStore()
+	store := NewMemoryTokenStore()
 
 	t.Run("retrieves an API key when a valid token is provided", func(t *testing.T) {
 		t.Logf("  > Why it's important: The primary function of the token store; verifies the core lookup logic.")
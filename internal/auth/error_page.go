@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// CorrelationHeader carries the ID that ties an error shown to a user to the
+// server log line for it
+const CorrelationHeader = "X-Request-ID"
+
+// retryExcludedParams are never copied into retry links
+var retryExcludedParams = []string{"api_key", "csrf_state", "code_verifier", "client_secret"}
+
+// errorTitles are the page headings for OAuth error codes
+var errorTitles = map[string]string{
+	"invalid_request":         "Invalid authorization request",
+	"invalid_grant":           "Authorization expired",
+	"invalid_target":          "Unknown resource",
+	"authorization_pending":   "Authorization not completed",
+	"temporarily_unavailable": "Service temporarily unavailable",
+	"server_error":            "Something went wrong",
+}
+
+// ErrorResponse is the JSON body for OAuth errors. It extends the RFC 6749
+// error fields with the correlation ID and retry link.
+type ErrorResponse struct {
+	TokenError
+	CorrelationID string `json:"correlation_id"`
+	RetryURL      string `json:"retry_url,omitempty"`
+}
+
+var errorPageTemplate = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>{{.Title}}</title>
+    <style>
+        body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif; max-width: 600px; margin: 50px auto; padding: 20px; }
+        .container { border: 1px solid #f5c6cb; background: #f8d7da; padding: 20px 30px; border-radius: 8px; color: #721c24; }
+        .button { display: inline-block; background: #007bff; color: white; text-decoration: none; padding: 10px 20px; border-radius: 4px; margin-top: 10px; }
+        .details { margin-top: 20px; font-size: 0.85rem; color: #666; }
+        code { background: #f8f9fa; padding: 2px 4px; border-radius: 3px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <h1>{{.Title}}</h1>
+        <p>{{.Description}}</p>
+        {{if .RetryURL}}<a class="button" href="{{.RetryURL}}">Try again</a>{{else}}<p>Close this window and connect again from Claude.</p>{{end}}
+    </div>
+    <div class="details">
+        Error <code>{{.Code}}</code> &middot; Reference <code>{{.CorrelationID}}</code><br>
+        Include the reference when reporting this problem.
+    </div>
+</body>
+</html>`))
+
+// WriteError reports an OAuth failure as an HTML page, or as JSON when the
+// request accepts application/json. An empty retryURL leaves out the retry link.
+func WriteError(w http.ResponseWriter, r *http.Request, status int, code, description, retryURL string) {
+	if wantsJSON(r) {
+		WriteJSONError(w, r, status, code, description, retryURL)
+		return
+	}
+
+	correlationID := logError(w, r, status, code, description)
+	title, ok := errorTitles[code]
+	if !ok {
+		title = "Authorization error"
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := errorPageTemplate.Execute(w, map[string]string{
+		"Title":         title,
+		"Description":   description,
+		"Code":          code,
+		"CorrelationID": correlationID,
+		"RetryURL":      retryURL,
+	}); err != nil {
+		fmt.Printf("Failed to write error page: %v\n", err)
+	}
+}
+
+// WriteJSONError reports an OAuth failure as JSON, for endpoints called by
+// clients rather than shown in a browser
+func WriteJSONError(w http.ResponseWriter, r *http.Request, status int, code, description, retryURL string) {
+	correlationID := logError(w, r, status, code, description)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(ErrorResponse{
+		TokenError:    TokenError{Error: code, ErrorDescription: description},
+		CorrelationID: correlationID,
+		RetryURL:      retryURL,
+	}); err != nil {
+		fmt.Printf("Failed to write error response: %v\n", err)
+	}
+}
+
+// RetryURL links back to the request's path with its original query
+// parameters, leaving out secrets and single-use form values
+func RetryURL(r *http.Request) string {
+	query, err := url.ParseQuery(r.URL.RawQuery)
+	if err != nil {
+		return r.URL.Path
+	}
+	for _, name := range retryExcludedParams {
+		query.Del(name)
+	}
+	if len(query) == 0 {
+		return r.URL.Path
+	}
+	return r.URL.Path + "?" + query.Encode()
+}
+
+// CorrelationID returns the request's X-Request-ID when it is a plausible ID,
+// or a new one
+func CorrelationID(r *http.Request) string {
+	id := r.Header.Get(CorrelationHeader)
+	if id == "" || len(id) > 128 || strings.ContainsFunc(id, func(c rune) bool {
+		return c <= ' ' || c > '~' || c == '"' || c == '<' || c == '>'
+	}) {
+		return uuid.New().String()
+	}
+	return id
+}
+
+// logError logs the failure under a correlation ID and echoes the ID in the
+// response headers
+func logError(w http.ResponseWriter, r *http.Request, status int, code, description string) string {
+	correlationID := CorrelationID(r)
+	w.Header().Set(CorrelationHeader, correlationID)
+	fmt.Printf("[OAuth] Error %s (%d) on %s %s [%s]: %s\n", code, status, r.Method, r.URL.Path, correlationID, description)
+	return correlationID
+}
+
+func wantsJSON(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "application/json")
+}
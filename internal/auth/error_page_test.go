@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestWriteError(t *testing.T) {
+	t.Logf("Importance: OAuth failures happen inside Claude's connect popup; a page that explains the problem, offers a retry and gives a reference we can find in the logs is what makes them debuggable.")
+
+	t.Run("renders an HTML page with a retry link and reference", func(t *testing.T) {
+		t.Logf("  > Why it's important: Users see this page in the browser, so it must explain the error and let them try again.")
+		req := httptest.NewRequest("POST", "/oauth/authorize?client_id=c1&state=s%201&redirect_uri=https%3A%2F%2Fclaude.ai%2Fcb", nil)
+		req.Header.Set(CorrelationHeader, "req-123")
+		w := httptest.NewRecorder()
+
+		WriteError(w, req, http.StatusBadRequest, "invalid_request", "Form expired <b>now</b>", RetryURL(req))
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400, got %d", w.Code)
+		}
+		if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") {
+			t.Errorf("Expected HTML, got %s", w.Header().Get("Content-Type"))
+		}
+		if w.Header().Get(CorrelationHeader) != "req-123" {
+			t.Errorf("Expected correlation header req-123, got %q", w.Header().Get(CorrelationHeader))
+		}
+		body := w.Body.String()
+		for _, want := range []string{"Invalid authorization request", "invalid_request", "req-123", "Try again", "client_id=c1", "Form expired &lt;b&gt;now&lt;/b&gt;"} {
+			if !strings.Contains(body, want) {
+				t.Errorf("Expected page to contain %q", want)
+			}
+		}
+	})
+
+	t.Run("returns JSON when the client asks for it", func(t *testing.T) {
+		t.Logf("  > Why it's important: Scripts and polling pages parse the error, so they need structured fields instead of HTML.")
+		req := httptest.NewRequest("GET", "/rtm/callback?code=abc", nil)
+		req.Header.Set("Accept", "application/json")
+		w := httptest.NewRecorder()
+
+		WriteError(w, req, http.StatusBadRequest, "authorization_pending", "Not yet", RetryURL(req))
+
+		var body ErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Expected JSON body: %v", err)
+		}
+		if body.Error != "authorization_pending" || body.ErrorDescription != "Not yet" {
+			t.Errorf("Unexpected error fields %+v", body.TokenError)
+		}
+		if body.CorrelationID == "" || body.CorrelationID != w.Header().Get(CorrelationHeader) {
+			t.Errorf("Expected correlation ID to match header, got %q", body.CorrelationID)
+		}
+		if body.RetryURL != "/rtm/callback?code=abc" {
+			t.Errorf("Expected retry URL /rtm/callback?code=abc, got %q", body.RetryURL)
+		}
+	})
+
+	t.Run("omits the retry link when retrying cannot help", func(t *testing.T) {
+		t.Logf("  > Why it's important: Retrying an expired code would fail the same way, so users are told to reconnect instead.")
+		req := httptest.NewRequest("GET", "/rtm/callback?code=old", nil)
+		w := httptest.NewRecorder()
+
+		WriteError(w, req, http.StatusBadRequest, "invalid_grant", "Expired", "")
+
+		if strings.Contains(w.Body.String(), "Try again") {
+			t.Error("Expected no retry link")
+		}
+		if !strings.Contains(w.Body.String(), "connect again from Claude") {
+			t.Error("Expected reconnect guidance")
+		}
+	})
+}
+
+func TestRetryURL(t *testing.T) {
+	t.Logf("Importance: Retry links must restore the original OAuth parameters without leaking secrets into browser history.")
+
+	req := httptest.NewRequest("POST", "/oauth/authorize?client_id=c1&state=xyz&code_challenge=abc&api_key=secret&csrf_state=old", nil)
+	retry, err := url.Parse(RetryURL(req))
+	if err != nil {
+		t.Fatalf("Invalid retry URL: %v", err)
+	}
+
+	if retry.Path != "/oauth/authorize" {
+		t.Errorf("Expected path /oauth/authorize, got %s", retry.Path)
+	}
+	query := retry.Query()
+	for name, want := range map[string]string{"client_id": "c1", "state": "xyz", "code_challenge": "abc"} {
+		if query.Get(name) != want {
+			t.Errorf("Expected %s=%s, got %q", name, want, query.Get(name))
+		}
+	}
+	for _, name := range []string{"api_key", "csrf_state"} {
+		if query.Has(name) {
+			t.Errorf("Expected %s to be dropped", name)
+		}
+	}
+}
+
+func TestCorrelationID(t *testing.T) {
+	t.Logf("Importance: Correlation IDs are echoed into pages and logs, so untrusted header values must be replaced.")
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(CorrelationHeader, `<script>"x"</script>`)
+	if id := CorrelationID(req); strings.ContainsAny(id, `<>"`) {
+		t.Errorf("Expected unsafe request ID to be replaced, got %q", id)
+	}
+
+	req.Header.Set(CorrelationHeader, "fly-abc-123")
+	if id := CorrelationID(req); id != "fly-abc-123" {
+		t.Errorf("Expected request ID to be reused, got %q", id)
+	}
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// IntrospectionResponse answers a token introspection request (RFC 7662).
+// Tokens that aren't active are described by Active alone.
+type IntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	Audience  string `json:"aud,omitempty"`
+	Issuer    string `json:"iss,omitempty"`
+}
+
+// IntrospectionToken checks an introspection request and returns the token
+// it asks about. Only registered clients with a secret may introspect, as
+// the answer says whose a token is and what it may do. When ok is false
+// the error has already been written.
+func IntrospectionToken(w http.ResponseWriter, r *http.Request, clients *Clients, guard *AttemptGuard) (string, bool) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		WriteJSONError(w, r, http.StatusMethodNotAllowed, "invalid_request", "Use POST to introspect a token", "")
+		return "", false
+	}
+	if wait := guard.Throttle("introspect", ClientIP(r)); wait > 0 {
+		guard.RejectThrottled(w, r, wait)
+		return "", false
+	}
+	if err := r.ParseForm(); err != nil {
+		WriteJSONError(w, r, http.StatusBadRequest, "invalid_request", "Request body is not a valid form", "")
+		return "", false
+	}
+
+	clientID, cerr := clients.Authenticate(r)
+	if cerr == nil {
+		client, ok, err := clients.Lookup(clientID)
+		if err != nil || !ok || !client.Confidential() {
+			cerr = &ClientError{http.StatusUnauthorized, "invalid_client", "Token introspection needs a registered client with a secret"}
+		}
+	}
+	if cerr != nil {
+		WriteClientError(w, r, cerr)
+		return "", false
+	}
+
+	token := r.FormValue("token")
+	if token == "" {
+		WriteJSONError(w, r, http.StatusBadRequest, "invalid_request", "Missing token parameter", "")
+		return "", false
+	}
+	return token, true
+}
+
+// WriteIntrospection writes response, dropping everything but Active for
+// inactive tokens
+func WriteIntrospection(w http.ResponseWriter, response IntrospectionResponse) {
+	if !response.Active {
+		response = IntrospectionResponse{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
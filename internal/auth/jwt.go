@@ -0,0 +1,332 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vcto/mcp-adapters/internal/kv"
+)
+
+// signingKeyBucket is the kv bucket holding JWT signing keys by kid
+const signingKeyBucket = "oauth_signing_keys"
+
+// defaultKeyRotation is how long a signing key signs new tokens when
+// OAUTH_JWT_KEY_ROTATION is unset
+const defaultKeyRotation = 30 * 24 * time.Hour
+
+// jwtAlgorithm is the only algorithm tokens are signed or accepted with
+const jwtAlgorithm = "ES256"
+
+// ErrInvalidJWT is returned for tokens that are malformed, not signed by a
+// published key, expired or issued by someone else
+var ErrInvalidJWT = errors.New("invalid JWT")
+
+// JWTEnabledFromEnv reports whether OAUTH_ACCESS_TOKEN_FORMAT asks for JWT
+// access tokens instead of opaque ones
+func JWTEnabledFromEnv() bool {
+	return strings.EqualFold(os.Getenv("OAUTH_ACCESS_TOKEN_FORMAT"), "jwt")
+}
+
+// KeyRotationFromEnv reads OAUTH_JWT_KEY_ROTATION, how long a signing key is
+// used before a new one replaces it, defaulting to 30 days
+func KeyRotationFromEnv() time.Duration {
+	value := os.Getenv("OAUTH_JWT_KEY_ROTATION")
+	if value == "" {
+		return defaultKeyRotation
+	}
+	rotation, err := time.ParseDuration(value)
+	if err != nil || rotation <= 0 {
+		log.Printf("Invalid OAUTH_JWT_KEY_ROTATION %q, using %s", value, defaultKeyRotation)
+		return defaultKeyRotation
+	}
+	return rotation
+}
+
+// AccessClaims are the claims of a JWT access token
+type AccessClaims struct {
+	Issuer    string `json:"iss"`
+	Subject   string `json:"sub,omitempty"`
+	Audience  string `json:"aud,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Scope     string `json:"scope,omitempty"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	ID        string `json:"jti"`
+}
+
+// signingKey is a stored P-256 key, its private half PKCS#8 encoded
+type signingKey struct {
+	KID        string    `json:"kid"`
+	PrivateKey []byte    `json:"private_key"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// JWTSigner issues and verifies ES256 access tokens. Keys are kept in a kv
+// store so tokens survive restarts and instances sharing the store sign
+// with the same keys. Each key signs new tokens for the rotation period and
+// is then retired, staying in the JWKS until the tokens it signed have
+// expired, so services validating locally never see a token they cannot
+// check.
+type JWTSigner struct {
+	mu       sync.Mutex
+	keys     *kv.Bucket[signingKey]
+	issuer   string
+	rotation time.Duration
+	tokenTTL time.Duration // 0 issues tokens without exp and keeps retired keys
+	now      func() time.Time
+}
+
+// NewJWTSigner signs tokens as issuer that last tokenTTL, with keys kept in
+// store and replaced every rotation
+func NewJWTSigner(store kv.Store, issuer string, rotation, tokenTTL time.Duration) *JWTSigner {
+	return &JWTSigner{
+		keys:     kv.NewBucket[signingKey](store, signingKeyBucket),
+		issuer:   issuer,
+		rotation: rotation,
+		tokenTTL: tokenTTL,
+		now:      time.Now,
+	}
+}
+
+// Sign returns a token for claims, filling in the issuer, issue and expiry
+// times and a unique ID
+func (s *JWTSigner) Sign(claims AccessClaims) (string, error) {
+	key, err := s.currentKey()
+	if err != nil {
+		return "", err
+	}
+	private, err := parseSigningKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	now := s.now()
+	claims.Issuer = s.issuer
+	claims.IssuedAt = now.Unix()
+	if s.tokenTTL > 0 {
+		claims.ExpiresAt = now.Add(s.tokenTTL).Unix()
+	}
+	claims.ID = uuid.New().String()
+
+	header, err := json.Marshal(map[string]string{"alg": jwtAlgorithm, "typ": "at+jwt", "kid": key.KID})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	digest := sha256.Sum256([]byte(signingInput))
+	r, sig, err := ecdsa.Sign(rand.Reader, private, digest[:])
+	if err != nil {
+		return "", err
+	}
+	// JWS wants r and s as fixed-width big-endian integers (RFC 7518 section 3.4)
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	sig.FillBytes(signature[32:])
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// Verify checks token's signature against the published keys, its issuer
+// and its expiry, and returns its claims
+func (s *JWTSigner) Verify(token string) (*AccessClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidJWT
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		KID string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil || header.Alg != jwtAlgorithm {
+		return nil, ErrInvalidJWT
+	}
+	key, ok, err := s.keys.Get(header.KID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidJWT
+	}
+	private, err := parseSigningKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(signature) != 64 {
+		return nil, ErrInvalidJWT
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r := new(big.Int).SetBytes(signature[:32])
+	sig := new(big.Int).SetBytes(signature[32:])
+	if !ecdsa.Verify(&private.PublicKey, digest[:], r, sig) {
+		return nil, ErrInvalidJWT
+	}
+
+	var claims AccessClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrInvalidJWT
+	}
+	if claims.Issuer != s.issuer {
+		return nil, ErrInvalidJWT
+	}
+	if claims.ExpiresAt != 0 && s.now().Unix() >= claims.ExpiresAt {
+		return nil, ErrInvalidJWT
+	}
+	return &claims, nil
+}
+
+// Rotate retires the current signing key now instead of when its rotation
+// period is up, e.g. after it may have leaked. Tokens it signed keep working
+// until they expire; delete its kid from the store to reject them at once.
+func (s *JWTSigner) Rotate() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.generateKey()
+	return err
+}
+
+// HandleJWKS serves the public keys tokens are signed with, current and
+// retired, as a JSON Web Key Set (RFC 7517)
+func (s *JWTSigner) HandleJWKS(w http.ResponseWriter, r *http.Request) {
+	// Make sure a key exists, so the set isn't empty before the first token
+	if _, err := s.currentKey(); err != nil {
+		log.Printf("[OAuth] Failed to load signing key: %v", err)
+		WriteJSONError(w, r, http.StatusServiceUnavailable, "temporarily_unavailable", "The signing keys could not be read. Try again.", "")
+		return
+	}
+	keys, err := s.allKeys()
+	if err != nil {
+		log.Printf("[OAuth] Failed to list signing keys: %v", err)
+		WriteJSONError(w, r, http.StatusServiceUnavailable, "temporarily_unavailable", "The signing keys could not be read. Try again.", "")
+		return
+	}
+
+	set := make([]map[string]string, 0, len(keys))
+	for _, key := range keys {
+		private, err := parseSigningKey(key)
+		if err != nil {
+			log.Printf("[OAuth] Skipping unreadable signing key %s: %v", key.KID, err)
+			continue
+		}
+		set = append(set, map[string]string{
+			"kty": "EC",
+			"crv": "P-256",
+			"kid": key.KID,
+			"use": "sig",
+			"alg": jwtAlgorithm,
+			"x":   base64.RawURLEncoding.EncodeToString(private.PublicKey.X.FillBytes(make([]byte, 32))),
+			"y":   base64.RawURLEncoding.EncodeToString(private.PublicKey.Y.FillBytes(make([]byte, 32))),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	// Short enough that a rotated-in key is picked up before it signs much
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"keys": set}); err != nil {
+		log.Printf("Failed to encode JWKS: %v", err)
+	}
+}
+
+// currentKey returns the newest key, generating one when there is none or
+// the newest has been signing for its whole rotation period
+func (s *JWTSigner) currentKey() (signingKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys, err := s.allKeys()
+	if err != nil {
+		return signingKey{}, err
+	}
+	if len(keys) > 0 && s.now().Sub(keys[0].CreatedAt) < s.rotation {
+		return keys[0], nil
+	}
+	return s.generateKey()
+}
+
+// generateKey stores a new key, kept until every token it can sign has
+// expired
+func (s *JWTSigner) generateKey() (signingKey, error) {
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return signingKey{}, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return signingKey{}, err
+	}
+	key := signingKey{KID: uuid.New().String(), PrivateKey: der, CreatedAt: s.now().UTC()}
+
+	var keep time.Duration // Without a token lifetime, retired keys are kept
+	if s.tokenTTL > 0 {
+		keep = s.rotation + s.tokenTTL
+	}
+	if err := s.keys.Put(key.KID, key, keep); err != nil {
+		return signingKey{}, err
+	}
+	log.Printf("[AUDIT] JWT signing key rotated kid=%s", key.KID)
+	return key, nil
+}
+
+// allKeys returns the stored keys, newest first
+func (s *JWTSigner) allKeys() ([]signingKey, error) {
+	kids, err := s.keys.Keys()
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]signingKey, 0, len(kids))
+	for _, kid := range kids {
+		key, ok, err := s.keys.Get(kid)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.After(keys[j].CreatedAt) })
+	return keys, nil
+}
+
+// parseSigningKey decodes a stored key's private half
+func parseSigningKey(key signingKey) (*ecdsa.PrivateKey, error) {
+	parsed, err := x509.ParsePKCS8PrivateKey(key.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("signing key %s: %w", key.KID, err)
+	}
+	private, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("signing key %s is not an ECDSA key", key.KID)
+	}
+	return private, nil
+}
+
+// decodeSegment decodes a base64url JSON segment of a token into v
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
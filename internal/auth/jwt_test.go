@@ -0,0 +1,181 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vcto/mcp-adapters/internal/kv"
+)
+
+func TestJWTAccessTokens(t *testing.T) {
+	t.Logf("Importance: Signed access tokens let other services behind the gateway validate requests without calling us.")
+
+	newSigner := func(now *time.Time) *JWTSigner {
+		signer := NewJWTSigner(kv.NewMemoryStore(), "https://example.com", 24*time.Hour, time.Hour)
+		signer.now = func() time.Time { return *now }
+		return signer
+	}
+	jwks := func(signer *JWTSigner) []map[string]string {
+		w := httptest.NewRecorder()
+		signer.HandleJWKS(w, httptest.NewRequest("GET", "/.well-known/jwks.json", nil))
+		var set struct {
+			Keys []map[string]string `json:"keys"`
+		}
+		_ = json.NewDecoder(w.Body).Decode(&set)
+		return set.Keys
+	}
+
+	t.Run("sign and verify", func(t *testing.T) {
+		t.Logf("  > Why it's important: A token we signed must verify, and one that was altered must not.")
+		now := time.Now()
+		signer := newSigner(&now)
+		token, err := signer.Sign(AccessClaims{Audience: "https://example.com/mcp", ClientID: "client-1"})
+		if err != nil {
+			t.Fatalf("Sign: %v", err)
+		}
+		claims, err := signer.Verify(token)
+		if err != nil {
+			t.Fatalf("Verify: %v", err)
+		}
+		if claims.ClientID != "client-1" || claims.Issuer != "https://example.com" || claims.ExpiresAt != now.Add(time.Hour).Unix() || claims.ID == "" {
+			t.Errorf("Unexpected claims %+v", claims)
+		}
+
+		parts := strings.Split(token, ".")
+		forged, _ := json.Marshal(AccessClaims{Issuer: "https://example.com", ClientID: "attacker", IssuedAt: now.Unix()})
+		if _, err := signer.Verify(parts[0] + "." + base64.RawURLEncoding.EncodeToString(forged) + "." + parts[2]); err == nil {
+			t.Error("Expected altered claims rejected")
+		}
+		if _, err := signer.Verify("not-a-jwt"); err == nil {
+			t.Error("Expected a malformed token rejected")
+		}
+
+		now = now.Add(time.Hour)
+		if _, err := signer.Verify(token); err == nil {
+			t.Error("Expected an expired token rejected")
+		}
+	})
+
+	t.Run("jwks verifies tokens", func(t *testing.T) {
+		t.Logf("  > Why it's important: Other services only have the published keys to check tokens with.")
+		now := time.Now()
+		signer := newSigner(&now)
+		token, _ := signer.Sign(AccessClaims{})
+		keys := jwks(signer)
+		if len(keys) != 1 {
+			t.Fatalf("Expected one published key, got %d", len(keys))
+		}
+
+		x, _ := base64.RawURLEncoding.DecodeString(keys[0]["x"])
+		y, _ := base64.RawURLEncoding.DecodeString(keys[0]["y"])
+		public := ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		parts := strings.Split(token, ".")
+		signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if !ecdsa.Verify(&public, digest[:], new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])) {
+			t.Error("Expected the token to verify with the published key")
+		}
+		if keys[0]["kty"] != "EC" || keys[0]["alg"] != "ES256" || keys[0]["kid"] == "" {
+			t.Errorf("Unexpected JWK %v", keys[0])
+		}
+	})
+
+	t.Run("rotation", func(t *testing.T) {
+		t.Logf("  > Why it's important: Rotating keys must not break tokens signed before the rotation.")
+		now := time.Now()
+		signer := newSigner(&now)
+		before, _ := signer.Sign(AccessClaims{})
+
+		now = now.Add(25 * time.Hour)
+		after, _ := signer.Sign(AccessClaims{})
+		if kid(t, before) == kid(t, after) {
+			t.Fatal("Expected a new key after the rotation period")
+		}
+		if len(jwks(signer)) != 2 {
+			t.Errorf("Expected the retired key still published")
+		}
+
+		now = now.Add(time.Minute)
+		if err := signer.Rotate(); err != nil {
+			t.Fatalf("Rotate: %v", err)
+		}
+		if rotated, _ := signer.Sign(AccessClaims{}); kid(t, rotated) == kid(t, after) {
+			t.Error("Expected Rotate to switch keys at once")
+		}
+		if _, err := signer.Verify(after); err != nil {
+			t.Errorf("Expected tokens from the retired key still valid, got %v", err)
+		}
+	})
+
+	t.Run("adapter", func(t *testing.T) {
+		t.Logf("  > Why it's important: JWTs must work end to end, and revoking one must still take effect here.")
+		t.Setenv("GO_TEST", "1")
+		t.Setenv("TOKEN_DB_PATH", "")
+		t.Setenv("OAUTH_DB_PATH", "")
+		adapter := NewOAuthAdapter("http://localhost:8080", 9090)
+		defer adapter.Close()
+		adapter.UseJWT(defaultKeyRotation)
+
+		adapter.saveCode(&AuthCode{Code: "jwt-code", RTMAPIKey: "rtm-key", ExpiresAt: time.Now().Add(time.Minute)})
+		form := url.Values{"grant_type": {"authorization_code"}, "code": {"jwt-code"}}
+		req := httptest.NewRequest("POST", "/oauth/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		adapter.HandleToken(w, req)
+		var response TokenResponse
+		_ = json.NewDecoder(w.Body).Decode(&response)
+		if strings.Count(response.AccessToken, ".") != 2 {
+			t.Fatalf("Expected a JWT access token, got %q", response.AccessToken)
+		}
+		if apiKey, err := adapter.ValidateToken("Bearer " + response.AccessToken); err != nil || apiKey != "rtm-key" {
+			t.Errorf("Expected the JWT accepted for rtm-key, got %q %v", apiKey, err)
+		}
+
+		w = httptest.NewRecorder()
+		adapter.HandleAuthServerMetadata(w, httptest.NewRequest("GET", "/.well-known/oauth-authorization-server", nil))
+		if !strings.Contains(w.Body.String(), `"jwks_uri":"http://localhost:8080/.well-known/jwks.json"`) {
+			t.Errorf("Expected jwks_uri in metadata, got %s", w.Body.String())
+		}
+
+		req = httptest.NewRequest("POST", "/oauth/revoke", strings.NewReader(url.Values{"token": {response.AccessToken}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		adapter.HandleRevoke(httptest.NewRecorder(), req)
+		if _, err := adapter.ValidateToken("Bearer " + response.AccessToken); err == nil {
+			t.Error("Expected the revoked JWT rejected")
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Logf("  > Why it's important: Without the option, tokens stay opaque and no keys are published.")
+		t.Setenv("GO_TEST", "1")
+		adapter := NewOAuthAdapter("http://localhost:8080", 9090)
+		defer adapter.Close()
+		w := httptest.NewRecorder()
+		adapter.HandleJWKS(w, httptest.NewRequest("GET", "/.well-known/jwks.json", nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 with opaque tokens, got %d", w.Code)
+		}
+	})
+}
+
+// kid returns the key ID in a token's header
+func kid(t *testing.T, token string) string {
+	t.Helper()
+	var header struct {
+		KID string `json:"kid"`
+	}
+	if err := decodeSegment(strings.Split(token, ".")[0], &header); err != nil {
+		t.Fatalf("Bad token header: %v", err)
+	}
+	return header.KID
+}
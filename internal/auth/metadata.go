@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// ServerMetadata describes an authorization server and the MCP endpoint it
+// protects, for OAuth discovery. Both adapters build their
+// /.well-known documents from it, so the two can't drift apart.
+type ServerMetadata struct {
+	Issuer                string
+	Resource              string // The protected MCP endpoint
+	AuthorizationEndpoint string
+	TokenEndpoint         string
+	RegistrationEndpoint  string
+	RevocationEndpoint    string
+	IntrospectionEndpoint string
+	JWKSURI               string // Empty unless access tokens are JWTs
+	Scopes                []string
+	RequirePKCE           bool
+	// ResourceIndicators advertises that the resource parameter is checked
+	ResourceIndicators bool
+}
+
+// NewServerMetadata describes the server at serverURL offering scopes,
+// with the standard /oauth endpoints. OAUTH_ISSUER replaces the issuer, for
+// deployments whose tokens must name a different public identity;
+// OAUTH_ENDPOINT_BASE_URL moves the endpoints, such as behind a gateway
+// that serves them on another host; OAUTH_SCOPES_SUPPORTED replaces the
+// scopes advertised.
+func NewServerMetadata(serverURL string, scopes []string) ServerMetadata {
+	issuer := serverURL
+	if value := strings.TrimSuffix(os.Getenv("OAUTH_ISSUER"), "/"); value != "" {
+		issuer = value
+	}
+	base := serverURL
+	if value := strings.TrimSuffix(os.Getenv("OAUTH_ENDPOINT_BASE_URL"), "/"); value != "" {
+		base = value
+	}
+	if value := strings.Fields(strings.ReplaceAll(os.Getenv("OAUTH_SCOPES_SUPPORTED"), ",", " ")); len(value) > 0 {
+		scopes = value
+	}
+	return ServerMetadata{
+		Issuer:                issuer,
+		Resource:              serverURL + "/mcp",
+		AuthorizationEndpoint: base + "/oauth/authorize",
+		TokenEndpoint:         base + "/oauth/token",
+		RegistrationEndpoint:  base + "/oauth/register",
+		RevocationEndpoint:    base + "/oauth/revoke",
+		IntrospectionEndpoint: base + "/oauth/introspect",
+		Scopes:                scopes,
+		RequirePKCE:           RequirePKCEFromEnv(),
+	}
+}
+
+// AuthorizationServer is the RFC 8414 authorization server metadata
+func (m ServerMetadata) AuthorizationServer() map[string]interface{} {
+	authMethods := []string{AuthMethodNone, AuthMethodSecretPost, AuthMethodSecretBasic}
+	metadata := map[string]interface{}{
+		"issuer":                                     m.Issuer,
+		"authorization_endpoint":                     m.AuthorizationEndpoint,
+		"token_endpoint":                             m.TokenEndpoint,
+		"registration_endpoint":                      m.RegistrationEndpoint,
+		"revocation_endpoint":                        m.RevocationEndpoint,
+		"introspection_endpoint":                     m.IntrospectionEndpoint,
+		"response_types_supported":                   []string{"code"},
+		"grant_types_supported":                      []string{"authorization_code", "refresh_token"},
+		"code_challenge_methods_supported":           []string{PKCEMethodS256},
+		"require_pkce":                               m.RequirePKCE,
+		"token_endpoint_auth_methods_supported":      authMethods,
+		"revocation_endpoint_auth_methods_supported": authMethods,
+		// Introspection reveals who a token belongs to, so only clients
+		// with a secret may ask
+		"introspection_endpoint_auth_methods_supported": []string{AuthMethodSecretPost, AuthMethodSecretBasic},
+	}
+	if len(m.Scopes) > 0 {
+		metadata["scopes_supported"] = m.Scopes
+	}
+	if m.JWKSURI != "" {
+		metadata["jwks_uri"] = m.JWKSURI
+	}
+	if m.ResourceIndicators {
+		metadata["resource_indicators_supported"] = true
+	}
+	return metadata
+}
+
+// ProtectedResource is the RFC 9728 protected resource metadata
+func (m ServerMetadata) ProtectedResource() map[string]interface{} {
+	metadata := map[string]interface{}{
+		"resource":                 m.Resource,
+		"authorization_servers":    []string{m.Issuer},
+		"bearer_methods_supported": []string{"header"},
+	}
+	if len(m.Scopes) > 0 {
+		metadata["scopes_supported"] = m.Scopes
+	}
+	return metadata
+}
+
+// HandleAuthorizationServer serves AuthorizationServer at
+// /.well-known/oauth-authorization-server
+func (m ServerMetadata) HandleAuthorizationServer(w http.ResponseWriter, r *http.Request) {
+	writeMetadata(w, m.AuthorizationServer())
+}
+
+// HandleProtectedResource serves ProtectedResource at
+// /.well-known/oauth-protected-resource
+func (m ServerMetadata) HandleProtectedResource(w http.ResponseWriter, r *http.Request) {
+	writeMetadata(w, m.ProtectedResource())
+}
+
+func writeMetadata(w http.ResponseWriter, metadata map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(metadata); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServerMetadata(t *testing.T) {
+	t.Logf("Importance: Clients find every OAuth endpoint through discovery, so the metadata must be complete and point where the server really is.")
+
+	t.Run("defaults", func(t *testing.T) {
+		t.Logf("  > Why it's important: Clients need revocation, introspection and auth methods advertised to use them.")
+		metadata := NewServerMetadata("https://mcp.example.com", []string{"rtm:read"}).AuthorizationServer()
+		for field, want := range map[string]string{
+			"issuer":                 "https://mcp.example.com",
+			"authorization_endpoint": "https://mcp.example.com/oauth/authorize",
+			"token_endpoint":         "https://mcp.example.com/oauth/token",
+			"revocation_endpoint":    "https://mcp.example.com/oauth/revoke",
+			"introspection_endpoint": "https://mcp.example.com/oauth/introspect",
+		} {
+			if metadata[field] != want {
+				t.Errorf("Expected %s %q, got %v", field, want, metadata[field])
+			}
+		}
+		if metadata["token_endpoint_auth_methods_supported"] == nil || metadata["introspection_endpoint_auth_methods_supported"] == nil {
+			t.Error("Expected endpoint auth methods advertised")
+		}
+		if _, ok := metadata["jwks_uri"]; ok {
+			t.Error("Expected no jwks_uri without JWT access tokens")
+		}
+	})
+
+	t.Run("configured", func(t *testing.T) {
+		t.Logf("  > Why it's important: Behind a gateway the public issuer and endpoints differ from the server's own URL.")
+		t.Setenv("OAUTH_ISSUER", "https://id.example.com/")
+		t.Setenv("OAUTH_ENDPOINT_BASE_URL", "https://gateway.example.com")
+		t.Setenv("OAUTH_SCOPES_SUPPORTED", "tasks:read, tasks:write")
+		metadata := NewServerMetadata("http://10.0.0.5:8080", nil)
+		if metadata.Issuer != "https://id.example.com" || metadata.TokenEndpoint != "https://gateway.example.com/oauth/token" {
+			t.Errorf("Expected the configured issuer and endpoints, got %+v", metadata)
+		}
+		if strings.Join(metadata.Scopes, " ") != "tasks:read tasks:write" {
+			t.Errorf("Expected the configured scopes, got %v", metadata.Scopes)
+		}
+		resource := metadata.ProtectedResource()
+		if servers := resource["authorization_servers"].([]string); len(servers) != 1 || servers[0] != "https://id.example.com" {
+			t.Errorf("Expected the issuer as authorization server, got %v", servers)
+		}
+	})
+}
+
+func TestHandleIntrospect(t *testing.T) {
+	t.Logf("Importance: Other services behind the gateway rely on introspection to tell live tokens from revoked ones.")
+
+	t.Setenv("GO_TEST", "1")
+	t.Setenv("TOKEN_DB_PATH", "")
+	t.Setenv("OAUTH_DB_PATH", "")
+	adapter := NewOAuthAdapter("http://localhost:8080", 9090)
+	defer adapter.Close()
+	adapter.UseJWT(time.Hour)
+
+	req := httptest.NewRequest("POST", "/oauth/register", strings.NewReader(`{"redirect_uris":["https://app.example.com/cb"]}`))
+	w := httptest.NewRecorder()
+	adapter.HandleRegister(w, req)
+	var registration map[string]interface{}
+	_ = json.NewDecoder(w.Body).Decode(&registration)
+	clientID, _ := registration["client_id"].(string)
+	secret, _ := registration["client_secret"].(string)
+
+	adapter.saveCode(&AuthCode{Code: "introspect-code", RTMAPIKey: "rtm-key", ClientID: clientID, ExpiresAt: time.Now().Add(time.Minute)})
+	form := url.Values{"grant_type": {"authorization_code"}, "code": {"introspect-code"}, "client_id": {clientID}, "client_secret": {secret}}
+	req = httptest.NewRequest("POST", "/oauth/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	adapter.HandleToken(w, req)
+	var tokens TokenResponse
+	_ = json.NewDecoder(w.Body).Decode(&tokens)
+
+	introspect := func(token string, authenticate bool) (int, map[string]interface{}) {
+		form := url.Values{"token": {token}}
+		req := httptest.NewRequest("POST", "/oauth/introspect", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if authenticate {
+			req.SetBasicAuth(clientID, secret)
+		}
+		w := httptest.NewRecorder()
+		adapter.HandleIntrospect(w, req)
+		var response map[string]interface{}
+		_ = json.NewDecoder(w.Body).Decode(&response)
+		return w.Code, response
+	}
+
+	t.Run("live token", func(t *testing.T) {
+		t.Logf("  > Why it's important: A live token must be reported active with who it was issued to.")
+		status, response := introspect(tokens.AccessToken, true)
+		if status != http.StatusOK || response["active"] != true || response["client_id"] != clientID {
+			t.Errorf("Expected an active token for %s, got %d %v", clientID, status, response)
+		}
+	})
+
+	t.Run("unknown and revoked tokens", func(t *testing.T) {
+		t.Logf("  > Why it's important: Revoked tokens must stop passing at every service at once, and say nothing more.")
+		if _, response := introspect("not-a-token", true); response["active"] != false || len(response) != 1 {
+			t.Errorf("Expected only active=false for an unknown token, got %v", response)
+		}
+		form := url.Values{"token": {tokens.AccessToken}}
+		req := httptest.NewRequest("POST", "/oauth/revoke", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		adapter.HandleRevoke(httptest.NewRecorder(), req)
+		if _, response := introspect(tokens.AccessToken, true); response["active"] != false {
+			t.Errorf("Expected a revoked token inactive, got %v", response)
+		}
+	})
+
+	t.Run("caller must authenticate", func(t *testing.T) {
+		t.Logf("  > Why it's important: Introspection says whose a token is, so anonymous callers must be turned away.")
+		if status, _ := introspect(tokens.AccessToken, false); status != http.StatusUnauthorized {
+			t.Errorf("Expected 401 without client credentials, got %d", status)
+		}
+	})
+}
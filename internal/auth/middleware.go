@@ -30,6 +30,7 @@ func Middleware(adapter *OAuthAdapter) func(http.Handler) http.Handler {
 			// Validate token
 			apiKey, err := adapter.ValidateToken(authHeader)
 			if err != nil {
+				adapter.Auditor().Record(r, AuditValidationFailed, AuditFailure, "", err.Error())
 				w.Header().Set("WWW-Authenticate", `Bearer realm="`+adapter.serverURL+`/.well-known/oauth-protected-resource" error="invalid_token"`)
 				http.Error(w, "Invalid token", http.StatusUnauthorized)
 				return
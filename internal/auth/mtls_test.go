@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCert is a certificate and key signed by parent, or self-signed
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+func newTestCert(t *testing.T, template *x509.Certificate, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Minute)
+	template.NotAfter = time.Now().Add(time.Hour)
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCert{cert: cert, key: key, der: der}
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.der}, PrivateKey: c.key}
+}
+
+func writePEM(t *testing.T, path, kind string, der []byte) {
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	t.Logf("Importance: With mutual TLS, the client certificate is the only thing standing between the network and the MCP endpoint.")
+
+	ca := newTestCert(t, &x509.Certificate{Subject: pkix.Name{CommonName: "Test CA"}, IsCA: true,
+		KeyUsage: x509.KeyUsageCertSign, BasicConstraintsValid: true}, nil)
+	serverCert := newTestCert(t, &x509.Certificate{Subject: pkix.Name{CommonName: "server"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")}, ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}}, ca)
+	client := func(name string, signer *testCert) *testCert {
+		return newTestCert(t, &x509.Certificate{Subject: pkix.Name{CommonName: name},
+			ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}, signer)
+	}
+
+	dir := t.TempDir()
+	config := MTLSConfig{
+		CertFile:       filepath.Join(dir, "server.pem"),
+		KeyFile:        filepath.Join(dir, "server-key.pem"),
+		ClientCAFile:   filepath.Join(dir, "ca.pem"),
+		AllowedClients: []string{"laptop"},
+	}
+	writePEM(t, config.CertFile, "CERTIFICATE", serverCert.der)
+	keyDER, _ := x509.MarshalECPrivateKey(serverCert.key)
+	writePEM(t, config.KeyFile, "EC PRIVATE KEY", keyDER)
+	writePEM(t, config.ClientCAFile, "CERTIFICATE", ca.der)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	if err := config.Apply(srv.Config); err != nil {
+		t.Fatal(err)
+	}
+	srv.TLS = srv.Config.TLSConfig
+	srv.StartTLS()
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	get := func(path string, cert *testCert) (int, error) {
+		tlsConfig := &tls.Config{RootCAs: roots}
+		if cert != nil {
+			tlsConfig.Certificates = []tls.Certificate{cert.tlsCertificate()}
+		}
+		httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+		resp, err := httpClient.Get(srv.URL + path)
+		if err != nil {
+			return 0, err
+		}
+		resp.Body.Close()
+		return resp.StatusCode, nil
+	}
+
+	t.Run("client certificates", func(t *testing.T) {
+		t.Logf("  > Why it's important: Only allowed certificates from the configured CAs may reach /mcp.")
+		if status, err := get("/mcp", client("laptop", ca)); err != nil || status != http.StatusOK {
+			t.Errorf("Expected an allowed certificate accepted, got %d %v", status, err)
+		}
+		if status, err := get("/mcp", nil); err != nil || status != http.StatusUnauthorized {
+			t.Errorf("Expected 401 without a certificate, got %d %v", status, err)
+		}
+		if status, err := get("/mcp", client("phone", ca)); err != nil || status != http.StatusForbidden {
+			t.Errorf("Expected 403 for a certificate not allowed, got %d %v", status, err)
+		}
+		rogueCA := newTestCert(t, &x509.Certificate{Subject: pkix.Name{CommonName: "Rogue CA"}, IsCA: true,
+			KeyUsage: x509.KeyUsageCertSign, BasicConstraintsValid: true}, nil)
+		// Go clients withhold a certificate the server's CAs didn't sign, so
+		// this is refused as having none if the handshake gets that far
+		if status, err := get("/mcp", client("laptop", rogueCA)); err == nil && status != http.StatusUnauthorized {
+			t.Errorf("Expected another CA's certificate refused, got %d", status)
+		}
+	})
+
+	t.Run("health stays open", func(t *testing.T) {
+		t.Logf("  > Why it's important: Platform health checks can't present client certificates.")
+		if status, err := get("/health", nil); err != nil || status != http.StatusOK {
+			t.Errorf("Expected /health without a certificate, got %d %v", status, err)
+		}
+	})
+
+	t.Run("incomplete configuration", func(t *testing.T) {
+		t.Logf("  > Why it's important: A half-configured mTLS setup must fail startup, not quietly serve plain HTTP.")
+		if _, err := (MTLSConfig{ClientCAFile: config.ClientCAFile}).TLSConfig(); err == nil {
+			t.Error("Expected an error without a server certificate")
+		}
+		if _, err := (MTLSConfig{CertFile: config.CertFile, KeyFile: config.KeyFile, ClientCAFile: config.KeyFile}).TLSConfig(); err == nil {
+			t.Error("Expected an error for a CA file without certificates")
+		}
+	})
+}
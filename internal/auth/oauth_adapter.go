@@ -8,33 +8,80 @@ import (
 	"net/url"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/vcto/mcp-adapters/internal/kv"
 )
 
 // OAuthAdapter provides OAuth2 facade for RTM API key authentication
 type OAuthAdapter struct {
-	serverURL      string
-	tokenStore     TokenStoreInterface
-	authCodes      map[string]*AuthCode // Temporary auth codes
+	serverURL  string
+	metadata   ServerMetadata // Discovery metadata, endpoints and issuer
+	tokenStore TokenStore
+	authCodes  map[string]*AuthCode // Temporary auth codes
+	codesMu    sync.Mutex
+	// store keeps auth codes, written through from authCodes, across restarts
+	store          kv.Store
+	codes          *kv.Bucket[AuthCode]
+	refresh        *RefreshTokens
+	redeemed       *RedeemedCodes // Authorization codes already exchanged
+	clients        *Clients       // Registered OAuth clients
+	jwt            *JWTSigner     // Signs access tokens as JWTs, nil for opaque tokens
+	accessTTL      time.Duration  // How long access tokens last, 0 for no limit
 	callbackServer *OAuthCallbackServer
 	callbackPort   int
+	guard          *AttemptGuard // Limits authorization code guessing
+	requirePKCE    bool          // Refuse authorization requests without an S256 challenge
+	oidc           *OIDCProvider // Upstream sign-in in OIDC passthrough mode, nil otherwise
+	oidcRequests   *kv.Bucket[oidcRequest]
+	identities     *kv.Bucket[OIDCIdentity] // External subjects linked to RTM API keys
+	pending        *kv.Bucket[authRequest]  // Authorization requests behind open key forms
+	audit          *Auditor                 // Records authentication events, nil logs only
+	done           chan struct{}            // For stopping cleanup goroutine
 }
 
 type AuthCode struct {
-	Code      string
-	RTMAPIKey string
-	ExpiresAt time.Time
+	Code        string
+	RTMAPIKey   string
+	ClientID    string // Client the code was issued to
+	RedirectURI string // Redirect URI the code was sent to
+	// CodeChallenge is the PKCE S256 challenge the code_verifier must
+	// match, empty when the client sent none
+	CodeChallenge string
+	ExpiresAt     time.Time
 }
 
 // NewOAuthAdapter creates a new OAuth adapter
 func NewOAuthAdapter(serverURL string, callbackPort int) *OAuthAdapter {
+	store := OpenOAuthStore()
+	accessTTL := AccessTokenTTLFromEnv(defaultAccessTokenTTL)
 	adapter := &OAuthAdapter{
 		serverURL:    serverURL,
-		tokenStore:   CreateTokenStore(),
+		metadata:     NewServerMetadata(serverURL, nil),
+		tokenStore:   CreateTokenStore(store, accessTTL),
 		authCodes:    make(map[string]*AuthCode),
+		store:        store,
+		codes:        kv.NewBucket[AuthCode](store, authCodeBucket),
+		refresh:      NewRefreshTokens(store),
+		redeemed:     NewRedeemedCodes(store, authCodeLifetime),
+		clients:      NewClients(store, serverURL, ""),
+		accessTTL:    accessTTL,
 		callbackPort: callbackPort,
+		guard:        NewAttemptGuard(GuardLimitsFromEnv()),
+		requirePKCE:  RequirePKCEFromEnv(),
+		oidcRequests: kv.NewBucket[oidcRequest](store, oidcRequestBucket),
+		identities:   kv.NewBucket[OIDCIdentity](store, oidcIdentityBucket),
+		pending:      kv.NewBucket[authRequest](store, pendingAuthorizationBucket),
+		done:         make(chan struct{}),
+	}
+	if config, ok := OIDCConfigFromEnv(); ok {
+		adapter.UseOIDC(config)
+	}
+	go adapter.cleanupCodes()
+	if JWTEnabledFromEnv() {
+		adapter.jwt = NewJWTSigner(store, adapter.metadata.Issuer, KeyRotationFromEnv(), accessTTL)
 	}
 	adapter.callbackServer = NewOAuthCallbackServer(adapter, callbackPort)
 
@@ -67,6 +114,7 @@ func (a *OAuthAdapter) StopCallbackServer() error {
 
 // Close cleans up all resources (for testing)
 func (a *OAuthAdapter) Close() error {
+	close(a.done)
 	// Stop callback server if running
 	if a.callbackServer != nil {
 		if err := a.callbackServer.Stop(); err != nil {
@@ -75,40 +123,168 @@ func (a *OAuthAdapter) Close() error {
 	}
 	// Close token store
 	if a.tokenStore != nil {
-		return a.tokenStore.Close()
+		if err := a.tokenStore.Close(); err != nil {
+			return err
+		}
+	}
+	if a.store != nil {
+		return a.store.Close()
 	}
 	return nil
 }
 
-// HandleProtectedResourceMetadata handles /.well-known/oauth-protected-resource
-func (a *OAuthAdapter) HandleProtectedResourceMetadata(w http.ResponseWriter, r *http.Request) {
-	metadata := map[string]interface{}{
-		"resource":              a.serverURL + "/mcp",
-		"authorization_servers": []string{a.serverURL},
+// SetStore keeps auth codes, issued tokens, refresh tokens, registered
+// clients, signing keys and linked identities in store instead, such as a
+// shared or test store. The adapter takes ownership and closes it.
+func (a *OAuthAdapter) SetStore(store kv.Store) error {
+	if a.tokenStore != nil {
+		if err := a.tokenStore.Close(); err != nil {
+			return err
+		}
 	}
+	if a.store != nil {
+		if err := a.store.Close(); err != nil {
+			return err
+		}
+	}
+	a.store = store
+	a.codes = kv.NewBucket[AuthCode](store, authCodeBucket)
+	a.tokenStore = NewKVTokenStore(store, a.accessTTL)
+	a.refresh = NewRefreshTokens(store)
+	a.redeemed = NewRedeemedCodes(store, authCodeLifetime)
+	a.clients = NewClients(store, a.serverURL, "")
+	a.oidcRequests = kv.NewBucket[oidcRequest](store, oidcRequestBucket)
+	a.identities = kv.NewBucket[OIDCIdentity](store, oidcIdentityBucket)
+	a.pending = kv.NewBucket[authRequest](store, pendingAuthorizationBucket)
+	if a.jwt != nil {
+		a.jwt = NewJWTSigner(store, a.metadata.Issuer, a.jwt.rotation, a.accessTTL)
+	}
+	return nil
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(metadata); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+// saveCode records an unexchanged auth code in memory and the store
+func (a *OAuthAdapter) saveCode(authCode *AuthCode) {
+	a.codesMu.Lock()
+	a.authCodes[authCode.Code] = authCode
+	a.codesMu.Unlock()
+	if err := a.codes.Put(authCode.Code, *authCode, time.Until(authCode.ExpiresAt)); err != nil {
+		fmt.Printf("[OAuth] WARNING: Failed to persist auth code: %v\n", err)
+	}
+}
+
+// lookupCode returns the auth code, loading it from the store when it was
+// issued before a restart
+func (a *OAuthAdapter) lookupCode(code string) (*AuthCode, bool) {
+	a.codesMu.Lock()
+	defer a.codesMu.Unlock()
+	if authCode, ok := a.authCodes[code]; ok {
+		return authCode, true
+	}
+	stored, ok, err := a.codes.Get(code)
+	if err != nil || !ok {
+		return nil, false
+	}
+	a.authCodes[code] = &stored
+	return &stored, true
+}
+
+// removeCode forgets a used auth code
+func (a *OAuthAdapter) removeCode(code string) {
+	a.codesMu.Lock()
+	delete(a.authCodes, code)
+	a.codesMu.Unlock()
+	if err := a.codes.Delete(code); err != nil {
+		fmt.Printf("[OAuth] WARNING: Failed to remove auth code: %v\n", err)
 	}
 }
 
+// cleanupCodes drops codes that expired without being exchanged
+// periodically, so abandoned authorizations don't pile up in memory
+func (a *OAuthAdapter) cleanupCodes() {
+	ticker := time.NewTicker(codeCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if removed := a.removeExpiredCodes(); removed > 0 {
+				fmt.Printf("[OAuth] Removed %d expired auth codes\n", removed)
+			}
+		case <-a.done:
+			return
+		}
+	}
+}
+
+// removeExpiredCodes drops expired codes from memory and returns how many
+// were removed
+func (a *OAuthAdapter) removeExpiredCodes() int {
+	a.codesMu.Lock()
+	defer a.codesMu.Unlock()
+
+	removed := 0
+	now := time.Now()
+	for code, authCode := range a.authCodes {
+		if now.After(authCode.ExpiresAt) {
+			delete(a.authCodes, code)
+			removed++
+		}
+	}
+	// The store drops its copies itself once they outlive their TTL
+	return removed
+}
+
+// Guard returns the adapter's brute-force protection, for metrics and audit
+func (a *OAuthAdapter) Guard() *AttemptGuard {
+	return a.guard
+}
+
+// HandleProtectedResourceMetadata handles /.well-known/oauth-protected-resource
+func (a *OAuthAdapter) HandleProtectedResourceMetadata(w http.ResponseWriter, r *http.Request) {
+	a.metadata.HandleProtectedResource(w, r)
+}
+
 // HandleAuthServerMetadata handles /.well-known/oauth-authorization-server
 func (a *OAuthAdapter) HandleAuthServerMetadata(w http.ResponseWriter, r *http.Request) {
-	metadata := map[string]interface{}{
-		"issuer":                           a.serverURL,
-		"authorization_endpoint":           a.serverURL + "/oauth/authorize",
-		"token_endpoint":                   a.serverURL + "/oauth/token",
-		"registration_endpoint":            a.serverURL + "/oauth/register",
-		"response_types_supported":         []string{"code"},
-		"grant_types_supported":            []string{"authorization_code"},
-		"code_challenge_methods_supported": []string{"S256"},
+	metadata := a.metadata
+	metadata.RequirePKCE = a.requirePKCE
+	if a.jwt != nil {
+		metadata.JWKSURI = a.serverURL + "/.well-known/jwks.json"
 	}
+	metadata.HandleAuthorizationServer(w, r)
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(metadata); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+// Clients returns the adapter's registered OAuth clients
+func (a *OAuthAdapter) Clients() *Clients {
+	return a.clients
+}
+
+// UseAuditLog records authorization attempts, issued tokens, refused
+// bearer tokens and revocations in auditLog
+func (a *OAuthAdapter) UseAuditLog(auditLog AuditLog) {
+	a.audit = NewAuditor(auditLog)
+}
+
+// Auditor returns where the adapter records authentication events, for
+// middleware that refuses tokens
+func (a *OAuthAdapter) Auditor() *Auditor {
+	return a.audit
+}
+
+// UseJWT switches the adapter to issuing JWT access tokens signed with keys
+// kept in its store, as OAUTH_ACCESS_TOKEN_FORMAT=jwt does
+func (a *OAuthAdapter) UseJWT(rotation time.Duration) {
+	a.jwt = NewJWTSigner(a.store, a.metadata.Issuer, rotation, a.accessTTL)
+}
+
+// HandleJWKS handles /.well-known/jwks.json, publishing the keys access
+// tokens are signed with so other services can validate them locally
+func (a *OAuthAdapter) HandleJWKS(w http.ResponseWriter, r *http.Request) {
+	if a.jwt == nil {
+		WriteJSONError(w, r, http.StatusNotFound, "invalid_request", "Access tokens are opaque; set OAUTH_ACCESS_TOKEN_FORMAT=jwt to issue signed tokens", "")
+		return
 	}
+	a.jwt.HandleJWKS(w, r)
 }
 
 // HandleAuthorize handles /oauth/authorize
@@ -123,13 +299,43 @@ func (a *OAuthAdapter) HandleAuthorize(w http.ResponseWriter, r *http.Request) {
 	clientID := r.URL.Query().Get("client_id")
 	redirectURI := r.URL.Query().Get("redirect_uri")
 	clientState := r.URL.Query().Get("state") // Client's state parameter
-	resource := r.URL.Query().Get("resource") // June 2025 spec
+	codeChallenge := r.URL.Query().Get("code_challenge")
+	codeChallengeMethod := r.URL.Query().Get("code_challenge_method")
 
 	// Generate CSRF token (stateless - just a UUID)
 	csrfState := uuid.New().String()
 
 	// For RTM adapter, show API key input form
 	if r.Method == "GET" {
+		// Don't offer the form for a redirect the client never registered
+		if cerr := a.clients.CheckAuthorize(clientID, redirectURI); cerr != nil {
+			WriteError(w, r, cerr.Status, cerr.Code, cerr.Description, "")
+			return
+		}
+		if cerr := CheckPKCEChallenge(codeChallenge, codeChallengeMethod, a.requirePKCE); cerr != nil {
+			WriteError(w, r, cerr.Status, cerr.Code, cerr.Description, "")
+			return
+		}
+		if cerr := CheckState(clientState); cerr != nil {
+			WriteError(w, r, cerr.Status, cerr.Code, cerr.Description, "")
+			return
+		}
+		req := authRequest{
+			ClientID:      clientID,
+			RedirectURI:   redirectURI,
+			ClientState:   clientState,
+			CodeChallenge: codeChallenge,
+		}
+		if a.oidc != nil {
+			a.startOIDC(w, r, req)
+			return
+		}
+		if err := a.savePendingAuthorization(csrfState, req); err != nil {
+			fmt.Printf("[OAuth] ERROR: Failed to save authorization request: %v\n", err)
+			WriteError(w, r, http.StatusServiceUnavailable, "temporarily_unavailable", "The sign-in form could not be prepared. Try again.", RetryURL(r))
+			return
+		}
+
 		// Set CSRF cookie
 		http.SetCookie(w, &http.Cookie{
 			Name:     "csrf_token",
@@ -140,7 +346,8 @@ func (a *OAuthAdapter) HandleAuthorize(w http.ResponseWriter, r *http.Request) {
 			MaxAge:   600, // 10 minutes
 		})
 
-		// IMPORTANT: Form submits directly back to this same URL
+		// IMPORTANT: Form submits directly back to this same URL, carrying
+		// only the CSRF token; what the client asked for stays server-side
 		// No intermediate pages!
 		html := fmt.Sprintf(`<!DOCTYPE html>
 <html>
@@ -214,11 +421,7 @@ func (a *OAuthAdapter) HandleAuthorize(w http.ResponseWriter, r *http.Request) {
 		<h1>🐄 Connect Remember The Milk</h1>
 		<p>Enter your RTM API Key to authorize Claude to access your tasks.</p>
 		<form method="POST">
-			<input type="hidden" name="client_id" value="%s">
-			<input type="hidden" name="redirect_uri" value="%s">
-			<input type="hidden" name="client_state" value="%s">
 			<input type="hidden" name="csrf_state" value="%s">
-			<input type="hidden" name="resource" value="%s">
 			<label>
 				RTM API Key:
 				<input type="password" name="api_key" required autofocus>
@@ -230,7 +433,7 @@ func (a *OAuthAdapter) HandleAuthorize(w http.ResponseWriter, r *http.Request) {
 		</div>
 	</div>
 </body>
-</html>`, clientID, redirectURI, clientState, csrfState, resource)
+</html>`, csrfState)
 
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		if _, err := w.Write([]byte(html)); err != nil {
@@ -240,42 +443,87 @@ func (a *OAuthAdapter) HandleAuthorize(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// With OIDC sign-in, keys are only linked after the provider vouches
+	// for the user, never typed straight into this form
+	if a.oidc != nil {
+		WriteError(w, r, http.StatusBadRequest, "invalid_request",
+			"Sign in through your organization's identity provider instead.", "")
+		return
+	}
+
 	// Handle form submission (POST)
 	apiKey := r.FormValue("api_key")
 	csrfState = r.FormValue("csrf_state")
-	clientState = r.FormValue("client_state")
-	formRedirectURI := r.FormValue("redirect_uri")
 
-	fmt.Printf("[OAuth] Form submission: has_api_key=%v, csrf_state=%s, client_state=%s\n",
-		apiKey != "", csrfState, clientState)
+	fmt.Printf("[OAuth] Form submission: has_api_key=%v, csrf_state=%s\n", apiKey != "", csrfState)
 
 	// Validate CSRF token from cookie
 	cookie, err := r.Cookie("csrf_token")
 	if err != nil || cookie.Value == "" {
-		http.Error(w, "Missing CSRF cookie", http.StatusBadRequest)
+		a.audit.Record(r, AuditAuthorize, AuditFailure, "", "missing CSRF cookie")
+		WriteError(w, r, http.StatusBadRequest, "invalid_request",
+			"Your browser did not send the session cookie for this form. Make sure cookies are enabled for this site, then try again.", RetryURL(r))
 		return
 	}
 
 	// Verify the form token matches the cookie
 	if csrfState != cookie.Value {
-		http.Error(w, "Invalid CSRF token", http.StatusBadRequest)
+		a.audit.Record(r, AuditAuthorize, AuditFailure, "", "CSRF token mismatch")
+		WriteError(w, r, http.StatusBadRequest, "invalid_request",
+			"This form has expired or was opened in another tab. Try again to get a fresh form.", RetryURL(r))
 		return
 	}
 
 	if apiKey == "" {
-		http.Error(w, "API key required", http.StatusBadRequest)
+		a.audit.Record(r, AuditAuthorize, AuditFailure, "", "missing API key")
+		WriteError(w, r, http.StatusBadRequest, "invalid_request", "An RTM API key is required.", RetryURL(r))
 		return
 	}
 
+	// Redirect only where, and with the state, the form was opened for;
+	// anything else posted is ignored
+	req, ok := a.takePendingAuthorization(csrfState)
+	if !ok {
+		a.audit.Record(r, AuditAuthorize, AuditFailure, "", "expired or reused form")
+		WriteError(w, r, http.StatusBadRequest, "invalid_request",
+			"This form has expired or was already submitted. Start again from your app.", "")
+		return
+	}
+	// The client's registration may have changed since the form was opened
+	if cerr := a.clients.CheckAuthorize(req.ClientID, req.RedirectURI); cerr != nil {
+		a.audit.Record(r, AuditAuthorize, AuditFailure, req.ClientID, cerr.Code)
+		WriteError(w, r, cerr.Status, cerr.Code, cerr.Description, "")
+		return
+	}
+
+	a.completeAuthorization(w, r, apiKey, req)
+}
+
+// authRequest is what a client asked for at /oauth/authorize, carried
+// through sign-in until a code is issued for it
+type authRequest struct {
+	ClientID      string `json:"client_id"`
+	RedirectURI   string `json:"redirect_uri"`
+	ClientState   string `json:"client_state,omitempty"`
+	CodeChallenge string `json:"code_challenge,omitempty"`
+}
+
+// completeAuthorization issues a code for apiKey and sends the browser
+// back to the client with it
+func (a *OAuthAdapter) completeAuthorization(w http.ResponseWriter, r *http.Request, apiKey string, req authRequest) {
 	// Generate auth code
 	code := uuid.New().String()
-	a.authCodes[code] = &AuthCode{
-		Code:      code,
-		RTMAPIKey: apiKey,
-		ExpiresAt: time.Now().Add(10 * time.Minute),
-	}
+	a.saveCode(&AuthCode{
+		Code:          code,
+		RTMAPIKey:     apiKey,
+		ClientID:      req.ClientID,
+		RedirectURI:   req.RedirectURI,
+		CodeChallenge: req.CodeChallenge,
+		ExpiresAt:     time.Now().Add(authCodeLifetime),
+	})
 
 	fmt.Printf("[OAuth] Generated auth code: %s (expires in 10 min)\n", code)
+	a.audit.Record(r, AuditAuthorize, AuditSuccess, req.ClientID, "")
 
 	// Clear CSRF cookie
 	http.SetCookie(w, &http.Cookie{
@@ -289,10 +537,10 @@ func (a *OAuthAdapter) HandleAuthorize(w http.ResponseWriter, r *http.Request) {
 	// CRITICAL: Immediately redirect back to Claude with the authorization code
 	// No intermediate pages, no "success" page, no "Open RTM" button!
 	// Claude is waiting for this redirect to complete the OAuth flow.
-	u, _ := url.Parse(formRedirectURI)
+	u, _ := url.Parse(req.RedirectURI)
 	q := u.Query()
 	q.Set("code", code)
-	q.Set("state", clientState) // Return client's original state
+	q.Set("state", req.ClientState) // Return client's original state
 	u.RawQuery = q.Encode()
 
 	fmt.Printf("[OAuth] Immediately redirecting back to Claude: %s\n", u.String())
@@ -307,76 +555,308 @@ func (a *OAuthAdapter) HandleAuthorize(w http.ResponseWriter, r *http.Request) {
 // HandleToken handles /oauth/token
 func (a *OAuthAdapter) HandleToken(w http.ResponseWriter, r *http.Request) {
 	fmt.Printf("[OAuth] Token request: method=%s\n", r.Method)
+	ip := ClientIP(r)
+	if wait := a.guard.Throttle("token", ip); wait > 0 {
+		a.guard.RejectThrottled(w, r, wait)
+		return
+	}
 
 	// Parse form data
 	if err := r.ParseForm(); err != nil {
 		fmt.Printf("[OAuth] ERROR: Failed to parse form: %v\n", err)
-		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+		WriteJSONError(w, r, http.StatusBadRequest, "invalid_request", "Request body is not a valid form", "")
 		return
 	}
 	grantType := r.FormValue("grant_type")
 	code := r.FormValue("code")
+	clientID, cerr := a.clients.Authenticate(r)
+	if cerr != nil {
+		a.guard.Failure("client", ip, "")
+		WriteClientError(w, r, cerr)
+		return
+	}
+	if grantType == "refresh_token" {
+		a.handleRefreshToken(w, r, ip, clientID)
+		return
+	}
+	if wait := a.guard.Check(ip, code); wait > 0 {
+		a.guard.Reject(w, r, wait)
+		return
+	}
 	// resource := r.FormValue("resource") // June 2025 spec - TODO: use for validation
 
 	fmt.Printf("[OAuth] Token request: grant_type=%s, code=%s\n", grantType, code)
 
 	if grantType != "authorization_code" {
-		http.Error(w, "Unsupported grant type", http.StatusBadRequest)
+		WriteJSONError(w, r, http.StatusBadRequest, "unsupported_grant_type", "Only authorization_code and refresh_token are supported", "")
+		return
+	}
+
+	// A code presented again after its exchange may have leaked
+	if replayed, err := a.redeemed.Redeemed(code); err != nil {
+		fmt.Printf("[OAuth] WARNING: Failed to read code redemptions: %v\n", err)
+	} else if replayed {
+		a.refuseReplay(w, r, ip, code)
 		return
 	}
 
 	// Validate auth code
-	authCode, exists := a.authCodes[code]
-	if !exists || time.Now().After(authCode.ExpiresAt) {
-		fmt.Printf("[OAuth] ERROR: Invalid or expired code: %s (exists=%v)\n", code, exists)
-		http.Error(w, "Invalid or expired code", http.StatusBadRequest)
+	authCode, exists := a.lookupCode(code)
+	if !exists {
+		fmt.Printf("[OAuth] ERROR: Invalid code: %s\n", code)
+		a.guard.Failure("token", ip, code)
+		WriteJSONError(w, r, http.StatusBadRequest, "invalid_grant", "Invalid authorization code", "")
+		return
+	}
+	if time.Now().After(authCode.ExpiresAt) {
+		fmt.Printf("[OAuth] ERROR: Expired code: %s\n", code)
+		a.removeCode(code)
+		WriteJSONError(w, r, http.StatusBadRequest, "invalid_grant",
+			fmt.Sprintf("Authorization code expired after %.0f minutes; start the authorization flow again", authCodeLifetime.Minutes()), "")
+		return
+	}
+
+	// The code only works for the client it was issued to, and when the
+	// redirect_uri is repeated it must be the one the code was sent to
+	if !a.clients.MayRedeem(authCode.ClientID, clientID) {
+		fmt.Printf("[OAuth] ERROR: Code issued to %s redeemed by %s\n", authCode.ClientID, clientID)
+		a.guard.Failure("token", ip, code)
+		WriteJSONError(w, r, http.StatusBadRequest, "invalid_grant", "The code was issued to another client", "")
+		return
+	}
+	if redirectURI := r.FormValue("redirect_uri"); redirectURI != "" && redirectURI != authCode.RedirectURI {
+		a.guard.Failure("token", ip, code)
+		WriteJSONError(w, r, http.StatusBadRequest, "invalid_grant", "redirect_uri does not match the authorization request", "")
+		return
+	}
+
+	// Validate PKCE if a challenge was sent with the authorization request
+	if authCode.CodeChallenge != "" {
+		codeVerifier := r.FormValue("code_verifier")
+		if codeVerifier == "" {
+			a.guard.Failure("token", ip, code)
+			WriteJSONError(w, r, http.StatusBadRequest, "invalid_request", "Missing code_verifier for PKCE", "")
+			return
+		}
+		if !VerifyPKCE(authCode.CodeChallenge, codeVerifier) {
+			a.guard.Failure("token", ip, code)
+			WriteJSONError(w, r, http.StatusBadRequest, "invalid_grant", "Invalid code_verifier", "")
+			return
+		}
+	} else if a.requirePKCE {
+		// Issued before PKCE was required
+		WriteJSONError(w, r, http.StatusBadRequest, "invalid_grant", "The code was issued without PKCE, which this server requires", "")
+		return
+	}
+
+	// Only the first of several requests racing with the code gets tokens
+	claimed, err := a.redeemed.Claim(code)
+	if err != nil {
+		fmt.Printf("[OAuth] ERROR: Failed to claim auth code: %v\n", err)
+		WriteJSONError(w, r, http.StatusInternalServerError, "server_error", "The code could not be redeemed. Try again.", "")
+		return
+	}
+	if !claimed {
+		a.refuseReplay(w, r, ip, code)
 		return
 	}
 
 	fmt.Printf("[OAuth] Code validated successfully\n")
+	a.guard.Success(ip, code)
+
+	// Clean up auth code (one-time use)
+	a.removeCode(code)
+
+	if authCode.ClientID != "" {
+		clientID = authCode.ClientID
+	}
+	a.issueTokens(w, r, authCode.RTMAPIKey, clientID, TokenKey(code))
+}
+
+// refuseReplay answers a code that was already exchanged, revoking the
+// tokens issued from it since the code may have leaked (RFC 6749 4.1.2)
+func (a *OAuthAdapter) refuseReplay(w http.ResponseWriter, r *http.Request, ip, code string) {
+	fmt.Printf("[OAuth] ERROR: Auth code replayed\n")
+	a.guard.Replay("token", ip, code)
+
+	tokens, err := a.redeemed.TakeTokens(code)
+	if err != nil {
+		fmt.Printf("[OAuth] ERROR: Failed to read tokens issued from replayed code: %v\n", err)
+	}
+	for _, token := range tokens {
+		a.tokenStore.Delete(token)
+		if _, err := a.refresh.Revoke(token); err != nil {
+			fmt.Printf("[OAuth] ERROR: Failed to revoke refresh token: %v\n", err)
+		}
+	}
+	if len(tokens) > 0 {
+		fmt.Printf("[OAuth] Revoked %d token(s) issued from replayed code\n", len(tokens))
+		a.audit.Record(r, AuditRevoked, AuditSuccess, "", "code_replay")
+	}
+	WriteJSONError(w, r, http.StatusBadRequest, "invalid_grant", "The code has already been used", "")
+}
+
+// handleRefreshToken exchanges a refresh token for a new access token and
+// a replacement refresh token, without the browser flow
+func (a *OAuthAdapter) handleRefreshToken(w http.ResponseWriter, r *http.Request, ip, clientID string) {
+	refreshToken := r.FormValue("refresh_token")
+	if wait := a.guard.Check(ip, refreshToken); wait > 0 {
+		a.guard.Reject(w, r, wait)
+		return
+	}
+	if refreshToken == "" {
+		a.guard.Failure("refresh", ip, "")
+		WriteJSONError(w, r, http.StatusBadRequest, "invalid_request", "Missing refresh_token parameter", "")
+		return
+	}
+
+	grant, ok, err := a.refresh.Redeem(refreshToken)
+	if err != nil {
+		fmt.Printf("[OAuth] ERROR: Failed to read refresh token: %v\n", err)
+		WriteJSONError(w, r, http.StatusInternalServerError, "server_error", "The refresh token could not be checked. Try again.", "")
+		return
+	}
+	if !ok {
+		a.guard.Failure("refresh", ip, refreshToken)
+		WriteJSONError(w, r, http.StatusBadRequest, "invalid_grant", "Invalid, expired or already used refresh token", "")
+		return
+	}
 
-	// Generate bearer token
+	if !a.clients.MayRedeem(grant.ClientID, clientID) {
+		a.guard.Failure("refresh", ip, refreshToken)
+		WriteJSONError(w, r, http.StatusBadRequest, "invalid_grant", "The refresh token was issued to another client", "")
+		return
+	}
+
+	fmt.Printf("[OAuth] Refresh token redeemed\n")
+	a.guard.Success(ip, refreshToken)
+	a.issueTokens(w, r, grant.Credential, grant.ClientID, grant.CodeKey)
+}
+
+// issueTokens stores a new bearer token for apiKey and answers with it and
+// a refresh token for the next one, bound to clientID
+func (a *OAuthAdapter) issueTokens(w http.ResponseWriter, r *http.Request, apiKey, clientID, codeKey string) {
 	token := uuid.New().String()
-	a.tokenStore.Store(token, authCode.RTMAPIKey)
+	if a.jwt != nil {
+		var err error
+		token, err = a.jwt.Sign(AccessClaims{
+			Subject:  TokenKey(apiKey)[:16], // Stable per RTM account without revealing the key
+			Audience: a.serverURL + "/mcp",
+			ClientID: clientID,
+		})
+		if err != nil {
+			fmt.Printf("[OAuth] ERROR: Failed to sign access token: %v\n", err)
+			WriteJSONError(w, r, http.StatusInternalServerError, "server_error", "The access token could not be issued. Try again.", "")
+			return
+		}
+	}
+	// JWTs are stored too, for the RTM API key they stand for and so
+	// revoking one takes effect here at once
+	a.tokenStore.Store(token, apiKey)
 
 	fmt.Printf("[OAuth] Generated bearer token: %s...\n", token[:8])
 
-	// Clean up auth code (one-time use)
-	delete(a.authCodes, code)
+	refreshToken, err := a.refresh.Issue(RefreshGrant{Credential: apiKey, ClientID: clientID, CodeKey: codeKey})
+	if err != nil {
+		// The access token still works; the client authorizes again when it expires
+		fmt.Printf("[OAuth] WARNING: Failed to issue refresh token: %v\n", err)
+	}
+	if codeKey != "" {
+		if err := a.redeemed.RecordTokens(codeKey, token, refreshToken); err != nil {
+			fmt.Printf("[OAuth] WARNING: Failed to record tokens issued from code: %v\n", err)
+		}
+	}
 
 	// Return token response
-	response := map[string]interface{}{
-		"access_token": token,
-		"token_type":   "Bearer",
-		"expires_in":   3600,
+	response := TokenResponse{
+		AccessToken:  token,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(a.accessTTL.Seconds()), // Omitted when tokens don't expire
+		RefreshToken: refreshToken,
 	}
 
+	a.audit.Record(r, AuditTokenIssued, AuditSuccess, clientID, r.FormValue("grant_type"))
+
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 	}
 }
 
-// HandleRegister handles /oauth/register (DCR)
-func (a *OAuthAdapter) HandleRegister(w http.ResponseWriter, r *http.Request) {
-	// Simple DCR implementation - accept any client
-	var req map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request", http.StatusBadRequest)
+// HandleRevoke handles /oauth/revoke (RFC 7009). The token is removed
+// whether it is an access or refresh token, so token_type_hint is not
+// needed; unknown tokens are not an error, so a client can always
+// disconnect cleanly.
+func (a *OAuthAdapter) HandleRevoke(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		WriteJSONError(w, r, http.StatusMethodNotAllowed, "invalid_request", "Use POST to revoke a token", "")
+		return
+	}
+	if wait := a.guard.Throttle("revoke", ClientIP(r)); wait > 0 {
+		a.guard.RejectThrottled(w, r, wait)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		WriteJSONError(w, r, http.StatusBadRequest, "invalid_request", "The form could not be read.", "")
+		return
+	}
+	token := r.FormValue("token")
+	if token == "" {
+		WriteJSONError(w, r, http.StatusBadRequest, "invalid_request", "Missing token parameter", "")
 		return
 	}
 
-	clientID := uuid.New().String()
-	response := map[string]interface{}{
-		"client_id":     clientID,
-		"client_name":   req["client_name"],
-		"redirect_uris": req["redirect_uris"],
+	a.tokenStore.Delete(token)
+	if _, err := a.refresh.Revoke(token); err != nil {
+		fmt.Printf("[OAuth] ERROR: Failed to revoke refresh token: %v\n", err)
+		WriteJSONError(w, r, http.StatusServiceUnavailable, "temporarily_unavailable", "The token could not be revoked. Try again.", "")
+		return
 	}
+	fmt.Printf("[OAuth] Token revoked\n")
+	a.audit.Record(r, AuditRevoked, AuditSuccess, "", "")
+	w.WriteHeader(http.StatusOK)
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+// HandleIntrospect handles /oauth/introspect (RFC 7662), telling a
+// registered confidential client, such as another service behind the same
+// gateway, whether an access token is live. Refresh tokens are never
+// described.
+func (a *OAuthAdapter) HandleIntrospect(w http.ResponseWriter, r *http.Request) {
+	token, ok := IntrospectionToken(w, r, a.clients, a.guard)
+	if !ok {
+		return
+	}
+	response := IntrospectionResponse{Issuer: a.metadata.Issuer, TokenType: "Bearer"}
+	if a.jwt != nil && strings.Count(token, ".") == 2 {
+		claims, err := a.jwt.Verify(token)
+		if err != nil {
+			WriteIntrospection(w, IntrospectionResponse{})
+			return
+		}
+		response.ClientID = claims.ClientID
+		response.Subject = claims.Subject
+		response.Audience = claims.Audience
+		response.Scope = claims.Scope
+		response.IssuedAt = claims.IssuedAt
+		response.ExpiresAt = claims.ExpiresAt
 	}
+	_, response.Active = a.tokenStore.Get(token)
+	WriteIntrospection(w, response)
+}
+
+// HandleRegister handles /oauth/register (DCR)
+func (a *OAuthAdapter) HandleRegister(w http.ResponseWriter, r *http.Request) {
+	if wait := a.guard.Throttle("register", ClientIP(r)); wait > 0 {
+		a.guard.RejectThrottled(w, r, wait)
+		return
+	}
+	a.clients.HandleRegister(w, r)
+}
+
+// HandleClientConfiguration handles /oauth/register/{client_id}, where a
+// registered client reads, updates or deletes its registration
+func (a *OAuthAdapter) HandleClientConfiguration(w http.ResponseWriter, r *http.Request) {
+	a.clients.HandleConfiguration(w, r)
 }
 
 // ValidateToken checks if bearer token is valid and returns RTM API key
@@ -390,6 +870,13 @@ func (a *OAuthAdapter) ValidateToken(authHeader string) (string, error) {
 	}
 
 	token := strings.TrimPrefix(authHeader, "Bearer ")
+	// Opaque tokens issued before JWTs were turned on stay valid
+	if a.jwt != nil && strings.Count(token, ".") == 2 {
+		if _, err := a.jwt.Verify(token); err != nil {
+			fmt.Printf("[OAuth] ERROR: JWT rejected: %v\n", err)
+			return "", fmt.Errorf("invalid token")
+		}
+	}
 	apiKey, exists := a.tokenStore.Get(token)
 	if !exists {
 		fmt.Printf("[OAuth] ERROR: Token not found in store\n")
@@ -1,13 +1,17 @@
 package auth
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"regexp"
 	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/vcto/mcp-adapters/internal/kv"
 )
 
 func TestOAuthAdapterAuthorizeFlow(t *testing.T) {
@@ -50,7 +54,7 @@ func TestOAuthAdapterAuthorizeFlow(t *testing.T) {
 	t.Run("generates an authorization code on a valid form submission", func(t *testing.T) {
 		t.Logf("  > Why it's important: This is the successful path for the first leg of OAuth, ensuring a valid user submission results in an auth code.")
 		// Step 1: GET to get a valid CSRF token and cookie
-		reqGet := httptest.NewRequest("GET", "/oauth/authorize", nil)
+		reqGet := httptest.NewRequest("GET", "/oauth/authorize?state=abc123&redirect_uri=http://localhost/cb", nil)
 		wGet := httptest.NewRecorder()
 		adapter.HandleAuthorize(wGet, reqGet)
 		csrfCookie := wGet.Result().Cookies()[0]
@@ -130,6 +134,25 @@ func TestOAuthAdapterTokenFlow(t *testing.T) {
 		if w.Code != http.StatusBadRequest {
 			t.Errorf("Expected 400 Bad Request for expired code, got %d", w.Code)
 		}
+		if !strings.Contains(w.Body.String(), "expired after 10 minutes") {
+			t.Errorf("Expected the expiry explained, got %s", w.Body.String())
+		}
+		if _, exists := adapter.authCodes["expired-code"]; exists {
+			t.Error("Expired code should be removed")
+		}
+	})
+
+	t.Run("cleanup removes only expired codes", func(t *testing.T) {
+		t.Logf("  > Why it's important: Codes from abandoned authorizations must not accumulate in memory for the life of the process.")
+		adapter.authCodes["abandoned"] = &AuthCode{Code: "abandoned", ExpiresAt: time.Now().Add(-time.Minute)}
+		adapter.authCodes["pending"] = &AuthCode{Code: "pending", ExpiresAt: time.Now().Add(time.Minute)}
+
+		if removed := adapter.removeExpiredCodes(); removed != 1 {
+			t.Errorf("Expected 1 code removed, got %d", removed)
+		}
+		if _, exists := adapter.authCodes["pending"]; !exists {
+			t.Error("Unexpired code should be kept")
+		}
 	})
 }
 
@@ -193,3 +216,310 @@ func TestOAuthAdapterMiddleware(t *testing.T) {
 		}
 	})
 }
+
+func TestOAuthStatePersistence(t *testing.T) {
+	t.Logf("Importance: Codes and tokens issued before a restart or deploy must keep working, or every user has to authorize again.")
+	t.Setenv("GO_TEST", "1")
+	t.Setenv("TOKEN_DB_PATH", "")
+	t.Setenv("OAUTH_DB_PATH", t.TempDir()+"/oauth.db")
+
+	exchange := func(adapter *OAuthAdapter, code string) (int, string) {
+		form := url.Values{"grant_type": {"authorization_code"}, "code": {code}}
+		req := httptest.NewRequest("POST", "/oauth/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		adapter.HandleToken(w, req)
+		var response map[string]interface{}
+		_ = json.NewDecoder(w.Body).Decode(&response)
+		token, _ := response["access_token"].(string)
+		return w.Code, token
+	}
+
+	first := NewOAuthAdapter("http://localhost:8080", 9090)
+	first.saveCode(&AuthCode{Code: "kept-code", RTMAPIKey: "rtm-key", ExpiresAt: time.Now().Add(5 * time.Minute)})
+	if err := first.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	second := NewOAuthAdapter("http://localhost:8080", 9090)
+	status, token := exchange(second, "kept-code")
+	if status != http.StatusOK || token == "" {
+		t.Fatalf("Expected a code issued before the restart to exchange, got %d", status)
+	}
+	if err := second.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	third := NewOAuthAdapter("http://localhost:8080", 9090)
+	defer third.Close()
+	if apiKey, err := third.ValidateToken("Bearer " + token); err != nil || apiKey != "rtm-key" {
+		t.Errorf("Expected the token to survive the restart, got %q, %v", apiKey, err)
+	}
+	// Replaying the code also revokes the token, so this comes last
+	if status, _ := exchange(third, "kept-code"); status != http.StatusBadRequest {
+		t.Errorf("Expected the code to stay single use after the restart, got %d", status)
+	}
+
+	t.Run("pluggable store", func(t *testing.T) {
+		t.Logf("  > Why it's important: Tests and other deployments can keep codes and tokens in a store of their choosing.")
+		store := kv.NewMemoryStore()
+		if err := third.SetStore(store); err != nil {
+			t.Fatalf("SetStore: %v", err)
+		}
+		third.saveCode(&AuthCode{Code: "memory-code", RTMAPIKey: "other-key", ExpiresAt: time.Now().Add(time.Minute)})
+		_, token := exchange(third, "memory-code")
+		if keys, _ := store.Keys(tokenBucket); len(keys) != 1 || keys[0] == token {
+			t.Errorf("Expected one token stored under its hash, got %v", keys)
+		}
+		if apiKey, err := third.ValidateToken("Bearer " + token); err != nil || apiKey != "other-key" {
+			t.Errorf("Expected the token read from the new store, got %q, %v", apiKey, err)
+		}
+	})
+}
+
+// sharedStore lets several adapters use one store, as instances sharing a
+// Redis server do, without the first to close it closing it for all
+type sharedStore struct{ kv.Store }
+
+func (sharedStore) Close() error { return nil }
+
+func TestTokenBackends(t *testing.T) {
+	t.Logf("Importance: Instances behind a load balancer must share token state, or a sign-in breaks whenever requests land on different instances.")
+	t.Setenv("GO_TEST", "1")
+	t.Setenv("TOKEN_DB_PATH", "")
+
+	t.Run("selection", func(t *testing.T) {
+		t.Logf("  > Why it's important: Existing deployments must keep their backend when OAUTH_TOKEN_STORE is unset.")
+		for _, tc := range []struct{ backend, dbPath, want string }{
+			{"", "", TokenBackendMemory},
+			{"", "/data/oauth.db", TokenBackendSQLite},
+			{"redis", "/data/oauth.db", TokenBackendRedis},
+			{"Memory", "/data/oauth.db", TokenBackendMemory},
+			{"etcd", "", TokenBackendMemory},
+		} {
+			t.Setenv("OAUTH_TOKEN_STORE", tc.backend)
+			t.Setenv("OAUTH_DB_PATH", tc.dbPath)
+			if got := TokenBackendFromEnv(); got != tc.want {
+				t.Errorf("OAUTH_TOKEN_STORE=%q OAUTH_DB_PATH=%q: expected %s, got %s", tc.backend, tc.dbPath, tc.want, got)
+			}
+		}
+	})
+
+	t.Run("implementations", func(t *testing.T) {
+		t.Logf("  > Why it's important: Each backend must be a real TokenStore, chosen as configured.")
+		t.Setenv("OAUTH_DB_PATH", t.TempDir()+"/oauth.db")
+		for backend, check := range map[string]func(TokenStore) bool{
+			TokenBackendMemory: func(s TokenStore) bool { _, ok := s.(*MemoryTokenStore); return ok },
+			TokenBackendSQLite: func(s TokenStore) bool { _, ok := s.(*SQLiteTokenStore); return ok },
+			TokenBackendRedis:  func(s TokenStore) bool { _, ok := s.(*KVTokenStore); return ok },
+		} {
+			t.Setenv("OAUTH_TOKEN_STORE", backend)
+			store := CreateTokenStore(kv.NewMemoryStore(), time.Hour)
+			if !check(store) {
+				t.Errorf("Unexpected store %T for %s", store, backend)
+			}
+			store.Store("token", "api-key")
+			if apiKey, ok := store.Get("token"); !ok || apiKey != "api-key" {
+				t.Errorf("%s: expected the token stored, got %q", backend, apiKey)
+			}
+			_ = store.Close()
+		}
+	})
+
+	t.Run("unreachable redis", func(t *testing.T) {
+		t.Logf("  > Why it's important: A misconfigured REDIS_URL must not stop the server starting.")
+		t.Setenv("OAUTH_TOKEN_STORE", TokenBackendRedis)
+		t.Setenv("REDIS_URL", "redis://127.0.0.1:1")
+		store := OpenOAuthStore()
+		defer store.Close()
+		if _, ok := store.(*kv.MemoryStore); !ok {
+			t.Errorf("Expected the memory fallback, got %T", store)
+		}
+	})
+
+	t.Run("instances share state", func(t *testing.T) {
+		t.Logf("  > Why it's important: A code issued by one instance must be redeemable on another, and its token valid on both.")
+		t.Setenv("OAUTH_TOKEN_STORE", "")
+		t.Setenv("OAUTH_DB_PATH", "")
+		shared := sharedStore{kv.NewMemoryStore()}
+		first := NewOAuthAdapter("http://localhost:8080", 9090)
+		defer first.Close()
+		second := NewOAuthAdapter("http://localhost:8080", 9090)
+		defer second.Close()
+		for _, adapter := range []*OAuthAdapter{first, second} {
+			if err := adapter.SetStore(shared); err != nil {
+				t.Fatalf("SetStore: %v", err)
+			}
+		}
+
+		first.saveCode(&AuthCode{Code: "shared-code", RTMAPIKey: "rtm-key", ExpiresAt: time.Now().Add(time.Minute)})
+		form := url.Values{"grant_type": {"authorization_code"}, "code": {"shared-code"}}
+		req := httptest.NewRequest("POST", "/oauth/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		second.HandleToken(w, req)
+		var response TokenResponse
+		_ = json.NewDecoder(w.Body).Decode(&response)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected the code redeemed on the other instance, got %d", w.Code)
+		}
+		if apiKey, err := first.ValidateToken("Bearer " + response.AccessToken); err != nil || apiKey != "rtm-key" {
+			t.Errorf("Expected the token valid on the first instance, got %q %v", apiKey, err)
+		}
+
+		first.saveCode(&AuthCode{Code: "raced-code", RTMAPIKey: "rtm-key", ExpiresAt: time.Now().Add(time.Minute)})
+		var wg sync.WaitGroup
+		codes := make([]int, 8)
+		for i := range codes {
+			wg.Add(1)
+			go func(i int, adapter *OAuthAdapter) {
+				defer wg.Done()
+				form := url.Values{"grant_type": {"authorization_code"}, "code": {"raced-code"}}
+				req := httptest.NewRequest("POST", "/oauth/token", strings.NewReader(form.Encode()))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				w := httptest.NewRecorder()
+				adapter.HandleToken(w, req)
+				codes[i] = w.Code
+			}(i, []*OAuthAdapter{first, second}[i%2])
+		}
+		wg.Wait()
+		granted := 0
+		for _, code := range codes {
+			if code == http.StatusOK {
+				granted++
+			}
+		}
+		if granted != 1 {
+			t.Errorf("Expected one instance to redeem a raced code, got %d grants (%v)", granted, codes)
+		}
+	})
+}
+
+// TestRefreshTokenGrant tests renewing an access token with a refresh token
+func TestRefreshTokenGrant(t *testing.T) {
+	t.Logf("Importance: Access tokens expire after an hour; clients must renew them without sending the user through the browser again.")
+	t.Setenv("GO_TEST", "1")
+	t.Setenv("TOKEN_DB_PATH", "")
+	t.Setenv("OAUTH_DB_PATH", "")
+
+	adapter := NewOAuthAdapter("http://localhost:8080", 9090)
+	defer adapter.Close()
+	adapter.saveCode(&AuthCode{Code: "refresh-code", RTMAPIKey: "rtm-key", ExpiresAt: time.Now().Add(5 * time.Minute)})
+
+	token := func(form url.Values) (int, map[string]interface{}) {
+		req := httptest.NewRequest("POST", "/oauth/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		adapter.HandleToken(w, req)
+		var response map[string]interface{}
+		_ = json.NewDecoder(w.Body).Decode(&response)
+		return w.Code, response
+	}
+
+	status, first := token(url.Values{"grant_type": {"authorization_code"}, "code": {"refresh-code"}})
+	refreshToken, _ := first["refresh_token"].(string)
+	if status != http.StatusOK || refreshToken == "" {
+		t.Fatalf("Expected a refresh token with the access token, got %d %v", status, first)
+	}
+
+	status, renewed := token(url.Values{"grant_type": {"refresh_token"}, "refresh_token": {refreshToken}})
+	accessToken, _ := renewed["access_token"].(string)
+	if status != http.StatusOK || accessToken == "" || accessToken == first["access_token"] {
+		t.Fatalf("Expected a new access token, got %d %v", status, renewed)
+	}
+	if apiKey, err := adapter.ValidateToken("Bearer " + accessToken); err != nil || apiKey != "rtm-key" {
+		t.Errorf("Expected the renewed token to act with the same key, got %q, %v", apiKey, err)
+	}
+	if next, _ := renewed["refresh_token"].(string); next == "" || next == refreshToken {
+		t.Errorf("Expected a replacement refresh token, got %q", next)
+	}
+
+	t.Run("used once", func(t *testing.T) {
+		t.Logf("  > Why it's important: Rotating refresh tokens limits what a stolen one is worth.")
+		status, response := token(url.Values{"grant_type": {"refresh_token"}, "refresh_token": {refreshToken}})
+		if status != http.StatusBadRequest || response["error"] != "invalid_grant" {
+			t.Errorf("Expected a used refresh token rejected, got %d %v", status, response)
+		}
+	})
+}
+
+// TestTokenRevocation tests the RFC 7009 revocation endpoint and token lifetimes
+func TestTokenRevocation(t *testing.T) {
+	t.Logf("Importance: Users must be able to disconnect the integration, and tokens must stop working when their lifetime is up.")
+	t.Setenv("GO_TEST", "1")
+	t.Setenv("TOKEN_DB_PATH", "")
+	t.Setenv("OAUTH_DB_PATH", "")
+
+	post := func(handler http.HandlerFunc, form url.Values) (int, map[string]interface{}) {
+		req := httptest.NewRequest("POST", "/oauth/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		handler(w, req)
+		var response map[string]interface{}
+		_ = json.NewDecoder(w.Body).Decode(&response)
+		return w.Code, response
+	}
+	connect := func(adapter *OAuthAdapter) (string, string) {
+		adapter.saveCode(&AuthCode{Code: "revoke-code", RTMAPIKey: "rtm-key", ExpiresAt: time.Now().Add(time.Minute)})
+		_, response := post(adapter.HandleToken, url.Values{"grant_type": {"authorization_code"}, "code": {"revoke-code"}})
+		access, _ := response["access_token"].(string)
+		refresh, _ := response["refresh_token"].(string)
+		return access, refresh
+	}
+
+	t.Run("access token", func(t *testing.T) {
+		t.Logf("  > Why it's important: A revoked access token must no longer reach the user's RTM account.")
+		adapter := NewOAuthAdapter("http://localhost:8080", 9090)
+		defer adapter.Close()
+		access, _ := connect(adapter)
+		if status, _ := post(adapter.HandleRevoke, url.Values{"token": {access}, "token_type_hint": {"access_token"}}); status != http.StatusOK {
+			t.Fatalf("Expected 200 from revocation, got %d", status)
+		}
+		if _, err := adapter.ValidateToken("Bearer " + access); err == nil {
+			t.Error("Expected the revoked token rejected")
+		}
+	})
+
+	t.Run("refresh token", func(t *testing.T) {
+		t.Logf("  > Why it's important: A revoked refresh token must not be able to mint new access tokens.")
+		adapter := NewOAuthAdapter("http://localhost:8080", 9090)
+		defer adapter.Close()
+		_, refresh := connect(adapter)
+		post(adapter.HandleRevoke, url.Values{"token": {refresh}})
+		if status, _ := post(adapter.HandleToken, url.Values{"grant_type": {"refresh_token"}, "refresh_token": {refresh}}); status != http.StatusBadRequest {
+			t.Errorf("Expected the revoked refresh token rejected, got %d", status)
+		}
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		t.Logf("  > Why it's important: RFC 7009 answers 200 for unknown tokens, so clients can always disconnect.")
+		adapter := NewOAuthAdapter("http://localhost:8080", 9090)
+		defer adapter.Close()
+		if status, _ := post(adapter.HandleRevoke, url.Values{"token": {"never-issued"}}); status != http.StatusOK {
+			t.Errorf("Expected 200, got %d", status)
+		}
+		if status, _ := post(adapter.HandleRevoke, url.Values{}); status != http.StatusBadRequest {
+			t.Errorf("Expected a missing token rejected, got %d", status)
+		}
+	})
+
+	t.Run("lifetime", func(t *testing.T) {
+		t.Logf("  > Why it's important: Operators choose how long access tokens live, and clients are told.")
+		t.Setenv("OAUTH_ACCESS_TOKEN_TTL", "50ms")
+		adapter := NewOAuthAdapter("http://localhost:8080", 9090)
+		defer adapter.Close()
+		adapter.saveCode(&AuthCode{Code: "ttl-code", RTMAPIKey: "rtm-key", ExpiresAt: time.Now().Add(time.Minute)})
+		_, response := post(adapter.HandleToken, url.Values{"grant_type": {"authorization_code"}, "code": {"ttl-code"}})
+		if _, ok := response["expires_in"]; ok {
+			t.Errorf("Expected no whole-second lifetime to report for 50ms, got %v", response["expires_in"])
+		}
+		access, _ := response["access_token"].(string)
+		if _, err := adapter.ValidateToken("Bearer " + access); err != nil {
+			t.Fatalf("Expected a fresh token accepted: %v", err)
+		}
+		time.Sleep(100 * time.Millisecond)
+		if _, err := adapter.ValidateToken("Bearer " + access); err == nil {
+			t.Error("Expected the token rejected after its lifetime")
+		}
+	})
+}
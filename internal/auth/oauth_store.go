@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"os"
+	"time"
+
+	"github.com/vcto/mcp-adapters/internal/kv"
+	"github.com/vcto/mcp-adapters/internal/residency"
+)
+
+// authCodeBucket is the kv bucket holding unexchanged authorization codes
+const authCodeBucket = "oauth_codes"
+
+// authCodeLifetime is how long an authorization code may be exchanged
+const authCodeLifetime = 10 * time.Minute
+
+// codeCleanupInterval is how often expired, unexchanged codes are dropped
+// from memory
+const codeCleanupInterval = 5 * time.Minute
+
+// OAuthDBPath is the SQLite file OAuth adapters keep their state in:
+// OAUTH_DB_PATH, or TOKEN_DB_PATH when only that is set, so deployments that
+// already persist tokens keep codes and sessions in the same file. Empty
+// means memory.
+func OAuthDBPath() string {
+	path := os.Getenv("OAUTH_DB_PATH")
+	if path == "" {
+		path = os.Getenv("TOKEN_DB_PATH")
+	}
+	return residency.StoragePath(path)
+}
+
+// OpenOAuthStore opens the store for pending authorization codes and
+// sessions on the backend OAUTH_TOKEN_STORE chooses: Redis at REDIS_URL, or
+// SQLite at OAuthDBPath. It falls back to memory when the backend is unset
+// or cannot be opened. Users part way through authorizing can then finish
+// after a restart or deploy, or on another instance when using Redis.
+func OpenOAuthStore() kv.Store {
+	if TokenBackendFromEnv() == TokenBackendRedis {
+		store, err := kv.NewRedisStore(os.Getenv("REDIS_URL"))
+		if err != nil {
+			log.Printf("Failed to open Redis OAuth store (check REDIS_URL): %v, falling back to in-memory", err)
+			return kv.NewMemoryStore()
+		}
+		log.Printf("Using Redis OAuth store")
+		return store
+	}
+
+	path := OAuthDBPath()
+	if path == "" || TokenBackendFromEnv() == TokenBackendMemory {
+		log.Println("Using in-memory OAuth session store (set OAUTH_DB_PATH or TOKEN_DB_PATH for persistence)")
+		return kv.NewMemoryStore()
+	}
+
+	store, err := kv.NewSQLiteStore(path)
+	if err != nil {
+		log.Printf("Failed to open OAuth session store at %s: %v, falling back to in-memory", path, err)
+		return kv.NewMemoryStore()
+	}
+	log.Printf("Using SQLite OAuth session store at %s", path)
+	return store
+}
+
+// tokenBucket is the kv bucket KVTokenStore keeps tokens in
+const tokenBucket = "oauth_tokens"
+
+// KVTokenStore keeps bearer tokens and the RTM API keys they stand for in a
+// kv store, keyed by a hash of the token. The store drops a token once its
+// lifetime is up.
+type KVTokenStore struct {
+	tokens *kv.Bucket[string]
+	ttl    time.Duration
+}
+
+// NewKVTokenStore creates a token store in store whose tokens last ttl, or
+// indefinitely when ttl is 0
+func NewKVTokenStore(store kv.Store, ttl time.Duration) *KVTokenStore {
+	return &KVTokenStore{tokens: kv.NewBucket[string](store, tokenBucket), ttl: ttl}
+}
+
+// Store saves a token-apiKey mapping
+func (s *KVTokenStore) Store(token, apiKey string) {
+	if err := s.tokens.Put(TokenKey(token), apiKey, s.ttl); err != nil {
+		log.Printf("Failed to store token: %v", err)
+		return
+	}
+	residency.Record(residency.SubjectID(token), residency.StoreTokens)
+}
+
+// Get retrieves apiKey for token
+func (s *KVTokenStore) Get(token string) (string, bool) {
+	apiKey, ok, err := s.tokens.Get(TokenKey(token))
+	if err != nil {
+		log.Printf("Failed to read token: %v", err)
+		return "", false
+	}
+	return apiKey, ok
+}
+
+// Delete removes a token
+func (s *KVTokenStore) Delete(token string) {
+	if err := s.tokens.Delete(TokenKey(token)); err != nil {
+		log.Printf("Failed to delete token: %v", err)
+	}
+}
+
+// Close does nothing; the kv store belongs to whoever opened it
+func (s *KVTokenStore) Close() error {
+	return nil
+}
+
+// TokenKey is the key a token is stored under, so stores never hold
+// usable tokens
+func TokenKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,434 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultOIDCScopes are requested from the identity provider when
+// OIDC_SCOPES is unset
+const defaultOIDCScopes = "openid email"
+
+// oidcDiscoveryTTL is how long the provider's discovery document is trusted
+// before it is fetched again
+const oidcDiscoveryTTL = time.Hour
+
+// oidcKeyRefetchInterval limits how often an unknown kid makes the
+// provider's keys be fetched again, so forged tokens can't hammer it
+const oidcKeyRefetchInterval = time.Minute
+
+// oidcHTTPTimeout bounds each call to the identity provider
+const oidcHTTPTimeout = 10 * time.Second
+
+// ErrInvalidIDToken is returned for ID tokens that are malformed, not
+// signed by the provider, expired or issued for another client or login
+var ErrInvalidIDToken = errors.New("invalid ID token")
+
+// OIDCConfig is the upstream identity provider sign-in is delegated to in
+// OIDC passthrough mode
+type OIDCConfig struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+}
+
+// OIDCConfigFromEnv reads OIDC_ISSUER_URL, OIDC_CLIENT_ID,
+// OIDC_CLIENT_SECRET and OIDC_SCOPES, reporting false when passthrough is
+// not configured
+func OIDCConfigFromEnv() (OIDCConfig, bool) {
+	config := OIDCConfig{
+		IssuerURL:    strings.TrimSuffix(os.Getenv("OIDC_ISSUER_URL"), "/"),
+		ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+		ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		Scopes:       strings.Fields(defaultOIDCScopes),
+	}
+	if config.IssuerURL == "" {
+		return config, false
+	}
+	if config.ClientID == "" {
+		fmt.Printf("[OAuth] WARNING: OIDC_ISSUER_URL is set without OIDC_CLIENT_ID; OIDC sign-in is disabled\n")
+		return config, false
+	}
+	if scopes := strings.Fields(os.Getenv("OIDC_SCOPES")); len(scopes) > 0 {
+		config.Scopes = scopes
+	}
+	return config, true
+}
+
+// IDClaims are the claims of a verified ID token
+type IDClaims struct {
+	Issuer        string   `json:"iss"`
+	Subject       string   `json:"sub"`
+	Audience      audience `json:"aud"`
+	ExpiresAt     int64    `json:"exp"`
+	IssuedAt      int64    `json:"iat"`
+	Nonce         string   `json:"nonce"`
+	Email         string   `json:"email,omitempty"`
+	EmailVerified bool     `json:"email_verified,omitempty"`
+}
+
+// Identity is the user's stable key: the provider's issuer and subject.
+// Email addresses can be reassigned, so they are never used to match.
+func (c *IDClaims) Identity() string {
+	return c.Issuer + " " + c.Subject
+}
+
+// audience is an aud claim, which may be a string or a list
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+// oidcDiscovery is the part of the provider's discovery document used here
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// OIDCProvider talks to the upstream identity provider: it finds its
+// endpoints through discovery, exchanges authorization codes and verifies
+// the ID tokens it returns against its published keys.
+type OIDCProvider struct {
+	config OIDCConfig
+	client *http.Client
+	now    func() time.Time
+
+	mu            sync.Mutex
+	discovery     *oidcDiscovery
+	discoveredAt  time.Time
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+// NewOIDCProvider creates a provider for config. Nothing is fetched until
+// the first sign-in, so an unreachable provider doesn't stop startup.
+func NewOIDCProvider(config OIDCConfig) *OIDCProvider {
+	return &OIDCProvider{
+		config: config,
+		client: &http.Client{Timeout: oidcHTTPTimeout},
+		now:    time.Now,
+	}
+}
+
+// AuthCodeURL is the provider's authorization URL for a sign-in returning
+// to redirectURI, bound to state and nonce and protected by PKCE with
+// verifier
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, redirectURI, state, nonce, verifier string) (string, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	digest := sha256.Sum256([]byte(verifier))
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {strings.Join(p.config.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(digest[:])},
+		"code_challenge_method": {PKCEMethodS256},
+	}
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return discovery.AuthorizationEndpoint + separator + params.Encode(), nil
+}
+
+// Exchange trades the provider's authorization code for an ID token and
+// returns its verified claims
+func (p *OIDCProvider) Exchange(ctx context.Context, code, redirectURI, verifier, nonce string) (*IDClaims, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {verifier},
+	}
+	if p.config.ClientSecret == "" {
+		form.Set("client_id", p.config.ClientID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: token request: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("oidc: token response: %w", err)
+	}
+	var token struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, fmt.Errorf("oidc: token response is not JSON (status %d)", resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc: token request refused: %s %s", token.Error, token.ErrorDescription)
+	}
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("oidc: token response has no id_token; is the openid scope requested?")
+	}
+	return p.VerifyIDToken(ctx, token.IDToken, nonce)
+}
+
+// VerifyIDToken checks token's signature against the provider's keys, its
+// issuer, audience, expiry and nonce, and returns its claims
+func (p *OIDCProvider) VerifyIDToken(ctx context.Context, token, nonce string) (*IDClaims, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidIDToken
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		KID string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrInvalidIDToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidIDToken
+	}
+	key, err := p.key(ctx, discovery, header.KID)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if !verifySignature(header.Alg, key, digest[:], signature) {
+		return nil, ErrInvalidIDToken
+	}
+
+	var claims IDClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrInvalidIDToken
+	}
+	if claims.Issuer != discovery.Issuer || claims.Subject == "" || !containsString(claims.Audience, p.config.ClientID) {
+		return nil, ErrInvalidIDToken
+	}
+	if p.now().Unix() >= claims.ExpiresAt || claims.Nonce != nonce {
+		return nil, ErrInvalidIDToken
+	}
+	return &claims, nil
+}
+
+// verifySignature checks an RS256 or ES256 signature over digest
+func verifySignature(alg string, key crypto.PublicKey, digest, signature []byte) bool {
+	switch alg {
+	case "RS256":
+		public, ok := key.(*rsa.PublicKey)
+		return ok && rsa.VerifyPKCS1v15(public, crypto.SHA256, digest, signature) == nil
+	case "ES256":
+		public, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(signature) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		return ecdsa.Verify(public, digest, r, s)
+	default:
+		// Never "none", and never HMAC keyed with a public key
+		return false
+	}
+}
+
+// discover returns the provider's discovery document, fetching it when it
+// is missing or old. The issuer must match the configured one exactly, so
+// a compromised document can't point sign-in somewhere else.
+func (p *OIDCProvider) discover(ctx context.Context) (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil && p.now().Sub(p.discoveredAt) < oidcDiscoveryTTL {
+		return p.discovery, nil
+	}
+
+	var discovery oidcDiscovery
+	if err := p.getJSON(ctx, p.config.IssuerURL+"/.well-known/openid-configuration", &discovery); err != nil {
+		if p.discovery != nil {
+			// Keep signing in with what we had while the provider is unreachable
+			fmt.Printf("[OAuth] WARNING: OIDC discovery failed, using cached endpoints: %v\n", err)
+			return p.discovery, nil
+		}
+		return nil, err
+	}
+	if strings.TrimSuffix(discovery.Issuer, "/") != p.config.IssuerURL {
+		return nil, fmt.Errorf("oidc: provider reports issuer %q, expected %q", discovery.Issuer, p.config.IssuerURL)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("oidc: discovery document is missing endpoints")
+	}
+	p.discovery = &discovery
+	p.discoveredAt = p.now()
+	return p.discovery, nil
+}
+
+// key returns the provider's public key for kid, fetching the key set
+// again when kid is unknown, as it is just after the provider rotates
+func (p *OIDCProvider) key(ctx context.Context, discovery *oidcDiscovery, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	if p.keys != nil && p.now().Sub(p.keysFetchedAt) < oidcKeyRefetchInterval {
+		return nil, ErrInvalidIDToken
+	}
+
+	var set struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	if err := p.getJSON(ctx, discovery.JWKSURI, &set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if use := jwk["use"]; use != "" && use != "sig" {
+			continue
+		}
+		if key, err := parseJWK(jwk); err == nil {
+			keys[jwk["kid"]] = key
+		}
+	}
+	p.keys = keys
+	p.keysFetchedAt = p.now()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, ErrInvalidIDToken
+}
+
+// lookupKey finds kid among the fetched keys; a token without a kid is
+// accepted only when the provider publishes a single key. Callers hold p.mu.
+func (p *OIDCProvider) lookupKey(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+// getJSON fetches rawURL and decodes its JSON body into v
+func (p *OIDCProvider) getJSON(ctx context.Context, rawURL string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("oidc: fetch %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("oidc: fetch %s: status %d", rawURL, resp.StatusCode)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v); err != nil {
+		return fmt.Errorf("oidc: decode %s: %w", rawURL, err)
+	}
+	return nil
+}
+
+// parseJWK decodes an RSA or P-256 public key from a JSON Web Key
+func parseJWK(jwk map[string]string) (crypto.PublicKey, error) {
+	decode := func(name string) (*big.Int, error) {
+		data, err := base64.RawURLEncoding.DecodeString(jwk[name])
+		if err != nil || len(data) == 0 {
+			return nil, fmt.Errorf("jwk: bad %s", name)
+		}
+		return new(big.Int).SetBytes(data), nil
+	}
+
+	switch jwk["kty"] {
+	case "RSA":
+		n, err := decode("n")
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode("e")
+		if err != nil || !e.IsInt64() {
+			return nil, fmt.Errorf("jwk: bad e")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if jwk["crv"] != "P-256" {
+			return nil, fmt.Errorf("jwk: unsupported curve %q", jwk["crv"])
+		}
+		x, err := decode("x")
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode("y")
+		if err != nil {
+			return nil, err
+		}
+		if !elliptic.P256().IsOnCurve(x, y) {
+			return nil, fmt.Errorf("jwk: point is not on P-256")
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("jwk: unsupported key type %q", jwk["kty"])
+	}
+}
+
+// containsString reports whether list holds s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,247 @@
+package auth
+
+import (
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// oidcRequestBucket holds authorization requests while the user signs in
+// upstream or links their key, keyed by state or link token
+const oidcRequestBucket = "oidc_requests"
+
+// oidcIdentityBucket maps external identities to RTM API keys, keyed by
+// TokenKey of the issuer and subject
+const oidcIdentityBucket = "oidc_identities"
+
+// oidcCallbackPath is where the identity provider sends the user back
+const oidcCallbackPath = "/oauth/oidc/callback"
+
+// oidcRequest is an authorization request waiting on the identity
+// provider, or, once Identity is set, on the user linking their key
+type oidcRequest struct {
+	authRequest
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	Identity string `json:"identity,omitempty"`
+	Email    string `json:"email,omitempty"`
+}
+
+// OIDCIdentity is the RTM API key linked to an external identity
+type OIDCIdentity struct {
+	Credential string    `json:"credential"`
+	Email      string    `json:"email,omitempty"`
+	LinkedAt   time.Time `json:"linked_at"`
+}
+
+// UseOIDC delegates sign-in to the identity provider in config. Users
+// sign in there, and the RTM API key linked to their identity is used, so
+// organizations can put the adapter behind their existing SSO.
+func (a *OAuthAdapter) UseOIDC(config OIDCConfig) {
+	a.oidc = NewOIDCProvider(config)
+	fmt.Printf("[OAuth] Sign-in delegated to OIDC provider %s\n", config.IssuerURL)
+}
+
+// LinkIdentity links the external identity to credential, replacing any
+// earlier link, so an administrator can provision users ahead of time
+func (a *OAuthAdapter) LinkIdentity(identity, credential, email string) error {
+	return a.identities.Put(TokenKey(identity), OIDCIdentity{
+		Credential: credential,
+		Email:      email,
+		LinkedAt:   time.Now().UTC(),
+	}, 0)
+}
+
+// UnlinkIdentity removes the identity's link, so its next sign-in asks for
+// a key again
+func (a *OAuthAdapter) UnlinkIdentity(identity string) error {
+	return a.identities.Delete(TokenKey(identity))
+}
+
+// startOIDC sends the user to the identity provider, remembering req
+// under the state the provider will return
+func (a *OAuthAdapter) startOIDC(w http.ResponseWriter, r *http.Request, req authRequest) {
+	pending := oidcRequest{
+		authRequest: req,
+		Nonce:       uuid.New().String(),
+		// Two UUIDs make a 72-character verifier from allowed characters
+		Verifier: uuid.New().String() + uuid.New().String(),
+	}
+	state := uuid.New().String()
+	if err := a.oidcRequests.Put(state, pending, authCodeLifetime); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, "server_error", "Could not start sign-in. Try again.", RetryURL(r))
+		return
+	}
+	authURL, err := a.oidc.AuthCodeURL(r.Context(), a.serverURL+oidcCallbackPath, state, pending.Nonce, pending.Verifier)
+	if err != nil {
+		fmt.Printf("[OAuth] OIDC provider unavailable: %v\n", err)
+		WriteError(w, r, http.StatusBadGateway, "temporarily_unavailable",
+			"Your organization's sign-in service could not be reached. Try again in a moment.", RetryURL(r))
+		return
+	}
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// HandleOIDCCallback handles /oauth/oidc/callback, where the identity
+// provider returns the user. A linked identity goes straight back to the
+// client with a code; an unlinked one is asked for its RTM API key once.
+func (a *OAuthAdapter) HandleOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	if a.oidc == nil {
+		http.NotFound(w, r)
+		return
+	}
+	pending, ok := a.takeOIDCRequest(r.URL.Query().Get("state"))
+	if !ok {
+		WriteError(w, r, http.StatusBadRequest, "invalid_request",
+			"This sign-in has expired or was already used. Start again from your client.", "")
+		return
+	}
+
+	if upstreamError := r.URL.Query().Get("error"); upstreamError != "" {
+		// Let the client know, as it would if this server had refused
+		fmt.Printf("[OAuth] OIDC provider refused sign-in: %s\n", upstreamError)
+		u, _ := url.Parse(pending.RedirectURI)
+		q := u.Query()
+		q.Set("error", "access_denied")
+		q.Set("state", pending.ClientState)
+		u.RawQuery = q.Encode()
+		http.Redirect(w, r, u.String(), http.StatusFound)
+		return
+	}
+
+	claims, err := a.oidc.Exchange(r.Context(), r.URL.Query().Get("code"), a.serverURL+oidcCallbackPath, pending.Verifier, pending.Nonce)
+	if err != nil {
+		fmt.Printf("[OAuth] OIDC sign-in failed: %v\n", err)
+		WriteError(w, r, http.StatusBadGateway, "access_denied",
+			"Your organization's sign-in could not be verified. Start again from your client.", "")
+		return
+	}
+
+	identity, linked, err := a.identities.Get(TokenKey(claims.Identity()))
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, "server_error", "Could not look up your account. Try again.", "")
+		return
+	}
+	if linked {
+		a.completeAuthorization(w, r, identity.Credential, pending.authRequest)
+		return
+	}
+
+	// First sign-in: hold the request until the user links a key
+	pending.Identity = claims.Identity()
+	pending.Email = claims.Email
+	link := uuid.New().String()
+	if err := a.oidcRequests.Put(link, pending, authCodeLifetime); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, "server_error", "Could not continue sign-in. Try again.", "")
+		return
+	}
+	csrfState := uuid.New().String()
+	http.SetCookie(w, &http.Cookie{
+		Name:     "csrf_token",
+		Value:    csrfState,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   600, // 10 minutes
+	})
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := linkFormTemplate.Execute(w, map[string]string{
+		"Email": claims.Email,
+		"Link":  link,
+		"CSRF":  csrfState,
+	}); err != nil {
+		fmt.Printf("Failed to write HTML response: %v\n", err)
+	}
+}
+
+// HandleOIDCLink handles /oauth/oidc/link, where a user signed in through
+// the identity provider for the first time submits their RTM API key
+func (a *OAuthAdapter) HandleOIDCLink(w http.ResponseWriter, r *http.Request) {
+	if a.oidc == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		WriteJSONError(w, r, http.StatusMethodNotAllowed, "invalid_request", "Method not allowed", "")
+		return
+	}
+
+	cookie, err := r.Cookie("csrf_token")
+	if err != nil || cookie.Value == "" || cookie.Value != r.FormValue("csrf_state") {
+		WriteError(w, r, http.StatusBadRequest, "invalid_request",
+			"This form has expired or was opened in another tab. Start again from your client.", "")
+		return
+	}
+	apiKey := r.FormValue("api_key")
+	if apiKey == "" {
+		WriteError(w, r, http.StatusBadRequest, "invalid_request", "An RTM API key is required.", "")
+		return
+	}
+	pending, ok := a.takeOIDCRequest(r.FormValue("link"))
+	if !ok || pending.Identity == "" {
+		WriteError(w, r, http.StatusBadRequest, "invalid_request",
+			"This sign-in has expired or was already used. Start again from your client.", "")
+		return
+	}
+
+	if err := a.LinkIdentity(pending.Identity, apiKey, pending.Email); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, "server_error", "Could not save your key. Try again.", "")
+		return
+	}
+	log.Printf("[AUDIT] oidc_identity_linked email=%q", pending.Email)
+	a.completeAuthorization(w, r, apiKey, pending.authRequest)
+}
+
+// takeOIDCRequest returns and forgets the request stored under key, so
+// each state and link token works once
+func (a *OAuthAdapter) takeOIDCRequest(key string) (oidcRequest, bool) {
+	if key == "" {
+		return oidcRequest{}, false
+	}
+	pending, ok, err := a.oidcRequests.Get(key)
+	if err != nil || !ok {
+		return oidcRequest{}, false
+	}
+	if err := a.oidcRequests.Delete(key); err != nil {
+		return oidcRequest{}, false
+	}
+	return pending, true
+}
+
+// linkFormTemplate asks a user signed in for the first time for the RTM
+// API key to link to their identity
+var linkFormTemplate = template.Must(template.New("link").Parse(`<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="UTF-8">
+	<title>Connect Remember The Milk</title>
+	<style>
+		body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif; display: flex; justify-content: center; align-items: center; min-height: 100vh; margin: 0; background-color: #f5f5f5; }
+		.container { background: white; padding: 2rem; border-radius: 8px; box-shadow: 0 2px 4px rgba(0,0,0,0.1); max-width: 500px; text-align: center; }
+		label { display: block; margin: 2rem 0 1rem; text-align: left; }
+		input[type="password"] { width: 100%; padding: 0.5rem; font-size: 1rem; border: 1px solid #ddd; border-radius: 4px; margin-top: 0.5rem; }
+		button { background-color: #007bff; color: white; border: none; padding: 0.75rem 2rem; font-size: 1rem; border-radius: 4px; cursor: pointer; }
+	</style>
+</head>
+<body>
+	<div class="container">
+		<h1>🐄 Connect Remember The Milk</h1>
+		<p>Signed in{{if .Email}} as {{.Email}}{{end}}. Enter your RTM API Key once to link it to your account.</p>
+		<form method="POST" action="/oauth/oidc/link">
+			<input type="hidden" name="link" value="{{.Link}}">
+			<input type="hidden" name="csrf_state" value="{{.CSRF}}">
+			<label>
+				RTM API Key:
+				<input type="password" name="api_key" required autofocus>
+			</label>
+			<button type="submit">Connect</button>
+		</form>
+	</div>
+</body>
+</html>`))
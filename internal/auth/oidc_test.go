@@ -0,0 +1,235 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeIdP is an OpenID Connect provider issuing RS256 ID tokens
+type fakeIdP struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu        sync.Mutex
+	subject   string
+	nonce     string // From the last authorization request
+	challenge string
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &fakeIdP{key: key, subject: "user-1"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA", "kid": "k1", "use": "sig",
+			"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		idp.mu.Lock()
+		defer idp.mu.Unlock()
+		if id != "mcp" || secret != "idp-secret" || r.FormValue("code") != "upstream-code" ||
+			!VerifyPKCE(idp.challenge, r.FormValue("code_verifier")) {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"id_token": idp.sign(t, map[string]interface{}{"sub": idp.subject, "nonce": idp.nonce}),
+		})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+// sign issues an ID token for the test client, with claims overriding the
+// defaults
+func (idp *fakeIdP) sign(t *testing.T, claims map[string]interface{}) string {
+	all := map[string]interface{}{
+		"iss":   idp.server.URL,
+		"aud":   "mcp",
+		"exp":   time.Now().Add(time.Minute).Unix(),
+		"iat":   time.Now().Unix(),
+		"email": "ada@example.com",
+	}
+	for name, value := range claims {
+		all[name] = value
+	}
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "k1"})
+	payload, _ := json.Marshal(all)
+	signing := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signing))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, idp.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signing + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestOIDCPassthrough(t *testing.T) {
+	t.Logf("Importance: OIDC passthrough lets organizations put the adapter behind their SSO, so only identities the provider vouches for reach a stored RTM key.")
+
+	t.Setenv("GO_TEST", "1")
+	t.Setenv("TOKEN_DB_PATH", "")
+	t.Setenv("OAUTH_DB_PATH", "")
+	idp := newFakeIdP(t)
+	adapter := NewOAuthAdapter("http://localhost:8080", 9090)
+	defer adapter.Close()
+	adapter.UseOIDC(OIDCConfig{IssuerURL: idp.server.URL, ClientID: "mcp", ClientSecret: "idp-secret", Scopes: []string{"openid", "email"}})
+
+	// authorize starts a sign-in and returns the state sent to the provider
+	authorize := func(t *testing.T) string {
+		w := httptest.NewRecorder()
+		adapter.HandleAuthorize(w, httptest.NewRequest("GET", "/oauth/authorize?client_id=c1&redirect_uri=http://localhost:3000/cb&state=client-state", nil))
+		if w.Code != http.StatusFound || !strings.HasPrefix(w.Header().Get("Location"), idp.server.URL+"/authorize?") {
+			t.Fatalf("Expected a redirect to the provider, got %d %s", w.Code, w.Header().Get("Location"))
+		}
+		location, _ := url.Parse(w.Header().Get("Location"))
+		query := location.Query()
+		if query.Get("redirect_uri") != "http://localhost:8080/oauth/oidc/callback" || query.Get("code_challenge_method") != PKCEMethodS256 {
+			t.Errorf("Unexpected provider request: %s", location.RawQuery)
+		}
+		idp.mu.Lock()
+		idp.nonce, idp.challenge = query.Get("nonce"), query.Get("code_challenge")
+		idp.mu.Unlock()
+		return query.Get("state")
+	}
+	callback := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		adapter.HandleOIDCCallback(w, httptest.NewRequest("GET", "/oauth/oidc/callback?"+query, nil))
+		return w
+	}
+	clientCode := func(t *testing.T, w *httptest.ResponseRecorder) string {
+		location, _ := url.Parse(w.Header().Get("Location"))
+		if w.Code != http.StatusFound || location.Host != "localhost:3000" || location.Query().Get("state") != "client-state" {
+			t.Fatalf("Expected a redirect to the client, got %d %s", w.Code, w.Header().Get("Location"))
+		}
+		return location.Query().Get("code")
+	}
+
+	t.Run("first sign-in links a key", func(t *testing.T) {
+		t.Logf("  > Why it's important: A new user must link their key once, and the code issued afterwards must carry it.")
+		w := callback("code=upstream-code&state=" + authorize(t))
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `action="/oauth/oidc/link"`) || !strings.Contains(w.Body.String(), "ada@example.com") {
+			t.Fatalf("Expected the link form, got %d: %s", w.Code, w.Body.String())
+		}
+		link := regexp.MustCompile(`name="link" value="([^"]+)"`).FindStringSubmatch(w.Body.String())
+		csrf := regexp.MustCompile(`name="csrf_state" value="([^"]+)"`).FindStringSubmatch(w.Body.String())
+		if link == nil || csrf == nil {
+			t.Fatal("Expected link and csrf_state fields in the form")
+		}
+
+		form := url.Values{"link": {link[1]}, "csrf_state": {csrf[1]}, "api_key": {"rtm-key"}}
+		req := httptest.NewRequest("POST", "/oauth/oidc/link", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(&http.Cookie{Name: "csrf_token", Value: csrf[1]})
+		w = httptest.NewRecorder()
+		adapter.HandleOIDCLink(w, req)
+		code := clientCode(t, w)
+
+		if stored, ok := adapter.lookupCode(code); !ok || stored.RTMAPIKey != "rtm-key" || stored.ClientID != "c1" {
+			t.Errorf("Expected a code for the linked key, got %+v", stored)
+		}
+
+		// The link token works once
+		req = httptest.NewRequest("POST", "/oauth/oidc/link", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(&http.Cookie{Name: "csrf_token", Value: csrf[1]})
+		w = httptest.NewRecorder()
+		adapter.HandleOIDCLink(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected a reused link token refused, got %d", w.Code)
+		}
+	})
+
+	t.Run("linked identity goes straight back", func(t *testing.T) {
+		t.Logf("  > Why it's important: Returning users should only see their SSO sign-in, never the key form.")
+		code := clientCode(t, callback("code=upstream-code&state="+authorize(t)))
+		if stored, ok := adapter.lookupCode(code); !ok || stored.RTMAPIKey != "rtm-key" {
+			t.Errorf("Expected a code for the linked key, got %+v", stored)
+		}
+	})
+
+	t.Run("state is single use", func(t *testing.T) {
+		t.Logf("  > Why it's important: A replayed callback must not sign anyone in again.")
+		state := authorize(t)
+		clientCode(t, callback("code=upstream-code&state="+state))
+		if w := callback("code=upstream-code&state=" + state); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected a reused state refused, got %d", w.Code)
+		}
+	})
+
+	t.Run("provider refusal reaches the client", func(t *testing.T) {
+		t.Logf("  > Why it's important: The client must learn that sign-in was denied rather than wait forever.")
+		w := callback("error=access_denied&state=" + authorize(t))
+		location, _ := url.Parse(w.Header().Get("Location"))
+		if w.Code != http.StatusFound || location.Query().Get("error") != "access_denied" || location.Query().Get("state") != "client-state" {
+			t.Errorf("Expected access_denied sent to the client, got %d %s", w.Code, w.Header().Get("Location"))
+		}
+	})
+
+	t.Run("key form is closed", func(t *testing.T) {
+		t.Logf("  > Why it's important: Posting a key straight to /oauth/authorize would bypass the organization's sign-in.")
+		form := url.Values{"api_key": {"rtm-key"}, "client_id": {"c1"}, "redirect_uri": {"http://localhost:3000/cb"}}
+		req := httptest.NewRequest("POST", "/oauth/authorize", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		adapter.HandleAuthorize(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected the key form refused, got %d", w.Code)
+		}
+	})
+
+	t.Run("ID token checks", func(t *testing.T) {
+		t.Logf("  > Why it's important: A token for another client, another login or from another signer must not map to someone's key.")
+		ctx := context.Background()
+		if _, err := adapter.oidc.VerifyIDToken(ctx, idp.sign(t, map[string]interface{}{"sub": "u", "nonce": "n"}), "n"); err != nil {
+			t.Errorf("Expected a valid token accepted, got %v", err)
+		}
+		bad := map[string]map[string]interface{}{
+			"wrong nonce":    {"sub": "u", "nonce": "other"},
+			"wrong audience": {"sub": "u", "nonce": "n", "aud": []string{"someone-else"}},
+			"wrong issuer":   {"sub": "u", "nonce": "n", "iss": "https://evil.example"},
+			"expired":        {"sub": "u", "nonce": "n", "exp": time.Now().Add(-time.Minute).Unix()},
+		}
+		for name, claims := range bad {
+			if _, err := adapter.oidc.VerifyIDToken(ctx, idp.sign(t, claims), "n"); err != ErrInvalidIDToken {
+				t.Errorf("%s: expected ErrInvalidIDToken, got %v", name, err)
+			}
+		}
+		token := idp.sign(t, map[string]interface{}{"sub": "u", "nonce": "n"})
+		tampered := token[:strings.LastIndex(token, ".")] + ".AAAA"
+		if _, err := adapter.oidc.VerifyIDToken(ctx, tampered, "n"); err != ErrInvalidIDToken {
+			t.Errorf("Expected a bad signature refused, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPKCE(t *testing.T) {
+	t.Logf("Importance: PKCE is what stops an intercepted authorization code from being redeemed by someone else.")
+
+	verifier := "dBjftJeZ4CVP-mJ92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	digest := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(digest[:])
+
+	t.Run("challenge checks", func(t *testing.T) {
+		t.Logf("  > Why it's important: Only S256 protects the code, and requiring PKCE must refuse requests without it.")
+		if cerr := CheckPKCEChallenge("", "", false); cerr != nil {
+			t.Errorf("Expected no challenge allowed by default, got %v", cerr)
+		}
+		if cerr := CheckPKCEChallenge("", "", true); cerr == nil || cerr.Code != "invalid_request" {
+			t.Errorf("Expected a missing challenge refused when required, got %v", cerr)
+		}
+		if cerr := CheckPKCEChallenge(challenge, "plain", false); cerr == nil {
+			t.Error("Expected the plain method refused")
+		}
+		if cerr := CheckPKCEChallenge(challenge, PKCEMethodS256, true); cerr != nil {
+			t.Errorf("Expected an S256 challenge accepted, got %v", cerr)
+		}
+		if !VerifyPKCE(challenge, verifier) || VerifyPKCE(challenge, "wrong-verifier") {
+			t.Error("Expected only the matching verifier to verify")
+		}
+	})
+
+	t.Setenv("GO_TEST", "1")
+	t.Setenv("TOKEN_DB_PATH", "")
+	t.Setenv("OAUTH_DB_PATH", "")
+	t.Setenv("REQUIRE_PKCE", "true")
+	adapter := NewOAuthAdapter("http://localhost:8080", 9090)
+	defer adapter.Close()
+
+	token := func(code, codeVerifier string) *httptest.ResponseRecorder {
+		form := url.Values{"grant_type": {"authorization_code"}, "code": {code}, "code_verifier": {codeVerifier}}
+		req := httptest.NewRequest("POST", "/oauth/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		adapter.HandleToken(w, req)
+		return w
+	}
+
+	t.Run("authorize requires a challenge", func(t *testing.T) {
+		t.Logf("  > Why it's important: Clients that skip PKCE must be stopped before the user enters anything.")
+		w := httptest.NewRecorder()
+		adapter.HandleAuthorize(w, httptest.NewRequest("GET", "/oauth/authorize?client_id=c1&redirect_uri=http://localhost:3000/cb", nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 without a challenge, got %d", w.Code)
+		}
+
+		query := url.Values{"client_id": {"c1"}, "redirect_uri": {"http://localhost:3000/cb"},
+			"code_challenge": {challenge}, "code_challenge_method": {PKCEMethodS256}}
+		w = httptest.NewRecorder()
+		adapter.HandleAuthorize(w, httptest.NewRequest("GET", "/oauth/authorize?"+query.Encode(), nil))
+		csrf := regexp.MustCompile(`name="csrf_state" value="([^"]+)"`).FindStringSubmatch(w.Body.String())
+		if w.Code != http.StatusOK || csrf == nil {
+			t.Fatalf("Expected the form, got %d", w.Code)
+		}
+		if pending, ok := adapter.takePendingAuthorization(csrf[1]); !ok || pending.CodeChallenge != challenge {
+			t.Errorf("Expected the challenge kept for the form, got %+v", pending)
+		}
+	})
+
+	t.Run("token checks the verifier", func(t *testing.T) {
+		t.Logf("  > Why it's important: A code is only as safe as the verifier check at the token endpoint.")
+		adapter.saveCode(&AuthCode{Code: "pkce-code", RTMAPIKey: "rtm-key", CodeChallenge: challenge, ExpiresAt: time.Now().Add(time.Minute)})
+		if w := token("pkce-code", "wrong-verifier"); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected a wrong verifier refused, got %d", w.Code)
+		}
+		if w := token("pkce-code", verifier); w.Code != http.StatusOK {
+			t.Errorf("Expected the right verifier accepted, got %d: %s", w.Code, w.Body.String())
+		}
+
+		adapter.saveCode(&AuthCode{Code: "old-code", RTMAPIKey: "rtm-key", ExpiresAt: time.Now().Add(time.Minute)})
+		if w := token("old-code", ""); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected a code without a challenge refused, got %d", w.Code)
+		}
+	})
+
+	t.Run("metadata", func(t *testing.T) {
+		t.Logf("  > Why it's important: Clients read discovery metadata to know PKCE is mandatory here.")
+		w := httptest.NewRecorder()
+		adapter.HandleAuthServerMetadata(w, httptest.NewRequest("GET", "/.well-known/oauth-authorization-server", nil))
+		var metadata map[string]interface{}
+		_ = json.NewDecoder(w.Body).Decode(&metadata)
+		if metadata["require_pkce"] != true {
+			t.Errorf("Expected require_pkce advertised, got %v", metadata["require_pkce"])
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"sync"
+	"time"
+
+	"github.com/vcto/mcp-adapters/internal/kv"
+)
+
+// redeemedCodeBucket is the kv bucket holding when each authorization code
+// was exchanged, keyed by TokenKey
+const redeemedCodeBucket = "oauth_redeemed_codes"
+
+// codeTokensBucket is the kv bucket holding the tokens issued from each
+// authorization code, keyed by TokenKey of the code
+const codeTokensBucket = "oauth_code_tokens"
+
+// RedeemedCodes remembers which authorization codes have been exchanged, so
+// one presented again is refused as a replay rather than as unknown. Claim
+// is atomic across every instance sharing the store, so two requests racing
+// with one code can't both get tokens. It also remembers the tokens issued
+// from each code, which RFC 6749 section 4.1.2 says to revoke on a replay.
+type RedeemedCodes struct {
+	codes  *kv.Bucket[time.Time]
+	mu     sync.Mutex // Serializes updates to a code's token list
+	tokens *kv.Bucket[[]string]
+	ttl    time.Duration
+}
+
+// NewRedeemedCodes keeps redemptions in store for ttl, which should be at
+// least as long as a code stays valid
+func NewRedeemedCodes(store kv.Store, ttl time.Duration) *RedeemedCodes {
+	return &RedeemedCodes{
+		codes:  kv.NewBucket[time.Time](store, redeemedCodeBucket),
+		tokens: kv.NewBucket[[]string](store, codeTokensBucket),
+		ttl:    ttl,
+	}
+}
+
+// Redeemed reports whether code has already been exchanged
+func (c *RedeemedCodes) Redeemed(code string) (bool, error) {
+	if code == "" {
+		return false, nil
+	}
+	_, ok, err := c.codes.Get(TokenKey(code))
+	return ok, err
+}
+
+// Claim marks code as redeemed, reporting false when it already was
+func (c *RedeemedCodes) Claim(code string) (bool, error) {
+	return c.codes.PutIfAbsent(TokenKey(code), time.Now().UTC(), c.ttl)
+}
+
+// RecordTokens notes tokens as issued from the code whose TokenKey is
+// codeKey, including tokens later renewed from them. They are kept as long
+// as the redemption, since a replay is only recognized until then.
+func (c *RedeemedCodes) RecordTokens(codeKey string, tokens ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	issued, _, err := c.tokens.Get(codeKey)
+	if err != nil {
+		return err
+	}
+	for _, token := range tokens {
+		if token != "" {
+			issued = append(issued, token)
+		}
+	}
+	return c.tokens.Put(codeKey, issued, c.ttl)
+}
+
+// TakeTokens returns the tokens issued from code and forgets them, so the
+// caller can revoke them after the code was replayed
+func (c *RedeemedCodes) TakeTokens(code string) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := TokenKey(code)
+	issued, _, err := c.tokens.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return issued, c.tokens.Delete(key)
+}
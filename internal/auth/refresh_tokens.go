@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vcto/mcp-adapters/internal/kv"
+)
+
+// refreshTokenBucket is the kv bucket holding unredeemed refresh tokens
+const refreshTokenBucket = "oauth_refresh_tokens"
+
+// RefreshTokenTTL is how long a refresh token lasts unused
+const RefreshTokenTTL = 30 * 24 * time.Hour
+
+// RefreshGrant is what a refresh token stands for
+type RefreshGrant struct {
+	// Credential is the RTM API key or auth token new access tokens act with
+	Credential string `json:"credential"`
+	ClientID   string `json:"client_id,omitempty"`
+	Scope      string `json:"scope,omitempty"`
+	// CodeKey is the TokenKey of the authorization code the grant descends
+	// from, so tokens renewed from it are revoked if the code is replayed
+	CodeKey  string    `json:"code_key,omitempty"`
+	IssuedAt time.Time `json:"issued_at"`
+}
+
+// RefreshTokens issues and redeems refresh tokens, keyed in the store by a
+// hash of the token. Each token can be redeemed once; the token endpoint
+// issues its replacement, so a leaked token stops working once the client
+// has used it.
+type RefreshTokens struct {
+	mu     sync.Mutex
+	grants *kv.Bucket[RefreshGrant]
+}
+
+// NewRefreshTokens keeps refresh tokens in store
+func NewRefreshTokens(store kv.Store) *RefreshTokens {
+	return &RefreshTokens{grants: kv.NewBucket[RefreshGrant](store, refreshTokenBucket)}
+}
+
+// Issue returns a new refresh token for grant
+func (r *RefreshTokens) Issue(grant RefreshGrant) (string, error) {
+	token := uuid.New().String()
+	grant.IssuedAt = time.Now().UTC()
+	if err := r.grants.Put(TokenKey(token), grant, RefreshTokenTTL); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Redeem returns the grant for token and invalidates it, reporting whether
+// the token was valid
+func (r *RefreshTokens) Redeem(token string) (RefreshGrant, bool, error) {
+	if token == "" {
+		return RefreshGrant{}, false, nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	grant, ok, err := r.grants.Get(TokenKey(token))
+	if err != nil || !ok {
+		return RefreshGrant{}, false, err
+	}
+	if err := r.grants.Delete(TokenKey(token)); err != nil {
+		return RefreshGrant{}, false, err
+	}
+	return grant, true, nil
+}
+
+// Revoke invalidates token without redeeming it, reporting whether it was a
+// refresh token
+func (r *RefreshTokens) Revoke(token string) (bool, error) {
+	_, ok, err := r.Redeem(token)
+	return ok, err
+}
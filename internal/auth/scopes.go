@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"context"
+	"strings"
+)
+
+// scopesKey is the context key for the scopes granted to a request's token
+type scopesKey struct{}
+
+// ParseScope splits an OAuth scope parameter into its space-separated scopes
+func ParseScope(scope string) []string {
+	return strings.Fields(scope)
+}
+
+// HasScope reports whether scope is among scopes
+func HasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// WithScopes returns a context carrying the scopes granted to the request's
+// token, so tool middleware can hold calls to them
+func WithScopes(ctx context.Context, scopes []string) context.Context {
+	return context.WithValue(ctx, scopesKey{}, scopes)
+}
+
+// ScopesFromContext returns the scopes set by WithScopes, reporting false
+// when the request's token is not limited to scopes
+func ScopesFromContext(ctx context.Context) ([]string, bool) {
+	scopes, ok := ctx.Value(scopesKey{}).([]string)
+	return scopes, ok
+}
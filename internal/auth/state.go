@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"net/http"
+)
+
+// pendingAuthorizationBucket holds what each authorization form was opened
+// for, keyed by TokenKey of its CSRF token
+const pendingAuthorizationBucket = "oauth_pending_authorizations"
+
+// maxStateLength bounds the state a client may ask to have returned
+const maxStateLength = 512
+
+// CheckState refuses a client state that is too long or holds anything
+// but visible ASCII. State is opaque to this server, but it is stored and
+// sent back, so it must stay small and inert.
+func CheckState(state string) *ClientError {
+	if len(state) > maxStateLength {
+		return &ClientError{Status: http.StatusBadRequest, Code: "invalid_request",
+			Description: "The state parameter is too long."}
+	}
+	for i := 0; i < len(state); i++ {
+		if state[i] < 0x20 || state[i] > 0x7e {
+			return &ClientError{Status: http.StatusBadRequest, Code: "invalid_request",
+				Description: "The state parameter may only contain printable ASCII characters."}
+		}
+	}
+	return nil
+}
+
+// savePendingAuthorization remembers req for the form opened with
+// csrfState. The form carries only that token, so what the client asked
+// for, state included, can't be changed on its way back.
+func (a *OAuthAdapter) savePendingAuthorization(csrfState string, req authRequest) error {
+	return a.pending.Put(TokenKey(csrfState), req, authCodeLifetime)
+}
+
+// takePendingAuthorization returns and forgets the request the form with
+// csrfState was opened for, so each form can be submitted once
+func (a *OAuthAdapter) takePendingAuthorization(csrfState string) (authRequest, bool) {
+	key := TokenKey(csrfState)
+	req, ok, err := a.pending.Get(key)
+	if err != nil || !ok {
+		return authRequest{}, false
+	}
+	if err := a.pending.Delete(key); err != nil {
+		return authRequest{}, false
+	}
+	return req, true
+}
@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestAuthorizeStateIntegrity(t *testing.T) {
+	t.Logf("Importance: The state and redirect a client asked for must be exactly what comes back, never what an edited form says.")
+
+	t.Setenv("GO_TEST", "1")
+	t.Setenv("TOKEN_DB_PATH", "")
+	t.Setenv("OAUTH_DB_PATH", "")
+	adapter := NewOAuthAdapter("http://localhost:8080", 9090)
+	defer adapter.Close()
+
+	// open returns the form's CSRF token and cookie for an authorize request
+	open := func(t *testing.T, query string) (string, *http.Cookie) {
+		w := httptest.NewRecorder()
+		adapter.HandleAuthorize(w, httptest.NewRequest("GET", "/oauth/authorize?"+query, nil))
+		csrf := regexp.MustCompile(`name="csrf_state" value="([^"]+)"`).FindStringSubmatch(w.Body.String())
+		if w.Code != http.StatusOK || csrf == nil {
+			t.Fatalf("Expected the key form, got %d", w.Code)
+		}
+		return csrf[1], w.Result().Cookies()[0]
+	}
+	submit := func(form url.Values, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/oauth/authorize", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(cookie)
+		w := httptest.NewRecorder()
+		adapter.HandleAuthorize(w, req)
+		return w
+	}
+
+	t.Run("posted fields are ignored", func(t *testing.T) {
+		t.Logf("  > Why it's important: An edited form must not send the code elsewhere or with a forged state.")
+		csrf, cookie := open(t, "client_id=c1&redirect_uri=http://localhost:3000/cb&state=original")
+		w := submit(url.Values{"csrf_state": {csrf}, "api_key": {"rtm-key"},
+			"client_state": {"forged"}, "redirect_uri": {"https://evil.example/cb"}}, cookie)
+		location, _ := url.Parse(w.Header().Get("Location"))
+		if w.Code != http.StatusFound || location.Host != "localhost:3000" || location.Query().Get("state") != "original" {
+			t.Errorf("Expected the original redirect and state, got %d %s", w.Code, w.Header().Get("Location"))
+		}
+
+		// The form is used up
+		if w := submit(url.Values{"csrf_state": {csrf}, "api_key": {"rtm-key"}}, cookie); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected a resubmitted form refused, got %d", w.Code)
+		}
+	})
+
+	t.Run("state is not reflected into the page", func(t *testing.T) {
+		t.Logf("  > Why it's important: A state carrying markup must never reach the HTML form.")
+		w := httptest.NewRecorder()
+		adapter.HandleAuthorize(w, httptest.NewRequest("GET", "/oauth/authorize?state="+url.QueryEscape(`"><script>x</script>`), nil))
+		if strings.Contains(w.Body.String(), "<script>x") {
+			t.Error("Expected the state kept out of the page")
+		}
+	})
+
+	t.Run("malformed state", func(t *testing.T) {
+		t.Logf("  > Why it's important: Oversized or binary state is refused before anything is stored.")
+		for _, state := range []string{strings.Repeat("s", maxStateLength+1), "line\nbreak"} {
+			w := httptest.NewRecorder()
+			adapter.HandleAuthorize(w, httptest.NewRequest("GET", "/oauth/authorize?state="+url.QueryEscape(state), nil))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected state %.20q refused, got %d", state, w.Code)
+			}
+		}
+	})
+}
@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// minStaticKeyLength is the shortest static API key accepted. Keys never
+// expire and can't be throttled per user, so they must be unguessable;
+// `openssl rand -hex 32` gives 64 characters.
+const minStaticKeyLength = 32
+
+// StaticKeys are long-lived API keys accepted as bearer tokens in place of
+// OAuth, for self-hosted single-user deployments where the browser flow is
+// more trouble than it's worth. Keys are held only as hashes.
+type StaticKeys struct {
+	names map[string]string // TokenKey of each key to its name
+}
+
+// StaticKeysFromEnv reads keys from MCP_API_KEYS, a comma-separated list,
+// and MCP_API_KEYS_FILE, one per line with # comments. Either form may be
+// written name=key so warnings about it say which. It returns nil when no
+// keys are configured, leaving OAuth in charge.
+func StaticKeysFromEnv() *StaticKeys {
+	entries := strings.Split(os.Getenv("MCP_API_KEYS"), ",")
+	if path := os.Getenv("MCP_API_KEYS_FILE"); path != "" {
+		lines, err := readKeyFile(path)
+		if err != nil {
+			log.Printf("Auth: WARNING: cannot read MCP_API_KEYS_FILE: %v", err)
+		}
+		entries = append(entries, lines...)
+	}
+	return NewStaticKeys(entries)
+}
+
+// NewStaticKeys accepts each entry, key or name=key, skipping blank ones
+// and keys too short to be safe. It returns nil when none are left.
+func NewStaticKeys(entries []string) *StaticKeys {
+	keys := &StaticKeys{names: make(map[string]string)}
+	for i, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, key := fmt.Sprintf("key-%d", i+1), entry
+		if before, after, found := strings.Cut(entry, "="); found && before != "" && after != "" {
+			name, key = strings.TrimSpace(before), strings.TrimSpace(after)
+		}
+		if len(key) < minStaticKeyLength {
+			log.Printf("Auth: WARNING: ignoring static API key %q, shorter than %d characters", name, minStaticKeyLength)
+			continue
+		}
+		keys.names[TokenKey(key)] = name
+	}
+	if len(keys.names) == 0 {
+		return nil
+	}
+	return keys
+}
+
+// Len is how many keys are accepted
+func (k *StaticKeys) Len() int {
+	return len(k.names)
+}
+
+// Lookup returns the name of the key token is, if it is one. Only hashes
+// are compared, so timing says nothing about the keys themselves.
+func (k *StaticKeys) Lookup(token string) (string, bool) {
+	if token == "" {
+		return "", false
+	}
+	name, ok := k.names[TokenKey(token)]
+	return name, ok
+}
+
+// StaticKeyMiddleware requires one of keys as the bearer token on every
+// request except /health. There is no OAuth discovery to point clients
+// at, so a refused request only gets a plain Bearer challenge.
+func StaticKeyMiddleware(keys *StaticKeys) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/health" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			authHeader := r.Header.Get("Authorization")
+			token, found := strings.CutPrefix(authHeader, "Bearer ")
+			if !found {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="mcp"`)
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			if _, ok := keys.Lookup(strings.TrimSpace(token)); !ok {
+				log.Printf("[AUDIT] static_key_rejected ip=%s path=%s", ClientIP(r), r.URL.Path)
+				w.Header().Set("WWW-Authenticate", `Bearer realm="mcp" error="invalid_token"`)
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// readKeyFile returns the non-comment lines of path
+func readKeyFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStaticKeys(t *testing.T) {
+	t.Logf("Importance: Static API keys are the only thing protecting /mcp in single-user deployments that skip OAuth.")
+
+	laptop := strings.Repeat("a", 40)
+	phone := strings.Repeat("b", 40)
+
+	t.Run("configuration", func(t *testing.T) {
+		t.Logf("  > Why it's important: Short keys can be guessed, and no keys at all must leave OAuth in charge.")
+		dir := t.TempDir()
+		path := filepath.Join(dir, "keys")
+		if err := os.WriteFile(path, []byte("# my devices\nphone="+phone+"\n\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		t.Setenv("MCP_API_KEYS", "laptop="+laptop+", short=tooshort")
+		t.Setenv("MCP_API_KEYS_FILE", path)
+		keys := StaticKeysFromEnv()
+		if keys == nil || keys.Len() != 2 {
+			t.Fatalf("Expected two usable keys, got %v", keys)
+		}
+		if name, ok := keys.Lookup(phone); !ok || name != "phone" {
+			t.Errorf("Expected the file's key named phone, got %q %v", name, ok)
+		}
+		if _, ok := keys.Lookup("tooshort"); ok {
+			t.Error("Expected a short key ignored")
+		}
+
+		t.Setenv("MCP_API_KEYS", "")
+		t.Setenv("MCP_API_KEYS_FILE", "")
+		if StaticKeysFromEnv() != nil {
+			t.Error("Expected nil without configured keys")
+		}
+	})
+
+	t.Run("middleware", func(t *testing.T) {
+		t.Logf("  > Why it's important: Every request but the health check must carry a configured key.")
+		handler := StaticKeyMiddleware(NewStaticKeys([]string{laptop}))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		request := func(path, authorization string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("POST", path, nil)
+			if authorization != "" {
+				req.Header.Set("Authorization", authorization)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			return w
+		}
+
+		if w := request("/mcp", "Bearer "+laptop); w.Code != http.StatusOK {
+			t.Errorf("Expected a configured key accepted, got %d", w.Code)
+		}
+		if w := request("/mcp", ""); w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("Expected 401 with a challenge without a key, got %d", w.Code)
+		}
+		if w := request("/mcp", "Bearer "+phone); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected an unknown key refused, got %d", w.Code)
+		}
+		if w := request("/oauth/authorize", ""); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected OAuth paths closed too, got %d", w.Code)
+		}
+		if w := request("/health", ""); w.Code != http.StatusOK {
+			t.Errorf("Expected the health check open, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vcto/mcp-adapters/internal/kv"
+	"github.com/vcto/mcp-adapters/internal/residency"
+)
+
+// revokedTokenBucket is the kv bucket holding revoked subject IDs
+const revokedTokenBucket = "revoked_tokens"
+
+// TokenInfo describes a bearer token by its subject ID, so operators can
+// see and revoke tokens without the tokens themselves being shown or stored
+type TokenInfo struct {
+	Subject   string    `json:"subject"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	Requests  int64     `json:"requests"`
+	Revoked   bool      `json:"revoked,omitempty"`
+}
+
+// TokenRegistry records the bearer tokens accepted by the auth middleware
+// and the subjects an operator has revoked. Revocations are kept in a kv
+// store so they survive restarts; activity is kept in memory.
+type TokenRegistry struct {
+	mu      sync.RWMutex
+	seen    map[string]*TokenInfo
+	revoked map[string]bool
+	store   *kv.Bucket[time.Time]
+}
+
+// NewTokenRegistry creates a registry keeping revocations in store, loading
+// any already there
+func NewTokenRegistry(store kv.Store) (*TokenRegistry, error) {
+	r := &TokenRegistry{
+		seen:    make(map[string]*TokenInfo),
+		revoked: make(map[string]bool),
+		store:   kv.NewBucket[time.Time](store, revokedTokenBucket),
+	}
+
+	subjects, err := r.store.Keys()
+	if err != nil {
+		return nil, fmt.Errorf("listing revoked tokens: %w", err)
+	}
+	for _, subject := range subjects {
+		r.revoked[subject] = true
+	}
+	return r, nil
+}
+
+// Seen records a request made with token
+func (r *TokenRegistry) Seen(token string) {
+	subject := residency.SubjectID(token)
+	now := time.Now().UTC()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	info, ok := r.seen[subject]
+	if !ok {
+		info = &TokenInfo{Subject: subject, FirstSeen: now}
+		r.seen[subject] = info
+	}
+	info.LastSeen = now
+	info.Requests++
+}
+
+// Revoked reports whether token's subject has been revoked
+func (r *TokenRegistry) Revoked(token string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.revoked[residency.SubjectID(token)]
+}
+
+// Revoke rejects the subject's token from now on. It reports whether the
+// subject had been seen since startup; unseen subjects are revoked anyway,
+// so a token can be blocked before it is next used.
+func (r *TokenRegistry) Revoke(subject string) (bool, error) {
+	if err := r.store.Put(subject, time.Now().UTC(), 0); err != nil {
+		return false, fmt.Errorf("saving revocation: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.revoked[subject] = true
+	_, known := r.seen[subject]
+	log.Printf("[AUDIT] token revoked subject=%s known=%v", subject, known)
+	return known, nil
+}
+
+// List returns the tokens seen since startup and any revoked subjects,
+// most recently used first
+func (r *TokenRegistry) List() []TokenInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tokens := make([]TokenInfo, 0, len(r.seen))
+	for _, info := range r.seen {
+		entry := *info
+		entry.Revoked = r.revoked[info.Subject]
+		tokens = append(tokens, entry)
+	}
+	for subject := range r.revoked {
+		if _, ok := r.seen[subject]; !ok {
+			tokens = append(tokens, TokenInfo{Subject: subject, Revoked: true})
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		if !tokens[i].LastSeen.Equal(tokens[j].LastSeen) {
+			return tokens[i].LastSeen.After(tokens[j].LastSeen)
+		}
+		return tokens[i].Subject < tokens[j].Subject
+	})
+	return tokens
+}
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/vcto/mcp-adapters/internal/kv"
+	"github.com/vcto/mcp-adapters/internal/residency"
+)
+
+func TestTokenRegistry(t *testing.T) {
+	t.Logf("Importance: Revoking a leaked token is only useful if it stays revoked; a restart must not let it back in.")
+
+	store := kv.NewMemoryStore()
+	tokens, err := NewTokenRegistry(store)
+	if err != nil {
+		t.Fatalf("NewTokenRegistry failed: %v", err)
+	}
+	tokens.Seen("token-a")
+	tokens.Seen("token-a")
+	tokens.Seen("token-b")
+
+	listed := tokens.List()
+	if len(listed) != 2 {
+		t.Fatalf("Expected two tokens, got %v", listed)
+	}
+	for _, info := range listed {
+		if info.Subject == "token-a" || info.Subject == "token-b" {
+			t.Fatal("Expected tokens listed by subject ID, not value")
+		}
+		if info.Subject == residency.SubjectID("token-a") && info.Requests != 2 {
+			t.Errorf("Expected two requests for token-a, got %d", info.Requests)
+		}
+	}
+
+	known, err := tokens.Revoke(residency.SubjectID("token-a"))
+	if err != nil || !known {
+		t.Fatalf("Expected known subject revoked, got %v %v", known, err)
+	}
+	if known, _ := tokens.Revoke(residency.SubjectID("token-c")); known {
+		t.Error("Expected unseen subject reported as unknown")
+	}
+	if !tokens.Revoked("token-a") || tokens.Revoked("token-b") {
+		t.Error("Expected only token-a revoked")
+	}
+
+	t.Run("revocations survive a restart", func(t *testing.T) {
+		t.Logf("  > Why it's important: Activity is in memory, but revocations must come back from the store.")
+		restarted, err := NewTokenRegistry(store)
+		if err != nil {
+			t.Fatalf("NewTokenRegistry failed: %v", err)
+		}
+		if !restarted.Revoked("token-a") || !restarted.Revoked("token-c") {
+			t.Error("Expected revocations reloaded from the store")
+		}
+		if len(restarted.List()) != 2 {
+			t.Errorf("Expected the two revoked subjects listed, got %v", restarted.List())
+		}
+	})
+}
@@ -5,10 +5,12 @@ import (
 	"time"
 )
 
-// TokenStore manages OAuth tokens in memory
-type TokenStore struct {
+// MemoryTokenStore keeps OAuth tokens in memory, for single instances and
+// tests
+type MemoryTokenStore struct {
 	mu     sync.RWMutex
 	tokens map[string]*Token
+	ttl    time.Duration // How long a token lasts, 0 for no limit
 	done   chan struct{} // For stopping cleanup goroutine
 }
 
@@ -20,10 +22,17 @@ type Token struct {
 	ExpiresAt time.Time
 }
 
-// NewTokenStore creates a new token store
-func NewTokenStore() *TokenStore {
-	store := &TokenStore{
+// NewMemoryTokenStore creates a memory token store whose tokens last an hour
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return newTokenStoreWithTTL(defaultAccessTokenTTL)
+}
+
+// newTokenStoreWithTTL creates a token store whose tokens last ttl, or
+// indefinitely when ttl is 0
+func newTokenStoreWithTTL(ttl time.Duration) *MemoryTokenStore {
+	store := &MemoryTokenStore{
 		tokens: make(map[string]*Token),
+		ttl:    ttl,
 		done:   make(chan struct{}),
 	}
 	// Start cleanup goroutine
@@ -32,39 +41,42 @@ func NewTokenStore() *TokenStore {
 }
 
 // Store saves a token mapping
-func (s *TokenStore) Store(token, apiKey string) {
+func (s *MemoryTokenStore) Store(token, apiKey string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.tokens[token] = &Token{
+	t := &Token{
 		Value:     token,
 		RTMAPIKey: apiKey,
 		CreatedAt: time.Now(),
-		ExpiresAt: time.Now().Add(1 * time.Hour),
 	}
+	if s.ttl > 0 {
+		t.ExpiresAt = t.CreatedAt.Add(s.ttl)
+	}
+	s.tokens[token] = t
 }
 
 // Get retrieves API key for token
-func (s *TokenStore) Get(token string) (string, bool) {
+func (s *MemoryTokenStore) Get(token string) (string, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	t, exists := s.tokens[token]
-	if !exists || time.Now().After(t.ExpiresAt) {
+	if !exists || t.expired(time.Now()) {
 		return "", false
 	}
 	return t.RTMAPIKey, true
 }
 
 // Delete removes a token
-func (s *TokenStore) Delete(token string) {
+func (s *MemoryTokenStore) Delete(token string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	delete(s.tokens, token)
 }
 
 // cleanupExpired removes expired tokens periodically
-func (s *TokenStore) cleanupExpired() {
+func (s *MemoryTokenStore) cleanupExpired() {
 	ticker := time.NewTicker(5 * time.Minute)
 	defer ticker.Stop()
 
@@ -74,7 +86,7 @@ func (s *TokenStore) cleanupExpired() {
 			s.mu.Lock()
 			now := time.Now()
 			for token, t := range s.tokens {
-				if now.After(t.ExpiresAt) {
+				if t.expired(now) {
 					delete(s.tokens, token)
 				}
 			}
@@ -85,8 +97,13 @@ func (s *TokenStore) cleanupExpired() {
 	}
 }
 
+// expired reports whether the token has passed its expiry, if it has one
+func (t *Token) expired(now time.Time) bool {
+	return !t.ExpiresAt.IsZero() && now.After(t.ExpiresAt)
+}
+
 // Close stops the cleanup goroutine
-func (s *TokenStore) Close() error {
+func (s *MemoryTokenStore) Close() error {
 	close(s.done)
 	return nil
 }
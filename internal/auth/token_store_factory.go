@@ -6,40 +6,112 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/vcto/mcp-adapters/internal/atrest"
+	"github.com/vcto/mcp-adapters/internal/kv"
+	"github.com/vcto/mcp-adapters/internal/residency"
 )
 
-// TokenStoreInterface defines token storage operations
-type TokenStoreInterface interface {
+// TokenEncryptionTarget lists the token store columns encrypted at rest, for migrations
+var TokenEncryptionTarget = atrest.Target{Table: "oauth_tokens", Columns: []string{"api_key"}}
+
+// TokenStore keeps issued bearer tokens and the RTM API keys they stand
+// for. MemoryTokenStore, SQLiteTokenStore and KVTokenStore implement it;
+// OAUTH_TOKEN_STORE chooses which one the adapters use.
+type TokenStore interface {
 	Store(token, apiKey string)
 	Get(token string) (string, bool)
 	Delete(token string)
 	Close() error // For cleanup
 }
 
-// CreateTokenStore creates appropriate token store based on environment
-func CreateTokenStore() TokenStoreInterface {
+// Backends OAUTH_TOKEN_STORE can choose
+const (
+	TokenBackendMemory = "memory"
+	TokenBackendSQLite = "sqlite"
+	TokenBackendRedis  = "redis"
+)
+
+// TokenBackendFromEnv reads OAUTH_TOKEN_STORE. Unset, it is sqlite when
+// OAuthDBPath is set and memory otherwise, as before the setting existed.
+// Deployments running several instances behind a load balancer use redis,
+// so any instance can finish a sign-in another one started.
+func TokenBackendFromEnv() string {
+	backend := strings.ToLower(os.Getenv("OAUTH_TOKEN_STORE"))
+	switch backend {
+	case TokenBackendMemory, TokenBackendSQLite, TokenBackendRedis:
+		return backend
+	case "":
+	default:
+		log.Printf("Unknown OAUTH_TOKEN_STORE %q, choosing from OAUTH_DB_PATH", backend)
+	}
+	if OAuthDBPath() != "" {
+		return TokenBackendSQLite
+	}
+	return TokenBackendMemory
+}
+
+// defaultAccessTokenTTL is how long the generic adapter's access tokens
+// last when OAUTH_ACCESS_TOKEN_TTL is unset
+const defaultAccessTokenTTL = time.Hour
+
+// AccessTokenTTLFromEnv reads OAUTH_ACCESS_TOKEN_TTL, a Go duration such as
+// "1h" or "720h", falling back to def when it is unset or invalid. 0 means
+// access tokens don't expire.
+func AccessTokenTTLFromEnv(def time.Duration) time.Duration {
+	value := os.Getenv("OAUTH_ACCESS_TOKEN_TTL")
+	if value == "" {
+		return def
+	}
+	if value == "0" {
+		return 0
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl < 0 {
+		log.Printf("Invalid OAUTH_ACCESS_TOKEN_TTL %q, using default %s", value, def)
+		return def
+	}
+	return ttl
+}
+
+// CreateTokenStore creates the token store OAUTH_TOKEN_STORE chooses,
+// keeping tokens for ttl after they are issued (0 for no limit). The redis
+// backend keeps tokens in store, the adapter's OAuth store, which
+// OpenOAuthStore opened on Redis.
+func CreateTokenStore(store kv.Store, ttl time.Duration) TokenStore {
+	backend := TokenBackendFromEnv()
+	if backend == TokenBackendRedis {
+		return NewKVTokenStore(store, ttl)
+	}
+
 	// Check if we should use SQLite
-	dbPath := os.Getenv("TOKEN_DB_PATH")
-	if dbPath != "" {
+	dbPath := OAuthDBPath()
+	if backend == TokenBackendSQLite && dbPath != "" {
 		store, err := NewSQLiteTokenStore(dbPath)
 		if err != nil {
 			log.Printf("Failed to create SQLite token store: %v, falling back to in-memory", err)
-			return NewTokenStore() // Fall back to in-memory
+			return newTokenStoreWithTTL(ttl) // Fall back to in-memory
 		}
+		store.ttl = ttl
 		log.Printf("Using SQLite token store at %s", dbPath)
 
-		// Start cleanup routine
+		// Start cleanup routine. Tokens without a lifetime are kept: an
+		// idle one is still valid, and its record holds the scopes it was
+		// limited to.
 		go func() {
+			if ttl <= 0 {
+				return
+			}
 			ticker := time.NewTicker(1 * time.Hour)
 			defer ticker.Stop()
 			for {
 				select {
 				case <-ticker.C:
-					if err := store.CleanupExpired(24 * time.Hour); err != nil {
+					if err := store.CleanupExpired(ttl); err != nil {
 						log.Printf("Token cleanup error: %v", err)
 					}
 				case <-store.done:
@@ -51,19 +123,28 @@ func CreateTokenStore() TokenStoreInterface {
 		return store
 	}
 
-	log.Println("Using in-memory token store (set TOKEN_DB_PATH for persistence)")
-	return NewTokenStore()
+	log.Println("Using in-memory token store (set OAUTH_DB_PATH or TOKEN_DB_PATH for persistence)")
+	return newTokenStoreWithTTL(ttl)
 }
 
-// SQLiteTokenStore implements persistent token storage
+// SQLiteTokenStore implements persistent token storage. Tokens are stored
+// only as hashes, and API keys are encrypted when storage encryption is
+// configured.
 type SQLiteTokenStore struct {
-	db   *sql.DB
-	mu   sync.RWMutex
-	done chan struct{}
+	db      *sql.DB
+	mu      sync.RWMutex
+	done    chan struct{}
+	keyring *atrest.Keyring
+	ttl     time.Duration // How long a token lasts after it is issued, 0 for no limit
 }
 
 // NewSQLiteTokenStore creates a new SQLite-backed token store
 func NewSQLiteTokenStore(dbPath string) (*SQLiteTokenStore, error) {
+	keyring, err := atrest.Default()
+	if err != nil {
+		return nil, err
+	}
+
 	// Ensure directory exists
 	if dir := filepath.Dir(dbPath); dir != "." && dir != "" {
 		if err := os.MkdirAll(dir, 0755); err != nil {
@@ -71,7 +152,7 @@ func NewSQLiteTokenStore(dbPath string) (*SQLiteTokenStore, error) {
 		}
 	}
 
-	db, err := sql.Open("sqlite3", dbPath+"?_journal_mode=WAL")
+	db, err := sql.Open("sqlite3", dbPath+"?_journal_mode=WAL&_busy_timeout=5000")
 	if err != nil {
 		return nil, err
 	}
@@ -93,10 +174,15 @@ func NewSQLiteTokenStore(dbPath string) (*SQLiteTokenStore, error) {
 		}
 		return nil, fmt.Errorf("create db directory: %w", err)
 	}
+	if err := hashStoredTokens(db); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
 
 	return &SQLiteTokenStore{
-		db:   db,
-		done: make(chan struct{}),
+		db:      db,
+		done:    make(chan struct{}),
+		keyring: keyring,
 	}, nil
 }
 
@@ -105,13 +191,21 @@ func (s *SQLiteTokenStore) Store(token, apiKey string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	_, err := s.db.Exec(
+	sealed, err := s.keyring.Encrypt(apiKey)
+	if err != nil {
+		log.Printf("Failed to encrypt token: %v", err)
+		return
+	}
+
+	_, err = s.db.Exec(
 		"INSERT OR REPLACE INTO oauth_tokens (token, api_key, created_at, last_used) VALUES (?, ?, ?, ?)",
-		token, apiKey, time.Now(), time.Now(),
+		storedToken(token), sealed, time.Now(), time.Now(),
 	)
 	if err != nil {
 		log.Printf("Failed to store token: %v", err)
+		return
 	}
+	residency.Record(residency.SubjectID(token), residency.StoreTokens)
 }
 
 // Get retrieves apiKey for token and updates last_used
@@ -119,17 +213,26 @@ func (s *SQLiteTokenStore) Get(token string) (string, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	var apiKey string
-	err := s.db.QueryRow("SELECT api_key FROM oauth_tokens WHERE token = ?", token).Scan(&apiKey)
+	var sealed string
+	var createdAt time.Time
+	err := s.db.QueryRow("SELECT api_key, created_at FROM oauth_tokens WHERE token = ?", storedToken(token)).Scan(&sealed, &createdAt)
 	if err != nil {
 		return "", false
 	}
+	if s.ttl > 0 && time.Since(createdAt) > s.ttl {
+		return "", false
+	}
+	apiKey, err := s.keyring.Decrypt(sealed)
+	if err != nil {
+		log.Printf("Failed to decrypt token: %v", err)
+		return "", false
+	}
 
 	// Update last used (async to avoid blocking)
 	go func() {
 		s.mu.Lock()
 		defer s.mu.Unlock()
-		_, _ = s.db.Exec("UPDATE oauth_tokens SET last_used = ? WHERE token = ?", time.Now(), token)
+		_, _ = s.db.Exec("UPDATE oauth_tokens SET last_used = ? WHERE token = ?", time.Now(), storedToken(token))
 	}()
 
 	return apiKey, true
@@ -140,19 +243,19 @@ func (s *SQLiteTokenStore) Delete(token string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	_, err := s.db.Exec("DELETE FROM oauth_tokens WHERE token = ?", token)
+	_, err := s.db.Exec("DELETE FROM oauth_tokens WHERE token = ?", storedToken(token))
 	if err != nil {
 		log.Printf("Failed to delete token: %v", err)
 	}
 }
 
-// CleanupExpired removes tokens not used in the specified duration
+// CleanupExpired removes tokens issued more than maxAge ago
 func (s *SQLiteTokenStore) CleanupExpired(maxAge time.Duration) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	cutoff := time.Now().Add(-maxAge)
-	result, err := s.db.Exec("DELETE FROM oauth_tokens WHERE last_used < ?", cutoff)
+	result, err := s.db.Exec("DELETE FROM oauth_tokens WHERE created_at < ?", cutoff)
 	if err != nil {
 		return err
 	}
@@ -164,6 +267,46 @@ func (s *SQLiteTokenStore) CleanupExpired(maxAge time.Duration) error {
 	return nil
 }
 
+// storedTokenPrefix marks a token column holding a hash
+const storedTokenPrefix = "sha256:"
+
+// storedToken is what the token column holds for token, so a copy of the
+// database holds no usable bearer tokens
+func storedToken(token string) string {
+	return storedTokenPrefix + TokenKey(token)
+}
+
+// hashStoredTokens replaces tokens stored in plaintext by earlier releases
+// with their hashes
+func hashStoredTokens(db *sql.DB) error {
+	rows, err := db.Query("SELECT token FROM oauth_tokens WHERE token NOT LIKE ?", storedTokenPrefix+"%")
+	if err != nil {
+		return fmt.Errorf("reading plaintext tokens: %w", err)
+	}
+	var tokens []string
+	for rows.Next() {
+		var token string
+		if err := rows.Scan(&token); err != nil {
+			_ = rows.Close()
+			return fmt.Errorf("reading plaintext tokens: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+	if err := rows.Close(); err != nil {
+		return fmt.Errorf("reading plaintext tokens: %w", err)
+	}
+
+	for _, token := range tokens {
+		if _, err := db.Exec("UPDATE OR REPLACE oauth_tokens SET token = ? WHERE token = ?", storedToken(token), token); err != nil {
+			return fmt.Errorf("hashing stored token: %w", err)
+		}
+	}
+	if len(tokens) > 0 {
+		log.Printf("Hashed %d token(s) stored in plaintext", len(tokens))
+	}
+	return nil
+}
+
 // Close closes the database connection and stops the cleanup goroutine
 func (s *SQLiteTokenStore) Close() error {
 	close(s.done)
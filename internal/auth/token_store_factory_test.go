@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+func TestSQLiteTokenStoreHashesTokens(t *testing.T) {
+	t.Logf("Importance: A copy of the token database must not hand out bearer tokens that work against the server.")
+	path := filepath.Join(t.TempDir(), "tokens.db")
+
+	store, err := NewSQLiteTokenStore(path)
+	if err != nil {
+		t.Fatalf("NewSQLiteTokenStore failed: %v", err)
+	}
+	store.Store("bearer-new", "rtm-key-new")
+	if _, err := store.db.Exec("INSERT INTO oauth_tokens (token, api_key) VALUES (?, ?)", "bearer-legacy", "rtm-key-legacy"); err != nil {
+		t.Fatalf("Failed to insert legacy row: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	store, err = NewSQLiteTokenStore(path)
+	if err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	defer store.Close()
+
+	t.Run("only hashes on disk", func(t *testing.T) {
+		t.Logf("  > Why it's important: Rows written before hashing are migrated when the store opens.")
+		db, err := sql.Open("sqlite3", path)
+		if err != nil {
+			t.Fatalf("Failed to open database: %v", err)
+		}
+		defer db.Close()
+		var plaintext int
+		if err := db.QueryRow("SELECT COUNT(*) FROM oauth_tokens WHERE token IN ('bearer-new', 'bearer-legacy')").Scan(&plaintext); err != nil || plaintext != 0 {
+			t.Errorf("Expected no plaintext tokens stored, got %d (err=%v)", plaintext, err)
+		}
+	})
+
+	t.Run("looks tokens up by hash", func(t *testing.T) {
+		for token, want := range map[string]string{"bearer-new": "rtm-key-new", "bearer-legacy": "rtm-key-legacy"} {
+			if apiKey, ok := store.Get(token); !ok || apiKey != want {
+				t.Errorf("Expected %s to map to %s, got %q %v", token, want, apiKey, ok)
+			}
+		}
+		store.Delete("bearer-new")
+		if _, ok := store.Get("bearer-new"); ok {
+			t.Error("Expected the deleted token gone")
+		}
+	})
+}
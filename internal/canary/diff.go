@@ -0,0 +1,188 @@
+package canary
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maxDiffsPerResponse bounds how many differences one response pair reports
+const maxDiffsPerResponse = 20
+
+// indexPattern matches array indices in a diff path
+var indexPattern = regexp.MustCompile(`\[\d+\]`)
+
+// differ compares two decoded JSON documents
+type differ struct {
+	// ignore holds paths to skip, with array indices written as []
+	ignore map[string]bool
+	diffs  []string
+}
+
+// Diff compares two JSON documents structurally and returns their
+// differences as "path: detail" lines, such as
+// `result.tools[3].name: "a" != "b"`. Paths in ignore, written with array
+// indices as [] ("result.serverInfo.version", "result.tools[].description"),
+// are skipped. Bodies that are not JSON are compared byte for byte.
+func Diff(primary, canary []byte, ignore []string) []string {
+	d := &differ{ignore: make(map[string]bool, len(ignore))}
+	for _, path := range ignore {
+		d.ignore[path] = true
+	}
+
+	a, errA := decodeBody(primary)
+	b, errB := decodeBody(canary)
+	if errA != nil || errB != nil {
+		if !bytes.Equal(bytes.TrimSpace(primary), bytes.TrimSpace(canary)) {
+			return []string{"body: not JSON and bytes differ"}
+		}
+		return nil
+	}
+	d.compare("", a, b)
+	return d.diffs
+}
+
+// decodeBody decodes a JSON body, or the data lines of a server-sent event
+// stream as a JSON array
+func decodeBody(body []byte) (interface{}, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil, nil
+	}
+	if body[0] == '{' || body[0] == '[' {
+		var value interface{}
+		err := json.Unmarshal(body, &value)
+		return value, err
+	}
+
+	var events []interface{}
+	for _, line := range strings.Split(string(body), "\n") {
+		data, ok := strings.CutPrefix(strings.TrimSpace(line), "data:")
+		if !ok {
+			continue
+		}
+		var value interface{}
+		if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &value); err != nil {
+			return nil, err
+		}
+		events = append(events, value)
+	}
+	if events == nil {
+		return nil, fmt.Errorf("no JSON or event data")
+	}
+	return events, nil
+}
+
+func (d *differ) add(path, format string, args ...interface{}) {
+	if len(d.diffs) < maxDiffsPerResponse {
+		d.diffs = append(d.diffs, displayPath(path)+": "+fmt.Sprintf(format, args...))
+	}
+}
+
+func (d *differ) compare(path string, a, b interface{}) {
+	if d.ignore[indexPattern.ReplaceAllString(path, "[]")] {
+		return
+	}
+
+	switch a := a.(type) {
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok {
+			d.add(path, "object != %s", kind(b))
+			return
+		}
+		keys := make([]string, 0, len(a)+len(b))
+		for key := range a {
+			keys = append(keys, key)
+		}
+		for key := range b {
+			if _, ok := a[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			child := joinPath(path, key)
+			va, inA := a[key]
+			vb, inB := b[key]
+			switch {
+			case !inB:
+				if !d.ignore[indexPattern.ReplaceAllString(child, "[]")] {
+					d.add(child, "missing from canary")
+				}
+			case !inA:
+				if !d.ignore[indexPattern.ReplaceAllString(child, "[]")] {
+					d.add(child, "only in canary")
+				}
+			default:
+				d.compare(child, va, vb)
+			}
+		}
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok {
+			d.add(path, "array != %s", kind(b))
+			return
+		}
+		if len(a) != len(b) {
+			d.add(path, "length %d != %d", len(a), len(b))
+		}
+		for i := 0; i < len(a) && i < len(b); i++ {
+			d.compare(fmt.Sprintf("%s[%d]", path, i), a[i], b[i])
+		}
+	default:
+		if kind(a) != kind(b) {
+			d.add(path, "%s != %s", kind(a), kind(b))
+			return
+		}
+		if a != b {
+			d.add(path, "%s != %s", literal(a), literal(b))
+		}
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func displayPath(path string) string {
+	if path == "" {
+		return "body"
+	}
+	return path
+}
+
+// kind names a decoded JSON value's type
+func kind(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// literal formats a scalar for a diff line, shortening long strings
+func literal(v interface{}) string {
+	data, _ := json.Marshal(v)
+	if len(data) > 60 {
+		return string(data[:57]) + "..."
+	}
+	return string(data)
+}
@@ -0,0 +1,519 @@
+// Package canary mirrors live MCP traffic to a canary deployment and
+// compares its responses with the primary's, so a new build can be judged
+// on real requests before it is promoted. Clients only ever see the
+// primary's response: mirroring happens afterwards, one request at a time,
+// and a slow or failing canary never delays or changes what they receive.
+package canary
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mirror defaults
+const (
+	DefaultTimeout = 10 * time.Second
+	// maxCapturedBody bounds the request and response bodies kept for a
+	// comparison; larger exchanges are not mirrored
+	maxCapturedBody = 1 << 20
+	// queueSize bounds exchanges waiting for the canary; more are dropped
+	queueSize = 256
+	// maxReportMismatches keeps the most recent mismatches in the report
+	maxReportMismatches = 50
+	sessionHeader       = "Mcp-Session-Id"
+)
+
+// Report verdicts
+const (
+	VerdictPass      = "pass"
+	VerdictFail      = "fail"
+	VerdictNoTraffic = "no_traffic"
+)
+
+// Skip reasons
+const (
+	SkipNotJSONRPC     = "not_jsonrpc"
+	SkipWriteTool      = "write_tool"
+	SkipUnknownSession = "unknown_session"
+	SkipTooLarge       = "too_large"
+	SkipQueueFull      = "queue_full"
+)
+
+// DefaultIgnore lists response paths expected to differ between builds
+var DefaultIgnore = []string{"result.serverInfo.version", "[].result.serverInfo.version"}
+
+// Config configures a Mirror
+type Config struct {
+	// Target is the canary's base URL, such as https://canary.example.com
+	Target string
+	// MirrorWrites also mirrors tools/call for tools the primary does not
+	// mark read-only. Off by default: the canary would apply every change
+	// a second time.
+	MirrorWrites bool
+	// Ignore lists response paths not compared, in Diff's syntax
+	Ignore []string
+	// MaxMismatchRate is the share of mirrored requests allowed to differ
+	// or fail before the verdict is VerdictFail
+	MaxMismatchRate float64
+	// Timeout bounds each canary request
+	Timeout time.Duration
+}
+
+// Mismatch is one mirrored request the canary answered differently
+type Mismatch struct {
+	At            time.Time `json:"at"`
+	Method        string    `json:"method"`
+	Tool          string    `json:"tool,omitempty"`
+	PrimaryStatus int       `json:"primary_status"`
+	CanaryStatus  int       `json:"canary_status,omitempty"`
+	Diffs         []string  `json:"diffs,omitempty"`
+	Error         string    `json:"error,omitempty"`
+}
+
+// Report summarises mirroring so far
+type Report struct {
+	Target    string    `json:"target"`
+	StartedAt time.Time `json:"started_at"`
+	// Requests counts exchanges seen, mirrored or not
+	Requests         int            `json:"requests"`
+	Mirrored         int            `json:"mirrored"`
+	Skipped          map[string]int `json:"skipped"`
+	Matched          int            `json:"matched"`
+	StatusMismatches int            `json:"status_mismatches"`
+	BodyMismatches   int            `json:"body_mismatches"`
+	Errors           int            `json:"errors"`
+	PrimaryLatencyMS float64        `json:"primary_latency_ms"`
+	CanaryLatencyMS  float64        `json:"canary_latency_ms"`
+	MismatchRate     float64        `json:"mismatch_rate"`
+	Verdict          string         `json:"verdict"`
+	// Mismatches holds the most recent mismatches, oldest first
+	Mismatches []Mismatch `json:"mismatches"`
+}
+
+// exchange is a primary request and response waiting to be mirrored
+type exchange struct {
+	method         string
+	uri            string
+	header         http.Header
+	body           []byte
+	primaryStatus  int
+	primaryHeader  http.Header
+	primaryBody    []byte
+	primaryLatency time.Duration
+}
+
+// rpcCall is the part of a JSON-RPC request mirroring decisions use
+type rpcCall struct {
+	Method string `json:"method"`
+	Params struct {
+		Name string `json:"name"`
+	} `json:"params"`
+}
+
+// Mirror copies traffic to a canary and compares responses
+type Mirror struct {
+	config Config
+	target *url.URL
+	client *http.Client
+	queue  chan exchange
+	done   chan struct{}
+
+	mu      sync.Mutex
+	closing bool
+	report  Report
+	// sessions maps primary MCP session IDs to the canary's
+	sessions map[string]string
+	// readOnly holds tools the primary marks readOnlyHint
+	readOnly                  map[string]bool
+	primaryTotal, canaryTotal time.Duration
+}
+
+// New starts a mirror to config.Target
+func New(config Config) (*Mirror, error) {
+	target, err := url.Parse(config.Target)
+	if err != nil || target.Scheme == "" || target.Host == "" {
+		return nil, fmt.Errorf("invalid canary target %q", config.Target)
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+	if config.Ignore == nil {
+		config.Ignore = DefaultIgnore
+	}
+
+	m := &Mirror{
+		config: config,
+		target: target,
+		client: &http.Client{Timeout: config.Timeout},
+		queue:  make(chan exchange, queueSize),
+		done:   make(chan struct{}),
+		report: Report{
+			Target:     target.String(),
+			StartedAt:  time.Now().UTC(),
+			Skipped:    make(map[string]int),
+			Mismatches: []Mismatch{},
+		},
+		sessions: make(map[string]string),
+		readOnly: make(map[string]bool),
+	}
+	go m.worker()
+	return m, nil
+}
+
+// Middleware serves requests with next and queues POST and DELETE
+// exchanges, the MCP requests and session ends, for mirroring. GET event
+// streams are never mirrored.
+func (m *Mirror) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxCapturedBody+1))
+		r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+		if err != nil || len(body) > maxCapturedBody {
+			m.skip(SkipTooLarge)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header := r.Header.Clone()
+		capture := &captureWriter{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		next.ServeHTTP(capture, r)
+		if capture.truncated {
+			m.skip(SkipTooLarge)
+			return
+		}
+
+		m.enqueue(exchange{
+			method:         r.Method,
+			uri:            r.URL.RequestURI(),
+			header:         header,
+			body:           body,
+			primaryStatus:  capture.status,
+			primaryHeader:  w.Header().Clone(),
+			primaryBody:    capture.body.Bytes(),
+			primaryLatency: time.Since(start),
+		})
+	})
+}
+
+func (m *Mirror) enqueue(ex exchange) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closing {
+		return
+	}
+	select {
+	case m.queue <- ex:
+	default:
+		m.report.Requests++
+		m.report.Skipped[SkipQueueFull]++
+	}
+}
+
+func (m *Mirror) skip(reason string) {
+	m.mu.Lock()
+	m.report.Requests++
+	m.report.Skipped[reason]++
+	m.mu.Unlock()
+}
+
+// Close stops accepting exchanges and waits for queued ones to finish
+func (m *Mirror) Close() {
+	m.mu.Lock()
+	if m.closing {
+		m.mu.Unlock()
+		return
+	}
+	m.closing = true
+	close(m.queue)
+	m.mu.Unlock()
+	<-m.done
+}
+
+func (m *Mirror) worker() {
+	defer close(m.done)
+	for ex := range m.queue {
+		m.process(ex)
+	}
+}
+
+// process mirrors one exchange, in arrival order so sessions are created
+// on the canary before they are used
+func (m *Mirror) process(ex exchange) {
+	calls, isRPC := decodeCalls(ex.body)
+	if ex.method == http.MethodPost && !isRPC {
+		m.skip(SkipNotJSONRPC)
+		return
+	}
+	m.learnTools(calls, ex)
+
+	m.mu.Lock()
+	var tool string
+	for _, call := range calls {
+		if call.Method != "tools/call" {
+			continue
+		}
+		tool = call.Params.Name
+		if !m.config.MirrorWrites && !m.readOnly[tool] {
+			m.report.Requests++
+			m.report.Skipped[SkipWriteTool]++
+			m.mu.Unlock()
+			return
+		}
+	}
+	primarySession := ex.header.Get(sessionHeader)
+	if primarySession != "" {
+		canarySession, ok := m.sessions[primarySession]
+		if !ok {
+			m.report.Requests++
+			m.report.Skipped[SkipUnknownSession]++
+			m.mu.Unlock()
+			return
+		}
+		ex.header.Set(sessionHeader, canarySession)
+	}
+	m.mu.Unlock()
+
+	mismatch := Mismatch{At: time.Now().UTC(), Method: ex.method, Tool: tool, PrimaryStatus: ex.primaryStatus}
+	if len(calls) > 0 {
+		mismatch.Method = calls[0].Method
+	}
+
+	start := time.Now()
+	status, header, body, err := m.send(ex)
+	latency := time.Since(start)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.report.Requests++
+	m.report.Mirrored++
+	m.primaryTotal += ex.primaryLatency
+	m.canaryTotal += latency
+
+	switch {
+	case err != nil:
+		m.report.Errors++
+		mismatch.Error = err.Error()
+	case status != ex.primaryStatus:
+		m.report.StatusMismatches++
+		mismatch.CanaryStatus = status
+	default:
+		mismatch.CanaryStatus = status
+		mismatch.Diffs = Diff(ex.primaryBody, body, m.config.Ignore)
+		if len(mismatch.Diffs) > 0 {
+			m.report.BodyMismatches++
+		}
+	}
+
+	if err == nil && status == ex.primaryStatus {
+		if primary, canary := ex.primaryHeader.Get(sessionHeader), header.Get(sessionHeader); primary != "" && canary != "" {
+			m.sessions[primary] = canary
+		}
+		if ex.method == http.MethodDelete {
+			delete(m.sessions, primarySession)
+		}
+	}
+
+	if mismatch.Error == "" && mismatch.CanaryStatus == ex.primaryStatus && len(mismatch.Diffs) == 0 {
+		m.report.Matched++
+		return
+	}
+	log.Printf("Canary: %s differs (%s)", mismatch.Method, describe(mismatch))
+	m.report.Mismatches = append(m.report.Mismatches, mismatch)
+	if len(m.report.Mismatches) > maxReportMismatches {
+		m.report.Mismatches = m.report.Mismatches[1:]
+	}
+}
+
+// send replays an exchange against the canary
+func (m *Mirror) send(ex exchange) (int, http.Header, []byte, error) {
+	req, err := http.NewRequest(ex.method, strings.TrimSuffix(m.target.String(), "/")+ex.uri, bytes.NewReader(ex.body))
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	req.Header = ex.header
+	req.Header.Del("Content-Length")
+	req.Header.Set("X-Canary-Mirror", "true")
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxCapturedBody))
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("reading canary response: %w", err)
+	}
+	return resp.StatusCode, resp.Header, body, nil
+}
+
+// learnTools records which tools the primary marks read-only from its
+// tools/list responses
+func (m *Mirror) learnTools(calls []rpcCall, ex exchange) {
+	listed := false
+	for _, call := range calls {
+		listed = listed || call.Method == "tools/list"
+	}
+	if !listed || ex.primaryStatus != http.StatusOK {
+		return
+	}
+
+	decoded, err := decodeBody(ex.primaryBody)
+	if err != nil {
+		return
+	}
+	responses, ok := decoded.([]interface{})
+	if !ok {
+		responses = []interface{}{decoded}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, response := range responses {
+		data, _ := json.Marshal(response)
+		var list struct {
+			Result struct {
+				Tools []struct {
+					Name        string `json:"name"`
+					Annotations struct {
+						ReadOnlyHint *bool `json:"readOnlyHint"`
+					} `json:"annotations"`
+				} `json:"tools"`
+			} `json:"result"`
+		}
+		if json.Unmarshal(data, &list) != nil {
+			continue
+		}
+		for _, tool := range list.Result.Tools {
+			m.readOnly[tool.Name] = tool.Annotations.ReadOnlyHint != nil && *tool.Annotations.ReadOnlyHint
+		}
+	}
+}
+
+// decodeCalls reads a JSON-RPC request or batch
+func decodeCalls(body []byte) ([]rpcCall, bool) {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil, false
+	}
+	var calls []rpcCall
+	if body[0] == '[' {
+		if err := json.Unmarshal(body, &calls); err != nil {
+			return nil, false
+		}
+	} else {
+		var call rpcCall
+		if err := json.Unmarshal(body, &call); err != nil {
+			return nil, false
+		}
+		calls = []rpcCall{call}
+	}
+	for _, call := range calls {
+		if call.Method == "" {
+			return nil, false
+		}
+	}
+	return calls, len(calls) > 0
+}
+
+func describe(m Mismatch) string {
+	switch {
+	case m.Error != "":
+		return "canary error: " + m.Error
+	case m.CanaryStatus != m.PrimaryStatus:
+		return fmt.Sprintf("status %d != %d", m.PrimaryStatus, m.CanaryStatus)
+	default:
+		return strings.Join(m.Diffs, "; ")
+	}
+}
+
+// Report returns the report so far, with its verdict
+func (m *Mirror) Report() Report {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	report := m.report
+	report.Skipped = make(map[string]int, len(m.report.Skipped))
+	for reason, n := range m.report.Skipped {
+		report.Skipped[reason] = n
+	}
+	report.Mismatches = append([]Mismatch{}, m.report.Mismatches...)
+
+	if report.Mirrored == 0 {
+		report.Verdict = VerdictNoTraffic
+		return report
+	}
+	report.PrimaryLatencyMS = float64(m.primaryTotal.Milliseconds()) / float64(report.Mirrored)
+	report.CanaryLatencyMS = float64(m.canaryTotal.Milliseconds()) / float64(report.Mirrored)
+	failed := report.StatusMismatches + report.BodyMismatches + report.Errors
+	report.MismatchRate = float64(failed) / float64(report.Mirrored)
+	report.Verdict = VerdictPass
+	if report.MismatchRate > m.config.MaxMismatchRate {
+		report.Verdict = VerdictFail
+	}
+	return report
+}
+
+// ServeReport writes the report as JSON
+func (m *Mirror) ServeReport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(m.Report()); err != nil {
+		log.Printf("Canary: failed to write report: %v", err)
+	}
+}
+
+// WriteReport saves the report as JSON at path
+func (m *Mirror) WriteReport(path string) error {
+	data, err := json.MarshalIndent(m.Report(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// captureWriter passes a response through while keeping a copy of it
+type captureWriter struct {
+	http.ResponseWriter
+	status    int
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (c *captureWriter) WriteHeader(status int) {
+	c.status = status
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *captureWriter) Write(data []byte) (int, error) {
+	if !c.truncated {
+		if c.body.Len()+len(data) > maxCapturedBody {
+			c.truncated = true
+			c.body.Reset()
+		} else {
+			c.body.Write(data)
+		}
+	}
+	return c.ResponseWriter.Write(data)
+}
+
+// Flush keeps streamed responses streaming
+func (c *captureWriter) Flush() {
+	if flusher, ok := c.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,208 @@
+package canary
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	t.Logf("Importance: The diff is what an operator reads before promoting; it must point at the exact field that changed.")
+
+	primary := `{"jsonrpc":"2.0","id":1,"result":{"serverInfo":{"name":"rtm","version":"1.1.0"},"tools":[{"name":"a"},{"name":"b"}],"count":2}}`
+	canary := `{"jsonrpc":"2.0","id":1,"result":{"serverInfo":{"name":"rtm","version":"1.2.0"},"tools":[{"name":"a"},{"name":"c"},{"name":"d"}],"count":"2","extra":true}}`
+	got := Diff([]byte(primary), []byte(canary), DefaultIgnore)
+	want := []string{
+		"result.count: number != string",
+		"result.extra: only in canary",
+		"result.tools: length 2 != 3",
+		`result.tools[1].name: "b" != "c"`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected diff:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	t.Run("event streams compare their data", func(t *testing.T) {
+		t.Logf("  > Why it's important: Streamable HTTP answers POSTs as event streams; those must be compared as JSON, not bytes.")
+		a := "event: message\ndata: {\"id\":1,\"result\":{\"ok\":true}}\n\n"
+		b := "event: message\r\ndata: {\"id\":1,\"result\":{\"ok\":false}}\r\n\r\n"
+		if got := Diff([]byte(a), []byte(b), nil); len(got) != 1 || got[0] != "[0].result.ok: true != false" {
+			t.Errorf("Unexpected diff %v", got)
+		}
+		if got := Diff([]byte(a), []byte(a), nil); len(got) != 0 {
+			t.Errorf("Expected identical streams to match, got %v", got)
+		}
+	})
+
+	t.Run("ignored paths skip every array element", func(t *testing.T) {
+		a := `{"result":{"tools":[{"name":"a","description":"old"}]}}`
+		b := `{"result":{"tools":[{"name":"a","description":"new"}]}}`
+		if got := Diff([]byte(a), []byte(b), []string{"result.tools[].description"}); len(got) != 0 {
+			t.Errorf("Expected ignored field skipped, got %v", got)
+		}
+	})
+}
+
+// fakeMCP answers the JSON-RPC methods the mirror test sends. Each server
+// issues its own session IDs, as separate deployments do.
+type fakeMCP struct {
+	*httptest.Server
+	mu       sync.Mutex
+	calls    []string
+	sessions map[string]bool
+	prefix   string
+	result   string
+}
+
+func newFakeMCP(prefix, result string) *fakeMCP {
+	f := &fakeMCP{sessions: make(map[string]bool), prefix: prefix, result: result}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		var req struct {
+			ID     int    `json:"id"`
+			Method string `json:"method"`
+			Params struct {
+				Name string `json:"name"`
+			} `json:"params"`
+		}
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &req)
+		f.calls = append(f.calls, strings.TrimSpace(req.Method+" "+req.Params.Name))
+
+		if req.Method == "initialize" {
+			session := fmt.Sprintf("%s-%d", f.prefix, len(f.sessions)+1)
+			f.sessions[session] = true
+			w.Header().Set(sessionHeader, session)
+			_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":{"serverInfo":{"name":"rtm","version":"%s"}}}`, req.ID, f.prefix)
+			return
+		}
+		if !f.sessions[r.Header.Get(sessionHeader)] {
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+		switch req.Method {
+		case "tools/list":
+			_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":{"tools":[{"name":"search","annotations":{"readOnlyHint":true}},{"name":"delete","annotations":{"readOnlyHint":false}}]}}`, req.ID)
+		case "tools/call":
+			_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":{"content":[{"type":"text","text":%q}]}}`, req.ID, f.result)
+		default:
+			_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":{}}`, req.ID)
+		}
+	}))
+	return f
+}
+
+func (f *fakeMCP) received() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+// forwardTo returns a handler relaying requests to url, standing in for the
+// proxy's reverse proxy
+func forwardTo(t *testing.T, url string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, _ := http.NewRequest(r.Method, url+r.URL.RequestURI(), r.Body)
+		req.Header = r.Header
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Errorf("Primary request failed: %v", err)
+			return
+		}
+		defer resp.Body.Close()
+		for key, values := range resp.Header {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(resp.StatusCode)
+		_, _ = io.Copy(w, resp.Body)
+	})
+}
+
+func TestMirror(t *testing.T) {
+	t.Logf("Importance: A canary is judged on copies of real traffic; mirroring must never change what clients get or repeat their writes.")
+
+	primary := newFakeMCP("primary", "3 tasks")
+	defer primary.Close()
+	canaryServer := newFakeMCP("canary", "3 tasks")
+	defer canaryServer.Close()
+
+	mirror, err := New(Config{Target: canaryServer.URL})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	proxy := mirror.Middleware(forwardTo(t, primary.URL))
+
+	call := func(session, body string) (*httptest.ResponseRecorder, string) {
+		req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+		if session != "" {
+			req.Header.Set(sessionHeader, session)
+		}
+		w := httptest.NewRecorder()
+		proxy.ServeHTTP(w, req)
+		return w, w.Header().Get(sessionHeader)
+	}
+
+	w, session := call("", `{"jsonrpc":"2.0","id":1,"method":"initialize"}`)
+	if session != "primary-1" || !strings.Contains(w.Body.String(), `"version":"primary"`) {
+		t.Fatalf("Expected the client to get the primary's response, got %s %s", session, w.Body.String())
+	}
+	call(session, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+	call(session, `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"search"}}`)
+	call(session, `{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"delete"}}`)
+	call("stale-session", `{"jsonrpc":"2.0","id":5,"method":"ping"}`)
+	call("", `grant_type=authorization_code`)
+	mirror.Close()
+
+	t.Run("sessions are mapped and writes are not repeated", func(t *testing.T) {
+		t.Logf("  > Why it's important: The canary issues its own session IDs, and a mirrored delete would delete twice.")
+		got := strings.Join(canaryServer.received(), ",")
+		if got != "initialize,tools/list,tools/call search" {
+			t.Errorf("Unexpected canary requests %s", got)
+		}
+	})
+
+	report := mirror.Report()
+	t.Run("report", func(t *testing.T) {
+		if report.Requests != 6 || report.Mirrored != 3 || report.Matched != 3 || report.Verdict != VerdictPass {
+			t.Errorf("Unexpected report %+v", report)
+		}
+		if report.Skipped[SkipWriteTool] != 1 || report.Skipped[SkipUnknownSession] != 1 || report.Skipped[SkipNotJSONRPC] != 1 {
+			t.Errorf("Unexpected skip counts %v", report.Skipped)
+		}
+	})
+
+	t.Run("differences fail the verdict", func(t *testing.T) {
+		t.Logf("  > Why it's important: A canary that answers differently must not be promoted.")
+		changed := newFakeMCP("canary", "2 tasks")
+		defer changed.Close()
+		mirror, _ := New(Config{Target: changed.URL, Timeout: time.Second})
+		proxy := mirror.Middleware(forwardTo(t, primary.URL))
+		send := func(session, body string) string {
+			req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+			req.Header.Set(sessionHeader, session)
+			w := httptest.NewRecorder()
+			proxy.ServeHTTP(w, req)
+			return w.Header().Get(sessionHeader)
+		}
+		session := send("", `{"jsonrpc":"2.0","id":1,"method":"initialize"}`)
+		send(session, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+		send(session, `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"search"}}`)
+		mirror.Close()
+
+		report := mirror.Report()
+		if report.Verdict != VerdictFail || report.BodyMismatches != 1 || len(report.Mismatches) != 1 {
+			t.Fatalf("Expected one body mismatch failing the verdict, got %+v", report)
+		}
+		mismatch := report.Mismatches[0]
+		if mismatch.Tool != "search" || len(mismatch.Diffs) != 1 || mismatch.Diffs[0] != `result.content[0].text: "3 tasks" != "2 tasks"` {
+			t.Errorf("Unexpected mismatch %+v", mismatch)
+		}
+	})
+}
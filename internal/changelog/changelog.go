@@ -0,0 +1,142 @@
+// Package changelog compares the running server's tool surface and feature
+// flags with the previous release, so clients can adapt after a deploy.
+package changelog
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/vcto/mcp-adapters/internal/health"
+	"github.com/vcto/mcp-adapters/internal/lazy"
+	"github.com/vcto/mcp-adapters/internal/manifest"
+	"github.com/vcto/mcp-adapters/internal/residency"
+)
+
+// surfaces holds the previous release's surface for each server, named
+// <server name>.json. Replace a server's file with its current surface when
+// cutting a release.
+//
+//go:embed surfaces/*.json
+var surfaces embed.FS
+
+// Surface is what a server version offers clients: its manifest tools and
+// the state of its feature flags
+type Surface struct {
+	Version string          `json:"version"`
+	Tools   []string        `json:"tools"`
+	Flags   map[string]bool `json:"flags"`
+}
+
+// Changes lists how the running surface differs from the previous release
+type Changes struct {
+	Server          string       `json:"server"`
+	Version         string       `json:"version"`
+	PreviousVersion string       `json:"previous_version"`
+	AddedTools      []string     `json:"added_tools"`
+	RemovedTools    []string     `json:"removed_tools"`
+	Flags           []FlagChange `json:"flags"`
+}
+
+// FlagChange is a feature flag that was added, removed or toggled. Previous
+// or Current is nil when the flag did not exist in that version.
+type FlagChange struct {
+	Name     string `json:"name"`
+	Previous *bool  `json:"previous"`
+	Current  *bool  `json:"current"`
+}
+
+// Flags reports the feature flags that change what clients see
+func Flags() map[string]bool {
+	return map[string]bool{
+		"MCP_TOOL_GATEWAY":       manifest.GatewayEnabled(),
+		"MCP_LAZY_INIT":          lazy.Enabled(),
+		"MCP_OUTAGE_SIMULATION":  health.OutageSimulationEnabled(),
+		"DATA_RESIDENCY_ROUTING": residency.RoutingEnabled(),
+	}
+}
+
+// Current builds the running surface from the server's manifests
+func Current(version string, manifests ...*manifest.Manifest) Surface {
+	seen := make(map[string]bool)
+	var tools []string
+	for _, m := range manifests {
+		for name := range m.Tools {
+			if !seen[name] {
+				seen[name] = true
+				tools = append(tools, name)
+			}
+		}
+	}
+	sort.Strings(tools)
+	return Surface{Version: version, Tools: tools, Flags: Flags()}
+}
+
+// Previous loads the embedded surface of the server's previous release
+func Previous(server string) (Surface, error) {
+	var surface Surface
+	data, err := surfaces.ReadFile("surfaces/" + server + ".json")
+	if err != nil {
+		return surface, fmt.Errorf("no previous release recorded for %s", server)
+	}
+	if err := json.Unmarshal(data, &surface); err != nil {
+		return surface, fmt.Errorf("parsing previous surface for %s: %w", server, err)
+	}
+	return surface, nil
+}
+
+// Diff compares two surfaces
+func Diff(server string, previous, current Surface) Changes {
+	changes := Changes{
+		Server:          server,
+		Version:         current.Version,
+		PreviousVersion: previous.Version,
+		AddedTools:      missing(current.Tools, previous.Tools),
+		RemovedTools:    missing(previous.Tools, current.Tools),
+		Flags:           []FlagChange{},
+	}
+
+	names := make(map[string]bool)
+	for name := range previous.Flags {
+		names[name] = true
+	}
+	for name := range current.Flags {
+		names[name] = true
+	}
+	for name := range names {
+		before, hadBefore := previous.Flags[name]
+		after, hasAfter := current.Flags[name]
+		if hadBefore && hasAfter && before == after {
+			continue
+		}
+		change := FlagChange{Name: name}
+		if hadBefore {
+			change.Previous = &before
+		}
+		if hasAfter {
+			change.Current = &after
+		}
+		changes.Flags = append(changes.Flags, change)
+	}
+	sort.Slice(changes.Flags, func(i, j int) bool {
+		return changes.Flags[i].Name < changes.Flags[j].Name
+	})
+	return changes
+}
+
+// missing returns the names in a that are not in b
+func missing(a, b []string) []string {
+	in := make(map[string]bool, len(b))
+	for _, name := range b {
+		in[name] = true
+	}
+	out := []string{}
+	for _, name := range a {
+		if !in[name] {
+			out = append(out, name)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
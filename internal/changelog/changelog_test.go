@@ -0,0 +1,91 @@
+package changelog
+
+import (
+	"testing"
+
+	"github.com/vcto/mcp-adapters/internal/manifest"
+)
+
+func TestDiff(t *testing.T) {
+	t.Logf("Importance: Clients use the changelog to notice tools that appeared or vanished after a deploy.")
+
+	previous := Surface{
+		Version: "1.0.0",
+		Tools:   []string{"a", "b", "c"},
+		Flags:   map[string]bool{"OLD": true, "SAME": false, "TOGGLED": false},
+	}
+	current := Surface{
+		Version: "1.1.0",
+		Tools:   []string{"b", "c", "d"},
+		Flags:   map[string]bool{"NEW": true, "SAME": false, "TOGGLED": true},
+	}
+
+	changes := Diff("test-server", previous, current)
+	if changes.Version != "1.1.0" || changes.PreviousVersion != "1.0.0" {
+		t.Errorf("Unexpected versions: %+v", changes)
+	}
+	if len(changes.AddedTools) != 1 || changes.AddedTools[0] != "d" {
+		t.Errorf("Expected d added, got %v", changes.AddedTools)
+	}
+	if len(changes.RemovedTools) != 1 || changes.RemovedTools[0] != "a" {
+		t.Errorf("Expected a removed, got %v", changes.RemovedTools)
+	}
+
+	if len(changes.Flags) != 3 {
+		t.Fatalf("Expected 3 flag changes, got %+v", changes.Flags)
+	}
+	byName := make(map[string]FlagChange)
+	for _, change := range changes.Flags {
+		byName[change.Name] = change
+	}
+	if c := byName["NEW"]; c.Previous != nil || c.Current == nil || !*c.Current {
+		t.Errorf("Expected NEW to be added as enabled, got %+v", c)
+	}
+	if c := byName["OLD"]; c.Previous == nil || c.Current != nil {
+		t.Errorf("Expected OLD to be removed, got %+v", c)
+	}
+	if c := byName["TOGGLED"]; c.Previous == nil || *c.Previous || c.Current == nil || !*c.Current {
+		t.Errorf("Expected TOGGLED to go from false to true, got %+v", c)
+	}
+}
+
+func TestPrevious(t *testing.T) {
+	t.Logf("Importance: Every shipped server needs a recorded previous release, or its changelog reports everything as new.")
+
+	for _, name := range []string{"rtm-server", "spektrix-server", "cowpilot-everything"} {
+		surface, err := Previous(name)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if surface.Version == "" || len(surface.Tools) == 0 {
+			t.Errorf("%s: incomplete surface %+v", name, surface)
+		}
+	}
+
+	if _, err := Previous("unknown-server"); err == nil {
+		t.Error("Expected error for a server without a recorded release")
+	}
+}
+
+func TestCurrent(t *testing.T) {
+	t.Logf("Importance: The running surface must cover every manifest tool once, in a stable order.")
+
+	t.Setenv("MCP_TOOL_GATEWAY", "true")
+	a := &manifest.Manifest{Adapter: "a", Tools: map[string]manifest.ToolAccess{"x": {}, "shared": {}}}
+	b := &manifest.Manifest{Adapter: "b", Tools: map[string]manifest.ToolAccess{"shared": {}, "y": {}}}
+
+	surface := Current("2.0.0", a, b)
+	want := []string{"shared", "x", "y"}
+	if len(surface.Tools) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, surface.Tools)
+	}
+	for i := range want {
+		if surface.Tools[i] != want[i] {
+			t.Errorf("Expected %v, got %v", want, surface.Tools)
+		}
+	}
+	if !surface.Flags["MCP_TOOL_GATEWAY"] {
+		t.Error("Expected gateway flag to be reported as enabled")
+	}
+}
@@ -0,0 +1,49 @@
+package changelog
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/vcto/mcp-adapters/internal/manifest"
+)
+
+// URI is the resource listing changes since the previous release
+const URI = "server://changelog"
+
+// SetupResource registers server://changelog for the named server
+func SetupResource(s *server.MCPServer, name, version string, manifests ...*manifest.Manifest) {
+	s.AddResource(mcp.NewResource(URI,
+		"Server Changelog",
+		mcp.WithResourceDescription("Tools added or removed and feature flags changed since the previous release. Re-read after a deploy to detect capability changes."),
+		mcp.WithMIMEType("application/json"),
+	), func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		current := Current(version, manifests...)
+
+		result := map[string]interface{}{
+			"generated_at": time.Now().UTC(),
+		}
+		previous, err := Previous(name)
+		if err != nil {
+			// Without a recorded release everything counts as new
+			result["note"] = err.Error()
+			previous = Surface{}
+		}
+		result["changes"] = Diff(name, previous, current)
+
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      URI,
+				MIMEType: "application/json",
+				Text:     string(data),
+			},
+		}, nil
+	})
+}
@@ -0,0 +1,27 @@
+// Package config reads settings shared by the adapters from the environment.
+package config
+
+import (
+	"log"
+	"os"
+	"time"
+)
+
+// DurationFromEnv reads a duration such as "30s" or "6h" from the
+// environment variable key, returning def when it is unset or invalid.
+// "0" is returned as 0, which callers take to mean off or unbounded.
+func DurationFromEnv(key string, def time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	if value == "0" {
+		return 0
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid %s %q, using default %s: %v", key, value, def, err)
+		return def
+	}
+	return d
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDurationFromEnv(t *testing.T) {
+	t.Logf("Importance: Timeouts, TTLs and poll intervals are tuned through the environment without code changes.")
+
+	t.Run("parses durations", func(t *testing.T) {
+		t.Logf("  > Why it's important: A set value must replace the default.")
+		t.Setenv("TEST_DURATION", "90m")
+		if got := DurationFromEnv("TEST_DURATION", time.Hour); got != 90*time.Minute {
+			t.Errorf("Expected 90m, got %v", got)
+		}
+	})
+
+	t.Run("falls back to the default", func(t *testing.T) {
+		t.Logf("  > Why it's important: A typo must not turn a TTL into zero.")
+		t.Setenv("TEST_DURATION", "soon")
+		if got := DurationFromEnv("TEST_DURATION", time.Hour); got != time.Hour {
+			t.Errorf("Expected default for invalid value, got %v", got)
+		}
+		t.Setenv("TEST_DURATION", "")
+		if got := DurationFromEnv("TEST_DURATION", time.Hour); got != time.Hour {
+			t.Errorf("Expected default for unset value, got %v", got)
+		}
+	})
+
+	t.Run("accepts zero", func(t *testing.T) {
+		t.Logf("  > Why it's important: \"0\" is how operators switch a cache or poller off.")
+		t.Setenv("TEST_DURATION", "0")
+		if got := DurationFromEnv("TEST_DURATION", time.Hour); got != 0 {
+			t.Errorf("Expected 0, got %v", got)
+		}
+	})
+}
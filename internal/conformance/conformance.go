@@ -0,0 +1,508 @@
+// Package conformance checks a deployed MCP server's OAuth setup against the
+// specs the MCP authorization flow relies on: protected resource metadata
+// (RFC 9728), authorization server metadata (RFC 8414), dynamic client
+// registration (RFC 7591), PKCE (RFC 7636) and resource indicators (RFC 8707).
+// All checks are black-box HTTP requests and need no credentials.
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Check outcomes
+const (
+	StatusPass = "pass"
+	StatusFail = "fail"
+	StatusSkip = "skip"
+)
+
+// conformanceRedirectURI is registered for the test client; nothing listens there
+const conformanceRedirectURI = "http://127.0.0.1:9/conformance/callback"
+
+// Result is the outcome of one check
+type Result struct {
+	ID       string        `json:"id"`
+	Spec     string        `json:"spec"`
+	Name     string        `json:"name"`
+	Status   string        `json:"status"`
+	Detail   string        `json:"detail,omitempty"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// Report collects the results of a run
+type Report struct {
+	Target    string    `json:"target"`
+	StartedAt time.Time `json:"started_at"`
+	Results   []Result  `json:"results"`
+}
+
+// Counts returns how many checks passed, failed and were skipped
+func (r *Report) Counts() (passed, failed, skipped int) {
+	for _, result := range r.Results {
+		switch result.Status {
+		case StatusPass:
+			passed++
+		case StatusFail:
+			failed++
+		default:
+			skipped++
+		}
+	}
+	return passed, failed, skipped
+}
+
+// Failed reports whether any check failed
+func (r *Report) Failed() bool {
+	_, failed, _ := r.Counts()
+	return failed > 0
+}
+
+// Suite runs the checks against one server
+type Suite struct {
+	// BaseURL is the server's origin, e.g. https://example.fly.dev
+	BaseURL string
+	// MCPPath is the MCP endpoint path (default /mcp)
+	MCPPath string
+	// Client makes the requests; it must not follow redirects
+	Client *http.Client
+
+	// Discovered along the way and shared between checks
+	resourceMetadata map[string]interface{}
+	authServer       string
+	authMetadata     map[string]interface{}
+	clientID         string
+}
+
+// NewSuite creates a suite for baseURL with a 15s per-request timeout
+func NewSuite(baseURL string) *Suite {
+	return &Suite{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		MCPPath: "/mcp",
+		Client: &http.Client{
+			Timeout: 15 * time.Second,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// check is one conformance check. It returns a detail for the report and an
+// error when the check fails, or a skip error when a prerequisite is missing.
+type check struct {
+	id   string
+	spec string
+	name string
+	run  func(ctx context.Context, s *Suite) (detail string, err error)
+}
+
+// skipError marks a check that could not run
+type skipError struct{ reason string }
+
+func (e *skipError) Error() string { return e.reason }
+
+func skip(format string, args ...interface{}) error {
+	return &skipError{reason: fmt.Sprintf(format, args...)}
+}
+
+// Run executes every check in order and returns the report
+func (s *Suite) Run(ctx context.Context) *Report {
+	report := &Report{Target: s.mcpURL(), StartedAt: time.Now().UTC()}
+	for _, c := range checks {
+		start := time.Now()
+		detail, err := c.run(ctx, s)
+
+		result := Result{ID: c.id, Spec: c.spec, Name: c.name, Status: StatusPass, Detail: detail}
+		if skipped, ok := err.(*skipError); ok {
+			result.Status = StatusSkip
+			result.Detail = skipped.reason
+		} else if err != nil {
+			result.Status = StatusFail
+			result.Detail = err.Error()
+		}
+		result.Duration = time.Since(start)
+		report.Results = append(report.Results, result)
+	}
+	return report
+}
+
+func (s *Suite) mcpURL() string {
+	return s.BaseURL + s.MCPPath
+}
+
+// checks run in order; later checks use what earlier ones discovered
+var checks = []check{
+	{"prm-document", "RFC 9728 §3", "Protected resource metadata is published", checkResourceMetadata},
+	{"prm-resource", "RFC 9728 §3.3", "Metadata resource identifies this server", checkResourceIdentifier},
+	{"unauthenticated", "RFC 6750 §3", "Requests without a token get 401 with a Bearer challenge", checkUnauthenticated},
+	{"challenge-metadata", "RFC 9728 §5.1", "The 401 challenge points to the resource metadata", checkChallengeMetadata},
+	{"invalid-token", "RFC 6750 §3.1", "Invalid tokens get 401 with error=\"invalid_token\"", checkInvalidToken},
+	{"as-metadata", "RFC 8414 §2", "Authorization server metadata is complete", checkAuthServerMetadata},
+	{"as-issuer", "RFC 8414 §3.3", "Issuer matches the authorization server URL", checkIssuer},
+	{"pkce-s256", "RFC 7636 §4.2", "PKCE S256 is advertised", checkPKCEAdvertised},
+	{"dcr-register", "RFC 7591 §3.2.1", "Dynamic client registration returns 201 with a client_id", checkRegistration},
+	{"dcr-invalid", "RFC 7591 §3.2.2", "Malformed registrations get a 400 JSON error", checkRegistrationError},
+	{"resource-indicator", "RFC 8707 §2", "Authorization requests accept a resource parameter", checkResourceIndicator},
+	{"pkce-required", "OAuth 2.1 §4.1.1", "Authorization requests without PKCE are rejected", checkPKCERequired},
+	{"token-invalid-grant", "RFC 6749 §5.2", "Bad authorization codes get a 400 invalid_grant error", checkInvalidGrant},
+}
+
+func checkResourceMetadata(ctx context.Context, s *Suite) (string, error) {
+	var metadata map[string]interface{}
+	if err := s.getJSON(ctx, s.BaseURL+"/.well-known/oauth-protected-resource", &metadata); err != nil {
+		return "", err
+	}
+	if _, ok := metadata["resource"].(string); !ok {
+		return "", fmt.Errorf("metadata has no resource")
+	}
+	servers := stringList(metadata["authorization_servers"])
+	if len(servers) == 0 {
+		return "", fmt.Errorf("metadata lists no authorization_servers")
+	}
+	s.resourceMetadata = metadata
+	s.authServer = strings.TrimRight(servers[0], "/")
+	return "authorization server " + s.authServer, nil
+}
+
+func checkResourceIdentifier(ctx context.Context, s *Suite) (string, error) {
+	if s.resourceMetadata == nil {
+		return "", skip("no resource metadata")
+	}
+	resource, _ := s.resourceMetadata["resource"].(string)
+	if resource != s.mcpURL() && strings.TrimRight(resource, "/") != s.BaseURL {
+		return "", fmt.Errorf("resource %q is neither %s nor %s", resource, s.mcpURL(), s.BaseURL)
+	}
+	return resource, nil
+}
+
+func checkUnauthenticated(ctx context.Context, s *Suite) (string, error) {
+	resp, err := s.postMCP(ctx, "")
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		return "", fmt.Errorf("got HTTP %d, want 401", resp.StatusCode)
+	}
+	if challenge := resp.Header.Get("WWW-Authenticate"); !strings.HasPrefix(challenge, "Bearer") {
+		return "", fmt.Errorf("WWW-Authenticate %q is not a Bearer challenge", challenge)
+	}
+	return "", nil
+}
+
+func checkChallengeMetadata(ctx context.Context, s *Suite) (string, error) {
+	resp, err := s.postMCP(ctx, "")
+	if err != nil {
+		return "", err
+	}
+	challenge := resp.Header.Get("WWW-Authenticate")
+	if !strings.Contains(challenge, "resource_metadata=") {
+		return "", fmt.Errorf("WWW-Authenticate %q has no resource_metadata parameter", challenge)
+	}
+	return challenge, nil
+}
+
+func checkInvalidToken(ctx context.Context, s *Suite) (string, error) {
+	resp, err := s.postMCP(ctx, "conformance-invalid-token")
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		return "", fmt.Errorf("got HTTP %d, want 401", resp.StatusCode)
+	}
+	if challenge := resp.Header.Get("WWW-Authenticate"); !strings.Contains(challenge, `error="invalid_token"`) {
+		return "", fmt.Errorf("WWW-Authenticate %q lacks error=\"invalid_token\"", challenge)
+	}
+	return "", nil
+}
+
+func checkAuthServerMetadata(ctx context.Context, s *Suite) (string, error) {
+	if s.authServer == "" {
+		return "", skip("no authorization server discovered")
+	}
+	var metadata map[string]interface{}
+	if err := s.getJSON(ctx, s.authServer+"/.well-known/oauth-authorization-server", &metadata); err != nil {
+		return "", err
+	}
+	var missing []string
+	for _, field := range []string{"issuer", "authorization_endpoint", "token_endpoint"} {
+		if value, _ := metadata[field].(string); value == "" {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("missing %s", strings.Join(missing, ", "))
+	}
+	if !contains(stringList(metadata["response_types_supported"]), "code") {
+		return "", fmt.Errorf("response_types_supported does not include code")
+	}
+	s.authMetadata = metadata
+	return "", nil
+}
+
+func checkIssuer(ctx context.Context, s *Suite) (string, error) {
+	if s.authMetadata == nil {
+		return "", skip("no authorization server metadata")
+	}
+	if issuer, _ := s.authMetadata["issuer"].(string); strings.TrimRight(issuer, "/") != s.authServer {
+		return "", fmt.Errorf("issuer %q does not match %s", issuer, s.authServer)
+	}
+	return "", nil
+}
+
+func checkPKCEAdvertised(ctx context.Context, s *Suite) (string, error) {
+	if s.authMetadata == nil {
+		return "", skip("no authorization server metadata")
+	}
+	methods := stringList(s.authMetadata["code_challenge_methods_supported"])
+	if !contains(methods, "S256") {
+		return "", fmt.Errorf("code_challenge_methods_supported is %v, want S256", methods)
+	}
+	return "", nil
+}
+
+func checkRegistration(ctx context.Context, s *Suite) (string, error) {
+	endpoint := s.endpoint("registration_endpoint")
+	if endpoint == "" {
+		return "", skip("no registration_endpoint advertised")
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"client_name":                "oauth-conformance",
+		"redirect_uris":              []string{conformanceRedirectURI},
+		"grant_types":                []string{"authorization_code"},
+		"response_types":             []string{"code"},
+		"token_endpoint_auth_method": "none",
+	})
+	resp, data, err := s.do(ctx, http.MethodPost, endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+
+	var registered map[string]interface{}
+	if err := json.Unmarshal(data, &registered); err != nil {
+		return "", fmt.Errorf("response is not JSON: %v", err)
+	}
+	clientID, _ := registered["client_id"].(string)
+	if clientID == "" {
+		return "", fmt.Errorf("response has no client_id")
+	}
+	s.clientID = clientID
+
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("got HTTP %d, want 201 Created", resp.StatusCode)
+	}
+	if !contains(stringList(registered["redirect_uris"]), conformanceRedirectURI) {
+		return "", fmt.Errorf("registered redirect_uris do not include %s", conformanceRedirectURI)
+	}
+	return "client_id " + clientID, nil
+}
+
+func checkRegistrationError(ctx context.Context, s *Suite) (string, error) {
+	endpoint := s.endpoint("registration_endpoint")
+	if endpoint == "" {
+		return "", skip("no registration_endpoint advertised")
+	}
+	resp, data, err := s.do(ctx, http.MethodPost, endpoint, "application/json", strings.NewReader("{not json"))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusBadRequest {
+		return "", fmt.Errorf("got HTTP %d, want 400", resp.StatusCode)
+	}
+	return "", requireOAuthError(data, "")
+}
+
+func checkResourceIndicator(ctx context.Context, s *Suite) (string, error) {
+	resp, err := s.authorize(ctx, true)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return "", fmt.Errorf("got HTTP %d for a valid request with resource=%s", resp.StatusCode, s.mcpURL())
+	}
+	if errorCode := redirectError(resp); errorCode != "" {
+		return "", fmt.Errorf("redirected with error=%s", errorCode)
+	}
+	return fmt.Sprintf("HTTP %d", resp.StatusCode), nil
+}
+
+func checkPKCERequired(ctx context.Context, s *Suite) (string, error) {
+	resp, err := s.authorize(ctx, false)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode == http.StatusBadRequest || redirectError(resp) == "invalid_request" {
+		return "", nil
+	}
+	return "", fmt.Errorf("request without code_challenge got HTTP %d instead of an invalid_request error", resp.StatusCode)
+}
+
+func checkInvalidGrant(ctx context.Context, s *Suite) (string, error) {
+	endpoint := s.endpoint("token_endpoint")
+	if endpoint == "" {
+		return "", skip("no token_endpoint advertised")
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {"conformance-invalid-code"},
+		"redirect_uri":  {conformanceRedirectURI},
+		"client_id":     {s.testClientID()},
+		"code_verifier": {strings.Repeat("v", 43)},
+		"resource":      {s.mcpURL()},
+	}
+	resp, data, err := s.do(ctx, http.MethodPost, endpoint, "application/x-www-form-urlencoded", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusBadRequest {
+		return "", fmt.Errorf("got HTTP %d, want 400", resp.StatusCode)
+	}
+	return "", requireOAuthError(data, "invalid_grant")
+}
+
+// authorize sends an authorization request, with or without PKCE
+func (s *Suite) authorize(ctx context.Context, withPKCE bool) (*http.Response, error) {
+	endpoint := s.endpoint("authorization_endpoint")
+	if endpoint == "" {
+		return nil, skip("no authorization_endpoint advertised")
+	}
+	query := url.Values{
+		"response_type": {"code"},
+		"client_id":     {s.testClientID()},
+		"redirect_uri":  {conformanceRedirectURI},
+		"state":         {"conformance"},
+		"resource":      {s.mcpURL()},
+	}
+	if withPKCE {
+		// S256 challenge for the RFC 7636 Appendix B example verifier
+		query.Set("code_challenge", "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM")
+		query.Set("code_challenge_method", "S256")
+	}
+	resp, _, err := s.do(ctx, http.MethodGet, endpoint+"?"+query.Encode(), "", nil)
+	return resp, err
+}
+
+func (s *Suite) testClientID() string {
+	if s.clientID != "" {
+		return s.clientID
+	}
+	return "oauth-conformance"
+}
+
+// endpoint returns an endpoint URL from the authorization server metadata
+func (s *Suite) endpoint(field string) string {
+	value, _ := s.authMetadata[field].(string)
+	return value
+}
+
+func (s *Suite) postMCP(ctx context.Context, token string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.mcpURL(),
+		strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}`))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	_ = resp.Body.Close()
+	return resp, nil
+}
+
+func (s *Suite) getJSON(ctx context.Context, rawURL string, v interface{}) error {
+	resp, data, err := s.do(ctx, http.MethodGet, rawURL, "", nil)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned HTTP %d", rawURL, resp.StatusCode)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("GET %s did not return JSON: %v", rawURL, err)
+	}
+	return nil
+}
+
+func (s *Suite) do(ctx context.Context, method, rawURL, contentType string, body io.Reader) (*http.Response, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	return resp, data, err
+}
+
+// requireOAuthError checks for an RFC 6749 §5.2 JSON error body, optionally
+// with a specific error code
+func requireOAuthError(data []byte, code string) error {
+	var body struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil || body.Error == "" {
+		return fmt.Errorf("error response is not a JSON OAuth error: %q", truncate(string(data), 80))
+	}
+	if code != "" && body.Error != code {
+		return fmt.Errorf("got error=%s, want %s", body.Error, code)
+	}
+	return nil
+}
+
+// redirectError returns the error parameter of a redirect back to the client
+func redirectError(resp *http.Response) string {
+	location, err := resp.Location()
+	if err != nil {
+		return ""
+	}
+	return location.Query().Get("error")
+}
+
+func stringList(value interface{}) []string {
+	items, _ := value.([]interface{})
+	list := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+func contains(list []string, want string) bool {
+	for _, item := range list {
+		if item == want {
+			return true
+		}
+	}
+	return false
+}
+
+func truncate(s string, n int) string {
+	s = strings.TrimSpace(s)
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
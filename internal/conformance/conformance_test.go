@@ -0,0 +1,294 @@
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// newCompliantServer fakes an MCP server that follows every checked spec
+func newCompliantServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	var server *httptest.Server
+
+	mux.HandleFunc("/.well-known/oauth-protected-resource", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"resource":              server.URL + "/mcp",
+			"authorization_servers": []string{server.URL},
+		})
+	})
+	mux.HandleFunc("/.well-known/oauth-authorization-server", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"issuer":                           server.URL,
+			"authorization_endpoint":           server.URL + "/oauth/authorize",
+			"token_endpoint":                   server.URL + "/oauth/token",
+			"registration_endpoint":            server.URL + "/oauth/register",
+			"response_types_supported":         []string{"code"},
+			"code_challenge_methods_supported": []string{"S256"},
+		})
+	})
+	mux.HandleFunc("/mcp", func(w http.ResponseWriter, r *http.Request) {
+		challenge := fmt.Sprintf(`Bearer resource_metadata="%s/.well-known/oauth-protected-resource"`, server.URL)
+		if r.Header.Get("Authorization") != "" {
+			challenge += `, error="invalid_token"`
+		}
+		w.Header().Set("WWW-Authenticate", challenge)
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	mux.HandleFunc("/oauth/register", func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_client_metadata"})
+			return
+		}
+		writeJSON(w, http.StatusCreated, map[string]interface{}{
+			"client_id":     "client-1",
+			"redirect_uris": req["redirect_uris"],
+		})
+	})
+	mux.HandleFunc("/oauth/authorize", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("code_challenge") == "" {
+			http.Redirect(w, r, r.URL.Query().Get("redirect_uri")+"?error=invalid_request", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+	})
+
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newLegacyServer fakes the older pattern: realm-only challenges, 200 from
+// registration, plain-text errors and no PKCE enforcement
+func newLegacyServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	var server *httptest.Server
+
+	mux.HandleFunc("/.well-known/oauth-protected-resource", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"resource":              server.URL + "/mcp",
+			"authorization_servers": []string{server.URL},
+		})
+	})
+	mux.HandleFunc("/.well-known/oauth-authorization-server", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"issuer":                           server.URL,
+			"authorization_endpoint":           server.URL + "/oauth/authorize",
+			"token_endpoint":                   server.URL + "/oauth/token",
+			"registration_endpoint":            server.URL + "/oauth/register",
+			"response_types_supported":         []string{"code"},
+			"code_challenge_methods_supported": []string{"S256"},
+		})
+	})
+	mux.HandleFunc("/mcp", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/.well-known/oauth-protected-resource"`, server.URL))
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	mux.HandleFunc("/oauth/register", func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"client_id":     "client-1",
+			"redirect_uris": req["redirect_uris"],
+		})
+	})
+	mux.HandleFunc("/oauth/authorize", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Invalid code", http.StatusBadRequest)
+	})
+
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func statuses(report *Report) map[string]string {
+	byID := make(map[string]string, len(report.Results))
+	for _, result := range report.Results {
+		byID[result.ID] = result.Status
+	}
+	return byID
+}
+
+func TestSuite(t *testing.T) {
+	t.Logf("Importance: The conformance suite is how we verify a deployment will work with spec-following MCP clients; a check that passes broken servers or fails good ones gives false confidence.")
+
+	t.Run("compliant server passes every check", func(t *testing.T) {
+		t.Logf("  > Why it's important: A false failure would make the CLI useless as a CI gate.")
+		server := newCompliantServer(t)
+
+		report := NewSuite(server.URL).Run(context.Background())
+
+		if len(report.Results) != len(checks) {
+			t.Fatalf("Expected %d results, got %d", len(checks), len(report.Results))
+		}
+		for _, result := range report.Results {
+			if result.Status != StatusPass {
+				t.Errorf("%s: expected pass, got %s (%s)", result.ID, result.Status, result.Detail)
+			}
+		}
+		if report.Target != server.URL+"/mcp" {
+			t.Errorf("Expected target %s/mcp, got %s", server.URL, report.Target)
+		}
+	})
+
+	t.Run("legacy server fails the checks it breaks", func(t *testing.T) {
+		t.Logf("  > Why it's important: These are the gaps clients hit in practice; the suite must name each one.")
+		server := newLegacyServer(t)
+
+		got := statuses(NewSuite(server.URL).Run(context.Background()))
+
+		want := map[string]string{
+			"prm-document":        StatusPass,
+			"unauthenticated":     StatusPass,
+			"challenge-metadata":  StatusFail,
+			"invalid-token":       StatusFail,
+			"as-metadata":         StatusPass,
+			"pkce-s256":           StatusPass,
+			"dcr-register":        StatusFail,
+			"dcr-invalid":         StatusFail,
+			"resource-indicator":  StatusPass,
+			"pkce-required":       StatusFail,
+			"token-invalid-grant": StatusFail,
+		}
+		for id, status := range want {
+			if got[id] != status {
+				t.Errorf("%s: expected %s, got %s", id, status, got[id])
+			}
+		}
+	})
+
+	t.Run("missing metadata skips dependent checks", func(t *testing.T) {
+		t.Logf("  > Why it's important: One missing document should read as one failure, not a cascade of misleading ones.")
+		server := httptest.NewServer(http.NotFoundHandler())
+		defer server.Close()
+
+		got := statuses(NewSuite(server.URL).Run(context.Background()))
+
+		if got["prm-document"] != StatusFail {
+			t.Errorf("Expected prm-document to fail, got %s", got["prm-document"])
+		}
+		for _, id := range []string{"prm-resource", "as-metadata", "as-issuer", "dcr-register", "token-invalid-grant"} {
+			if got[id] != StatusSkip {
+				t.Errorf("%s: expected skip, got %s", id, got[id])
+			}
+		}
+	})
+}
+
+func TestReportFormats(t *testing.T) {
+	t.Logf("Importance: CI systems parse these reports; malformed JSON or JUnit hides failures.")
+
+	report := &Report{
+		Target: "https://example.test/mcp",
+		Results: []Result{
+			{ID: "a", Spec: "RFC 1", Name: "passes", Status: StatusPass},
+			{ID: "b", Spec: "RFC 2", Name: "fails", Status: StatusFail, Detail: "got HTTP 200"},
+			{ID: "c", Spec: "RFC 3", Name: "skips", Status: StatusSkip, Detail: "no metadata"},
+		},
+	}
+	if !report.Failed() {
+		t.Error("Expected report with a failure to be Failed")
+	}
+
+	t.Run("json", func(t *testing.T) {
+		t.Logf("  > Why it's important: The summary block lets scripts gate on failures without walking results.")
+		var buf bytes.Buffer
+		if err := report.Write(&buf, FormatJSON); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		var decoded struct {
+			Summary map[string]int `json:"summary"`
+			Results []Result       `json:"results"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+			t.Fatalf("Invalid JSON: %v", err)
+		}
+		if decoded.Summary["passed"] != 1 || decoded.Summary["failed"] != 1 || decoded.Summary["skipped"] != 1 {
+			t.Errorf("Unexpected summary %v", decoded.Summary)
+		}
+		if len(decoded.Results) != 3 {
+			t.Errorf("Expected 3 results, got %d", len(decoded.Results))
+		}
+	})
+
+	t.Run("junit", func(t *testing.T) {
+		t.Logf("  > Why it's important: JUnit failures and skips must use the elements CI tools count.")
+		var buf bytes.Buffer
+		if err := report.Write(&buf, FormatJUnit); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		var suite junitSuite
+		if err := xml.Unmarshal(buf.Bytes(), &suite); err != nil {
+			t.Fatalf("Invalid XML: %v", err)
+		}
+		if suite.Tests != 3 || suite.Failures != 1 || suite.Skipped != 1 {
+			t.Errorf("Unexpected counts tests=%d failures=%d skipped=%d", suite.Tests, suite.Failures, suite.Skipped)
+		}
+		if suite.Cases[1].Failure == nil || suite.Cases[1].Failure.Message != "got HTTP 200" {
+			t.Errorf("Expected failure message on second case, got %+v", suite.Cases[1])
+		}
+		if suite.Cases[2].Skipped == nil {
+			t.Errorf("Expected skipped element on third case")
+		}
+	})
+
+	t.Run("text and unknown formats", func(t *testing.T) {
+		t.Logf("  > Why it's important: Humans read the text form, and typos in -format should be reported.")
+		var buf bytes.Buffer
+		if err := report.Write(&buf, FormatText); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		if !strings.Contains(buf.String(), "1 passed, 1 failed, 1 skipped") || !strings.Contains(buf.String(), "got HTTP 200") {
+			t.Errorf("Unexpected text report:\n%s", buf.String())
+		}
+		if err := report.Write(&buf, "yaml"); err == nil {
+			t.Error("Expected error for unknown format")
+		}
+	})
+}
+
+// TestLiveDeployment runs the suite against OAUTH_CONFORMANCE_URL, e.g.
+//
+//	OAUTH_CONFORMANCE_URL=https://rtm.fly.dev go test ./internal/conformance -run Live -v
+func TestLiveDeployment(t *testing.T) {
+	target := os.Getenv("OAUTH_CONFORMANCE_URL")
+	if target == "" {
+		t.Skip("OAUTH_CONFORMANCE_URL not set")
+	}
+	t.Logf("Importance: Confirms a real deployment follows the OAuth specs MCP clients rely on.")
+
+	report := NewSuite(target).Run(context.Background())
+	for _, result := range report.Results {
+		switch result.Status {
+		case StatusFail:
+			t.Errorf("%s (%s): %s", result.ID, result.Spec, result.Detail)
+		case StatusSkip:
+			t.Logf("%s skipped: %s", result.ID, result.Detail)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package conformance
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// Report formats accepted by Write
+const (
+	FormatText  = "text"
+	FormatJSON  = "json"
+	FormatJUnit = "junit"
+)
+
+// Write renders the report in the given format
+func (r *Report) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatText:
+		return r.WriteText(w)
+	case FormatJSON:
+		return r.WriteJSON(w)
+	case FormatJUnit:
+		return r.WriteJUnit(w)
+	default:
+		return fmt.Errorf("unknown report format %q (use text, json or junit)", format)
+	}
+}
+
+// WriteText renders one line per check followed by a summary
+func (r *Report) WriteText(w io.Writer) error {
+	fmt.Fprintf(w, "OAuth conformance for %s\n\n", r.Target)
+	for _, result := range r.Results {
+		fmt.Fprintf(w, "%-4s  %-20s %-17s %s\n", statusLabel(result.Status), result.ID, result.Spec, result.Name)
+		if result.Detail != "" && result.Status != StatusPass {
+			fmt.Fprintf(w, "      %s\n", result.Detail)
+		}
+	}
+	passed, failed, skipped := r.Counts()
+	_, err := fmt.Fprintf(w, "\n%d passed, %d failed, %d skipped\n", passed, failed, skipped)
+	return err
+}
+
+// WriteJSON renders the report with a summary block
+func (r *Report) WriteJSON(w io.Writer) error {
+	passed, failed, skipped := r.Counts()
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"target":     r.Target,
+		"started_at": r.StartedAt,
+		"summary": map[string]int{
+			"passed":  passed,
+			"failed":  failed,
+			"skipped": skipped,
+		},
+		"results": r.Results,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+type junitSuite struct {
+	XMLName   xml.Name    `xml:"testsuite"`
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Skipped   int         `xml:"skipped,attr"`
+	Time      float64     `xml:"time,attr"`
+	Timestamp string      `xml:"timestamp,attr"`
+	Cases     []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+}
+
+// WriteJUnit renders the report as a JUnit XML test suite, one test case per
+// check, for CI systems that collect JUnit results
+func (r *Report) WriteJUnit(w io.Writer) error {
+	_, failed, skipped := r.Counts()
+	suite := junitSuite{
+		Name:      "oauth-conformance " + r.Target,
+		Tests:     len(r.Results),
+		Failures:  failed,
+		Skipped:   skipped,
+		Timestamp: r.StartedAt.Format("2006-01-02T15:04:05"),
+	}
+	for _, result := range r.Results {
+		c := junitCase{
+			Name:      fmt.Sprintf("%s: %s", result.ID, result.Name),
+			Classname: result.Spec,
+			Time:      result.Duration.Seconds(),
+		}
+		switch result.Status {
+		case StatusFail:
+			c.Failure = &junitMessage{Message: result.Detail}
+		case StatusSkip:
+			c.Skipped = &junitMessage{Message: result.Detail}
+		}
+		suite.Time += c.Time
+		suite.Cases = append(suite.Cases, c)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suite); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func statusLabel(status string) string {
+	switch status {
+	case StatusPass:
+		return "PASS"
+	case StatusFail:
+		return "FAIL"
+	default:
+		return "SKIP"
+	}
+}
@@ -4,6 +4,7 @@ package core
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -16,10 +17,15 @@ import (
 	"time"
 
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/vcto/mcp-adapters/internal/admin"
+	"github.com/vcto/mcp-adapters/internal/audit"
 	"github.com/vcto/mcp-adapters/internal/auth"
+	"github.com/vcto/mcp-adapters/internal/deadline"
 	"github.com/vcto/mcp-adapters/internal/debug"
 	"github.com/vcto/mcp-adapters/internal/middleware"
 	"github.com/vcto/mcp-adapters/internal/rtm"
+	"github.com/vcto/mcp-adapters/internal/security"
+	"github.com/vcto/mcp-adapters/internal/webhooks"
 )
 
 // InfrastructureConfig configures shared MCP server infrastructure
@@ -27,11 +33,14 @@ type InfrastructureConfig struct {
 	ServerURL      string
 	Port           string
 	AuthDisabled   bool
-	RTMHandler     *rtm.Handler
 	DebugStorage   debug.Storage
 	DebugConfig    *debug.DebugConfig
 	ServerName     string
 	AllowedOrigins []string
+	Webhooks       *webhooks.Registry  // Inbound webhooks, nil when none are configured
+	Tokens         *auth.TokenRegistry // Bearer token activity and revocations, nil to skip tracking
+	Admin          *admin.Service      // Operator control plane, served when ADMIN_TOKEN is set
+	Security       *security.Scanner   // Dependency vulnerability scanning for /health?security=true
 }
 
 // MCPServerResult contains the configured server and shutdown function
@@ -65,7 +74,18 @@ func SetupInfrastructure(mcpServer *server.MCPServer, config InfrastructureConfi
 	}
 
 	// Setup standard endpoints
-	setupStandardEndpoints(mux)
+	setupStandardEndpoints(mux, config)
+
+	// Inbound webhooks verify their own secrets, so they sit outside OAuth
+	if config.Webhooks != nil {
+		mux.Handle(webhooks.PathPrefix, config.Webhooks)
+	}
+
+	// Operator control plane, on this port and optionally over gRPC
+	stopAdmin := setupAdmin(mux, config)
+
+	// Client deadlines bound everything below, auth included
+	handler = deadline.Middleware(handler)
 
 	// Mount MCP handler
 	mux.Handle("/mcp", handler)
@@ -84,8 +104,17 @@ func SetupInfrastructure(mcpServer *server.MCPServer, config InfrastructureConfi
 		Handler: finalHandler,
 	}
 
+	// Mutual TLS authenticates clients at the transport, before any of the above
+	if mtlsConfig, ok := auth.MTLSConfigFromEnv(); ok {
+		if err := mtlsConfig.Apply(srv); err != nil {
+			log.Fatalf("Failed to enable mutual TLS: %v", err)
+		}
+		log.Printf("Auth: Enabled mutual TLS client authentication")
+	}
+
 	// Setup graceful shutdown
 	shutdownFunc := func() error {
+		stopAdmin()
 		return gracefulShutdown(srv)
 	}
 
@@ -115,7 +144,13 @@ func StartServer(result *MCPServerResult, config InfrastructureConfig) {
 	serverErr := make(chan error, 1)
 	go func() {
 		log.Printf("Server starting on :%s", config.Port)
-		if err := result.Server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if result.Server.TLSConfig != nil {
+			err = result.Server.ListenAndServeTLS("", "")
+		} else {
+			err = result.Server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			serverErr <- err
 		}
 	}()
@@ -158,14 +193,20 @@ func buildMiddlewareStack(streamableServer *server.StreamableHTTPServer, config
 	return handler
 }
 
-// setupOAuthEndpoints configures OAuth authentication
+// setupOAuthEndpoints configures OAuth authentication, or static API keys
+// when MCP_API_KEYS or MCP_API_KEYS_FILE is set
 func setupOAuthEndpoints(mux *http.ServeMux, config InfrastructureConfig, handler *http.Handler) {
 	rtmAPIKey := os.Getenv("RTM_API_KEY")
 	rtmSecret := os.Getenv("RTM_API_SECRET")
 
-	if rtmAPIKey != "" && rtmSecret != "" {
+	if staticKeys := auth.StaticKeysFromEnv(); staticKeys != nil {
+		// Static API keys replace the browser flow entirely
+		*handler = auth.StaticKeyMiddleware(staticKeys)(*handler)
+		log.Printf("Auth: Enabled %d static API key(s), OAuth endpoints off", staticKeys.Len())
+	} else if rtmAPIKey != "" && rtmSecret != "" {
 		// Use RTM OAuth adapter
 		rtmAdapter := rtm.NewOAuthAdapter(rtmAPIKey, rtmSecret, config.ServerURL)
+		rtmAdapter.UseAuditLog(audit.Default())
 		rtmSetup := rtm.NewSetupHandler()
 
 		// OAuth endpoints for RTM (claude.ai compatibility)
@@ -173,16 +214,21 @@ func setupOAuthEndpoints(mux *http.ServeMux, config InfrastructureConfig, handle
 		mux.HandleFunc("/token", rtmAdapter.HandleToken)
 		mux.HandleFunc("/oauth/authorize", rtmAdapter.HandleAuthorize)
 		mux.HandleFunc("/oauth/token", rtmAdapter.HandleToken)
+		mux.HandleFunc("/oauth/revoke", rtmAdapter.HandleRevoke)
+		mux.HandleFunc("/oauth/introspect", rtmAdapter.HandleIntrospect)
 		mux.HandleFunc("/oauth/register", rtmAdapter.HandleRegister)
+		mux.HandleFunc("/oauth/register/", rtmAdapter.HandleClientConfiguration)
 		mux.HandleFunc("/rtm/callback", rtmAdapter.HandleCallback)
 		mux.HandleFunc("/rtm/check-auth", rtmAdapter.HandleCheckAuth)
 		mux.HandleFunc("/rtm/setup", rtmSetup.HandleSetup)
+		mux.HandleFunc("/health/oauth", rtmAdapter.Guard().HandleMetrics)
 
 		// OAuth discovery endpoints (RFC 9728 + Claude compatibility)
-		setupRTMWellKnownEndpoints(mux, config.ServerURL)
+		mux.HandleFunc("/.well-known/oauth-protected-resource", rtmAdapter.HandleProtectedResourceMetadata)
+		mux.HandleFunc("/.well-known/oauth-authorization-server", rtmAdapter.HandleAuthServerMetadata)
 
 		// Add auth middleware to the MCP handler
-		*handler = rtmAuthMiddleware(rtmAdapter, config.RTMHandler, config)(*handler)
+		*handler = rtmAuthMiddleware(rtmAdapter, config)(*handler)
 
 		log.Printf("OAuth: Enabled RTM OAuth adapter")
 	} else {
@@ -194,6 +240,7 @@ func setupOAuthEndpoints(mux *http.ServeMux, config InfrastructureConfig, handle
 			}
 		}
 		oauthAdapter := auth.NewOAuthAdapter(config.ServerURL, callbackPort)
+		oauthAdapter.UseAuditLog(audit.Default())
 
 		// Add auth middleware to the MCP handler
 		*handler = auth.Middleware(oauthAdapter)(*handler)
@@ -201,49 +248,57 @@ func setupOAuthEndpoints(mux *http.ServeMux, config InfrastructureConfig, handle
 		// OAuth endpoints
 		mux.HandleFunc("/.well-known/oauth-protected-resource", oauthAdapter.HandleProtectedResourceMetadata)
 		mux.HandleFunc("/.well-known/oauth-authorization-server", oauthAdapter.HandleAuthServerMetadata)
+		mux.HandleFunc("/.well-known/jwks.json", oauthAdapter.HandleJWKS)
 		mux.HandleFunc("/oauth/authorize", oauthAdapter.HandleAuthorize)
 		mux.HandleFunc("/oauth/token", oauthAdapter.HandleToken)
+		mux.HandleFunc("/oauth/revoke", oauthAdapter.HandleRevoke)
+		mux.HandleFunc("/oauth/introspect", oauthAdapter.HandleIntrospect)
 		mux.HandleFunc("/oauth/register", oauthAdapter.HandleRegister)
+		mux.HandleFunc("/oauth/register/", oauthAdapter.HandleClientConfiguration)
+		mux.HandleFunc("/oauth/oidc/callback", oauthAdapter.HandleOIDCCallback)
+		mux.HandleFunc("/oauth/oidc/link", oauthAdapter.HandleOIDCLink)
+		mux.HandleFunc("/health/oauth", oauthAdapter.Guard().HandleMetrics)
 		log.Printf("OAuth: Enabled generic OAuth adapter")
 	}
 }
 
-// setupRTMWellKnownEndpoints adds RTM-specific discovery endpoints
-func setupRTMWellKnownEndpoints(mux *http.ServeMux, serverURL string) {
-	mux.HandleFunc("/.well-known/oauth-protected-resource", func(w http.ResponseWriter, r *http.Request) {
-		metadata := map[string]interface{}{
-			"authorization_servers": []string{serverURL},
-			"resource":              serverURL + "/mcp",
-			"scopes_supported":      []string{"rtm:read", "rtm:write"},
-		}
-		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(metadata); err != nil {
-			log.Printf("Failed to encode OAuth metadata: %v", err)
-		}
-	})
+// setupAdmin mounts the HTTP admin API and starts the gRPC control plane
+// when ADMIN_GRPC_ADDR is set. It returns a function stopping the gRPC server.
+func setupAdmin(mux *http.ServeMux, config InfrastructureConfig) func() {
+	stop := func() {}
+	if config.Admin == nil {
+		return stop
+	}
+	token := admin.TokenFromEnv()
+	if token == "" {
+		log.Printf("Admin: control plane disabled (set ADMIN_TOKEN to enable)")
+		return stop
+	}
 
-	mux.HandleFunc("/.well-known/oauth-authorization-server", func(w http.ResponseWriter, r *http.Request) {
-		metadata := map[string]interface{}{
-			"issuer":                           serverURL,
-			"authorization_endpoint":           serverURL + "/oauth/authorize", // FIX: Added /oauth prefix
-			"token_endpoint":                   serverURL + "/oauth/token",     // FIX: Added /oauth prefix
-			"registration_endpoint":            serverURL + "/oauth/register",
-			"scopes_supported":                 []string{"rtm:read", "rtm:write"},
-			"response_types_supported":         []string{"code"},
-			"grant_types_supported":            []string{"authorization_code"},
-			"code_challenge_methods_supported": []string{"S256"},
-			"resource_indicators_supported":    true,
+	mux.Handle(admin.PathPrefix, admin.NewHTTPHandler(config.Admin, token))
+	log.Printf("Admin: HTTP API at %s%s", config.ServerURL, admin.PathPrefix)
+
+	if addr := admin.GRPCAddrFromEnv(); addr != "" {
+		var tlsConfig *tls.Config
+		if mtls, ok := auth.MTLSConfigFromEnv(); ok {
+			var err error
+			if tlsConfig, err = admin.GRPCTLSConfig(mtls); err != nil {
+				log.Fatalf("Admin: gRPC control plane: %v", err)
+			}
 		}
-		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(metadata); err != nil {
-			log.Printf("Failed to encode auth server metadata: %v", err)
+		grpcServer := admin.NewGRPCServer(config.Admin, token, tlsConfig)
+		if err := admin.ServeGRPC(grpcServer, addr, tlsConfig != nil); err != nil {
+			log.Fatalf("Admin: gRPC control plane: %v", err)
 		}
-	})
+		log.Printf("Admin: gRPC control plane on %s", addr)
+		stop = grpcServer.GracefulStop
+	}
+	return stop
 }
 
 // setupStandardEndpoints adds health check and logo endpoints
-func setupStandardEndpoints(mux *http.ServeMux) {
-	mux.HandleFunc("/health", handleHealth)
+func setupStandardEndpoints(mux *http.ServeMux, config InfrastructureConfig) {
+	mux.HandleFunc("/health", security.HealthHandler(config.Security, handleHealth))
 	mux.HandleFunc("/logo", handleLogo)
 }
 
@@ -283,7 +338,7 @@ func protocolDetectionMiddleware(next http.Handler) http.Handler {
 }
 
 // rtmAuthMiddleware validates RTM bearer tokens
-func rtmAuthMiddleware(adapter *rtm.OAuthAdapter, rtmHandler *rtm.Handler, config InfrastructureConfig) func(http.Handler) http.Handler {
+func rtmAuthMiddleware(adapter *rtm.OAuthAdapter, config InfrastructureConfig) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Skip auth for OAuth and standard endpoints
@@ -312,24 +367,37 @@ func rtmAuthMiddleware(adapter *rtm.OAuthAdapter, rtmHandler *rtm.Handler, confi
 			// Extract bearer token
 			const bearerPrefix = "Bearer "
 			if !strings.HasPrefix(authHeader, bearerPrefix) {
+				adapter.Auditor().Record(r, auth.AuditValidationFailed, auth.AuditFailure, "", "invalid authorization format")
 				http.Error(w, "Invalid Authorization format", http.StatusUnauthorized)
 				return
 			}
 
 			token := strings.TrimPrefix(authHeader, bearerPrefix)
+			if config.Tokens != nil && config.Tokens.Revoked(token) {
+				adapter.Auditor().Record(r, auth.AuditValidationFailed, auth.AuditFailure, "", "revoked token")
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf("Bearer realm=\"%s/.well-known/oauth-protected-resource\", error=\"invalid_token\"", config.ServerURL))
+				http.Error(w, "Token revoked", http.StatusUnauthorized)
+				return
+			}
 			if !adapter.ValidateBearer(token) {
+				adapter.Auditor().Record(r, auth.AuditValidationFailed, auth.AuditFailure, "", "invalid token")
 				// CRITICAL: WWW-Authenticate header required for ALL 401 responses
 				w.Header().Set("WWW-Authenticate", fmt.Sprintf("Bearer realm=\"%s/.well-known/oauth-protected-resource\"", config.ServerURL))
 				http.Error(w, "Invalid token", http.StatusUnauthorized)
 				return
 			}
 
-			// Set token on the registered RTM handler instance
-			if rtmHandler != nil {
-				rtmHandler.SetAuthToken(token)
+			if config.Tokens != nil {
+				config.Tokens.Seen(token)
 			}
 
-			next.ServeHTTP(w, r)
+			// Tools act as the token's RTM user for this request only, limited
+			// to the scopes it was granted
+			ctx := rtm.WithAuthToken(r.Context(), token)
+			if scopes := adapter.TokenScopes(token); scopes != nil {
+				ctx = auth.WithScopes(ctx, scopes)
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
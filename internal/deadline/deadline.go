@@ -0,0 +1,153 @@
+// Package deadline carries a client's time budget through a request. Clients
+// send the X-Request-Deadline header, or a "deadline" field in a tool call's
+// _meta, and the server turns it into a context deadline. Each layer keeps a
+// small reserve for its own work, so upstream calls such as RTM searches see
+// only the time that is really left and fail fast once it is gone, instead of
+// finishing after the client has given up.
+package deadline
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/vcto/mcp-adapters/internal/health"
+)
+
+// Header carries the client's deadline on HTTP requests
+const Header = "X-Request-Deadline"
+
+// MetaKey is the tool call _meta field carrying the deadline
+const MetaKey = "deadline"
+
+// Reserve is the time each layer keeps back from the layers below it for
+// encoding and returning its response
+const Reserve = 50 * time.Millisecond
+
+// Parse reads a deadline given as an RFC 3339 time, a budget in
+// milliseconds ("1500"), or a Go duration ("1.5s") counted from now
+func Parse(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, fmt.Errorf("empty deadline")
+	}
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return now.Add(time.Duration(ms) * time.Millisecond), nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(d), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("deadline %q is not an RFC 3339 time, milliseconds or a duration", value)
+}
+
+// With bounds ctx by deadline, keeping any earlier deadline ctx already has
+func With(ctx context.Context, deadline time.Time) (context.Context, context.CancelFunc) {
+	if current, ok := ctx.Deadline(); ok && current.Before(deadline) {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, deadline)
+}
+
+// Sub returns a context for the layer below, whose deadline is ctx's minus
+// reserve. Without a deadline on ctx it only adds cancellation.
+func Sub(ctx context.Context, reserve time.Duration) (context.Context, context.CancelFunc) {
+	current, ok := ctx.Deadline()
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, current.Add(-reserve))
+}
+
+// Remaining returns the time left before ctx's deadline, and false when it
+// has none
+func Remaining(ctx context.Context) (time.Duration, bool) {
+	current, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+	return time.Until(current), true
+}
+
+// Middleware derives the request context's deadline from the
+// X-Request-Deadline header. Malformed values are rejected with 400 and
+// requests whose budget is already spent with 504, without running next.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value := r.Header.Get(Header)
+		if value == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		at, err := Parse(value, time.Now())
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid %s: %v", Header, err), http.StatusBadRequest)
+			return
+		}
+		if !time.Now().Before(at.Add(-Reserve)) {
+			http.Error(w, "Request deadline exceeded", http.StatusGatewayTimeout)
+			return
+		}
+
+		ctx, cancel := With(r.Context(), at)
+		defer cancel()
+		ctx, cancelSub := Sub(ctx, Reserve)
+		defer cancelSub()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ToolMiddleware applies a deadline from the tool call's _meta, keeping any
+// earlier one from the HTTP header, and fails calls whose budget is already
+// spent without running them. Register it first so it wraps the others.
+func ToolMiddleware() server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if meta := request.Params.Meta; meta != nil && meta.AdditionalFields[MetaKey] != nil {
+				at, err := parseMeta(meta.AdditionalFields[MetaKey])
+				if err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("Invalid _meta.%s: %v", MetaKey, err)), nil
+				}
+				var cancel context.CancelFunc
+				ctx, cancel = With(ctx, at)
+				defer cancel()
+			}
+
+			if remaining, ok := Remaining(ctx); ok && remaining <= Reserve {
+				return Exceeded(request.Params.Name), nil
+			}
+			ctx, cancel := Sub(ctx, Reserve)
+			defer cancel()
+			return next(ctx, request)
+		}
+	}
+}
+
+// Exceeded is the result for a tool call whose budget ran out. Repeating the
+// call with the same budget would fail the same way, so it is not retryable.
+func Exceeded(tool string) *mcp.CallToolResult {
+	result := mcp.NewToolResultError(fmt.Sprintf("%s: request deadline exceeded before the call could finish", tool))
+	health.SetRetryHint(result, health.RetryHint{Reason: "request deadline exceeded"})
+	return result
+}
+
+// parseMeta reads a _meta deadline, which JSON decodes as a string or a
+// number of milliseconds
+func parseMeta(value any) (time.Time, error) {
+	switch v := value.(type) {
+	case string:
+		return Parse(v, time.Now())
+	case float64:
+		return time.Now().Add(time.Duration(v * float64(time.Millisecond))), nil
+	default:
+		return time.Time{}, fmt.Errorf("unsupported type %T", value)
+	}
+}
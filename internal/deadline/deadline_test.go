@@ -0,0 +1,165 @@
+package deadline
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/vcto/mcp-adapters/internal/health"
+)
+
+func TestParse(t *testing.T) {
+	t.Logf("Importance: Clients send budgets in whatever form their HTTP stack makes easy; all of them must land on the same deadline.")
+
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"1500", now.Add(1500 * time.Millisecond)},
+		{"1.5s", now.Add(1500 * time.Millisecond)},
+		{" 2m ", now.Add(2 * time.Minute)},
+		{"2026-01-02T03:04:06.5Z", now.Add(1500 * time.Millisecond)},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.value, now)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tt.value, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("Parse(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	for _, value := range []string{"", "soon", "12abc"} {
+		if _, err := Parse(value, now); err == nil {
+			t.Errorf("Expected Parse(%q) to fail", value)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Logf("Importance: The header is the only way HTTP clients can stop the server working on requests they have already abandoned.")
+
+	var got time.Time
+	var hasDeadline bool
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, hasDeadline = r.Context().Deadline()
+	}))
+
+	serve := func(value string) *httptest.ResponseRecorder {
+		got, hasDeadline = time.Time{}, false
+		req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+		if value != "" {
+			req.Header.Set(Header, value)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("no header leaves the request alone", func(t *testing.T) {
+		t.Logf("  > Why it's important: Clients that never heard of the header must see no change.")
+		if rec := serve(""); rec.Code != http.StatusOK || hasDeadline {
+			t.Errorf("Expected 200 without a deadline, got %d (deadline %v)", rec.Code, hasDeadline)
+		}
+	})
+
+	t.Run("budget becomes a deadline less the reserve", func(t *testing.T) {
+		t.Logf("  > Why it's important: The layers below must finish early enough for the response to reach the client in time.")
+		before := time.Now()
+		if rec := serve("2000"); rec.Code != http.StatusOK || !hasDeadline {
+			t.Fatalf("Expected 200 with a deadline, got %d (deadline %v)", rec.Code, hasDeadline)
+		}
+		earliest := before.Add(2*time.Second - Reserve)
+		if got.Before(earliest) || got.After(time.Now().Add(2*time.Second-Reserve)) {
+			t.Errorf("Expected deadline about 2s less %v from now, got %v", Reserve, time.Until(got))
+		}
+	})
+
+	t.Run("malformed header is rejected", func(t *testing.T) {
+		t.Logf("  > Why it's important: A typo must not silently run the request without the budget the client asked for.")
+		if rec := serve("soon"); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400, got %d", rec.Code)
+		}
+	})
+
+	t.Run("spent budget is refused", func(t *testing.T) {
+		t.Logf("  > Why it's important: Work the client can no longer receive is wasted upstream quota.")
+		if rec := serve(time.Now().Add(-time.Second).Format(time.RFC3339Nano)); rec.Code != http.StatusGatewayTimeout {
+			t.Errorf("Expected 504, got %d", rec.Code)
+		}
+		if rec := serve("10"); rec.Code != http.StatusGatewayTimeout {
+			t.Errorf("Expected 504 for a budget inside the reserve, got %d", rec.Code)
+		}
+	})
+}
+
+func TestToolMiddleware(t *testing.T) {
+	t.Logf("Importance: Stdio clients have no headers, so _meta is how they bound a slow tool call.")
+
+	var got time.Time
+	var hasDeadline, ran bool
+	handler := ToolMiddleware()(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ran = true
+		got, hasDeadline = ctx.Deadline()
+		return mcp.NewToolResultText("ok"), nil
+	})
+
+	call := func(ctx context.Context, value any) *mcp.CallToolResult {
+		ran, hasDeadline = false, false
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "search_tasks"
+		if value != nil {
+			request.Params.Meta = &mcp.Meta{AdditionalFields: map[string]any{MetaKey: value}}
+		}
+		result, err := handler(ctx, request)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return result
+	}
+
+	t.Run("meta deadline bounds the call", func(t *testing.T) {
+		t.Logf("  > Why it's important: JSON numbers arrive as float64 and must be read as milliseconds.")
+		if result := call(context.Background(), float64(2000)); result.IsError || !hasDeadline {
+			t.Fatalf("Expected the call to run with a deadline, got %v", result.Content)
+		}
+		if remaining := time.Until(got); remaining > 2*time.Second-Reserve || remaining < time.Second {
+			t.Errorf("Expected about 2s less %v left, got %v", Reserve, remaining)
+		}
+	})
+
+	t.Run("earlier header deadline wins", func(t *testing.T) {
+		t.Logf("  > Why it's important: A generous _meta value must not extend the budget the HTTP client set.")
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		header, _ := ctx.Deadline()
+		call(ctx, "1m")
+		if !hasDeadline || got.After(header) {
+			t.Errorf("Expected a deadline no later than %v, got %v", header, got)
+		}
+	})
+
+	t.Run("bad meta value is a tool error", func(t *testing.T) {
+		t.Logf("  > Why it's important: The model needs to see why its deadline was ignored.")
+		if result := call(context.Background(), true); !result.IsError || ran {
+			t.Errorf("Expected a tool error without running the tool, got %v", result.Content)
+		}
+	})
+
+	t.Run("spent budget is not retryable", func(t *testing.T) {
+		t.Logf("  > Why it's important: Retrying with the same budget would only fail again.")
+		result := call(context.Background(), float64(1))
+		if !result.IsError || ran {
+			t.Fatalf("Expected a tool error without running the tool, got %v", result.Content)
+		}
+		if hint, ok := health.GetRetryHint(result); !ok || hint.Retryable {
+			t.Errorf("Expected a non-retryable hint, got %+v", hint)
+		}
+	})
+}
@@ -11,6 +11,8 @@ import (
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/vcto/mcp-adapters/internal/atrest"
+	"github.com/vcto/mcp-adapters/internal/residency"
 )
 
 // DebugConfig holds runtime configuration for the debug system
@@ -143,22 +145,32 @@ func (n *NoOpStorage) IsEnabled() bool {
 	return false
 }
 
-// FileStorage implements SQLite-based storage
+// EncryptionTarget lists the debug log columns encrypted at rest, for migrations
+var EncryptionTarget = atrest.Target{Table: "conversations", Columns: []string{"params", "result", "error"}}
+
+// FileStorage implements SQLite-based storage. Message payloads are encrypted
+// when storage encryption is configured.
 type FileStorage struct {
 	db       *sql.DB
 	dbPath   string
 	enabled  bool
 	maxBytes int64
+	keyring  *atrest.Keyring
 }
 
 // NewFileStorage creates a new file-based storage
 func NewFileStorage(config *DebugConfig) (*FileStorage, error) {
+	keyring, err := atrest.Default()
+	if err != nil {
+		return nil, err
+	}
+
 	var dbPath string
 	switch config.StorageType {
 	case "memory":
 		dbPath = ":memory:"
 	case "file":
-		dbPath = config.StoragePath
+		dbPath = residency.StoragePath(config.StoragePath)
 		// Create directory if needed
 		if dbPath != ":memory:" {
 			if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
@@ -179,6 +191,7 @@ func NewFileStorage(config *DebugConfig) (*FileStorage, error) {
 		dbPath:   dbPath,
 		enabled:  true,
 		maxBytes: int64(config.MaxFileMB) * 1024 * 1024,
+		keyring:  keyring,
 	}
 
 	if err := storage.createTablesWithValidation(); err != nil {
@@ -270,16 +283,26 @@ func (fs *FileStorage) LogMessage(sessionID, direction, method string, params, r
 		log.Printf("Storage limit enforcement failed: %v", err)
 	}
 
+	payloads := []string{string(paramsJSON), string(resultJSON), string(errorJSON)}
+	for i, payload := range payloads {
+		sealed, err := fs.keyring.Encrypt(payload)
+		if err != nil {
+			return err
+		}
+		payloads[i] = sealed
+	}
+
 	query := `
 	INSERT INTO conversations (session_id, timestamp, direction, method, params, result, error, performance_ms, size_bytes)
 	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
-	_, err := fs.db.Exec(query, sessionID, time.Now(), direction, method, string(paramsJSON), string(resultJSON), string(errorJSON), performanceMS, sizeBytes)
+	_, err := fs.db.Exec(query, sessionID, time.Now(), direction, method, payloads[0], payloads[1], payloads[2], performanceMS, sizeBytes)
 	if err != nil {
 		return err
 	}
 
 	fs.updateSessionCount(sessionID)
+	residency.Record(sessionID, residency.StoreDebug)
 	return nil
 }
 
@@ -349,11 +372,26 @@ func (fs *FileStorage) GetConversation(sessionID string) ([]ConversationRecord,
 		if err != nil {
 			return nil, err
 		}
+		if err := fs.decryptRecord(&record); err != nil {
+			return nil, err
+		}
 		records = append(records, record)
 	}
 	return records, nil
 }
 
+// decryptRecord opens a record's encrypted payloads in place
+func (fs *FileStorage) decryptRecord(record *ConversationRecord) error {
+	for _, field := range []*string{&record.Params, &record.Result, &record.Error} {
+		plaintext, err := fs.keyring.Decrypt(*field)
+		if err != nil {
+			return fmt.Errorf("record %d: %w", record.ID, err)
+		}
+		*field = plaintext
+	}
+	return nil
+}
+
 func (fs *FileStorage) GetRecentSessions(limit int) ([]string, error) {
 	if limit <= 0 {
 		limit = 10
@@ -407,6 +445,9 @@ func (fs *FileStorage) GetMessagesByMethod(method string, limit int) ([]Conversa
 		if err != nil {
 			return nil, err
 		}
+		if err := fs.decryptRecord(&record); err != nil {
+			return nil, err
+		}
 		records = append(records, record)
 	}
 	return records, nil
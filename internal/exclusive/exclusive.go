@@ -0,0 +1,213 @@
+// Package exclusive keeps tools that must not overlap from running at the
+// same time for the same subject, such as two batch jobs editing one user's
+// tasks. A conflicting call fails fast with an "operation in progress" error
+// naming the tool and job holding the lock.
+package exclusive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/vcto/mcp-adapters/internal/health"
+)
+
+// inProgressBackoff is the suggested wait before retrying a locked-out call
+const inProgressBackoff = 5 * time.Second
+
+// SubjectFunc identifies whose data a tool call touches, e.g. a hash of the
+// caller's upstream token
+type SubjectFunc func(ctx context.Context) string
+
+// Holder describes the call holding a lock
+type Holder struct {
+	Tool  string    `json:"tool"`
+	JobID string    `json:"job_id,omitempty"`
+	Since time.Time `json:"since"`
+}
+
+// InProgressError is returned when a lock is already held
+type InProgressError struct {
+	Scope  string
+	Holder Holder
+}
+
+func (e *InProgressError) Error() string {
+	if e.Holder.JobID != "" {
+		return fmt.Sprintf("operation in progress: %s (job %s) has held the %s lock since %s", e.Holder.Tool, e.Holder.JobID, e.Scope, e.Holder.Since.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("operation in progress: %s has held the %s lock since %s", e.Holder.Tool, e.Scope, e.Holder.Since.Format(time.RFC3339))
+}
+
+// Result renders the error as a structured tool error
+func (e *InProgressError) Result() *mcp.CallToolResult {
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"error":            "operation_in_progress",
+		"message":          e.Error() + ". Wait for it to finish, then retry.",
+		"scope":            e.Scope,
+		"conflicting_tool": e.Holder.Tool,
+		"job_id":           e.Holder.JobID,
+		"since":            e.Holder.Since,
+	}, "", "  ")
+	if err != nil {
+		data = []byte(e.Error())
+	}
+	result := mcp.NewToolResultError(string(data))
+	// Nothing ran, so the call is safe to repeat once the lock is free
+	health.SetRetryHint(result, health.RetryHint{Retryable: true, BackoffMs: inProgressBackoff.Milliseconds(), Reason: "operation in progress"})
+	return result
+}
+
+// Locks holds one lease per key
+type Locks struct {
+	mu   sync.Mutex
+	held map[string]*Lease
+	now  func() time.Time
+}
+
+// NewLocks creates an empty lock table
+func NewLocks() *Locks {
+	return &Locks{held: make(map[string]*Lease), now: time.Now}
+}
+
+// Acquire takes the lock for key on behalf of tool, or reports who holds it
+func (l *Locks) Acquire(scope, key, tool string) (*Lease, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if held, ok := l.held[key]; ok {
+		return nil, &InProgressError{Scope: scope, Holder: held.Holder()}
+	}
+	lease := &Lease{locks: l, key: key, holder: Holder{Tool: tool, Since: l.now()}}
+	l.held[key] = lease
+	return lease, nil
+}
+
+// Lease is a held lock. Its methods are safe to call on a nil lease.
+type Lease struct {
+	locks *Locks
+	key   string
+
+	mu       sync.Mutex
+	holder   Holder
+	detached bool
+	released bool
+}
+
+// Holder returns who holds the lease
+func (l *Lease) Holder() Holder {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.holder
+}
+
+// SetJob records the background job holding the lease, so conflicting calls
+// can point at it
+func (l *Lease) SetJob(id string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.holder.JobID = id
+}
+
+// Release frees the lock; later calls do nothing
+func (l *Lease) Release() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	if l.released {
+		l.mu.Unlock()
+		return
+	}
+	l.released = true
+	l.mu.Unlock()
+
+	l.locks.mu.Lock()
+	defer l.locks.mu.Unlock()
+	if l.locks.held[l.key] == l {
+		delete(l.locks.held, l.key)
+	}
+}
+
+type leaseKey struct{}
+
+// Detach hands the lease for the current call to a background job, which
+// must Release it when done. It returns nil when the tool is not exclusive.
+func Detach(ctx context.Context) *Lease {
+	lease, _ := ctx.Value(leaseKey{}).(*Lease)
+	if lease == nil {
+		return nil
+	}
+	lease.mu.Lock()
+	defer lease.mu.Unlock()
+	lease.detached = true
+	return lease
+}
+
+func (l *Lease) isDetached() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.detached
+}
+
+// rule is one tool's exclusion scope and how to find its subject
+type rule struct {
+	scope   string
+	subject SubjectFunc
+}
+
+// Registry maps tools to the exclusion scopes their adapter declared
+type Registry struct {
+	mu     sync.RWMutex
+	locks  *Locks
+	byTool map[string]rule
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{locks: NewLocks(), byTool: make(map[string]rule)}
+}
+
+// Add registers tool-to-scope declarations for an adapter. Tools sharing a
+// scope never run concurrently for the same subject.
+func (r *Registry) Add(subject SubjectFunc, scopes map[string]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for tool, scope := range scopes {
+		r.byTool[tool] = rule{scope: scope, subject: subject}
+	}
+}
+
+// Middleware holds the tool's lock for the duration of the call, or until a
+// background job that called Detach releases it
+func (r *Registry) Middleware() server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			r.mu.RLock()
+			rule, ok := r.byTool[request.Params.Name]
+			r.mu.RUnlock()
+			if !ok {
+				return next(ctx, request)
+			}
+
+			lease, err := r.locks.Acquire(rule.scope, rule.scope+"|"+rule.subject(ctx), request.Params.Name)
+			if err != nil {
+				return err.(*InProgressError).Result(), nil
+			}
+
+			result, callErr := next(context.WithValue(ctx, leaseKey{}, lease), request)
+			if !lease.isDetached() {
+				lease.Release()
+			}
+			return result, callErr
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package exclusive
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func callTool(handler server.ToolHandlerFunc, name string) *mcp.CallToolResult {
+	request := mcp.CallToolRequest{}
+	request.Params.Name = name
+	result, _ := handler(context.Background(), request)
+	return result
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Logf("Importance: Two batch jobs editing the same user's tasks at once can interleave and corrupt each other's changes.")
+
+	subject := "user-a"
+	registry := NewRegistry()
+	registry.Add(func(ctx context.Context) string { return subject }, map[string]string{
+		"batch_one": "batch",
+		"batch_two": "batch",
+	})
+
+	// batch_one hands its lock to a background job that is still running
+	var job *Lease
+	handler := registry.Middleware()(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if request.Params.Name == "batch_one" {
+			job = Detach(ctx)
+			job.SetJob("job-42")
+		}
+		return mcp.NewToolResultText("ok"), nil
+	})
+
+	if result := callTool(handler, "batch_one"); result.IsError {
+		t.Fatalf("Expected first call to run, got %+v", result)
+	}
+
+	t.Run("conflicting call names the running job", func(t *testing.T) {
+		t.Logf("  > Why it's important: Agents need the job ID to check on or wait for the operation in progress.")
+		result := callTool(handler, "batch_two")
+		if !result.IsError {
+			t.Fatal("Expected batch_two to be rejected while the job holds the lock")
+		}
+		var body map[string]interface{}
+		if err := json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &body); err != nil {
+			t.Fatalf("Expected structured error, got %v", err)
+		}
+		if body["error"] != "operation_in_progress" || body["job_id"] != "job-42" || body["conflicting_tool"] != "batch_one" {
+			t.Errorf("Unexpected error body: %v", body)
+		}
+	})
+
+	t.Run("other subjects and tools are unaffected", func(t *testing.T) {
+		t.Logf("  > Why it's important: Locks must not serialize unrelated users or tools.")
+		subject = "user-b"
+		defer func() { subject = "user-a" }()
+		if result := callTool(handler, "batch_two"); result.IsError {
+			t.Errorf("Expected another user's call to run, got %+v", result)
+		}
+		subject = "user-a"
+		if result := callTool(handler, "unrelated"); result.IsError {
+			t.Errorf("Expected undeclared tool to run, got %+v", result)
+		}
+	})
+
+	t.Run("lock is freed when the job finishes", func(t *testing.T) {
+		t.Logf("  > Why it's important: A finished job must not block later operations.")
+		job.Release()
+		job.Release()
+		if result := callTool(handler, "batch_two"); result.IsError {
+			t.Errorf("Expected call to run after release, got %+v", result)
+		}
+		// Synchronous calls release on return
+		if result := callTool(handler, "batch_two"); result.IsError {
+			t.Errorf("Expected synchronous call to release its lock, got %+v", result)
+		}
+	})
+}
@@ -0,0 +1,161 @@
+// Package health tracks the runtime health of API adapters and exposes it
+// to agents through the adapter_status tool.
+package health
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Circuit breaker states
+const (
+	StateClosed   = "closed"
+	StateOpen     = "open"
+	StateHalfOpen = "half-open"
+)
+
+// Defaults used by NewBreaker when zero values are given
+const (
+	defaultFailureThreshold = 5
+	defaultCooldown         = 30 * time.Second
+)
+
+// ErrCircuitOpen is returned by Allow while the circuit is open
+var ErrCircuitOpen = errors.New("circuit open: upstream API is failing, retry later")
+
+// Breaker is a consecutive-failure circuit breaker for an upstream API.
+// After threshold consecutive failures the circuit opens and calls are
+// rejected until the cooldown passes; one trial call is then let through
+// and its outcome closes or re-opens the circuit.
+type Breaker struct {
+	mu          sync.Mutex
+	threshold   int
+	cooldown    time.Duration
+	state       string
+	failures    int
+	openedAt    time.Time
+	lastError   string
+	lastErrorAt time.Time
+	simulation  *SimulatedOutage
+	now         func() time.Time
+}
+
+// BreakerState is a point-in-time copy of a breaker's state
+type BreakerState struct {
+	State               string           `json:"state"`
+	ConsecutiveFailures int              `json:"consecutive_failures"`
+	OpenedAt            *time.Time       `json:"opened_at,omitempty"`
+	RetryAt             *time.Time       `json:"retry_at,omitempty"`
+	LastError           string           `json:"last_error,omitempty"`
+	LastErrorAt         *time.Time       `json:"last_error_at,omitempty"`
+	SimulatedOutage     *SimulatedOutage `json:"simulated_outage,omitempty"`
+}
+
+// NewBreaker creates a closed breaker
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	if threshold <= 0 {
+		threshold = defaultFailureThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = defaultCooldown
+	}
+	return &Breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		state:     StateClosed,
+		now:       time.Now,
+	}
+}
+
+// Allow reports whether a call may proceed, moving an open circuit to
+// half-open once the cooldown has passed
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.simulated(); err != nil {
+		return err
+	}
+
+	switch b.state {
+	case StateOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		b.state = StateHalfOpen
+		return nil
+	case StateHalfOpen:
+		// Only the single trial call is allowed until it reports back
+		return ErrCircuitOpen
+	default:
+		return nil
+	}
+}
+
+// Success records a successful call and closes the circuit
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = StateClosed
+	b.failures = 0
+}
+
+// Failure records a failed call, opening the circuit when the threshold is
+// reached or when the half-open trial call fails
+func (b *Breaker) Failure(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.noteError(err)
+	b.failures++
+	if b.state == StateHalfOpen || b.failures >= b.threshold {
+		b.state = StateOpen
+		b.openedAt = b.now()
+	}
+}
+
+// NoteError records an error for reporting without affecting the circuit.
+// Use it for client errors such as bad input or expired credentials.
+func (b *Breaker) NoteError(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.noteError(err)
+}
+
+func (b *Breaker) noteError(err error) {
+	if err == nil {
+		return
+	}
+	b.lastError = err.Error()
+	b.lastErrorAt = b.now()
+}
+
+// Snapshot returns the current breaker state
+func (b *Breaker) Snapshot() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := BreakerState{
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		LastError:           b.lastError,
+	}
+	if b.state != StateClosed {
+		openedAt := b.openedAt
+		retryAt := openedAt.Add(b.cooldown)
+		state.OpenedAt = &openedAt
+		state.RetryAt = &retryAt
+	}
+	if !b.lastErrorAt.IsZero() {
+		lastErrorAt := b.lastErrorAt
+		state.LastErrorAt = &lastErrorAt
+	}
+	if b.simulated() != nil {
+		simulation := *b.simulation
+		state.SimulatedOutage = &simulation
+	}
+	return state
+}
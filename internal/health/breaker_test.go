@@ -0,0 +1,86 @@
+package health
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	t.Logf("Importance: The breaker stops agents hammering a failing upstream API and tells them when to retry.")
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	newBreaker := func() *Breaker {
+		b := NewBreaker(2, time.Minute)
+		b.now = func() time.Time { return now }
+		return b
+	}
+
+	t.Run("opens after consecutive failures", func(t *testing.T) {
+		t.Logf("  > Why it's important: A burst of failures must trip the circuit so calls fail fast.")
+		b := newBreaker()
+		b.Failure(errors.New("timeout"))
+		if err := b.Allow(); err != nil {
+			t.Fatalf("Expected circuit to stay closed after one failure, got %v", err)
+		}
+		b.Failure(errors.New("timeout"))
+		if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("Expected ErrCircuitOpen, got %v", err)
+		}
+
+		state := b.Snapshot()
+		if state.State != StateOpen || state.ConsecutiveFailures != 2 {
+			t.Errorf("Unexpected state: %+v", state)
+		}
+		if state.RetryAt == nil || !state.RetryAt.Equal(now.Add(time.Minute)) {
+			t.Errorf("Expected retry time one cooldown after opening, got %v", state.RetryAt)
+		}
+		if state.LastError != "timeout" {
+			t.Errorf("Expected last error 'timeout', got %q", state.LastError)
+		}
+	})
+
+	t.Run("half-open trial closes or re-opens", func(t *testing.T) {
+		t.Logf("  > Why it's important: Recovery must be detected automatically without letting a flood of calls through.")
+		b := newBreaker()
+		b.Failure(errors.New("down"))
+		b.Failure(errors.New("down"))
+
+		now = now.Add(2 * time.Minute)
+		if err := b.Allow(); err != nil {
+			t.Fatalf("Expected trial call after cooldown, got %v", err)
+		}
+		if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("Expected only one trial call while half-open, got %v", err)
+		}
+
+		b.Failure(errors.New("still down"))
+		if b.Snapshot().State != StateOpen {
+			t.Errorf("Expected failed trial to re-open the circuit")
+		}
+
+		now = now.Add(2 * time.Minute)
+		if err := b.Allow(); err != nil {
+			t.Fatalf("Expected second trial call, got %v", err)
+		}
+		b.Success()
+		if state := b.Snapshot(); state.State != StateClosed || state.ConsecutiveFailures != 0 {
+			t.Errorf("Expected successful trial to close the circuit, got %+v", state)
+		}
+	})
+
+	t.Run("noted errors do not trip the circuit", func(t *testing.T) {
+		t.Logf("  > Why it's important: Bad input or expired tokens are reported but are not upstream outages.")
+		b := newBreaker()
+		for i := 0; i < 5; i++ {
+			b.NoteError(errors.New("invalid auth token"))
+		}
+		state := b.Snapshot()
+		if state.State != StateClosed {
+			t.Errorf("Expected circuit to stay closed, got %s", state.State)
+		}
+		if state.LastError != "invalid auth token" || state.LastErrorAt == nil {
+			t.Errorf("Expected noted error to be reported, got %+v", state)
+		}
+	})
+}
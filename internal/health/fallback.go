@@ -0,0 +1,120 @@
+package health
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/vcto/mcp-adapters/internal/config"
+)
+
+// DefaultMaxStale is how old a fallback copy may be before it is no longer served
+const DefaultMaxStale = 24 * time.Hour
+
+// upstreamError marks an error as an upstream outage rather than a client error
+type upstreamError struct {
+	err error
+}
+
+func (e *upstreamError) Error() string { return e.err.Error() }
+func (e *upstreamError) Unwrap() error { return e.err }
+
+// Upstream marks err as caused by the upstream API being unavailable
+// (network failure, timeout or 5xx). Nil stays nil.
+func Upstream(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &upstreamError{err: err}
+}
+
+// IsUpstream reports whether err was caused by the upstream API being unavailable
+func IsUpstream(err error) bool {
+	var upstream *upstreamError
+	return errors.As(err, &upstream) || errors.Is(err, ErrCircuitOpen)
+}
+
+// Staleness describes a last-known-good copy served in place of live data
+type Staleness struct {
+	Stale      bool      `json:"stale"`
+	FetchedAt  time.Time `json:"fetched_at"`
+	AgeSeconds int64     `json:"age_seconds"`
+	Reason     string    `json:"reason"`
+}
+
+// FallbackCache keeps the last successful result per key so it can be served,
+// marked stale, while the upstream API is down
+type FallbackCache struct {
+	mu       sync.Mutex
+	maxStale time.Duration
+	entries  map[string]fallbackEntry
+}
+
+type fallbackEntry struct {
+	value     any
+	fetchedAt time.Time
+}
+
+// NewFallbackCache creates a cache that serves copies up to maxStale old.
+// A maxStale of zero or less disables fallbacks.
+func NewFallbackCache(maxStale time.Duration) *FallbackCache {
+	return &FallbackCache{
+		maxStale: maxStale,
+		entries:  make(map[string]fallbackEntry),
+	}
+}
+
+// MaxStaleFromEnv reads a staleness limit such as "6h" from the environment,
+// returning def when the variable is unset or invalid. "0" disables fallbacks.
+func MaxStaleFromEnv(key string, def time.Duration) time.Duration {
+	return config.DurationFromEnv(key, def)
+}
+
+// Fetch calls fetch and remembers its result under key. When fetch fails
+// because the upstream is down and a copy younger than the staleness limit
+// exists, that copy is returned with a non-nil Staleness instead of the error.
+func Fetch[T any](c *FallbackCache, key string, fetch func() (T, error)) (T, *Staleness, error) {
+	value, err := fetch()
+	if err == nil {
+		c.mu.Lock()
+		c.entries[key] = fallbackEntry{value: value, fetchedAt: time.Now()}
+		c.mu.Unlock()
+		return value, nil, nil
+	}
+
+	if !IsUpstream(err) {
+		return value, nil, err
+	}
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+
+	age := time.Since(entry.fetchedAt)
+	if !ok || c.maxStale <= 0 || age > c.maxStale {
+		return value, nil, err
+	}
+	cached, ok := entry.value.(T)
+	if !ok {
+		return value, nil, err
+	}
+
+	return cached, &Staleness{
+		Stale:      true,
+		FetchedAt:  entry.fetchedAt,
+		AgeSeconds: int64(age.Seconds()),
+		Reason:     err.Error(),
+	}, nil
+}
+
+// CacheState reports the freshness of the copy stored under key
+func (c *FallbackCache) CacheState(name, key string) CacheState {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+
+	if !ok {
+		return NewCacheState(name, time.Time{})
+	}
+	return NewCacheState(name, entry.fetchedAt)
+}
@@ -0,0 +1,77 @@
+package health
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFallbackCache(t *testing.T) {
+	t.Logf("Importance: Key resources should keep answering with clearly marked stale data during upstream outages instead of failing outright.")
+
+	t.Run("serves stale copy on upstream failure", func(t *testing.T) {
+		t.Logf("  > Why it's important: Agents can keep working from the last-known-good data and see how old it is.")
+		cache := NewFallbackCache(time.Hour)
+		if _, _, err := Fetch(cache, "k", func() ([]string, error) { return []string{"a", "b"}, nil }); err != nil {
+			t.Fatalf("Initial fetch failed: %v", err)
+		}
+
+		value, stale, err := Fetch(cache, "k", func() ([]string, error) {
+			return nil, Upstream(errors.New("connection refused"))
+		})
+		if err != nil {
+			t.Fatalf("Expected fallback instead of error, got %v", err)
+		}
+		if stale == nil || !stale.Stale || stale.Reason != "connection refused" {
+			t.Errorf("Expected stale marker with reason, got %+v", stale)
+		}
+		if len(value) != 2 {
+			t.Errorf("Expected cached value, got %v", value)
+		}
+	})
+
+	t.Run("does not mask client errors", func(t *testing.T) {
+		t.Logf("  > Why it's important: Auth failures and bad requests must surface, not be hidden behind old data.")
+		cache := NewFallbackCache(time.Hour)
+		_, _, _ = Fetch(cache, "k", func() (int, error) { return 1, nil })
+
+		if _, stale, err := Fetch(cache, "k", func() (int, error) { return 0, errors.New("invalid auth token") }); err == nil || stale != nil {
+			t.Errorf("Expected client error to be returned, got stale=%+v err=%v", stale, err)
+		}
+	})
+
+	t.Run("respects staleness limit", func(t *testing.T) {
+		t.Logf("  > Why it's important: Data older than the configured limit is too old to be trusted.")
+		cache := NewFallbackCache(time.Hour)
+		cache.entries["k"] = fallbackEntry{value: 1, fetchedAt: time.Now().Add(-2 * time.Hour)}
+
+		if _, _, err := Fetch(cache, "k", func() (int, error) { return 0, ErrCircuitOpen }); err == nil {
+			t.Error("Expected error when cached copy is older than the limit")
+		}
+
+		disabled := NewFallbackCache(0)
+		_, _, _ = Fetch(disabled, "k", func() (int, error) { return 1, nil })
+		if _, _, err := Fetch(disabled, "k", func() (int, error) { return 0, ErrCircuitOpen }); err == nil {
+			t.Error("Expected error when fallbacks are disabled")
+		}
+	})
+}
+
+func TestMaxStaleFromEnv(t *testing.T) {
+	t.Logf("Importance: Operators tune how stale a fallback may be without code changes.")
+
+	t.Setenv("TEST_MAX_STALE", "90m")
+	if got := MaxStaleFromEnv("TEST_MAX_STALE", time.Hour); got != 90*time.Minute {
+		t.Errorf("Expected 90m, got %v", got)
+	}
+
+	t.Setenv("TEST_MAX_STALE", "soon")
+	if got := MaxStaleFromEnv("TEST_MAX_STALE", time.Hour); got != time.Hour {
+		t.Errorf("Expected default for invalid value, got %v", got)
+	}
+
+	t.Setenv("TEST_MAX_STALE", "0")
+	if got := MaxStaleFromEnv("TEST_MAX_STALE", time.Hour); got != 0 {
+		t.Errorf("Expected 0 to disable fallbacks, got %v", got)
+	}
+}
@@ -0,0 +1,81 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// RetryMetaKey is the tool result _meta key holding a RetryHint
+const RetryMetaKey = "retry"
+
+// Suggested waits before retrying a transient failure
+const (
+	// circuitBackoff matches the breaker's default cooldown
+	circuitBackoff  = defaultCooldown
+	upstreamBackoff = 2 * time.Second
+)
+
+// RetryHint tells clients whether repeating a failed tool call may succeed
+// and how long to wait first
+type RetryHint struct {
+	Retryable bool   `json:"retryable"`
+	BackoffMs int64  `json:"backoff_ms,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// Hinter is implemented by errors that know whether retrying can succeed,
+// such as API errors that need the user to sign in again
+type Hinter interface {
+	RetryHint() (RetryHint, bool)
+}
+
+// ToolError builds an error result for message, marking it retryable when
+// err shows the upstream API is unavailable. Calls that ran out of the
+// client's time budget are not retryable, nor are errors whose Hinter says so.
+func ToolError(message string, err error) *mcp.CallToolResult {
+	result := mcp.NewToolResultError(message)
+	var hinter Hinter
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		SetRetryHint(result, RetryHint{Reason: "request deadline exceeded"})
+	case errors.Is(err, ErrCircuitOpen):
+		SetRetryHint(result, RetryHint{Retryable: true, BackoffMs: circuitBackoff.Milliseconds(), Reason: "circuit open"})
+	case IsUpstream(err):
+		SetRetryHint(result, RetryHint{Retryable: true, BackoffMs: upstreamBackoff.Milliseconds(), Reason: "upstream unavailable"})
+	case errors.As(err, &hinter):
+		if hint, ok := hinter.RetryHint(); ok {
+			SetRetryHint(result, hint)
+		}
+	}
+	return result
+}
+
+// SetRetryHint attaches hint to result's _meta
+func SetRetryHint(result *mcp.CallToolResult, hint RetryHint) {
+	if result.Meta == nil {
+		result.Meta = make(map[string]any)
+	}
+	result.Meta[RetryMetaKey] = hint
+}
+
+// GetRetryHint returns the hint attached to result, if any. Results relayed
+// through JSON, such as those from call_grouped, carry the hint as a map.
+func GetRetryHint(result *mcp.CallToolResult) (RetryHint, bool) {
+	switch value := result.Meta[RetryMetaKey].(type) {
+	case RetryHint:
+		return value, true
+	case map[string]any:
+		var hint RetryHint
+		data, err := json.Marshal(value)
+		if err != nil || json.Unmarshal(data, &hint) != nil {
+			return RetryHint{}, false
+		}
+		return hint, true
+	default:
+		return RetryHint{}, false
+	}
+}
@@ -0,0 +1,49 @@
+package health
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestToolError(t *testing.T) {
+	t.Logf("Importance: Agents retry automatically only when the error says the failure was transient.")
+
+	cases := map[string]struct {
+		err       error
+		retryable bool
+	}{
+		"upstream outage": {Upstream(errors.New("503")), true},
+		"circuit open":    {fmt.Errorf("RTM call: %w", ErrCircuitOpen), true},
+		"bad input":       {errors.New("invalid list"), false},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			result := ToolError("Failed: "+tc.err.Error(), tc.err)
+			if !result.IsError {
+				t.Error("Expected an error result")
+			}
+			hint, ok := GetRetryHint(result)
+			if ok != tc.retryable || hint.Retryable != tc.retryable {
+				t.Errorf("Expected retryable=%v, got %+v (present=%v)", tc.retryable, hint, ok)
+			}
+			if tc.retryable && hint.BackoffMs <= 0 {
+				t.Errorf("Expected a backoff for transient failures, got %+v", hint)
+			}
+		})
+	}
+}
+
+func TestGetRetryHintFromJSON(t *testing.T) {
+	t.Logf("Importance: Hints relayed through call_grouped arrive as decoded JSON and must still be recognized.")
+
+	result := mcp.NewToolResultError("down")
+	result.Meta = map[string]any{RetryMetaKey: map[string]any{"retryable": true, "backoff_ms": float64(2000)}}
+
+	hint, ok := GetRetryHint(result)
+	if !ok || !hint.Retryable || hint.BackoffMs != 2000 {
+		t.Errorf("Expected decoded hint, got %+v (present=%v)", hint, ok)
+	}
+}
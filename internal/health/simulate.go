@@ -0,0 +1,128 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Simulated outage modes
+const (
+	// OutageErrors fails every upstream call, bypassing fallback caches
+	OutageErrors = "errors"
+	// OutageStale makes the upstream look unreachable, so last-known-good
+	// copies are served where they exist
+	OutageStale = "stale"
+)
+
+// OutageToolName is the admin tool that starts and ends simulated outages
+const OutageToolName = "simulate_outage"
+
+// maxOutageMinutes caps how long a simulated outage can run
+const maxOutageMinutes = 240
+
+// ErrSimulatedOutage is returned by Allow while an outage is being simulated
+var ErrSimulatedOutage = errors.New("simulated outage: upstream API is unavailable")
+
+// SimulatedOutage describes an outage started with simulate_outage
+type SimulatedOutage struct {
+	Mode  string    `json:"mode"`
+	Until time.Time `json:"until"`
+}
+
+// OutageSimulationEnabled reports whether MCP_OUTAGE_SIMULATION allows the
+// simulate_outage tool to be registered
+func OutageSimulationEnabled() bool {
+	return os.Getenv("MCP_OUTAGE_SIMULATION") == "true"
+}
+
+// Simulate makes Allow fail in the given mode for d. A d of zero or less
+// ends any simulated outage.
+func (b *Breaker) Simulate(mode string, d time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if d <= 0 {
+		b.simulation = nil
+		return
+	}
+	b.simulation = &SimulatedOutage{Mode: mode, Until: b.now().Add(d)}
+}
+
+// simulated returns the error for an active simulated outage, clearing it
+// once it has expired; callers must hold b.mu
+func (b *Breaker) simulated() error {
+	if b.simulation == nil {
+		return nil
+	}
+	if !b.now().Before(b.simulation.Until) {
+		b.simulation = nil
+		return nil
+	}
+	if b.simulation.Mode == OutageStale {
+		return Upstream(ErrSimulatedOutage)
+	}
+	return ErrSimulatedOutage
+}
+
+// OutageTarget is implemented by adapter handlers whose upstream calls can
+// be failed on demand
+type OutageTarget interface {
+	Reporter
+	UpstreamBreaker() *Breaker
+}
+
+// SetupOutageTool registers simulate_outage for the given adapters, letting
+// teams test how their workflows cope with upstream incidents
+func SetupOutageTool(s *server.MCPServer, targets ...OutageTarget) {
+	breakers := make(map[string]*Breaker, len(targets))
+	names := make([]string, 0, len(targets))
+	for _, target := range targets {
+		name := target.AdapterStatus().Name
+		if breaker := target.UpstreamBreaker(); breaker != nil {
+			breakers[name] = breaker
+			names = append(names, name)
+		}
+	}
+
+	s.AddTool(mcp.NewTool(OutageToolName,
+		mcp.WithDescription("Admin: simulate an upstream outage for an adapter. In 'errors' mode its tools fail; in 'stale' mode cached copies are served marked stale. Pass minutes=0 to end the simulation early."),
+		mcp.WithString("adapter", mcp.Required(), mcp.Description("Adapter to affect: "+strings.Join(names, ", "))),
+		mcp.WithString("mode", mcp.Description("'errors' (default) or 'stale'")),
+		mcp.WithNumber("minutes", mcp.Description(fmt.Sprintf("How long the outage lasts, up to %d (default: 5, 0 ends it)", maxOutageMinutes))),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		adapter := request.GetString("adapter", "")
+		breaker, ok := breakers[adapter]
+		if !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("Unknown adapter '%s'. Available: %s", adapter, strings.Join(names, ", "))), nil
+		}
+
+		mode := request.GetString("mode", OutageErrors)
+		if mode != OutageErrors && mode != OutageStale {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid mode '%s': use '%s' or '%s'", mode, OutageErrors, OutageStale)), nil
+		}
+
+		minutes := request.GetFloat("minutes", 5)
+		if minutes < 0 || minutes > maxOutageMinutes {
+			return mcp.NewToolResultError(fmt.Sprintf("minutes must be between 0 and %d", maxOutageMinutes)), nil
+		}
+
+		breaker.Simulate(mode, time.Duration(minutes*float64(time.Minute)))
+
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"adapter": adapter,
+			"circuit": breaker.Snapshot(),
+		}, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError("Failed to format outage status"), nil
+		}
+		return mcp.NewToolResultText(string(data)), nil
+	})
+}
@@ -0,0 +1,76 @@
+package health
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSimulatedOutage(t *testing.T) {
+	t.Logf("Importance: Teams rehearse upstream incidents with simulate_outage instead of waiting for a real one.")
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	newBreaker := func() *Breaker {
+		b := NewBreaker(2, time.Minute)
+		b.now = func() time.Time { return now }
+		return b
+	}
+
+	t.Run("errors mode bypasses fallbacks", func(t *testing.T) {
+		t.Logf("  > Why it's important: Agents must see hard failures, not cached copies, in errors mode.")
+		b := newBreaker()
+		b.Simulate(OutageErrors, 5*time.Minute)
+
+		err := b.Allow()
+		if !errors.Is(err, ErrSimulatedOutage) {
+			t.Fatalf("Expected ErrSimulatedOutage, got %v", err)
+		}
+		if IsUpstream(err) {
+			t.Error("Expected errors mode not to trigger stale fallbacks")
+		}
+	})
+
+	t.Run("stale mode serves fallbacks", func(t *testing.T) {
+		t.Logf("  > Why it's important: Stale mode exercises the last-known-good path agents rely on during outages.")
+		b := newBreaker()
+		cache := NewFallbackCache(time.Hour)
+		fetch := func() (string, error) {
+			if err := b.Allow(); err != nil {
+				return "", err
+			}
+			return "live", nil
+		}
+		if _, _, err := Fetch(cache, "key", fetch); err != nil {
+			t.Fatalf("Expected live fetch, got %v", err)
+		}
+
+		b.Simulate(OutageStale, 5*time.Minute)
+		value, staleness, err := Fetch(cache, "key", fetch)
+		if err != nil || value != "live" || staleness == nil {
+			t.Errorf("Expected stale copy, got %q, %+v, %v", value, staleness, err)
+		}
+	})
+
+	t.Run("expires and clears", func(t *testing.T) {
+		t.Logf("  > Why it's important: A forgotten simulation must not leave an adapter broken.")
+		b := newBreaker()
+		b.Simulate(OutageErrors, 5*time.Minute)
+		if state := b.Snapshot(); state.SimulatedOutage == nil || state.SimulatedOutage.Mode != OutageErrors {
+			t.Errorf("Expected simulation in snapshot, got %+v", state)
+		}
+
+		now = now.Add(5 * time.Minute)
+		if err := b.Allow(); err != nil {
+			t.Errorf("Expected simulation to expire, got %v", err)
+		}
+		if state := b.Snapshot(); state.SimulatedOutage != nil || state.State != StateClosed {
+			t.Errorf("Expected closed circuit without simulation, got %+v", state)
+		}
+
+		b.Simulate(OutageStale, time.Minute)
+		b.Simulate(OutageStale, 0)
+		if err := b.Allow(); err != nil {
+			t.Errorf("Expected simulation to be cleared, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// AdapterStatus describes the health of a single adapter
+type AdapterStatus struct {
+	Name          string       `json:"name"`
+	Authenticated bool         `json:"authenticated"`
+	AuthDetail    string       `json:"auth_detail,omitempty"`
+	Circuit       BreakerState `json:"circuit"`
+	Caches        []CacheState `json:"caches"`
+	// Warmup is how the adapter's deferred initialization went, empty
+	// until it has run
+	Warmup string `json:"warmup,omitempty"`
+}
+
+// Warmup states
+const (
+	WarmupWarmed    = "warmed"     // Credentials checked and caches filled
+	WarmupNotWarmed = "not_warmed" // Nothing to warm with yet, such as no auth token
+	WarmupFailed    = "failed"     // The last attempt failed; it is retried on next use
+)
+
+// CacheState describes how fresh one of an adapter's caches is
+type CacheState struct {
+	Name       string     `json:"name"`
+	Populated  bool       `json:"populated"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+	AgeSeconds int64      `json:"age_seconds,omitempty"`
+}
+
+// NewCacheState builds a CacheState from the time the cache was last filled.
+// A zero updatedAt means the cache is empty.
+func NewCacheState(name string, updatedAt time.Time) CacheState {
+	if updatedAt.IsZero() {
+		return CacheState{Name: name}
+	}
+	return CacheState{
+		Name:       name,
+		Populated:  true,
+		UpdatedAt:  &updatedAt,
+		AgeSeconds: int64(time.Since(updatedAt).Seconds()),
+	}
+}
+
+// Reporter is implemented by adapter handlers that can describe their health
+type Reporter interface {
+	AdapterStatus() AdapterStatus
+}
+
+// SetupStatusTool registers the adapter_status tool reporting on the given adapters
+func SetupStatusTool(s *server.MCPServer, reporters ...Reporter) {
+	s.AddTool(mcp.NewTool("adapter_status",
+		mcp.WithDescription("Report each enabled adapter's auth state, circuit-breaker state, cache freshness, and last error. Use this to diagnose why an adapter's tools are failing."),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		adapters := make([]AdapterStatus, 0, len(reporters))
+		for _, r := range reporters {
+			adapters = append(adapters, r.AdapterStatus())
+		}
+
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"adapters":     adapters,
+			"generated_at": time.Now().UTC(),
+		}, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError("Failed to format adapter status"), nil
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: string(data),
+				},
+			},
+		}, nil
+	})
+}
@@ -0,0 +1,121 @@
+// Package kv provides small persistent key-value storage for server state
+// such as saved searches, preferences, idempotency keys and intent logs.
+// Keys live in named buckets and may expire after a TTL.
+package kv
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// ErrClosed is returned by operations on a closed store
+var ErrClosed = errors.New("kv: store is closed")
+
+// Store is a bucketed key-value store. A zero TTL means the entry never expires.
+type Store interface {
+	// Get returns the value for key, reporting whether it exists and has not expired
+	Get(bucket, key string) ([]byte, bool, error)
+	// Put stores value under key, replacing any existing value
+	Put(bucket, key string, value []byte, ttl time.Duration) error
+	// PutIfAbsent stores value under key only when no unexpired value
+	// exists, reporting whether it did. The check and the write are one
+	// atomic step, even across instances sharing the store.
+	PutIfAbsent(bucket, key string, value []byte, ttl time.Duration) (bool, error)
+	// Delete removes key; deleting a missing key is not an error
+	Delete(bucket, key string) error
+	// Keys lists the unexpired keys in bucket in sorted order
+	Keys(bucket string) ([]string, error)
+	// Close releases the store's resources
+	Close() error
+}
+
+// Open returns a SQLite store at path, or an in-memory store when path is empty
+func Open(path string) (Store, error) {
+	if path == "" {
+		return NewMemoryStore(), nil
+	}
+	return NewSQLiteStore(path)
+}
+
+// OpenFromEnv opens the store configured by KV_DB_PATH, falling back to
+// memory when it is unset or cannot be opened
+func OpenFromEnv() Store {
+	path := os.Getenv("KV_DB_PATH")
+	if path == "" {
+		log.Println("Using in-memory kv store (set KV_DB_PATH for persistence)")
+		return NewMemoryStore()
+	}
+
+	store, err := NewSQLiteStore(path)
+	if err != nil {
+		log.Printf("Failed to open kv store at %s: %v, falling back to in-memory", path, err)
+		return NewMemoryStore()
+	}
+	log.Printf("Using SQLite kv store at %s", path)
+	return store
+}
+
+// Bucket is a typed view of one bucket, storing values as JSON
+type Bucket[T any] struct {
+	store Store
+	name  string
+}
+
+// NewBucket returns a typed view of the named bucket
+func NewBucket[T any](store Store, name string) *Bucket[T] {
+	return &Bucket[T]{store: store, name: name}
+}
+
+// Get decodes the value for key, reporting whether it exists
+func (b *Bucket[T]) Get(key string) (T, bool, error) {
+	var value T
+	data, ok, err := b.store.Get(b.name, key)
+	if err != nil || !ok {
+		return value, false, err
+	}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return value, false, fmt.Errorf("kv: decoding %s/%s: %w", b.name, key, err)
+	}
+	return value, true, nil
+}
+
+// Put encodes and stores value under key
+func (b *Bucket[T]) Put(key string, value T, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("kv: encoding %s/%s: %w", b.name, key, err)
+	}
+	return b.store.Put(b.name, key, data, ttl)
+}
+
+// PutIfAbsent encodes and stores value under key unless it already exists,
+// reporting whether it did
+func (b *Bucket[T]) PutIfAbsent(key string, value T, ttl time.Duration) (bool, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return false, fmt.Errorf("kv: encoding %s/%s: %w", b.name, key, err)
+	}
+	return b.store.PutIfAbsent(b.name, key, data, ttl)
+}
+
+// Delete removes key
+func (b *Bucket[T]) Delete(key string) error {
+	return b.store.Delete(b.name, key)
+}
+
+// Keys lists the bucket's unexpired keys
+func (b *Bucket[T]) Keys() ([]string, error) {
+	return b.store.Keys(b.name)
+}
+
+// expiry converts a TTL into an absolute expiry, zero for no expiry
+func expiry(now time.Time, ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return now.Add(ttl)
+}
@@ -0,0 +1,238 @@
+package kv
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/vcto/mcp-adapters/internal/atrest"
+)
+
+// testStores returns each backend and a function moving their clocks forward
+func testStores(t *testing.T) (map[string]Store, func(time.Duration)) {
+	t.Helper()
+
+	now := time.Now()
+	memory := NewMemoryStore()
+	memory.now = func() time.Time { return now }
+
+	sqlite, err := NewSQLiteStore(filepath.Join(t.TempDir(), "kv.db"))
+	if err != nil {
+		t.Fatalf("Failed to open SQLite store: %v", err)
+	}
+	sqlite.now = func() time.Time { return now }
+	t.Cleanup(func() {
+		_ = sqlite.Close()
+	})
+
+	server := miniredis.RunT(t)
+	redis, err := NewRedisStore("redis://" + server.Addr())
+	if err != nil {
+		t.Fatalf("Failed to open Redis store: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = redis.Close()
+	})
+
+	advance := func(d time.Duration) {
+		now = now.Add(d)
+		server.FastForward(d)
+	}
+	return map[string]Store{"memory": memory, "sqlite": sqlite, "redis": redis}, advance
+}
+
+func TestStore(t *testing.T) {
+	t.Logf("Importance: Subsystems share this store for small state, so every backend must behave identically.")
+
+	stores, advance := testStores(t)
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("  > Why it's important: Switching KV_DB_PATH on or off, or moving to Redis, must not change behavior.")
+
+			if err := store.Put("prefs", "theme", []byte("dark"), 0); err != nil {
+				t.Fatalf("Put failed: %v", err)
+			}
+			if err := store.Put("prefs", "lang", []byte("en"), time.Minute); err != nil {
+				t.Fatalf("Put failed: %v", err)
+			}
+			if err := store.Put("other", "theme", []byte("light"), 0); err != nil {
+				t.Fatalf("Put failed: %v", err)
+			}
+
+			value, ok, err := store.Get("prefs", "theme")
+			if err != nil || !ok || string(value) != "dark" {
+				t.Errorf("Expected dark, got %q (ok=%v, err=%v)", value, ok, err)
+			}
+			if _, ok, _ := store.Get("prefs", "missing"); ok {
+				t.Error("Expected missing key to be absent")
+			}
+
+			keys, err := store.Keys("prefs")
+			if err != nil || len(keys) != 2 || keys[0] != "lang" || keys[1] != "theme" {
+				t.Errorf("Expected sorted keys [lang theme], got %v (err=%v)", keys, err)
+			}
+
+			advance(time.Minute)
+			if _, ok, _ := store.Get("prefs", "lang"); ok {
+				t.Error("Expected lang to expire after its TTL")
+			}
+			if keys, _ := store.Keys("prefs"); len(keys) != 1 {
+				t.Errorf("Expected expired keys to be hidden, got %v", keys)
+			}
+
+			if err := store.Delete("prefs", "theme"); err != nil {
+				t.Fatalf("Delete failed: %v", err)
+			}
+			if _, ok, _ := store.Get("prefs", "theme"); ok {
+				t.Error("Expected deleted key to be absent")
+			}
+			if value, ok, _ := store.Get("other", "theme"); !ok || string(value) != "light" {
+				t.Errorf("Expected buckets to be independent, got %q", value)
+			}
+		})
+	}
+}
+
+func TestPutIfAbsent(t *testing.T) {
+	t.Logf("Importance: One-time values such as redeemed authorization codes rely on exactly one writer winning.")
+
+	stores, advance := testStores(t)
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("  > Why it's important: Instances racing on a shared backend must agree on who stored the value.")
+
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			winners := 0
+			for i := 0; i < 8; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					ok, err := store.PutIfAbsent("claims", "code", []byte(strconv.Itoa(i)), time.Minute)
+					if err != nil {
+						t.Errorf("PutIfAbsent failed: %v", err)
+					}
+					if ok {
+						mu.Lock()
+						winners++
+						mu.Unlock()
+					}
+				}(i)
+			}
+			wg.Wait()
+			if winners != 1 {
+				t.Errorf("Expected exactly one writer to win, got %d", winners)
+			}
+
+			advance(time.Minute)
+			if ok, err := store.PutIfAbsent("claims", "code", []byte("again"), 0); err != nil || !ok {
+				t.Errorf("Expected an expired value replaced, got ok=%v err=%v", ok, err)
+			}
+			if ok, _ := store.PutIfAbsent("claims", "code", []byte("third"), 0); ok {
+				t.Error("Expected a value without expiry kept")
+			}
+			if value, _, _ := store.Get("claims", "code"); string(value) != "again" {
+				t.Errorf("Expected the winning value stored, got %q", value)
+			}
+		})
+	}
+}
+
+func TestSQLiteStorePersists(t *testing.T) {
+	t.Logf("Importance: State such as intent logs must survive restarts.")
+
+	path := filepath.Join(t.TempDir(), "nested", "kv.db")
+	store, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	if err := store.Put("searches", "work", []byte("list:Work"), 0); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := store.Put("idempotency", "req-1", []byte("{}"), time.Nanosecond); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	reopened, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer func() {
+		_ = reopened.Close()
+	}()
+
+	if value, ok, _ := reopened.Get("searches", "work"); !ok || string(value) != "list:Work" {
+		t.Errorf("Expected value to persist, got %q (ok=%v)", value, ok)
+	}
+	if removed, err := reopened.CleanupExpired(); err != nil || removed != 1 {
+		t.Errorf("Expected one expired entry removed, got %d (err=%v)", removed, err)
+	}
+}
+
+func TestSQLiteStoreEncryption(t *testing.T) {
+	t.Logf("Importance: With storage encryption configured, values must never reach disk in plaintext.")
+
+	path := filepath.Join(t.TempDir(), "kv.db")
+	store, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	defer func() {
+		_ = store.Close()
+	}()
+	store.keyring, _ = atrest.NewKeyring("test-key")
+
+	if err := store.Put("prefs", "token", []byte("secret-value"), 0); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	var raw string
+	if err := store.db.QueryRow(`SELECT value FROM kv WHERE bucket = 'prefs' AND key = 'token'`).Scan(&raw); err != nil {
+		t.Fatalf("Failed to read raw value: %v", err)
+	}
+	if !atrest.IsEncrypted(raw) || strings.Contains(raw, "secret-value") {
+		t.Errorf("Expected encrypted value on disk, got %q", raw)
+	}
+
+	if value, ok, err := store.Get("prefs", "token"); err != nil || !ok || string(value) != "secret-value" {
+		t.Errorf("Expected decrypted value, got %q (ok=%v, err=%v)", value, ok, err)
+	}
+}
+
+func TestBucket(t *testing.T) {
+	t.Logf("Importance: Typed buckets spare each subsystem from hand-rolling JSON encoding.")
+
+	type search struct {
+		Query string `json:"query"`
+		Count int    `json:"count"`
+	}
+
+	searches := NewBucket[search](NewMemoryStore(), "searches")
+	if err := searches.Put("work", search{Query: "list:Work", Count: 3}, 0); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	got, ok, err := searches.Get("work")
+	if err != nil || !ok || got.Query != "list:Work" || got.Count != 3 {
+		t.Errorf("Unexpected value %+v (ok=%v, err=%v)", got, ok, err)
+	}
+
+	if _, ok, err := searches.Get("missing"); ok || err != nil {
+		t.Errorf("Expected missing key without error, got ok=%v err=%v", ok, err)
+	}
+
+	store := NewMemoryStore()
+	if err := store.Put("searches", "bad", []byte("not json"), 0); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if _, _, err := NewBucket[search](store, "searches").Get("bad"); err == nil {
+		t.Error("Expected decode error for malformed value")
+	}
+}
@@ -0,0 +1,134 @@
+package kv
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+func (e memoryEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// MemoryStore keeps entries in memory; state is lost on restart
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]map[string]memoryEntry
+	closed  bool
+	now     func() time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]map[string]memoryEntry),
+		now:     time.Now,
+	}
+}
+
+// Get implements Store
+func (s *MemoryStore) Get(bucket, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, false, ErrClosed
+	}
+	entry, ok := s.buckets[bucket][key]
+	if !ok {
+		return nil, false, nil
+	}
+	if entry.expired(s.now()) {
+		delete(s.buckets[bucket], key)
+		return nil, false, nil
+	}
+	return append([]byte{}, entry.value...), true, nil
+}
+
+// Put implements Store
+func (s *MemoryStore) Put(bucket, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+	entries, ok := s.buckets[bucket]
+	if !ok {
+		entries = make(map[string]memoryEntry)
+		s.buckets[bucket] = entries
+	}
+	entries[key] = memoryEntry{
+		value:     append([]byte{}, value...),
+		expiresAt: expiry(s.now(), ttl),
+	}
+	return nil
+}
+
+// PutIfAbsent implements Store
+func (s *MemoryStore) PutIfAbsent(bucket, key string, value []byte, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return false, ErrClosed
+	}
+	now := s.now()
+	entries, ok := s.buckets[bucket]
+	if !ok {
+		entries = make(map[string]memoryEntry)
+		s.buckets[bucket] = entries
+	}
+	if entry, ok := entries[key]; ok && !entry.expired(now) {
+		return false, nil
+	}
+	entries[key] = memoryEntry{
+		value:     append([]byte{}, value...),
+		expiresAt: expiry(now, ttl),
+	}
+	return true, nil
+}
+
+// Delete implements Store
+func (s *MemoryStore) Delete(bucket, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+	delete(s.buckets[bucket], key)
+	return nil
+}
+
+// Keys implements Store
+func (s *MemoryStore) Keys(bucket string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, ErrClosed
+	}
+	now := s.now()
+	keys := make([]string, 0, len(s.buckets[bucket]))
+	for key, entry := range s.buckets[bucket] {
+		if !entry.expired(now) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Close implements Store
+func (s *MemoryStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
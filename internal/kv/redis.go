@@ -0,0 +1,213 @@
+package kv
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/vcto/mcp-adapters/internal/atrest"
+)
+
+// defaultRedisPrefix starts every key the store writes, so it can share a
+// Redis database with other applications
+const defaultRedisPrefix = "mcp:"
+
+// redisTimeout bounds each command, including reconnecting
+const redisTimeout = 5 * time.Second
+
+// RedisStore keeps entries in Redis, so several instances behind a load
+// balancer share them. Keys are prefix + bucket + ":" + key and expire
+// through Redis TTLs. Values are encrypted when storage encryption is
+// configured. Connections are pooled and re-dialled by go-redis.
+type RedisStore struct {
+	client  *redis.Client
+	prefix  string
+	keyring *atrest.Keyring
+}
+
+// NewRedisStore connects to the server at rawURL, such as
+// redis://:password@host:6379/0 or rediss:// for TLS. A prefix query
+// parameter replaces the default key prefix.
+func NewRedisStore(rawURL string) (*RedisStore, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("kv: redis URL: %w", err)
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return nil, fmt.Errorf("kv: redis URL must start with redis:// or rediss://")
+	}
+	prefix := defaultRedisPrefix
+	query := u.Query()
+	if p := query.Get("prefix"); p != "" {
+		prefix = p
+	}
+	// go-redis rejects query parameters it doesn't know
+	query.Del("prefix")
+	u.RawQuery = query.Encode()
+
+	opts, err := redis.ParseURL(u.String())
+	if err != nil {
+		return nil, fmt.Errorf("kv: redis URL: %w", err)
+	}
+	opts.DialTimeout = redisTimeout
+	opts.ReadTimeout = redisTimeout
+	opts.WriteTimeout = redisTimeout
+	if opts.TLSConfig != nil {
+		opts.TLSConfig.MinVersion = tls.VersionTLS12
+	}
+
+	keyring, err := atrest.Default()
+	if err != nil {
+		return nil, err
+	}
+	s := &RedisStore{client: redis.NewClient(opts), prefix: prefix, keyring: keyring}
+
+	// Fail now rather than on the first request when the server is unreachable
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := s.client.Ping(ctx).Err(); err != nil {
+		_ = s.client.Close()
+		return nil, fmt.Errorf("kv: redis: %w", err)
+	}
+	return s, nil
+}
+
+// Get implements Store
+func (s *RedisStore) Get(bucket, key string) ([]byte, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	value, err := s.client.Get(ctx, s.key(bucket, key)).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("kv: get %s/%s: %w", bucket, key, s.wrap(err))
+	}
+	plaintext, err := s.keyring.Decrypt(value)
+	if err != nil {
+		return nil, false, fmt.Errorf("kv: get %s/%s: %w", bucket, key, err)
+	}
+	return []byte(plaintext), true, nil
+}
+
+// Put implements Store
+func (s *RedisStore) Put(bucket, key string, value []byte, ttl time.Duration) error {
+	stored, err := s.seal(value)
+	if err != nil {
+		return fmt.Errorf("kv: put %s/%s: %w", bucket, key, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := s.client.Set(ctx, s.key(bucket, key), stored, redisTTL(ttl)).Err(); err != nil {
+		return fmt.Errorf("kv: put %s/%s: %w", bucket, key, s.wrap(err))
+	}
+	return nil
+}
+
+// PutIfAbsent implements Store with SET NX, which Redis applies atomically
+func (s *RedisStore) PutIfAbsent(bucket, key string, value []byte, ttl time.Duration) (bool, error) {
+	stored, err := s.seal(value)
+	if err != nil {
+		return false, fmt.Errorf("kv: put %s/%s: %w", bucket, key, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	ok, err := s.client.SetNX(ctx, s.key(bucket, key), stored, redisTTL(ttl)).Result()
+	if err != nil {
+		return false, fmt.Errorf("kv: put %s/%s: %w", bucket, key, s.wrap(err))
+	}
+	return ok, nil
+}
+
+// Delete implements Store
+func (s *RedisStore) Delete(bucket, key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := s.client.Del(ctx, s.key(bucket, key)).Err(); err != nil {
+		return fmt.Errorf("kv: delete %s/%s: %w", bucket, key, s.wrap(err))
+	}
+	return nil
+}
+
+// Keys implements Store, walking the bucket with SCAN so a large bucket
+// doesn't block the server
+func (s *RedisStore) Keys(bucket string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	prefix := s.key(bucket, "")
+	seen := make(map[string]bool)
+	iter := s.client.Scan(ctx, 0, escapeGlob(prefix)+"*", 1000).Iterator()
+	for iter.Next(ctx) {
+		// SCAN may return a key more than once
+		seen[strings.TrimPrefix(iter.Val(), prefix)] = true
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("kv: keys %s: %w", bucket, s.wrap(err))
+	}
+
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Close implements Store
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
+
+// key is the Redis key for bucket and key
+func (s *RedisStore) key(bucket, key string) string {
+	return s.prefix + bucket + ":" + key
+}
+
+// seal returns value as it is stored, encrypted when storage encryption is
+// configured
+func (s *RedisStore) seal(value []byte) (string, error) {
+	if !s.keyring.Enabled() {
+		return string(value), nil
+	}
+	return s.keyring.Encrypt(string(value))
+}
+
+// wrap reports commands on a closed store as ErrClosed
+func (s *RedisStore) wrap(err error) error {
+	if errors.Is(err, redis.ErrClosed) {
+		return ErrClosed
+	}
+	return err
+}
+
+// redisTTL converts a TTL to go-redis's expiration, which treats zero as
+// no expiry and rounds sub-millisecond TTLs down to it
+func redisTTL(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		return 0
+	}
+	return max(ttl, time.Millisecond)
+}
+
+// escapeGlob escapes the characters SCAN MATCH treats as patterns
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
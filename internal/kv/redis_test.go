@@ -0,0 +1,134 @@
+package kv
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/vcto/mcp-adapters/internal/atrest"
+)
+
+func TestRedisStore(t *testing.T) {
+	t.Logf("Importance: Instances behind a load balancer share OAuth state through Redis, so the client must survive real-world conditions.")
+
+	t.Run("reconnects", func(t *testing.T) {
+		t.Logf("  > Why it's important: Managed Redis drops idle connections; requests must not fail because of it.")
+		server := miniredis.RunT(t)
+		store, err := NewRedisStore("redis://" + server.Addr())
+		if err != nil {
+			t.Fatalf("NewRedisStore: %v", err)
+		}
+		defer store.Close()
+
+		if err := store.Put("sessions", "a", []byte("1"), 0); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+		server.Close()
+		if err := server.Restart(); err != nil {
+			t.Fatalf("Restart failed: %v", err)
+		}
+		if value, ok, err := store.Get("sessions", "a"); err != nil || !ok || string(value) != "1" {
+			t.Errorf("Expected the value after reconnecting, got %q (ok=%v, err=%v)", value, ok, err)
+		}
+	})
+
+	t.Run("authenticates", func(t *testing.T) {
+		t.Logf("  > Why it's important: Hosted Redis requires a password, taken from the URL.")
+		server := miniredis.RunT(t)
+		server.RequireAuth("hunter2")
+		if _, err := NewRedisStore("redis://" + server.Addr()); err == nil {
+			t.Error("Expected connecting without the password to fail")
+		}
+		store, err := NewRedisStore("redis://default:hunter2@" + server.Addr() + "/1")
+		if err != nil {
+			t.Fatalf("Expected the password accepted, got %v", err)
+		}
+		defer store.Close()
+		if err := store.Put("sessions", "a", []byte("1"), 0); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+		server.Select(1)
+		if !server.Exists("mcp:sessions:a") {
+			t.Error("Expected the key written to the database in the URL")
+		}
+	})
+
+	t.Run("prefix and encryption", func(t *testing.T) {
+		t.Logf("  > Why it's important: A shared Redis must not see other apps' keys or plaintext credentials.")
+		server := miniredis.RunT(t)
+		store, err := NewRedisStore("redis://" + server.Addr() + "?prefix=app1:")
+		if err != nil {
+			t.Fatalf("NewRedisStore: %v", err)
+		}
+		defer store.Close()
+		store.keyring, _ = atrest.NewKeyring("test-key")
+
+		if err := store.Put("oauth_tokens", "t1", []byte("secret-value"), 0); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+		raw, err := server.Get("app1:oauth_tokens:t1")
+		if err != nil || !atrest.IsEncrypted(raw) || strings.Contains(raw, "secret-value") {
+			t.Errorf("Expected an encrypted value under the prefix, got %q (err=%v)", raw, err)
+		}
+		if value, _, _ := store.Get("oauth_tokens", "t1"); string(value) != "secret-value" {
+			t.Errorf("Expected the decrypted value, got %q", value)
+		}
+	})
+
+	t.Run("sets TTLs in Redis", func(t *testing.T) {
+		t.Logf("  > Why it's important: Expiry is left to Redis, so entries must carry a TTL rather than live forever.")
+		server := miniredis.RunT(t)
+		store, err := NewRedisStore("redis://" + server.Addr())
+		if err != nil {
+			t.Fatalf("NewRedisStore: %v", err)
+		}
+		defer store.Close()
+
+		if _, err := store.PutIfAbsent("claims", "c", []byte("1"), time.Minute); err != nil {
+			t.Fatalf("PutIfAbsent failed: %v", err)
+		}
+		if ttl := server.TTL("mcp:claims:c"); ttl != time.Minute {
+			t.Errorf("Expected a one minute TTL, got %v", ttl)
+		}
+	})
+
+	t.Run("bad URL", func(t *testing.T) {
+		t.Logf("  > Why it's important: A mistyped URL must fail at startup, not on the first sign-in.")
+		for _, rawURL := range []string{"http://localhost:6379", "redis://localhost:6379/notanumber"} {
+			if _, err := NewRedisStore(rawURL); err == nil {
+				t.Errorf("Expected %s rejected", rawURL)
+			}
+		}
+	})
+}
+
+func TestRedisStoreAgainstServer(t *testing.T) {
+	t.Logf("Importance: The in-process server doesn't catch every difference from a real Redis; this runs the same checks against one.")
+	rawURL := os.Getenv("REDIS_TEST_URL")
+	if rawURL == "" {
+		t.Skip("REDIS_TEST_URL not set")
+	}
+
+	store, err := NewRedisStore(rawURL)
+	if err != nil {
+		t.Fatalf("NewRedisStore: %v", err)
+	}
+	defer store.Close()
+	store.prefix = "mcp-test:" + time.Now().Format("150405.000000") + ":"
+
+	if ok, err := store.PutIfAbsent("claims", "code", []byte("1"), time.Second); err != nil || !ok {
+		t.Fatalf("Expected the first claim stored, got ok=%v err=%v", ok, err)
+	}
+	if ok, _ := store.PutIfAbsent("claims", "code", []byte("2"), time.Second); ok {
+		t.Error("Expected the second claim refused")
+	}
+	if keys, err := store.Keys("claims"); err != nil || len(keys) != 1 || keys[0] != "code" {
+		t.Errorf("Expected [code], got %v (err=%v)", keys, err)
+	}
+	time.Sleep(1100 * time.Millisecond)
+	if _, ok, _ := store.Get("claims", "code"); ok {
+		t.Error("Expected the claim expired")
+	}
+}
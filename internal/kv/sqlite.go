@@ -0,0 +1,186 @@
+package kv
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/vcto/mcp-adapters/internal/atrest"
+)
+
+// EncryptionTarget lists the kv columns encrypted at rest, for migrations
+var EncryptionTarget = atrest.Target{Table: "kv", Columns: []string{"value"}}
+
+// SQLiteStore persists entries in a single SQLite table keyed by bucket and
+// key. Values are encrypted when storage encryption is configured.
+type SQLiteStore struct {
+	db      *sql.DB
+	keyring *atrest.Keyring
+	now     func() time.Time
+}
+
+// NewSQLiteStore opens or creates a store at dbPath
+func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
+	keyring, err := atrest.Default()
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure directory exists
+	if dir := filepath.Dir(dbPath); dir != "." && dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("create db directory: %w", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite3", dbPath+"?_journal_mode=WAL&_busy_timeout=5000")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS kv (
+			bucket TEXT NOT NULL,
+			key TEXT NOT NULL,
+			value BLOB NOT NULL,
+			expires_at INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (bucket, key)
+		);
+		CREATE INDEX IF NOT EXISTS idx_kv_expires ON kv(expires_at) WHERE expires_at > 0;
+	`)
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("create kv table: %w", err)
+	}
+
+	return &SQLiteStore{db: db, keyring: keyring, now: time.Now}, nil
+}
+
+// Get implements Store
+func (s *SQLiteStore) Get(bucket, key string) ([]byte, bool, error) {
+	var value []byte
+	err := s.db.QueryRow(
+		`SELECT value FROM kv WHERE bucket = ? AND key = ? AND (expires_at = 0 OR expires_at > ?)`,
+		bucket, key, s.now().UnixNano(),
+	).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("kv: get %s/%s: %w", bucket, key, err)
+	}
+
+	plaintext, err := s.keyring.Decrypt(string(value))
+	if err != nil {
+		return nil, false, fmt.Errorf("kv: get %s/%s: %w", bucket, key, err)
+	}
+	return []byte(plaintext), true, nil
+}
+
+// Put implements Store
+func (s *SQLiteStore) Put(bucket, key string, value []byte, ttl time.Duration) error {
+	expiresAt, value, err := s.seal(value, ttl)
+	if err != nil {
+		return fmt.Errorf("kv: put %s/%s: %w", bucket, key, err)
+	}
+
+	_, err = s.db.Exec(
+		`INSERT OR REPLACE INTO kv (bucket, key, value, expires_at) VALUES (?, ?, ?, ?)`,
+		bucket, key, value, expiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("kv: put %s/%s: %w", bucket, key, err)
+	}
+	return nil
+}
+
+// PutIfAbsent implements Store. An expired row is overwritten in the same
+// statement, so instances sharing the file can't both claim the key.
+func (s *SQLiteStore) PutIfAbsent(bucket, key string, value []byte, ttl time.Duration) (bool, error) {
+	expiresAt, value, err := s.seal(value, ttl)
+	if err != nil {
+		return false, fmt.Errorf("kv: put %s/%s: %w", bucket, key, err)
+	}
+
+	result, err := s.db.Exec(`
+		INSERT INTO kv (bucket, key, value, expires_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (bucket, key) DO UPDATE SET value = excluded.value, expires_at = excluded.expires_at
+		WHERE kv.expires_at > 0 AND kv.expires_at <= ?`,
+		bucket, key, value, expiresAt, s.now().UnixNano(),
+	)
+	if err != nil {
+		return false, fmt.Errorf("kv: put %s/%s: %w", bucket, key, err)
+	}
+	stored, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("kv: put %s/%s: %w", bucket, key, err)
+	}
+	return stored == 1, nil
+}
+
+// seal returns the expires_at column for ttl and value as it is stored,
+// encrypted when storage encryption is configured
+func (s *SQLiteStore) seal(value []byte, ttl time.Duration) (int64, []byte, error) {
+	var expiresAt int64
+	if at := expiry(s.now(), ttl); !at.IsZero() {
+		expiresAt = at.UnixNano()
+	}
+	if !s.keyring.Enabled() {
+		return expiresAt, value, nil
+	}
+	sealed, err := s.keyring.Encrypt(string(value))
+	if err != nil {
+		return 0, nil, err
+	}
+	return expiresAt, []byte(sealed), nil
+}
+
+// Delete implements Store
+func (s *SQLiteStore) Delete(bucket, key string) error {
+	if _, err := s.db.Exec(`DELETE FROM kv WHERE bucket = ? AND key = ?`, bucket, key); err != nil {
+		return fmt.Errorf("kv: delete %s/%s: %w", bucket, key, err)
+	}
+	return nil
+}
+
+// Keys implements Store
+func (s *SQLiteStore) Keys(bucket string) ([]string, error) {
+	rows, err := s.db.Query(
+		`SELECT key FROM kv WHERE bucket = ? AND (expires_at = 0 OR expires_at > ?) ORDER BY key`,
+		bucket, s.now().UnixNano(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("kv: keys %s: %w", bucket, err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	keys := []string{}
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("kv: keys %s: %w", bucket, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// CleanupExpired deletes expired entries, returning how many were removed
+func (s *SQLiteStore) CleanupExpired() (int64, error) {
+	result, err := s.db.Exec(`DELETE FROM kv WHERE expires_at > 0 AND expires_at <= ?`, s.now().UnixNano())
+	if err != nil {
+		return 0, fmt.Errorf("kv: cleanup: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// Close implements Store
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
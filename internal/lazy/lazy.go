@@ -0,0 +1,234 @@
+// Package lazy defers expensive adapter initialization, such as credential
+// validation and cache warming, until the first call to one of its tools.
+// Tools are still registered and listed up front.
+package lazy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/vcto/mcp-adapters/internal/health"
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrNotReady is returned by an initializer with nothing to work with yet,
+// such as no credentials configured. The adapter is reported as not warmed
+// and initialization runs again on the next call.
+var ErrNotReady = errors.New("nothing to initialize with yet")
+
+// Enabled reports whether adapters initialize on first use. MCP_LAZY_INIT=false
+// initializes them at startup instead.
+func Enabled() bool {
+	return os.Getenv("MCP_LAZY_INIT") != "false"
+}
+
+// Init runs an adapter's initialization once. A failed run is retried on the
+// next call so a brief upstream outage doesn't disable the adapter.
+type Init struct {
+	name string
+	fn   func(ctx context.Context) error
+	runs singleflight.Group
+
+	// mu guards the outcome only; it is never held while fn runs, so
+	// status checks answer while a slow upstream is being waited on
+	mu    sync.Mutex
+	done  bool
+	state string // One of the health.Warmup states, empty until run
+}
+
+// New creates an initializer for the named adapter
+func New(name string, fn func(ctx context.Context) error) *Init {
+	return &Init{name: name, fn: fn}
+}
+
+// Name returns the adapter name
+func (i *Init) Name() string {
+	return i.name
+}
+
+// Do runs the initialization unless it has already succeeded. Concurrent
+// callers share the run in progress. The run is detached from ctx's
+// cancellation, as other callers may be waiting on it; when ctx ends first
+// Do returns an error wrapping ErrNotReady and the run carries on in the
+// background, keeping its result.
+func (i *Init) Do(ctx context.Context) error {
+	if i.Done() {
+		return nil
+	}
+
+	result := i.runs.DoChan(i.name, func() (interface{}, error) {
+		if i.Done() {
+			return nil, nil
+		}
+		err := i.fn(context.WithoutCancel(ctx))
+
+		i.mu.Lock()
+		defer i.mu.Unlock()
+		switch {
+		case err == nil:
+			i.done = true
+			i.state = health.WarmupWarmed
+		case errors.Is(err, ErrNotReady):
+			i.state = health.WarmupNotWarmed
+		default:
+			i.state = health.WarmupFailed
+		}
+		return nil, err
+	})
+
+	select {
+	case r := <-result:
+		if r.Err != nil {
+			return fmt.Errorf("initializing %s: %w", i.name, r.Err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("initializing %s: %w: %w", i.name, ErrNotReady, ctx.Err())
+	}
+}
+
+// Done reports whether the initialization has succeeded
+func (i *Init) Done() bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.done
+}
+
+// State reports how the last initialization went, as one of the
+// health.Warmup states, or "" when it has not run
+func (i *Init) State() string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.state
+}
+
+// Report wraps reporter so the adapter's status includes its warmup state
+func (i *Init) Report(reporter health.Reporter) health.Reporter {
+	return initReporter{init: i, reporter: reporter}
+}
+
+type initReporter struct {
+	init     *Init
+	reporter health.Reporter
+}
+
+func (r initReporter) AdapterStatus() health.AdapterStatus {
+	status := r.reporter.AdapterStatus()
+	status.Warmup = r.init.State()
+	return status
+}
+
+// Registry maps tools to the initializer of the adapter that owns them
+type Registry struct {
+	mu     sync.RWMutex
+	inits  []*Init
+	byTool map[string]*Init
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{byTool: make(map[string]*Init)}
+}
+
+// Add registers an initializer to run before any of the given tools
+func (r *Registry) Add(init *Init, tools ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.inits = append(r.inits, init)
+	for _, tool := range tools {
+		r.byTool[tool] = init
+	}
+}
+
+// Middleware runs the owning adapter's initializer before each tool call.
+// A failed initialization is logged and the tool still runs, so tools that
+// cope with upstream outages keep working; it is retried on the next call.
+// A call whose context ends while the initializer is still running fails
+// with ErrNotReady rather than waiting on it.
+func (r *Registry) Middleware() server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			r.mu.RLock()
+			init := r.byTool[request.Params.Name]
+			r.mu.RUnlock()
+
+			if init != nil {
+				err := init.Do(ctx)
+				if err != nil && ctx.Err() != nil {
+					return nil, err
+				}
+				if err != nil && !errors.Is(err, ErrNotReady) {
+					log.Printf("Lazy init: %v", err)
+				}
+			}
+			return next(ctx, request)
+		}
+	}
+}
+
+// DefaultInitTimeout bounds each adapter's initialization at startup
+const DefaultInitTimeout = 10 * time.Second
+
+// InitTimeoutFromEnv reads the per-adapter startup budget such as "5s" from
+// MCP_INIT_TIMEOUT, returning DefaultInitTimeout when unset or invalid
+func InitTimeoutFromEnv() time.Duration {
+	value := os.Getenv("MCP_INIT_TIMEOUT")
+	if value == "" {
+		return DefaultInitTimeout
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("Invalid MCP_INIT_TIMEOUT %q, using default %s", value, DefaultInitTimeout)
+		return DefaultInitTimeout
+	}
+	return d
+}
+
+// InitAll runs every initializer concurrently, for deployments that prefer
+// paying the cost at startup. Each adapter gets its own timeout so one slow
+// upstream doesn't hold up the rest; an initializer still running when its
+// time is up carries on in the background. Failures are joined into the
+// returned error and retried on first use.
+func (r *Registry) InitAll(ctx context.Context, timeout time.Duration) error {
+	r.mu.RLock()
+	inits := append([]*Init{}, r.inits...)
+	r.mu.RUnlock()
+
+	errs := make([]error, len(inits))
+	var wg sync.WaitGroup
+	for idx, init := range inits {
+		wg.Add(1)
+		go func(idx int, init *Init) {
+			defer wg.Done()
+			errs[idx] = initWithin(ctx, init, timeout)
+		}(idx, init)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// initWithin runs init, giving up waiting after timeout. A run still going
+// when the wait ends finishes in the background and its result is kept.
+func initWithin(ctx context.Context, init *Init, timeout time.Duration) error {
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := init.Do(waitCtx)
+	switch {
+	case err == nil || waitCtx.Err() == nil:
+		return err
+	case ctx.Err() != nil:
+		return fmt.Errorf("initializing %s: %w, continuing in the background", init.Name(), ctx.Err())
+	default:
+		return fmt.Errorf("initializing %s: timed out after %s, continuing in the background", init.Name(), timeout)
+	}
+}
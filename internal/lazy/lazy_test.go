@@ -0,0 +1,285 @@
+package lazy
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/vcto/mcp-adapters/internal/health"
+)
+
+func callTool(r *Registry, name string) (*mcp.CallToolResult, error) {
+	handler := r.Middleware()(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ran " + request.Params.Name), nil
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = name
+	return handler(context.Background(), request)
+}
+
+func TestRegistryMiddleware(t *testing.T) {
+	t.Logf("Importance: Deferring adapter setup to first use cuts cold starts, but the setup must still run before the adapter's tools.")
+
+	t.Run("initializes once on first use", func(t *testing.T) {
+		t.Logf("  > Why it's important: Credential checks and cache warming should cost one upstream round trip, not one per call.")
+		runs := 0
+		r := NewRegistry()
+		init := New("demo", func(ctx context.Context) error {
+			runs++
+			return nil
+		})
+		r.Add(init, "demo_list", "demo_edit")
+
+		if _, err := callTool(r, "other_tool"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if runs != 0 {
+			t.Fatalf("Expected tools from other adapters not to initialize demo, got %d runs", runs)
+		}
+
+		for _, name := range []string{"demo_list", "demo_edit", "demo_list"} {
+			if _, err := callTool(r, name); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		}
+		if runs != 1 || !init.Done() {
+			t.Errorf("Expected a single successful run, got %d runs (done=%v)", runs, init.Done())
+		}
+	})
+
+	t.Run("retries failed initialization without blocking tools", func(t *testing.T) {
+		t.Logf("  > Why it's important: A brief outage at first use must not disable the adapter or block tools that handle outages themselves.")
+		runs := 0
+		r := NewRegistry()
+		init := New("demo", func(ctx context.Context) error {
+			runs++
+			if runs == 1 {
+				return errors.New("upstream unavailable")
+			}
+			return nil
+		})
+		r.Add(init, "demo_list")
+
+		result, err := callTool(r, "demo_list")
+		if err != nil || result.IsError {
+			t.Fatalf("Expected the tool to run despite the failed init, got %v / %+v", err, result)
+		}
+		if init.Done() {
+			t.Fatal("Expected init to remain pending after a failure")
+		}
+
+		if _, err := callTool(r, "demo_list"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if runs != 2 || !init.Done() {
+			t.Errorf("Expected init to succeed on the second call, got %d runs (done=%v)", runs, init.Done())
+		}
+	})
+
+	t.Run("concurrent first calls share one run", func(t *testing.T) {
+		t.Logf("  > Why it's important: Clients often fire several tool calls at once right after connecting.")
+		var mu sync.Mutex
+		runs := 0
+		r := NewRegistry()
+		r.Add(New("demo", func(ctx context.Context) error {
+			mu.Lock()
+			runs++
+			mu.Unlock()
+			return nil
+		}), "demo_list")
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _ = callTool(r, "demo_list")
+			}()
+		}
+		wg.Wait()
+
+		if runs != 1 {
+			t.Errorf("Expected one run, got %d", runs)
+		}
+	})
+
+	t.Run("nothing to warm is not warmed", func(t *testing.T) {
+		t.Logf("  > Why it's important: An adapter without credentials must not report itself ready, and must warm once they arrive.")
+		configured := false
+		r := NewRegistry()
+		init := New("demo", func(ctx context.Context) error {
+			if !configured {
+				return ErrNotReady
+			}
+			return nil
+		})
+		r.Add(init, "demo_list")
+		reporter := init.Report(fakeReporter{Name: "demo"})
+		if state := reporter.AdapterStatus().Warmup; state != "" {
+			t.Errorf("Expected no warmup state before first use, got %q", state)
+		}
+
+		_, _ = callTool(r, "demo_list")
+		if init.Done() || reporter.AdapterStatus().Warmup != health.WarmupNotWarmed {
+			t.Errorf("Expected not warmed without credentials, got done=%v state=%q", init.Done(), init.State())
+		}
+
+		configured = true
+		_, _ = callTool(r, "demo_list")
+		if !init.Done() || reporter.AdapterStatus().Warmup != health.WarmupWarmed {
+			t.Errorf("Expected warmed once credentials arrive, got done=%v state=%q", init.Done(), init.State())
+		}
+	})
+}
+
+func TestInitDoesNotBlock(t *testing.T) {
+	t.Logf("Importance: A hung upstream during initialization must not freeze status checks or tool calls with it.")
+
+	release := make(chan struct{})
+	defer close(release)
+	r := NewRegistry()
+	init := New("slow", func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+	r.Add(init, "slow_tool")
+	go func() {
+		_ = init.Do(context.Background())
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	t.Run("status answers during the run", func(t *testing.T) {
+		t.Logf("  > Why it's important: adapter_status and admin health are how operators find the hung adapter.")
+		answered := make(chan string, 1)
+		go func() {
+			answered <- init.Report(fakeReporter{Name: "slow"}).AdapterStatus().Warmup
+		}()
+		select {
+		case state := <-answered:
+			if state != "" {
+				t.Errorf("Expected no warmup state while the first run is going, got %q", state)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Expected the status without waiting for the run")
+		}
+	})
+
+	t.Run("calls give up when their context ends", func(t *testing.T) {
+		t.Logf("  > Why it's important: A client that stops waiting must get an answer, not hold a goroutine on the run.")
+		handler := r.Middleware()(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mcp.NewToolResultText("ran"), nil
+		})
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "slow_tool"
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		result, err := handler(ctx, request)
+		if !errors.Is(err, ErrNotReady) || result != nil {
+			t.Errorf("Expected ErrNotReady once the call's context ended, got %v / %+v", err, result)
+		}
+	})
+}
+
+type fakeReporter health.AdapterStatus
+
+func (f fakeReporter) AdapterStatus() health.AdapterStatus { return health.AdapterStatus(f) }
+
+func TestInitAll(t *testing.T) {
+	t.Logf("Importance: MCP_LAZY_INIT=false initializes every adapter at startup; a slow upstream must not hold up the others.")
+
+	t.Run("reports every failure", func(t *testing.T) {
+		t.Logf("  > Why it's important: Operators need to see all broken adapters at once, not one per restart.")
+		r := NewRegistry()
+		ok := New("ok", func(ctx context.Context) error { return nil })
+		r.Add(ok, "ok_tool")
+		r.Add(New("rtm", func(ctx context.Context) error { return errors.New("bad token") }), "rtm_tool")
+		r.Add(New("spektrix", func(ctx context.Context) error { return errors.New("bad key") }), "spektrix_tool")
+
+		err := r.InitAll(context.Background(), time.Second)
+		if err == nil {
+			t.Fatal("Expected an aggregated error")
+		}
+		for _, want := range []string{"initializing rtm: bad token", "initializing spektrix: bad key"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("Expected %q in %q", want, err.Error())
+			}
+		}
+		if !ok.Done() {
+			t.Error("Expected healthy adapter to be initialized")
+		}
+	})
+
+	t.Run("times out slow adapters independently", func(t *testing.T) {
+		t.Logf("  > Why it's important: One hung upstream must not delay the whole server's readiness.")
+		release := make(chan struct{})
+		defer close(release)
+
+		r := NewRegistry()
+		fast := New("fast", func(ctx context.Context) error {
+			time.Sleep(40 * time.Millisecond)
+			return nil
+		})
+		r.Add(fast, "fast_tool")
+		r.Add(New("slow", func(ctx context.Context) error {
+			<-release // ignores ctx, like a client without context support
+			return nil
+		}), "slow_tool")
+		r.Add(New("fast2", func(ctx context.Context) error {
+			time.Sleep(40 * time.Millisecond)
+			return nil
+		}), "fast2_tool")
+
+		start := time.Now()
+		err := r.InitAll(context.Background(), 50*time.Millisecond)
+		elapsed := time.Since(start)
+
+		if err == nil || !strings.Contains(err.Error(), "initializing slow: timed out") {
+			t.Errorf("Expected a timeout for slow adapter, got %v", err)
+		}
+		// Run one after another these would take at least 130ms
+		if elapsed > 110*time.Millisecond {
+			t.Errorf("Expected adapters to initialize concurrently within the budget, took %v", elapsed)
+		}
+		if !fast.Done() {
+			t.Error("Expected fast adapter to be initialized")
+		}
+	})
+
+	t.Run("timed out adapters finish in the background", func(t *testing.T) {
+		t.Logf("  > Why it's important: Work cut off at the startup deadline would be thrown away and repeated on the first call.")
+		finished := make(chan error, 1)
+		r := NewRegistry()
+		slow := New("slow", func(ctx context.Context) error {
+			select {
+			case <-time.After(60 * time.Millisecond):
+				finished <- nil
+				return nil
+			case <-ctx.Done():
+				finished <- ctx.Err()
+				return ctx.Err()
+			}
+		})
+		r.Add(slow, "slow_tool")
+
+		if err := r.InitAll(context.Background(), 10*time.Millisecond); err == nil {
+			t.Fatal("Expected the wait to time out")
+		}
+		if err := <-finished; err != nil {
+			t.Fatalf("Expected the run to carry on, but its context was cancelled: %v", err)
+		}
+		// Do records the result just after the initializer returns
+		deadline := time.Now().Add(time.Second)
+		for !slow.Done() && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if !slow.Done() {
+			t.Error("Expected the background run to mark the adapter initialized")
+		}
+	})
+}
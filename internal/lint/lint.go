@@ -0,0 +1,307 @@
+// Package lint checks an MCP server's registry — every tool, prompt and
+// resource it lists — against the naming and description rules connector
+// clients such as Claude.ai enforce. A registry that breaks them can fail to
+// load in the client, or lose individual tools, with no error on the server,
+// so servers run the check at startup and refuse to start on a violation.
+package lint
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Rules are the constraints a connector client places on the registry
+type Rules struct {
+	// NamePattern is the regexp tool, prompt and prompt argument names must
+	// match in full
+	NamePattern string `json:"name_pattern"`
+	// PropertyPattern is the regexp tool input property names must match
+	PropertyPattern string `json:"property_pattern"`
+	// MaxDescriptionLength limits descriptions, in characters
+	MaxDescriptionLength int `json:"max_description_length"`
+	// RequireDescription rejects tools and prompts without a description
+	RequireDescription bool `json:"require_description"`
+	// URISchemes lists the resource URI schemes clients accept; empty
+	// accepts any scheme
+	URISchemes []string `json:"uri_schemes"`
+}
+
+// DefaultRules returns the rules Claude.ai applies to connectors
+func DefaultRules() Rules {
+	return Rules{
+		NamePattern:          `^[a-zA-Z0-9_-]{1,64}$`,
+		PropertyPattern:      `^[a-zA-Z0-9_.-]{1,64}$`,
+		MaxDescriptionLength: 1024,
+		RequireDescription:   true,
+	}
+}
+
+// RulesFromEnv returns the default rules with any overrides from the JSON
+// file named by CONNECTOR_RULES, for clients with different limits
+func RulesFromEnv() (Rules, error) {
+	rules := DefaultRules()
+	path := os.Getenv("CONNECTOR_RULES")
+	if path == "" {
+		return rules, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return rules, fmt.Errorf("reading CONNECTOR_RULES: %w", err)
+	}
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return rules, fmt.Errorf("parsing CONNECTOR_RULES %s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// Violation is one registry entry breaking one rule
+type Violation struct {
+	// Kind is "tool", "prompt", "resource" or "resource template"
+	Kind    string `json:"kind"`
+	Name    string `json:"name"`
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s %q: %s", v.Kind, v.Name, v.Message)
+}
+
+// Enforce checks the server's registry and returns an error listing every
+// violation, or nil when the registry complies
+func Enforce(ctx context.Context, s *server.MCPServer, rules Rules) error {
+	violations, err := Check(ctx, s, rules)
+	if err != nil {
+		return err
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+
+	lines := make([]string, len(violations))
+	for i, v := range violations {
+		lines[i] = "  " + v.String()
+	}
+	return fmt.Errorf("%d connector rule violations:\n%s", len(violations), strings.Join(lines, "\n"))
+}
+
+// EnforceFromEnv enforces the rules from RulesFromEnv, for server startup
+func EnforceFromEnv(ctx context.Context, s *server.MCPServer) error {
+	rules, err := RulesFromEnv()
+	if err != nil {
+		return err
+	}
+	return Enforce(ctx, s, rules)
+}
+
+// Check lists the server's tools, prompts, resources and resource
+// templates as a client would see them and returns the rule violations,
+// ordered by kind and name. Lists the server does not support are skipped.
+func Check(ctx context.Context, s *server.MCPServer, rules Rules) ([]Violation, error) {
+	names, err := regexp.Compile(rules.NamePattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid name_pattern: %w", err)
+	}
+	properties, err := regexp.Compile(rules.PropertyPattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid property_pattern: %w", err)
+	}
+	c := &checker{rules: rules, names: names, properties: properties}
+
+	var tools struct {
+		Tools []struct {
+			Name        string `json:"name"`
+			Description string `json:"description"`
+			InputSchema struct {
+				Properties map[string]json.RawMessage `json:"properties"`
+			} `json:"inputSchema"`
+		} `json:"tools"`
+	}
+	if err := list(ctx, s, mcp.MethodToolsList, &tools); err != nil {
+		return nil, err
+	}
+	for _, tool := range tools.Tools {
+		c.name("tool", tool.Name)
+		c.description("tool", tool.Name, tool.Description, true)
+		for property := range tool.InputSchema.Properties {
+			if !properties.MatchString(property) {
+				c.add("tool", tool.Name, fmt.Sprintf("input property %q does not match %s", property, rules.PropertyPattern))
+			}
+		}
+	}
+
+	var prompts struct {
+		Prompts []struct {
+			Name        string `json:"name"`
+			Description string `json:"description"`
+			Arguments   []struct {
+				Name string `json:"name"`
+			} `json:"arguments"`
+		} `json:"prompts"`
+	}
+	if err := list(ctx, s, mcp.MethodPromptsList, &prompts); err != nil {
+		return nil, err
+	}
+	for _, prompt := range prompts.Prompts {
+		c.name("prompt", prompt.Name)
+		c.description("prompt", prompt.Name, prompt.Description, true)
+		for _, arg := range prompt.Arguments {
+			if !names.MatchString(arg.Name) {
+				c.add("prompt", prompt.Name, fmt.Sprintf("argument %q does not match %s", arg.Name, rules.NamePattern))
+			}
+		}
+	}
+
+	var resources struct {
+		Resources []struct {
+			URI         string `json:"uri"`
+			Name        string `json:"name"`
+			Description string `json:"description"`
+		} `json:"resources"`
+	}
+	if err := list(ctx, s, mcp.MethodResourcesList, &resources); err != nil {
+		return nil, err
+	}
+	for _, resource := range resources.Resources {
+		c.resource("resource", resource.URI, resource.Name, resource.Description)
+	}
+
+	var templates struct {
+		ResourceTemplates []struct {
+			URITemplate string `json:"uriTemplate"`
+			Name        string `json:"name"`
+			Description string `json:"description"`
+		} `json:"resourceTemplates"`
+	}
+	if err := list(ctx, s, mcp.MethodResourcesTemplatesList, &templates); err != nil {
+		return nil, err
+	}
+	for _, template := range templates.ResourceTemplates {
+		c.resource("resource template", template.URITemplate, template.Name, template.Description)
+	}
+
+	sort.SliceStable(c.violations, func(i, j int) bool {
+		if c.violations[i].Kind != c.violations[j].Kind {
+			return c.violations[i].Kind < c.violations[j].Kind
+		}
+		return c.violations[i].Name < c.violations[j].Name
+	})
+	return c.violations, nil
+}
+
+// checker accumulates violations for one run
+type checker struct {
+	rules      Rules
+	names      *regexp.Regexp
+	properties *regexp.Regexp
+	violations []Violation
+}
+
+func (c *checker) add(kind, name, message string) {
+	c.violations = append(c.violations, Violation{Kind: kind, Name: name, Message: message})
+}
+
+func (c *checker) name(kind, name string) {
+	if !c.names.MatchString(name) {
+		c.add(kind, name, fmt.Sprintf("name does not match %s", c.rules.NamePattern))
+	}
+}
+
+func (c *checker) description(kind, name, description string, required bool) {
+	if strings.TrimSpace(description) == "" {
+		if required && c.rules.RequireDescription {
+			c.add(kind, name, "description is empty")
+		}
+		return
+	}
+	if length := len([]rune(description)); c.rules.MaxDescriptionLength > 0 && length > c.rules.MaxDescriptionLength {
+		c.add(kind, name, fmt.Sprintf("description is %d characters, over the %d limit", length, c.rules.MaxDescriptionLength))
+	}
+	if description != strings.TrimSpace(description) {
+		c.add(kind, name, "description has leading or trailing whitespace")
+	}
+	for i, r := range description {
+		if unicode.IsControl(r) && r != '\n' {
+			c.add(kind, name, fmt.Sprintf("description has control character %U at byte %d", r, i))
+			break
+		}
+	}
+}
+
+// resource checks a resource's URI, or a template's, and its name
+func (c *checker) resource(kind, uri, name, description string) {
+	if name == "" {
+		c.add(kind, uri, "name is empty")
+	}
+	// Resource descriptions are optional, but must follow the rules when set
+	c.description(kind, uri, description, false)
+
+	// Templates are checked up to their first expression
+	prefix, _, _ := strings.Cut(uri, "{")
+	scheme, _, ok := strings.Cut(prefix, "://")
+	if !ok {
+		if parsed, err := url.Parse(uri); err == nil && parsed.Scheme != "" {
+			scheme, ok = parsed.Scheme, true
+		}
+	}
+	if !ok || scheme == "" {
+		c.add(kind, uri, "URI has no scheme")
+		return
+	}
+	if len(c.rules.URISchemes) > 0 && !contains(c.rules.URISchemes, scheme) {
+		c.add(kind, uri, fmt.Sprintf("URI scheme %q is not one of %s", scheme, strings.Join(c.rules.URISchemes, ", ")))
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// list sends a list request to the server as a client would, so hooks and
+// filters apply, and decodes the result into target
+func list(ctx context.Context, s *server.MCPServer, method mcp.MCPMethod, target interface{}) error {
+	request, err := json.Marshal(map[string]interface{}{"jsonrpc": mcp.JSONRPC_VERSION, "id": 1, "method": method})
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(s.HandleMessage(ctx, request))
+	if err != nil {
+		return fmt.Errorf("encoding %s response: %w", method, err)
+	}
+
+	var response struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return fmt.Errorf("decoding %s response: %w", method, err)
+	}
+	if response.Error != nil {
+		if response.Error.Code == mcp.METHOD_NOT_FOUND {
+			return nil
+		}
+		return fmt.Errorf("%s: %s", method, response.Error.Message)
+	}
+	if len(response.Result) == 0 {
+		return fmt.Errorf("%s returned no result", method)
+	}
+	return json.Unmarshal(response.Result, target)
+}
@@ -0,0 +1,136 @@
+package lint
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func noopTool(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return mcp.NewToolResultText("ok"), nil
+}
+
+func noopPrompt(context.Context, mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	return &mcp.GetPromptResult{}, nil
+}
+
+func noopResource(context.Context, mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	return nil, nil
+}
+
+// newTestServer registers one compliant entry of each kind plus the given tools
+func newTestServer(tools ...mcp.Tool) *server.MCPServer {
+	s := server.NewMCPServer("test", "1.0.0",
+		server.WithToolCapabilities(false),
+		server.WithPromptCapabilities(false),
+		server.WithResourceCapabilities(false, false),
+	)
+	s.AddTool(mcp.NewTool("get_tasks", mcp.WithDescription("Lists tasks."), mcp.WithString("filter")), noopTool)
+	s.AddPrompt(mcp.NewPrompt("weekly_review", mcp.WithPromptDescription("Reviews the week."), mcp.WithArgument("list")), noopPrompt)
+	s.AddResource(mcp.NewResource("rtm://today", "Today's tasks"), noopResource)
+	s.AddResourceTemplate(mcp.NewResourceTemplate("rtm://lists/{name}", "Tasks in a list"), noopResource)
+	for _, tool := range tools {
+		s.AddTool(tool, noopTool)
+	}
+	return s
+}
+
+func TestCheck(t *testing.T) {
+	t.Logf("Importance: Connector clients silently drop or reject registries that break their rules; the server must catch it before users do.")
+	ctx := context.Background()
+
+	t.Run("compliant registry", func(t *testing.T) {
+		violations, err := Check(ctx, newTestServer(), DefaultRules())
+		if err != nil || len(violations) != 0 {
+			t.Errorf("Expected no violations, got %v %v", violations, err)
+		}
+	})
+
+	t.Run("names, descriptions and properties", func(t *testing.T) {
+		t.Logf("  > Why it's important: Each message must name the entry and the rule so the fix is obvious from the startup log.")
+		s := newTestServer(
+			mcp.NewTool("rtm.get tasks", mcp.WithDescription("Lists tasks.")),
+			mcp.NewTool("no_description"),
+			mcp.NewTool("long_description", mcp.WithDescription(strings.Repeat("x", 1025))),
+			mcp.NewTool("padded", mcp.WithDescription("Lists tasks.\n")),
+			mcp.NewTool("tabbed", mcp.WithDescription("Lists\ttasks.")),
+			mcp.NewTool("bad_property", mcp.WithDescription("Lists tasks."), mcp.WithString("due date")),
+		)
+
+		violations, err := Check(ctx, s, DefaultRules())
+		if err != nil {
+			t.Fatalf("Check failed: %v", err)
+		}
+		got := make([]string, len(violations))
+		for i, v := range violations {
+			got[i] = v.String()
+		}
+		want := []string{
+			`tool "bad_property": input property "due date" does not match ^[a-zA-Z0-9_.-]{1,64}$`,
+			`tool "long_description": description is 1025 characters, over the 1024 limit`,
+			`tool "no_description": description is empty`,
+			`tool "padded": description has leading or trailing whitespace`,
+			`tool "rtm.get tasks": name does not match ^[a-zA-Z0-9_-]{1,64}$`,
+			`tool "tabbed": description has control character U+0009 at byte 5`,
+		}
+		if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("Unexpected violations:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+		}
+	})
+
+	t.Run("resource schemes", func(t *testing.T) {
+		s := newTestServer()
+		s.AddResource(mcp.NewResource("server://changelog", "Changelog"), noopResource)
+		s.AddResourceTemplate(mcp.NewResourceTemplate("{path}", "Anything"), noopResource)
+
+		rules := DefaultRules()
+		rules.URISchemes = []string{"rtm"}
+		violations, err := Check(ctx, s, rules)
+		if err != nil || len(violations) != 2 {
+			t.Fatalf("Expected two violations, got %v %v", violations, err)
+		}
+		if violations[0].Message != `URI scheme "server" is not one of rtm` || violations[1].Message != "URI has no scheme" {
+			t.Errorf("Unexpected violations %v", violations)
+		}
+	})
+
+	t.Run("enforce lists every violation", func(t *testing.T) {
+		s := newTestServer(mcp.NewTool("a.b"), mcp.NewTool("c d"))
+		err := Enforce(ctx, s, DefaultRules())
+		if err == nil || !strings.HasPrefix(err.Error(), "4 connector rule violations:") {
+			t.Errorf("Expected 4 violations reported, got %v", err)
+		}
+		if err := Enforce(ctx, newTestServer(), DefaultRules()); err != nil {
+			t.Errorf("Expected compliant registry to pass, got %v", err)
+		}
+	})
+}
+
+func TestRulesFromEnv(t *testing.T) {
+	t.Logf("Importance: Other clients have other limits; overrides must apply without dropping the default rules they leave out.")
+	path := filepath.Join(t.TempDir(), "rules.json")
+	if err := os.WriteFile(path, []byte(`{"max_description_length": 200, "uri_schemes": ["rtm"]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONNECTOR_RULES", path)
+
+	rules, err := RulesFromEnv()
+	if err != nil {
+		t.Fatalf("RulesFromEnv failed: %v", err)
+	}
+	if rules.MaxDescriptionLength != 200 || len(rules.URISchemes) != 1 || rules.NamePattern != DefaultRules().NamePattern {
+		t.Errorf("Unexpected rules %+v", rules)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"name_pattern": "["}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := EnforceFromEnv(context.Background(), newTestServer()); err == nil || !strings.Contains(err.Error(), "invalid name_pattern") {
+		t.Errorf("Expected invalid pattern error, got %v", err)
+	}
+}
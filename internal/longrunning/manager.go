@@ -2,8 +2,10 @@ package longrunning
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sort"
 	"sync"
 	"time"
 
@@ -170,22 +172,54 @@ func (m *Manager) SendProgressNotification(task *Task, progress float64, total *
 	task.lastNotified = now
 	task.mu.Unlock()
 
-	percentage := 100.0
+	return m.notify(task, progress, total, message)
+}
+
+// notify sends notifications/progress to the task's session without rate
+// limiting, so final updates are never dropped. Tasks without a session
+// are only logged.
+func (m *Manager) notify(task *Task, progress float64, total *float64, message string) error {
 	if total != nil && *total > 0 {
-		percentage = (progress / *total) * 100
-	} else if progress > 0 && total == nil {
+		log.Printf("Progress notification for task %s: %.1f%% - %s",
+			task.id, (progress / *total)*100, message)
+	} else {
 		log.Printf("Progress notification for task %s: %.1f - %s",
 			task.id, progress, message)
-		return nil
 	}
-	log.Printf("Progress notification for task %s: %.1f%% - %s",
-		task.id, percentage, message)
-
-	// TODO(vcto): Implement actual notification sending when mcp-go supports it
 
+	if m.server == nil || task.sessionID == "" {
+		return nil
+	}
+	params := map[string]any{
+		"progressToken": task.progressToken,
+		"progress":      progress,
+	}
+	if total != nil {
+		params["total"] = *total
+	}
+	if message != "" {
+		params["message"] = message
+	}
+	err := m.server.SendNotificationToSpecificClient(task.sessionID, "notifications/progress", params)
+	if errors.Is(err, server.ErrSessionNotFound) {
+		// The client disconnected; there is no one left to tell
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("sending progress for task %s: %w", task.id, err)
+	}
 	return nil
 }
 
+// SessionID returns the ID of the MCP session a request arrived on, or ""
+// outside a session. Progress for a task is sent to this session.
+func SessionID(ctx context.Context) string {
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		return session.SessionID()
+	}
+	return ""
+}
+
 // SetMinNotificationInterval configures the rate limiting for progress notifications
 func (m *Manager) SetMinNotificationInterval(interval time.Duration) {
 	m.minNotificationInterval = interval
@@ -198,6 +232,44 @@ func (m *Manager) GetActiveTaskCount() int {
 	return len(m.tasks)
 }
 
+// TaskInfo describes a running task for diagnostics
+type TaskInfo struct {
+	ID        string    `json:"id"`
+	SessionID string    `json:"session_id"`
+	Progress  float64   `json:"progress"`
+	Total     float64   `json:"total,omitempty"`
+	Message   string    `json:"message,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	Cancelled bool      `json:"cancelled,omitempty"`
+}
+
+// Tasks describes the active tasks, oldest first
+func (m *Manager) Tasks() []TaskInfo {
+	m.mu.RLock()
+	tasks := make([]*Task, 0, len(m.tasks))
+	for _, task := range m.tasks {
+		tasks = append(tasks, task)
+	}
+	m.mu.RUnlock()
+
+	infos := make([]TaskInfo, 0, len(tasks))
+	for _, task := range tasks {
+		task.mu.RLock()
+		infos = append(infos, TaskInfo{
+			ID:        task.id,
+			SessionID: task.sessionID,
+			Progress:  task.progress,
+			Total:     task.total,
+			Message:   task.message,
+			StartedAt: task.startTime,
+			Cancelled: task.cancelled,
+		})
+		task.mu.RUnlock()
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].StartedAt.Before(infos[j].StartedAt) })
+	return infos
+}
+
 // GetSessionTaskCount returns the number of active tasks for a session
 func (m *Manager) GetSessionTaskCount(sessionID string) int {
 	m.mu.RLock()
@@ -129,3 +129,57 @@ func TestProgressReporting(t *testing.T) {
 		assert.Contains(t, task.GetMessage(), "Processing files: file1.txt (1 of 10)")
 	})
 }
+
+// fakeSession is a connected client that records its notifications
+type fakeSession struct {
+	id            string
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (f *fakeSession) Initialize()                                         {}
+func (f *fakeSession) Initialized() bool                                   { return true }
+func (f *fakeSession) NotificationChannel() chan<- mcp.JSONRPCNotification { return f.notifications }
+func (f *fakeSession) SessionID() string                                   { return f.id }
+
+func TestProgressNotifications(t *testing.T) {
+	t.Logf("Importance: Clients only see progress if notifications/progress actually reaches their session; logging it server-side is not enough.")
+	mcpServer := server.NewMCPServer("test", "1.0")
+	manager := NewManager(mcpServer)
+	session := &fakeSession{id: "session-live", notifications: make(chan mcp.JSONRPCNotification, 10)}
+	require.NoError(t, mcpServer.RegisterSession(context.Background(), session))
+
+	ctx := mcpServer.WithContext(context.Background(), session)
+	require.Equal(t, "session-live", SessionID(ctx))
+
+	task, _ := manager.StartTask(ctx, mcp.ProgressToken("live-token"), SessionID(ctx))
+	task.SetTotal(4)
+	require.NoError(t, task.UpdateProgress(1, "1 of 4 done"))
+
+	t.Run("updates carry the request's progress token", func(t *testing.T) {
+		note := <-session.notifications
+		assert.Equal(t, "notifications/progress", note.Method)
+		fields := note.Params.AdditionalFields
+		assert.Equal(t, mcp.ProgressToken("live-token"), fields["progressToken"])
+		assert.Equal(t, float64(1), fields["progress"])
+		assert.Equal(t, float64(4), fields["total"])
+		assert.Equal(t, "1 of 4 done", fields["message"])
+	})
+
+	t.Run("final update is never rate limited", func(t *testing.T) {
+		t.Logf("  > Why it's important: A completion right after an update must still reach the client, or it waits forever.")
+		_ = task.UpdateProgress(4, "4 of 4 done") // Within the rate limit window
+		task.Complete()
+		task.Complete()
+		var last mcp.JSONRPCNotification
+		for len(session.notifications) > 0 {
+			last = <-session.notifications
+		}
+		assert.Equal(t, float64(4), last.Params.AdditionalFields["progress"])
+		assert.Equal(t, "4 of 4 done", last.Params.AdditionalFields["message"])
+	})
+
+	t.Run("disconnected sessions are not an error", func(t *testing.T) {
+		gone, _ := manager.StartTask(context.Background(), mcp.ProgressToken("gone-token"), "session-gone")
+		assert.NoError(t, gone.UpdateProgress(1, "still working"))
+	})
+}
@@ -97,12 +97,15 @@ func (t *Task) IncrementProgress(message string) error {
 
 // Complete marks the task as completed successfully.
 // This sends a final progress notification and removes the task from the manager.
+// It is a no-op once the task has finished.
 func (t *Task) Complete() {
 	t.mu.Lock()
-	if t.endTime == nil {
-		now := time.Now()
-		t.endTime = &now
+	if t.endTime != nil {
+		t.mu.Unlock()
+		return
 	}
+	now := time.Now()
+	t.endTime = &now
 	t.mu.Unlock()
 
 	// Send final progress notification
@@ -120,7 +123,7 @@ func (t *Task) Complete() {
 		totalPtr = &progress // Set total = progress for 100%
 	}
 
-	_ = t.manager.SendProgressNotification(t, progress, totalPtr, message)
+	_ = t.manager.notify(t, progress, totalPtr, message)
 
 	// Remove from manager
 	t.manager.RemoveTask(t)
@@ -128,13 +131,16 @@ func (t *Task) Complete() {
 
 // CompleteWithError marks the task as failed with the given error.
 // This sends an error notification and removes the task from the manager.
+// It is a no-op once the task has finished.
 func (t *Task) CompleteWithError(err error) {
 	t.mu.Lock()
-	t.error = err
-	if t.endTime == nil {
-		now := time.Now()
-		t.endTime = &now
+	if t.endTime != nil {
+		t.mu.Unlock()
+		return
 	}
+	t.error = err
+	now := time.Now()
+	t.endTime = &now
 	t.mu.Unlock()
 
 	// Send error notification
@@ -142,7 +148,7 @@ func (t *Task) CompleteWithError(err error) {
 	progress := t.progress
 	t.mu.RUnlock()
 
-	_ = t.manager.SendProgressNotification(t, progress, nil, "Error: "+err.Error())
+	_ = t.manager.notify(t, progress, nil, "Error: "+err.Error())
 
 	// Remove from manager
 	t.manager.RemoveTask(t)
@@ -174,7 +180,7 @@ func (t *Task) Cancel(reason string) {
 	progress := t.progress
 	t.mu.RUnlock()
 
-	_ = t.manager.SendProgressNotification(t, progress, nil, "Cancelled: "+reason)
+	_ = t.manager.notify(t, progress, nil, "Cancelled: "+reason)
 
 	// Remove from manager
 	t.manager.RemoveTask(t)
@@ -0,0 +1,206 @@
+package manifest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Gateway tool names
+const (
+	GatewayListGroups  = "list_groups"
+	GatewayCallGrouped = "call_grouped"
+)
+
+// gatewayBypassKey marks internal tools/list requests that must see every tool
+type gatewayBypassKey struct{}
+
+// GatewayEnabled reports whether MCP_TOOL_GATEWAY asks for gateway mode, where
+// grouped tools are hidden behind list_groups and call_grouped
+func GatewayEnabled() bool {
+	return os.Getenv("MCP_TOOL_GATEWAY") == "true"
+}
+
+// GatewayToolFilter hides grouped tools from tools/list, leaving the gateway
+// tools and any tool not covered by a manifest
+func GatewayToolFilter(manifests ...*Manifest) server.ToolFilterFunc {
+	groups := toolGroups(manifests)
+
+	return func(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+		if ctx.Value(gatewayBypassKey{}) != nil {
+			return tools
+		}
+		visible := make([]mcp.Tool, 0, len(tools))
+		for _, tool := range tools {
+			if _, grouped := groups[tool.Name]; !grouped {
+				visible = append(visible, tool)
+			}
+		}
+		return visible
+	}
+}
+
+// SetupGateway registers list_groups and call_grouped, which let clients that
+// struggle with large tool lists browse and call tools one group at a time
+func SetupGateway(s *server.MCPServer, manifests ...*Manifest) {
+	groups := toolGroups(manifests)
+
+	s.AddTool(mcp.NewTool(GatewayListGroups,
+		mcp.WithDescription("List tool groups (e.g. rtm, spektrix, admin). Pass a group to see its tools with descriptions and input schemas, then run them with call_grouped."),
+		mcp.WithString("group", mcp.Description("Group to list tools for (default: list all groups)")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		tools, err := listAllTools(ctx, s)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to list tools: %v", err)), nil
+		}
+
+		group := request.GetString("group", "")
+		var result interface{}
+		if group == "" {
+			result = map[string]interface{}{"groups": summarizeGroups(tools, groups)}
+		} else {
+			members := make([]mcp.Tool, 0)
+			for _, tool := range tools {
+				if groups[tool.Name] == group {
+					members = append(members, tool)
+				}
+			}
+			if len(members) == 0 {
+				return mcp.NewToolResultError(fmt.Sprintf("Unknown group '%s'. Call list_groups without arguments to see groups.", group)), nil
+			}
+			result = map[string]interface{}{"group": group, "tools": members}
+		}
+
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError("Failed to format groups"), nil
+		}
+		return mcp.NewToolResultText(string(data)), nil
+	})
+
+	s.AddTool(mcp.NewTool(GatewayCallGrouped,
+		mcp.WithDescription("Call a tool from a group listed by list_groups"),
+		mcp.WithString("group", mcp.Required(), mcp.Description("Group the tool belongs to")),
+		mcp.WithString("tool", mcp.Required(), mcp.Description("Tool name")),
+		mcp.WithObject("arguments", mcp.Description("Arguments for the tool")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		group := request.GetString("group", "")
+		name := request.GetString("tool", "")
+		if groups[name] == "" || groups[name] != group {
+			return mcp.NewToolResultError(fmt.Sprintf("Tool '%s' is not in group '%s'. Use list_groups to see available tools.", name, group)), nil
+		}
+
+		var arguments interface{} = map[string]interface{}{}
+		switch args := request.GetArguments()["arguments"].(type) {
+		case map[string]interface{}:
+			arguments = args
+		case string:
+			// Some clients send nested objects as JSON strings
+			var decoded map[string]interface{}
+			if err := json.Unmarshal([]byte(args), &decoded); err != nil {
+				return mcp.NewToolResultError("arguments must be a JSON object"), nil
+			}
+			arguments = decoded
+		}
+
+		return callTool(ctx, s, name, arguments)
+	})
+}
+
+// summarizeGroups lists each group with its tool names
+func summarizeGroups(tools []mcp.Tool, groups map[string]string) []map[string]interface{} {
+	members := make(map[string][]string)
+	for _, tool := range tools {
+		if group, ok := groups[tool.Name]; ok {
+			members[group] = append(members[group], tool.Name)
+		}
+	}
+
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	summary := make([]map[string]interface{}, 0, len(names))
+	for _, name := range names {
+		sort.Strings(members[name])
+		summary = append(summary, map[string]interface{}{
+			"group": name,
+			"tools": members[name],
+			"count": len(members[name]),
+		})
+	}
+	return summary
+}
+
+// listAllTools fetches the unfiltered tool list through the server itself
+func listAllTools(ctx context.Context, s *server.MCPServer) ([]mcp.Tool, error) {
+	raw, err := dispatch(context.WithValue(ctx, gatewayBypassKey{}, true), s, mcp.MethodToolsList, map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Tools []mcp.Tool `json:"tools"`
+	}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("parsing tools/list result: %w", err)
+	}
+	return result.Tools, nil
+}
+
+// callTool runs a tool through the server so middleware and hooks still apply
+func callTool(ctx context.Context, s *server.MCPServer, name string, arguments interface{}) (*mcp.CallToolResult, error) {
+	raw, err := dispatch(ctx, s, mcp.MethodToolsCall, map[string]interface{}{
+		"name":      name,
+		"arguments": arguments,
+	})
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	rawMessage := json.RawMessage(raw)
+	result, err := mcp.ParseCallToolResult(&rawMessage)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to parse result from %s: %v", name, err)), nil
+	}
+	return result, nil
+}
+
+// dispatch sends a JSON-RPC request to the server and returns its raw result
+func dispatch(ctx context.Context, s *server.MCPServer, method mcp.MCPMethod, params interface{}) (json.RawMessage, error) {
+	message, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": mcp.JSONRPC_VERSION,
+		"id":      "gateway",
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(s.HandleMessage(ctx, message))
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, err
+	}
+	if response.Error != nil {
+		return nil, fmt.Errorf("%s", response.Error.Message)
+	}
+	return response.Result, nil
+}
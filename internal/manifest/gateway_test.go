@@ -0,0 +1,119 @@
+package manifest
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func newGatewayServer(t *testing.T) *server.MCPServer {
+	t.Helper()
+	m := testManifest()
+	hooks := &server.Hooks{}
+	AttachGroups(hooks, m)
+
+	s := server.NewMCPServer("test", "1.0.0",
+		server.WithToolCapabilities(false),
+		server.WithHooks(hooks),
+		server.WithToolFilter(GatewayToolFilter(m)),
+	)
+	SetupGateway(s, m)
+
+	s.AddTool(mcp.NewTool("demo_list", mcp.WithDescription("List demo items")), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("listed " + request.GetString("filter", "all")), nil
+	})
+	s.AddTool(mcp.NewTool("standalone"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ok"), nil
+	})
+	return s
+}
+
+func callGateway(t *testing.T, s *server.MCPServer, name string, args map[string]interface{}) *mcp.CallToolResult {
+	t.Helper()
+	raw, err := dispatch(context.Background(), s, mcp.MethodToolsCall, map[string]interface{}{"name": name, "arguments": args})
+	if err != nil {
+		t.Fatalf("%s failed: %v", name, err)
+	}
+	result, err := mcp.ParseCallToolResult(&raw)
+	if err != nil {
+		t.Fatalf("Failed to parse %s result: %v", name, err)
+	}
+	return result
+}
+
+func resultText(result *mcp.CallToolResult) string {
+	if len(result.Content) == 0 {
+		return ""
+	}
+	if text, ok := result.Content[0].(mcp.TextContent); ok {
+		return text.Text
+	}
+	return ""
+}
+
+func TestGateway(t *testing.T) {
+	t.Logf("Importance: Clients that struggle with large tool lists rely on the gateway to reach every grouped tool.")
+
+	s := newGatewayServer(t)
+
+	t.Run("hides grouped tools from tools/list", func(t *testing.T) {
+		t.Logf("  > Why it's important: The point of gateway mode is a short tool list.")
+		raw, err := dispatch(context.Background(), s, mcp.MethodToolsList, map[string]interface{}{})
+		if err != nil {
+			t.Fatalf("tools/list failed: %v", err)
+		}
+		var result struct {
+			Tools []mcp.Tool `json:"tools"`
+		}
+		if err := json.Unmarshal(raw, &result); err != nil {
+			t.Fatalf("Failed to decode tools/list: %v", err)
+		}
+
+		names := map[string]bool{}
+		for _, tool := range result.Tools {
+			names[tool.Name] = true
+		}
+		if names["demo_list"] {
+			t.Error("Expected grouped tool demo_list to be hidden")
+		}
+		for _, want := range []string{GatewayListGroups, GatewayCallGrouped, "standalone"} {
+			if !names[want] {
+				t.Errorf("Expected %s to be listed", want)
+			}
+		}
+	})
+
+	t.Run("lists groups and their tools", func(t *testing.T) {
+		t.Logf("  > Why it's important: Agents discover hidden tools and their schemas through list_groups.")
+		summary := resultText(callGateway(t, s, GatewayListGroups, nil))
+		if !strings.Contains(summary, `"group": "demo"`) || !strings.Contains(summary, "demo_list") {
+			t.Errorf("Expected demo group with demo_list, got %s", summary)
+		}
+
+		detail := resultText(callGateway(t, s, GatewayListGroups, map[string]interface{}{"group": "demo"}))
+		if !strings.Contains(detail, "List demo items") {
+			t.Errorf("Expected tool descriptions in group detail, got %s", detail)
+		}
+	})
+
+	t.Run("calls grouped tools", func(t *testing.T) {
+		t.Logf("  > Why it's important: Hidden tools must remain fully usable through call_grouped.")
+		result := callGateway(t, s, GatewayCallGrouped, map[string]interface{}{
+			"group":     "demo",
+			"tool":      "demo_list",
+			"arguments": map[string]interface{}{"filter": "open"},
+		})
+		if text := resultText(result); text != "listed open" {
+			t.Errorf("Expected 'listed open', got %q", text)
+		}
+
+		wrongGroup := callGateway(t, s, GatewayCallGrouped, map[string]interface{}{"group": "admin", "tool": "demo_list"})
+		if !wrongGroup.IsError {
+			t.Error("Expected error when the tool is not in the given group")
+		}
+	})
+}
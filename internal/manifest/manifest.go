@@ -0,0 +1,218 @@
+// Package manifest describes what each adapter's tools read and write in the
+// upstream account, and derives permission descriptors for client approval UIs.
+package manifest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Access levels for a tool
+const (
+	AccessNone  = "none"
+	AccessRead  = "read"
+	AccessWrite = "write"
+)
+
+// MetaKey is the tools/list _meta key holding permission descriptors
+const MetaKey = "permissions"
+
+// GroupsMetaKey is the tools/list _meta key mapping tool names to groups
+const GroupsMetaKey = "groups"
+
+// GroupAdmin is the group for server administration tools shared by adapters
+const GroupAdmin = "admin"
+
+// Manifest declares an adapter's upstream account and what each tool touches
+type Manifest struct {
+	// Adapter is the short adapter name, e.g. "rtm"
+	Adapter string
+	// Service is the upstream product name shown to users; empty when the
+	// tools do not talk to an upstream service
+	Service string
+	// Account describes which upstream account the tools act on
+	Account string
+	// Tools maps tool names to the data they read and write
+	Tools map[string]ToolAccess
+}
+
+// ToolAccess lists the kinds of upstream data a tool reads and writes.
+// A tool with neither only works with data local to the server.
+type ToolAccess struct {
+	Reads  []string
+	Writes []string
+	// Group overrides the tool's group, which defaults to the adapter name
+	Group string
+	// Exclusive names a lock scope; tools sharing a scope never run at the
+	// same time for the same subject
+	Exclusive string
+	// Idempotent marks a tool with writes as safe to repeat, e.g. one that
+	// sets fields to given values. Tools without writes are always safe.
+	Idempotent bool
+}
+
+// GroupOf returns the group a tool belongs to
+func (m *Manifest) GroupOf(tool string) string {
+	if access, ok := m.Tools[tool]; ok && access.Group != "" {
+		return access.Group
+	}
+	return m.Adapter
+}
+
+// AdapterTools returns the sorted names of tools in the adapter's own group,
+// leaving out shared tools such as those in the admin group
+func (m *Manifest) AdapterTools() []string {
+	var tools []string
+	for name := range m.Tools {
+		if m.GroupOf(name) == m.Adapter {
+			tools = append(tools, name)
+		}
+	}
+	sort.Strings(tools)
+	return tools
+}
+
+// ExclusiveScopes maps each tool that declared an Exclusive scope to it
+func (m *Manifest) ExclusiveScopes() map[string]string {
+	scopes := make(map[string]string)
+	for name, access := range m.Tools {
+		if access.Exclusive != "" {
+			scopes[name] = access.Exclusive
+		}
+	}
+	return scopes
+}
+
+// Permission is the descriptor attached for a single tool
+type Permission struct {
+	Adapter     string   `json:"adapter"`
+	Account     string   `json:"account"`
+	Access      string   `json:"access"`
+	Reads       []string `json:"reads"`
+	Writes      []string `json:"writes"`
+	Description string   `json:"description"`
+}
+
+// Permissions generates descriptors for every tool in the manifest
+func (m *Manifest) Permissions() map[string]Permission {
+	perms := make(map[string]Permission, len(m.Tools))
+	for name, access := range m.Tools {
+		perms[name] = m.permission(access)
+	}
+	return perms
+}
+
+func (m *Manifest) permission(access ToolAccess) Permission {
+	p := Permission{
+		Adapter: m.Adapter,
+		Account: m.Account,
+		Access:  AccessNone,
+		Reads:   append([]string{}, access.Reads...),
+		Writes:  append([]string{}, access.Writes...),
+	}
+
+	var parts []string
+	if len(access.Reads) > 0 {
+		p.Access = AccessRead
+		parts = append(parts, "reads "+joinWords(access.Reads))
+	}
+	if len(access.Writes) > 0 {
+		p.Access = AccessWrite
+		parts = append(parts, "changes "+joinWords(access.Writes))
+	}
+
+	switch {
+	case m.Service == "":
+		p.Description = "Does not access any external account."
+	case len(parts) == 0:
+		p.Description = fmt.Sprintf("Does not access your %s account.", m.Service)
+	default:
+		p.Description = fmt.Sprintf("%s in your %s account.", capitalize(strings.Join(parts, ", and ")), m.Service)
+	}
+	return p
+}
+
+// AttachPermissions adds an after-list-tools hook that publishes permission
+// descriptors under _meta.permissions, keyed by tool name. mcp-go's Tool type
+// has no _meta field, so descriptors ride on the tools/list result instead.
+func AttachPermissions(hooks *server.Hooks, manifests ...*Manifest) {
+	perms := make(map[string]Permission)
+	for _, m := range manifests {
+		for name, p := range m.Permissions() {
+			perms[name] = p
+		}
+	}
+
+	hooks.AddAfterListTools(func(ctx context.Context, id any, message *mcp.ListToolsRequest, result *mcp.ListToolsResult) {
+		listed := make(map[string]Permission, len(result.Tools))
+		for _, tool := range result.Tools {
+			if p, ok := perms[tool.Name]; ok {
+				listed[tool.Name] = p
+			}
+		}
+		if len(listed) == 0 {
+			return
+		}
+		if result.Meta == nil {
+			result.Meta = make(map[string]any)
+		}
+		result.Meta[MetaKey] = listed
+	})
+}
+
+// AttachGroups adds an after-list-tools hook that publishes each listed
+// tool's group under _meta.groups, keyed by tool name
+func AttachGroups(hooks *server.Hooks, manifests ...*Manifest) {
+	groups := toolGroups(manifests)
+
+	hooks.AddAfterListTools(func(ctx context.Context, id any, message *mcp.ListToolsRequest, result *mcp.ListToolsResult) {
+		listed := make(map[string]string, len(result.Tools))
+		for _, tool := range result.Tools {
+			if group, ok := groups[tool.Name]; ok {
+				listed[tool.Name] = group
+			}
+		}
+		if len(listed) == 0 {
+			return
+		}
+		if result.Meta == nil {
+			result.Meta = make(map[string]any)
+		}
+		result.Meta[GroupsMetaKey] = listed
+	})
+}
+
+// toolGroups maps every manifest tool to its group
+func toolGroups(manifests []*Manifest) map[string]string {
+	groups := make(map[string]string)
+	for _, m := range manifests {
+		for name := range m.Tools {
+			groups[name] = m.GroupOf(name)
+		}
+	}
+	return groups
+}
+
+// joinWords renders ["a", "b", "c"] as "a, b and c"
+func joinWords(words []string) string {
+	switch len(words) {
+	case 0:
+		return ""
+	case 1:
+		return words[0]
+	default:
+		return strings.Join(words[:len(words)-1], ", ") + " and " + words[len(words)-1]
+	}
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,125 @@
+package manifest
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func testManifest() *Manifest {
+	return &Manifest{
+		Adapter: "demo",
+		Service: "Demo",
+		Account: "The demo account",
+		Tools: map[string]ToolAccess{
+			"demo_list":   {Reads: []string{"items"}},
+			"demo_edit":   {Reads: []string{"items", "folders"}, Writes: []string{"items"}},
+			"demo_status": {},
+		},
+	}
+}
+
+func TestPermissions(t *testing.T) {
+	t.Logf("Importance: Approval dialogs show these descriptions to users deciding whether to allow a tool call.")
+
+	perms := testManifest().Permissions()
+
+	cases := map[string]struct {
+		access      string
+		description string
+	}{
+		"demo_list":   {AccessRead, "Reads items in your Demo account."},
+		"demo_edit":   {AccessWrite, "Reads items and folders, and changes items in your Demo account."},
+		"demo_status": {AccessNone, "Does not access your Demo account."},
+	}
+	for name, want := range cases {
+		got := perms[name]
+		if got.Access != want.access || got.Description != want.description {
+			t.Errorf("%s: expected %s %q, got %s %q", name, want.access, want.description, got.Access, got.Description)
+		}
+		if got.Account != "The demo account" || got.Adapter != "demo" {
+			t.Errorf("%s: expected adapter and account to be copied, got %+v", name, got)
+		}
+	}
+}
+
+func TestAttachPermissions(t *testing.T) {
+	t.Logf("Importance: Descriptors and groups only help if they actually reach clients in the tools/list response.")
+
+	hooks := &server.Hooks{}
+	AttachPermissions(hooks, testManifest())
+	AttachGroups(hooks, testManifest())
+
+	s := server.NewMCPServer("test", "1.0.0", server.WithToolCapabilities(false), server.WithHooks(hooks))
+	s.AddTool(mcp.NewTool("demo_list"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return nil, nil
+	})
+	s.AddTool(mcp.NewTool("unlisted"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return nil, nil
+	})
+
+	resp := s.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+	data, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatalf("Failed to marshal response: %v", err)
+	}
+
+	var decoded struct {
+		Result struct {
+			Meta struct {
+				Permissions map[string]Permission `json:"permissions"`
+				Groups      map[string]string     `json:"groups"`
+			} `json:"_meta"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	perms := decoded.Result.Meta.Permissions
+	if perms["demo_list"].Access != AccessRead {
+		t.Errorf("Expected read descriptor for demo_list, got %+v", perms)
+	}
+	if _, ok := perms["unlisted"]; ok {
+		t.Errorf("Expected no descriptor for tools missing from the manifest")
+	}
+	if _, ok := perms["demo_edit"]; ok {
+		t.Errorf("Expected no descriptor for manifest tools that are not registered")
+	}
+	if groups := decoded.Result.Meta.Groups; groups["demo_list"] != "demo" || groups["unlisted"] != "" {
+		t.Errorf("Expected demo_list in group demo only, got %v", groups)
+	}
+}
+
+func TestAdapterTools(t *testing.T) {
+	t.Logf("Importance: Adapter initialization is bound to these tools, so shared admin tools must not trigger it.")
+
+	m := testManifest()
+	m.Tools["demo_admin"] = ToolAccess{Group: GroupAdmin}
+
+	got := m.AdapterTools()
+	want := []string{"demo_edit", "demo_list", "demo_status"}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestExclusiveScopes(t *testing.T) {
+	t.Logf("Importance: Only tools that declared a scope may be serialized; everything else must run freely.")
+
+	m := testManifest()
+	m.Tools["demo_batch"] = ToolAccess{Writes: []string{"items"}, Exclusive: "batch"}
+
+	scopes := m.ExclusiveScopes()
+	if len(scopes) != 1 || scopes["demo_batch"] != "batch" {
+		t.Errorf("Expected only demo_batch in scope batch, got %v", scopes)
+	}
+}
@@ -0,0 +1,83 @@
+package manifest
+
+import (
+	"context"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/vcto/mcp-adapters/internal/health"
+)
+
+// RetryMetaKey is the tools/list _meta key mapping tool names to their retry policy
+const RetryMetaKey = "retry"
+
+// RetryPolicy is a tool's declared retry behavior
+type RetryPolicy struct {
+	Safe bool `json:"safe"`
+}
+
+// RetrySafe reports whether repeating the tool after a failure cannot
+// duplicate its effects
+func (access ToolAccess) RetrySafe() bool {
+	return len(access.Writes) == 0 || access.Idempotent
+}
+
+// AttachRetryPolicies adds an after-list-tools hook that publishes whether each
+// listed tool is safe to retry under _meta.retry, keyed by tool name
+func AttachRetryPolicies(hooks *server.Hooks, manifests ...*Manifest) {
+	policies := retryPolicies(manifests)
+
+	hooks.AddAfterListTools(func(ctx context.Context, id any, message *mcp.ListToolsRequest, result *mcp.ListToolsResult) {
+		listed := make(map[string]RetryPolicy, len(result.Tools))
+		for _, tool := range result.Tools {
+			if policy, ok := policies[tool.Name]; ok {
+				listed[tool.Name] = policy
+			}
+		}
+		if len(listed) == 0 {
+			return
+		}
+		if result.Meta == nil {
+			result.Meta = make(map[string]any)
+		}
+		result.Meta[RetryMetaKey] = listed
+	})
+}
+
+// RetryMiddleware gives every tool error a retry hint in _meta.retry. Hints
+// set by handlers for transient failures are withdrawn for tools declared
+// unsafe to repeat; errors without a hint are marked not retryable.
+func RetryMiddleware(manifests ...*Manifest) server.ToolHandlerMiddleware {
+	policies := retryPolicies(manifests)
+
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			result, err := next(ctx, request)
+			if err != nil || result == nil || !result.IsError {
+				return result, err
+			}
+
+			hint, ok := health.GetRetryHint(result)
+			if !ok {
+				hint = health.RetryHint{Reason: "not a transient failure"}
+			}
+			policy, declared := policies[request.Params.Name]
+			if hint.Retryable && declared && !policy.Safe {
+				hint = health.RetryHint{Reason: "tool is not safe to repeat automatically; check whether it took effect first"}
+			}
+			health.SetRetryHint(result, hint)
+			return result, nil
+		}
+	}
+}
+
+// retryPolicies maps every manifest tool to its retry policy
+func retryPolicies(manifests []*Manifest) map[string]RetryPolicy {
+	policies := make(map[string]RetryPolicy)
+	for _, m := range manifests {
+		for name, access := range m.Tools {
+			policies[name] = RetryPolicy{Safe: access.RetrySafe()}
+		}
+	}
+	return policies
+}
@@ -0,0 +1,61 @@
+package manifest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/vcto/mcp-adapters/internal/health"
+)
+
+func TestRetryMiddleware(t *testing.T) {
+	t.Logf("Importance: Retrying a tool that is not idempotent after a timeout can duplicate its writes.")
+
+	m := testManifest()
+	m.Tools["demo_create"] = ToolAccess{Writes: []string{"items"}}
+	m.Tools["demo_set"] = ToolAccess{Writes: []string{"items"}, Idempotent: true}
+
+	outage := health.Upstream(errors.New("upstream timed out"))
+	handler := RetryMiddleware(m)(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if request.Params.Name == "demo_edit_bad_input" {
+			return mcp.NewToolResultError("name is required"), nil
+		}
+		return health.ToolError("Failed", outage), nil
+	})
+
+	cases := map[string]bool{
+		"demo_list":           true,  // read-only
+		"demo_set":            true,  // declared idempotent
+		"demo_create":         false, // might have created the item before timing out
+		"demo_edit_bad_input": false, // not transient
+	}
+	for tool, retryable := range cases {
+		t.Run(tool, func(t *testing.T) {
+			request := mcp.CallToolRequest{}
+			request.Params.Name = tool
+			result, err := handler(context.Background(), request)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			hint, ok := health.GetRetryHint(result)
+			if !ok {
+				t.Fatal("Expected every error to carry a retry hint")
+			}
+			if hint.Retryable != retryable {
+				t.Errorf("Expected retryable=%v, got %+v", retryable, hint)
+			}
+		})
+	}
+
+	t.Run("successful results are untouched", func(t *testing.T) {
+		t.Logf("  > Why it's important: Hints only make sense on failures.")
+		ok := RetryMiddleware(m)(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mcp.NewToolResultText("done"), nil
+		})
+		result, _ := ok(context.Background(), mcp.CallToolRequest{})
+		if _, present := health.GetRetryHint(result); present {
+			t.Error("Expected no retry hint on success")
+		}
+	})
+}
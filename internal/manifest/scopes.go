@@ -0,0 +1,46 @@
+package manifest
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/vcto/mcp-adapters/internal/auth"
+)
+
+// WriteScope is the OAuth scope a token needs to use the adapter's tools
+// that change upstream data, e.g. "rtm:write"
+func (m *Manifest) WriteScope() string {
+	return m.Adapter + ":write"
+}
+
+// ScopeMiddleware rejects calls to tools declaring writes when the request's
+// token was granted scopes without the adapter's write scope, such as a
+// token limited to rtm:read. Tokens without scopes are not limited, and
+// calls through call_grouped are checked against the tool they run.
+func ScopeMiddleware(manifests ...*Manifest) server.ToolHandlerMiddleware {
+	required := make(map[string]string)
+	for _, m := range manifests {
+		for name, access := range m.Tools {
+			if len(access.Writes) > 0 {
+				required[name] = m.WriteScope()
+			}
+		}
+	}
+
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			scope, writes := required[request.Params.Name]
+			if !writes {
+				return next(ctx, request)
+			}
+			if granted, limited := auth.ScopesFromContext(ctx); limited && !auth.HasScope(granted, scope) {
+				return mcp.NewToolResultError(fmt.Sprintf(
+					"%s changes data, but this connection was not granted %s. Reconnect and allow write access to use it.",
+					request.Params.Name, scope)), nil
+			}
+			return next(ctx, request)
+		}
+	}
+}
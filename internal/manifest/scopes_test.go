@@ -0,0 +1,52 @@
+package manifest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/vcto/mcp-adapters/internal/auth"
+)
+
+func TestScopeMiddleware(t *testing.T) {
+	t.Logf("Importance: A client granted only read access must not be able to change the user's data.")
+
+	m := testManifest()
+	m.Tools["demo_create"] = ToolAccess{Writes: []string{"items"}}
+
+	handler := ScopeMiddleware(m)(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("done"), nil
+	})
+	call := func(ctx context.Context, tool string) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = tool
+		result, err := handler(ctx, request)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return result
+	}
+
+	readOnly := auth.WithScopes(context.Background(), []string{"demo:read"})
+	readWrite := auth.WithScopes(context.Background(), []string{"demo:read", "demo:write"})
+
+	cases := []struct {
+		name    string
+		ctx     context.Context
+		tool    string
+		allowed bool
+	}{
+		{"read tool with read scope", readOnly, "demo_list", true},
+		{"write tool with read scope", readOnly, "demo_create", false},
+		{"write tool with write scope", readWrite, "demo_create", true},
+		{"write tool without scopes", context.Background(), "demo_create", true}, // token predates scopes
+		{"tool outside manifests", readOnly, "unknown_tool", true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if result := call(tc.ctx, tc.tool); result.IsError == tc.allowed {
+				t.Errorf("Expected allowed=%v, got %+v", tc.allowed, result)
+			}
+		})
+	}
+}
@@ -21,7 +21,7 @@ func DefaultCORSConfig() CORSConfig {
 	return CORSConfig{
 		AllowOrigins:     []string{"https://claude.ai"},
 		AllowMethods:     []string{"GET", "POST", "OPTIONS"},
-		AllowHeaders:     []string{"Accept", "Content-Type", "Authorization", "X-Requested-With"},
+		AllowHeaders:     []string{"Accept", "Content-Type", "Authorization", "X-Requested-With", "X-Request-Deadline"},
 		ExposeHeaders:    []string{"X-Request-ID"},
 		AllowCredentials: true,
 		MaxAge:           3600,
@@ -0,0 +1,170 @@
+// Package ratelimit paces calls to third-party APIs with a token bucket
+// shared by every caller of one client, and holds them all back when the
+// API signals throttling.
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// maxBackoffShift caps the exponential backoff at 2^6 = 64 seconds
+const maxBackoffShift = 6
+
+// Limiter paces requests with a token bucket. Callers wait for a token; a
+// pause, either asked for by the API or started by Backoff, holds every
+// caller until it ends.
+type Limiter struct {
+	mu         sync.Mutex
+	perSecond  float64
+	burst      float64
+	tokens     float64
+	last       time.Time
+	pauseUntil time.Time
+	throttled  int64 // consecutive Backoff calls
+	metrics    Metrics
+	totalWait  time.Duration
+	waits      int64
+}
+
+// Metrics counts what the limiter has done
+type Metrics struct {
+	RequestsTotal   int64
+	RequestsBlocked int64
+	BurstUsed       int64
+	// Throttled is how many times in a row Backoff has been called
+	Throttled   int64
+	AvgWaitTime time.Duration
+}
+
+// New allows perSecond requests a second on average, with bursts of up to
+// burst requests
+func New(perSecond, burst float64) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{perSecond: perSecond, burst: burst, tokens: burst, last: time.Now()}
+}
+
+// Rate returns the requests per second the limiter allows
+func (l *Limiter) Rate() float64 {
+	return l.perSecond
+}
+
+// Wait blocks until a request may be sent or ctx is done
+func (l *Limiter) Wait(ctx context.Context) error {
+	start := time.Now()
+	defer l.recordWait(start)
+
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		var wait time.Duration
+		if now.Before(l.pauseUntil) {
+			wait = l.pauseUntil.Sub(now)
+		} else {
+			l.refill(now)
+			if l.tokens >= 1 {
+				l.tokens--
+				l.metrics.RequestsTotal++
+				if l.tokens < l.burst-1 {
+					l.metrics.BurstUsed++
+				}
+				l.mu.Unlock()
+				return nil
+			}
+			wait = time.Duration((1 - l.tokens) / l.perSecond * float64(time.Second))
+			l.metrics.RequestsBlocked++
+		}
+		l.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// Pause holds every caller for d, as an API asks after throttling
+func (l *Limiter) Pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pauseLocked(d)
+}
+
+// Backoff pauses callers for a time that doubles with each consecutive
+// call, from 2 seconds up to 64, for APIs that throttle without saying for
+// how long. Saved-up burst is dropped too.
+func (l *Limiter) Backoff() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.throttled++
+	shift := l.throttled
+	if shift > maxBackoffShift {
+		shift = maxBackoffShift
+	}
+	l.pauseLocked(time.Duration(int64(1)<<shift) * time.Second)
+	l.tokens = 0
+}
+
+// ResetBackoff ends the pause and starts the backoff over, after a request
+// got through
+func (l *Limiter) ResetBackoff() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.throttled = 0
+	l.pauseUntil = time.Time{}
+}
+
+// Metrics returns a snapshot of the limiter's counters
+func (l *Limiter) Metrics() Metrics {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	metrics := l.metrics
+	metrics.Throttled = l.throttled
+	if l.waits > 0 {
+		metrics.AvgWaitTime = l.totalWait / time.Duration(l.waits)
+	}
+	return metrics
+}
+
+// EstimateDuration estimates how long the next n requests will take
+func (l *Limiter) EstimateDuration(n int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill(time.Now())
+	available := int(l.tokens)
+	if n <= available {
+		return 100 * time.Millisecond // Nearly instant with burst
+	}
+	return time.Duration(float64(n-available) / l.perSecond * float64(time.Second))
+}
+
+// refill adds the tokens earned since the last refill. Callers hold l.mu.
+func (l *Limiter) refill(now time.Time) {
+	l.tokens += now.Sub(l.last).Seconds() * l.perSecond
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+}
+
+// pauseLocked extends the pause to d from now. Callers hold l.mu.
+func (l *Limiter) pauseLocked(d time.Duration) {
+	if until := time.Now().Add(d); until.After(l.pauseUntil) {
+		l.pauseUntil = until
+	}
+}
+
+func (l *Limiter) recordWait(start time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.totalWait += time.Since(start)
+	l.waits++
+}
@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	t.Logf("Importance: RTM and Spektrix both throttle clients that call too fast; the shared limiter is what keeps batch jobs from failing halfway.")
+
+	t.Run("paces requests", func(t *testing.T) {
+		t.Logf("  > Why it's important: Bursts beyond the limit must queue for tokens.")
+		limiter := New(50, 50)
+		start := time.Now()
+		for i := 0; i < 60; i++ {
+			if err := limiter.Wait(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// 50 come from the burst, the other 10 at 50 a second
+		if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+			t.Errorf("Expected 60 requests to take about 200ms, took %v", elapsed)
+		}
+		if metrics := limiter.Metrics(); metrics.RequestsTotal != 60 || metrics.RequestsBlocked == 0 || metrics.BurstUsed == 0 {
+			t.Errorf("Expected 60 requests with some blocked, got %+v", metrics)
+		}
+	})
+
+	t.Run("pauses every caller", func(t *testing.T) {
+		t.Logf("  > Why it's important: After a throttling response nobody may send, even with tokens left.")
+		limiter := New(100, 100)
+		limiter.Pause(time.Minute)
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if err := limiter.Wait(ctx); err == nil {
+			t.Error("Expected a wait during a pause to run out of time")
+		}
+
+		limiter.ResetBackoff()
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Errorf("Expected the reset to end the pause, got %v", err)
+		}
+	})
+
+	t.Run("backs off exponentially", func(t *testing.T) {
+		t.Logf("  > Why it's important: An API that keeps throttling must see callers slow down further each time.")
+		limiter := New(100, 100)
+		var pauses []time.Duration
+		for i := 0; i < 8; i++ {
+			limiter.Backoff()
+			limiter.mu.Lock()
+			pauses = append(pauses, time.Until(limiter.pauseUntil).Round(time.Second))
+			limiter.pauseUntil = time.Time{}
+			limiter.mu.Unlock()
+		}
+		want := []time.Duration{2, 4, 8, 16, 32, 64, 64, 64}
+		for i := range want {
+			if pauses[i] != want[i]*time.Second {
+				t.Errorf("Backoff %d: expected %v, got %v", i+1, want[i]*time.Second, pauses[i])
+			}
+		}
+		if metrics := limiter.Metrics(); metrics.Throttled != 8 {
+			t.Errorf("Expected 8 consecutive throttles, got %d", metrics.Throttled)
+		}
+		limiter.ResetBackoff()
+		if metrics := limiter.Metrics(); metrics.Throttled != 0 {
+			t.Errorf("Expected the reset to clear the count, got %d", metrics.Throttled)
+		}
+	})
+
+	t.Run("estimates batches", func(t *testing.T) {
+		t.Logf("  > Why it's important: Batch tools report an ETA computed from the limiter.")
+		limiter := New(2, 3)
+		if got := limiter.EstimateDuration(3); got > time.Second {
+			t.Errorf("Expected the burst to cover 3 requests, got %v", got)
+		}
+		if got := limiter.EstimateDuration(7); got < 1900*time.Millisecond || got > 2*time.Second {
+			t.Errorf("Expected 4 requests beyond the burst to take 2s, got %v", got)
+		}
+	})
+}
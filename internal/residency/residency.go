@@ -0,0 +1,219 @@
+// Package residency tags stored user data with the region it was written in
+// and routes storage to region-local paths, for deployments that run
+// machines in several Fly.io regions.
+package residency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vcto/mcp-adapters/internal/kv"
+)
+
+// Store names used when recording where data lives
+const (
+	StoreTokens        = "tokens"
+	StoreDebug         = "debug"
+	StoreSavedSearches = "saved_searches"
+)
+
+// ledgerBucket is the kv bucket holding residency entries
+const ledgerBucket = "residency"
+
+// recordInterval limits how often repeat writes for the same entry hit the store
+const recordInterval = time.Minute
+
+// Region returns the region this machine stores data in: DATA_REGION if set,
+// otherwise Fly's FLY_REGION, otherwise "local"
+func Region() string {
+	if region := os.Getenv("DATA_REGION"); region != "" {
+		return region
+	}
+	if region := os.Getenv("FLY_REGION"); region != "" {
+		return region
+	}
+	return "local"
+}
+
+// RoutingEnabled reports whether DATA_RESIDENCY_ROUTING asks for storage
+// paths to be split by region
+func RoutingEnabled() bool {
+	return os.Getenv("DATA_RESIDENCY_ROUTING") == "true"
+}
+
+// StoragePath routes a database path into a directory for the current
+// region, so /data/tokens.db becomes /data/<region>/tokens.db. Paths are
+// returned unchanged when routing is disabled or the database is in memory.
+func StoragePath(path string) string {
+	if !RoutingEnabled() || path == "" || strings.HasPrefix(path, ":memory:") {
+		return path
+	}
+	return filepath.Join(filepath.Dir(path), Region(), filepath.Base(path))
+}
+
+// SubjectID derives a stable, non-reversible subject ID from a secret such
+// as an auth token, so the ledger never stores credentials
+func SubjectID(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Entry records that a subject has data in a store in a region
+type Entry struct {
+	Subject   string    `json:"subject"`
+	Store     string    `json:"store"`
+	Region    string    `json:"region"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// Ledger tracks which stores and regions hold each subject's data. Each
+// region's machines see the entries in their own kv store.
+type Ledger struct {
+	entries *kv.Bucket[Entry]
+	region  string
+
+	mu      sync.Mutex
+	flushed map[string]time.Time
+	now     func() time.Time
+}
+
+// NewLedger creates a ledger for the given region backed by store
+func NewLedger(store kv.Store, region string) *Ledger {
+	return &Ledger{
+		entries: kv.NewBucket[Entry](store, ledgerBucket),
+		region:  region,
+		flushed: make(map[string]time.Time),
+		now:     time.Now,
+	}
+}
+
+// Region is the region the ledger records entries for
+func (l *Ledger) Region() string {
+	return l.region
+}
+
+// Record notes that subject has data in store in the ledger's region
+func (l *Ledger) Record(subject, store string) {
+	if l == nil || subject == "" {
+		return
+	}
+
+	key := subject + "|" + store + "|" + l.region
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Hot paths such as debug logging write often; refresh last_seen at most once a minute
+	if last, ok := l.flushed[key]; ok && now.Sub(last) < recordInterval {
+		return
+	}
+
+	entry, ok, err := l.entries.Get(key)
+	if err != nil {
+		log.Printf("Residency: failed to read ledger: %v", err)
+		return
+	}
+	if !ok {
+		entry = Entry{Subject: subject, Store: store, Region: l.region, FirstSeen: now}
+	}
+	entry.LastSeen = now
+
+	if err := l.entries.Put(key, entry, 0); err != nil {
+		log.Printf("Residency: failed to update ledger: %v", err)
+		return
+	}
+	l.flushed[key] = now
+}
+
+// SubjectReport lists where one subject's data lives
+type SubjectReport struct {
+	Subject   string   `json:"subject"`
+	Regions   []string `json:"regions"`
+	Locations []Entry  `json:"locations"`
+}
+
+// Report groups ledger entries by subject. A non-empty subject limits the
+// report to that subject.
+func (l *Ledger) Report(subject string) ([]SubjectReport, error) {
+	keys, err := l.entries.Keys()
+	if err != nil {
+		return nil, err
+	}
+
+	bySubject := make(map[string]*SubjectReport)
+	for _, key := range keys {
+		if subject != "" && !strings.HasPrefix(key, subject+"|") {
+			continue
+		}
+		entry, ok, err := l.entries.Get(key)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		report, exists := bySubject[entry.Subject]
+		if !exists {
+			report = &SubjectReport{Subject: entry.Subject, Regions: []string{}, Locations: []Entry{}}
+			bySubject[entry.Subject] = report
+		}
+		report.Locations = append(report.Locations, entry)
+		if !containsString(report.Regions, entry.Region) {
+			report.Regions = append(report.Regions, entry.Region)
+		}
+	}
+
+	reports := make([]SubjectReport, 0, len(bySubject))
+	for _, report := range bySubject {
+		sort.Strings(report.Regions)
+		reports = append(reports, *report)
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].Subject < reports[j].Subject
+	})
+	return reports, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	defaultMu     sync.RWMutex
+	defaultLedger *Ledger
+)
+
+// SetDefault installs the ledger that stores record into
+func SetDefault(l *Ledger) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultLedger = l
+}
+
+// Default returns the installed ledger, or nil if none is installed
+func Default() *Ledger {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultLedger
+}
+
+// Record notes a write in the default ledger; it does nothing when no
+// ledger is installed
+func Record(subject, store string) {
+	Default().Record(subject, store)
+}
@@ -0,0 +1,93 @@
+package residency
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vcto/mcp-adapters/internal/kv"
+)
+
+func TestStoragePath(t *testing.T) {
+	t.Logf("Importance: With routing on, each region's machines must write to their own database files.")
+
+	t.Setenv("FLY_REGION", "ams")
+	t.Setenv("DATA_REGION", "")
+
+	t.Setenv("DATA_RESIDENCY_ROUTING", "")
+	if got := StoragePath("/data/tokens.db"); got != "/data/tokens.db" {
+		t.Errorf("Expected unchanged path without routing, got %s", got)
+	}
+
+	t.Setenv("DATA_RESIDENCY_ROUTING", "true")
+	if got := StoragePath("/data/tokens.db"); got != filepath.Join("/data", "ams", "tokens.db") {
+		t.Errorf("Expected region-local path, got %s", got)
+	}
+	if got := StoragePath(":memory:"); got != ":memory:" {
+		t.Errorf("Expected in-memory databases to be left alone, got %s", got)
+	}
+
+	t.Setenv("DATA_REGION", "eu-west")
+	if got := Region(); got != "eu-west" {
+		t.Errorf("Expected DATA_REGION to override FLY_REGION, got %s", got)
+	}
+}
+
+func TestLedger(t *testing.T) {
+	t.Logf("Importance: Admins answering data-location requests need an accurate list of where each subject's data lives.")
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	store := kv.NewMemoryStore()
+
+	ams := NewLedger(store, "ams")
+	ams.now = func() time.Time { return now }
+	ord := NewLedger(store, "ord")
+	ord.now = func() time.Time { return now }
+
+	ams.Record("user-a", StoreTokens)
+	ams.Record("user-a", StoreDebug)
+	ord.Record("user-a", StoreTokens)
+	ams.Record("user-b", StoreTokens)
+
+	reports, err := ams.Report("")
+	if err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+	if len(reports) != 2 || reports[0].Subject != "user-a" {
+		t.Fatalf("Expected reports for user-a and user-b, got %+v", reports)
+	}
+	if regions := reports[0].Regions; len(regions) != 2 || regions[0] != "ams" || regions[1] != "ord" {
+		t.Errorf("Expected user-a in ams and ord, got %v", regions)
+	}
+	if len(reports[0].Locations) != 3 {
+		t.Errorf("Expected three locations for user-a, got %+v", reports[0].Locations)
+	}
+
+	t.Run("filters by subject", func(t *testing.T) {
+		t.Logf("  > Why it's important: Reports for one subject must not leak others.")
+		reports, err := ams.Report("user-b")
+		if err != nil || len(reports) != 1 || reports[0].Subject != "user-b" {
+			t.Errorf("Expected only user-b, got %+v (err=%v)", reports, err)
+		}
+	})
+
+	t.Run("keeps first seen and refreshes last seen", func(t *testing.T) {
+		t.Logf("  > Why it's important: Retention reviews rely on when data was first and last written.")
+		now = now.Add(30 * time.Second)
+		ams.Record("user-b", StoreTokens) // throttled
+		now = now.Add(time.Minute)
+		ams.Record("user-b", StoreTokens)
+
+		reports, _ := ams.Report("user-b")
+		entry := reports[0].Locations[0]
+		if !entry.FirstSeen.Equal(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)) || !entry.LastSeen.Equal(now) {
+			t.Errorf("Unexpected timestamps: %+v", entry)
+		}
+	})
+
+	t.Run("nil ledger ignores records", func(t *testing.T) {
+		t.Logf("  > Why it's important: Stores record unconditionally; servers without a ledger must not crash.")
+		var ledger *Ledger
+		ledger.Record("user-a", StoreTokens)
+	})
+}
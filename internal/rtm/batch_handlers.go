@@ -10,7 +10,10 @@ import (
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/vcto/mcp-adapters/internal/exclusive"
+	"github.com/vcto/mcp-adapters/internal/health"
 	"github.com/vcto/mcp-adapters/internal/longrunning"
+	"github.com/vcto/mcp-adapters/internal/ratelimit"
 )
 
 // SetupBatchTools adds RTM batch operation tools with progress support
@@ -19,7 +22,7 @@ func (h *Handler) SetupBatchTools(s *server.MCPServer, taskManager *longrunning.
 	handlerWithManager := &batchHandler{
 		Handler:     h,
 		taskManager: taskManager,
-		rateLimiter: NewRateLimiter(),
+		rateLimiter: h.client.Limiter,
 	}
 
 	// Batch update due dates
@@ -56,11 +59,17 @@ func (h *Handler) SetupBatchTools(s *server.MCPServer, taskManager *longrunning.
 	), handlerWithManager.createJobStatusHandler())
 }
 
+// LockSubject identifies the current RTM user for exclusive tool locks
+func (h *Handler) LockSubject(ctx context.Context) string {
+	return intentOwner(h.ClientFor(ctx).GetAuthToken())
+}
+
 // batchHandler wraps Handler with task manager
 type batchHandler struct {
 	*Handler
 	taskManager *longrunning.Manager
-	rateLimiter *RateLimiter
+	// rateLimiter is the client's shared limiter, used here for ETA estimates
+	rateLimiter *ratelimit.Limiter
 }
 
 // BatchOperation represents a batch operation function
@@ -85,40 +94,38 @@ func (h *batchHandler) createBatchHandler(operation BatchOperation) func(context
 			return mcp.NewToolResultError(fmt.Sprintf("Invalid positions: %v", err)), nil
 		}
 
-		// Get session ID (would come from connection context in real implementation)
-		sessionID := "default-session" // TODO: Get from connection context
-
-		// Run with progress tracking
-		result, err := longrunning.RunWithProgress(ctx, request, h.taskManager, sessionID,
-			func(ctx context.Context, task *longrunning.Task) (*mcp.CallToolResult, error) {
-				// If no progress tracking, run synchronously
-				if task == nil {
-					return h.runBatchSynchronously(ctx, positions, args, operation)
-				}
-
-				// Run asynchronously with progress
-				jobID := task.ID()
-
-				// Start operation in background
-				go func() {
-					defer task.Complete()
-					if err := operation(ctx, task, positions, args); err != nil {
-						task.CompleteWithError(err)
-					}
-				}()
-
-				// Return job ID immediately
-				return &mcp.CallToolResult{
-					Content: []mcp.Content{
-						mcp.TextContent{
-							Type: "text",
-							Text: fmt.Sprintf("Batch operation started\nJob ID: %s\nUse check_rtm_job_status to monitor progress", jobID),
-						},
-					},
-				}, nil
-			})
+		// The operation outlives this call, so only the task can cancel it.
+		// Without a progress token, run synchronously.
+		taskCtx, task, hasProgress := longrunning.WithProgress(context.WithoutCancel(ctx), request, h.taskManager, longrunning.SessionID(ctx))
+		if !hasProgress {
+			return h.runBatchSynchronously(ctx, positions, args, operation)
+		}
+
+		// Run asynchronously with progress
+		jobID := task.ID()
+
+		// The job keeps any exclusive lock until it finishes
+		lease := exclusive.Detach(ctx)
+		lease.SetJob(jobID)
+
+		// Start operation in background
+		go func() {
+			defer lease.Release()
+			defer task.Complete()
+			if err := operation(taskCtx, task, positions, args); err != nil {
+				task.CompleteWithError(err)
+			}
+		}()
 
-		return result, err
+		// Return job ID immediately
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Batch operation started\nJob ID: %s\nProgress notifications follow as each task is processed", jobID),
+				},
+			},
+		}, nil
 	}
 }
 
@@ -127,7 +134,7 @@ func (h *batchHandler) runBatchSynchronously(ctx context.Context, positions []in
 	// For synchronous operation, we pass nil task since there's no progress tracking
 	err := operation(ctx, nil, positions, args)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Batch operation failed: %v", err)), nil
+		return health.ToolError(fmt.Sprintf("Batch operation failed: %v", err), err), nil
 	}
 
 	return &mcp.CallToolResult{
@@ -143,6 +150,7 @@ func (h *batchHandler) runBatchSynchronously(ctx context.Context, positions []in
 // Batch operation implementations
 
 func (h *batchHandler) handleBatchSetDueDate(ctx context.Context, task *longrunning.Task, positions []int, args map[string]any) error {
+	client := h.ClientFor(ctx)
 	dueDate, _ := args["due_date"].(string)
 	if dueDate == "" {
 		return fmt.Errorf("due_date is required")
@@ -166,25 +174,14 @@ func (h *batchHandler) handleBatchSetDueDate(ctx context.Context, task *longrunn
 			return err
 		}
 
-		// Wait for rate limit
-		if err := h.rateLimiter.Wait(ctx); err != nil {
-			return fmt.Errorf("rate limit wait failed: %w", err)
-		}
-
 		// Update task
 		updates := map[string]string{"due": dueDate}
-		err := h.client.UpdateTask(t.ListID, t.SeriesID, t.ID, updates)
+		err := client.UpdateTask(t.ListID, t.SeriesID, t.ID, updates)
 		if err != nil {
-			// Check if it's a rate limit error
-			if rtmErr, ok := err.(*RTMError); ok && rtmErr.Code == 98 {
-				h.rateLimiter.HandleError503()
-			}
 			if task != nil {
 				progress, _ := task.GetProgress()
 				_ = task.UpdateProgress(progress, fmt.Sprintf("Failed to update task %s: %v", t.Name, err))
 			}
-		} else {
-			h.rateLimiter.ResetBackoff()
 		}
 
 		// Report progress with time estimate
@@ -200,6 +197,7 @@ func (h *batchHandler) handleBatchSetDueDate(ctx context.Context, task *longrunn
 }
 
 func (h *batchHandler) handleBatchSetPriority(ctx context.Context, task *longrunning.Task, positions []int, args map[string]any) error {
+	client := h.ClientFor(ctx)
 	priority, _ := args["priority"].(string)
 	if priority == "" {
 		return fmt.Errorf("priority is required")
@@ -220,22 +218,13 @@ func (h *batchHandler) handleBatchSetPriority(ctx context.Context, task *longrun
 			return err
 		}
 
-		if err := h.rateLimiter.Wait(ctx); err != nil {
-			return fmt.Errorf("rate limit wait failed: %w", err)
-		}
-
 		updates := map[string]string{"priority": priority}
-		err := h.client.UpdateTask(t.ListID, t.SeriesID, t.ID, updates)
+		err := client.UpdateTask(t.ListID, t.SeriesID, t.ID, updates)
 		if err != nil {
-			if rtmErr, ok := err.(*RTMError); ok && rtmErr.Code == 98 {
-				h.rateLimiter.HandleError503()
-			}
 			if task != nil {
 				progress, _ := task.GetProgress()
 				_ = task.UpdateProgress(progress, fmt.Sprintf("Failed: %v", err))
 			}
-		} else {
-			h.rateLimiter.ResetBackoff()
 		}
 
 		if processor != nil {
@@ -250,6 +239,7 @@ func (h *batchHandler) handleBatchSetPriority(ctx context.Context, task *longrun
 }
 
 func (h *batchHandler) handleBatchAddTags(ctx context.Context, task *longrunning.Task, positions []int, args map[string]any) error {
+	client := h.ClientFor(ctx)
 	tags, _ := args["tags"].(string)
 	if tags == "" {
 		return fmt.Errorf("tags are required")
@@ -270,10 +260,6 @@ func (h *batchHandler) handleBatchAddTags(ctx context.Context, task *longrunning
 			return err
 		}
 
-		if err := h.rateLimiter.Wait(ctx); err != nil {
-			return fmt.Errorf("rate limit wait failed: %w", err)
-		}
-
 		// Get existing tags and add new ones
 		existingTags := "" // TODO: Get from task
 		allTags := existingTags
@@ -283,17 +269,12 @@ func (h *batchHandler) handleBatchAddTags(ctx context.Context, task *longrunning
 		allTags += tags
 
 		updates := map[string]string{"tags": allTags}
-		err := h.client.UpdateTask(t.ListID, t.SeriesID, t.ID, updates)
+		err := client.UpdateTask(t.ListID, t.SeriesID, t.ID, updates)
 		if err != nil {
-			if rtmErr, ok := err.(*RTMError); ok && rtmErr.Code == 98 {
-				h.rateLimiter.HandleError503()
-			}
 			if task != nil {
 				progress, _ := task.GetProgress()
 				_ = task.UpdateProgress(progress, fmt.Sprintf("Failed: %v", err))
 			}
-		} else {
-			h.rateLimiter.ResetBackoff()
 		}
 
 		if processor != nil {
@@ -308,6 +289,7 @@ func (h *batchHandler) handleBatchAddTags(ctx context.Context, task *longrunning
 }
 
 func (h *batchHandler) handleBatchComplete(ctx context.Context, task *longrunning.Task, positions []int, args map[string]any) error {
+	client := h.ClientFor(ctx)
 	tasks, err := h.getCachedTasksByPositions(positions)
 	if err != nil {
 		return err
@@ -323,21 +305,12 @@ func (h *batchHandler) handleBatchComplete(ctx context.Context, task *longrunnin
 			return err
 		}
 
-		if err := h.rateLimiter.Wait(ctx); err != nil {
-			return fmt.Errorf("rate limit wait failed: %w", err)
-		}
-
-		err := h.client.CompleteTask(t.ListID, t.SeriesID, t.ID)
+		err := client.CompleteTask(t.ListID, t.SeriesID, t.ID)
 		if err != nil {
-			if rtmErr, ok := err.(*RTMError); ok && rtmErr.Code == 98 {
-				h.rateLimiter.HandleError503()
-			}
 			if task != nil {
 				progress, _ := task.GetProgress()
 				_ = task.UpdateProgress(progress, fmt.Sprintf("Failed: %v", err))
 			}
-		} else {
-			h.rateLimiter.ResetBackoff()
 		}
 
 		if processor != nil {
@@ -0,0 +1,141 @@
+package rtm
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// CalendarURI is the resource serving upcoming tasks as an iCalendar feed
+const CalendarURI = "rtm://calendar.ics"
+
+// CalendarMIMEType is the MIME type of the calendar feed
+const CalendarMIMEType = "text/calendar"
+
+// DefaultCalendarDays is how many days ahead the calendar feed covers
+const DefaultCalendarDays = 14
+
+// icsLineLimit is the longest content line RFC 5545 allows, in octets
+const icsLineLimit = 75
+
+// CalendarDaysFromEnv reads the feed window from RTM_CALENDAR_DAYS, returning
+// DefaultCalendarDays when it is unset or not a positive number of days
+func CalendarDaysFromEnv() int {
+	value := os.Getenv("RTM_CALENDAR_DAYS")
+	if value == "" {
+		return DefaultCalendarDays
+	}
+	days, err := strconv.Atoi(value)
+	if err != nil || days <= 0 {
+		log.Printf("Invalid RTM_CALENDAR_DAYS %q, using default %d", value, DefaultCalendarDays)
+		return DefaultCalendarDays
+	}
+	return days
+}
+
+// CalendarFilter is the RTM search for incomplete tasks due within days
+func CalendarFilter(days int) string {
+	return fmt.Sprintf(`status:incomplete AND dueWithin:"%d days of today"`, days)
+}
+
+// GetCalendarTasks retrieves incomplete tasks due within the next days
+func (c *Client) GetCalendarTasks(days int) ([]Task, error) {
+	return c.GetTasks(CalendarFilter(days), "")
+}
+
+// RenderICS renders tasks with due dates as iCalendar events. Tasks with a
+// due time become timed events; the rest become all-day events on their due
+// date in the user's time zone.
+func RenderICS(tasks []Task, now time.Time) string {
+	var b strings.Builder
+	writeICSLine(&b, "BEGIN:VCALENDAR")
+	writeICSLine(&b, "VERSION:2.0")
+	writeICSLine(&b, "PRODID:-//mcp-adapters//RTM Tasks//EN")
+	writeICSLine(&b, "CALSCALE:GREGORIAN")
+	writeICSLine(&b, "X-WR-CALNAME:Remember The Milk")
+
+	stamp := now.UTC().Format("20060102T150405Z")
+	for _, task := range tasks {
+		due, err := time.Parse(time.RFC3339, task.Due)
+		if err != nil {
+			continue
+		}
+
+		writeICSLine(&b, "BEGIN:VEVENT")
+		writeICSLine(&b, fmt.Sprintf("UID:%s-%s@rememberthemilk.com", task.SeriesID, task.ID))
+		writeICSLine(&b, "DTSTAMP:"+stamp)
+		if task.HasDueTime {
+			writeICSLine(&b, "DTSTART:"+due.UTC().Format("20060102T150405Z"))
+		} else {
+			writeICSLine(&b, "DTSTART;VALUE=DATE:"+due.Format("20060102"))
+			writeICSLine(&b, "DTEND;VALUE=DATE:"+due.AddDate(0, 0, 1).Format("20060102"))
+		}
+		writeICSLine(&b, "SUMMARY:"+escapeICSText(task.Name))
+		if priority := icsPriority(task.Priority); priority != "" {
+			writeICSLine(&b, "PRIORITY:"+priority)
+		}
+		if len(task.Tags) > 0 {
+			tags := make([]string, len(task.Tags))
+			for i, tag := range task.Tags {
+				tags[i] = escapeICSText(tag)
+			}
+			writeICSLine(&b, "CATEGORIES:"+strings.Join(tags, ","))
+		}
+		if len(task.Notes) > 0 {
+			notes := make([]string, 0, len(task.Notes))
+			for _, note := range task.Notes {
+				notes = append(notes, strings.TrimSpace(note.Title+"\n"+note.Body))
+			}
+			writeICSLine(&b, "DESCRIPTION:"+escapeICSText(strings.Join(notes, "\n\n")))
+		}
+		if task.URL != "" {
+			writeICSLine(&b, "URL:"+task.URL)
+		}
+		writeICSLine(&b, "END:VEVENT")
+	}
+
+	writeICSLine(&b, "END:VCALENDAR")
+	return b.String()
+}
+
+// icsPriority maps RTM priorities onto iCalendar's 1 (highest) to 9 scale
+func icsPriority(priority string) string {
+	switch priority {
+	case "1":
+		return "1"
+	case "2":
+		return "5"
+	case "3":
+		return "9"
+	default:
+		return ""
+	}
+}
+
+// escapeICSText escapes a TEXT value per RFC 5545
+func escapeICSText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// writeICSLine writes a content line, folding it at 75 octets without
+// splitting UTF-8 characters
+func writeICSLine(b *strings.Builder, line string) {
+	limit := icsLineLimit
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space that counts toward the limit
+		limit = icsLineLimit - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
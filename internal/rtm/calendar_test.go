@@ -0,0 +1,68 @@
+package rtm
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderICS(t *testing.T) {
+	t.Logf("Importance: Calendar clients reject malformed feeds, so upcoming tasks must render as valid iCalendar.")
+
+	now := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	tasks := []Task{
+		{ID: "11", SeriesID: "1", Name: "Dentist, then pharmacy", Due: "2024-05-03T14:30:00+02:00", HasDueTime: true, Priority: "1", Tags: []string{"health"}},
+		{ID: "21", SeriesID: "2", Name: "Pay rent", Due: "2024-05-05T00:00:00+02:00", Notes: []Note{{Title: "Landlord", Body: "IBAN on file"}}},
+		{ID: "31", SeriesID: "3", Name: "Someday", Due: ""},
+	}
+
+	ics := RenderICS(tasks, now)
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"UID:1-11@rememberthemilk.com\r\n",
+		"DTSTART:20240503T123000Z\r\n",
+		`SUMMARY:Dentist\, then pharmacy` + "\r\n",
+		"PRIORITY:1\r\n",
+		"CATEGORIES:health\r\n",
+		"DTSTART;VALUE=DATE:20240505\r\n",
+		"DTEND;VALUE=DATE:20240506\r\n",
+		`DESCRIPTION:Landlord\nIBAN on file` + "\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("Expected feed to contain %q, got:\n%s", want, ics)
+		}
+	}
+	if strings.Count(ics, "BEGIN:VEVENT") != 2 {
+		t.Errorf("Expected tasks without due dates to be skipped, got:\n%s", ics)
+	}
+
+	t.Run("folds long lines", func(t *testing.T) {
+		t.Logf("  > Why it's important: RFC 5545 limits lines to 75 octets; long task names must be folded, not truncated.")
+		name := strings.Repeat("é", 60)
+		ics := RenderICS([]Task{{ID: "1", SeriesID: "1", Name: name, Due: "2024-05-05T00:00:00Z"}}, now)
+		for _, line := range strings.Split(strings.TrimSuffix(ics, "\r\n"), "\r\n") {
+			if len(line) > icsLineLimit {
+				t.Errorf("Line exceeds %d octets: %q", icsLineLimit, line)
+			}
+		}
+		unfolded := strings.ReplaceAll(ics, "\r\n ", "")
+		if !strings.Contains(unfolded, "SUMMARY:"+name+"\r\n") {
+			t.Errorf("Expected folded summary to unfold to the full name")
+		}
+	})
+}
+
+func TestCalendarDaysFromEnv(t *testing.T) {
+	t.Logf("Importance: Operators size the calendar window; bad values must fall back instead of producing an empty feed.")
+
+	t.Setenv("RTM_CALENDAR_DAYS", "30")
+	if got := CalendarDaysFromEnv(); got != 30 {
+		t.Errorf("Expected 30, got %d", got)
+	}
+	t.Setenv("RTM_CALENDAR_DAYS", "-1")
+	if got := CalendarDaysFromEnv(); got != DefaultCalendarDays {
+		t.Errorf("Expected default for invalid value, got %d", got)
+	}
+}
@@ -0,0 +1,79 @@
+package rtm
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseChangesURI(t *testing.T) {
+	t.Logf("Importance: Clients build the since parameter by hand, so every reasonable form must work and mistakes must say how to fix them.")
+
+	now := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		uri  string
+		want time.Time
+	}{
+		{"rtm://changes?since=2026-03-04T10%3A00%3A00Z", now.Add(-2 * time.Hour)},
+		{"rtm://changes?since=2026-03-04T11:00:00+01:00", now.Add(-2 * time.Hour)},
+		{"rtm://changes?since=1772618400", now.Add(-2 * time.Hour)},
+		{"rtm://changes?since=2h", now.Add(-2 * time.Hour)},
+		{ChangesURI(now.Add(-time.Minute)), now.Add(-time.Minute)},
+	}
+	for _, tt := range tests {
+		got, err := ParseChangesURI(tt.uri, now)
+		if err != nil {
+			t.Errorf("ParseChangesURI(%q) failed: %v", tt.uri, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseChangesURI(%q) = %v, want %v", tt.uri, got, tt.want)
+		}
+	}
+
+	for _, uri := range []string{"rtm://changes", "rtm://changes?since=yesterday", "rtm://changes?since=2027-01-01T00%3A00%3A00Z", "rtm://changesets?since=1h", "rtm://today"} {
+		if _, err := ParseChangesURI(uri, now); err == nil {
+			t.Errorf("Expected ParseChangesURI(%q) to fail", uri)
+		}
+	}
+}
+
+func TestGetChanges(t *testing.T) {
+	t.Logf("Importance: Polling for changes must report completions and deletions too, or clients keep showing finished tasks.")
+
+	now := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+	fake := &syncingRTM{now: now.Add(-time.Hour), tasks: map[string]*fakeTask{}}
+	fake.change("1", func(task *fakeTask) { task.name = "Old task" })
+	fake.change("2", func(task *fakeTask) { task.name = "Done task" })
+	fake.change("3", func(task *fakeTask) { task.name = "Gone task" })
+	fake.now = now
+	fake.change("2", func(task *fakeTask) { task.completed = true })
+	fake.change("3", func(task *fakeTask) { task.deleted = true })
+	fake.change("4", func(task *fakeTask) { task.name = "New task"; task.list = "work" })
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client := NewClient("key", "secret")
+	client.BaseURL = server.URL
+	client.AuthToken = "token"
+	client.Limiter = nil
+	client.Tasks.now = func() time.Time { return now.Add(time.Minute) }
+
+	changes, err := client.GetChanges(now.Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("GetChanges failed: %v", err)
+	}
+
+	if names := taskNames(changes.Tasks); len(names) != 2 || names[0] != "Done task" || names[1] != "New task" {
+		t.Errorf("Expected the completed and new tasks, got %v", names)
+	}
+	if len(changes.Deleted) != 1 || changes.Deleted[0].SeriesID != "3" {
+		t.Errorf("Expected series 3 deleted, got %+v", changes.Deleted)
+	}
+	if len(fake.calls) != 1 || fake.calls[0]["last_sync"] != "2026-03-04T11:58:30Z" || fake.calls[0]["filter"] != "" {
+		t.Errorf("Expected one unfiltered last_sync request allowing for skew, got %v", fake.calls)
+	}
+	if !changes.SyncedAt.Equal(now.Add(time.Minute)) {
+		t.Errorf("Expected the sync time to be the read time, got %v", changes.SyncedAt)
+	}
+}
@@ -5,25 +5,76 @@
 package rtm
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
+	"math/rand"
+	"net"
 	"net/http"
 	"net/url"
+	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/vcto/mcp-adapters/internal/config"
+	"github.com/vcto/mcp-adapters/internal/health"
+	"github.com/vcto/mcp-adapters/internal/ratelimit"
+)
+
+// defaultBaseURL is RTM's REST API endpoint
+const defaultBaseURL = "https://api.rememberthemilk.com/services/rest/"
+
+// defaultAuthEndpoint is RTM's page for granting an application access
+const defaultAuthEndpoint = "https://www.rememberthemilk.com/services/auth/"
+
+// defaultTimeout bounds each HTTP request to RTM
+const defaultTimeout = 10 * time.Second
+
+// RTM allows 1 request a second on average, with bursts of up to 3
+const (
+	rateLimit = 1
+	rateBurst = 3
 )
 
-// RTMError represents an RTM API error
-type RTMError struct {
-	Code int    `json:"code"`
-	Msg  string `json:"msg"`
+// RetryPolicy controls how Call retries transient failures
+type RetryPolicy struct {
+	// MaxRetries is how many times a failed call is retried; 0 disables retries
+	MaxRetries int
+	// BaseDelay is the wait before the first retry, doubled for each later one
+	BaseDelay time.Duration
+	// MaxDelay caps the wait between retries
+	MaxDelay time.Duration
 }
 
-func (e *RTMError) Error() string {
-	return fmt.Sprintf("RTM API error %d: %s", e.Code, e.Msg)
+// DefaultRetryPolicy retries transient failures three times over a few seconds
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries: 3,
+		BaseDelay:  500 * time.Millisecond,
+		MaxDelay:   8 * time.Second,
+	}
+}
+
+// backoff returns the wait before the given retry (1-based), with full jitter
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < retry && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	// Jitter spreads out retries from concurrent callers
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
 }
 
 // Client handles RTM API communication
@@ -34,10 +85,35 @@ type Client struct {
 	Secret string
 	// AuthToken is the user's authentication token (obtained via OAuth)
 	AuthToken string
-	// BaseURL is the RTM API endpoint (default: https://api.rememberthemilk.com/services/rest/)
+	// BaseURL is the RTM API endpoint (default: RTM_API_BASE_URL, or
+	// https://api.rememberthemilk.com/services/rest/)
 	BaseURL string
+	// AuthEndpoint is the page where users grant access (default: https://www.rememberthemilk.com/services/auth/)
+	AuthEndpoint string
 	// client is the HTTP client used for API requests
 	client *http.Client
+	// Transactions records undoable timeline mutations for rtm_undo
+	Transactions *TransactionLog
+	// Breaker stops calls to RTM while the API is failing
+	Breaker *health.Breaker
+	// Limiter paces all API calls to RTM's 1 request/second limit
+	Limiter *ratelimit.Limiter
+	// Timelines reuses timelines across mutations for the same user
+	Timelines *TimelineCache
+	// Tasks keeps task lists in sync with deltas instead of refetching them
+	Tasks *TaskCache
+	// ChunkAbove is how many tasks an unscoped fetch may return before the
+	// account's searches are fetched one list at a time; 0 never chunks
+	ChunkAbove int
+	// Retry controls retries of transient failures (5xx, timeouts, error 105)
+	Retry RetryPolicy
+	// Debug logs retries and other diagnostics
+	Debug bool
+
+	// zones caches each user's time zone from rtm.settings.getList
+	zones *zoneCache
+	// ctx bounds the API calls made through this client; see WithContext
+	ctx context.Context
 
 	// Func fields for mocking in tests
 	GetFrobFunc  func() (string, error)
@@ -47,19 +123,98 @@ type Client struct {
 // NewClient creates a new RTM API client with the specified API key and secret.
 func NewClient(apiKey, secret string) *Client {
 	c := &Client{
-		APIKey:  apiKey,
-		Secret:  secret,
-		BaseURL: "https://api.rememberthemilk.com/services/rest/",
+		APIKey:       apiKey,
+		Secret:       secret,
+		BaseURL:      defaultBaseURL,
+		AuthEndpoint: defaultAuthEndpoint,
 		client: &http.Client{
-			Timeout: 10 * time.Second,
+			Timeout: config.DurationFromEnv("RTM_API_TIMEOUT", defaultTimeout),
 		},
+		Transactions: NewTransactionLog(defaultUndoHistory, clientIdleTTL()),
+		Breaker:      health.NewBreaker(0, 0),
+		Limiter:      ratelimit.New(rateLimit, rateBurst),
+		Retry:        DefaultRetryPolicy(),
+		Timelines:    NewTimelineCache(config.DurationFromEnv("RTM_TIMELINE_TTL", defaultTimelineTTL)),
+		Tasks:        NewTaskCache(config.DurationFromEnv("RTM_TASK_CACHE_TTL", defaultTaskCacheTTL)),
+		ChunkAbove:   ChunkThresholdFromEnv(),
+		zones:        &zoneCache{},
+	}
+	if baseURL := os.Getenv("RTM_API_BASE_URL"); baseURL != "" {
+		c.BaseURL = baseURL
 	}
+	c.Debug, _ = strconv.ParseBool(os.Getenv("MCP_DEBUG"))
 	// Point the public methods to the real implementations by default.
 	c.GetFrobFunc = c.getFrob
 	c.GetTokenFunc = c.getToken
 	return c
 }
 
+// SetTransport sends API requests through rt, such as a test double or an
+// instrumented transport; nil restores http.DefaultTransport. The transport is
+// shared with clients made by ForToken and WithContext, so set it before use.
+func (c *Client) SetTransport(rt http.RoundTripper) {
+	c.client.Transport = rt
+}
+
+// SetTimeout bounds each HTTP request to RTM, replacing RTM_API_TIMEOUT or
+// the 10 second default; 0 means no limit beyond the caller's context. Like
+// SetTransport it is shared with derived clients, so set it before use.
+func (c *Client) SetTimeout(d time.Duration) {
+	c.client.Timeout = d
+}
+
+// ForToken returns a client acting for the user with token. It shares c's
+// HTTP client, rate limiter and circuit breaker, and its undo history,
+// timeline, task and time zone caches, which are keyed by token.
+func (c *Client) ForToken(token string) *Client {
+	u := c.clone()
+	u.AuthToken = token
+	u.GetFrobFunc = u.getFrob
+	u.GetTokenFunc = u.getToken
+	return u
+}
+
+// WithContext returns a copy of c whose API calls stop when ctx is done, so
+// a request's deadline bounds rate limit waits, retries and the HTTP calls
+// themselves
+func (c *Client) WithContext(ctx context.Context) *Client {
+	u := c.clone()
+	u.ctx = ctx
+	u.GetFrobFunc = c.GetFrobFunc
+	u.GetTokenFunc = c.GetTokenFunc
+	return u
+}
+
+// clone copies c's configuration and shared state
+func (c *Client) clone() *Client {
+	return &Client{
+		APIKey:       c.APIKey,
+		Secret:       c.Secret,
+		AuthToken:    c.AuthToken,
+		BaseURL:      c.BaseURL,
+		AuthEndpoint: c.AuthEndpoint,
+		client:       c.client,
+		Transactions: c.Transactions,
+		Breaker:      c.Breaker,
+		Limiter:      c.Limiter,
+		Timelines:    c.Timelines,
+		Tasks:        c.Tasks,
+		ChunkAbove:   c.ChunkAbove,
+		Retry:        c.Retry,
+		Debug:        c.Debug,
+		zones:        c.zones,
+		ctx:          c.ctx,
+	}
+}
+
+// callContext returns the context bounding c's API calls
+func (c *Client) callContext() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
 // AuthURL generates the RTM authentication URL for the OAuth flow.
 func (c *Client) AuthURL(perms string) string {
 	params := map[string]string{
@@ -68,7 +223,7 @@ func (c *Client) AuthURL(perms string) string {
 	}
 	sig := c.sign(params)
 
-	u, _ := url.Parse("https://www.rememberthemilk.com/services/auth/")
+	u, _ := url.Parse(c.AuthEndpoint)
 	q := u.Query()
 	for k, v := range params {
 		q.Set(k, v)
@@ -148,12 +303,56 @@ func (c *Client) getToken(frob string) error {
 	return nil
 }
 
+// Account is the RTM user a token acts for and what it may do
+type Account struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	FullName string `json:"full_name"`
+	// Perms is "read", "write" or "delete"
+	Perms string `json:"perms"`
+}
+
+// CheckToken verifies the auth token and returns the account it acts for
+func (c *Client) CheckToken() (*Account, error) {
+	resp, err := c.Call("rtm.auth.checkToken", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Rsp struct {
+			Auth struct {
+				Perms string `json:"perms"`
+				User  struct {
+					ID       string `json:"id"`
+					Username string `json:"username"`
+					Fullname string `json:"fullname"`
+				} `json:"user"`
+			} `json:"auth"`
+		} `json:"rsp"`
+	}
+
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("parsing token check: %w", err)
+	}
+
+	auth := result.Rsp.Auth
+	return &Account{
+		UserID:   auth.User.ID,
+		Username: auth.User.Username,
+		FullName: auth.User.Fullname,
+		Perms:    auth.Perms,
+	}, nil
+}
+
 // Call makes an authenticated API call to the RTM API.
 func (c *Client) Call(method string, params map[string]string) ([]byte, error) {
 	if params == nil {
 		params = make(map[string]string)
 	}
 
+	timeline := params["timeline"]
+
 	params["method"] = method
 	params["api_key"] = c.APIKey
 	params["format"] = "json"
@@ -171,22 +370,184 @@ func (c *Client) Call(method string, params map[string]string) ([]byte, error) {
 	}
 	u.RawQuery = q.Encode()
 
-	resp, err := c.client.Get(u.String())
-	if err != nil {
-		return nil, fmt.Errorf("HTTP request failed: %w", err)
+	ctx := c.callContext()
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("RTM %s: %w", method, err)
 	}
-	defer func() {
-		if closeErr := resp.Body.Close(); closeErr != nil {
-			// Log error but don't fail - response already read
-			fmt.Printf("Warning: failed to close response body: %v\n", closeErr)
+	if c.Breaker != nil {
+		if err := c.Breaker.Allow(); err != nil {
+			return nil, fmt.Errorf("RTM %s: %w", method, err)
 		}
-	}()
+	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := c.callWithRetry(ctx, method, u.String())
 	if err != nil {
-		return nil, fmt.Errorf("reading response: %w", err)
+		// A rejected timeline must not be reused for later mutations
+		var rtmErr *RTMError
+		if timeline != "" && c.Timelines != nil && errors.As(err, &rtmErr) && rtmErr.Code == errCodeInvalidTimeline {
+			c.Timelines.Invalidate(c.AuthToken)
+		}
+		// Running out of the caller's budget says nothing about RTM's health
+		if c.Breaker != nil && ctx.Err() == nil {
+			if health.IsUpstream(err) {
+				c.Breaker.Failure(err)
+			} else {
+				c.Breaker.Success()
+				c.Breaker.NoteError(err)
+			}
+		}
+		return nil, err
+	}
+	if c.Breaker != nil {
+		c.Breaker.Success()
+	}
+
+	var txCheck struct {
+		Rsp struct {
+			Transaction struct {
+				ID       string `json:"id"`
+				Undoable string `json:"undoable"`
+			} `json:"transaction"`
+		} `json:"rsp"`
+	}
+
+	if err := json.Unmarshal(body, &txCheck); err == nil {
+		// Remember undoable mutations so they can be rolled back with rtm_undo
+		tx := txCheck.Rsp.Transaction
+		if timeline != "" && tx.ID != "" && tx.Undoable == "1" && c.Transactions != nil {
+			c.Transactions.Record(c.AuthToken, Transaction{
+				ID:        tx.ID,
+				Timeline:  timeline,
+				Method:    method,
+				CreatedAt: time.Now(),
+			})
+		}
+	}
+
+	return body, nil
+}
+
+// callWithRetry performs the request with exponential backoff, retrying
+// failures RTM definitely did not act on for every method, and other
+// transient failures for idempotent methods only. Each attempt waits on the
+// rate limiter. Retries stop early when ctx's deadline would pass during the
+// backoff.
+func (c *Client) callWithRetry(ctx context.Context, method, rawURL string) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		// Queue behind other callers rather than tripping RTM's throttling
+		if c.Limiter != nil {
+			if err := c.Limiter.Wait(ctx); err != nil {
+				if ctx.Err() != nil {
+					return nil, fmt.Errorf("RTM %s: waiting for rate limit: %w", method, ctx.Err())
+				}
+				return nil, fmt.Errorf("rate limit wait failed: %w", err)
+			}
+		}
+
+		body, err := c.get(ctx, rawURL)
+		if err == nil {
+			err = checkResponse(body)
+		}
+		if err == nil {
+			if attempt > 0 && c.Debug {
+				log.Printf("[DEBUG] RTM %s: succeeded after %d retries", method, attempt)
+			}
+			return body, nil
+		}
+		if attempt >= c.Retry.MaxRetries || !(notApplied(err) || isTransient(err) && isIdempotent(method)) {
+			if attempt > 0 && c.Debug {
+				log.Printf("[DEBUG] RTM %s: giving up after %d retries: %v", method, attempt, err)
+			}
+			return nil, err
+		}
+
+		delay := c.Retry.backoff(attempt + 1)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			if c.Debug {
+				log.Printf("[DEBUG] RTM %s: not retrying, deadline is %v away: %v", method, time.Until(deadline), err)
+			}
+			return nil, err
+		}
+		if c.Debug {
+			log.Printf("[DEBUG] RTM %s: retry %d/%d in %v after: %v", method, attempt+1, c.Retry.MaxRetries, delay, err)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("RTM %s: %w", method, ctx.Err())
+		}
 	}
+}
 
+// isTransient reports whether a failed call is worth retrying: server errors,
+// network timeouts, and RTM's service-unavailable error
+func isTransient(err error) bool {
+	var rtmErr *RTMError
+	if errors.As(err, &rtmErr) {
+		return rtmErr.Code == errCodeServiceUnavailable
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return netErr.Timeout()
+	}
+	var status *httpStatusError
+	return errors.As(err, &status)
+}
+
+// notSent reports whether a failed call provably never reached RTM: the
+// connection could not be made, or the circuit breaker refused the call.
+// Other upstream failures, such as a timeout, may come after RTM acted.
+func notSent(err error) bool {
+	if errors.Is(err, health.ErrCircuitOpen) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// notApplied reports whether RTM definitely did not act on a failed call, so
+// even a write may be sent again: it never reached RTM, or RTM refused it
+// with error 105 or HTTP 503
+func notApplied(err error) bool {
+	var rtmErr *RTMError
+	if errors.As(err, &rtmErr) {
+		return rtmErr.Code == errCodeServiceUnavailable
+	}
+	var status *httpStatusError
+	if errors.As(err, &status) {
+		return status.Code == http.StatusServiceUnavailable
+	}
+	return notSent(err)
+}
+
+// isIdempotent reports whether an RTM method may be repeated after a
+// failure that leaves unclear whether RTM acted on it, such as a timeout
+// or a read error once the request was sent. A write such as
+// rtm.tasks.add that timed out after RTM committed it would be applied
+// twice, so only reads, and creating a timeline, which changes nothing,
+// are retried.
+func isIdempotent(method string) bool {
+	if method == "rtm.timelines.create" {
+		return true
+	}
+	name := method[strings.LastIndex(method, ".")+1:]
+	return strings.HasPrefix(name, "get") || strings.HasPrefix(name, "check") || name == "echo"
+}
+
+// httpStatusError is an HTTP 5xx response from RTM
+type httpStatusError struct {
+	Code int
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("RTM API returned HTTP %d", e.Code)
+}
+
+// checkResponse returns the RTM error in a failed response. Service
+// unavailable errors are marked as upstream failures.
+func checkResponse(body []byte) error {
 	var errorCheck struct {
 		Rsp struct {
 			Stat string `json:"stat"`
@@ -197,27 +558,90 @@ func (c *Client) Call(method string, params map[string]string) ([]byte, error) {
 		} `json:"rsp"`
 	}
 
-	if err := json.Unmarshal(body, &errorCheck); err == nil {
-		if errorCheck.Rsp.Stat == "fail" {
-			code := 0
-			if _, err := fmt.Sscanf(errorCheck.Rsp.Err.Code, "%d", &code); err != nil {
-				// Log parsing failure and include original code in error message
-				msg := fmt.Sprintf("%s (unparseable code: %s)", errorCheck.Rsp.Err.Msg, errorCheck.Rsp.Err.Code)
-				return nil, &RTMError{
-					Code: -1, // Use -1 to indicate parsing failure
-					Msg:  msg,
-				}
-			}
-			return nil, &RTMError{
-				Code: code,
-				Msg:  errorCheck.Rsp.Err.Msg,
-			}
+	if err := json.Unmarshal(body, &errorCheck); err != nil || errorCheck.Rsp.Stat != "fail" {
+		return nil
+	}
+
+	code := 0
+	if _, err := fmt.Sscanf(errorCheck.Rsp.Err.Code, "%d", &code); err != nil {
+		// Log parsing failure and include original code in error message
+		msg := fmt.Sprintf("%s (unparseable code: %s)", errorCheck.Rsp.Err.Msg, errorCheck.Rsp.Err.Code)
+		return &RTMError{
+			Code: -1, // Use -1 to indicate parsing failure
+			Msg:  msg,
 		}
 	}
+	rtmErr := &RTMError{
+		Code: code,
+		Msg:  errorCheck.Rsp.Err.Msg,
+	}
+	if code == errCodeServiceUnavailable {
+		return health.Upstream(rtmErr)
+	}
+	return rtmErr
+}
+
+// get performs the HTTP request for Call. Transport failures and server
+// errors are returned as errors so the circuit breaker can count them.
+func (c *Client) get(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("building request: %w", err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("HTTP request stopped: %w", ctx.Err())
+		}
+		return nil, health.Upstream(fmt.Errorf("HTTP request failed: %w", err))
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			// Log error but don't fail - response already read
+			fmt.Printf("Warning: failed to close response body: %v\n", closeErr)
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, health.Upstream(fmt.Errorf("reading response: %w", err))
+	}
+
+	// RTM answers 503 when requests arrive faster than its rate limit
+	if resp.StatusCode == http.StatusServiceUnavailable && c.Limiter != nil {
+		c.Limiter.Backoff()
+	}
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return nil, health.Upstream(&httpStatusError{Code: resp.StatusCode})
+	}
+	if c.Limiter != nil {
+		c.Limiter.ResetBackoff()
+	}
 
 	return body, nil
 }
 
+// UndoLog returns the undoable changes made through c
+func (c *Client) UndoLog() *TransactionLog {
+	return c.Transactions
+}
+
+// UndoTransaction reverts a transaction using the timeline it was made on.
+// The cached timeline is dropped so later changes start a fresh timeline.
+func (c *Client) UndoTransaction(tx Transaction) error {
+	if c.Timelines != nil {
+		c.Timelines.Invalidate(c.AuthToken)
+	}
+
+	params := map[string]string{
+		"timeline":       tx.Timeline,
+		"transaction_id": tx.ID,
+	}
+
+	_, err := c.Call("rtm.transactions.undo", params)
+	return err
+}
+
 // GetAPIKey returns the API key
 func (c *Client) GetAPIKey() string {
 	return c.APIKey
@@ -260,17 +684,27 @@ func (c *Client) sign(params map[string]string) string {
 
 // Task represents an RTM task with its properties and metadata
 type Task struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	Due       string    `json:"due"`
-	Priority  string    `json:"priority"`
-	Completed string    `json:"completed"`
-	Deleted   string    `json:"deleted"`
-	Modified  time.Time `json:"modified"`
-	Added     time.Time `json:"added"`
-	ListID    string    `json:"list_id"`
-	SeriesID  string    `json:"series_id"`
-	URL       string    `json:"url"`
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Due          string    `json:"due"`
+	HasDueTime   bool      `json:"has_due_time"`
+	Start        string    `json:"start,omitempty"`
+	HasStartTime bool      `json:"has_start_time,omitempty"`
+	Priority     string    `json:"priority"`
+	Completed    string    `json:"completed"`
+	Deleted      string    `json:"deleted"`
+	Modified     time.Time `json:"modified"`
+	Added        time.Time `json:"added"`
+	ListID       string    `json:"list_id"`
+	SeriesID     string    `json:"series_id"`
+	URL          string    `json:"url"`
+	LocationID   string    `json:"location_id"`
+	Tags         []string  `json:"tags,omitempty"`
+	Notes        []Note    `json:"notes,omitempty"`
+	Estimate     string    `json:"estimate,omitempty"`
+	Postponed    int       `json:"postponed,omitempty"`
+	Repeat       string    `json:"repeat,omitempty"`
+	Source       string    `json:"source,omitempty"`
 }
 
 // List represents an RTM list (a container for tasks)
@@ -282,6 +716,19 @@ type List struct {
 	Archived string `json:"archived"`
 	Position string `json:"position"`
 	Smart    string `json:"smart"`
+	// Filter is the search criteria of a Smart List
+	Filter string `json:"filter,omitempty"`
+}
+
+// Location represents a saved RTM location that tasks can be assigned to
+type Location struct {
+	ID        string  `json:"id"`
+	Name      string  `json:"name"`
+	Longitude float64 `json:"longitude"`
+	Latitude  float64 `json:"latitude"`
+	Zoom      int     `json:"zoom"`
+	Address   string  `json:"address"`
+	Viewable  string  `json:"viewable"`
 }
 
 // GetLists retrieves all lists
@@ -307,19 +754,172 @@ func (c *Client) GetLists() ([]List, error) {
 	return result.Rsp.Lists.List, nil
 }
 
-// GetTasks retrieves tasks with optional filter
+// GetTags retrieves the names of all tags in use
+func (c *Client) GetTags() ([]string, error) {
+	resp, err := c.Call("rtm.tags.getList", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Rsp struct {
+			Stat string          `json:"stat"`
+			Tags json.RawMessage `json:"tags"`
+		} `json:"rsp"`
+	}
+
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("parsing tags: %w", err)
+	}
+
+	return parseTagNames(result.Rsp.Tags), nil
+}
+
+// parseTagNames decodes RTM's tag container. RTM returns an empty array when
+// there are no tags, otherwise {"tag": ...} holding either a single entry or a
+// list, where entries are plain names or {"name": ...} objects.
+func parseTagNames(raw json.RawMessage) []string {
+	var container struct {
+		Tag json.RawMessage `json:"tag"`
+	}
+	if len(raw) == 0 || json.Unmarshal(raw, &container) != nil || len(container.Tag) == 0 {
+		return nil
+	}
+
+	var entries []json.RawMessage
+	if json.Unmarshal(container.Tag, &entries) != nil {
+		entries = []json.RawMessage{container.Tag}
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		var name string
+		if json.Unmarshal(entry, &name) != nil {
+			var obj struct {
+				Name string `json:"name"`
+			}
+			if json.Unmarshal(entry, &obj) != nil {
+				continue
+			}
+			name = obj.Name
+		}
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// TaskListOptions selects which tasks GetTasksWithOptions returns
+type TaskListOptions struct {
+	// IncludeCompleted keeps completed tasks that match the filter
+	IncludeCompleted bool
+	// IncludeDeleted keeps deleted tasks that match the filter
+	IncludeDeleted bool
+}
+
+// GetTasks retrieves incomplete tasks with optional filter
 func (c *Client) GetTasks(filter, listID string) ([]Task, error) {
+	return c.GetTasksWithOptions(filter, listID, TaskListOptions{})
+}
+
+// GetTasksWithOptions retrieves tasks with optional filter, including
+// completed or deleted tasks when asked to. Repeated queries are answered
+// from the task cache, brought up to date with only what changed since.
+// Large accounts are fetched one list at a time and not cached.
+func (c *Client) GetTasksWithOptions(filter, listID string, opts TaskListOptions) ([]Task, error) {
+	if listID == "" && c.Chunked() {
+		var tasks []Task
+		err := c.EachTask(filter, opts, func(task Task) bool {
+			tasks = append(tasks, task)
+			return true
+		})
+		return tasks, err
+	}
+	query := taskQuery{filter: filter, listID: listID, opts: opts}
+	if cached, ok := c.Tasks.get(c.AuthToken, query, c.userLocation); ok {
+		tasks, err := c.syncTasks(query, cached)
+		if err != nil {
+			c.Tasks.drop(c.AuthToken, query)
+			return nil, err
+		}
+		return tasks, nil
+	}
+
+	syncedAt := c.Tasks.syncTime()
+	tasks, _, err := c.listTasks(taskParams(query), opts)
+	if err != nil {
+		if listID == "" && isTimeout(err) {
+			c.Tasks.markLarge(c.AuthToken)
+		}
+		return nil, err
+	}
+	c.localizeTasks(tasks)
+	if listID == "" && c.ChunkAbove > 0 && len(tasks) > c.ChunkAbove {
+		// Too large to keep; later searches and lookups go list by list
+		c.Tasks.markLarge(c.AuthToken)
+		return tasks, nil
+	}
+	c.Tasks.put(c.AuthToken, query, tasks, time.Time{}, syncedAt)
+	return tasks, nil
+}
+
+// syncTasks brings a cached result up to date. It first asks for every task
+// changed since the last sync, since a changed task may have stopped matching
+// the query, and only when there are any fetches those that still match.
+func (c *Client) syncTasks(query taskQuery, cached cachedTasks) ([]Task, error) {
+	syncedAt := c.Tasks.syncTime()
+	lastSync := cached.syncedAt.Add(-taskSyncSkew).UTC().Format(time.RFC3339)
+
+	all := TaskListOptions{IncludeCompleted: true, IncludeDeleted: true}
+	changedTasks, deleted, err := c.listTasks(map[string]string{"last_sync": lastSync}, all)
+	if err != nil {
+		return nil, err
+	}
+	if len(changedTasks) == 0 && len(deleted) == 0 {
+		c.Tasks.put(c.AuthToken, query, cached.tasks, cached.fetchedAt, syncedAt)
+		return append([]Task(nil), cached.tasks...), nil
+	}
+
+	changed := make(map[taskKey]bool, len(changedTasks)+len(deleted))
+	for _, task := range changedTasks {
+		changed[taskKey{task.SeriesID, task.ID}] = true
+	}
+	for _, key := range deleted {
+		changed[key] = true
+	}
+
+	params := taskParams(query)
+	params["last_sync"] = lastSync
+	matching, _, err := c.listTasks(params, query.opts)
+	if err != nil {
+		return nil, err
+	}
+	c.localizeTasks(matching)
+
+	tasks := mergeTasks(cached.tasks, changed, matching)
+	c.Tasks.put(c.AuthToken, query, tasks, cached.fetchedAt, syncedAt)
+	return tasks, nil
+}
+
+// taskParams returns the rtm.tasks.getList parameters for query
+func taskParams(query taskQuery) map[string]string {
 	params := make(map[string]string)
-	if filter != "" {
-		params["filter"] = filter
+	if query.filter != "" {
+		params["filter"] = query.filter
 	}
-	if listID != "" {
-		params["list_id"] = listID
+	if query.listID != "" {
+		params["list_id"] = query.listID
 	}
+	return params
+}
 
+// listTasks calls rtm.tasks.getList and flattens the result. With last_sync
+// RTM also reports tasks deleted since then, returned as deleted.
+func (c *Client) listTasks(params map[string]string, opts TaskListOptions) ([]Task, []taskKey, error) {
 	resp, err := c.Call("rtm.tasks.getList", params)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// RTM's task response structure is complex
@@ -328,59 +928,87 @@ func (c *Client) GetTasks(filter, listID string) ([]Task, error) {
 			Stat  string `json:"stat"`
 			Tasks struct {
 				List []struct {
-					ID         string `json:"id"`
-					Taskseries []struct {
-						ID       string          `json:"id"`
-						Created  string          `json:"created"`
-						Modified string          `json:"modified"`
-						Name     string          `json:"name"`
-						Source   string          `json:"source"`
-						URL      string          `json:"url"`
-						RRule    json.RawMessage `json:"rrule,omitempty"`
-						Task     []struct {
-							ID        string `json:"id"`
-							Due       string `json:"due"`
-							Added     string `json:"added"`
-							Completed string `json:"completed"`
-							Deleted   string `json:"deleted"`
-							Priority  string `json:"priority"`
-						} `json:"task"`
-					} `json:"taskseries"`
+					ID         string          `json:"id"`
+					Taskseries []rtmTaskSeries `json:"taskseries"`
+					Deleted    struct {
+						Taskseries []rtmTaskSeries `json:"taskseries"`
+					} `json:"deleted"`
 				} `json:"list"`
 			} `json:"tasks"`
 		} `json:"rsp"`
 	}
 
 	if err := json.Unmarshal(resp, &result); err != nil {
-		return nil, fmt.Errorf("parsing tasks: %w", err)
+		return nil, nil, fmt.Errorf("parsing tasks: %w", err)
 	}
 
 	// Flatten the nested structure
 	var tasks []Task
+	var deleted []taskKey
 	for _, list := range result.Rsp.Tasks.List {
 		for _, series := range list.Taskseries {
+			tasks = append(tasks, series.tasks(list.ID, opts)...)
+		}
+		for _, series := range list.Deleted.Taskseries {
 			for _, task := range series.Task {
-				if task.Deleted == "" && task.Completed == "" {
-					t := Task{
-						ID:       task.ID,
-						Name:     series.Name,
-						Due:      task.Due,
-						Priority: task.Priority,
-						ListID:   list.ID,
-						SeriesID: series.ID,
-						URL:      series.URL,
-					}
-					tasks = append(tasks, t)
-				}
+				deleted = append(deleted, taskKey{series.ID, task.ID})
 			}
 		}
 	}
+	return tasks, deleted, nil
+}
 
-	return tasks, nil
+// ErrTaskNotFound is returned by GetTask when no task has the given IDs
+var ErrTaskNotFound = errors.New("task not found")
+
+// GetTask retrieves one task by series and task ID, whether or not it is
+// completed or deleted. RTM has no lookup by ID, so this scans all tasks, one
+// list at a time for large accounts.
+func (c *Client) GetTask(seriesID, taskID string) (*Task, error) {
+	all := TaskListOptions{IncludeCompleted: true, IncludeDeleted: true}
+	if c.Chunked() {
+		var found *Task
+		err := c.EachTask("", all, func(task Task) bool {
+			if task.SeriesID == seriesID && task.ID == taskID {
+				found = &task
+				return false
+			}
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+		if found == nil {
+			return nil, fmt.Errorf("%w: series %s, task %s", ErrTaskNotFound, seriesID, taskID)
+		}
+		return found, nil
+	}
+
+	tasks, err := c.GetTasksWithOptions("", "", all)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tasks {
+		if tasks[i].SeriesID == seriesID && tasks[i].ID == taskID {
+			return &tasks[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: series %s, task %s", ErrTaskNotFound, seriesID, taskID)
 }
 
-// AddTask creates a new task
+// AddTask creates a new task named exactly name
 func (c *Client) AddTask(name string, listID string) (*Task, error) {
+	return c.addTask(name, listID, false)
+}
+
+// SmartAdd creates a task from text in RTM's Smart Add syntax, such as
+// "Call Bob tomorrow !1 #work", letting RTM set the due date, priority, tags,
+// list and the rest from it. The returned task shows how the text was read.
+func (c *Client) SmartAdd(text string) (*Task, error) {
+	return c.addTask(text, "", true)
+}
+
+func (c *Client) addTask(name, listID string, parse bool) (*Task, error) {
 	// First get timeline
 	timeline, err := c.getTimeline()
 	if err != nil {
@@ -394,6 +1022,9 @@ func (c *Client) AddTask(name string, listID string) (*Task, error) {
 	if listID != "" {
 		params["list_id"] = listID
 	}
+	if parse {
+		params["parse"] = "1"
+	}
 
 	resp, err := c.Call("rtm.tasks.add", params)
 	if err != nil {
@@ -404,22 +1035,8 @@ func (c *Client) AddTask(name string, listID string) (*Task, error) {
 		Rsp struct {
 			Stat string `json:"stat"`
 			List struct {
-				ID         string `json:"id"`
-				Taskseries []struct {
-					ID      string `json:"id"`
-					Name    string `json:"name"`
-					Created string `json:"created"`
-					URL     string `json:"url"`
-					Task    []struct {
-						ID         string `json:"id"`
-						Due        string `json:"due"`
-						HasDueTime string `json:"has_due_time"`
-						Added      string `json:"added"`
-						Completed  string `json:"completed"`
-						Deleted    string `json:"deleted"`
-						Priority   string `json:"priority"`
-					} `json:"task"`
-				} `json:"taskseries"`
+				ID         string          `json:"id"`
+				Taskseries []rtmTaskSeries `json:"taskseries"`
 			} `json:"list"`
 		} `json:"rsp"`
 	}
@@ -432,23 +1049,12 @@ func (c *Client) AddTask(name string, listID string) (*Task, error) {
 		return nil, fmt.Errorf("no taskseries returned from RTM")
 	}
 
-	taskseries := result.Rsp.List.Taskseries[0]
-	if len(taskseries.Task) == 0 {
+	tasks := result.Rsp.List.Taskseries[0].tasks(result.Rsp.List.ID, TaskListOptions{IncludeCompleted: true, IncludeDeleted: true})
+	if len(tasks) == 0 {
 		return nil, fmt.Errorf("no task returned in taskseries from RTM")
 	}
-
-	task := taskseries.Task[0]
-	return &Task{
-		ID:        task.ID,
-		Name:      taskseries.Name,
-		ListID:    result.Rsp.List.ID,
-		SeriesID:  taskseries.ID,
-		Priority:  task.Priority,
-		Due:       task.Due,
-		Completed: task.Completed,
-		Deleted:   task.Deleted,
-		URL:       taskseries.URL,
-	}, nil
+	c.localizeTasks(tasks[:1])
+	return &tasks[0], nil
 }
 
 // CompleteTask marks a task as complete
@@ -469,8 +1075,114 @@ func (c *Client) CompleteTask(listID, seriesID, taskID string) error {
 	return err
 }
 
-// getTimeline gets a timeline for making changes
+// DeleteTask marks a task as deleted
+func (c *Client) DeleteTask(listID, seriesID, taskID string) error {
+	timeline, err := c.getTimeline()
+	if err != nil {
+		return err
+	}
+
+	params := map[string]string{
+		"timeline":      timeline,
+		"list_id":       listID,
+		"taskseries_id": seriesID,
+		"task_id":       taskID,
+	}
+
+	_, err = c.Call("rtm.tasks.delete", params)
+	return err
+}
+
+// AddTags adds comma-separated tags to a task, keeping the tags it has
+func (c *Client) AddTags(listID, seriesID, taskID, tags string) error {
+	timeline, err := c.getTimeline()
+	if err != nil {
+		return err
+	}
+
+	params := map[string]string{
+		"timeline":      timeline,
+		"list_id":       listID,
+		"taskseries_id": seriesID,
+		"task_id":       taskID,
+		"tags":          tags,
+	}
+
+	_, err = c.Call("rtm.tasks.addTags", params)
+	return err
+}
+
+// AddNote adds a note to a task
+func (c *Client) AddNote(listID, seriesID, taskID, title, text string) error {
+	timeline, err := c.getTimeline()
+	if err != nil {
+		return err
+	}
+
+	params := map[string]string{
+		"timeline":      timeline,
+		"list_id":       listID,
+		"taskseries_id": seriesID,
+		"task_id":       taskID,
+		"note_title":    title,
+		"note_text":     text,
+	}
+
+	_, err = c.Call("rtm.tasks.notes.add", params)
+	return err
+}
+
+// DuplicateTask creates a new task in listID with source's tags, priority,
+// estimate and notes. The name is copied unless name is given, and is not
+// parsed with Smart Add. Due dates and recurrence are not copied. When
+// copying fails after the task was created, the new task is returned along
+// with the error.
+func (c *Client) DuplicateTask(source *Task, listID, name string) (*Task, error) {
+	if name == "" {
+		name = source.Name
+	}
+	task, err := c.AddTask(name, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make(map[string]string)
+	if len(source.Tags) > 0 {
+		updates["tags"] = strings.Join(source.Tags, ",")
+	}
+	if source.Priority != "" && source.Priority != "N" {
+		updates["priority"] = source.Priority
+	}
+	if source.Estimate != "" {
+		updates["estimate"] = source.Estimate
+	}
+	if len(updates) > 0 {
+		if err := c.UpdateTask(task.ListID, task.SeriesID, task.ID, updates); err != nil {
+			return task, err
+		}
+		task.Tags = source.Tags
+		task.Priority = updates["priority"]
+		task.Estimate = source.Estimate
+	}
+
+	for i, note := range source.Notes {
+		if err := c.AddNote(task.ListID, task.SeriesID, task.ID, note.Title, note.Body); err != nil {
+			return task, fmt.Errorf("copying note %d of %d: %w", i+1, len(source.Notes), err)
+		}
+		task.Notes = append(task.Notes, Note{Title: note.Title, Body: note.Body})
+	}
+	return task, nil
+}
+
+// getTimeline gets a timeline for making changes, reusing the user's cached
+// timeline when there is one
 func (c *Client) getTimeline() (string, error) {
+	if c.Timelines != nil {
+		if timeline, ok := c.Timelines.Get(c.AuthToken); ok {
+			return timeline, nil
+		}
+	}
+
 	resp, err := c.Call("rtm.timelines.create", nil)
 	if err != nil {
 		return "", err
@@ -487,6 +1199,9 @@ func (c *Client) getTimeline() (string, error) {
 		return "", fmt.Errorf("parsing timeline: %w", err)
 	}
 
+	if c.Timelines != nil {
+		c.Timelines.Put(c.AuthToken, result.Rsp.Timeline)
+	}
 	return result.Rsp.Timeline, nil
 }
 
@@ -523,8 +1238,23 @@ func (c *Client) UpdateTask(listID, seriesID, taskID string, updates map[string]
 			method = "rtm.tasks.setTags"
 			params["tags"] = value
 		case "list":
+			// moveTo names the current list from_list_id rather than list_id
 			method = "rtm.tasks.moveTo"
+			params["from_list_id"] = listID
 			params["to_list_id"] = value
+			delete(params, "list_id")
+		case "location":
+			// An empty location_id clears the location
+			method = "rtm.tasks.setLocation"
+			if value != "" {
+				params["location_id"] = value
+			}
+		case "url":
+			// An empty url clears the link
+			method = "rtm.tasks.setURL"
+			if value != "" {
+				params["url"] = value
+			}
 		default:
 			return fmt.Errorf("unsupported field: %s", field)
 		}
@@ -538,8 +1268,54 @@ func (c *Client) UpdateTask(listID, seriesID, taskID string, updates map[string]
 	return nil
 }
 
-// CreateList creates a new list
-func (c *Client) CreateList(name string) (*List, error) {
+// GetLocations retrieves all saved locations
+func (c *Client) GetLocations() ([]Location, error) {
+	resp, err := c.Call("rtm.locations.getList", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// RTM encodes numeric location fields as strings
+	var result struct {
+		Rsp struct {
+			Stat      string `json:"stat"`
+			Locations struct {
+				Location []struct {
+					ID        string `json:"id"`
+					Name      string `json:"name"`
+					Longitude string `json:"longitude"`
+					Latitude  string `json:"latitude"`
+					Zoom      string `json:"zoom"`
+					Address   string `json:"address"`
+					Viewable  string `json:"viewable"`
+				} `json:"location"`
+			} `json:"locations"`
+		} `json:"rsp"`
+	}
+
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("parsing locations: %w", err)
+	}
+
+	locations := make([]Location, 0, len(result.Rsp.Locations.Location))
+	for _, loc := range result.Rsp.Locations.Location {
+		location := Location{
+			ID:       loc.ID,
+			Name:     loc.Name,
+			Address:  loc.Address,
+			Viewable: loc.Viewable,
+		}
+		location.Longitude, _ = strconv.ParseFloat(loc.Longitude, 64)
+		location.Latitude, _ = strconv.ParseFloat(loc.Latitude, 64)
+		location.Zoom, _ = strconv.Atoi(loc.Zoom)
+		locations = append(locations, location)
+	}
+
+	return locations, nil
+}
+
+// CreateList creates a new list, or a Smart List when filter is an RTM search
+func (c *Client) CreateList(name, filter string) (*List, error) {
 	timeline, err := c.getTimeline()
 	if err != nil {
 		return nil, err
@@ -549,6 +1325,9 @@ func (c *Client) CreateList(name string) (*List, error) {
 		"timeline": timeline,
 		"name":     name,
 	}
+	if filter != "" {
+		params["filter"] = filter
+	}
 
 	resp, err := c.Call("rtm.lists.add", params)
 	if err != nil {
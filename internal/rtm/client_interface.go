@@ -1,8 +1,10 @@
 package rtm
 
-// RTMClientInterface defines the methods required for RTM API interactions.
-// Both the real client and mock clients will implement this interface.
-type RTMClientInterface interface {
+import "time"
+
+// AuthClient is the part of the RTM API the OAuth adapter needs: RTM's frob
+// exchange and checking a token.
+type AuthClient interface {
 	GetFrob() (string, error)
 	GetToken(frob string) error
 	GetAPIKey() string
@@ -11,3 +13,46 @@ type RTMClientInterface interface {
 	Sign(params map[string]string) string
 	GetLists() ([]List, error)
 }
+
+// RTMClientInterface is every RTM call the handlers make, for one user. Client
+// implements it over HTTP and FakeClient in memory, so Handler and
+// EnhancedHandler can be tested without either.
+type RTMClientInterface interface {
+	AuthClient
+	AuthURL(perms string) string
+	CheckToken() (*Account, error)
+
+	GetSettings() (*Settings, error)
+	GetTags() ([]string, error)
+	GetLocations() ([]Location, error)
+
+	CreateList(name, filter string) (*List, error)
+	RenameList(listID, newName string) error
+	ArchiveList(listID string, archive bool) error
+
+	GetTasks(filter, listID string) ([]Task, error)
+	GetTasksWithOptions(filter, listID string, opts TaskListOptions) ([]Task, error)
+	GetTask(seriesID, taskID string) (*Task, error)
+	GetCalendarTasks(days int) ([]Task, error)
+	GetChanges(since time.Time) (*Changes, error)
+	// SyncTime is the since to pass GetChanges for changes from now on
+	SyncTime() time.Time
+	// Chunked reports whether searches should use SearchPage
+	Chunked() bool
+	SearchPage(filter string, opts TaskListOptions, offset, limit int) ([]Task, int, error)
+
+	AddTask(name string, listID string) (*Task, error)
+	SmartAdd(text string) (*Task, error)
+	DuplicateTask(source *Task, listID, name string) (*Task, error)
+	UpdateTask(listID, seriesID, taskID string, updates map[string]string) error
+	CompleteTask(listID, seriesID, taskID string) error
+	DeleteTask(listID, seriesID, taskID string) error
+	AddTags(listID, seriesID, taskID, tags string) error
+	AddNote(listID, seriesID, taskID, title, text string) error
+
+	// UndoLog holds the undoable changes made through the client, or nil
+	UndoLog() *TransactionLog
+	UndoTransaction(tx Transaction) error
+}
+
+var _ RTMClientInterface = (*Client)(nil)
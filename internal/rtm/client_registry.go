@@ -0,0 +1,119 @@
+package rtm
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/vcto/mcp-adapters/internal/config"
+)
+
+// defaultClientIdleTTL is how long a user's client is kept after their last request
+const defaultClientIdleTTL = time.Hour
+
+// clientIdleTTL returns how long per-user state is kept after the user's last
+// request, from RTM_CLIENT_IDLE_TTL
+func clientIdleTTL() time.Duration {
+	return config.DurationFromEnv("RTM_CLIENT_IDLE_TTL", defaultClientIdleTTL)
+}
+
+type authTokenKey struct{}
+
+// WithAuthToken returns a context carrying the RTM auth token of the
+// request's user, so tools act on that user's account
+func WithAuthToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, authTokenKey{}, token)
+}
+
+// AuthTokenFromContext returns the RTM auth token set by WithAuthToken
+func AuthTokenFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(authTokenKey{}).(string)
+	return token
+}
+
+type registeredClient struct {
+	client   *Client
+	owner    string
+	lastUsed time.Time
+}
+
+// ClientRegistry keeps one RTM client per auth token, so users sharing a
+// server never act with each other's token. Clients are derived from the
+// base client with Client.ForToken. Clients idle for longer than the TTL are
+// dropped.
+type ClientRegistry struct {
+	base    *Client
+	idleTTL time.Duration
+	now     func() time.Time
+
+	mu      sync.Mutex
+	clients map[string]*registeredClient
+}
+
+// NewClientRegistry creates a registry deriving clients from base
+func NewClientRegistry(base *Client, idleTTL time.Duration) *ClientRegistry {
+	return &ClientRegistry{
+		base:    base,
+		idleTTL: idleTTL,
+		now:     time.Now,
+		clients: make(map[string]*registeredClient),
+	}
+}
+
+// Get returns the client for token, creating it on first use
+func (r *ClientRegistry) Get(token string) *Client {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	r.evictIdle(now)
+	entry, ok := r.clients[token]
+	if !ok {
+		entry = &registeredClient{client: r.base.ForToken(token), owner: intentOwner(token)}
+		r.clients[token] = entry
+	}
+	entry.lastUsed = now
+	return entry.client
+}
+
+// ForOwner returns the client of a live user by their owner ID, the hashed
+// token batch jobs and intents are filed under
+func (r *ClientRegistry) ForOwner(owner string) (*Client, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.evictIdle(r.now())
+	for _, entry := range r.clients {
+		if entry.owner == owner {
+			return entry.client, true
+		}
+	}
+	return nil, false
+}
+
+// Remove drops the client for token, such as when the token is revoked
+func (r *ClientRegistry) Remove(token string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.clients, token)
+}
+
+// Len returns how many users have a client
+func (r *ClientRegistry) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.evictIdle(r.now())
+	return len(r.clients)
+}
+
+// evictIdle drops clients unused for longer than the TTL; callers hold mu
+func (r *ClientRegistry) evictIdle(now time.Time) {
+	if r.idleTTL <= 0 {
+		return
+	}
+	for token, entry := range r.clients {
+		if now.Sub(entry.lastUsed) > r.idleTTL {
+			delete(r.clients, token)
+		}
+	}
+}
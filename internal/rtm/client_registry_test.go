@@ -0,0 +1,120 @@
+package rtm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestClientRegistry(t *testing.T) {
+	t.Logf("Importance: Each signed-in user needs their own client, but all of them must share the one RTM rate limit and breaker.")
+
+	base := NewClient("key", "secret")
+	registry := NewClientRegistry(base, time.Hour)
+	now := time.Now()
+	registry.now = func() time.Time { return now }
+
+	alice := registry.Get("alice")
+	if alice != registry.Get("alice") {
+		t.Error("Expected the same client for the same token")
+	}
+	bob := registry.Get("bob")
+	if bob == alice || bob.AuthToken != "bob" || alice.AuthToken != "alice" {
+		t.Fatalf("Expected a client per token, got %q and %q", alice.AuthToken, bob.AuthToken)
+	}
+	if bob.Limiter != base.Limiter || bob.Breaker != base.Breaker || bob.Timelines != base.Timelines {
+		t.Error("Expected user clients to share the base client's limiter, breaker and timelines")
+	}
+	if base.AuthToken != "" {
+		t.Errorf("Expected the base client untouched, got token %q", base.AuthToken)
+	}
+
+	if client, ok := registry.ForOwner(intentOwner("bob")); !ok || client != bob {
+		t.Error("Expected to find bob's client by owner")
+	}
+
+	t.Run("idle clients are dropped", func(t *testing.T) {
+		now = now.Add(30 * time.Minute)
+		registry.Get("alice")
+		now = now.Add(45 * time.Minute)
+		if registry.Len() != 1 {
+			t.Errorf("Expected only alice's client to remain, got %d clients", registry.Len())
+		}
+		if _, ok := registry.ForOwner(intentOwner("bob")); ok {
+			t.Error("Expected bob's idle client to be dropped")
+		}
+	})
+}
+
+func TestHandlerClientPerUser(t *testing.T) {
+	t.Logf("Importance: Users sharing one HTTP server must never act on, or see, each other's RTM account.")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("method") != "rtm.tasks.getList" {
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok"}}`)
+			return
+		}
+		// Each user's only task is named after their token
+		_, _ = fmt.Fprintf(w, `{"rsp":{"stat":"ok","tasks":{"list":[{"id":"l1","taskseries":[{"id":"s1","name":%q,"tags":[],"notes":[],"task":[{"id":"t1","due":"","completed":"","deleted":"","priority":"N"}]}]}]}}}`,
+			"task of "+query.Get("auth_token"))
+	}))
+	defer server.Close()
+
+	h := &Handler{client: NewClient("key", "secret")}
+	h.client.BaseURL = server.URL
+	h.client.Limiter = nil
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 40)
+	for i := 0; i < 20; i++ {
+		for _, user := range []string{"alice", "bob"} {
+			wg.Add(1)
+			go func(user string) {
+				defer wg.Done()
+				tasks, _, err := h.TodayTasks(WithAuthToken(context.Background(), user))
+				if err != nil {
+					errs <- err
+					return
+				}
+				if len(tasks) != 1 || tasks[0].Name != "task of "+user {
+					errs <- fmt.Errorf("%s got %+v", user, tasks)
+				}
+			}(user)
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	t.Run("search results stay with their user", func(t *testing.T) {
+		t.Logf("  > Why it's important: Exporting the last search must never return another user's tasks.")
+		alice := WithAuthToken(context.Background(), "alice")
+		bob := WithAuthToken(context.Background(), "bob")
+		if _, _, err := h.searchTasks(h.ClientFor(alice), "list:Inbox", false, true); err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+
+		result, _ := h.handleExportCSV(bob, mcp.CallToolRequest{})
+		if !result.IsError {
+			t.Errorf("Expected bob to have no search to export, got %v", result.Content)
+		}
+		result, _ = h.handleExportCSV(alice, mcp.CallToolRequest{})
+		if result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "task of alice") {
+			t.Errorf("Expected alice's search exported, got %v", result.Content)
+		}
+	})
+
+	if h.client.AuthToken != "" {
+		t.Errorf("Expected the shared client's token untouched, got %q", h.client.AuthToken)
+	}
+}
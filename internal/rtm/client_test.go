@@ -0,0 +1,92 @@
+package rtm
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// roundTripFunc is an http.RoundTripper answering from a function
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestClientConfiguration(t *testing.T) {
+	t.Logf("Importance: Tests point the client at mocks and deployments tune timeouts without code changes.")
+
+	t.Run("base URL from environment", func(t *testing.T) {
+		t.Logf("  > Why it's important: RTM_API_BASE_URL lets a whole server run against a mock RTM.")
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok"}}`)
+		}))
+		defer server.Close()
+		t.Setenv("RTM_API_BASE_URL", server.URL)
+
+		client := NewClient("key", "secret")
+		client.Limiter = nil
+		if client.BaseURL != server.URL {
+			t.Fatalf("Expected base URL %s, got %s", server.URL, client.BaseURL)
+		}
+		if _, err := client.Call("rtm.test.echo", nil); err != nil {
+			t.Errorf("Expected the mock to answer, got %v", err)
+		}
+	})
+
+	t.Run("timeout from environment", func(t *testing.T) {
+		t.Logf("  > Why it's important: Slow links need more than the 10 second default.")
+		t.Setenv("RTM_API_TIMEOUT", "45s")
+		if got := NewClient("key", "secret").client.Timeout; got != 45*time.Second {
+			t.Errorf("Expected 45s, got %s", got)
+		}
+		t.Setenv("RTM_API_TIMEOUT", "soon")
+		if got := NewClient("key", "secret").client.Timeout; got != defaultTimeout {
+			t.Errorf("Expected the default for an invalid value, got %s", got)
+		}
+	})
+
+	t.Run("timeout fails slow requests", func(t *testing.T) {
+		t.Logf("  > Why it's important: A hung RTM must fail the call rather than hold it open.")
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(200 * time.Millisecond)
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok"}}`)
+		}))
+		defer server.Close()
+
+		client := newRetryTestClient(server.URL)
+		client.Retry = RetryPolicy{}
+		client.SetTimeout(20 * time.Millisecond)
+		if _, err := client.Call("rtm.test.echo", nil); !isTimeout(err) {
+			t.Errorf("Expected a timeout, got %v", err)
+		}
+	})
+
+	t.Run("injected transport", func(t *testing.T) {
+		t.Logf("  > Why it's important: Tests can answer RTM calls in memory, and shared clients use the same transport.")
+		var methods []string
+		client := NewClient("key", "secret")
+		client.Limiter = nil
+		client.SetTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			methods = append(methods, req.URL.Query().Get("method"))
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": {"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(`{"rsp":{"stat":"ok","settings":{"timezone":"Europe/London"}}}`)),
+				Request:    req,
+			}, nil
+		}))
+
+		settings, err := client.ForToken("token-1").GetSettings()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if settings.Timezone != "Europe/London" || len(methods) != 1 || methods[0] != "rtm.settings.getList" {
+			t.Errorf("Expected one call through the transport, got %v and %+v", methods, settings)
+		}
+	})
+}
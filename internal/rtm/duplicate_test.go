@@ -0,0 +1,130 @@
+package rtm
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const duplicateSourceResponse = `{"rsp":{"stat":"ok","tasks":{"list":[{"id":"100","taskseries":[
+	{"id":"1","created":"2024-05-01T09:00:00Z","modified":"2024-05-02T10:30:00Z","name":"Launch checklist","source":"api","url":"","location_id":"",
+	 "tags":{"tag":["launch","work"]},
+	 "notes":{"note":[
+	   {"id":"n1","created":"2024-05-01T09:05:00Z","modified":"2024-05-01T09:05:00Z","title":"Steps","$t":"Announce, then ship"},
+	   {"id":"n2","created":"2024-05-01T09:06:00Z","modified":"2024-05-01T09:06:00Z","title":"","$t":"Ask design for assets"}]},
+	 "task":[{"id":"11","due":"2024-05-03T17:00:00Z","has_due_time":"1","added":"2024-05-01T09:00:00Z","completed":"","deleted":"","priority":"2","postponed":"0","estimate":"2 hours"}]}
+]}]}}}`
+
+func TestDuplicateTask(t *testing.T) {
+	t.Logf("Importance: Copying a project task as a template must carry over everything but its dates, or the copy is not worth making.")
+
+	var mu sync.Mutex
+	var changes []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		query := r.URL.Query()
+		switch query.Get("method") {
+		case "rtm.timelines.create":
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok","timeline":"tl-1"}}`)
+		case "rtm.tasks.getList":
+			_, _ = fmt.Fprint(w, duplicateSourceResponse)
+		case "rtm.lists.getList":
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok","lists":{"list":[{"id":"100","name":"Inbox"},{"id":"200","name":"Work"}]}}}`)
+		case "rtm.tasks.add":
+			changes = append(changes, query)
+			_, _ = fmt.Fprintf(w, `{"rsp":{"stat":"ok","list":{"id":%q,"taskseries":[{"id":"2","name":%q,"tags":[],"notes":[],"task":[{"id":"21","priority":"N"}]}]}}}`, query.Get("list_id"), query.Get("name"))
+		case "rtm.tasks.notes.add":
+			changes = append(changes, query)
+			if query.Get("note_text") == "fail" {
+				_, _ = fmt.Fprint(w, `{"rsp":{"stat":"fail","err":{"code":"340","msg":"Note text invalid"}}}`)
+				return
+			}
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok"}}`)
+		default:
+			changes = append(changes, query)
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok"}}`)
+		}
+	}))
+	defer server.Close()
+
+	h := &Handler{client: NewClient("key", "secret")}
+	h.client.BaseURL = server.URL
+	h.client.AuthToken = "token"
+	h.client.Limiter = nil
+
+	duplicate := func(args map[string]any) *mcp.CallToolResult {
+		mu.Lock()
+		changes = nil
+		mu.Unlock()
+		result, err := callTool(h.handleDuplicateTask, args)
+		if err != nil {
+			t.Fatalf("rtm_duplicate_task failed: %v", err)
+		}
+		return result
+	}
+
+	result := duplicate(map[string]any{"series_id": "1", "task_id": "11", "list": "work"})
+	if result.IsError {
+		t.Fatalf("Expected the task duplicated, got %+v", result)
+	}
+	byMethod := map[string][]url.Values{}
+	for _, change := range changes {
+		byMethod[change.Get("method")] = append(byMethod[change.Get("method")], change)
+	}
+	add := byMethod["rtm.tasks.add"]
+	if len(add) != 1 || add[0].Get("name") != "Launch checklist" || add[0].Get("list_id") != "200" || add[0].Get("parse") != "" {
+		t.Errorf("Expected the name added unparsed to Work, got %v", add)
+	}
+	if tags := byMethod["rtm.tasks.setTags"]; len(tags) != 1 || tags[0].Get("tags") != "launch,work" || tags[0].Get("taskseries_id") != "2" {
+		t.Errorf("Expected tags copied to the new series, got %v", tags)
+	}
+	if p := byMethod["rtm.tasks.setPriority"]; len(p) != 1 || p[0].Get("priority") != "2" {
+		t.Errorf("Expected priority copied, got %v", p)
+	}
+	if e := byMethod["rtm.tasks.setEstimate"]; len(e) != 1 || e[0].Get("estimate") != "2 hours" {
+		t.Errorf("Expected estimate copied, got %v", e)
+	}
+	notes := byMethod["rtm.tasks.notes.add"]
+	if len(notes) != 2 || notes[0].Get("note_title") != "Steps" || notes[1].Get("note_text") != "Ask design for assets" {
+		t.Errorf("Expected both notes copied in order, got %v", notes)
+	}
+	if byMethod["rtm.tasks.setDueDate"] != nil {
+		t.Error("Expected the due date not to be copied")
+	}
+	if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, `"series_id": "2"`) || !strings.Contains(text, `"priority": "2"`) {
+		t.Errorf("Expected the new task reported, got %s", text)
+	}
+
+	t.Run("defaults to the original list and a new name", func(t *testing.T) {
+		t.Logf("  > Why it's important: Templates are usually copied in place under the next project's name.")
+		duplicate(map[string]any{"series_id": "1", "task_id": "11", "name": "Launch checklist v2 !1"})
+		if len(changes) == 0 || changes[0].Get("list_id") != "100" || changes[0].Get("name") != "Launch checklist v2 !1" {
+			t.Errorf("Expected the copy added to list 100 under the new name, got %v", changes)
+		}
+	})
+
+	t.Run("unknown task", func(t *testing.T) {
+		t.Logf("  > Why it's important: Nothing should be created when the original cannot be found.")
+		result := duplicate(map[string]any{"series_id": "9", "task_id": "99"})
+		if !result.IsError || len(changes) != 0 {
+			t.Errorf("Expected an error without changes, got %d changes", len(changes))
+		}
+	})
+
+	t.Run("partial copy reports the new task", func(t *testing.T) {
+		t.Logf("  > Why it's important: A half-made copy exists in RTM; the agent needs its IDs to finish or remove it.")
+		source := &Task{Name: "Copy me", Notes: []Note{{Body: "fail"}}}
+		task, err := h.client.DuplicateTask(source, "100", "")
+		if err == nil || task == nil || task.ID != "21" {
+			t.Errorf("Expected the new task with the note error, got %+v, %v", task, err)
+		}
+	})
+}
@@ -3,38 +3,80 @@ package rtm
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/vcto/mcp-adapters/internal/health"
+	"github.com/vcto/mcp-adapters/internal/kv"
+	"github.com/vcto/mcp-adapters/internal/longrunning"
 )
 
 // EnhancedHandler extends base Handler with atomic tools
 type EnhancedHandler struct {
 	*Handler
 	jobQueue      *JobQueue
-	searchCache   map[string][]Task // Cache search results with positions
-	savedSearches map[string]string // User's saved searches
+	searchCacheMu sync.Mutex
+	searchCache   map[string]map[string][]Task // Search results with positions, by owner and cache key
+	savedSearches *SavedSearches               // User's saved searches
+	taskManager   *longrunning.Manager
 }
 
 // NewEnhancedHandler creates handler with atomic tools
 func NewEnhancedHandler(baseHandler *Handler) *EnhancedHandler {
 	eh := &EnhancedHandler{
 		Handler:       baseHandler,
-		searchCache:   make(map[string][]Task),
-		savedSearches: make(map[string]string),
+		searchCache:   make(map[string]map[string][]Task),
+		savedSearches: NewSavedSearches(kv.NewMemoryStore()),
 	}
 	eh.jobQueue = NewJobQueue(baseHandler)
 
-	// Load saved searches from storage if available
-	// TODO: Implement persistence
-
 	return eh
 }
 
+// SetStore keeps saved searches and batch jobs in store, so presets and
+// queued batch updates survive restarts when it is backed by SQLite.
+// Unfinished jobs already in the store are resumed.
+func (eh *EnhancedHandler) SetStore(store kv.Store) {
+	eh.savedSearches = NewSavedSearches(store)
+
+	resumed, err := eh.jobQueue.Persist(store)
+	if err != nil {
+		log.Printf("RTM: Batch jobs will not survive restarts: %v", err)
+		return
+	}
+	if resumed > 0 {
+		log.Printf("RTM: Resumed %d unfinished batch jobs", resumed)
+	}
+}
+
+// SetTaskManager sends MCP progress notifications through manager for batch
+// jobs queued by calls that carry a progress token
+func (eh *EnhancedHandler) SetTaskManager(manager *longrunning.Manager) {
+	eh.taskManager = manager
+}
+
+// startProgress begins tracking a job's progress when the request asked
+// for progress notifications. The task outlives the request.
+func (eh *EnhancedHandler) startProgress(ctx context.Context, request mcp.CallToolRequest) *longrunning.Task {
+	if eh.taskManager == nil {
+		return nil
+	}
+	_, task, _ := longrunning.WithProgress(context.WithoutCancel(ctx), request, eh.taskManager, longrunning.SessionID(ctx))
+	return task
+}
+
+// Jobs returns the queue running this handler's batch operations
+func (eh *EnhancedHandler) Jobs() *JobQueue {
+	return eh.jobQueue
+}
+
 // SetupAtomicTools registers fine-grained RTM tools
 func (eh *EnhancedHandler) SetupAtomicTools(s *server.MCPServer) {
 	// Search enhancements
@@ -80,12 +122,30 @@ func (eh *EnhancedHandler) SetupAtomicTools(s *server.MCPServer) {
 		mcp.WithString("tags", mcp.Required(), mcp.Description("Comma-separated tags to add")),
 	), eh.handleBatchTagsAdd)
 
+	s.AddTool(mcp.NewTool("move_rtm_tasks_to_list",
+		mcp.WithDescription("Move multiple tasks to another list by position. Returns job ID for async processing."),
+		mcp.WithString("positions", mcp.Required(), mcp.Description("Task position numbers")),
+		mcp.WithString("list", mcp.Required(), mcp.Description("Name or ID of the list to move tasks to")),
+	), eh.handleBatchMove)
+
+	s.AddTool(mcp.NewTool("delete_rtm_tasks_batch",
+		mcp.WithDescription("Delete multiple tasks by position or task ID. Run with dry_run=true first to list exactly what would be deleted, then confirm using the task IDs it returns. Returns job ID for async processing."),
+		mcp.WithString("positions", mcp.Description("Task position numbers from the last search")),
+		mcp.WithString("task_ids", mcp.Description("Comma-separated task IDs, e.g. from a dry run")),
+		mcp.WithBoolean("dry_run", mcp.Description("List the tasks that would be deleted without deleting them (default: false)")),
+	), eh.handleBatchDelete)
+
 	// Job management
 	s.AddTool(mcp.NewTool("check_rtm_job_status",
 		mcp.WithDescription("Check status of async batch operation. Shows progress and any failures."),
 		mcp.WithString("job_id", mcp.Required(), mcp.Description("Job ID returned from batch operation")),
 	), eh.handleCheckJobStatus)
 
+	s.AddTool(mcp.NewTool("cancel_rtm_job",
+		mcp.WithDescription("Stop a queued or running batch operation. Tasks already changed stay changed; the rest are left alone. Reports how far the job got."),
+		mcp.WithString("job_id", mcp.Required(), mcp.Description("Job ID returned from batch operation")),
+	), eh.handleCancelJob)
+
 	// Intelligent task creation
 	s.AddTool(mcp.NewTool("analyze_rtm_task_context",
 		mcp.WithDescription("Extract semantic tags from task content. Recognizes patterns like 'call doc' → #call #medical"),
@@ -101,14 +161,16 @@ func (eh *EnhancedHandler) SetupAtomicTools(s *server.MCPServer) {
 	), eh.handleSmartCreate)
 
 	s.AddTool(mcp.NewTool("create_rtm_tasks_batch",
-		mcp.WithDescription("Create multiple tasks efficiently. Returns job ID for async processing."),
+		mcp.WithDescription("Create multiple tasks, one per line, using RTM Smart Add syntax (e.g. 'Call Bob tomorrow !1 #work'). Returns a job ID for async processing, or with sync=true adds up to 10 tasks immediately and reports how each line was read."),
 		mcp.WithString("tasks", mcp.Required(), mcp.Description("Newline-separated list of tasks to create")),
 		mcp.WithString("smart_defaults", mcp.Description("Apply smart analysis to each task (default: true)")),
+		mcp.WithString("sync", mcp.Description("If true, add the tasks now and return per-line results instead of a job ID (at most 10 tasks; default: false)")),
 	), eh.handleBatchCreate)
 }
 
 // handleSmartSearch implements enhanced search with caching
 func (eh *EnhancedHandler) handleSmartSearch(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := eh.ClientFor(ctx)
 	args, ok := request.Params.Arguments.(map[string]any)
 	if !ok {
 		return mcp.NewToolResultError("invalid arguments"), nil
@@ -117,11 +179,18 @@ func (eh *EnhancedHandler) handleSmartSearch(ctx context.Context, request mcp.Ca
 	// Check for saved search
 	var query string
 	if savedName, ok := args["use_saved"].(string); ok && savedName != "" {
-		if savedQuery, exists := eh.savedSearches[savedName]; exists {
-			query = savedQuery
-		} else {
-			return mcp.NewToolResultError(fmt.Sprintf("No saved search named '%s'", savedName)), nil
+		if client.GetAuthToken() == "" {
+			return mcp.NewToolResultError("RTM authentication required. Use rtm_auth_url first"), nil
+		}
+		owner := intentOwner(client.GetAuthToken())
+		saved, exists, err := eh.savedSearches.Get(owner, savedName)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to load saved search: %v", err)), nil
+		}
+		if !exists {
+			return mcp.NewToolResultError(eh.unknownSavedSearch(owner, savedName)), nil
 		}
+		query = saved.Query
 	} else if q, ok := args["query"].(string); ok {
 		query = q
 	} else {
@@ -129,18 +198,20 @@ func (eh *EnhancedHandler) handleSmartSearch(ctx context.Context, request mcp.Ca
 	}
 
 	// Execute search
-	tasks, err := eh.client.GetTasks(query, "")
+	tasks, err := client.GetTasks(query, "")
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Search failed: %v", err)), nil
+		return health.ToolError(fmt.Sprintf("Search failed: %v", err), err), nil
 	}
 
 	// Cache results
 	cacheKey := fmt.Sprintf("search_%d", time.Now().Unix())
-	eh.searchCache[cacheKey] = tasks
+	eh.cacheSearch(intentOwner(client.GetAuthToken()), cacheKey, tasks)
 
 	// Save search if requested
 	if saveName, ok := args["save_as"].(string); ok && saveName != "" {
-		eh.savedSearches[saveName] = query
+		if err := eh.savedSearches.Save(intentOwner(client.GetAuthToken()), saveName, query); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Search ran but could not be saved: %v", err)), nil
+		}
 	}
 
 	// Format with position numbers
@@ -188,25 +259,11 @@ func (eh *EnhancedHandler) handleGetByPosition(ctx context.Context, request mcp.
 		return mcp.NewToolResultError("invalid position format"), nil
 	}
 
-	// Find most recent cache
-	var latestKey string
-	var latestTime int64
-	for key := range eh.searchCache {
-		var t int64
-		if _, err := fmt.Sscanf(key, "search_%d", &t); err != nil {
-			continue
-		}
-		if t > latestTime {
-			latestTime = t
-			latestKey = key
-		}
-	}
-
-	if latestKey == "" {
+	tasks, ok := eh.latestSearch(intentOwner(eh.ClientFor(ctx).GetAuthToken()))
+	if !ok {
 		return mcp.NewToolResultError("No cached search results. Run search_rtm_tasks_smart first."), nil
 	}
 
-	tasks := eh.searchCache[latestKey]
 	if position < 1 || position > len(tasks) {
 		return mcp.NewToolResultError(fmt.Sprintf("Position %d out of range (1-%d)", position, len(tasks))), nil
 	}
@@ -229,36 +286,54 @@ func (eh *EnhancedHandler) handleBatchDueDate(ctx context.Context, request mcp.C
 	positions, _ := args["positions"].(string)
 	dueDate, _ := args["due_date"].(string)
 
+	return eh.queueTaskJob(ctx, request, "batch_due_date", positions, map[string]interface{}{"due_date": dueDate},
+		fmt.Sprintf("Updating due date to '%s'", dueDate))
+}
+
+// queueTaskJob queues a batch job over the tasks at positions in the last
+// search and describes it to the caller
+func (eh *EnhancedHandler) queueTaskJob(ctx context.Context, request mcp.CallToolRequest, jobType, positions string, inputs map[string]interface{}, action string) (*mcp.CallToolResult, error) {
 	// Parse positions and get tasks from cache
-	tasks, err := eh.getTasksByPositions(positions)
+	tasks, err := eh.getTasksByPositions(intentOwner(eh.ClientFor(ctx).GetAuthToken()), positions)
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
+	if len(tasks) == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("No tasks at positions %q in the last search results", positions)), nil
+	}
+
+	return eh.queueTasks(ctx, request, jobType, tasks, inputs, action), nil
+}
+
+// queueTasks queues a batch job over already resolved tasks
+func (eh *EnhancedHandler) queueTasks(ctx context.Context, request mcp.CallToolRequest, jobType string, tasks []map[string]string, inputs map[string]interface{}, action string) *mcp.CallToolResult {
+	results := map[string]interface{}{"tasks": tasks}
+	for key, value := range inputs {
+		results[key] = value
+	}
 
 	// Create batch job
 	job := &BatchJob{
 		ID:         uuid.New().String(),
-		Type:       "batch_due_date",
+		Type:       jobType,
 		Status:     JobStatusPending,
 		CreatedAt:  time.Now(),
 		TotalTasks: len(tasks),
-		Results: map[string]interface{}{
-			"tasks":    tasks,
-			"due_date": dueDate,
-		},
+		Results:    results,
+		Owner:      intentOwner(eh.ClientFor(ctx).GetAuthToken()),
 	}
 
-	eh.jobQueue.QueueJob(job)
+	eh.jobQueue.QueueJobWithProgress(job, eh.startProgress(ctx, request))
 
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{
 			mcp.TextContent{
 				Type: "text",
-				Text: fmt.Sprintf("Batch update queued\nJob ID: %s\nUpdating due date to '%s' for %d tasks\nUse check_rtm_job_status to monitor progress",
-					job.ID, dueDate, len(tasks)),
+				Text: fmt.Sprintf("Batch update queued\nJob ID: %s\n%s for %d tasks\nUse check_rtm_job_status to monitor progress",
+					job.ID, action, len(tasks)),
 			},
 		},
-	}, nil
+	}
 }
 
 // handleCheckJobStatus returns job progress
@@ -269,6 +344,11 @@ func (eh *EnhancedHandler) handleCheckJobStatus(ctx context.Context, request mcp
 		return mcp.NewToolResultError("job_id required"), nil
 	}
 
+	// Jobs resumed after a restart wait for their owner to reconnect;
+	// ClientFor registers the caller's client so their jobs can run
+	eh.ClientFor(ctx)
+	eh.jobQueue.ResumeWaiting()
+
 	job, exists := eh.jobQueue.GetJob(jobID)
 	if !exists {
 		return mcp.NewToolResultError("Job not found"), nil
@@ -294,9 +374,18 @@ func (eh *EnhancedHandler) handleCheckJobStatus(ctx context.Context, request mcp
 		status["failures"] = job.Failed
 	}
 
+	if job.CancelRequested {
+		status["cancel_requested"] = true
+	}
+	if job.Status == JobStatusCancelled {
+		status["not_processed"] = job.TotalTasks - job.Completed
+	}
+
 	if job.CompletedAt != nil {
 		status["completed_at"] = job.CompletedAt
-		status["duration"] = job.CompletedAt.Sub(*job.StartedAt).Round(time.Second).String()
+		if job.StartedAt != nil {
+			status["duration"] = job.CompletedAt.Sub(*job.StartedAt).Round(time.Second).String()
+		}
 	}
 
 	data, _ := json.MarshalIndent(status, "", "  ")
@@ -310,12 +399,74 @@ func (eh *EnhancedHandler) handleCheckJobStatus(ctx context.Context, request mcp
 	}, nil
 }
 
-// Helper: get tasks by position numbers from cache
-func (eh *EnhancedHandler) getTasksByPositions(positions string) ([]map[string]string, error) {
-	// Find most recent cache
+// handleCancelJob stops a batch job owned by the current user
+func (eh *EnhancedHandler) handleCancelJob(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := eh.ClientFor(ctx)
+	jobID := request.GetString("job_id", "")
+	if jobID == "" {
+		return mcp.NewToolResultError("job_id required"), nil
+	}
+	if client.GetAuthToken() == "" {
+		return mcp.NewToolResultError("RTM authentication required. Use rtm_auth_url first"), nil
+	}
+
+	// Jobs belong to the user who queued them
+	job, exists := eh.jobQueue.GetJob(jobID)
+	if !exists || (job.Owner != "" && job.Owner != intentOwner(client.GetAuthToken())) {
+		return mcp.NewToolResultError("Job not found"), nil
+	}
+
+	snapshot, err := eh.jobQueue.Cancel(jobID)
+	switch {
+	case errors.Is(err, ErrJobNotFound):
+		return mcp.NewToolResultError("Job not found"), nil
+	case errors.Is(err, ErrJobFinished):
+		return mcp.NewToolResultText(fmt.Sprintf("Job %s already %s (%d/%d tasks processed); nothing to cancel",
+			jobID, snapshot.Status, snapshot.Completed, snapshot.TotalTasks)), nil
+	case err != nil:
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to cancel job: %v", err)), nil
+	}
+
+	var text string
+	if snapshot.Status == JobStatusCancelled {
+		text = fmt.Sprintf("Job %s cancelled before it changed any tasks (%d tasks left alone)",
+			jobID, snapshot.TotalTasks-snapshot.Completed)
+	} else {
+		text = fmt.Sprintf("Cancelling job %s: %d/%d tasks processed so far. It stops after the task in progress; "+
+			"tasks already changed stay changed. Use check_rtm_job_status for the final count.",
+			jobID, snapshot.Completed, snapshot.TotalTasks)
+	}
+	return mcp.NewToolResultText(text), nil
+}
+
+// taskRef identifies a task for a batch job
+func taskRef(task Task) map[string]string {
+	return map[string]string{
+		"list_id":   task.ListID,
+		"series_id": task.SeriesID,
+		"task_id":   task.ID,
+		"name":      task.Name,
+	}
+}
+
+// cacheSearch keeps smart search results for the owner under key
+func (eh *EnhancedHandler) cacheSearch(owner, key string, tasks []Task) {
+	eh.searchCacheMu.Lock()
+	defer eh.searchCacheMu.Unlock()
+	if eh.searchCache[owner] == nil {
+		eh.searchCache[owner] = make(map[string][]Task)
+	}
+	eh.searchCache[owner][key] = tasks
+}
+
+// latestSearch returns the owner's most recent smart search results
+func (eh *EnhancedHandler) latestSearch(owner string) ([]Task, bool) {
+	eh.searchCacheMu.Lock()
+	defer eh.searchCacheMu.Unlock()
+
 	var latestKey string
 	var latestTime int64
-	for key := range eh.searchCache {
+	for key := range eh.searchCache[owner] {
 		var t int64
 		if _, err := fmt.Sscanf(key, "search_%d", &t); err != nil {
 			continue
@@ -325,12 +476,19 @@ func (eh *EnhancedHandler) getTasksByPositions(positions string) ([]map[string]s
 			latestKey = key
 		}
 	}
-
 	if latestKey == "" {
+		return nil, false
+	}
+	return eh.searchCache[owner][latestKey], true
+}
+
+// Helper: get tasks by position numbers from the owner's cache
+func (eh *EnhancedHandler) getTasksByPositions(owner, positions string) ([]map[string]string, error) {
+	cachedTasks, ok := eh.latestSearch(owner)
+	if !ok {
 		return nil, fmt.Errorf("no cached search results")
 	}
 
-	cachedTasks := eh.searchCache[latestKey]
 	posList := strings.Split(positions, ",")
 	tasks := make([]map[string]string, 0, len(posList))
 
@@ -343,39 +501,213 @@ func (eh *EnhancedHandler) getTasksByPositions(positions string) ([]map[string]s
 			continue
 		}
 
-		task := cachedTasks[pos-1]
-		tasks = append(tasks, map[string]string{
-			"list_id":   task.ListID,
-			"series_id": task.SeriesID,
-			"task_id":   task.ID,
-		})
+		tasks = append(tasks, taskRef(cachedTasks[pos-1]))
 	}
 
 	return tasks, nil
 }
 
-// Additional batch handlers follow same pattern...
+// handleBatchPriority queues batch priority update
 func (eh *EnhancedHandler) handleBatchPriority(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	// Similar to handleBatchDueDate
-	return &mcp.CallToolResult{}, nil
+	args, _ := request.Params.Arguments.(map[string]any)
+	positions, _ := args["positions"].(string)
+	priority, _ := args["priority"].(string)
+
+	var label string
+	switch strings.ToUpper(strings.TrimSpace(priority)) {
+	case "1":
+		priority, label = "1", "high"
+	case "2":
+		priority, label = "2", "medium"
+	case "3":
+		priority, label = "3", "low"
+	case "N", "NONE":
+		priority, label = "N", "none"
+	default:
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid priority %q: use 1, 2, 3, or N", priority)), nil
+	}
+
+	return eh.queueTaskJob(ctx, request, "batch_priority", positions, map[string]interface{}{"priority": priority},
+		fmt.Sprintf("Setting priority to %s", label))
 }
 
+// handleBatchComplete queues batch completion
 func (eh *EnhancedHandler) handleBatchComplete(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	// Similar to handleBatchDueDate
-	return &mcp.CallToolResult{}, nil
+	args, _ := request.Params.Arguments.(map[string]any)
+	positions, _ := args["positions"].(string)
+
+	return eh.queueTaskJob(ctx, request, "batch_complete", positions, nil, "Completing")
 }
 
+// handleBatchTagsAdd queues adding tags, keeping each task's existing tags
 func (eh *EnhancedHandler) handleBatchTagsAdd(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	// Similar to handleBatchDueDate
-	return &mcp.CallToolResult{}, nil
+	args, _ := request.Params.Arguments.(map[string]any)
+	positions, _ := args["positions"].(string)
+	tagsArg, _ := args["tags"].(string)
+
+	var tags []string
+	for _, tag := range strings.Split(tagsArg, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) == 0 {
+		return mcp.NewToolResultError("tags required"), nil
+	}
+
+	return eh.queueTaskJob(ctx, request, "batch_tags_add", positions, map[string]interface{}{"tags": strings.Join(tags, ",")},
+		fmt.Sprintf("Adding tags %s", strings.Join(tags, ", ")))
+}
+
+// handleBatchMove queues moving tasks to another list
+func (eh *EnhancedHandler) handleBatchMove(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, _ := request.Params.Arguments.(map[string]any)
+	positions, _ := args["positions"].(string)
+	list, _ := args["list"].(string)
+	list = strings.TrimSpace(list)
+	if list == "" {
+		return mcp.NewToolResultError("list required"), nil
+	}
+
+	listID, err := eh.resolveListID(eh.ClientFor(ctx), list)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	return eh.queueTaskJob(ctx, request, "batch_move", positions, map[string]interface{}{"list_id": listID},
+		fmt.Sprintf("Moving to list '%s'", list))
+}
+
+// handleBatchDelete lists or queues deleting tasks by position or ID
+func (eh *EnhancedHandler) handleBatchDelete(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	positions := strings.TrimSpace(request.GetString("positions", ""))
+	taskIDs := strings.TrimSpace(request.GetString("task_ids", ""))
+	if (positions == "") == (taskIDs == "") {
+		return mcp.NewToolResultError("Provide either positions or task_ids"), nil
+	}
+
+	var tasks []Task
+	var err error
+	if positions != "" {
+		tasks, err = eh.tasksAtPositions(eh.ClientFor(ctx), positions)
+	} else {
+		tasks, err = eh.tasksByID(eh.ClientFor(ctx), taskIDs)
+	}
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if len(tasks) == 0 {
+		return mcp.NewToolResultError("No tasks matched the given positions or task_ids"), nil
+	}
+
+	if request.GetBool("dry_run", false) {
+		var b strings.Builder
+		ids := make([]string, len(tasks))
+		fmt.Fprintf(&b, "Dry run: %d tasks would be deleted\n", len(tasks))
+		for i, task := range tasks {
+			ids[i] = task.ID
+			fmt.Fprintf(&b, "%d. %s [task_id %s]", i+1, task.Name, task.ID)
+			if task.Due != "" {
+				fmt.Fprintf(&b, " due %s", task.Due)
+			}
+			if task.Repeat != "" {
+				b.WriteString(" (repeating)")
+			}
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "Nothing was deleted. To delete exactly these tasks, call again with task_ids=%q", strings.Join(ids, ","))
+		return mcp.NewToolResultText(b.String()), nil
+	}
+
+	refs := make([]map[string]string, len(tasks))
+	for i, task := range tasks {
+		refs[i] = taskRef(task)
+	}
+	return eh.queueTasks(ctx, request, "batch_delete", refs, nil, "Deleting"), nil
+}
+
+// tasksAtPositions returns the tasks at positions in the user's last search
+func (eh *EnhancedHandler) tasksAtPositions(client RTMClientInterface, positions string) ([]Task, error) {
+	refs, err := eh.getTasksByPositions(intentOwner(client.GetAuthToken()), positions)
+	if err != nil {
+		return nil, err
+	}
+	return eh.tasksByID(client, joinTaskIDs(refs))
+}
+
+// tasksByID finds tasks by ID in the user's cached search results, falling
+// back to fetching the user's tasks for any that are not cached
+func (eh *EnhancedHandler) tasksByID(client RTMClientInterface, ids string) ([]Task, error) {
+	known := make(map[string]Task)
+	eh.searchCacheMu.Lock()
+	for _, cached := range eh.searchCache[intentOwner(client.GetAuthToken())] {
+		for _, task := range cached {
+			known[task.ID] = task
+		}
+	}
+	eh.searchCacheMu.Unlock()
+
+	var wanted []string
+	fetched := false
+	for _, id := range strings.Split(ids, ",") {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		if _, ok := known[id]; !ok && !fetched {
+			all, err := client.GetTasks("", "")
+			if err != nil {
+				return nil, fmt.Errorf("Failed to look up tasks: %v", err)
+			}
+			for _, task := range all {
+				known[task.ID] = task
+			}
+			fetched = true
+		}
+		wanted = append(wanted, id)
+	}
+
+	tasks := make([]Task, 0, len(wanted))
+	var unknown []string
+	for _, id := range wanted {
+		task, ok := known[id]
+		if !ok {
+			unknown = append(unknown, id)
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("Unknown task IDs: %s", strings.Join(unknown, ", "))
+	}
+	return tasks, nil
+}
+
+func joinTaskIDs(refs []map[string]string) string {
+	ids := make([]string, len(refs))
+	for i, ref := range refs {
+		ids[i] = ref["task_id"]
+	}
+	return strings.Join(ids, ",")
 }
 
 func (eh *EnhancedHandler) handleSaveSearch(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := eh.ClientFor(ctx)
 	args, _ := request.Params.Arguments.(map[string]any)
 	name, _ := args["name"].(string)
 	query, _ := args["query"].(string)
+	name = strings.TrimSpace(name)
+	if name == "" || strings.TrimSpace(query) == "" {
+		return mcp.NewToolResultError("name and query are required"), nil
+	}
+	if client.GetAuthToken() == "" {
+		return mcp.NewToolResultError("RTM authentication required. Use rtm_auth_url first"), nil
+	}
 
-	eh.savedSearches[name] = query
+	if err := eh.savedSearches.Save(intentOwner(client.GetAuthToken()), name, query); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to save search: %v", err)), nil
+	}
 
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{
@@ -387,6 +719,20 @@ func (eh *EnhancedHandler) handleSaveSearch(ctx context.Context, request mcp.Cal
 	}, nil
 }
 
+// unknownSavedSearch describes a missing preset along with the owner's saved names
+func (eh *EnhancedHandler) unknownSavedSearch(owner, name string) string {
+	message := fmt.Sprintf("No saved search named '%s'", name)
+	saved, err := eh.savedSearches.List(owner)
+	if err != nil || len(saved) == 0 {
+		return message
+	}
+	names := make([]string, len(saved))
+	for i, search := range saved {
+		names[i] = search.Name
+	}
+	return fmt.Sprintf("%s. Saved searches: %s", message, strings.Join(names, ", "))
+}
+
 // Smart task creation
 func (eh *EnhancedHandler) handleAnalyzeContext(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	args, _ := request.Params.Arguments.(map[string]any)
@@ -440,6 +786,7 @@ func (eh *EnhancedHandler) handleAnalyzeContext(ctx context.Context, request mcp
 }
 
 func (eh *EnhancedHandler) handleSmartCreate(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := eh.ClientFor(ctx)
 	args, _ := request.Params.Arguments.(map[string]any)
 	taskText, _ := args["task"].(string)
 
@@ -465,9 +812,9 @@ func (eh *EnhancedHandler) handleSmartCreate(ctx context.Context, request mcp.Ca
 	}
 
 	// Create task with smart defaults
-	task, err := eh.client.AddTask(taskText, "")
+	task, err := client.AddTask(taskText, "")
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to create task: %v", err)), nil
+		return health.ToolError(fmt.Sprintf("Failed to create task: %v", err), err), nil
 	}
 
 	data, _ := json.MarshalIndent(task, "", "  ")
@@ -481,19 +828,42 @@ func (eh *EnhancedHandler) handleSmartCreate(ctx context.Context, request mcp.Ca
 	}, nil
 }
 
+// maxSyncCreate is the most tasks create_rtm_tasks_batch adds while the
+// caller waits; RTM's rate limit makes each take about a second
+const maxSyncCreate = 10
+
+// createResult is how one line of a synchronous create_rtm_tasks_batch went
+type createResult struct {
+	// Line is the line number in the tasks argument, counting blank lines
+	Line  int    `json:"line"`
+	Text  string `json:"text"`
+	Added bool   `json:"added"`
+	// Parsed reports that Smart Add read syntax from the text, so the task's
+	// name is shorter than the line
+	Parsed bool   `json:"parsed,omitempty"`
+	Task   *Task  `json:"task,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
 func (eh *EnhancedHandler) handleBatchCreate(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	args, _ := request.Params.Arguments.(map[string]any)
 	tasksText, _ := args["tasks"].(string)
 
 	tasks := strings.Split(tasksText, "\n")
 	cleanTasks := []string{}
-	for _, task := range tasks {
+	lines := []int{}
+	for i, task := range tasks {
 		task = strings.TrimSpace(task)
 		if task != "" {
 			cleanTasks = append(cleanTasks, task)
+			lines = append(lines, i+1)
 		}
 	}
 
+	if sync, _ := args["sync"].(string); sync == "true" {
+		return eh.createTasksNow(ctx, cleanTasks, lines), nil
+	}
+
 	job := &BatchJob{
 		ID:         uuid.New().String(),
 		Type:       "batch_create",
@@ -503,9 +873,10 @@ func (eh *EnhancedHandler) handleBatchCreate(ctx context.Context, request mcp.Ca
 		Results: map[string]interface{}{
 			"tasks": cleanTasks,
 		},
+		Owner: intentOwner(eh.ClientFor(ctx).GetAuthToken()),
 	}
 
-	eh.jobQueue.QueueJob(job)
+	eh.jobQueue.QueueJobWithProgress(job, eh.startProgress(ctx, request))
 
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{
@@ -517,3 +888,61 @@ func (eh *EnhancedHandler) handleBatchCreate(ctx context.Context, request mcp.Ca
 		},
 	}, nil
 }
+
+// createTasksNow adds each task with Smart Add while the caller waits,
+// reporting every line's outcome. Lines not reached before the request's
+// deadline are reported as not added.
+func (eh *EnhancedHandler) createTasksNow(ctx context.Context, texts []string, lines []int) *mcp.CallToolResult {
+	client := eh.ClientFor(ctx)
+	if client.GetAuthToken() == "" {
+		return mcp.NewToolResultError("RTM authentication required. Use rtm_auth_url first.")
+	}
+	if len(texts) == 0 {
+		return mcp.NewToolResultError("No tasks to create")
+	}
+	if len(texts) > maxSyncCreate {
+		return mcp.NewToolResultError(fmt.Sprintf("sync=true adds at most %d tasks, got %d. Omit sync to queue them as a job.", maxSyncCreate, len(texts)))
+	}
+
+	results := make([]createResult, len(texts))
+	added := 0
+	var lastErr error
+	for i, text := range texts {
+		results[i] = createResult{Line: lines[i], Text: text}
+		if err := ctx.Err(); err != nil {
+			results[i].Error = fmt.Sprintf("not attempted: %v", err)
+			lastErr = err
+			continue
+		}
+		task, err := client.SmartAdd(text)
+		if err != nil {
+			results[i].Error = err.Error()
+			lastErr = err
+			continue
+		}
+		results[i].Added = true
+		results[i].Task = task
+		results[i].Parsed = task.Name != text
+		added++
+	}
+
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"added":   added,
+		"failed":  len(texts) - added,
+		"results": results,
+	}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Failed to format results")
+	}
+	if added == 0 {
+		return health.ToolError(string(data), lastErr)
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: string(data),
+			},
+		},
+	}
+}
@@ -1,7 +1,14 @@
 package rtm
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
 )
 
 func TestEnhancedHandlerCreation(t *testing.T) {
@@ -62,3 +69,84 @@ func TestJobQueueCreation(t *testing.T) {
 		t.Fatalf("Wrong job ID: got %s, want test-123", retrieved.ID)
 	}
 }
+
+func TestBatchCreateSync(t *testing.T) {
+	t.Logf("Importance: Interactive users adding a few tasks want to see each one added, and how RTM read it, without polling a job.")
+
+	decode := func(t *testing.T, result *mcp.CallToolResult) (added, failed int, results []createResult) {
+		t.Helper()
+		var out struct {
+			Added   int            `json:"added"`
+			Failed  int            `json:"failed"`
+			Results []createResult `json:"results"`
+		}
+		if err := json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &out); err != nil {
+			t.Fatalf("Expected JSON results, got %v", result.Content)
+		}
+		return out.Added, out.Failed, out.Results
+	}
+
+	t.Run("adds with Smart Add", func(t *testing.T) {
+		t.Logf("  > Why it's important: Each line must go through Smart Add, and the result must show what RTM parsed.")
+		var parse []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			query := r.URL.Query()
+			switch query.Get("method") {
+			case "rtm.timelines.create":
+				_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok","timeline":"1"}}`)
+			case "rtm.tasks.add":
+				parse = append(parse, query.Get("parse"))
+				name, _, _ := strings.Cut(query.Get("name"), " !")
+				_, _ = fmt.Fprintf(w, `{"rsp":{"stat":"ok","list":{"id":"1","taskseries":[{"id":"2","name":%q,"task":[{"id":"3","priority":"1"}]}]}}}`, name)
+			}
+		}))
+		defer server.Close()
+
+		h := &Handler{client: NewClient("key", "secret")}
+		h.client.BaseURL = server.URL
+		h.client.Limiter = nil
+		h.client.AuthToken = "token-1"
+		eh := NewEnhancedHandler(h)
+
+		result, _ := callTool(eh.handleBatchCreate, map[string]any{"tasks": "Call Bob !1\n\nBuy milk", "sync": "true"})
+		if result.IsError {
+			t.Fatalf("Expected tasks added, got %v", result.Content)
+		}
+		added, failed, results := decode(t, result)
+		if added != 2 || failed != 0 || len(parse) != 2 || parse[0] != "1" || parse[1] != "1" {
+			t.Fatalf("Expected two Smart Adds, got %d added, %d failed, parse=%v", added, failed, parse)
+		}
+		if r := results[0]; r.Line != 1 || !r.Parsed || r.Task.Name != "Call Bob" || r.Task.Priority != "1" {
+			t.Errorf("Expected line 1 parsed to Call Bob at priority 1, got %+v", r)
+		}
+		if r := results[1]; r.Line != 3 || r.Parsed || r.Task.Name != "Buy milk" {
+			t.Errorf("Expected line 3 added as written, got %+v", r)
+		}
+	})
+
+	t.Run("reports failures per line", func(t *testing.T) {
+		t.Logf("  > Why it's important: One bad line must not hide which others were added.")
+		fake := NewFakeClient("token")
+		eh := NewEnhancedHandler(NewHandlerWithClient(fake))
+
+		fake.Err = &RTMError{Code: errCodeInvalidToken, Msg: "Invalid auth token"}
+		result, _ := callTool(eh.handleBatchCreate, map[string]any{"tasks": "One\nTwo", "sync": "true"})
+		added, failed, results := decode(t, result)
+		if !result.IsError || added != 0 || failed != 2 || !strings.Contains(results[1].Error, "reconnect") {
+			t.Errorf("Expected every line to fail with advice, got %+v", results)
+		}
+		if len(fake.Tasks) != 0 {
+			t.Errorf("Expected nothing added, got %+v", fake.Tasks)
+		}
+	})
+
+	t.Run("limits sync size", func(t *testing.T) {
+		t.Logf("  > Why it's important: Large batches would hold the call open for too long and belong in a job.")
+		fake := NewFakeClient("token")
+		eh := NewEnhancedHandler(NewHandlerWithClient(fake))
+		result, _ := callTool(eh.handleBatchCreate, map[string]any{"tasks": strings.Repeat("Task\n", maxSyncCreate+1), "sync": "true"})
+		if !result.IsError || len(fake.Calls) != 0 {
+			t.Errorf("Expected the batch refused without calls, got %v and %v", result.Content, fake.Calls)
+		}
+	})
+}
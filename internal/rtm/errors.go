@@ -0,0 +1,65 @@
+package rtm
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vcto/mcp-adapters/internal/health"
+)
+
+// RTM error codes handled specially
+const (
+	errCodeInvalidToken       = 98
+	errCodeNotAuthorized      = 101
+	errCodeServiceUnavailable = 105
+	errCodeMethodNotFound     = 112
+)
+
+// Errors for well-known RTM error codes. An *RTMError with one of these codes
+// matches it with errors.Is.
+var (
+	ErrInvalidToken   = errors.New("the Remember The Milk login is invalid or has expired; reconnect Remember The Milk and try again")
+	ErrNotAuthorized  = errors.New("Remember The Milk access has not been granted; approve this app in Remember The Milk and try again")
+	ErrUnavailable    = errors.New("Remember The Milk is temporarily unavailable; try again in a few minutes")
+	ErrMethodNotFound = errors.New("Remember The Milk does not recognise this request; the server may need updating")
+)
+
+var codeErrors = map[int]error{
+	errCodeInvalidToken:       ErrInvalidToken,
+	errCodeNotAuthorized:      ErrNotAuthorized,
+	errCodeServiceUnavailable: ErrUnavailable,
+	errCodeMethodNotFound:     ErrMethodNotFound,
+}
+
+// RTMError represents an RTM API error
+type RTMError struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+// Error explains well-known codes in terms the user can act on, keeping
+// RTM's own message for reference
+func (e *RTMError) Error() string {
+	if known, ok := codeErrors[e.Code]; ok {
+		return fmt.Sprintf("%v (RTM error %d: %s)", known, e.Code, e.Msg)
+	}
+	return fmt.Sprintf("RTM API error %d: %s", e.Code, e.Msg)
+}
+
+// Is matches the error for e's code, such as ErrInvalidToken for code 98
+func (e *RTMError) Is(target error) bool {
+	known, ok := codeErrors[e.Code]
+	return ok && known == target
+}
+
+// RetryHint marks errors that retrying cannot fix until the user acts.
+// Service unavailable errors are marked retryable as upstream failures.
+func (e *RTMError) RetryHint() (health.RetryHint, bool) {
+	switch e.Code {
+	case errCodeInvalidToken, errCodeNotAuthorized:
+		return health.RetryHint{Reason: "reauthentication required"}, true
+	case errCodeMethodNotFound:
+		return health.RetryHint{Reason: "method not supported"}, true
+	}
+	return health.RetryHint{}, false
+}
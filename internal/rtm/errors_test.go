@@ -0,0 +1,69 @@
+package rtm
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/vcto/mcp-adapters/internal/health"
+)
+
+func TestRTMErrorCodes(t *testing.T) {
+	t.Logf("Importance: \"RTM API error 98\" means nothing to a user; well-known codes must say what to do next.")
+
+	cases := []struct {
+		code    string
+		target  error
+		advice  string
+		reason  string
+		retries bool
+	}{
+		{"98", ErrInvalidToken, "reconnect Remember The Milk", "reauthentication required", false},
+		{"101", ErrNotAuthorized, "approve this app", "reauthentication required", false},
+		{"105", ErrUnavailable, "try again in a few minutes", "upstream unavailable", true},
+		{"112", ErrMethodNotFound, "may need updating", "method not supported", false},
+	}
+	for _, tc := range cases {
+		t.Run("code "+tc.code, func(t *testing.T) {
+			t.Logf("  > Why it's important: Callers branch on the typed error and agents on the retry hint.")
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"rsp":{"stat":"fail","err":{"code":"` + tc.code + `","msg":"RTM says no"}}}`))
+			}))
+			defer server.Close()
+
+			client := newRetryTestClient(server.URL)
+			client.Retry = RetryPolicy{}
+			_, err := client.Call("rtm.lists.getList", nil)
+			if !errors.Is(err, tc.target) {
+				t.Fatalf("Expected %v, got %v", tc.target, err)
+			}
+			if !strings.Contains(err.Error(), tc.advice) || !strings.Contains(err.Error(), "RTM error "+tc.code) {
+				t.Errorf("Expected advice and the RTM code in %q", err.Error())
+			}
+
+			result := health.ToolError("Failed to get lists: "+err.Error(), err)
+			hint, ok := health.GetRetryHint(result)
+			if !result.IsError || !ok || hint.Reason != tc.reason || hint.Retryable != tc.retries {
+				t.Errorf("Expected an error result with reason %q, got %+v", tc.reason, hint)
+			}
+		})
+	}
+
+	t.Run("other codes", func(t *testing.T) {
+		t.Logf("  > Why it's important: Codes without special handling keep RTM's own message and no hint.")
+		err := &RTMError{Code: 340, Msg: "list_id invalid or not provided"}
+		if err.Error() != "RTM API error 340: list_id invalid or not provided" || errors.Is(err, ErrInvalidToken) {
+			t.Errorf("Unexpected error %q", err.Error())
+		}
+		result := health.ToolError(err.Error(), err)
+		if _, ok := health.GetRetryHint(result); ok {
+			t.Error("Expected no retry hint")
+		}
+		if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "340") {
+			t.Errorf("Expected the code in %q", text)
+		}
+	})
+}
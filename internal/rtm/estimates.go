@@ -0,0 +1,259 @@
+package rtm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/vcto/mcp-adapters/internal/health"
+)
+
+// defaultEstimateQuery is the search rtm_estimate_report totals by default
+const defaultEstimateQuery = "due:today"
+
+var (
+	// isoEstimate matches ISO 8601 durations as RTM returns them, e.g. PT1H30M
+	isoEstimate = regexp.MustCompile(`^p(?:(\d+(?:\.\d+)?)d)?(?:t(?:(\d+(?:\.\d+)?)h)?(?:(\d+(?:\.\d+)?)m)?(?:(\d+(?:\.\d+)?)s)?)?$`)
+	// textEstimate matches one part of an estimate as typed, e.g. "1.5 hours"
+	textEstimate = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*(days?|d|hours?|hrs?|h|minutes?|mins?|m)`)
+	// estimateFiller is what may separate the parts of a typed estimate
+	estimateFiller = regexp.MustCompile(`^[\s,]*(and[\s,]*)?$`)
+)
+
+// ParseEstimate reads a task's time estimate, either an ISO 8601 duration such
+// as "PT1H30M" or text such as "1 hour 30 minutes" or "2h". A day counts as 24
+// hours. It reports false for empty or unreadable estimates.
+func ParseEstimate(estimate string) (time.Duration, bool) {
+	estimate = strings.ToLower(strings.TrimSpace(estimate))
+	if estimate == "" {
+		return 0, false
+	}
+
+	if m := isoEstimate.FindStringSubmatch(estimate); m != nil {
+		if estimate == "p" || estimate == "pt" {
+			return 0, false
+		}
+		units := []time.Duration{24 * time.Hour, time.Hour, time.Minute, time.Second}
+		var total time.Duration
+		for i, unit := range units {
+			if m[i+1] != "" {
+				n, _ := strconv.ParseFloat(m[i+1], 64)
+				total += time.Duration(n * float64(unit))
+			}
+		}
+		return total, true
+	}
+
+	matches := textEstimate.FindAllStringSubmatchIndex(estimate, -1)
+	if len(matches) == 0 {
+		return 0, false
+	}
+	var total time.Duration
+	last := 0
+	for _, m := range matches {
+		if !estimateFiller.MatchString(estimate[last:m[0]]) {
+			return 0, false
+		}
+		n, _ := strconv.ParseFloat(estimate[m[2]:m[3]], 64)
+		unit := time.Minute
+		switch estimate[m[4]] {
+		case 'd':
+			unit = 24 * time.Hour
+		case 'h':
+			unit = time.Hour
+		}
+		total += time.Duration(n * float64(unit))
+		last = m[1]
+	}
+	if strings.TrimSpace(estimate[last:]) != "" {
+		return 0, false
+	}
+	return total, true
+}
+
+// formatEstimate writes d in hours and minutes, e.g. "2h 30m"
+func formatEstimate(d time.Duration) string {
+	minutes := int(d.Round(time.Minute) / time.Minute)
+	sign := ""
+	if minutes < 0 {
+		sign, minutes = "-", -minutes
+	}
+	switch {
+	case minutes < 60:
+		return fmt.Sprintf("%s%dm", sign, minutes)
+	case minutes%60 == 0:
+		return fmt.Sprintf("%s%dh", sign, minutes/60)
+	default:
+		return fmt.Sprintf("%s%dh %dm", sign, minutes/60, minutes%60)
+	}
+}
+
+// EstimateTotal is the estimated time of the tasks in one list or with one tag
+type EstimateTotal struct {
+	Name string `json:"name"`
+	// Tasks counts every task in the group, estimated or not
+	Tasks   int    `json:"tasks"`
+	Minutes int    `json:"minutes"`
+	Total   string `json:"total"`
+	// Unestimated counts the group's tasks without a readable estimate
+	Unestimated int `json:"unestimated,omitempty"`
+}
+
+// EstimateReport sums the time estimates of a set of tasks
+type EstimateReport struct {
+	Query     string `json:"query"`
+	Tasks     int    `json:"tasks"`
+	Estimated int    `json:"estimated"`
+	Minutes   int    `json:"minutes"`
+	Total     string `json:"total"`
+	// Available, SpareMinutes and Fits compare the total with the time the
+	// user has; SpareMinutes is negative when the plan runs over
+	Available    string `json:"available,omitempty"`
+	SpareMinutes *int   `json:"spare_minutes,omitempty"`
+	Fits         *bool  `json:"fits,omitempty"`
+	// ByList and ByTag are largest first. A task with several tags counts
+	// toward each of them.
+	ByList []EstimateTotal `json:"by_list"`
+	ByTag  []EstimateTotal `json:"by_tag"`
+	// Unestimated names the tasks without a readable estimate
+	Unestimated []string `json:"unestimated,omitempty"`
+}
+
+// EstimateTotals sums the estimates of tasks, grouped by list and by tag.
+// Lists are named from lists, falling back to their IDs.
+func EstimateTotals(tasks []Task, lists []List) EstimateReport {
+	listNames := make(map[string]string, len(lists))
+	for _, list := range lists {
+		listNames[list.ID] = list.Name
+	}
+
+	var total time.Duration
+	report := EstimateReport{Tasks: len(tasks)}
+	byList := map[string]*estimateGroup{}
+	byTag := map[string]*estimateGroup{}
+	for _, task := range tasks {
+		d, ok := ParseEstimate(task.Estimate)
+		if ok {
+			report.Estimated++
+			total += d
+		} else {
+			report.Unestimated = append(report.Unestimated, task.Name)
+		}
+
+		name := listNames[task.ListID]
+		if name == "" {
+			name = task.ListID
+		}
+		addToGroup(byList, name, d, ok)
+		for _, tag := range task.Tags {
+			addToGroup(byTag, tag, d, ok)
+		}
+	}
+
+	report.Minutes = int(total.Round(time.Minute) / time.Minute)
+	report.Total = formatEstimate(total)
+	report.ByList = sortedTotals(byList)
+	report.ByTag = sortedTotals(byTag)
+	return report
+}
+
+type estimateGroup struct {
+	tasks, unestimated int
+	total              time.Duration
+}
+
+func addToGroup(groups map[string]*estimateGroup, name string, d time.Duration, estimated bool) {
+	group := groups[name]
+	if group == nil {
+		group = &estimateGroup{}
+		groups[name] = group
+	}
+	group.tasks++
+	if estimated {
+		group.total += d
+	} else {
+		group.unestimated++
+	}
+}
+
+func sortedTotals(groups map[string]*estimateGroup) []EstimateTotal {
+	totals := make([]EstimateTotal, 0, len(groups))
+	for name, group := range groups {
+		totals = append(totals, EstimateTotal{
+			Name:        name,
+			Tasks:       group.tasks,
+			Minutes:     int(group.total.Round(time.Minute) / time.Minute),
+			Total:       formatEstimate(group.total),
+			Unestimated: group.unestimated,
+		})
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Minutes != totals[j].Minutes {
+			return totals[i].Minutes > totals[j].Minutes
+		}
+		return totals[i].Name < totals[j].Name
+	})
+	return totals
+}
+
+func (h *Handler) handleEstimateReport(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := h.ClientFor(ctx)
+	params, err := parseParams[EstimateReportParams](request.Params.Arguments)
+	if err != nil {
+		return mcp.NewToolResultError("invalid arguments format"), nil
+	}
+	if client.GetAuthToken() == "" {
+		return mcp.NewToolResultError("RTM authentication required. Use rtm_auth_url first."), nil
+	}
+
+	var available time.Duration
+	if params.Available != "" {
+		var ok bool
+		if available, ok = ParseEstimate(params.Available); !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid available time '%s'. Use a duration such as '6h' or '7 hours 30 minutes'.", params.Available)), nil
+		}
+	}
+
+	query := params.Query
+	if query == "" {
+		query = defaultEstimateQuery
+	}
+	query, tasks, err := h.searchTasks(client, query, false, true)
+	if err != nil {
+		return health.ToolError(fmt.Sprintf("Failed to search tasks: %v", err), err), nil
+	}
+	lists, err := client.GetLists()
+	if err != nil {
+		return health.ToolError(fmt.Sprintf("Failed to get lists: %v", err), err), nil
+	}
+
+	report := EstimateTotals(tasks, lists)
+	report.Query = query
+	if params.Available != "" {
+		spare := int(available.Round(time.Minute)/time.Minute) - report.Minutes
+		fits := spare >= 0
+		report.Available = formatEstimate(available)
+		report.SpareMinutes = &spare
+		report.Fits = &fits
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Failed to format estimate report"), nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: string(data),
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,95 @@
+package rtm
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestParseEstimate(t *testing.T) {
+	t.Logf("Importance: RTM returns estimates as ISO 8601 durations but users type them freely; both must add up.")
+
+	cases := map[string]struct {
+		in   string
+		want time.Duration
+		ok   bool
+	}{
+		"iso hours and minutes": {"PT1H30M", 90 * time.Minute, true},
+		"iso day":               {"P1D", 24 * time.Hour, true},
+		"typed":                 {"1 hour 30 minutes", 90 * time.Minute, true},
+		"typed with and":        {"2 hours and 15 mins", 135 * time.Minute, true},
+		"compact":               {"1h30m", 90 * time.Minute, true},
+		"fraction":              {"1.5 hours", 90 * time.Minute, true},
+		"empty":                 {"", 0, false},
+		"bare iso":              {"PT", 0, false},
+		"months":                {"2 months", 0, false},
+		"words":                 {"a while", 0, false},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, ok := ParseEstimate(tc.in)
+			if got != tc.want || ok != tc.ok {
+				t.Errorf("ParseEstimate(%q) = %s, %v; want %s, %v", tc.in, got, ok, tc.want, tc.ok)
+			}
+		})
+	}
+}
+
+func TestEstimateReport(t *testing.T) {
+	t.Logf("Importance: Users check whether today's plan fits in the day before committing to it.")
+
+	fake := NewFakeClient("token")
+	fake.Lists = append(fake.Lists, List{ID: "work", Name: "Work"})
+	fake.Tasks = []Task{
+		{ID: "1", SeriesID: "1", ListID: "work", Name: "Write report", Estimate: "PT2H", Tags: []string{"deep", "writing"}},
+		{ID: "2", SeriesID: "2", ListID: "work", Name: "Review PRs", Estimate: "45 minutes", Tags: []string{"deep"}},
+		{ID: "3", SeriesID: "3", ListID: "inbox", Name: "Call dentist", Estimate: "PT15M"},
+		{ID: "4", SeriesID: "4", ListID: "inbox", Name: "Tidy desk"},
+	}
+	h := NewHandlerWithClient(fake)
+
+	report := func(t *testing.T, args map[string]any) EstimateReport {
+		t.Helper()
+		result, _ := callTool(h.handleEstimateReport, args)
+		if result.IsError {
+			t.Fatalf("rtm_estimate_report failed: %v", result.Content)
+		}
+		var report EstimateReport
+		if err := json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &report); err != nil {
+			t.Fatal(err)
+		}
+		return report
+	}
+
+	t.Run("totals by list and tag", func(t *testing.T) {
+		t.Logf("  > Why it's important: Seeing where the time goes shows what to move when the day is full.")
+		got := report(t, map[string]any{})
+		if got.Query != defaultEstimateQuery || got.Tasks != 4 || got.Estimated != 3 || got.Minutes != 180 || got.Total != "3h" {
+			t.Errorf("Unexpected totals %+v", got)
+		}
+		if len(got.ByList) != 2 || got.ByList[0] != (EstimateTotal{Name: "Work", Tasks: 2, Minutes: 165, Total: "2h 45m"}) ||
+			got.ByList[1] != (EstimateTotal{Name: "Inbox", Tasks: 2, Minutes: 15, Total: "15m", Unestimated: 1}) {
+			t.Errorf("Unexpected list totals %+v", got.ByList)
+		}
+		if len(got.ByTag) != 2 || got.ByTag[0].Name != "deep" || got.ByTag[0].Minutes != 165 || got.ByTag[1].Name != "writing" {
+			t.Errorf("Unexpected tag totals %+v", got.ByTag)
+		}
+		if len(got.Unestimated) != 1 || got.Unestimated[0] != "Tidy desk" || got.Fits != nil {
+			t.Errorf("Expected Tidy desk unestimated and no fit without available time, got %+v", got)
+		}
+	})
+
+	t.Run("compares with available time", func(t *testing.T) {
+		t.Logf("  > Why it's important: The answer to \"does it fit\" should not need arithmetic from the agent.")
+		got := report(t, map[string]any{"available": "2h 30m"})
+		if got.Fits == nil || *got.Fits || got.SpareMinutes == nil || *got.SpareMinutes != -30 || got.Available != "2h 30m" {
+			t.Errorf("Expected the plan 30 minutes over, got %+v", got)
+		}
+		result, _ := callTool(h.handleEstimateReport, map[string]any{"available": "all day"})
+		if !result.IsError {
+			t.Error("Expected an unreadable available time to be rejected")
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package rtm
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/vcto/mcp-adapters/internal/health"
+)
+
+// csvHeader lists the columns written by TasksCSV
+var csvHeader = []string{
+	"name", "due", "priority", "tags", "list_id", "completed",
+	"estimate", "repeat", "url", "notes", "series_id", "task_id",
+}
+
+// TasksCSV renders tasks as CSV with a header row. Tags are separated by
+// spaces and notes by blank lines so each task stays on one record.
+func TasksCSV(tasks []Task) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	if err := w.Write(csvHeader); err != nil {
+		return "", err
+	}
+	for _, task := range tasks {
+		notes := make([]string, 0, len(task.Notes))
+		for _, note := range task.Notes {
+			notes = append(notes, strings.TrimSpace(note.Title+"\n"+note.Body))
+		}
+		record := []string{
+			task.Name,
+			task.Due,
+			task.Priority,
+			strings.Join(task.Tags, " "),
+			task.ListID,
+			task.Completed,
+			task.Estimate,
+			task.Repeat,
+			task.URL,
+			strings.Join(notes, "\n\n"),
+			task.SeriesID,
+			task.ID,
+		}
+		if err := w.Write(record); err != nil {
+			return "", err
+		}
+	}
+
+	w.Flush()
+	return buf.String(), w.Error()
+}
+
+func (h *Handler) handleExportCSV(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := h.ClientFor(ctx)
+	params, err := parseParams[ExportCSVParams](request.Params.Arguments)
+	if err != nil {
+		return mcp.NewToolResultError("invalid arguments format"), nil
+	}
+	if client.GetAuthToken() == "" {
+		return mcp.NewToolResultError("RTM authentication required. Use rtm_auth_url first."), nil
+	}
+
+	var tasks []Task
+	if params.Query == "" {
+		cached := h.lastSearch(intentOwner(client.GetAuthToken()))
+		if cached == nil {
+			return mcp.NewToolResultError("No previous search to export. Pass a query or run rtm_search first."), nil
+		}
+		tasks = cached.tasks
+	} else {
+		_, tasks, err = h.searchTasks(client, params.Query, params.IncludeCompleted == "true", true)
+		if err != nil {
+			return health.ToolError(fmt.Sprintf("Failed to search tasks: %v", err), err), nil
+		}
+	}
+
+	data, err := TasksCSV(tasks)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to format CSV: %v", err)), nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: data,
+			},
+		},
+	}, nil
+}
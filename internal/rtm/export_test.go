@@ -0,0 +1,68 @@
+package rtm
+
+import (
+	"context"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestTasksCSV(t *testing.T) {
+	t.Logf("Importance: Spreadsheets must receive one row per task even when names or notes contain commas, quotes or newlines.")
+
+	data, err := TasksCSV([]Task{
+		{ID: "11", SeriesID: "1", Name: `Buy "good" milk, eggs`, Priority: "1", Tags: []string{"shopping", "home"},
+			Notes: []Note{{Title: "Store", Body: "Corner shop\nnot the mall"}}},
+		{ID: "21", SeriesID: "2", Name: "Call mom", Due: "2024-05-03T18:00:00-04:00"},
+	})
+	if err != nil {
+		t.Fatalf("TasksCSV failed: %v", err)
+	}
+
+	records, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil {
+		t.Fatalf("Output is not valid CSV: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("Expected header and 2 rows, got %d", len(records))
+	}
+	if records[0][0] != "name" || len(records[0]) != len(csvHeader) {
+		t.Errorf("Unexpected header: %v", records[0])
+	}
+	if records[1][0] != `Buy "good" milk, eggs` || records[1][3] != "shopping home" {
+		t.Errorf("Unexpected first row: %v", records[1])
+	}
+	if records[1][9] != "Store\nCorner shop\nnot the mall" {
+		t.Errorf("Expected notes to survive round trip, got %q", records[1][9])
+	}
+}
+
+func TestHandleExportCSVUsesCachedSearch(t *testing.T) {
+	t.Logf("Importance: Exporting right after rtm_search must not need the query again or hit RTM a second time.")
+
+	h := &Handler{client: NewClient("key", "secret")}
+	h.client.AuthToken = "token"
+	request := mcp.CallToolRequest{}
+
+	result, _ := h.handleExportCSV(context.Background(), request)
+	if !result.IsError {
+		t.Error("Expected an error before any search has run")
+	}
+
+	h.cacheSearch(intentOwner("token"), &searchResultCache{
+		query:     "list:Inbox",
+		tasks:     []Task{{ID: "1", SeriesID: "2", Name: "Cached task"}},
+		timestamp: time.Now(),
+	})
+	result, _ = h.handleExportCSV(context.Background(), request)
+	if result.IsError {
+		t.Fatalf("Unexpected error: %v", result.Content)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	if !strings.Contains(text, "Cached task") {
+		t.Errorf("Expected cached task in CSV, got %q", text)
+	}
+}
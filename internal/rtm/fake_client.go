@@ -0,0 +1,544 @@
+package rtm
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FakeClient is an in-memory RTMClientInterface for tests of Handler and
+// EnhancedHandler that need no HTTP:
+//
+//	fake := NewFakeClient("token")
+//	fake.AddTask("Buy milk", "")
+//	h := NewHandlerWithClient(fake)
+//
+// Every task matches every filter unless Match is set. Mutations are
+// recorded in the undo log and can be undone.
+type FakeClient struct {
+	mu sync.Mutex
+
+	APIKey string
+	Token  string
+	// Lists start with the Inbox, which tasks are added to by default
+	Lists     []List
+	Tasks     []Task
+	Locations []Location
+	Settings  Settings
+	// Account is who CheckToken reports the token acts for
+	Account Account
+	// Match decides whether a task matches an RTM filter
+	Match func(task Task, filter string) bool
+	// Err, when set, fails every call that would reach RTM
+	Err error
+	// Calls names the methods called, in order
+	Calls []string
+	// Transactions records each mutation as undoable
+	Transactions *TransactionLog
+	// Now is the clock for task timestamps
+	Now func() time.Time
+
+	nextID int
+	// undo holds the lists and tasks before each transaction
+	undo map[string]fakeState
+}
+
+type fakeState struct {
+	lists []List
+	tasks []Task
+}
+
+// NewFakeClient creates a fake acting with token, holding only an Inbox
+func NewFakeClient(token string) *FakeClient {
+	return &FakeClient{
+		APIKey:       "fake-key",
+		Token:        token,
+		Lists:        []List{{ID: "inbox", Name: "Inbox", Locked: "1"}},
+		Account:      Account{UserID: "1", Username: "fake", FullName: "Fake User", Perms: "delete"},
+		Transactions: NewTransactionLog(defaultUndoHistory, 0),
+		Now:          time.Now,
+		undo:         make(map[string]fakeState),
+	}
+}
+
+// AddTask adds an incomplete task to the list, the Inbox if listID is empty
+func (f *FakeClient) AddTask(name, listID string) (*Task, error) {
+	if err := f.call("AddTask"); err != nil {
+		return nil, err
+	}
+	return f.addTask(name, listID)
+}
+
+// SmartAdd adds a task to the Inbox named after the whole text; the fake does
+// not parse Smart Add syntax
+func (f *FakeClient) SmartAdd(text string) (*Task, error) {
+	if err := f.call("SmartAdd"); err != nil {
+		return nil, err
+	}
+	return f.addTask(text, "")
+}
+
+func (f *FakeClient) addTask(name, listID string) (*Task, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if listID == "" {
+		listID = f.Lists[0].ID
+	}
+	if f.list(listID) == nil {
+		return nil, invalidList()
+	}
+	f.save("rtm.tasks.add")
+	f.nextID++
+	now := f.Now()
+	task := Task{
+		ID:       fmt.Sprintf("t%d", f.nextID),
+		SeriesID: fmt.Sprintf("s%d", f.nextID),
+		ListID:   listID,
+		Name:     name,
+		Priority: "N",
+		Added:    now,
+		Modified: now,
+	}
+	f.Tasks = append(f.Tasks, task)
+	return &task, nil
+}
+
+func (f *FakeClient) GetFrob() (string, error) {
+	return "fake-frob", f.call("GetFrob")
+}
+
+func (f *FakeClient) GetToken(frob string) error {
+	if err := f.call("GetToken"); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Token == "" {
+		f.Token = "fake-token"
+	}
+	return nil
+}
+
+func (f *FakeClient) GetAPIKey() string {
+	return f.APIKey
+}
+
+func (f *FakeClient) GetAuthToken() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.Token
+}
+
+func (f *FakeClient) SetAuthToken(token string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Token = token
+}
+
+func (f *FakeClient) Sign(params map[string]string) string {
+	return "fake-signature"
+}
+
+func (f *FakeClient) AuthURL(perms string) string {
+	return defaultAuthEndpoint + "?api_key=" + f.APIKey + "&perms=" + perms
+}
+
+func (f *FakeClient) CheckToken() (*Account, error) {
+	if err := f.call("CheckToken"); err != nil {
+		return nil, err
+	}
+	account := f.Account
+	return &account, nil
+}
+
+func (f *FakeClient) GetSettings() (*Settings, error) {
+	if err := f.call("GetSettings"); err != nil {
+		return nil, err
+	}
+	settings := f.Settings
+	return &settings, nil
+}
+
+func (f *FakeClient) GetLists() ([]List, error) {
+	if err := f.call("GetLists"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.Lists), nil
+}
+
+// GetTags returns the tags of all tasks, in the order first seen
+func (f *FakeClient) GetTags() ([]string, error) {
+	if err := f.call("GetTags"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var tags []string
+	for _, task := range f.Tasks {
+		for _, tag := range task.Tags {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags, nil
+}
+
+func (f *FakeClient) GetLocations() ([]Location, error) {
+	if err := f.call("GetLocations"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.Locations), nil
+}
+
+func (f *FakeClient) CreateList(name, filter string) (*List, error) {
+	if err := f.call("CreateList"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.save("rtm.lists.add")
+	f.nextID++
+	list := List{ID: fmt.Sprintf("l%d", f.nextID), Name: name, Filter: filter, Smart: "0"}
+	if filter != "" {
+		list.Smart = "1"
+	}
+	f.Lists = append(f.Lists, list)
+	return &list, nil
+}
+
+func (f *FakeClient) RenameList(listID, newName string) error {
+	return f.changeList("RenameList", "rtm.lists.setName", listID, func(list *List) { list.Name = newName })
+}
+
+func (f *FakeClient) ArchiveList(listID string, archive bool) error {
+	method := "rtm.lists.unarchive"
+	if archive {
+		method = "rtm.lists.archive"
+	}
+	return f.changeList("ArchiveList", method, listID, func(list *List) { list.Archived = flagValue(archive) })
+}
+
+func (f *FakeClient) GetTasks(filter, listID string) ([]Task, error) {
+	return f.GetTasksWithOptions(filter, listID, TaskListOptions{})
+}
+
+func (f *FakeClient) GetTasksWithOptions(filter, listID string, opts TaskListOptions) ([]Task, error) {
+	if err := f.call("GetTasks"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var tasks []Task
+	for _, task := range f.Tasks {
+		if (task.Completed != "" && !opts.IncludeCompleted) || (task.Deleted != "" && !opts.IncludeDeleted) {
+			continue
+		}
+		if listID != "" && task.ListID != listID {
+			continue
+		}
+		if filter != "" && f.Match != nil && !f.Match(task, filter) {
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+func (f *FakeClient) GetTask(seriesID, taskID string) (*Task, error) {
+	tasks, err := f.GetTasksWithOptions("", "", TaskListOptions{IncludeCompleted: true, IncludeDeleted: true})
+	if err != nil {
+		return nil, err
+	}
+	for _, task := range tasks {
+		if task.SeriesID == seriesID && task.ID == taskID {
+			return &task, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: series %s, task %s", ErrTaskNotFound, seriesID, taskID)
+}
+
+func (f *FakeClient) GetCalendarTasks(days int) ([]Task, error) {
+	return f.GetTasks(CalendarFilter(days), "")
+}
+
+// GetChanges returns the tasks modified after since
+func (f *FakeClient) GetChanges(since time.Time) (*Changes, error) {
+	if err := f.call("GetChanges"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	changes := &Changes{Since: since, SyncedAt: f.Now(), Tasks: []Task{}, Deleted: []DeletedTask{}}
+	for _, task := range f.Tasks {
+		switch {
+		case !task.Modified.After(since):
+		case task.Deleted != "":
+			changes.Deleted = append(changes.Deleted, DeletedTask{SeriesID: task.SeriesID, ID: task.ID})
+		default:
+			changes.Tasks = append(changes.Tasks, task)
+		}
+	}
+	return changes, nil
+}
+
+func (f *FakeClient) SyncTime() time.Time {
+	return f.Now()
+}
+
+// Chunked is false; the fake is never too large to search whole
+func (f *FakeClient) Chunked() bool {
+	return false
+}
+
+func (f *FakeClient) SearchPage(filter string, opts TaskListOptions, offset, limit int) ([]Task, int, error) {
+	tasks, err := f.GetTasksWithOptions(filter, "", opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	end := min(offset+limit, len(tasks))
+	if offset >= end {
+		return nil, len(tasks), nil
+	}
+	return tasks[offset:end], len(tasks), nil
+}
+
+// DuplicateTask copies the name, tags, priority, estimate and notes of source
+// into a new task, as Client.DuplicateTask does
+func (f *FakeClient) DuplicateTask(source *Task, listID, name string) (*Task, error) {
+	if name == "" {
+		name = source.Name
+	}
+	task, err := f.AddTask(name, listID)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	copied := f.task(task.SeriesID, task.ID)
+	copied.Tags = slices.Clone(source.Tags)
+	copied.Priority = source.Priority
+	copied.Estimate = source.Estimate
+	copied.Notes = slices.Clone(source.Notes)
+	result := *copied
+	return &result, nil
+}
+
+// updateMethods are the RTM methods Client.UpdateTask calls for each field
+var updateMethods = map[string]string{
+	"name":     "rtm.tasks.setName",
+	"due":      "rtm.tasks.setDueDate",
+	"priority": "rtm.tasks.setPriority",
+	"estimate": "rtm.tasks.setEstimate",
+	"tags":     "rtm.tasks.setTags",
+	"list":     "rtm.tasks.moveTo",
+	"location": "rtm.tasks.setLocation",
+	"url":      "rtm.tasks.setURL",
+}
+
+// UpdateTask supports the fields Client.UpdateTask does, each as its own
+// transaction
+func (f *FakeClient) UpdateTask(listID, seriesID, taskID string, updates map[string]string) error {
+	for field, value := range updates {
+		method, ok := updateMethods[field]
+		if !ok {
+			return fmt.Errorf("unsupported field: %s", field)
+		}
+		err := f.changeTask("UpdateTask", method, listID, seriesID, taskID, func(task *Task) error {
+			switch field {
+			case "name":
+				task.Name = value
+			case "due":
+				task.Due = value
+			case "priority":
+				task.Priority = value
+			case "estimate":
+				task.Estimate = value
+			case "tags":
+				task.Tags = splitTags(nil, value)
+			case "list":
+				if f.list(value) == nil {
+					return invalidList()
+				}
+				task.ListID = value
+			case "location":
+				task.LocationID = value
+			case "url":
+				task.URL = value
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("updating %s: %w", field, err)
+		}
+		if field == "list" {
+			listID = value
+		}
+	}
+	return nil
+}
+
+func (f *FakeClient) CompleteTask(listID, seriesID, taskID string) error {
+	return f.changeTask("CompleteTask", "rtm.tasks.complete", listID, seriesID, taskID, func(task *Task) error {
+		task.Completed = f.Now().UTC().Format(time.RFC3339)
+		return nil
+	})
+}
+
+func (f *FakeClient) DeleteTask(listID, seriesID, taskID string) error {
+	return f.changeTask("DeleteTask", "rtm.tasks.delete", listID, seriesID, taskID, func(task *Task) error {
+		task.Deleted = f.Now().UTC().Format(time.RFC3339)
+		return nil
+	})
+}
+
+func (f *FakeClient) AddTags(listID, seriesID, taskID, tags string) error {
+	return f.changeTask("AddTags", "rtm.tasks.addTags", listID, seriesID, taskID, func(task *Task) error {
+		task.Tags = splitTags(task.Tags, tags)
+		return nil
+	})
+}
+
+func (f *FakeClient) AddNote(listID, seriesID, taskID, title, text string) error {
+	return f.changeTask("AddNote", "rtm.tasks.notes.add", listID, seriesID, taskID, func(task *Task) error {
+		f.nextID++
+		now := f.Now()
+		task.Notes = append(task.Notes, Note{ID: fmt.Sprintf("n%d", f.nextID), Title: title, Body: text, Created: now, Modified: now})
+		return nil
+	})
+}
+
+func (f *FakeClient) UndoLog() *TransactionLog {
+	return f.Transactions
+}
+
+// UndoTransaction restores the lists and tasks from before tx
+func (f *FakeClient) UndoTransaction(tx Transaction) error {
+	if err := f.call("UndoTransaction"); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	state, ok := f.undo[tx.ID]
+	if !ok {
+		return &RTMError{Code: 350, Msg: "transaction_id invalid or not provided"}
+	}
+	delete(f.undo, tx.ID)
+	f.Lists, f.Tasks = state.lists, state.tasks
+	return nil
+}
+
+// call records a call and returns Err
+func (f *FakeClient) call(method string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Calls = append(f.Calls, method)
+	return f.Err
+}
+
+// changeTask applies change to a task as one undoable transaction
+func (f *FakeClient) changeTask(name, method, listID, seriesID, taskID string, change func(*Task) error) error {
+	if err := f.call(name); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	task := f.task(seriesID, taskID)
+	if task == nil || task.ListID != listID {
+		return &RTMError{Code: 320, Msg: "taskseries_id invalid or not provided"}
+	}
+	before := f.snapshot()
+	if err := change(task); err != nil {
+		f.Lists, f.Tasks = before.lists, before.tasks
+		return err
+	}
+	task.Modified = f.Now()
+	f.record(method, before)
+	return nil
+}
+
+func (f *FakeClient) changeList(name, method, listID string, change func(*List)) error {
+	if err := f.call(name); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	list := f.list(listID)
+	if list == nil {
+		return invalidList()
+	}
+	f.save(method)
+	change(list)
+	return nil
+}
+
+// save records the state before a transaction; called with f.mu held
+func (f *FakeClient) save(method string) {
+	f.record(method, f.snapshot())
+}
+
+func (f *FakeClient) record(method string, before fakeState) {
+	f.nextID++
+	tx := Transaction{ID: fmt.Sprintf("tx%d", f.nextID), Timeline: "fake", Method: method, CreatedAt: f.Now()}
+	f.undo[tx.ID] = before
+	if f.Transactions != nil {
+		f.Transactions.Record(f.Token, tx)
+	}
+}
+
+func (f *FakeClient) snapshot() fakeState {
+	tasks := slices.Clone(f.Tasks)
+	for i := range tasks {
+		tasks[i].Tags = slices.Clone(tasks[i].Tags)
+		tasks[i].Notes = slices.Clone(tasks[i].Notes)
+	}
+	return fakeState{lists: slices.Clone(f.Lists), tasks: tasks}
+}
+
+func (f *FakeClient) task(seriesID, taskID string) *Task {
+	for i := range f.Tasks {
+		if f.Tasks[i].SeriesID == seriesID && f.Tasks[i].ID == taskID {
+			return &f.Tasks[i]
+		}
+	}
+	return nil
+}
+
+func (f *FakeClient) list(listID string) *List {
+	for i := range f.Lists {
+		if f.Lists[i].ID == listID && f.Lists[i].Deleted != "1" {
+			return &f.Lists[i]
+		}
+	}
+	return nil
+}
+
+func invalidList() error {
+	return &RTMError{Code: 340, Msg: "list_id invalid or not provided"}
+}
+
+// splitTags adds comma-separated tags to tags, skipping ones it has
+func splitTags(tags []string, added string) []string {
+	for _, tag := range strings.Split(added, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func flagValue(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+var _ RTMClientInterface = (*FakeClient)(nil)
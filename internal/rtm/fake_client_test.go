@@ -0,0 +1,73 @@
+package rtm
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestFakeClientHandlers(t *testing.T) {
+	t.Logf("Importance: Tool handlers should be testable against an in-memory RTM, without HTTP fakes for every test.")
+
+	fake := NewFakeClient("token")
+	h := NewHandlerWithClient(fake)
+
+	result, err := callTool(h.handleQuickAdd, map[string]any{"task": "Buy milk"})
+	if err != nil || result.IsError {
+		t.Fatalf("rtm_quick_add failed: %v %v", err, result)
+	}
+	if len(fake.Tasks) != 1 || fake.Tasks[0].Name != "Buy milk" || fake.Tasks[0].ListID != "inbox" {
+		t.Fatalf("Expected Buy milk in the Inbox, got %+v", fake.Tasks)
+	}
+	task := fake.Tasks[0]
+
+	t.Run("complete and undo", func(t *testing.T) {
+		t.Logf("  > Why it's important: The fake must record undoable changes the way Client does for rtm_undo to be testable.")
+		result, _ := callTool(h.handleComplete, map[string]any{"list_id": task.ListID, "series_id": task.SeriesID, "task_id": task.ID})
+		if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "Completed 1 task") {
+			t.Fatalf("Expected one completed task, got %s", text)
+		}
+		if fake.Tasks[0].Completed == "" {
+			t.Fatal("Expected the task to be completed")
+		}
+		if result, _ := callTool(h.handleUndo, map[string]any{}); result.IsError {
+			t.Fatalf("rtm_undo failed: %v", result.Content)
+		}
+		if fake.Tasks[0].Completed != "" {
+			t.Error("Expected undo to reopen the task")
+		}
+	})
+
+	t.Run("enhanced handler", func(t *testing.T) {
+		t.Logf("  > Why it's important: EnhancedHandler builds on Handler, so it must act through the same fake.")
+		if _, err := fake.AddTask("Call plumber", ""); err != nil {
+			t.Fatal(err)
+		}
+		fake.Match = func(task Task, filter string) bool {
+			return strings.Contains(strings.ToLower(task.Name), strings.TrimPrefix(filter, "name:"))
+		}
+		eh := NewEnhancedHandler(h)
+		if result, _ := callTool(eh.handleSmartSearch, map[string]any{"query": "name:plumber"}); result.IsError {
+			t.Fatalf("search_rtm_tasks_smart failed: %v", result.Content)
+		}
+		result, _ := callTool(eh.handleGetByPosition, map[string]any{"position": "1"})
+		if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "Call plumber") {
+			t.Errorf("Expected position 1 to be Call plumber, got %s", text)
+		}
+		if !slices.Contains(fake.Calls, "GetTasks") {
+			t.Errorf("Expected the search to go through the fake, got calls %v", fake.Calls)
+		}
+	})
+
+	t.Run("failures", func(t *testing.T) {
+		t.Logf("  > Why it's important: Error paths are tested by making the fake fail instead of RTM.")
+		fake.Err = errors.New("connection refused")
+		result, _ := callTool(h.handleQuickAdd, map[string]any{"task": "Water plants"})
+		if !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "connection refused") {
+			t.Errorf("Expected the failure to be reported, got %v", result.Content)
+		}
+	})
+}
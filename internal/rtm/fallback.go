@@ -0,0 +1,48 @@
+package rtm
+
+import (
+	"context"
+
+	"github.com/vcto/mcp-adapters/internal/health"
+	"github.com/vcto/mcp-adapters/internal/lazy"
+)
+
+// TodayTasks returns the requesting user's tasks due today, falling back to
+// the last-known-good copy (with a non-nil Staleness) while RTM is unavailable
+func (h *Handler) TodayTasks(ctx context.Context) ([]Task, *health.Staleness, error) {
+	client := h.ClientFor(ctx)
+	return fetchWithFallback(h, client, "rtm://today", func() ([]Task, error) {
+		return client.GetTasks("due:today", "")
+	})
+}
+
+// Lists returns the requesting user's lists, falling back to the
+// last-known-good copy (with a non-nil Staleness) while RTM is unavailable
+func (h *Handler) Lists(ctx context.Context) ([]List, *health.Staleness, error) {
+	client := h.ClientFor(ctx)
+	return fetchWithFallback(h, client, "rtm://lists", client.GetLists)
+}
+
+// Warmup validates the configured auth token and fills the lists cache.
+// Until a token is available it returns lazy.ErrNotReady, so the adapter
+// is reported as not warmed and warmed on a later call.
+func (h *Handler) Warmup(ctx context.Context) error {
+	if h.client.GetAuthToken() == "" {
+		return lazy.ErrNotReady
+	}
+	_, _, err := h.Lists(ctx)
+	return err
+}
+
+func fetchWithFallback[T any](h *Handler, client RTMClientInterface, uri string, fetch func() (T, error)) (T, *health.Staleness, error) {
+	if h.fallback == nil {
+		value, err := fetch()
+		return value, nil, err
+	}
+	return health.Fetch(h.fallback, fallbackKey(client, uri), fetch)
+}
+
+// fallbackKey scopes cached copies to the client's user
+func fallbackKey(client RTMClientInterface, uri string) string {
+	return uri + "|" + client.GetAuthToken()
+}
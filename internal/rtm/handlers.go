@@ -3,22 +3,39 @@ package rtm
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/vcto/mcp-adapters/internal/health"
 )
 
 // Handler manages RTM integration for the MCP server.
 // It wraps an RTM client and provides tool handlers for MCP operations.
 type Handler struct {
-	// client is the underlying RTM API client
+	// client is the underlying RTM API client, used for requests that carry
+	// no auth token of their own
 	client *Client
-	// searchCache holds the last search results for pagination
-	searchCache *searchResultCache
+	// api replaces every user's client when set, as by NewHandlerWithClient
+	api RTMClientInterface
+	// clients holds a client per user for requests authenticated with a
+	// bearer token (see ClientFor)
+	clientsOnce sync.Once
+	clients     *ClientRegistry
+	// searches holds each user's last search results for pagination, by owner
+	searchMu sync.Mutex
+	searches map[string]*searchResultCache
+	// fallback keeps last-known-good resource data for RTM outages
+	fallback *health.FallbackCache
+	// intents queues mutations made while RTM is unreachable (nil when disabled)
+	intents *IntentLog
 }
 
 // searchResultCache stores search results for pagination
@@ -47,12 +64,28 @@ func NewHandler() *Handler {
 	}
 
 	return &Handler{
-		client: NewClient(apiKey, secret),
+		client:   NewClient(apiKey, secret),
+		fallback: health.NewFallbackCache(health.MaxStaleFromEnv("RTM_FALLBACK_MAX_STALE", health.DefaultMaxStale)),
+		intents:  IntentLogFromEnv(),
 	}
 }
 
-// SetAuthToken sets the RTM auth token on the underlying client.
-// This is typically called after successful OAuth authentication.
+// NewHandlerWithClient creates a handler whose tools all act through api, such
+// as a FakeClient, instead of per-user clients calling RTM
+func NewHandlerWithClient(api RTMClientInterface) *Handler {
+	client := NewClient(api.GetAPIKey(), "")
+	client.AuthToken = api.GetAuthToken()
+	return &Handler{
+		client:   client,
+		api:      api,
+		fallback: health.NewFallbackCache(health.DefaultMaxStale),
+	}
+}
+
+// SetAuthToken sets the RTM auth token on the underlying client, which acts
+// for requests that carry no token of their own, as in stdio and
+// RTM_AUTH_TOKEN setups. It is shared by every request; HTTP servers bind
+// each request's token with WithAuthToken instead.
 func (h *Handler) SetAuthToken(token string) {
 	h.client.AuthToken = token
 }
@@ -63,6 +96,62 @@ func (h *Handler) GetClient() *Client {
 	return h.client
 }
 
+// ClientFor returns the RTM client for the user making the request: that
+// user's own client when ctx carries an auth token (see WithAuthToken), or
+// the underlying client for stdio and RTM_AUTH_TOKEN setups. When ctx has a
+// deadline, the client's API calls are bound by it.
+func (h *Handler) ClientFor(ctx context.Context) RTMClientInterface {
+	if h.api != nil {
+		return h.api
+	}
+	client := h.client
+	if token := AuthTokenFromContext(ctx); token != "" && token != h.client.GetAuthToken() {
+		client = h.registry().Get(token)
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return client.WithContext(ctx)
+	}
+	return client
+}
+
+// clientForOwner returns the client of the user filed under owner (see
+// intentOwner), if that user is the underlying client's or has made a
+// request recently
+func (h *Handler) clientForOwner(owner string) (RTMClientInterface, bool) {
+	if h.api != nil {
+		return h.api, owner == "" || owner == intentOwner(h.api.GetAuthToken())
+	}
+	if owner == "" || owner == intentOwner(h.client.GetAuthToken()) {
+		return h.client, true
+	}
+	return h.registry().ForOwner(owner)
+}
+
+// registry returns the per-user client registry, creating it on first use
+func (h *Handler) registry() *ClientRegistry {
+	h.clientsOnce.Do(func() {
+		h.clients = NewClientRegistry(h.client, clientIdleTTL())
+	})
+	return h.clients
+}
+
+// lastSearch returns the owner's cached search results, or nil
+func (h *Handler) lastSearch(owner string) *searchResultCache {
+	h.searchMu.Lock()
+	defer h.searchMu.Unlock()
+	return h.searches[owner]
+}
+
+// cacheSearch keeps the owner's latest search results
+func (h *Handler) cacheSearch(owner string, cache *searchResultCache) {
+	h.searchMu.Lock()
+	defer h.searchMu.Unlock()
+	if h.searches == nil {
+		h.searches = make(map[string]*searchResultCache)
+	}
+	h.searches[owner] = cache
+}
+
 // SetupTools registers RTM-related tools with the MCP server.
 // This includes tools for authentication, task management, list operations,
 // and search functionality. If RTM_AUTH_TOKEN is set in the environment,
@@ -79,10 +168,25 @@ func (h *Handler) SetupTools(s *server.MCPServer) {
 		mcp.WithString("permissions", mcp.Required(), mcp.Description("Permissions level: read, write, or delete")),
 	), h.handleAuthURL)
 
+	// rtm_whoami - Show which account the token acts for
+	s.AddTool(mcp.NewTool("rtm_whoami",
+		mcp.WithDescription("Verify the RTM login and show the username, full name and permission level (read, write or delete) it acts with. Use before destructive operations to confirm the account."),
+	), h.withIntentReplay(h.handleWhoami))
+
 	// rtm_lists - Get all RTM lists
 	s.AddTool(mcp.NewTool("rtm_lists",
 		mcp.WithDescription("Get all Remember The Milk lists"),
-	), h.handleGetLists)
+	), h.withIntentReplay(h.handleGetLists))
+
+	// rtm_locations - Get all saved locations
+	s.AddTool(mcp.NewTool("rtm_locations",
+		mcp.WithDescription("Get all saved Remember The Milk locations. Use a location ID or name with rtm_update to assign it to a task."),
+	), h.withIntentReplay(h.handleGetLocations))
+
+	// rtm_tags - Get all tags with usage counts
+	s.AddTool(mcp.NewTool("rtm_tags",
+		mcp.WithDescription("Get all existing Remember The Milk tags with the number of incomplete tasks using each. Use this to pick real tags instead of guessing."),
+	), h.withIntentReplay(h.handleGetTags))
 
 	// rtm_search - Enhanced task search with pagination
 	s.AddTool(mcp.NewTool("rtm_search",
@@ -92,14 +196,28 @@ func (h *Handler) SetupTools(s *server.MCPServer) {
 		mcp.WithNumber("page", mcp.Description("Page number (1-based, default: 1)")),
 		mcp.WithNumber("page_size", mcp.Description("Results per page (default: 25, max: 100)")),
 		mcp.WithString("use_cache", mcp.Description("Use cached results if available (true/false, default: true)")),
-	), h.handleSearch)
+	), h.withIntentReplay(h.handleSearch))
+
+	// rtm_export_csv - Search results as CSV
+	s.AddTool(mcp.NewTool("rtm_export_csv",
+		mcp.WithDescription("Export tasks as CSV for spreadsheets. Runs the given search, or exports the last rtm_search results when no query is given."),
+		mcp.WithString("query", mcp.Description("RTM search (default: the last rtm_search query)")),
+		mcp.WithString("include_completed", mcp.Description("Include completed tasks in results (true/false)")),
+	), h.withIntentReplay(h.handleExportCSV))
+
+	// rtm_estimate_report - Total time estimates of a search
+	s.AddTool(mcp.NewTool("rtm_estimate_report",
+		mcp.WithDescription("Sum the time estimates of the tasks matching a search, with totals by list and by tag and the tasks that have no estimate. Give the time available to see whether the plan fits."),
+		mcp.WithString("query", mcp.Description("RTM search (default: due:today)")),
+		mcp.WithString("available", mcp.Description("Time available, e.g. '6h' or '7 hours 30 minutes'; adds fits and spare_minutes to the report")),
+	), h.withIntentReplay(h.handleEstimateReport))
 
 	// rtm_quick_add - Primary task creation tool using Smart Add
 	s.AddTool(mcp.NewTool("rtm_quick_add",
 		mcp.WithDescription("Add a task using RTM's Smart Add syntax. Supports natural language for due dates, priorities, lists, and tags."),
 		mcp.WithString("task", mcp.Required(), mcp.Description("Task in Smart Add format: 'Buy milk tomorrow !2 #shopping ^Tuesday =30min @store'")),
 		mcp.WithString("parse_only", mcp.Description("If true, only parse and return the interpretation without adding (true/false)")),
-	), h.handleQuickAdd)
+	), h.withIntentReplay(h.handleQuickAdd))
 
 	// rtm_update - Update task properties
 	s.AddTool(mcp.NewTool("rtm_update",
@@ -113,7 +231,9 @@ func (h *Handler) SetupTools(s *server.MCPServer) {
 		mcp.WithString("estimate", mcp.Description("Time estimate (e.g., '30 min', '2 hours')")),
 		mcp.WithString("tags", mcp.Description("Comma-separated tags")),
 		mcp.WithString("list_name", mcp.Description("Move to different list by name")),
-	), h.handleUpdateTask)
+		mcp.WithString("location", mcp.Description("Location ID or name from rtm_locations, or 'none' to clear")),
+		mcp.WithString("url", mcp.Description("Reference link for the task (e.g., 'https://example.com/doc'), or 'none' to clear")),
+	), h.withIntentReplay(h.handleUpdateTask))
 
 	// rtm_complete - Mark task(s) as complete
 	s.AddTool(mcp.NewTool("rtm_complete",
@@ -121,19 +241,36 @@ func (h *Handler) SetupTools(s *server.MCPServer) {
 		mcp.WithString("task_id", mcp.Required(), mcp.Description("Task ID or comma-separated IDs")),
 		mcp.WithString("series_id", mcp.Required(), mcp.Description("Task series ID or comma-separated IDs")),
 		mcp.WithString("list_id", mcp.Required(), mcp.Description("List ID or comma-separated IDs")),
-	), h.handleComplete)
+	), h.withIntentReplay(h.handleComplete))
+
+	// rtm_duplicate_task - Copy a task as a template
+	s.AddTool(mcp.NewTool("rtm_duplicate_task",
+		mcp.WithDescription("Copy a task's name, tags, priority, estimate and notes into a new task, optionally in another list. Useful for reusing project checklists that are not RTM recurrences. Due dates are not copied."),
+		mcp.WithString("task_id", mcp.Required(), mcp.Description("Task ID to copy")),
+		mcp.WithString("series_id", mcp.Required(), mcp.Description("Task series ID")),
+		mcp.WithString("list", mcp.Description("Name or ID of the list for the copy (default: the original's list)")),
+		mcp.WithString("name", mcp.Description("Name for the copy (default: the original's name)")),
+	), h.withIntentReplay(h.handleDuplicateTask))
 
 	// rtm_manage_list - List management
 	s.AddTool(mcp.NewTool("rtm_manage_list",
-		mcp.WithDescription("Create, rename, or archive lists"),
+		mcp.WithDescription("Create, rename, or archive lists. Create with a filter to make a Smart List, which shows every task matching an RTM search."),
 		mcp.WithString("action", mcp.Required(), mcp.Description("Action: create, rename, archive, unarchive")),
 		mcp.WithString("name", mcp.Description("List name (required for create/rename)")),
 		mcp.WithString("new_name", mcp.Description("New name for rename action")),
 		mcp.WithString("list_id", mcp.Description("List ID for archive/unarchive actions")),
-	), h.handleManageList)
+		mcp.WithString("filter", mcp.Description("RTM search for a new Smart List (create only), e.g. 'priority:1 AND dueBefore:tomorrow'")),
+	), h.withIntentReplay(h.handleManageList))
+
+	// rtm_undo - Revert a recent change
+	s.AddTool(mcp.NewTool("rtm_undo",
+		mcp.WithDescription("Undo a recent change (add, complete, update, list changes). Reverts the most recent change unless a transaction ID is given."),
+		mcp.WithString("transaction_id", mcp.Description("Transaction ID to undo (default: most recent)")),
+	), h.withIntentReplay(h.handleUndo))
 }
 
 func (h *Handler) handleAuthURL(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := h.ClientFor(ctx)
 	params, err := parseParams[AuthURLParams](request.Params.Arguments)
 	if err != nil {
 		// Default params if parsing fails
@@ -143,7 +280,7 @@ func (h *Handler) handleAuthURL(ctx context.Context, request mcp.CallToolRequest
 		params.Permissions = "read"
 	}
 
-	url := h.client.AuthURL(params.Permissions)
+	url := client.AuthURL(params.Permissions)
 
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{
@@ -156,13 +293,14 @@ func (h *Handler) handleAuthURL(ctx context.Context, request mcp.CallToolRequest
 }
 
 func (h *Handler) handleGetLists(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	if h.client.AuthToken == "" {
+	client := h.ClientFor(ctx)
+	if client.GetAuthToken() == "" {
 		return mcp.NewToolResultError("RTM authentication required. Use rtm_auth_url first."), nil
 	}
 
-	lists, err := h.client.GetLists()
+	lists, err := client.GetLists()
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to get lists: %v", err)), nil
+		return health.ToolError(fmt.Sprintf("Failed to get lists: %v", err), err), nil
 	}
 
 	// Format as JSON
@@ -181,12 +319,133 @@ func (h *Handler) handleGetLists(ctx context.Context, request mcp.CallToolReques
 	}, nil
 }
 
+func (h *Handler) handleWhoami(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := h.ClientFor(ctx)
+	if client.GetAuthToken() == "" {
+		return mcp.NewToolResultError("RTM authentication required. Use rtm_auth_url first."), nil
+	}
+
+	account, err := client.CheckToken()
+	if err != nil {
+		return health.ToolError(fmt.Sprintf("Failed to check RTM login: %v", err), err), nil
+	}
+
+	data, err := json.MarshalIndent(account, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Failed to format account"), nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: string(data),
+			},
+		},
+	}, nil
+}
+
+func (h *Handler) handleGetLocations(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := h.ClientFor(ctx)
+	if client.GetAuthToken() == "" {
+		return mcp.NewToolResultError("RTM authentication required. Use rtm_auth_url first."), nil
+	}
+
+	locations, err := client.GetLocations()
+	if err != nil {
+		return health.ToolError(fmt.Sprintf("Failed to get locations: %v", err), err), nil
+	}
+
+	data, err := json.MarshalIndent(locations, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Failed to format locations"), nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: string(data),
+			},
+		},
+	}, nil
+}
+
+// TagUsage is a tag name with the number of incomplete tasks carrying it
+type TagUsage struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func (h *Handler) handleGetTags(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := h.ClientFor(ctx)
+	if client.GetAuthToken() == "" {
+		return mcp.NewToolResultError("RTM authentication required. Use rtm_auth_url first."), nil
+	}
+
+	tags, err := client.GetTags()
+	if err != nil {
+		return health.ToolError(fmt.Sprintf("Failed to get tags: %v", err), err), nil
+	}
+
+	tasks, err := client.GetTasks("status:incomplete", "")
+	if err != nil {
+		return health.ToolError(fmt.Sprintf("Failed to count tag usage: %v", err), err), nil
+	}
+
+	usage := TagUsages(tags, tasks)
+
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"tags":  usage,
+		"count": len(usage),
+	}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Failed to format tags"), nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: string(data),
+			},
+		},
+	}, nil
+}
+
+// TagUsages counts how many tasks use each tag, most used first. Tags found
+// on tasks but missing from the tag list are included as well.
+func TagUsages(tags []string, tasks []Task) []TagUsage {
+	counts := make(map[string]int, len(tags))
+	for _, tag := range tags {
+		counts[tag] = 0
+	}
+	for _, task := range tasks {
+		for _, tag := range task.Tags {
+			counts[tag]++
+		}
+	}
+
+	usage := make([]TagUsage, 0, len(counts))
+	for name, count := range counts {
+		usage = append(usage, TagUsage{Name: name, Count: count})
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Count != usage[j].Count {
+			return usage[i].Count > usage[j].Count
+		}
+		return usage[i].Name < usage[j].Name
+	})
+	return usage
+}
+
 func (h *Handler) handleSearch(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := h.ClientFor(ctx)
 	params, err := parseParams[SearchParams](request.Params.Arguments)
 	if err != nil {
 		return mcp.NewToolResultError("invalid arguments format"), nil
 	}
-	if h.client.AuthToken == "" {
+	if client.GetAuthToken() == "" {
 		return mcp.NewToolResultError("RTM authentication required. Use rtm_auth_url first."), nil
 	}
 
@@ -210,52 +469,36 @@ func (h *Handler) handleSearch(ctx context.Context, request mcp.CallToolRequest)
 
 	useCache := params.UseCache != "false"
 	includeCompleted := params.IncludeCompleted == "true"
-	query := params.Query
-	if includeCompleted {
-		query = "(" + query + ") OR (" + query + " AND completed:within \"1 week\")"
-	}
-
-	// Check cache validity
-	var tasks []Task
-	if useCache && h.searchCache != nil &&
-		h.searchCache.query == query &&
-		time.Since(h.searchCache.timestamp) < cacheTTL {
-		// Use cached results
-		tasks = h.searchCache.tasks
+	var query string
+	var pagedTasks []Task
+	var totalTasks int
+	chunked := client.Chunked()
+	if chunked {
+		// Too many tasks to hold at once: fetch list by list, keeping one page
+		query, pagedTasks, totalTasks, page, err = searchTaskPage(client, params.Query, includeCompleted, page, pageSize)
+		useCache = false
 	} else {
-		// Fetch new results
-		var err error
-		tasks, err = h.client.GetTasks(query, "")
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to search tasks: %v", err)), nil
+		var tasks []Task
+		query, tasks, err = h.searchTasks(client, params.Query, includeCompleted, useCache)
+		totalTasks = len(tasks)
+		if totalPages := (totalTasks + pageSize - 1) / pageSize; page > totalPages && totalPages > 0 {
+			page = totalPages
 		}
-		// Update cache
-		h.searchCache = &searchResultCache{
-			query:     query,
-			tasks:     tasks,
-			timestamp: time.Now(),
+		if start := (page - 1) * pageSize; start < totalTasks {
+			pagedTasks = tasks[start:min(start+pageSize, totalTasks)]
 		}
 	}
+	if err != nil {
+		return health.ToolError(fmt.Sprintf("Failed to search tasks: %v", err), err), nil
+	}
 
 	// Calculate pagination
-	totalTasks := len(tasks)
 	totalPages := (totalTasks + pageSize - 1) / pageSize
-	if page > totalPages && totalPages > 0 {
-		page = totalPages
-	}
-
 	startIdx := (page - 1) * pageSize
-	endIdx := startIdx + pageSize
-	if endIdx > totalTasks {
-		endIdx = totalTasks
-	}
-
-	var pagedTasks []Task
-	if startIdx < totalTasks {
-		pagedTasks = tasks[startIdx:endIdx]
-	}
+	endIdx := startIdx + len(pagedTasks)
 
 	// Enhanced result with pagination metadata
+	cached := h.lastSearch(intentOwner(client.GetAuthToken()))
 	result := map[string]interface{}{
 		"query":       query,
 		"total_found": totalTasks,
@@ -265,9 +508,12 @@ func (h *Handler) handleSearch(ctx context.Context, request mcp.CallToolRequest)
 		"has_more":    page < totalPages,
 		"tasks":       pagedTasks,
 		"search_time": time.Now().Format("2006-01-02 15:04:05"),
-		"cache_used":  useCache && h.searchCache != nil && h.searchCache.query == query,
+		"cache_used":  useCache && cached != nil && cached.query == query,
 	}
 
+	if chunked {
+		result["fetched_by_list"] = true
+	}
 	if totalTasks > pageSize {
 		result["pagination_tip"] = fmt.Sprintf("Showing tasks %d-%d of %d. Use page parameter to navigate.", startIdx+1, endIdx, totalTasks)
 	}
@@ -287,12 +533,63 @@ func (h *Handler) handleSearch(ctx context.Context, request mcp.CallToolRequest)
 	}, nil
 }
 
+// searchQuery returns the RTM filter for a search, widened to tasks completed
+// in the last week when asked to
+func searchQuery(query string, includeCompleted bool) string {
+	if includeCompleted {
+		return "(" + query + ") OR (" + query + " AND completed:within \"1 week\")"
+	}
+	return query
+}
+
+// searchTasks runs an RTM search, reusing the cached results of the same
+// query while they are fresh. It returns the query as sent to RTM.
+func (h *Handler) searchTasks(client RTMClientInterface, query string, includeCompleted, useCache bool) (string, []Task, error) {
+	query = searchQuery(query, includeCompleted)
+
+	owner := intentOwner(client.GetAuthToken())
+	if cached := h.lastSearch(owner); useCache && cached != nil &&
+		cached.query == query &&
+		time.Since(cached.timestamp) < cacheTTL {
+		return query, cached.tasks, nil
+	}
+
+	tasks, err := client.GetTasksWithOptions(query, "", TaskListOptions{IncludeCompleted: includeCompleted})
+	if err != nil {
+		return query, nil, err
+	}
+	h.cacheSearch(owner, &searchResultCache{
+		query:     query,
+		tasks:     tasks,
+		timestamp: time.Now(),
+	})
+	return query, tasks, nil
+}
+
+// searchTaskPage runs an RTM search one list at a time, returning only the
+// requested page, the total found, and the page, moved back to the last one
+// when past the end
+func searchTaskPage(client RTMClientInterface, query string, includeCompleted bool, page, pageSize int) (string, []Task, int, int, error) {
+	query = searchQuery(query, includeCompleted)
+	opts := TaskListOptions{IncludeCompleted: includeCompleted}
+	tasks, total, err := client.SearchPage(query, opts, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return query, nil, 0, page, err
+	}
+	if totalPages := (total + pageSize - 1) / pageSize; page > totalPages && totalPages > 0 {
+		page = totalPages
+		tasks, total, err = client.SearchPage(query, opts, (page-1)*pageSize, pageSize)
+	}
+	return query, tasks, total, page, err
+}
+
 func (h *Handler) handleQuickAdd(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := h.ClientFor(ctx)
 	params, err := parseParams[QuickAddParams](request.Params.Arguments)
 	if err != nil {
 		return mcp.NewToolResultError("invalid arguments format"), nil
 	}
-	if h.client.AuthToken == "" {
+	if client.GetAuthToken() == "" {
 		return mcp.NewToolResultError("RTM authentication required. Use rtm_auth_url first."), nil
 	}
 
@@ -315,9 +612,19 @@ func (h *Handler) handleQuickAdd(ctx context.Context, request mcp.CallToolReques
 	}
 
 	// Use Smart Add - RTM's addTask API supports Smart Add syntax
-	task, err := h.client.AddTask(params.Task, "")
+	task, err := client.AddTask(params.Task, "")
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to add task: %v", err)), nil
+		if intent, ok := h.queueIntent(client, err, Intent{Kind: IntentQuickAdd, Task: params.Task}); ok {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("RTM is unreachable, so the task was queued as %s and will be added when RTM is back. See rtm://intents/pending.\n\nOriginal: %s", intent.ID, params.Task),
+					},
+				},
+			}, nil
+		}
+		return health.ToolError(fmt.Sprintf("Failed to add task: %v", err), err), nil
 	}
 
 	data, err := json.MarshalIndent(task, "", "  ")
@@ -336,11 +643,12 @@ func (h *Handler) handleQuickAdd(ctx context.Context, request mcp.CallToolReques
 }
 
 func (h *Handler) handleComplete(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := h.ClientFor(ctx)
 	params, err := parseParams[CompleteParams](request.Params.Arguments)
 	if err != nil {
 		return mcp.NewToolResultError("invalid arguments format"), nil
 	}
-	if h.client.AuthToken == "" {
+	if client.GetAuthToken() == "" {
 		return mcp.NewToolResultError("RTM authentication required. Use rtm_auth_url first."), nil
 	}
 
@@ -358,11 +666,20 @@ func (h *Handler) handleComplete(ctx context.Context, request mcp.CallToolReques
 	}
 
 	var completed []string
+	var queued []string
 	var failed []string
 
 	for i := 0; i < len(taskIDList); i++ {
-		err := h.client.CompleteTask(strings.TrimSpace(listIDList[i]), strings.TrimSpace(seriesIDList[i]), strings.TrimSpace(taskIDList[i]))
+		listID := strings.TrimSpace(listIDList[i])
+		seriesID := strings.TrimSpace(seriesIDList[i])
+		taskID := strings.TrimSpace(taskIDList[i])
+
+		err := client.CompleteTask(listID, seriesID, taskID)
 		if err != nil {
+			if intent, ok := h.queueIntent(client, err, Intent{Kind: IntentComplete, ListID: listID, SeriesID: seriesID, TaskID: taskID}); ok {
+				queued = append(queued, fmt.Sprintf("%s (%s)", taskID, intent.ID))
+				continue
+			}
 			failed = append(failed, fmt.Sprintf("%s: %v", taskIDList[i], err))
 		} else {
 			completed = append(completed, taskIDList[i])
@@ -370,6 +687,9 @@ func (h *Handler) handleComplete(ctx context.Context, request mcp.CallToolReques
 	}
 
 	result := fmt.Sprintf("Completed %d task(s)", len(completed))
+	if len(queued) > 0 {
+		result += fmt.Sprintf("\nQueued until RTM is reachable: %v (see rtm://intents/pending)", queued)
+	}
 	if len(failed) > 0 {
 		result += fmt.Sprintf("\nFailed: %v", failed)
 	}
@@ -385,11 +705,12 @@ func (h *Handler) handleComplete(ctx context.Context, request mcp.CallToolReques
 }
 
 func (h *Handler) handleUpdateTask(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := h.ClientFor(ctx)
 	params, err := parseParams[UpdateTaskParams](request.Params.Arguments)
 	if err != nil {
 		return mcp.NewToolResultError("invalid arguments format"), nil
 	}
-	if h.client.AuthToken == "" {
+	if client.GetAuthToken() == "" {
 		return mcp.NewToolResultError("RTM authentication required. Use rtm_auth_url first."), nil
 	}
 
@@ -431,14 +752,38 @@ func (h *Handler) handleUpdateTask(ctx context.Context, request mcp.CallToolRequ
 		messages = append(messages, "moved to different list")
 	}
 
+	if strings.EqualFold(params.Location, "none") {
+		updates["location"] = ""
+		messages = append(messages, "location cleared")
+	} else if params.Location != "" {
+		locationID, err := h.resolveLocationID(client, params.Location)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		updates["location"] = locationID
+		messages = append(messages, "location updated")
+	}
+
+	if strings.EqualFold(params.URL, "none") {
+		updates["url"] = ""
+		messages = append(messages, "URL cleared")
+	} else if params.URL != "" {
+		link, err := url.Parse(strings.TrimSpace(params.URL))
+		if err != nil || link.Scheme == "" || link.Host == "" {
+			return mcp.NewToolResultError(fmt.Sprintf("url %q is not an absolute link such as https://example.com/doc", params.URL)), nil
+		}
+		updates["url"] = link.String()
+		messages = append(messages, "URL updated")
+	}
+
 	if len(updates) == 0 {
 		return mcp.NewToolResultError("No updates specified. Provide at least one field to update."), nil
 	}
 
 	// Apply updates using RTM API
-	err = h.client.UpdateTask(params.ListID, params.SeriesID, params.TaskID, updates)
+	err = client.UpdateTask(params.ListID, params.SeriesID, params.TaskID, updates)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to update task: %v", err)), nil
+		return health.ToolError(fmt.Sprintf("Failed to update task: %v", err), err), nil
 	}
 
 	return &mcp.CallToolResult{
@@ -451,18 +796,132 @@ func (h *Handler) handleUpdateTask(ctx context.Context, request mcp.CallToolRequ
 	}, nil
 }
 
+func (h *Handler) handleDuplicateTask(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := h.ClientFor(ctx)
+	params, err := parseParams[DuplicateTaskParams](request.Params.Arguments)
+	if err != nil {
+		return mcp.NewToolResultError("invalid arguments format"), nil
+	}
+	if client.GetAuthToken() == "" {
+		return mcp.NewToolResultError("RTM authentication required. Use rtm_auth_url first."), nil
+	}
+
+	if params.SeriesID == "" || params.TaskID == "" {
+		return mcp.NewToolResultError("series_id and task_id are required"), nil
+	}
+
+	source, err := client.GetTask(params.SeriesID, params.TaskID)
+	if errors.Is(err, ErrTaskNotFound) {
+		return mcp.NewToolResultError(fmt.Sprintf("Task %s in series %s not found. Use rtm_search to find it.", params.TaskID, params.SeriesID)), nil
+	}
+	if err != nil {
+		return health.ToolError(fmt.Sprintf("Failed to read task: %v", err), err), nil
+	}
+
+	listID := source.ListID
+	if params.List != "" {
+		listID, err = h.resolveListID(client, params.List)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+
+	task, err := client.DuplicateTask(source, listID, params.Name)
+	if err != nil && task == nil {
+		return health.ToolError(fmt.Sprintf("Failed to duplicate task: %v", err), err), nil
+	}
+	if err != nil {
+		return health.ToolError(fmt.Sprintf("Created '%s' as task %s (series %s, list %s), but copying its details failed: %v. Finish it with rtm_update, or delete it with delete_rtm_tasks_batch.", task.Name, task.ID, task.SeriesID, task.ListID, err), err), nil
+	}
+
+	data, err := json.MarshalIndent(task, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Failed to format task"), nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: fmt.Sprintf("Duplicated '%s':\n%s", source.Name, data),
+			},
+		},
+	}, nil
+}
+
+// resolveLocationID accepts a location ID or name and returns the location ID
+func (h *Handler) resolveLocationID(client RTMClientInterface, location string) (string, error) {
+	locations, err := client.GetLocations()
+	if err != nil {
+		return "", fmt.Errorf("Failed to look up locations: %v", err)
+	}
+
+	for _, loc := range locations {
+		if loc.ID == location {
+			return loc.ID, nil
+		}
+	}
+	for _, loc := range locations {
+		if strings.EqualFold(loc.Name, location) {
+			return loc.ID, nil
+		}
+	}
+
+	return "", fmt.Errorf("Unknown location '%s'. Use rtm_locations to see saved locations.", location)
+}
+
+// resolveListID accepts a list ID or name and returns the ID of a list that
+// tasks can be moved into
+func (h *Handler) resolveListID(client RTMClientInterface, list string) (string, error) {
+	lists, err := client.GetLists()
+	if err != nil {
+		return "", fmt.Errorf("Failed to look up lists: %v", err)
+	}
+
+	var match *List
+	for i := range lists {
+		if lists[i].ID == list {
+			match = &lists[i]
+			break
+		}
+	}
+	if match == nil {
+		for i := range lists {
+			if strings.EqualFold(lists[i].Name, list) {
+				match = &lists[i]
+				break
+			}
+		}
+	}
+
+	switch {
+	case match == nil:
+		return "", fmt.Errorf("Unknown list '%s'. Use rtm_lists to see your lists.", list)
+	case match.Smart == "1":
+		return "", fmt.Errorf("'%s' is a smart list, which tasks cannot be moved into.", match.Name)
+	case match.Archived == "1":
+		return "", fmt.Errorf("'%s' is archived. Unarchive it before moving tasks into it.", match.Name)
+	}
+	return match.ID, nil
+}
+
 func (h *Handler) handleManageList(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := h.ClientFor(ctx)
 	params, err := parseParams[ManageListParams](request.Params.Arguments)
 	if err != nil {
 		return mcp.NewToolResultError("invalid arguments format"), nil
 	}
-	if h.client.AuthToken == "" {
+	if client.GetAuthToken() == "" {
 		return mcp.NewToolResultError("RTM authentication required. Use rtm_auth_url first."), nil
 	}
 
 	if params.Action == "" {
 		return mcp.NewToolResultError("action is required"), nil
 	}
+	params.Filter = strings.TrimSpace(params.Filter)
+	if params.Filter != "" && params.Action != "create" {
+		return mcp.NewToolResultError("filter can only be given when creating a Smart List"), nil
+	}
 
 	switch params.Action {
 	case "create":
@@ -470,16 +929,26 @@ func (h *Handler) handleManageList(ctx context.Context, request mcp.CallToolRequ
 			return mcp.NewToolResultError("name is required for create action"), nil
 		}
 
-		list, err := h.client.CreateList(params.Name)
+		list, err := client.CreateList(params.Name, params.Filter)
 		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to create list: %v", err)), nil
+			return health.ToolError(fmt.Sprintf("Failed to create list: %v", err), err), nil
+		}
+
+		text := fmt.Sprintf("List '%s' created with ID: %s", params.Name, list.ID)
+		if params.Filter != "" {
+			// RTM reports the criteria as it stored them
+			criteria := list.Filter
+			if criteria == "" {
+				criteria = params.Filter
+			}
+			text = fmt.Sprintf("Smart List '%s' created with ID: %s\nCriteria: %s", params.Name, list.ID, criteria)
 		}
 
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{
 					Type: "text",
-					Text: fmt.Sprintf("List '%s' created with ID: %s", params.Name, list.ID),
+					Text: text,
 				},
 			},
 		}, nil
@@ -489,9 +958,9 @@ func (h *Handler) handleManageList(ctx context.Context, request mcp.CallToolRequ
 			return mcp.NewToolResultError("list_id and new_name are required for rename action"), nil
 		}
 
-		err := h.client.RenameList(params.ListID, params.NewName)
+		err := client.RenameList(params.ListID, params.NewName)
 		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to rename list: %v", err)), nil
+			return health.ToolError(fmt.Sprintf("Failed to rename list: %v", err), err), nil
 		}
 
 		return &mcp.CallToolResult{
@@ -509,9 +978,9 @@ func (h *Handler) handleManageList(ctx context.Context, request mcp.CallToolRequ
 		}
 
 		archive := params.Action == "archive"
-		err := h.client.ArchiveList(params.ListID, archive)
+		err := client.ArchiveList(params.ListID, archive)
 		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to %s list: %v", params.Action, err)), nil
+			return health.ToolError(fmt.Sprintf("Failed to %s list: %v", params.Action, err), err), nil
 		}
 
 		return &mcp.CallToolResult{
@@ -527,3 +996,53 @@ func (h *Handler) handleManageList(ctx context.Context, request mcp.CallToolRequ
 		return mcp.NewToolResultError("Invalid action. Use: create, rename, archive, or unarchive"), nil
 	}
 }
+
+func (h *Handler) handleUndo(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := h.ClientFor(ctx)
+	params, err := parseParams[UndoParams](request.Params.Arguments)
+	if err != nil {
+		return mcp.NewToolResultError("invalid arguments format"), nil
+	}
+	if client.GetAuthToken() == "" {
+		return mcp.NewToolResultError("RTM authentication required. Use rtm_auth_url first."), nil
+	}
+
+	history := client.UndoLog()
+	if history == nil {
+		return mcp.NewToolResultError("Undo history is not enabled"), nil
+	}
+
+	token := client.GetAuthToken()
+	tx, ok := history.Take(token, params.TransactionID)
+	if !ok {
+		if params.TransactionID != "" {
+			return mcp.NewToolResultError(fmt.Sprintf("No undoable transaction with ID %s", params.TransactionID)), nil
+		}
+		return mcp.NewToolResultError("Nothing to undo"), nil
+	}
+
+	if err := client.UndoTransaction(tx); err != nil {
+		// Keep the transaction so the user can retry
+		history.Record(token, tx)
+		return health.ToolError(fmt.Sprintf("Failed to undo %s: %v", tx.Method, err), err), nil
+	}
+
+	result := map[string]interface{}{
+		"undone":    tx,
+		"remaining": history.Recent(token),
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Failed to format undo result"), nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: string(data),
+			},
+		},
+	}, nil
+}
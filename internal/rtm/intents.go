@@ -0,0 +1,353 @@
+package rtm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/vcto/mcp-adapters/internal/health"
+)
+
+// Intent kinds that can be queued while RTM is unreachable
+const (
+	IntentQuickAdd = "quick_add"
+	IntentComplete = "complete"
+)
+
+// maxIntentConflicts is how many conflicting intents are kept for reporting per owner
+const maxIntentConflicts = 20
+
+// Intent is a mutation queued while RTM was unreachable
+type Intent struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	Task      string    `json:"task,omitempty"`
+	ListID    string    `json:"list_id,omitempty"`
+	SeriesID  string    `json:"series_id,omitempty"`
+	TaskID    string    `json:"task_id,omitempty"`
+	QueuedAt  time.Time `json:"queued_at"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+// intentQueue holds one owner's pending intents and recent conflicts
+type intentQueue struct {
+	Pending   []Intent `json:"pending"`
+	Conflicts []Intent `json:"conflicts"`
+
+	replaying bool // A Replay is applying the pending intents
+}
+
+// IntentLog is a write-ahead log of mutations made during RTM outages. Intents
+// are replayed in order once RTM is reachable again. Owners are keyed by a hash
+// of the RTM auth token so tokens are never written to disk.
+type IntentLog struct {
+	mu     sync.Mutex
+	path   string
+	queues map[string]*intentQueue
+	nextID int
+}
+
+// NewIntentLog creates an intent log persisted at path, loading any intents
+// already there. An empty path keeps the log in memory only.
+func NewIntentLog(path string) (*IntentLog, error) {
+	l := &IntentLog{
+		path:   path,
+		queues: make(map[string]*intentQueue),
+	}
+	if path == "" {
+		return l, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading intent log: %w", err)
+	}
+	if err := json.Unmarshal(data, &l.queues); err != nil {
+		return nil, fmt.Errorf("parsing intent log: %w", err)
+	}
+	return l, nil
+}
+
+// IntentLogFromEnv configures the intent log from RTM_INTENT_LOG: unset
+// disables queueing, "memory" keeps intents in memory, anything else is a
+// file path for a durable log.
+func IntentLogFromEnv() *IntentLog {
+	setting := os.Getenv("RTM_INTENT_LOG")
+	if setting == "" {
+		return nil
+	}
+	if setting == "memory" {
+		setting = ""
+	}
+
+	intents, err := NewIntentLog(setting)
+	if err != nil {
+		log.Printf("RTM: Intent log disabled: %v", err)
+		return nil
+	}
+	return intents
+}
+
+// intentOwner derives the log key for an RTM auth token
+func intentOwner(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Queue appends an intent for the owner and returns it with its assigned ID
+func (l *IntentLog) Queue(owner string, intent Intent) (Intent, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.nextID++
+	intent.ID = fmt.Sprintf("intent-%d-%d", time.Now().Unix(), l.nextID)
+	if intent.QueuedAt.IsZero() {
+		intent.QueuedAt = time.Now()
+	}
+
+	q := l.queue(owner)
+	q.Pending = append(q.Pending, intent)
+	return intent, l.persist()
+}
+
+// Pending returns copies of the owner's pending intents and recent conflicts
+func (l *IntentLog) Pending(owner string) ([]Intent, []Intent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	q, ok := l.queues[owner]
+	if !ok {
+		return []Intent{}, []Intent{}
+	}
+	return append([]Intent{}, q.Pending...), append([]Intent{}, q.Conflicts...)
+}
+
+// Replay applies the owner's pending intents in order. Replay stops at the
+// first upstream failure so later intents are not applied out of order;
+// intents rejected by RTM or failing their conflict check are moved to the
+// conflict list. apply runs without the log locked; only one Replay per
+// owner applies intents at a time, and an intent is applied only while its
+// ID is still pending, so each intent is applied once. It returns how many
+// intents were applied.
+func (l *IntentLog) Replay(owner string, apply func(Intent) error) int {
+	l.mu.Lock()
+	q, ok := l.queues[owner]
+	if !ok || len(q.Pending) == 0 || q.replaying {
+		l.mu.Unlock()
+		return 0
+	}
+	q.replaying = true
+	pending := append([]Intent{}, q.Pending...)
+	l.mu.Unlock()
+
+	applied := 0
+	for _, intent := range pending {
+		if !l.stillPending(q, intent.ID) {
+			continue
+		}
+		intent.Attempts++
+
+		err := apply(intent)
+		if err != nil && health.IsUpstream(err) {
+			intent.LastError = err.Error()
+			l.retain(q, intent)
+			break
+		}
+		l.settle(q, intent, err)
+		if err == nil {
+			applied++
+		}
+	}
+
+	l.mu.Lock()
+	q.replaying = false
+	if len(q.Pending) == 0 && len(q.Conflicts) == 0 && l.queues[owner] == q {
+		delete(l.queues, owner)
+	}
+	if err := l.persist(); err != nil {
+		log.Printf("RTM: Failed to persist intent log: %v", err)
+	}
+	l.mu.Unlock()
+	return applied
+}
+
+// stillPending reports whether the intent with id is waiting to be applied
+func (l *IntentLog) stillPending(q *intentQueue, id string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, intent := range q.Pending {
+		if intent.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// retain keeps a pending intent queued with its updated attempt count
+func (l *IntentLog) retain(q *intentQueue, intent Intent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := range q.Pending {
+		if q.Pending[i].ID == intent.ID {
+			q.Pending[i] = intent
+		}
+	}
+}
+
+// settle removes a replayed intent from the pending list, recording it as
+// a conflict when err is set. The log is persisted after every intent so an
+// applied intent is not replayed after a restart.
+func (l *IntentLog) settle(q *intentQueue, intent Intent, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for i := range q.Pending {
+		if q.Pending[i].ID == intent.ID {
+			q.Pending = append(q.Pending[:i], q.Pending[i+1:]...)
+			break
+		}
+	}
+	if err != nil {
+		intent.LastError = err.Error()
+		q.Conflicts = append(q.Conflicts, intent)
+		if len(q.Conflicts) > maxIntentConflicts {
+			q.Conflicts = q.Conflicts[len(q.Conflicts)-maxIntentConflicts:]
+		}
+	}
+	if err := l.persist(); err != nil {
+		log.Printf("RTM: Failed to persist intent log: %v", err)
+	}
+}
+
+func (l *IntentLog) queue(owner string) *intentQueue {
+	q, ok := l.queues[owner]
+	if !ok {
+		q = &intentQueue{Pending: []Intent{}, Conflicts: []Intent{}}
+		l.queues[owner] = q
+	}
+	return q
+}
+
+// persist writes the log atomically; callers must hold l.mu
+func (l *IntentLog) persist() error {
+	if l.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(l.queues, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding intent log: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(l.path), ".intents-*")
+	if err != nil {
+		return fmt.Errorf("writing intent log: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("writing intent log: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing intent log: %w", err)
+	}
+	return os.Rename(tmp.Name(), l.path)
+}
+
+// queueIntent records a mutation for later replay when err shows RTM is
+// unreachable. It reports whether the intent was queued.
+func (h *Handler) queueIntent(client RTMClientInterface, err error, intent Intent) (Intent, bool) {
+	if h.intents == nil || !health.IsUpstream(err) {
+		return Intent{}, false
+	}
+	// An add that timed out may have been committed, and replaying it would
+	// add the task twice. Completions are checked against the task instead.
+	if intent.Kind == IntentQuickAdd && !notSent(err) {
+		return Intent{}, false
+	}
+
+	intent.LastError = err.Error()
+	queued, qErr := h.intents.Queue(intentOwner(client.GetAuthToken()), intent)
+	if qErr != nil {
+		log.Printf("RTM: Failed to persist intent log: %v", qErr)
+	}
+	return queued, true
+}
+
+// replayIntents applies intents queued for the client's user during an outage
+func (h *Handler) replayIntents(client RTMClientInterface) {
+	if h.intents == nil || client.GetAuthToken() == "" {
+		return
+	}
+
+	apply := func(intent Intent) error { return applyIntent(client, intent) }
+	if applied := h.intents.Replay(intentOwner(client.GetAuthToken()), apply); applied > 0 {
+		log.Printf("RTM: Replayed %d queued intent(s)", applied)
+	}
+}
+
+// applyIntent performs a queued mutation after checking it still makes sense
+func applyIntent(client RTMClientInterface, intent Intent) error {
+	switch intent.Kind {
+	case IntentQuickAdd:
+		// Another task with the same name is not a conflict: users add
+		// same-name tasks on purpose, and Replay applies each intent ID
+		// only once. Only an add that never reached RTM is kept for another
+		// try; one that may have been committed is reported instead.
+		_, err := client.AddTask(intent.Task, "")
+		if err != nil && health.IsUpstream(err) && !notSent(err) {
+			return fmt.Errorf("conflict: RTM may have added %q before failing, check before adding it again: %v", intent.Task, err)
+		}
+		return err
+
+	case IntentComplete:
+		existing, err := client.GetTasks("status:incomplete", intent.ListID)
+		if err != nil {
+			return err
+		}
+		for _, task := range existing {
+			if task.ID == intent.TaskID && task.SeriesID == intent.SeriesID {
+				return client.CompleteTask(intent.ListID, intent.SeriesID, intent.TaskID)
+			}
+		}
+		return fmt.Errorf("conflict: task %s is already completed or was deleted", intent.TaskID)
+
+	default:
+		return fmt.Errorf("unknown intent kind %q", intent.Kind)
+	}
+}
+
+// PendingIntents returns the requesting user's unsynced intents and recent conflicts
+func (h *Handler) PendingIntents(ctx context.Context) (pending, conflicts []Intent, enabled bool) {
+	if h.intents == nil {
+		return []Intent{}, []Intent{}, false
+	}
+	pending, conflicts = h.intents.Pending(intentOwner(h.ClientFor(ctx).GetAuthToken()))
+	return pending, conflicts, true
+}
+
+// withIntentReplay syncs queued intents before running a tool, so pending
+// mutations are applied as soon as RTM is reachable again
+func (h *Handler) withIntentReplay(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		h.replayIntents(h.ClientFor(ctx))
+		return next(ctx, request)
+	}
+}
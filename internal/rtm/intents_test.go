@@ -0,0 +1,195 @@
+package rtm
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vcto/mcp-adapters/internal/health"
+)
+
+func TestIntentLog(t *testing.T) {
+	t.Logf("Importance: Changes made during an outage must be applied exactly once, in order, or reported as conflicts - never silently lost.")
+
+	t.Run("replays in order and stops at upstream failure", func(t *testing.T) {
+		t.Logf("  > Why it's important: Applying later intents before earlier ones could complete a task before it is created.")
+		intents, _ := NewIntentLog("")
+		for _, task := range []string{"first", "second", "third"} {
+			if _, err := intents.Queue("owner", Intent{Kind: IntentQuickAdd, Task: task}); err != nil {
+				t.Fatalf("Queue failed: %v", err)
+			}
+		}
+
+		var seen []string
+		applied := intents.Replay("owner", func(intent Intent) error {
+			seen = append(seen, intent.Task)
+			if intent.Task == "second" {
+				return health.Upstream(errors.New("timeout"))
+			}
+			return nil
+		})
+
+		if applied != 1 || len(seen) != 2 {
+			t.Fatalf("Expected to apply 'first' and stop at 'second', applied=%d seen=%v", applied, seen)
+		}
+		pending, _ := intents.Pending("owner")
+		if len(pending) != 2 || pending[0].Task != "second" || pending[0].Attempts != 1 {
+			t.Errorf("Expected 'second' and 'third' to remain pending, got %+v", pending)
+		}
+	})
+
+	t.Run("moves rejected intents to conflicts", func(t *testing.T) {
+		t.Logf("  > Why it's important: Users need to see which queued changes could not be applied.")
+		intents, _ := NewIntentLog("")
+		_, _ = intents.Queue("owner", Intent{Kind: IntentComplete, TaskID: "1"})
+		_, _ = intents.Queue("owner", Intent{Kind: IntentComplete, TaskID: "2"})
+
+		applied := intents.Replay("owner", func(intent Intent) error {
+			if intent.TaskID == "1" {
+				return errors.New("conflict: task 1 is already completed or was deleted")
+			}
+			return nil
+		})
+
+		pending, conflicts := intents.Pending("owner")
+		if applied != 1 || len(pending) != 0 {
+			t.Errorf("Expected one applied and none pending, applied=%d pending=%v", applied, pending)
+		}
+		if len(conflicts) != 1 || conflicts[0].TaskID != "1" || conflicts[0].LastError == "" {
+			t.Errorf("Expected task 1 in conflicts with a reason, got %+v", conflicts)
+		}
+	})
+
+	t.Run("applies without holding the log", func(t *testing.T) {
+		t.Logf("  > Why it's important: Replay calls RTM, and a slow call must not block every other user's queue.")
+		intents, _ := NewIntentLog("")
+		_, _ = intents.Queue("owner", Intent{Kind: IntentQuickAdd, Task: "Buy milk"})
+
+		done := make(chan int)
+		go func() {
+			done <- intents.Replay("owner", func(intent Intent) error {
+				intents.Pending("other")
+				_, _ = intents.Queue("other", Intent{Kind: IntentQuickAdd, Task: "Call mum"})
+				return nil
+			})
+		}()
+		select {
+		case applied := <-done:
+			if applied != 1 {
+				t.Errorf("Expected one applied, got %d", applied)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Expected apply to use the log without deadlocking")
+		}
+		if pending, _ := intents.Pending("other"); len(pending) != 1 {
+			t.Errorf("Expected the intent queued during replay kept, got %+v", pending)
+		}
+	})
+
+	t.Run("applies each intent once", func(t *testing.T) {
+		t.Logf("  > Why it's important: Two tools replaying at once must not add the same task twice, while two queued adds of the same name are both kept.")
+		intents, _ := NewIntentLog("")
+		_, _ = intents.Queue("owner", Intent{Kind: IntentQuickAdd, Task: "Buy milk"})
+		_, _ = intents.Queue("owner", Intent{Kind: IntentQuickAdd, Task: "Buy milk"})
+
+		var mu sync.Mutex
+		seen := map[string]int{}
+		release := make(chan struct{})
+		apply := func(intent Intent) error {
+			<-release
+			mu.Lock()
+			defer mu.Unlock()
+			seen[intent.ID]++
+			return nil
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				intents.Replay("owner", apply)
+			}()
+		}
+		close(release)
+		wg.Wait()
+
+		if len(seen) != 2 {
+			t.Errorf("Expected both same-name adds applied, got %v", seen)
+		}
+		for id, count := range seen {
+			if count != 1 {
+				t.Errorf("Expected %s applied once, got %d", id, count)
+			}
+		}
+		if pending, conflicts := intents.Pending("owner"); len(pending) != 0 || len(conflicts) != 0 {
+			t.Errorf("Expected nothing left, got %+v %+v", pending, conflicts)
+		}
+	})
+
+	t.Run("persists across restarts", func(t *testing.T) {
+		t.Logf("  > Why it's important: A write-ahead log is only useful if queued changes survive a server restart.")
+		path := filepath.Join(t.TempDir(), "intents.json")
+		intents, err := NewIntentLog(path)
+		if err != nil {
+			t.Fatalf("NewIntentLog failed: %v", err)
+		}
+		if _, err := intents.Queue("owner", Intent{Kind: IntentQuickAdd, Task: "Buy milk"}); err != nil {
+			t.Fatalf("Queue failed: %v", err)
+		}
+
+		reloaded, err := NewIntentLog(path)
+		if err != nil {
+			t.Fatalf("Reload failed: %v", err)
+		}
+		pending, _ := reloaded.Pending("owner")
+		if len(pending) != 1 || pending[0].Task != "Buy milk" {
+			t.Errorf("Expected queued intent after reload, got %+v", pending)
+		}
+	})
+}
+
+func TestQuickAddIntents(t *testing.T) {
+	t.Logf("Importance: rtm.tasks.add is not idempotent, so an add RTM may already have committed must never be replayed.")
+
+	refused := health.Upstream(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")})
+	timedOut := health.Upstream(errors.New("HTTP request failed: context deadline exceeded"))
+
+	t.Run("queues only adds that never reached RTM", func(t *testing.T) {
+		t.Logf("  > Why it's important: A timeout can come after RTM added the task, and replaying it would add a duplicate.")
+		h := NewHandlerWithClient(NewFakeClient("token"))
+		h.intents, _ = NewIntentLog("")
+		client := h.ClientFor(context.Background())
+
+		for _, err := range []error{refused, health.ErrCircuitOpen} {
+			if _, ok := h.queueIntent(client, err, Intent{Kind: IntentQuickAdd, Task: "Buy milk"}); !ok {
+				t.Errorf("Expected an add that was never sent queued for %v", err)
+			}
+		}
+		if _, ok := h.queueIntent(client, timedOut, Intent{Kind: IntentQuickAdd, Task: "Buy milk"}); ok {
+			t.Error("Expected an add that may have been committed not queued")
+		}
+		if _, ok := h.queueIntent(client, timedOut, Intent{Kind: IntentComplete, TaskID: "1"}); !ok {
+			t.Error("Expected a completion queued, as replay checks the task first")
+		}
+	})
+
+	t.Run("replay reports adds that may have been committed", func(t *testing.T) {
+		t.Logf("  > Why it's important: A replayed add that times out again must not be retried and added twice.")
+		fake := NewFakeClient("token")
+		fake.Err = timedOut
+		err := applyIntent(fake, Intent{Kind: IntentQuickAdd, Task: "Buy milk"})
+		if err == nil || health.IsUpstream(err) {
+			t.Errorf("Expected a conflict, not a retry, got %v", err)
+		}
+
+		fake.Err = refused
+		if err := applyIntent(fake, Intent{Kind: IntentQuickAdd, Task: "Buy milk"}); !health.IsUpstream(err) {
+			t.Errorf("Expected an add that was never sent kept for another try, got %v", err)
+		}
+	})
+}
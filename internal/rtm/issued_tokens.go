@@ -0,0 +1,55 @@
+package rtm
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/vcto/mcp-adapters/internal/auth"
+)
+
+// issuedTokens records the RTM tokens handed to clients in an
+// auth.TokenStore, the same memory, SQLite or Redis backend the generic
+// adapter keeps its tokens in. Records are stored as JSON in place of an
+// API key, since the bearer token is the RTM token itself.
+type issuedTokens struct {
+	tokens auth.TokenStore
+}
+
+func newIssuedTokens(tokens auth.TokenStore) *issuedTokens {
+	return &issuedTokens{tokens: tokens}
+}
+
+// Get returns the record for token, reporting whether one exists
+func (t *issuedTokens) Get(token string) (issuedToken, bool) {
+	encoded, ok := t.tokens.Get(token)
+	if !ok {
+		return issuedToken{}, false
+	}
+	var issued issuedToken
+	if err := json.Unmarshal([]byte(encoded), &issued); err != nil {
+		log.Printf("RTM: Failed to decode access token record: %v", err)
+		return issuedToken{}, false
+	}
+	return issued, true
+}
+
+// Put records token as issued now with scope to clientID
+func (t *issuedTokens) Put(token, scope, clientID string) {
+	encoded, err := json.Marshal(issuedToken{IssuedAt: time.Now().UTC(), Scope: scope, ClientID: clientID})
+	if err != nil {
+		log.Printf("RTM: Failed to encode access token record: %v", err)
+		return
+	}
+	t.tokens.Store(token, string(encoded))
+}
+
+// Delete forgets token
+func (t *issuedTokens) Delete(token string) {
+	t.tokens.Delete(token)
+}
+
+// Close closes the token store
+func (t *issuedTokens) Close() error {
+	return t.tokens.Close()
+}
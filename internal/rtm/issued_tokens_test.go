@@ -0,0 +1,29 @@
+package rtm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vcto/mcp-adapters/internal/auth"
+	"github.com/vcto/mcp-adapters/internal/kv"
+)
+
+func TestIssuedTokens(t *testing.T) {
+	t.Logf("Importance: Issued RTM tokens live in the shared TokenStore, so every instance sees the same grants and revocations.")
+	store := kv.NewMemoryStore()
+	tokens := newIssuedTokens(auth.NewKVTokenStore(store, time.Hour))
+	defer tokens.Close()
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Logf("  > Why it's important: The scope and client recorded at issue are what later requests are checked against.")
+		tokens.Put("rtm-token", "tasks:read", "client-1")
+		issued, ok := tokens.Get("rtm-token")
+		if !ok || issued.Scope != "tasks:read" || issued.ClientID != "client-1" {
+			t.Fatalf("Expected the record back, got %+v (ok=%v)", issued, ok)
+		}
+		tokens.Delete("rtm-token")
+		if _, ok := tokens.Get("rtm-token"); ok {
+			t.Error("Expected the deleted token gone")
+		}
+	})
+}
@@ -1,9 +1,16 @@
 package rtm
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"sort"
 	"sync"
 	"time"
+
+	"github.com/vcto/mcp-adapters/internal/kv"
+	"github.com/vcto/mcp-adapters/internal/longrunning"
 )
 
 // JobStatus represents the current state of a batch job
@@ -14,6 +21,22 @@ const (
 	JobStatusProcessing JobStatus = "processing"
 	JobStatusCompleted  JobStatus = "completed"
 	JobStatusFailed     JobStatus = "failed"
+	JobStatusCancelled  JobStatus = "cancelled"
+)
+
+// Cancel errors
+var (
+	ErrJobNotFound = errors.New("job not found")
+	ErrJobFinished = errors.New("job already finished")
+)
+
+// Job persistence
+const (
+	jobBucket = "rtm_jobs"
+	// finishedJobTTL keeps finished jobs queryable for a day after a restart
+	finishedJobTTL = 24 * time.Hour
+	// unfinishedJobTTL drops jobs whose owner never reconnects
+	unfinishedJobTTL = 7 * 24 * time.Hour
 )
 
 // BatchJob represents a batch operation
@@ -29,6 +52,17 @@ type BatchJob struct {
 	Failed      []string               `json:"failed,omitempty"`
 	Results     map[string]interface{} `json:"results,omitempty"`
 	Error       string                 `json:"error,omitempty"`
+	// Owner is the hashed RTM token the job runs as, as in the intent log
+	Owner string `json:"owner,omitempty"`
+	// InFlight is set while item Completed is being sent to RTM
+	InFlight bool `json:"in_flight,omitempty"`
+	// CancelRequested stops a running job before its next item
+	CancelRequested bool `json:"cancel_requested,omitempty"`
+}
+
+// Finished reports whether the job has stopped for good
+func (j *BatchJob) Finished() bool {
+	return j.Status == JobStatusCompleted || j.Status == JobStatusFailed || j.Status == JobStatusCancelled
 }
 
 // JobQueue manages batch operations
@@ -38,6 +72,13 @@ type JobQueue struct {
 	handler  *Handler
 	workers  int
 	jobsChan chan string
+	// store persists jobs and their progress; nil keeps them in memory only
+	store *kv.Bucket[BatchJob]
+	// waiting holds jobs whose owner is not the current RTM user
+	waiting map[string]bool
+	// progress sends MCP progress notifications for jobs queued with a
+	// progress token
+	progress map[string]*longrunning.Task
 }
 
 // NewJobQueue creates a new job queue
@@ -47,6 +88,8 @@ func NewJobQueue(handler *Handler) *JobQueue {
 		handler:  handler,
 		workers:  1, // Single worker to respect RTM rate limits
 		jobsChan: make(chan string, 100),
+		waiting:  make(map[string]bool),
+		progress: make(map[string]*longrunning.Task),
 	}
 
 	// Start worker
@@ -55,11 +98,98 @@ func NewJobQueue(handler *Handler) *JobQueue {
 	return q
 }
 
+// Persist saves jobs and their per-item progress in store and resumes any
+// unfinished jobs already there, such as those interrupted by a restart.
+// It returns how many jobs were resumed.
+func (q *JobQueue) Persist(store kv.Store) (int, error) {
+	bucket := kv.NewBucket[BatchJob](store, jobBucket)
+	ids, err := bucket.Keys()
+	if err != nil {
+		return 0, fmt.Errorf("listing saved jobs: %w", err)
+	}
+
+	var resume, cancelled []*BatchJob
+	q.mu.Lock()
+	q.store = bucket
+	for _, id := range ids {
+		saved, ok, err := bucket.Get(id)
+		if err != nil {
+			log.Printf("RTM: Skipping unreadable job %s: %v", id, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		job := &saved
+		if _, exists := q.jobs[id]; exists {
+			continue
+		}
+		q.jobs[id] = job
+		if job.CancelRequested && !job.Finished() {
+			recoverInFlight(job)
+			markCancelled(job)
+			cancelled = append(cancelled, job)
+			continue
+		}
+		if job.Status == JobStatusPending || job.Status == JobStatusProcessing {
+			recoverInFlight(job)
+			job.Status = JobStatusPending
+			resume = append(resume, job)
+		}
+	}
+	q.mu.Unlock()
+
+	for _, job := range cancelled {
+		q.persist(job)
+	}
+	for _, job := range resume {
+		q.persist(job)
+		q.jobsChan <- job.ID
+	}
+	return len(resume), nil
+}
+
+// recoverInFlight settles the item a job was sending when the server
+// stopped. Repeating an update is harmless, but repeating a create could
+// add a duplicate task, so that item is reported instead of retried.
+func recoverInFlight(job *BatchJob) {
+	if !job.InFlight {
+		return
+	}
+	job.InFlight = false
+	if job.Type != "batch_create" {
+		return
+	}
+
+	var texts []string
+	item := fmt.Sprintf("item %d", job.Completed+1)
+	if err := decodeJobInput(job, "tasks", &texts); err == nil && job.Completed < len(texts) {
+		item = fmt.Sprintf("'%s'", texts[job.Completed])
+	}
+	job.Failed = append(job.Failed, fmt.Sprintf("Task %s: interrupted by a server restart; check RTM before adding it again", item))
+	job.Completed++
+}
+
 // QueueJob adds a new job to the queue
 func (q *JobQueue) QueueJob(job *BatchJob) {
+	q.QueueJobWithProgress(job, nil)
+}
+
+// QueueJobWithProgress adds a new job to the queue and reports its progress
+// on task as each item finishes. A nil task queues the job without
+// notifications; progress is not restored after a restart.
+func (q *JobQueue) QueueJobWithProgress(job *BatchJob, task *longrunning.Task) {
 	q.mu.Lock()
+	if job.Owner == "" {
+		job.Owner = intentOwner(q.handler.client.GetAuthToken())
+	}
 	q.jobs[job.ID] = job
+	if task != nil {
+		task.SetTotal(float64(job.TotalTasks))
+		q.progress[job.ID] = task
+	}
 	q.mu.Unlock()
+	q.persist(job)
 
 	// Queue for processing
 	q.jobsChan <- job.ID
@@ -73,6 +203,87 @@ func (q *JobQueue) GetJob(id string) (*BatchJob, bool) {
 	return job, ok
 }
 
+// Cancel stops a job. A job that has not started is cancelled at once; a
+// running job finishes the item it is sending and stops before the next, so
+// items already applied stay applied. It returns a copy of the job.
+func (q *JobQueue) Cancel(id string) (BatchJob, error) {
+	q.mu.Lock()
+	job, ok := q.jobs[id]
+	if !ok {
+		q.mu.Unlock()
+		return BatchJob{}, ErrJobNotFound
+	}
+	if job.Finished() {
+		snapshot := *job
+		q.mu.Unlock()
+		return snapshot, ErrJobFinished
+	}
+
+	job.CancelRequested = true
+	if job.Status == JobStatusPending {
+		markCancelled(job)
+		delete(q.waiting, id)
+	}
+	snapshot := *job
+	q.mu.Unlock()
+
+	q.persist(job)
+	if snapshot.Finished() {
+		q.finishProgress(job)
+	}
+	log.Printf("RTM: Cancel requested for job %s (%s, %d/%d done)", id, snapshot.Status, snapshot.Completed, snapshot.TotalTasks)
+	return snapshot, nil
+}
+
+// markCancelled finishes a job as cancelled. Callers hold q.mu.
+func markCancelled(job *BatchJob) {
+	job.Status = JobStatusCancelled
+	now := time.Now()
+	job.CompletedAt = &now
+}
+
+// Jobs returns copies of all jobs, newest first
+func (q *JobQueue) Jobs() []BatchJob {
+	q.mu.RLock()
+	jobs := make([]BatchJob, 0, len(q.jobs))
+	for _, job := range q.jobs {
+		snapshot := *job
+		snapshot.Failed = append([]string(nil), job.Failed...)
+		jobs = append(jobs, snapshot)
+	}
+	q.mu.RUnlock()
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.After(jobs[j].CreatedAt)
+	})
+	return jobs
+}
+
+// ResumeWaiting requeues jobs that were waiting for their RTM user to
+// reconnect. It returns how many jobs were requeued.
+func (q *JobQueue) ResumeWaiting() int {
+	var ready []string
+	q.mu.Lock()
+	for id := range q.waiting {
+		if job, ok := q.jobs[id]; ok && q.hasClient(job.Owner) {
+			ready = append(ready, id)
+			delete(q.waiting, id)
+		}
+	}
+	q.mu.Unlock()
+
+	for _, id := range ready {
+		q.jobsChan <- id
+	}
+	return len(ready)
+}
+
+// hasClient reports whether the job owner's client is available
+func (q *JobQueue) hasClient(owner string) bool {
+	_, ok := q.handler.clientForOwner(owner)
+	return ok
+}
+
 // worker processes jobs from the queue
 func (q *JobQueue) worker() {
 	for jobID := range q.jobsChan {
@@ -84,28 +295,43 @@ func (q *JobQueue) worker() {
 func (q *JobQueue) processJob(jobID string) {
 	q.mu.Lock()
 	job, ok := q.jobs[jobID]
+	if !ok || job.Finished() {
+		q.mu.Unlock()
+		return
+	}
+
+	// Jobs act on one user's account; a resumed job waits for its owner
+	client, ok := q.handler.clientForOwner(job.Owner)
 	if !ok {
+		q.waiting[jobID] = true
 		q.mu.Unlock()
 		return
 	}
 
 	job.Status = JobStatusProcessing
-	now := time.Now()
-	job.StartedAt = &now
+	if job.StartedAt == nil {
+		now := time.Now()
+		job.StartedAt = &now
+	}
 	q.mu.Unlock()
+	q.persist(job)
 
 	// Process based on job type
 	switch job.Type {
 	case "batch_due_date":
-		q.processBatchDueDate(job)
+		q.processBatchDueDate(job, client)
 	case "batch_priority":
-		q.processBatchPriority(job)
+		q.processBatchPriority(job, client)
 	case "batch_complete":
-		q.processBatchComplete(job)
+		q.processBatchComplete(job, client)
 	case "batch_tags_add":
-		q.processBatchTagsAdd(job)
+		q.processBatchTagsAdd(job, client)
+	case "batch_move":
+		q.processBatchMove(job, client)
+	case "batch_delete":
+		q.processBatchDelete(job, client)
 	case "batch_create":
-		q.processBatchCreate(job)
+		q.processBatchCreate(job, client)
 	default:
 		q.mu.Lock()
 		job.Status = JobStatusFailed
@@ -115,104 +341,243 @@ func (q *JobQueue) processJob(jobID string) {
 
 	// Mark completion
 	q.mu.Lock()
-	if job.Status != JobStatusFailed {
-		job.Status = JobStatusCompleted
+	if job.CancelRequested && job.Status != JobStatusFailed && job.Completed < job.TotalTasks {
+		markCancelled(job)
+	} else {
+		if job.Status != JobStatusFailed {
+			job.Status = JobStatusCompleted
+		}
+		now := time.Now()
+		job.CompletedAt = &now
 	}
-	now = time.Now()
-	job.CompletedAt = &now
 	q.mu.Unlock()
+	q.persist(job)
+	q.finishProgress(job)
 }
 
-// processBatchDueDate handles batch due date updates
-func (q *JobQueue) processBatchDueDate(job *BatchJob) {
-	tasks, ok := job.Results["tasks"].([]map[string]string)
-	if !ok {
-		q.mu.Lock()
-		job.Status = JobStatusFailed
-		job.Error = "Invalid or missing tasks data"
-		q.mu.Unlock()
+// persist saves a snapshot of the job when a store is configured
+func (q *JobQueue) persist(job *BatchJob) {
+	q.mu.RLock()
+	store := q.store
+	snapshot := *job
+	snapshot.Failed = append([]string(nil), job.Failed...)
+	q.mu.RUnlock()
+	if store == nil {
 		return
 	}
 
-	dueDate, ok := job.Results["due_date"].(string)
-	if !ok {
-		q.mu.Lock()
-		job.Status = JobStatusFailed
-		job.Error = "Invalid or missing due_date"
-		q.mu.Unlock()
-		return
+	ttl := unfinishedJobTTL
+	if snapshot.Finished() {
+		ttl = finishedJobTTL
+	}
+	if err := store.Put(snapshot.ID, snapshot, ttl); err != nil {
+		log.Printf("RTM: Failed to persist job %s: %v", snapshot.ID, err)
 	}
+}
 
-	for i, task := range tasks {
-		// Update job progress
-		q.mu.Lock()
-		job.Completed = i
-		q.mu.Unlock()
+// failJob marks a job failed with the given error
+func (q *JobQueue) failJob(job *BatchJob, message string) {
+	q.mu.Lock()
+	job.Status = JobStatusFailed
+	job.Error = message
+	q.mu.Unlock()
+}
+
+// runItems applies apply to each item from the job's saved progress onward.
+// Progress is persisted around every item so a restart resumes where the
+// job stopped.
+func (q *JobQueue) runItems(job *BatchJob, total int, describe func(i int) string, apply func(i int) error) {
+	q.mu.RLock()
+	start := job.Completed
+	q.mu.RUnlock()
 
+	for i := start; i < total; i++ {
 		// RTM rate limit
-		if i > 0 {
+		if i > start {
 			time.Sleep(1 * time.Second)
 		}
 
-		// Update task
-		updates := map[string]string{"due": dueDate}
-		err := q.handler.client.UpdateTask(task["list_id"], task["series_id"], task["task_id"], updates)
-		if err != nil {
-			q.mu.Lock()
-			job.Failed = append(job.Failed, fmt.Sprintf("Task %s: %v", task["task_id"], err))
+		q.mu.Lock()
+		if job.CancelRequested {
 			q.mu.Unlock()
+			return
+		}
+		job.InFlight = true
+		q.mu.Unlock()
+		q.persist(job)
+
+		err := apply(i)
+
+		q.mu.Lock()
+		if err != nil {
+			job.Failed = append(job.Failed, fmt.Sprintf("%s: %v", describe(i), err))
 		}
+		job.Completed = i + 1
+		job.InFlight = false
+		q.mu.Unlock()
+		q.persist(job)
+		q.reportProgress(job)
+	}
+}
+
+// reportProgress notifies the job's progress task, if any, of the items
+// done and failed so far
+func (q *JobQueue) reportProgress(job *BatchJob) {
+	q.mu.RLock()
+	task := q.progress[job.ID]
+	completed, message := job.Completed, progressMessage(job)
+	q.mu.RUnlock()
+	if task != nil {
+		_ = task.UpdateProgress(float64(completed), message)
 	}
+}
 
+// finishProgress sends the final notification for a finished job and
+// stops tracking its progress
+func (q *JobQueue) finishProgress(job *BatchJob) {
 	q.mu.Lock()
-	job.Completed = len(tasks)
+	task := q.progress[job.ID]
+	delete(q.progress, job.ID)
+	status, errText, message := job.Status, job.Error, progressMessage(job)
 	q.mu.Unlock()
+	if task == nil {
+		return
+	}
+
+	switch status {
+	case JobStatusFailed:
+		task.CompleteWithError(errors.New(errText))
+	case JobStatusCancelled:
+		task.Cancel(message)
+	default:
+		task.Complete()
+	}
+}
+
+// progressMessage summarises a job's progress, naming the latest failure.
+// Callers hold q.mu.
+func progressMessage(job *BatchJob) string {
+	message := fmt.Sprintf("%s: %d of %d tasks processed", job.Type, job.Completed, job.TotalTasks)
+	if n := len(job.Failed); n > 0 {
+		message += fmt.Sprintf(", %d failed (latest: %s)", n, job.Failed[n-1])
+	}
+	return message
+}
+
+// decodeJobInput reads a job input into target. Inputs are typed when a job
+// is queued but generic JSON values once reloaded from the store.
+func decodeJobInput(job *BatchJob, key string, target interface{}) error {
+	value, ok := job.Results[key]
+	if !ok {
+		return fmt.Errorf("missing %s", key)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
 }
 
-// Similar implementations for other batch operations...
-func (q *JobQueue) processBatchPriority(job *BatchJob) {
-	// Implementation similar to processBatchDueDate
+// processTaskJob runs apply on each task a position-based job selected,
+// reporting failures per task
+func (q *JobQueue) processTaskJob(job *BatchJob, apply func(task map[string]string) error) {
+	var tasks []map[string]string
+	if err := decodeJobInput(job, "tasks", &tasks); err != nil {
+		q.failJob(job, "Invalid or missing tasks data")
+		return
+	}
+
+	q.runItems(job, len(tasks), func(i int) string {
+		if name := tasks[i]["name"]; name != "" {
+			return fmt.Sprintf("Task '%s'", name)
+		}
+		return fmt.Sprintf("Task %s", tasks[i]["task_id"])
+	}, func(i int) error {
+		return apply(tasks[i])
+	})
 }
 
-func (q *JobQueue) processBatchComplete(job *BatchJob) {
-	// Implementation similar to processBatchDueDate
+// processBatchDueDate handles batch due date updates
+func (q *JobQueue) processBatchDueDate(job *BatchJob, client RTMClientInterface) {
+	var dueDate string
+	if err := decodeJobInput(job, "due_date", &dueDate); err != nil {
+		q.failJob(job, "Invalid or missing due_date")
+		return
+	}
+
+	q.processTaskJob(job, func(task map[string]string) error {
+		updates := map[string]string{"due": dueDate}
+		return client.UpdateTask(task["list_id"], task["series_id"], task["task_id"], updates)
+	})
 }
 
-func (q *JobQueue) processBatchTagsAdd(job *BatchJob) {
-	// Implementation similar to processBatchDueDate
+// processBatchPriority handles batch priority updates
+func (q *JobQueue) processBatchPriority(job *BatchJob, client RTMClientInterface) {
+	var priority string
+	if err := decodeJobInput(job, "priority", &priority); err != nil {
+		q.failJob(job, "Invalid or missing priority")
+		return
+	}
+
+	q.processTaskJob(job, func(task map[string]string) error {
+		updates := map[string]string{"priority": priority}
+		return client.UpdateTask(task["list_id"], task["series_id"], task["task_id"], updates)
+	})
 }
 
-func (q *JobQueue) processBatchCreate(job *BatchJob) {
-	taskTexts, ok := job.Results["tasks"].([]string)
-	if !ok {
-		q.mu.Lock()
-		job.Status = JobStatusFailed
-		job.Error = "Invalid or missing tasks data"
-		q.mu.Unlock()
+// processBatchComplete handles batch completion
+func (q *JobQueue) processBatchComplete(job *BatchJob, client RTMClientInterface) {
+	q.processTaskJob(job, func(task map[string]string) error {
+		return client.CompleteTask(task["list_id"], task["series_id"], task["task_id"])
+	})
+}
+
+// processBatchTagsAdd adds tags to each task, keeping its existing tags
+func (q *JobQueue) processBatchTagsAdd(job *BatchJob, client RTMClientInterface) {
+	var tags string
+	if err := decodeJobInput(job, "tags", &tags); err != nil {
+		q.failJob(job, "Invalid or missing tags")
 		return
 	}
 
-	for i, taskText := range taskTexts {
-		// Update progress
-		q.mu.Lock()
-		job.Completed = i
-		q.mu.Unlock()
+	q.processTaskJob(job, func(task map[string]string) error {
+		return client.AddTags(task["list_id"], task["series_id"], task["task_id"], tags)
+	})
+}
 
-		// RTM rate limit
-		if i > 0 {
-			time.Sleep(1 * time.Second)
-		}
+// processBatchMove moves each task to another list
+func (q *JobQueue) processBatchMove(job *BatchJob, client RTMClientInterface) {
+	var listID string
+	if err := decodeJobInput(job, "list_id", &listID); err != nil {
+		q.failJob(job, "Invalid or missing list_id")
+		return
+	}
 
-		// Create task
-		_, err := q.handler.client.AddTask(taskText, "")
-		if err != nil {
-			q.mu.Lock()
-			job.Failed = append(job.Failed, fmt.Sprintf("Task '%s': %v", taskText, err))
-			q.mu.Unlock()
-		}
+	q.processTaskJob(job, func(task map[string]string) error {
+		updates := map[string]string{"list": listID}
+		return client.UpdateTask(task["list_id"], task["series_id"], task["task_id"], updates)
+	})
+}
+
+// processBatchDelete handles batch deletion
+func (q *JobQueue) processBatchDelete(job *BatchJob, client RTMClientInterface) {
+	q.processTaskJob(job, func(task map[string]string) error {
+		return client.DeleteTask(task["list_id"], task["series_id"], task["task_id"])
+	})
+}
+
+// processBatchCreate handles batch task creation
+func (q *JobQueue) processBatchCreate(job *BatchJob, client RTMClientInterface) {
+	var taskTexts []string
+	if err := decodeJobInput(job, "tasks", &taskTexts); err != nil {
+		q.failJob(job, "Invalid or missing tasks data")
+		return
 	}
 
-	q.mu.Lock()
-	job.Completed = len(taskTexts)
-	q.mu.Unlock()
+	q.runItems(job, len(taskTexts), func(i int) string {
+		return fmt.Sprintf("Task '%s'", taskTexts[i])
+	}, func(i int) error {
+		_, err := client.SmartAdd(taskTexts[i])
+		return err
+	})
 }
@@ -0,0 +1,617 @@
+package rtm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/vcto/mcp-adapters/internal/kv"
+	"github.com/vcto/mcp-adapters/internal/longrunning"
+)
+
+// jobTestServer fakes the RTM calls batch jobs make and records the task
+// IDs and names they touch
+type jobTestServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	due     []string
+	added   []string
+	changes []string
+}
+
+func newJobTestServer() *jobTestServer {
+	s := &jobTestServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if query.Get("task_id") == "missing" {
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"fail","err":{"code":"340","msg":"task_id invalid or not provided"}}}`)
+			return
+		}
+		switch method := query.Get("method"); method {
+		case "rtm.tasks.setPriority", "rtm.tasks.complete", "rtm.tasks.addTags", "rtm.tasks.delete":
+			s.changes = append(s.changes, strings.TrimSpace(method+" "+query.Get("task_id")+" "+query.Get("priority")+query.Get("tags")))
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok"}}`)
+		case "rtm.tasks.moveTo":
+			s.changes = append(s.changes, method+" "+query.Get("task_id")+" "+query.Get("from_list_id")+"->"+query.Get("to_list_id"))
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok"}}`)
+		case "rtm.lists.getList":
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok","lists":{"list":[{"id":"l1","name":"Inbox"},{"id":"l2","name":"Errands"},{"id":"l3","name":"This Week","smart":"1"}]}}}`)
+		case "rtm.tasks.getList":
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok","tasks":{"list":[{"id":"l2","taskseries":[{"id":"s9","name":"Old errand","tags":[],"notes":[],"task":[{"id":"t9","due":"","completed":"","deleted":"","priority":"N"}]}]}]}}}`)
+		case "rtm.timelines.create":
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok","timeline":"1"}}`)
+		case "rtm.tasks.setDueDate":
+			s.due = append(s.due, query.Get("task_id"))
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok"}}`)
+		case "rtm.tasks.add":
+			s.added = append(s.added, query.Get("name"))
+			_, _ = fmt.Fprintf(w, `{"rsp":{"stat":"ok","list":{"id":"1","taskseries":[{"id":"2","name":%q,"task":[{"id":"3"}]}]}}}`, query.Get("name"))
+		default:
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok"}}`)
+		}
+	}))
+	return s
+}
+
+func (s *jobTestServer) calls() (due, added []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.due...), append([]string(nil), s.added...)
+}
+
+func newJobTestQueue(server *jobTestServer, token string) *JobQueue {
+	h := &Handler{client: NewClient("key", "secret")}
+	h.client.BaseURL = server.URL
+	h.client.AuthToken = token
+	return NewJobQueue(h)
+}
+
+func waitForJob(t *testing.T, q *JobQueue, id string, status JobStatus) BatchJob {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		q.mu.RLock()
+		job, ok := q.jobs[id]
+		var snapshot BatchJob
+		if ok {
+			snapshot = *job
+		}
+		q.mu.RUnlock()
+		if ok && snapshot.Status == status {
+			return snapshot
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("Job %s did not reach status %s", id, status)
+	return BatchJob{}
+}
+
+func seedJob(t *testing.T, store kv.Store, job BatchJob) {
+	t.Helper()
+	if err := kv.NewBucket[BatchJob](store, jobBucket).Put(job.ID, job, 0); err != nil {
+		t.Fatalf("Failed to seed job: %v", err)
+	}
+}
+
+func TestJobQueuePersistsJobs(t *testing.T) {
+	t.Logf("Importance: Queued batch updates must be written to the store so a restart neither drops them nor forgets their results.")
+
+	server := newJobTestServer()
+	defer server.Close()
+	store := kv.NewMemoryStore()
+
+	q := newJobTestQueue(server, "token")
+	if _, err := q.Persist(store); err != nil {
+		t.Fatalf("Persist failed: %v", err)
+	}
+	q.QueueJob(&BatchJob{
+		ID:         "job-1",
+		Type:       "batch_due_date",
+		Status:     JobStatusPending,
+		CreatedAt:  time.Now(),
+		TotalTasks: 1,
+		Results: map[string]interface{}{
+			"tasks":    []map[string]string{{"list_id": "1", "series_id": "2", "task_id": "3"}},
+			"due_date": "tomorrow",
+		},
+	})
+	waitForJob(t, q, "job-1", JobStatusCompleted)
+
+	saved, ok, err := kv.NewBucket[BatchJob](store, jobBucket).Get("job-1")
+	if err != nil || !ok {
+		t.Fatalf("Expected job in store, got ok=%v err=%v", ok, err)
+	}
+	if saved.Status != JobStatusCompleted || saved.Completed != 1 {
+		t.Errorf("Expected completed job with 1 item done, got %s with %d", saved.Status, saved.Completed)
+	}
+	if saved.Owner != intentOwner("token") {
+		t.Errorf("Expected job owned by the token's hash, got %q", saved.Owner)
+	}
+
+	t.Run("finished jobs reload without rerunning", func(t *testing.T) {
+		t.Logf("  > Why it's important: check_rtm_job_status should still answer after a restart, without repeating finished work.")
+		restarted := newJobTestQueue(server, "token")
+		resumed, err := restarted.Persist(store)
+		if err != nil {
+			t.Fatalf("Persist failed: %v", err)
+		}
+		if resumed != 0 {
+			t.Errorf("Expected no jobs resumed, got %d", resumed)
+		}
+		if job, ok := restarted.GetJob("job-1"); !ok || job.Status != JobStatusCompleted {
+			t.Errorf("Expected completed job after restart, got %+v", job)
+		}
+		if due, _ := server.calls(); len(due) != 1 {
+			t.Errorf("Expected one due date call in total, got %v", due)
+		}
+	})
+}
+
+func TestJobQueueResumesAfterRestart(t *testing.T) {
+	t.Logf("Importance: Long batch updates interrupted by a restart must carry on from their saved progress instead of silently stopping.")
+
+	tasks := []map[string]string{
+		{"list_id": "1", "series_id": "1", "task_id": "t1"},
+		{"list_id": "1", "series_id": "2", "task_id": "t2"},
+		{"list_id": "1", "series_id": "3", "task_id": "t3"},
+	}
+
+	t.Run("skips completed items", func(t *testing.T) {
+		t.Logf("  > Why it's important: Items finished before the restart must not be sent to RTM twice.")
+		server := newJobTestServer()
+		defer server.Close()
+		store := kv.NewMemoryStore()
+		seedJob(t, store, BatchJob{
+			ID:         "due",
+			Type:       "batch_due_date",
+			Status:     JobStatusProcessing,
+			CreatedAt:  time.Now(),
+			TotalTasks: 3,
+			Completed:  1,
+			InFlight:   true,
+			Owner:      intentOwner("token"),
+			Results:    map[string]interface{}{"tasks": tasks, "due_date": "friday"},
+		})
+
+		q := newJobTestQueue(server, "token")
+		resumed, err := q.Persist(store)
+		if err != nil {
+			t.Fatalf("Persist failed: %v", err)
+		}
+		if resumed != 1 {
+			t.Fatalf("Expected 1 job resumed, got %d", resumed)
+		}
+		job := waitForJob(t, q, "due", JobStatusCompleted)
+
+		due, _ := server.calls()
+		if fmt.Sprint(due) != "[t2 t3]" {
+			t.Errorf("Expected the in-flight and remaining items to run, got %v", due)
+		}
+		if job.Completed != 3 || len(job.Failed) != 0 {
+			t.Errorf("Expected 3 items done without failures, got %d and %v", job.Completed, job.Failed)
+		}
+	})
+
+	t.Run("does not repeat an interrupted create", func(t *testing.T) {
+		t.Logf("  > Why it's important: Re-sending a create that may already have succeeded would leave the user with duplicate tasks.")
+		server := newJobTestServer()
+		defer server.Close()
+		store := kv.NewMemoryStore()
+		seedJob(t, store, BatchJob{
+			ID:         "create",
+			Type:       "batch_create",
+			Status:     JobStatusProcessing,
+			CreatedAt:  time.Now(),
+			TotalTasks: 2,
+			InFlight:   true,
+			Owner:      intentOwner("token"),
+			Results:    map[string]interface{}{"tasks": []string{"Buy milk", "Call mom"}},
+		})
+
+		q := newJobTestQueue(server, "token")
+		if _, err := q.Persist(store); err != nil {
+			t.Fatalf("Persist failed: %v", err)
+		}
+		job := waitForJob(t, q, "create", JobStatusCompleted)
+
+		if _, added := server.calls(); fmt.Sprint(added) != "[Call mom]" {
+			t.Errorf("Expected only the remaining task to be added, got %v", added)
+		}
+		if len(job.Failed) != 1 {
+			t.Errorf("Expected the interrupted task reported as failed, got %v", job.Failed)
+		}
+	})
+
+	t.Run("waits for the job's owner", func(t *testing.T) {
+		t.Logf("  > Why it's important: A resumed job must not run against whichever RTM account happens to be connected.")
+		server := newJobTestServer()
+		defer server.Close()
+		store := kv.NewMemoryStore()
+		seedJob(t, store, BatchJob{
+			ID:         "owned",
+			Type:       "batch_due_date",
+			Status:     JobStatusPending,
+			CreatedAt:  time.Now(),
+			TotalTasks: 1,
+			Owner:      intentOwner("owner-token"),
+			Results:    map[string]interface{}{"tasks": tasks[:1], "due_date": "friday"},
+		})
+
+		q := newJobTestQueue(server, "other-token")
+		if _, err := q.Persist(store); err != nil {
+			t.Fatalf("Persist failed: %v", err)
+		}
+
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			q.mu.RLock()
+			waiting := q.waiting["owned"]
+			q.mu.RUnlock()
+			if waiting {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("Expected job to wait for its owner")
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		if due, _ := server.calls(); len(due) != 0 {
+			t.Fatalf("Expected no RTM calls for another user's job, got %v", due)
+		}
+
+		q.handler.client.AuthToken = "owner-token"
+		if n := q.ResumeWaiting(); n != 1 {
+			t.Fatalf("Expected 1 job requeued, got %d", n)
+		}
+		waitForJob(t, q, "owned", JobStatusCompleted)
+	})
+}
+
+func TestBatchTaskJobs(t *testing.T) {
+	t.Logf("Importance: The priority, complete, tag and move batch tools must apply their change to every selected task and report the ones RTM rejects.")
+
+	server := newJobTestServer()
+	defer server.Close()
+
+	h := &Handler{client: NewClient("key", "secret")}
+	h.client.BaseURL = server.URL
+	h.client.AuthToken = "token"
+	eh := NewEnhancedHandler(h)
+	eh.cacheSearch(intentOwner(h.client.AuthToken), "search_1", []Task{
+		{ID: "t1", SeriesID: "s1", ListID: "l1", Name: "Renew passport"},
+		{ID: "missing", SeriesID: "s2", ListID: "l1", Name: "Deleted elsewhere"},
+		{ID: "t3", SeriesID: "s3", ListID: "l1", Name: "Book dentist"},
+	})
+
+	jobID := regexp.MustCompile(`Job ID: (\S+)`)
+	run := func(t *testing.T, handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) BatchJob {
+		t.Helper()
+		result, err := callTool(handler, args)
+		if err != nil || result.IsError {
+			t.Fatalf("Expected job to be queued, got %v %+v", err, result)
+		}
+		match := jobID.FindStringSubmatch(result.Content[0].(mcp.TextContent).Text)
+		if match == nil {
+			t.Fatalf("Expected job ID in %+v", result.Content)
+		}
+		return waitForJob(t, eh.jobQueue, match[1], JobStatusCompleted)
+	}
+
+	t.Run("priority", func(t *testing.T) {
+		t.Logf("  > Why it's important: Each task must get the requested priority, and a rejected task must be named in the failures.")
+		job := run(t, eh.handleBatchPriority, map[string]any{"positions": "1,2", "priority": "n"})
+		if len(job.Failed) != 1 || !strings.Contains(job.Failed[0], "Deleted elsewhere") {
+			t.Errorf("Expected the rejected task named in failures, got %v", job.Failed)
+		}
+		if !slices.Contains(server.changeLog(), "rtm.tasks.setPriority t1 N") {
+			t.Errorf("Expected priority N set on t1, got %v", server.changeLog())
+		}
+	})
+
+	t.Run("complete", func(t *testing.T) {
+		t.Logf("  > Why it's important: Completing by position must complete exactly the selected tasks.")
+		job := run(t, eh.handleBatchComplete, map[string]any{"positions": "3"})
+		if job.Completed != 1 || len(job.Failed) != 0 {
+			t.Errorf("Expected 1 task completed without failures, got %d and %v", job.Completed, job.Failed)
+		}
+		if !slices.Contains(server.changeLog(), "rtm.tasks.complete t3") {
+			t.Errorf("Expected t3 completed, got %v", server.changeLog())
+		}
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		t.Logf("  > Why it's important: Adding tags must not replace the tags a task already has.")
+		run(t, eh.handleBatchTagsAdd, map[string]any{"positions": "1", "tags": "#errands, urgent"})
+		if !slices.Contains(server.changeLog(), "rtm.tasks.addTags t1 errands,urgent") {
+			t.Errorf("Expected tags added to t1, got %v", server.changeLog())
+		}
+	})
+
+	t.Run("move", func(t *testing.T) {
+		t.Logf("  > Why it's important: Moving by list name must resolve the name to the right list ID before queuing.")
+		run(t, eh.handleBatchMove, map[string]any{"positions": "3", "list": "errands"})
+		if !slices.Contains(server.changeLog(), "rtm.tasks.moveTo t3 l1->l2") {
+			t.Errorf("Expected t3 moved from l1 to l2, got %v", server.changeLog())
+		}
+	})
+
+	t.Run("rejects bad input", func(t *testing.T) {
+		t.Logf("  > Why it's important: Invalid arguments should be reported to the agent instead of queuing a job that cannot succeed.")
+		cases := []struct {
+			name    string
+			handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)
+			args    map[string]any
+		}{
+			{"priority", eh.handleBatchPriority, map[string]any{"positions": "1", "priority": "urgent"}},
+			{"tags", eh.handleBatchTagsAdd, map[string]any{"positions": "1", "tags": " , "}},
+			{"positions", eh.handleBatchComplete, map[string]any{"positions": "9"}},
+			{"unknown list", eh.handleBatchMove, map[string]any{"positions": "1", "list": "Someday"}},
+			{"smart list", eh.handleBatchMove, map[string]any{"positions": "1", "list": "This Week"}},
+		}
+		for _, tc := range cases {
+			if result, err := callTool(tc.handler, tc.args); err != nil || !result.IsError {
+				t.Errorf("Expected %s error result, got %v %+v", tc.name, err, result)
+			}
+		}
+	})
+}
+
+func (s *jobTestServer) changeLog() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.changes...)
+}
+
+func callTool(handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) (*mcp.CallToolResult, error) {
+	req := mcp.CallToolRequest{}
+	req.Params.Arguments = args
+	return handler(context.Background(), req)
+}
+
+func TestBatchDelete(t *testing.T) {
+	t.Logf("Importance: Deletion cannot be undone from a batch job, so the dry run must show exactly what the real run deletes.")
+
+	server := newJobTestServer()
+	defer server.Close()
+
+	h := &Handler{client: NewClient("key", "secret")}
+	h.client.BaseURL = server.URL
+	h.client.AuthToken = "token"
+	eh := NewEnhancedHandler(h)
+	eh.cacheSearch(intentOwner(h.client.AuthToken), "search_1", []Task{
+		{ID: "t1", SeriesID: "s1", ListID: "l1", Name: "Renew passport", Due: "2024-05-01T00:00:00Z"},
+		{ID: "t2", SeriesID: "s2", ListID: "l1", Name: "Water plants", Repeat: "every week"},
+	})
+
+	t.Run("dry run lists tasks without deleting", func(t *testing.T) {
+		t.Logf("  > Why it's important: The agent must be able to show the user what will go before anything is deleted.")
+		result, err := callTool(eh.handleBatchDelete, map[string]any{"positions": "1,2", "dry_run": true})
+		if err != nil || result.IsError {
+			t.Fatalf("Expected dry run listing, got %v %+v", err, result)
+		}
+		text := result.Content[0].(mcp.TextContent).Text
+		for _, want := range []string{"Renew passport [task_id t1]", "Water plants [task_id t2] (repeating)", `task_ids="t1,t2"`} {
+			if !strings.Contains(text, want) {
+				t.Errorf("Expected %q in dry run:\n%s", want, text)
+			}
+		}
+		if len(server.changeLog()) != 0 {
+			t.Errorf("Expected no RTM changes from a dry run, got %v", server.changeLog())
+		}
+	})
+
+	t.Run("deletes by task ID", func(t *testing.T) {
+		t.Logf("  > Why it's important: IDs from the dry run stay valid even if a later search renumbers positions.")
+		result, err := callTool(eh.handleBatchDelete, map[string]any{"task_ids": "t2, t9"})
+		if err != nil || result.IsError {
+			t.Fatalf("Expected job to be queued, got %v %+v", err, result)
+		}
+		match := regexp.MustCompile(`Job ID: (\S+)`).FindStringSubmatch(result.Content[0].(mcp.TextContent).Text)
+		if match == nil {
+			t.Fatalf("Expected job ID in %+v", result.Content)
+		}
+		waitForJob(t, eh.jobQueue, match[1], JobStatusCompleted)
+
+		want := []string{"rtm.tasks.delete t2", "rtm.tasks.delete t9"}
+		if fmt.Sprint(server.changeLog()) != fmt.Sprint(want) {
+			t.Errorf("Expected %v, got %v", want, server.changeLog())
+		}
+	})
+
+	t.Run("rejects ambiguous or unknown selections", func(t *testing.T) {
+		t.Logf("  > Why it's important: A destructive tool must refuse to guess which tasks were meant.")
+		cases := []map[string]any{
+			{},
+			{"positions": "1", "task_ids": "t1"},
+			{"task_ids": "t1,nope"},
+		}
+		for _, args := range cases {
+			if result, err := callTool(eh.handleBatchDelete, args); err != nil || !result.IsError {
+				t.Errorf("Expected error result for %v, got %v %+v", args, err, result)
+			}
+		}
+	})
+}
+
+func TestCancelJob(t *testing.T) {
+	t.Logf("Importance: A batch queued by mistake must be stoppable, and the user must learn which tasks it already changed.")
+
+	server := newJobTestServer()
+	defer server.Close()
+
+	h := &Handler{client: NewClient("key", "secret")}
+	h.client.BaseURL = server.URL
+	h.client.AuthToken = "token"
+	eh := NewEnhancedHandler(h)
+
+	taskRefs := func(ids ...string) []map[string]string {
+		var refs []map[string]string
+		for _, id := range ids {
+			refs = append(refs, map[string]string{"list_id": "l1", "series_id": "s" + id, "task_id": id, "name": "Task " + id})
+		}
+		return refs
+	}
+	running := &BatchJob{ID: "running", Type: "batch_complete", Status: JobStatusPending, CreatedAt: time.Now(), TotalTasks: 4,
+		Results: map[string]interface{}{"tasks": taskRefs("a1", "a2", "a3", "a4")}}
+	queued := &BatchJob{ID: "queued", Type: "batch_complete", Status: JobStatusPending, CreatedAt: time.Now(), TotalTasks: 2,
+		Results: map[string]interface{}{"tasks": taskRefs("b1", "b2")}}
+	eh.jobQueue.QueueJob(running)
+	eh.jobQueue.QueueJob(queued)
+
+	t.Run("queued job stops at once", func(t *testing.T) {
+		t.Logf("  > Why it's important: A job that has not started should never touch a task once cancelled.")
+		result, err := callTool(eh.handleCancelJob, map[string]any{"job_id": "queued"})
+		if err != nil || result.IsError {
+			t.Fatalf("Expected cancel to succeed, got %v %+v", err, result)
+		}
+		if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "before it changed any tasks") {
+			t.Errorf("Unexpected cancel message: %s", text)
+		}
+	})
+
+	t.Run("running job stops between items", func(t *testing.T) {
+		t.Logf("  > Why it's important: Cancellation is cooperative; the item in progress finishes and the rest are skipped.")
+		deadline := time.Now().Add(5 * time.Second)
+		for len(server.changeLog()) == 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		result, err := callTool(eh.handleCancelJob, map[string]any{"job_id": "running"})
+		if err != nil || result.IsError {
+			t.Fatalf("Expected cancel to succeed, got %v %+v", err, result)
+		}
+
+		job := waitForJob(t, eh.jobQueue, "running", JobStatusCancelled)
+		if job.Completed == 0 || job.Completed >= 4 {
+			t.Errorf("Expected a partial run, got %d/4", job.Completed)
+		}
+		if len(server.changeLog()) != job.Completed {
+			t.Errorf("Expected %d RTM changes, got %v", job.Completed, server.changeLog())
+		}
+		for _, change := range server.changeLog() {
+			if strings.Contains(change, "b") {
+				t.Errorf("Expected cancelled queued job not to run, got %v", server.changeLog())
+			}
+		}
+
+		status, _ := callTool(eh.handleCheckJobStatus, map[string]any{"job_id": "running"})
+		if text := status.Content[0].(mcp.TextContent).Text; !strings.Contains(text, fmt.Sprintf(`"not_processed": %d`, 4-job.Completed)) {
+			t.Errorf("Expected not_processed in status, got %s", text)
+		}
+	})
+
+	t.Run("finished and foreign jobs", func(t *testing.T) {
+		t.Logf("  > Why it's important: Users can only cancel their own jobs, and cancelling twice is harmless.")
+		if _, err := eh.jobQueue.Cancel("running"); err != ErrJobFinished {
+			t.Errorf("Expected ErrJobFinished, got %v", err)
+		}
+		result, _ := callTool(eh.handleCancelJob, map[string]any{"job_id": "running"})
+		if result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "nothing to cancel") {
+			t.Errorf("Expected a nothing-to-cancel message, got %+v", result)
+		}
+
+		h.client.AuthToken = "other-token"
+		defer func() { h.client.AuthToken = "token" }()
+		if result, _ := callTool(eh.handleCancelJob, map[string]any{"job_id": "queued"}); !result.IsError {
+			t.Error("Expected another user's job to be reported as not found")
+		}
+	})
+
+	t.Run("cancel survives a restart", func(t *testing.T) {
+		t.Logf("  > Why it's important: A job cancelled just before a restart must not resume afterwards.")
+		store := kv.NewMemoryStore()
+		seedJob(t, store, BatchJob{ID: "stopping", Type: "batch_complete", Status: JobStatusProcessing, CreatedAt: time.Now(),
+			TotalTasks: 2, Completed: 1, CancelRequested: true, Owner: intentOwner("token"),
+			Results: map[string]interface{}{"tasks": taskRefs("c1", "c2")}})
+
+		q := newJobTestQueue(server, "token")
+		resumed, err := q.Persist(store)
+		if err != nil || resumed != 0 {
+			t.Fatalf("Expected no jobs resumed, got %d %v", resumed, err)
+		}
+		if job, _ := q.GetJob("stopping"); job.Status != JobStatusCancelled {
+			t.Errorf("Expected cancelled, got %s", job.Status)
+		}
+	})
+}
+
+// progressSession is a connected MCP client that records its notifications
+type progressSession struct {
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (p *progressSession) Initialize()       {}
+func (p *progressSession) Initialized() bool { return true }
+func (p *progressSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return p.notifications
+}
+func (p *progressSession) SessionID() string { return "progress-session" }
+
+func TestJobProgressNotifications(t *testing.T) {
+	t.Logf("Importance: Agents that send a progress token should hear about each item, failures included, instead of polling check_rtm_job_status.")
+
+	rtmServer := newJobTestServer()
+	defer rtmServer.Close()
+
+	h := &Handler{client: NewClient("key", "secret")}
+	h.client.BaseURL = rtmServer.URL
+	h.client.AuthToken = "token"
+	eh := NewEnhancedHandler(h)
+	eh.cacheSearch(intentOwner(h.client.AuthToken), "search_1", []Task{
+		{ID: "t1", SeriesID: "s1", ListID: "l1", Name: "Renew passport"},
+		{ID: "missing", SeriesID: "s2", ListID: "l1", Name: "Deleted elsewhere"},
+	})
+
+	mcpServer := server.NewMCPServer("test", "1.0")
+	eh.SetTaskManager(longrunning.NewManager(mcpServer))
+	session := &progressSession{notifications: make(chan mcp.JSONRPCNotification, 10)}
+	if err := mcpServer.RegisterSession(context.Background(), session); err != nil {
+		t.Fatalf("RegisterSession failed: %v", err)
+	}
+
+	req := mcp.CallToolRequest{}
+	req.Params.Arguments = map[string]any{"positions": "1,2"}
+	req.Params.Meta = &mcp.Meta{ProgressToken: "batch-progress"}
+	ctx, cancel := context.WithCancel(mcpServer.WithContext(context.Background(), session))
+	result, err := eh.handleBatchComplete(ctx, req)
+	cancel() // The job must outlive the request
+	if err != nil || result.IsError {
+		t.Fatalf("Expected job to be queued, got %v %+v", err, result)
+	}
+
+	var messages []string
+	timeout := time.After(10 * time.Second)
+	for len(messages) < 3 {
+		select {
+		case note := <-session.notifications:
+			fields := note.Params.AdditionalFields
+			if fields["progressToken"] != mcp.ProgressToken("batch-progress") || fields["total"] != float64(2) {
+				t.Errorf("Unexpected notification %+v", fields)
+			}
+			message, _ := fields["message"].(string)
+			messages = append(messages, message)
+		case <-timeout:
+			t.Fatalf("Expected per-item and final notifications, got %v", messages)
+		}
+	}
+
+	if messages[0] != "batch_complete: 1 of 2 tasks processed" {
+		t.Errorf("Unexpected first update %q", messages[0])
+	}
+	if !strings.Contains(messages[1], "2 of 2 tasks processed, 1 failed") || !strings.Contains(messages[1], "Deleted elsewhere") {
+		t.Errorf("Expected the failure named in the update, got %q", messages[1])
+	}
+	if messages[2] != messages[1] {
+		t.Errorf("Expected the final notification to repeat the summary, got %q", messages[2])
+	}
+}
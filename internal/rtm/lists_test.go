@@ -0,0 +1,75 @@
+package rtm
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestManageListSmartList(t *testing.T) {
+	t.Logf("Importance: Smart Lists turn a search the user repeats into a list; the agent must see the criteria RTM actually stored.")
+
+	var mu sync.Mutex
+	var added map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		query := r.URL.Query()
+		switch query.Get("method") {
+		case "rtm.timelines.create":
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok","timeline":"tl-1"}}`)
+		case "rtm.lists.add":
+			added = map[string]string{"name": query.Get("name"), "filter": query.Get("filter")}
+			smart, filter := "0", ""
+			if query.Get("filter") != "" {
+				smart, filter = "1", "("+query.Get("filter")+")"
+			}
+			_, _ = fmt.Fprintf(w, `{"rsp":{"stat":"ok","transaction":{"id":"tx-1","undoable":"0"},"list":{"id":"42","name":%q,"smart":%q,"filter":%q}}}`, query.Get("name"), smart, filter)
+		default:
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok"}}`)
+		}
+	}))
+	defer server.Close()
+
+	h := &Handler{client: NewClient("key", "secret")}
+	h.client.BaseURL = server.URL
+	h.client.AuthToken = "token"
+	h.client.Limiter = nil
+
+	result, err := callTool(h.handleManageList, map[string]any{"action": "create", "name": "Urgent", "filter": " priority:1 AND dueBefore:tomorrow "})
+	if err != nil || result.IsError {
+		t.Fatalf("Expected the Smart List to be created, got %v %+v", err, result)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	if added["filter"] != "priority:1 AND dueBefore:tomorrow" {
+		t.Errorf("Expected the trimmed filter sent to rtm.lists.add, got %q", added["filter"])
+	}
+	if !strings.Contains(text, "Smart List 'Urgent' created with ID: 42") || !strings.Contains(text, "Criteria: (priority:1 AND dueBefore:tomorrow)") {
+		t.Errorf("Expected the stored criteria reported, got %q", text)
+	}
+
+	t.Run("plain lists send no filter", func(t *testing.T) {
+		t.Logf("  > Why it's important: An empty filter parameter must not turn an ordinary list into a Smart List.")
+		result, _ := callTool(h.handleManageList, map[string]any{"action": "create", "name": "Errands"})
+		if added["filter"] != "" || result.IsError {
+			t.Errorf("Expected no filter, got %v", added)
+		}
+		if text := result.Content[0].(mcp.TextContent).Text; text != "List 'Errands' created with ID: 42" {
+			t.Errorf("Unexpected result %q", text)
+		}
+	})
+
+	t.Run("filter only applies to create", func(t *testing.T) {
+		t.Logf("  > Why it's important: RTM cannot change a Smart List's criteria, so a filter on rename must not be silently dropped.")
+		result, _ := callTool(h.handleManageList, map[string]any{"action": "rename", "list_id": "42", "new_name": "Now", "filter": "priority:2"})
+		if !result.IsError {
+			t.Error("Expected an error for a filter on rename")
+		}
+	})
+}
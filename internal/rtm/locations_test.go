@@ -0,0 +1,125 @@
+package rtm
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestGetLocations(t *testing.T) {
+	t.Logf("Importance: RTM returns location coordinates as strings; they must be parsed so agents can reason about places.")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok","locations":{"location":[
+			{"id":"987","name":"Office","longitude":"-122.4194","latitude":"37.7749","zoom":"12","address":"1 Market St","viewable":"1"}
+		]}}}`)
+	}))
+	defer server.Close()
+
+	client := NewClient("key", "secret")
+	client.BaseURL = server.URL
+	client.AuthToken = "token-1"
+
+	locations, err := client.GetLocations()
+	if err != nil {
+		t.Fatalf("GetLocations failed: %v", err)
+	}
+	if len(locations) != 1 {
+		t.Fatalf("Expected 1 location, got %d", len(locations))
+	}
+
+	loc := locations[0]
+	if loc.ID != "987" || loc.Name != "Office" || loc.Address != "1 Market St" {
+		t.Errorf("Unexpected location: %+v", loc)
+	}
+	if loc.Latitude != 37.7749 || loc.Longitude != -122.4194 || loc.Zoom != 12 {
+		t.Errorf("Expected parsed coordinates, got lat=%v lon=%v zoom=%v", loc.Latitude, loc.Longitude, loc.Zoom)
+	}
+}
+
+func TestUpdateTaskURLAndLocation(t *testing.T) {
+	t.Logf("Importance: Assistants attach reference links and places to tasks; each must reach the right RTM method, and clearing must work.")
+
+	var mu sync.Mutex
+	var calls []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		query := r.URL.Query()
+		switch query.Get("method") {
+		case "rtm.timelines.create":
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok","timeline":"tl-1"}}`)
+		case "rtm.locations.getList":
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok","locations":{"location":[{"id":"987","name":"Office"}]}}}`)
+		default:
+			calls = append(calls, query)
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok","transaction":{"id":"tx-1","undoable":"1"}}}`)
+		}
+	}))
+	defer server.Close()
+
+	h := &Handler{client: NewClient("key", "secret")}
+	h.client.BaseURL = server.URL
+	h.client.AuthToken = "token"
+	h.client.Limiter = nil
+	task := map[string]any{"list_id": "l1", "series_id": "s1", "task_id": "t1"}
+	update := func(fields map[string]any) *mcp.CallToolResult {
+		args := map[string]any{}
+		for k, v := range task {
+			args[k] = v
+		}
+		for k, v := range fields {
+			args[k] = v
+		}
+		mu.Lock()
+		calls = nil
+		mu.Unlock()
+		result, err := callTool(h.handleUpdateTask, args)
+		if err != nil {
+			t.Fatalf("rtm_update failed: %v", err)
+		}
+		return result
+	}
+
+	result := update(map[string]any{"url": "https://example.com/brief", "location": "office"})
+	if result.IsError || len(calls) != 2 {
+		t.Fatalf("Expected two updates, got %d calls: %+v", len(calls), result)
+	}
+	byMethod := map[string]url.Values{}
+	for _, call := range calls {
+		byMethod[call.Get("method")] = call
+	}
+	if byMethod["rtm.tasks.setURL"].Get("url") != "https://example.com/brief" {
+		t.Errorf("Expected the URL set, got %v", byMethod["rtm.tasks.setURL"])
+	}
+	if byMethod["rtm.tasks.setLocation"].Get("location_id") != "987" {
+		t.Errorf("Expected the location resolved by name, got %v", byMethod["rtm.tasks.setLocation"])
+	}
+
+	t.Run("none clears", func(t *testing.T) {
+		t.Logf("  > Why it's important: Stale links and places must be removable, which RTM does when the value is left out.")
+		result := update(map[string]any{"url": "none", "location": "None"})
+		if result.IsError || len(calls) != 2 {
+			t.Fatalf("Expected two updates, got %d calls", len(calls))
+		}
+		for _, call := range calls {
+			if call.Has("url") || call.Has("location_id") {
+				t.Errorf("Expected %s without a value, got %v", call.Get("method"), call)
+			}
+		}
+	})
+
+	t.Run("rejects relative links", func(t *testing.T) {
+		t.Logf("  > Why it's important: RTM stores whatever it is given; a bare word would become a broken link.")
+		if result := update(map[string]any{"url": "brief"}); !result.IsError || len(calls) != 0 {
+			t.Errorf("Expected an error before calling RTM, got %d calls", len(calls))
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package rtm
+
+import (
+	"github.com/vcto/mcp-adapters/internal/manifest"
+)
+
+// batchScope serializes position-based batch tools for each user, since they
+// all act on the same cached search results
+const batchScope = "batch"
+
+// Manifest declares what each RTM tool reads and writes in the user's account
+func Manifest() *manifest.Manifest {
+	return &manifest.Manifest{
+		Adapter: "rtm",
+		Service: "Remember The Milk",
+		Account: "The Remember The Milk account you authorized via rtm_auth_url",
+		Tools: map[string]manifest.ToolAccess{
+			"rtm_auth_url":        {},
+			"rtm_whoami":          {Reads: []string{"account details"}},
+			"rtm_lists":           {Reads: []string{"lists"}},
+			"rtm_locations":       {Reads: []string{"locations"}},
+			"rtm_tags":            {Reads: []string{"tags", "tasks"}},
+			"rtm_search":          {Reads: []string{"tasks"}},
+			"rtm_export_csv":      {Reads: []string{"tasks"}},
+			"rtm_estimate_report": {Reads: []string{"tasks", "lists"}},
+			"rtm_quick_add":       {Writes: []string{"tasks"}},
+			"rtm_update":          {Reads: []string{"lists", "locations"}, Writes: []string{"tasks"}, Idempotent: true},
+			"rtm_complete":        {Writes: []string{"tasks"}, Idempotent: true},
+			"rtm_duplicate_task":  {Reads: []string{"tasks", "lists"}, Writes: []string{"tasks"}},
+			"rtm_manage_list":     {Writes: []string{"lists"}},
+			"rtm_undo":            {Writes: []string{"tasks", "lists"}},
+
+			"search_rtm_tasks_smart":   {Reads: []string{"tasks"}},
+			"get_rtm_task_by_position": {Reads: []string{"tasks"}},
+			"save_rtm_search_preset":   {},
+			"set_rtm_tasks_due_date":   {Writes: []string{"tasks"}, Exclusive: batchScope, Idempotent: true},
+			"set_rtm_tasks_priority":   {Writes: []string{"tasks"}, Exclusive: batchScope, Idempotent: true},
+			"complete_rtm_tasks_batch": {Writes: []string{"tasks"}, Exclusive: batchScope, Idempotent: true},
+			"add_rtm_tags_to_tasks":    {Writes: []string{"tasks"}, Exclusive: batchScope, Idempotent: true},
+			"move_rtm_tasks_to_list":   {Reads: []string{"lists"}, Writes: []string{"tasks"}, Exclusive: batchScope, Idempotent: true},
+			"delete_rtm_tasks_batch":   {Reads: []string{"tasks"}, Writes: []string{"tasks"}, Exclusive: batchScope},
+			"check_rtm_job_status":     {},
+			"cancel_rtm_job":           {Idempotent: true},
+			"analyze_rtm_task_context": {Reads: []string{"tasks", "lists"}},
+			"create_rtm_task_smart":    {Writes: []string{"tasks"}},
+			"create_rtm_tasks_batch":   {Writes: []string{"tasks"}},
+
+			"adapter_status":  {Group: manifest.GroupAdmin},
+			"simulate_outage": {Group: manifest.GroupAdmin},
+			"webhook_audit":   {Group: manifest.GroupAdmin},
+		},
+	}
+}
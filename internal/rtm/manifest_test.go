@@ -0,0 +1,66 @@
+package rtm
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/vcto/mcp-adapters/internal/lint"
+	"github.com/vcto/mcp-adapters/internal/longrunning"
+)
+
+func TestManifestCoversRegisteredTools(t *testing.T) {
+	t.Logf("Importance: A tool missing from the manifest would reach approval dialogs without any permission description.")
+
+	s := server.NewMCPServer("test", "1.0.0", server.WithToolCapabilities(false))
+	h := &Handler{client: NewClient("key", "secret")}
+	h.SetupTools(s)
+	NewEnhancedHandler(h).SetupAtomicTools(s)
+	h.SetupBatchTools(s, longrunning.NewManager(s))
+
+	resp := s.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+	data, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatalf("Failed to marshal response: %v", err)
+	}
+
+	var decoded struct {
+		Result struct {
+			Tools []struct {
+				Name string `json:"name"`
+			} `json:"tools"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(decoded.Result.Tools) == 0 {
+		t.Fatal("Expected registered tools")
+	}
+
+	tools := Manifest().Tools
+	for _, tool := range decoded.Result.Tools {
+		if _, ok := tools[tool.Name]; !ok {
+			t.Errorf("Tool %s is registered but missing from the RTM manifest", tool.Name)
+		}
+	}
+}
+
+func TestRegistryPassesConnectorLint(t *testing.T) {
+	t.Logf("Importance: The server refuses to start on a lint violation, so a bad tool name or description must fail here first.")
+
+	s := server.NewMCPServer("test", "1.0.0",
+		server.WithToolCapabilities(false),
+		server.WithPromptCapabilities(false),
+	)
+	h := &Handler{client: NewClient("key", "secret")}
+	h.SetupTools(s)
+	NewEnhancedHandler(h).SetupAtomicTools(s)
+	h.SetupBatchTools(s, longrunning.NewManager(s))
+	h.SetupPrompts(s)
+
+	if err := lint.Enforce(context.Background(), s, lint.DefaultRules()); err != nil {
+		t.Error(err)
+	}
+}
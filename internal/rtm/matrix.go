@@ -0,0 +1,101 @@
+package rtm
+
+import (
+	"sort"
+	"time"
+)
+
+// MatrixURI is the resource sorting incomplete tasks into an Eisenhower matrix
+const MatrixURI = "rtm://matrix"
+
+// MatrixFilter is the RTM search for the tasks the matrix sorts
+const MatrixFilter = "status:incomplete"
+
+// matrixUrgentDays is how many days after today a task may be due and still
+// count as urgent; overdue tasks are always urgent
+const matrixUrgentDays = 1
+
+// Quadrant is one cell of the priority matrix
+type Quadrant struct {
+	// Name is "do", "schedule", "delegate" or "eliminate"
+	Name      string `json:"name"`
+	Label     string `json:"label"`
+	Urgent    bool   `json:"urgent"`
+	Important bool   `json:"important"`
+	Tasks     []Task `json:"tasks"`
+	Count     int    `json:"count"`
+}
+
+// PriorityMatrix sorts tasks into the four Eisenhower quadrants. A task is
+// urgent when it is overdue or due today or tomorrow, in the time zone of its
+// due date, and important when it has priority 1 or 2. Each quadrant lists
+// the soonest due first, then the highest priority; tasks without a due date
+// come last.
+func PriorityMatrix(tasks []Task, now time.Time) []Quadrant {
+	quadrants := []Quadrant{
+		{Name: "do", Label: "Urgent and important: do now", Urgent: true, Important: true},
+		{Name: "schedule", Label: "Important, not urgent: schedule", Important: true},
+		{Name: "delegate", Label: "Urgent, not important: delegate or batch", Urgent: true},
+		{Name: "eliminate", Label: "Neither: drop or defer"},
+	}
+
+	for _, task := range tasks {
+		urgent := isUrgent(task, now)
+		important := task.Priority == "1" || task.Priority == "2"
+		for i := range quadrants {
+			if quadrants[i].Urgent == urgent && quadrants[i].Important == important {
+				quadrants[i].Tasks = append(quadrants[i].Tasks, task)
+				break
+			}
+		}
+	}
+
+	for i := range quadrants {
+		q := &quadrants[i]
+		if q.Tasks == nil {
+			q.Tasks = []Task{}
+		}
+		sort.SliceStable(q.Tasks, func(a, b int) bool {
+			dueA, okA := parseDue(q.Tasks[a])
+			dueB, okB := parseDue(q.Tasks[b])
+			if okA != okB {
+				return okA
+			}
+			if okA && !dueA.Equal(dueB) {
+				return dueA.Before(dueB)
+			}
+			return priorityRank(q.Tasks[a].Priority) < priorityRank(q.Tasks[b].Priority)
+		})
+		q.Count = len(q.Tasks)
+	}
+	return quadrants
+}
+
+// isUrgent reports whether task is due on or before the last urgent day
+func isUrgent(task Task, now time.Time) bool {
+	due, ok := parseDue(task)
+	if !ok {
+		return false
+	}
+	local := now.In(due.Location())
+	cutoff := time.Date(local.Year(), local.Month(), local.Day()+matrixUrgentDays+1, 0, 0, 0, 0, due.Location())
+	return due.Before(cutoff)
+}
+
+func parseDue(task Task) (time.Time, bool) {
+	if task.Due == "" {
+		return time.Time{}, false
+	}
+	due, err := time.Parse(time.RFC3339, task.Due)
+	return due, err == nil
+}
+
+// priorityRank orders RTM priorities highest first, with none ("N") last
+func priorityRank(priority string) int {
+	switch priority {
+	case "1", "2", "3":
+		return int(priority[0] - '0')
+	default:
+		return 4
+	}
+}
@@ -0,0 +1,54 @@
+package rtm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPriorityMatrix(t *testing.T) {
+	t.Logf("Importance: The matrix tells users what to do now versus schedule; a misplaced task undermines the whole view.")
+
+	// 23:30 on 3 May in Tokyo, 3 May's afternoon in UTC
+	now := time.Date(2024, 5, 3, 14, 30, 0, 0, time.UTC)
+
+	tasks := []Task{
+		{ID: "1", Name: "Someday idea", Priority: "N"},
+		{ID: "2", Name: "Tax return", Priority: "1", Due: "2024-05-04T00:00:00+09:00"},
+		{ID: "3", Name: "Overdue invoice", Priority: "2", Due: "2024-04-30T00:00:00+09:00"},
+		{ID: "4", Name: "Plan holiday", Priority: "2", Due: "2024-05-20T00:00:00+09:00"},
+		{ID: "5", Name: "Learn piano", Priority: "1"},
+		{ID: "6", Name: "Reply to newsletter", Priority: "3", Due: "2024-05-04T09:00:00+09:00"},
+		// Tomorrow in UTC, but the day after tomorrow in Tokyo
+		{ID: "7", Name: "Water plants", Priority: "N", Due: "2024-05-05T00:00:00+09:00"},
+	}
+
+	got := map[string][]string{}
+	for _, q := range PriorityMatrix(tasks, now) {
+		if q.Count != len(q.Tasks) {
+			t.Errorf("Quadrant %s count %d does not match %d tasks", q.Name, q.Count, len(q.Tasks))
+		}
+		for _, task := range q.Tasks {
+			got[q.Name] = append(got[q.Name], task.ID)
+		}
+	}
+
+	want := map[string][]string{
+		"do":        {"3", "2"},
+		"schedule":  {"4", "5"},
+		"delegate":  {"6"},
+		"eliminate": {"7", "1"},
+	}
+	for name, ids := range want {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("  > Why it's important: Urgency follows the user's own calendar day, soonest first.")
+			if len(got[name]) != len(ids) {
+				t.Fatalf("Expected %v, got %v", ids, got[name])
+			}
+			for i := range ids {
+				if got[name][i] != ids[i] {
+					t.Errorf("Expected %v, got %v", ids, got[name])
+				}
+			}
+		})
+	}
+}
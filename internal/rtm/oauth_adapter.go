@@ -3,12 +3,11 @@
 package rtm
 
 import (
-	"crypto/rand"
-	"crypto/sha256"
-	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
 	"net/url"
 	"strings"
@@ -17,14 +16,80 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/vcto/mcp-adapters/internal/auth"
+	"github.com/vcto/mcp-adapters/internal/config"
+	"github.com/vcto/mcp-adapters/internal/kv"
 )
 
+// defaultSessionTTL matches how long RTM keeps a frob valid
+const defaultSessionTTL = 60 * time.Minute
+
+// sessionCleanupInterval is how often abandoned sessions are removed
+const sessionCleanupInterval = 5 * time.Minute
+
+// exchangeBurst and exchangeInterval bound how often one session's frob is
+// exchanged with RTM: a few at once, then one per interval, however fast
+// the sign-in page or client polls
+const (
+	exchangeBurst    = 3
+	exchangeInterval = time.Second
+)
+
+// authSessionBucket is the kv bucket holding authorization sessions
+const authSessionBucket = "rtm_auth_sessions"
+
+// clientIDPrefix starts the client IDs minted by dynamic client registration
+const clientIDPrefix = "rtm_"
+
+// Scopes a client can ask for in the authorize request
+const (
+	ScopeRead  = "rtm:read"
+	ScopeWrite = "rtm:write"
+)
+
+// defaultScope is granted when a client asks for no scopes it knows
+const defaultScope = ScopeRead + " " + ScopeWrite
+
+// issuedToken records an RTM token handed to a client
+type issuedToken struct {
+	IssuedAt time.Time `json:"issued_at"`
+	Scope    string    `json:"scope,omitempty"`
+	ClientID string    `json:"client_id,omitempty"`
+}
+
+// revokedTokenBucket holds RTM tokens revoked through the revocation endpoint
+const revokedTokenBucket = "rtm_revoked_tokens"
+
 // OAuthAdapter adapts RTM's frob-based auth to OAuth flow
 type OAuthAdapter struct {
-	client       RTMClientInterface
+	client       AuthClient
 	sessions     map[string]*AuthSession
 	sessionMutex sync.RWMutex
+	exchanges    map[string]*exchangeBudget // Frob exchanges left per session, guarded by sessionMutex
+	exchangeMu   sync.Mutex                 // Serializes frob exchanges on the shared client
+	// store keeps sessions, written through from sessions, so a user part
+	// way through authorizing can finish after a restart
+	store        kv.Store
+	sessionStore *kv.Bucket[AuthSession]
+	refresh      *auth.RefreshTokens // Lets clients renew without the browser flow
+	redeemed     *auth.RedeemedCodes // Codes already exchanged for tokens
+	clients      *auth.Clients       // Registered OAuth clients
+	accessTTL    time.Duration       // How long an issued token is accepted, 0 for no limit
+	accessTokens *issuedTokens
+	revoked      *kv.Bucket[time.Time]
+	validations  *ValidationCache // RTM's recent answers for bearer tokens
 	serverURL    string
+	metadata     auth.ServerMetadata // Discovery metadata, endpoints and issuer
+	guard        *auth.AttemptGuard  // Limits authorization code guessing
+	requirePKCE  bool                // Refuse authorization requests without an S256 challenge
+	sessionTTL   time.Duration       // How long an unfinished session stays usable
+	audit        *auth.Auditor       // Records authentication events, nil logs only
+	done         chan struct{}       // For stopping cleanup goroutine
+}
+
+// exchangeBudget is a token bucket of frob exchanges for one session
+type exchangeBudget struct {
+	tokens float64
+	last   time.Time
 }
 
 // AuthSession tracks RTM auth progress with OAuth parameters
@@ -40,28 +105,90 @@ type AuthSession struct {
 	CodeChallengeMethod string // PKCE method (S256)
 	CodeVerifier        string // PKCE code verifier
 	Resource            string // MCP resource parameter
+	Scope               string // Scopes granted, space separated
 }
 
 // NewOAuthAdapter creates RTM OAuth adapter
 func NewOAuthAdapter(apiKey, secret, serverURL string) *OAuthAdapter {
-	return &OAuthAdapter{
-		client:    NewClient(apiKey, secret),
-		sessions:  make(map[string]*AuthSession),
-		serverURL: serverURL,
+	store := auth.OpenOAuthStore()
+	sessionTTL := SessionTTLFromEnv()
+	accessTTL := auth.AccessTokenTTLFromEnv(0)
+	a := &OAuthAdapter{
+		client:       NewClient(apiKey, secret),
+		sessions:     make(map[string]*AuthSession),
+		exchanges:    make(map[string]*exchangeBudget),
+		store:        store,
+		sessionStore: kv.NewBucket[AuthSession](store, authSessionBucket),
+		refresh:      auth.NewRefreshTokens(store),
+		redeemed:     auth.NewRedeemedCodes(store, 2*sessionTTL), // As long as stored sessions
+		clients:      auth.NewClients(store, serverURL, clientIDPrefix),
+		accessTTL:    accessTTL,
+		accessTokens: newIssuedTokens(auth.CreateTokenStore(store, accessTTL)),
+		revoked:      kv.NewBucket[time.Time](store, revokedTokenBucket),
+		validations:  NewValidationCache(ValidationTTLFromEnv()),
+		serverURL:    serverURL,
+		metadata:     rtmServerMetadata(serverURL),
+		guard:        auth.NewAttemptGuard(auth.GuardLimitsFromEnv()),
+		requirePKCE:  auth.RequirePKCEFromEnv(),
+		sessionTTL:   sessionTTL,
+		done:         make(chan struct{}),
 	}
+	// Start cleanup goroutine
+	go a.cleanupSessions()
+	return a
+}
+
+// rtmServerMetadata describes the adapter for OAuth discovery
+func rtmServerMetadata(serverURL string) auth.ServerMetadata {
+	metadata := auth.NewServerMetadata(serverURL, []string{ScopeRead, ScopeWrite})
+	metadata.ResourceIndicators = true
+	return metadata
+}
+
+// HandleProtectedResourceMetadata handles /.well-known/oauth-protected-resource
+func (a *OAuthAdapter) HandleProtectedResourceMetadata(w http.ResponseWriter, r *http.Request) {
+	a.metadata.HandleProtectedResource(w, r)
+}
+
+// HandleAuthServerMetadata handles /.well-known/oauth-authorization-server
+func (a *OAuthAdapter) HandleAuthServerMetadata(w http.ResponseWriter, r *http.Request) {
+	metadata := a.metadata
+	metadata.RequirePKCE = a.requirePKCE
+	metadata.HandleAuthorizationServer(w, r)
+}
+
+// SessionTTLFromEnv reads RTM_AUTH_SESSION_TTL, how long an authorization
+// session may wait for the user to finish. RTM frobs last about an hour,
+// so longer values only delay the error.
+func SessionTTLFromEnv() time.Duration {
+	ttl := config.DurationFromEnv("RTM_AUTH_SESSION_TTL", defaultSessionTTL)
+	if ttl <= 0 {
+		log.Printf("RTM_AUTH_SESSION_TTL must be positive, using %s", defaultSessionTTL)
+		return defaultSessionTTL
+	}
+	return ttl
 }
 
 // HandleAuthorize implements OAuth authorize endpoint
 func (a *OAuthAdapter) HandleAuthorize(w http.ResponseWriter, r *http.Request) {
 	// For GET requests, always show the form - RTM requires user interaction
 	if r.Method == "GET" {
+		query := r.URL.Query()
+		if cerr := a.clients.CheckAuthorize(query.Get("client_id"), query.Get("redirect_uri")); cerr != nil {
+			auth.WriteError(w, r, cerr.Status, cerr.Code, cerr.Description, "")
+			return
+		}
+		if cerr := auth.CheckPKCEChallenge(query.Get("code_challenge"), query.Get("code_challenge_method"), a.requirePKCE); cerr != nil {
+			auth.WriteError(w, r, cerr.Status, cerr.Code, cerr.Description, "")
+			return
+		}
 		a.showAuthForm(w, r)
 		return
 	}
 
 	// POST - process authorization (for manual form submission)
 	if err := r.ParseForm(); err != nil {
-		http.Error(w, "Invalid request", http.StatusBadRequest)
+		auth.WriteError(w, r, http.StatusBadRequest, "invalid_request", "The form could not be read.", auth.RetryURL(r))
 		return
 	}
 
@@ -76,20 +203,38 @@ func (a *OAuthAdapter) HandleAuthorize(w http.ResponseWriter, r *http.Request) {
 	csrfState := r.FormValue("csrf_state")
 	if csrfState == "" {
 		log.Printf("RTM: Missing CSRF token in form")
-		http.Error(w, "Missing CSRF token in form", http.StatusBadRequest)
+		a.audit.Record(r, auth.AuditAuthorize, auth.AuditFailure, clientID, "missing CSRF token")
+		auth.WriteError(w, r, http.StatusBadRequest, "invalid_request",
+			"The form was submitted without its security token. Try again to get a fresh form.", auth.RetryURL(r))
 		return
 	}
 
 	csrfCookie, err := r.Cookie("csrf_token")
 	if err != nil || csrfCookie.Value == "" {
 		log.Printf("RTM: CSRF cookie missing, error: %v", err)
-		http.Error(w, "Missing CSRF cookie - please disable popup blocker and try again without refreshing", http.StatusBadRequest)
+		a.audit.Record(r, auth.AuditAuthorize, auth.AuditFailure, clientID, "missing CSRF cookie")
+		auth.WriteError(w, r, http.StatusBadRequest, "invalid_request",
+			"Your browser did not send the session cookie for this form. Disable any popup blocker, make sure cookies are enabled for this site, then try again without refreshing.", auth.RetryURL(r))
 		return
 	}
 
 	log.Printf("RTM: CSRF validation - form: %s, cookie: %s", csrfState, csrfCookie.Value)
 	if csrfState != csrfCookie.Value {
-		http.Error(w, "Invalid CSRF token", http.StatusBadRequest)
+		a.audit.Record(r, auth.AuditAuthorize, auth.AuditFailure, clientID, "CSRF token mismatch")
+		auth.WriteError(w, r, http.StatusBadRequest, "invalid_request",
+			"This form has expired or was opened in another tab. Try again to get a fresh form.", auth.RetryURL(r))
+		return
+	}
+
+	// The form's hidden fields can be edited, so check them again
+	if cerr := a.clients.CheckAuthorize(clientID, redirectURI); cerr != nil {
+		a.audit.Record(r, auth.AuditAuthorize, auth.AuditFailure, clientID, cerr.Code)
+		auth.WriteError(w, r, cerr.Status, cerr.Code, cerr.Description, "")
+		return
+	}
+	if cerr := auth.CheckPKCEChallenge(codeChallenge, codeChallengeMethod, a.requirePKCE); cerr != nil {
+		a.audit.Record(r, auth.AuditAuthorize, auth.AuditFailure, clientID, cerr.Code)
+		auth.WriteError(w, r, cerr.Status, cerr.Code, cerr.Description, "")
 		return
 	}
 
@@ -97,24 +242,19 @@ func (a *OAuthAdapter) HandleAuthorize(w http.ResponseWriter, r *http.Request) {
 	frob, err := a.client.GetFrob()
 	if err != nil {
 		log.Printf("RTM: Failed to get frob: %v", err)
-		a.showError(w, "Failed to start RTM authentication")
+		auth.WriteError(w, r, http.StatusBadGateway, "temporarily_unavailable",
+			"Remember The Milk did not respond when starting authentication. Wait a moment, then try again.", auth.RetryURL(r))
 		return
 	}
 
 	// Step 2: Create fake OAuth code
 	code := uuid.New().String()
 
-	// Validate PKCE if provided
-	if codeChallenge != "" {
-		if codeChallengeMethod != "S256" {
-			http.Error(w, "Unsupported code_challenge_method. Only S256 is supported.", http.StatusBadRequest)
-			return
-		}
-	}
-
 	// Validate resource parameter for MCP compliance
 	if resource != "" && !strings.HasPrefix(resource, a.serverURL+"/mcp") {
-		http.Error(w, "Invalid resource parameter", http.StatusBadRequest)
+		a.audit.Record(r, auth.AuditAuthorize, auth.AuditFailure, clientID, "invalid_target")
+		auth.WriteError(w, r, http.StatusBadRequest, "invalid_target",
+			fmt.Sprintf("The resource %q is not served here; expected %s/mcp.", resource, a.serverURL), "")
 		return
 	}
 
@@ -129,11 +269,10 @@ func (a *OAuthAdapter) HandleAuthorize(w http.ResponseWriter, r *http.Request) {
 		CodeChallenge:       codeChallenge,
 		CodeChallengeMethod: codeChallengeMethod,
 		Resource:            resource,
+		Scope:               grantedScope(r.FormValue("scope")),
 	}
 
-	a.sessionMutex.Lock()
-	a.sessions[code] = session
-	a.sessionMutex.Unlock()
+	a.saveSession(session)
 
 	// Step 4: Build RTM auth URL with frob
 	rtmParams := map[string]string{
@@ -143,7 +282,8 @@ func (a *OAuthAdapter) HandleAuthorize(w http.ResponseWriter, r *http.Request) {
 	}
 	sig := a.client.Sign(rtmParams)
 
-	rtmURL := fmt.Sprintf("https://www.rememberthemilk.com/services/auth/?api_key=%s&perms=delete&frob=%s&api_sig=%s",
+	rtmURL := fmt.Sprintf("%s?api_key=%s&perms=delete&frob=%s&api_sig=%s",
+		a.authEndpoint(),
 		url.QueryEscape(a.client.GetAPIKey()),
 		url.QueryEscape(frob),
 		url.QueryEscape(sig))
@@ -167,18 +307,24 @@ func (a *OAuthAdapter) HandleCallback(w http.ResponseWriter, r *http.Request) {
 	log.Printf("RTM: Callback hit for code %s from %s", code, r.RemoteAddr)
 
 	if code == "" {
-		http.Error(w, "Missing code parameter", http.StatusBadRequest)
+		auth.WriteError(w, r, http.StatusBadRequest, "invalid_request", "The link is missing its authorization code.", "")
 		return
 	}
 
 	// Look up session to get redirect URI
-	a.sessionMutex.RLock()
-	session, exists := a.sessions[code]
-	a.sessionMutex.RUnlock()
+	session, expired := a.lookupSession(code)
+	if expired {
+		log.Printf("RTM: Expired code %s in callback", code)
+		a.audit.Record(r, auth.AuditAuthorize, auth.AuditFailure, session.ClientID, "expired session")
+		auth.WriteError(w, r, http.StatusBadRequest, "invalid_grant", a.sessionExpiredMessage(), a.restartURL(session))
+		return
+	}
 
-	if !exists {
+	if session == nil {
 		log.Printf("RTM: Invalid code %s in callback", code)
-		http.Error(w, "Invalid code", http.StatusBadRequest)
+		a.audit.Record(r, auth.AuditAuthorize, auth.AuditFailure, "", "unknown code")
+		auth.WriteError(w, r, http.StatusBadRequest, "invalid_grant",
+			"This authorization link has expired or was already used.", "")
 		return
 	}
 
@@ -186,26 +332,30 @@ func (a *OAuthAdapter) HandleCallback(w http.ResponseWriter, r *http.Request) {
 	if session.Token == "" {
 		log.Printf("RTM: Callback hit but no token for code %s - trying immediate exchange", code)
 		// Try one more time to get the token
-		if err := a.client.GetToken(session.Frob); err == nil {
+		if token, err := a.exchangeFrob(session.Frob); err == nil {
 			a.sessionMutex.Lock()
-			session.Token = a.client.GetAuthToken()
+			session.Token = token
 			a.sessionMutex.Unlock()
+			a.saveSession(session)
 			log.Printf("RTM: Late token exchange successful for code %s", code)
 		} else {
 			log.Printf("RTM: Late token exchange failed: %v", err)
-			http.Error(w, "Authorization not completed. Please try again.", http.StatusBadRequest)
+			auth.WriteError(w, r, http.StatusBadRequest, "authorization_pending",
+				"Authorization not completed. Click \"OK, I'll allow it\" on the Remember The Milk page, then try again.", auth.RetryURL(r))
 			return
 		}
 	}
 
 	log.Printf("RTM: Auth verified, redirecting to %s with code=%s state=%s",
 		session.RedirectURI, code, session.State)
+	a.audit.Record(r, auth.AuditAuthorize, auth.AuditSuccess, session.ClientID, "")
 
 	// Redirect back to original redirect_uri with our code
 	u, err := url.Parse(session.RedirectURI)
 	if err != nil {
 		log.Printf("RTM: Invalid redirect URI: %v", err)
-		http.Error(w, "Invalid redirect URI", http.StatusInternalServerError)
+		auth.WriteError(w, r, http.StatusInternalServerError, "server_error",
+			"The client's redirect address is invalid, so you cannot be sent back to it.", "")
 		return
 	}
 	q := u.Query()
@@ -221,39 +371,91 @@ func (a *OAuthAdapter) HandleCallback(w http.ResponseWriter, r *http.Request) {
 
 // HandleToken implements OAuth token endpoint
 func (a *OAuthAdapter) HandleToken(w http.ResponseWriter, r *http.Request) {
+	ip := auth.ClientIP(r)
+	if wait := a.guard.Throttle("token", ip); wait > 0 {
+		a.guard.RejectThrottled(w, r, wait)
+		return
+	}
 	if err := r.ParseForm(); err != nil {
-		http.Error(w, "Invalid request", http.StatusBadRequest)
+		a.sendTokenError(w, "invalid_request", "Request body is not a valid form")
+		return
+	}
+
+	clientID, cerr := a.clients.Authenticate(r)
+	if cerr != nil {
+		a.guard.Failure("client", ip, "")
+		auth.WriteClientError(w, r, cerr)
+		return
+	}
+
+	if r.FormValue("grant_type") == "refresh_token" {
+		a.handleRefreshToken(w, r, clientID)
 		return
 	}
 
 	code := r.FormValue("code")
 	codeVerifier := r.FormValue("code_verifier")
+	if wait := a.guard.Check(ip, code); wait > 0 {
+		a.guard.Reject(w, r, wait)
+		return
+	}
 
 	if code == "" {
+		a.guard.Failure("token", ip, "")
 		a.sendTokenError(w, "invalid_request", "Missing code parameter")
 		return
 	}
 
+	// A code presented again after its exchange may have leaked
+	if replayed, err := a.redeemed.Redeemed(code); err != nil {
+		log.Printf("RTM: Failed to read code redemptions: %v", err)
+	} else if replayed {
+		a.refuseReplay(w, r, ip, code)
+		return
+	}
+
 	// Look up session
-	a.sessionMutex.RLock()
-	session, exists := a.sessions[code]
-	a.sessionMutex.RUnlock()
+	session, expired := a.lookupSession(code)
+	if expired {
+		a.sendTokenError(w, "invalid_grant", "Authorization code expired; start the authorization flow again")
+		return
+	}
 
-	if !exists {
+	if session == nil {
+		a.guard.Failure("token", ip, code)
 		a.sendTokenError(w, "invalid_grant", "Invalid authorization code")
 		return
 	}
 
+	// The code only works for the client it was issued to, and when the
+	// redirect_uri is repeated it must be the one the code was sent to
+	if !a.clients.MayRedeem(session.ClientID, clientID) {
+		a.guard.Failure("token", ip, code)
+		a.sendTokenError(w, "invalid_grant", "Authorization code was issued to another client")
+		return
+	}
+	if redirectURI := r.FormValue("redirect_uri"); redirectURI != "" && redirectURI != session.RedirectURI {
+		a.guard.Failure("token", ip, code)
+		a.sendTokenError(w, "invalid_grant", "redirect_uri does not match the authorization request")
+		return
+	}
+
 	// Validate PKCE if challenge was provided
 	if session.CodeChallenge != "" {
 		if codeVerifier == "" {
+			a.guard.Failure("token", ip, code)
 			a.sendTokenError(w, "invalid_request", "Missing code_verifier for PKCE")
 			return
 		}
 		if !a.validatePKCE(session.CodeChallenge, codeVerifier) {
+			a.guard.Failure("token", ip, code)
 			a.sendTokenError(w, "invalid_grant", "Invalid code_verifier")
 			return
 		}
+	} else if a.requirePKCE {
+		// Started before PKCE was required
+		a.sendTokenError(w, "invalid_grant", "Authorization code was issued without PKCE, which this server requires")
+		return
 	}
 
 	log.Printf("RTM DEBUG: Token request for code %s, session.Token='%s'", code, session.Token)
@@ -261,14 +463,20 @@ func (a *OAuthAdapter) HandleToken(w http.ResponseWriter, r *http.Request) {
 	// Check if we already have token (from polling)
 	if session.Token != "" {
 		log.Printf("RTM DEBUG: Token ready, returning success")
-		a.sendTokenSuccess(w, session.Token)
-		a.removeSession(code)
+		a.redeem(w, r, ip, session)
+		return
+	}
+
+	// Clients polling faster than RTM is asked are told to keep waiting
+	if !a.mayExchange(code) {
+		a.sendTokenError(w, "authorization_pending", "User has not completed authorization")
 		return
 	}
 
 	// Try to exchange frob for token
 	log.Printf("RTM DEBUG: Token not ready, trying immediate exchange")
-	if err := a.client.GetToken(session.Frob); err != nil {
+	token, err := a.exchangeFrob(session.Frob)
+	if err != nil {
 		log.Printf("RTM DEBUG: Immediate exchange failed: %v", err)
 		// User might not have authorized yet
 		a.sendTokenError(w, "authorization_pending", "User has not completed authorization")
@@ -277,9 +485,165 @@ func (a *OAuthAdapter) HandleToken(w http.ResponseWriter, r *http.Request) {
 
 	// Success!
 	log.Printf("RTM DEBUG: Immediate exchange succeeded")
-	session.Token = a.client.GetAuthToken()
-	a.sendTokenSuccess(w, session.Token)
-	a.removeSession(code)
+	a.sessionMutex.Lock()
+	session.Token = token
+	a.sessionMutex.Unlock()
+	a.redeem(w, r, ip, session)
+}
+
+// redeem answers with the token for session's code, unless another request
+// with the code got there first, and forgets the session
+func (a *OAuthAdapter) redeem(w http.ResponseWriter, r *http.Request, ip string, session *AuthSession) {
+	claimed, err := a.redeemed.Claim(session.Code)
+	if err != nil {
+		log.Printf("RTM: Failed to claim authorization code: %v", err)
+		auth.WriteJSONError(w, r, http.StatusInternalServerError, "server_error", "The code could not be redeemed. Try again.", "")
+		return
+	}
+	if !claimed {
+		a.refuseReplay(w, r, ip, session.Code)
+		return
+	}
+
+	a.guard.Success(ip, session.Code)
+	a.sendTokenSuccess(w, r, session.Token, session.ClientID, session.Scope, auth.TokenKey(session.Code))
+	a.removeSession(session.Code)
+}
+
+// refuseReplay answers a code that was already exchanged, revoking the
+// tokens issued from it since the code may have leaked (RFC 6749 4.1.2)
+func (a *OAuthAdapter) refuseReplay(w http.ResponseWriter, r *http.Request, ip, code string) {
+	log.Printf("RTM: Authorization code replayed")
+	a.guard.Replay("token", ip, code)
+
+	tokens, err := a.redeemed.TakeTokens(code)
+	if err != nil {
+		log.Printf("RTM: Failed to read tokens issued from replayed code: %v", err)
+	}
+	revoked := 0
+	for _, token := range tokens {
+		// Refresh tokens are dropped; the RTM token itself is refused
+		if wasRefresh, err := a.refresh.Revoke(token); err != nil {
+			log.Printf("RTM: Failed to revoke refresh token: %v", err)
+		} else if wasRefresh {
+			continue
+		}
+		if err := a.revokeToken(token); err != nil {
+			log.Printf("RTM: Failed to revoke token: %v", err)
+			continue
+		}
+		revoked++
+	}
+	if revoked > 0 {
+		log.Printf("RTM: Revoked the token issued from the replayed code")
+		a.audit.Record(r, auth.AuditRevoked, auth.AuditSuccess, "", "code_replay")
+	}
+	a.sendTokenError(w, "invalid_grant", "Authorization code has already been used")
+}
+
+// mayExchange takes one frob exchange from the session's allowance,
+// reporting false when it has none left
+func (a *OAuthAdapter) mayExchange(code string) bool {
+	a.sessionMutex.Lock()
+	defer a.sessionMutex.Unlock()
+	now := time.Now()
+	budget, ok := a.exchanges[code]
+	if !ok {
+		budget = &exchangeBudget{tokens: exchangeBurst, last: now}
+		a.exchanges[code] = budget
+	}
+	refill := float64(now.Sub(budget.last)) / float64(exchangeInterval)
+	budget.tokens = math.Min(exchangeBurst, budget.tokens+refill)
+	budget.last = now
+	if budget.tokens < 1 {
+		return false
+	}
+	budget.tokens--
+	return true
+}
+
+// handleRefreshToken answers grant_type=refresh_token. The RTM token behind
+// the refresh token is returned again with a replacement refresh token; RTM
+// tokens don't expire, so there is nothing to renew upstream.
+func (a *OAuthAdapter) handleRefreshToken(w http.ResponseWriter, r *http.Request, clientID string) {
+	refreshToken := r.FormValue("refresh_token")
+	ip := auth.ClientIP(r)
+	if wait := a.guard.Check(ip, refreshToken); wait > 0 {
+		a.guard.Reject(w, r, wait)
+		return
+	}
+
+	if refreshToken == "" {
+		a.guard.Failure("refresh", ip, "")
+		a.sendTokenError(w, "invalid_request", "Missing refresh_token parameter")
+		return
+	}
+
+	grant, ok, err := a.refresh.Redeem(refreshToken)
+	if err != nil {
+		log.Printf("RTM: Failed to read refresh token: %v", err)
+		a.sendTokenError(w, "server_error", "The refresh token could not be checked. Try again.")
+		return
+	}
+	if !ok {
+		a.guard.Failure("refresh", ip, refreshToken)
+		a.sendTokenError(w, "invalid_grant", "Invalid, expired or already used refresh token")
+		return
+	}
+
+	if a.isRevoked(grant.Credential) {
+		a.sendTokenError(w, "invalid_grant", "The connection was revoked; authorize again")
+		return
+	}
+
+	// A token issued to one client is not usable by another
+	if !a.clients.MayRedeem(grant.ClientID, clientID) {
+		a.guard.Failure("refresh", ip, refreshToken)
+		a.sendTokenError(w, "invalid_grant", "Refresh token was issued to another client")
+		return
+	}
+
+	// A client may renew with fewer scopes, never more
+	scope := grantedScope(grant.Scope)
+	if requested := r.FormValue("scope"); requested != "" {
+		granted := auth.ParseScope(scope)
+		for _, s := range auth.ParseScope(requested) {
+			if !auth.HasScope(granted, s) {
+				a.sendTokenError(w, "invalid_scope", fmt.Sprintf("Scope %s was not granted to this refresh token", s))
+				return
+			}
+		}
+		scope = strings.Join(auth.ParseScope(requested), " ")
+	}
+
+	a.guard.Success(ip, refreshToken)
+	a.sendTokenSuccess(w, r, grant.Credential, grant.ClientID, scope, grant.CodeKey)
+}
+
+// grantedScope is the scope granted for a requested one: the known scopes
+// asked for, or all of them when none are. Earlier clients sent no scope and
+// expect full access.
+func grantedScope(requested string) string {
+	var granted []string
+	for _, s := range auth.ParseScope(requested) {
+		if (s == ScopeRead || s == ScopeWrite) && !auth.HasScope(granted, s) {
+			granted = append(granted, s)
+		}
+	}
+	if len(granted) == 0 {
+		return defaultScope
+	}
+	return strings.Join(granted, " ")
+}
+
+// TokenScopes returns the scopes token was granted, or nil when the adapter
+// has no record of them, such as for tokens issued before scopes were kept
+func (a *OAuthAdapter) TokenScopes(token string) []string {
+	issued, ok := a.accessTokens.Get(token)
+	if !ok || issued.Scope == "" {
+		return nil
+	}
+	return auth.ParseScope(issued.Scope)
 }
 
 // Helper methods
@@ -405,9 +769,15 @@ func (a *OAuthAdapter) showIntermediatePage(w http.ResponseWriter, rtmURL, code,
                         }, 1000);
                     } else if (data.error && !data.pending) {
                         clearInterval(checkInterval);
-                        updateStatus('error', data.error);
-                        document.getElementById('checkBtn').disabled = false;
-                        document.getElementById('checkBtn').textContent = 'Try Again';
+                        updateStatus('error', data.error_description || data.error);
+                        const checkBtn = document.getElementById('checkBtn');
+                        checkBtn.disabled = false;
+                        if (data.retry_url) {
+                            checkBtn.textContent = 'Start Again';
+                            checkBtn.onclick = () => { window.location.href = data.retry_url; };
+                        } else {
+                            checkBtn.textContent = 'Try Again';
+                        }
                     } else if (data.pending) {
                         // Still waiting - update message periodically
                         updateStatus('checking', 'Still waiting... Make sure you clicked "Allow" on the RTM page!');
@@ -482,39 +852,34 @@ func (a *OAuthAdapter) showIntermediatePage(w http.ResponseWriter, rtmURL, code,
 	}
 }
 
-func (a *OAuthAdapter) showError(w http.ResponseWriter, message string) {
-	html := fmt.Sprintf(`
-<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="UTF-8">
-    <title>Authorization Error</title>
-    <style>
-        body { font-family: Arial, sans-serif; max-width: 600px; margin: 50px auto; padding: 20px; }
-        .error { border: 1px solid #f5c6cb; background: #f8d7da; padding: 20px; border-radius: 4px; color: #721c24; }
-    </style>
-</head>
-<body>
-    <div class="error">
-        <h2>Authorization Error</h2>
-        <p>%s</p>
-    </div>
-</body>
-</html>`, message)
+func (a *OAuthAdapter) sendTokenSuccess(w http.ResponseWriter, r *http.Request, token, clientID, scope, codeKey string) {
+	// Issuing the token again, after authorizing again, undoes a revocation
+	// and any cached refusal
+	key := auth.TokenKey(token)
+	if err := a.revoked.Delete(key); err != nil {
+		log.Printf("RTM: Failed to clear token revocation: %v", err)
+	}
+	a.validations.Invalidate(token)
+	a.accessTokens.Put(token, scope, clientID)
 
-	w.Header().Set("Content-Type", "text/html")
-	w.WriteHeader(http.StatusOK)
-	if _, err := fmt.Fprint(w, html); err != nil {
-		log.Printf("Failed to write error response: %v", err)
+	refreshToken, err := a.refresh.Issue(auth.RefreshGrant{Credential: token, ClientID: clientID, Scope: scope, CodeKey: codeKey})
+	if err != nil {
+		log.Printf("RTM: Failed to issue refresh token: %v", err)
+	}
+	if codeKey != "" {
+		if err := a.redeemed.RecordTokens(codeKey, token, refreshToken); err != nil {
+			log.Printf("RTM: Failed to record tokens issued from code: %v", err)
+		}
 	}
-}
 
-func (a *OAuthAdapter) sendTokenSuccess(w http.ResponseWriter, token string) {
 	response := auth.TokenResponse{
-		AccessToken: token,
-		TokenType:   "Bearer",
-		ExpiresIn:   0, // RTM tokens don't expire
+		AccessToken:  token,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(a.accessTTL.Seconds()), // RTM tokens don't expire unless a lifetime is set
+		RefreshToken: refreshToken,
+		Scope:        scope,
 	}
+	a.audit.Record(r, auth.AuditTokenIssued, auth.AuditSuccess, clientID, r.FormValue("grant_type"))
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
@@ -538,29 +903,163 @@ func (a *OAuthAdapter) sendTokenError(w http.ResponseWriter, error, description
 func (a *OAuthAdapter) removeSession(code string) {
 	a.sessionMutex.Lock()
 	delete(a.sessions, code)
+	delete(a.exchanges, code)
 	a.sessionMutex.Unlock()
+	if err := a.sessionStore.Delete(code); err != nil {
+		log.Printf("RTM: Failed to remove stored session: %v", err)
+	}
+}
+
+// saveSession records a session in memory and the store. Stored sessions
+// outlive the TTL by as long again, so a late return is still told the
+// session expired rather than that it never existed.
+func (a *OAuthAdapter) saveSession(session *AuthSession) {
+	a.sessionMutex.Lock()
+	a.sessions[session.Code] = session
+	stored := *session
+	a.sessionMutex.Unlock()
+	if err := a.sessionStore.Put(session.Code, stored, 2*a.sessionTTL); err != nil {
+		log.Printf("RTM: Failed to persist session: %v", err)
+	}
+}
+
+// lookupSession returns the session for code, or nil when there is none.
+// A session older than the TTL is removed and reported as expired, since
+// its frob can no longer be exchanged.
+func (a *OAuthAdapter) lookupSession(code string) (*AuthSession, bool) {
+	a.sessionMutex.RLock()
+	session, exists := a.sessions[code]
+	a.sessionMutex.RUnlock()
+
+	if !exists {
+		stored, ok, err := a.sessionStore.Get(code)
+		if err != nil || !ok {
+			return nil, false
+		}
+		// Started before a restart
+		session = &stored
+		a.sessionMutex.Lock()
+		a.sessions[code] = session
+		a.sessionMutex.Unlock()
+	}
+	if time.Since(session.CreatedAt) > a.sessionTTL {
+		a.removeSession(code)
+		return session, true
+	}
+	return session, false
+}
+
+// cleanupSessions removes abandoned sessions periodically
+func (a *OAuthAdapter) cleanupSessions() {
+	ticker := time.NewTicker(sessionCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if removed := a.removeExpiredSessions(); removed > 0 {
+				log.Printf("RTM: Removed %d expired auth sessions", removed)
+			}
+		case <-a.done:
+			return
+		}
+	}
+}
+
+// removeExpiredSessions drops sessions older than the TTL and returns how
+// many were removed
+func (a *OAuthAdapter) removeExpiredSessions() int {
+	a.sessionMutex.Lock()
+	defer a.sessionMutex.Unlock()
+
+	removed := 0
+	for code, session := range a.sessions {
+		if time.Since(session.CreatedAt) > a.sessionTTL {
+			delete(a.sessions, code)
+			delete(a.exchanges, code)
+			removed++
+		}
+	}
+	// The store drops its copies itself once they outlive their TTL
+	return removed
+}
+
+// sessionExpiredMessage tells the user why their session ended and how to
+// start over
+func (a *OAuthAdapter) sessionExpiredMessage() string {
+	return fmt.Sprintf("This authorization session expired after %.0f minutes without being completed. "+
+		"Start again to get a fresh Remember The Milk link.", a.sessionTTL.Minutes())
+}
+
+// restartURL links to a new authorization for the same client, so an
+// expired session can be restarted without going back to the client
+func (a *OAuthAdapter) restartURL(session *AuthSession) string {
+	params := url.Values{}
+	params.Set("response_type", "code")
+	for name, value := range map[string]string{
+		"client_id":             session.ClientID,
+		"redirect_uri":          session.RedirectURI,
+		"state":                 session.State,
+		"code_challenge":        session.CodeChallenge,
+		"code_challenge_method": session.CodeChallengeMethod,
+		"resource":              session.Resource,
+	} {
+		if value != "" {
+			params.Set(name, value)
+		}
+	}
+	return a.serverURL + "/oauth/authorize?" + params.Encode()
+}
+
+// exchangeFrob trades frob for the RTM token of the user who approved it.
+// The client keeps the token it gets and is shared by every sign-in, so
+// exchanges run one at a time; otherwise two users finishing at once could
+// each be handed the other's token.
+func (a *OAuthAdapter) exchangeFrob(frob string) (string, error) {
+	a.exchangeMu.Lock()
+	defer a.exchangeMu.Unlock()
+
+	if err := a.client.GetToken(frob); err != nil {
+		return "", err
+	}
+	return a.client.GetAuthToken(), nil
 }
 
 // HandleCheckAuth checks if frob has been authorized
 func (a *OAuthAdapter) HandleCheckAuth(w http.ResponseWriter, r *http.Request) {
 	code := r.URL.Query().Get("code")
+	ip := auth.ClientIP(r)
+	if wait := a.guard.Throttle("check-auth", ip); wait > 0 {
+		a.guard.RejectThrottled(w, r, wait)
+		return
+	}
+	if wait := a.guard.Check(ip, code); wait > 0 {
+		a.guard.Reject(w, r, wait)
+		return
+	}
 	if code == "" {
-		http.Error(w, "Missing code parameter", http.StatusBadRequest)
+		a.guard.Failure("check-auth", ip, "")
+		auth.WriteJSONError(w, r, http.StatusBadRequest, "invalid_request", "Missing code parameter", "")
 		return
 	}
 
 	// Look up session
-	a.sessionMutex.RLock()
-	session, exists := a.sessions[code]
-	a.sessionMutex.RUnlock()
+	session, expired := a.lookupSession(code)
+	if expired {
+		auth.WriteJSONError(w, r, http.StatusBadRequest, "invalid_grant", a.sessionExpiredMessage(), a.restartURL(session))
+		return
+	}
 
-	if !exists {
-		http.Error(w, "Invalid code", http.StatusBadRequest)
+	if session == nil {
+		a.guard.Failure("check-auth", ip, code)
+		auth.WriteJSONError(w, r, http.StatusBadRequest, "invalid_grant",
+			"This authorization session has expired. Close this window and connect again from Claude.", "")
 		return
 	}
 
 	// If we already have a token, return success immediately
 	if session.Token != "" {
+		a.guard.Success(ip, code)
 		w.Header().Set("Content-Type", "application/json")
 		if writeErr := json.NewEncoder(w).Encode(map[string]interface{}{
 			"authorized": true,
@@ -570,15 +1069,29 @@ func (a *OAuthAdapter) HandleCheckAuth(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Polling faster than RTM is asked is told to keep waiting
+	if !a.mayExchange(code) {
+		w.Header().Set("Content-Type", "application/json")
+		if writeErr := json.NewEncoder(w).Encode(map[string]interface{}{
+			"authorized": false,
+			"pending":    true,
+		}); writeErr != nil {
+			log.Printf("Failed to write check auth pending response: %v", writeErr)
+		}
+		return
+	}
+
 	// Try to exchange frob for token
-	err := a.client.GetToken(session.Frob)
+	token, err := a.exchangeFrob(session.Frob)
 	if err == nil {
 		// Success! Store token and respond
 		a.sessionMutex.Lock()
-		session.Token = a.client.GetAuthToken()
+		session.Token = token
 		a.sessionMutex.Unlock()
+		a.saveSession(session)
 
 		log.Printf("RTM: Successfully exchanged frob for token for code %s", code)
+		a.guard.Success(ip, code)
 
 		w.Header().Set("Content-Type", "application/json")
 		if writeErr := json.NewEncoder(w).Encode(map[string]interface{}{
@@ -592,7 +1105,7 @@ func (a *OAuthAdapter) HandleCheckAuth(w http.ResponseWriter, r *http.Request) {
 	// Check if it's a "not authorized" error vs other errors
 	if rtmErr, ok := err.(*RTMError); ok {
 		log.Printf("RTM: Check auth failed with code %d: %s", rtmErr.Code, rtmErr.Msg)
-		if rtmErr.Code == 101 {
+		if errors.Is(rtmErr, ErrNotAuthorized) {
 			// User hasn't authorized yet, return pending
 			w.Header().Set("Content-Type", "application/json")
 			if writeErr := json.NewEncoder(w).Encode(map[string]interface{}{
@@ -611,6 +1124,7 @@ func (a *OAuthAdapter) HandleCheckAuth(w http.ResponseWriter, r *http.Request) {
 	if writeErr := json.NewEncoder(w).Encode(map[string]interface{}{
 		"authorized": false,
 		"error":      fmt.Sprintf("Authorization failed: %v", err),
+		"retry_url":  a.restartURL(session),
 	}); writeErr != nil {
 		log.Printf("Failed to write check auth error response: %v", writeErr)
 	}
@@ -618,43 +1132,155 @@ func (a *OAuthAdapter) HandleCheckAuth(w http.ResponseWriter, r *http.Request) {
 
 // validatePKCE validates PKCE code_verifier against code_challenge
 func (a *OAuthAdapter) validatePKCE(codeChallenge, codeVerifier string) bool {
-	// Generate challenge from verifier using S256
-	h := sha256.Sum256([]byte(codeVerifier))
-	computedChallenge := base64.RawURLEncoding.EncodeToString(h[:])
-	return computedChallenge == codeChallenge
+	return auth.VerifyPKCE(codeChallenge, codeVerifier)
 }
 
-// HandleRegister implements Dynamic Client Registration (RFC 7591)
-func (a *OAuthAdapter) HandleRegister(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+// HandleRevoke implements token revocation (RFC 7009). Revoking a refresh
+// token also revokes the RTM token it renews, and revoking an RTM token
+// drops any session still holding it, so the integration is disconnected
+// either way. RTM has no call to invalidate its tokens, so revoked ones are
+// remembered and refused until the user authorizes again. Unknown tokens
+// are not an error.
+func (a *OAuthAdapter) HandleRevoke(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		auth.WriteJSONError(w, r, http.StatusMethodNotAllowed, "invalid_request", "Use POST to revoke a token", "")
+		return
+	}
+	if wait := a.guard.Throttle("revoke", auth.ClientIP(r)); wait > 0 {
+		a.guard.RejectThrottled(w, r, wait)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		a.sendTokenError(w, "invalid_request", "Request body is not a valid form")
+		return
+	}
+	token := r.FormValue("token")
+	if token == "" {
+		a.sendTokenError(w, "invalid_request", "Missing token parameter")
 		return
 	}
 
-	// Generate client credentials
-	clientID := "rtm_" + generateRandomString(16)
-	clientSecret := generateRandomString(32)
+	grant, wasRefresh, err := a.refresh.Redeem(token)
+	if err != nil {
+		log.Printf("RTM: Failed to revoke refresh token: %v", err)
+		auth.WriteJSONError(w, r, http.StatusServiceUnavailable, "temporarily_unavailable", "The token could not be revoked. Try again.", "")
+		return
+	}
+	if wasRefresh {
+		token = grant.Credential
+	} else if !a.knownToken(token) {
+		// Recording every string sent here would let anyone fill the store
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	clientID := grant.ClientID
+	if issued, ok := a.accessTokens.Get(token); ok && clientID == "" {
+		clientID = issued.ClientID
+	}
 
-	response := map[string]interface{}{
-		"client_id":                clientID,
-		"client_secret":            clientSecret,
-		"client_id_issued_at":      time.Now().Unix(),
-		"client_secret_expires_at": 0, // Never expires
+	if err := a.revokeToken(token); err != nil {
+		log.Printf("RTM: Failed to revoke token: %v", err)
+		auth.WriteJSONError(w, r, http.StatusServiceUnavailable, "temporarily_unavailable", "The token could not be revoked. Try again.", "")
+		return
 	}
+	log.Printf("RTM: Token revoked")
+	a.audit.Record(r, auth.AuditRevoked, auth.AuditSuccess, clientID, "")
+	w.WriteHeader(http.StatusOK)
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf("Failed to encode DCR response: %v", err)
+// knownToken reports whether token is an RTM token this adapter issued
+// and has not already revoked
+func (a *OAuthAdapter) knownToken(token string) bool {
+	if a.isRevoked(token) {
+		return false
+	}
+	if a.accessTTL > 0 {
+		_, ok := a.accessTokens.Get(token)
+		return ok
 	}
+	return a.rtmAccepts(token)
 }
 
-// generateRandomString creates a cryptographically secure random string
-func generateRandomString(length int) string {
-	b := make([]byte, length)
-	if _, err := rand.Read(b); err != nil {
-		panic(err)
+// revokeToken refuses token from now on and drops the sessions holding it
+func (a *OAuthAdapter) revokeToken(token string) error {
+	key := auth.TokenKey(token)
+	if err := a.revoked.Put(key, time.Now().UTC(), 0); err != nil {
+		return err
 	}
-	return base64.RawURLEncoding.EncodeToString(b)[:length]
+	a.accessTokens.Delete(token)
+	a.validations.Invalidate(token)
+
+	a.sessionMutex.RLock()
+	var codes []string
+	for code, session := range a.sessions {
+		if session.Token == token {
+			codes = append(codes, code)
+		}
+	}
+	a.sessionMutex.RUnlock()
+	for _, code := range codes {
+		a.removeSession(code)
+	}
+	return nil
+}
+
+// isRevoked reports whether token was revoked through HandleRevoke
+func (a *OAuthAdapter) isRevoked(token string) bool {
+	_, ok, err := a.revoked.Get(auth.TokenKey(token))
+	if err != nil {
+		log.Printf("RTM: Failed to read token revocations: %v", err)
+		return true
+	}
+	return ok
+}
+
+// HandleIntrospect implements token introspection (RFC 7662) for
+// registered confidential clients. A token is active while this adapter
+// would accept it as a bearer token, so the answer may come from RTM.
+func (a *OAuthAdapter) HandleIntrospect(w http.ResponseWriter, r *http.Request) {
+	token, ok := auth.IntrospectionToken(w, r, a.clients, a.guard)
+	if !ok {
+		return
+	}
+	if !a.ValidateBearer(token) {
+		auth.WriteIntrospection(w, auth.IntrospectionResponse{})
+		return
+	}
+	response := auth.IntrospectionResponse{
+		Active:    true,
+		TokenType: "Bearer",
+		Issuer:    a.metadata.Issuer,
+		Audience:  a.metadata.Resource,
+	}
+	if issued, ok := a.accessTokens.Get(token); ok {
+		response.Scope = issued.Scope
+		response.ClientID = issued.ClientID
+		response.IssuedAt = issued.IssuedAt.Unix()
+		if a.accessTTL > 0 {
+			response.ExpiresAt = issued.IssuedAt.Add(a.accessTTL).Unix()
+		}
+	}
+	auth.WriteIntrospection(w, response)
+}
+
+// HandleRegister implements Dynamic Client Registration (RFC 7591)
+func (a *OAuthAdapter) HandleRegister(w http.ResponseWriter, r *http.Request) {
+	if wait := a.guard.Throttle("register", auth.ClientIP(r)); wait > 0 {
+		a.guard.RejectThrottled(w, r, wait)
+		return
+	}
+	a.clients.HandleRegister(w, r)
+}
+
+// HandleClientConfiguration handles /oauth/register/{client_id}, where a
+// registered client reads, updates or deletes its registration (RFC 7592)
+func (a *OAuthAdapter) HandleClientConfiguration(w http.ResponseWriter, r *http.Request) {
+	a.clients.HandleConfiguration(w, r)
+}
+
+// Clients returns the adapter's registered OAuth clients
+func (a *OAuthAdapter) Clients() *auth.Clients {
+	return a.clients
 }
 
 // ValidateBearer checks if a bearer token is valid by testing it against RTM API
@@ -662,25 +1288,119 @@ func (a *OAuthAdapter) ValidateBearer(token string) bool {
 	if token == "" {
 		return false
 	}
+	if a.isRevoked(token) {
+		log.Printf("RTM DEBUG: Token was revoked")
+		return false
+	}
+	if a.accessTTL > 0 {
+		if _, ok := a.accessTokens.Get(token); !ok {
+			log.Printf("RTM DEBUG: Token expired")
+			return false
+		}
+	}
+
+	return a.rtmAccepts(token)
+}
+
+// rtmAccepts reports whether RTM accepts token, answering from the
+// validation cache when it can
+func (a *OAuthAdapter) rtmAccepts(token string) bool {
+	if valid, ok := a.validations.Get(token); ok {
+		return valid
+	}
 
-	// Create a temporary client with the token to test it
-	// Note: This requires the concrete client for now
-	testClient := NewClient(a.client.GetAPIKey(), "")
-	testClient.AuthToken = token
+	err := a.checkWithRTM(token)
+	switch {
+	case err == nil:
+		a.validations.Put(token, true)
+	case errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrNotAuthorized):
+		// Only RTM's own refusal is cached; an outage must not lock users out
+		a.validations.Put(token, false)
+	}
+	return err == nil
+}
+
+// checkWithRTM asks RTM whether it accepts token
+func (a *OAuthAdapter) checkWithRTM(token string) error {
+	// Create a temporary client with the token to test it, pointed at the
+	// same API as the adapter's client when that is a real one
+	var testClient *Client
+	if c, ok := a.client.(*Client); ok {
+		testClient = c.ForToken(token)
+	} else {
+		testClient = NewClient(a.client.GetAPIKey(), "")
+		testClient.AuthToken = token
+	}
 
 	// Test token by making a minimal API call
 	_, err := testClient.GetLists()
 	if err != nil {
 		log.Printf("RTM DEBUG: Token validation failed: %v", err)
-		return false
+		return err
 	}
 
 	log.Printf("RTM DEBUG: Token validation successful")
-	return true
+	return nil
+}
+
+// authEndpoint returns where users grant access, RTM's own page unless the
+// adapter's client is pointed elsewhere
+func (a *OAuthAdapter) authEndpoint() string {
+	if c, ok := a.client.(*Client); ok && c.AuthEndpoint != "" {
+		return c.AuthEndpoint
+	}
+	return defaultAuthEndpoint
+}
+
+// Close stops the session cleanup goroutine and closes the token and
+// session stores
+func (a *OAuthAdapter) Close() error {
+	close(a.done)
+	if err := a.accessTokens.Close(); err != nil {
+		log.Printf("RTM: Failed to close token store: %v", err)
+	}
+	return a.store.Close()
+}
+
+// SetStore keeps authorization sessions, redeemed codes, issued and
+// refresh tokens, revocations and registered clients in store instead, such
+// as a shared or test store. The adapter takes ownership and closes it.
+func (a *OAuthAdapter) SetStore(store kv.Store) error {
+	if err := a.accessTokens.Close(); err != nil {
+		return err
+	}
+	if err := a.store.Close(); err != nil {
+		return err
+	}
+	a.store = store
+	a.sessionStore = kv.NewBucket[AuthSession](store, authSessionBucket)
+	a.refresh = auth.NewRefreshTokens(store)
+	a.redeemed = auth.NewRedeemedCodes(store, 2*a.sessionTTL)
+	a.clients = auth.NewClients(store, a.serverURL, clientIDPrefix)
+	a.accessTokens = newIssuedTokens(auth.NewKVTokenStore(store, a.accessTTL))
+	a.revoked = kv.NewBucket[time.Time](store, revokedTokenBucket)
+	return nil
+}
+
+// UseAuditLog records authorization attempts, issued tokens, refused
+// bearer tokens and revocations in auditLog
+func (a *OAuthAdapter) UseAuditLog(auditLog auth.AuditLog) {
+	a.audit = auth.NewAuditor(auditLog)
+}
+
+// Auditor returns where the adapter records authentication events, for
+// middleware that refuses bearer tokens
+func (a *OAuthAdapter) Auditor() *auth.Auditor {
+	return a.audit
+}
+
+// Guard returns the adapter's brute-force protection, for metrics and audit
+func (a *OAuthAdapter) Guard() *auth.AttemptGuard {
+	return a.guard
 }
 
 // SetClient sets the RTM client (for testing)
-func (a *OAuthAdapter) SetClient(client RTMClientInterface) {
+func (a *OAuthAdapter) SetClient(client AuthClient) {
 	a.client = client
 }
 
@@ -8,11 +8,12 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
 
-// MockRTMClient implements RTMClientInterface for testing
+// MockRTMClient implements AuthClient for testing
 type MockRTMClient struct {
 	// Control behavior
 	ShouldFailGetFrob  bool
@@ -318,6 +319,49 @@ func TestAuthorizationTimeout(t *testing.T) {
 	}
 }
 
+// TestRequirePKCE tests refusing authorization flows without PKCE when REQUIRE_PKCE is set
+func TestRequirePKCE(t *testing.T) {
+	t.Logf("Importance: Deployments can insist on PKCE so an intercepted code is useless without the client's verifier.")
+	t.Setenv("REQUIRE_PKCE", "true")
+	adapter := NewOAuthAdapter("test-key", "test-secret", "http://localhost:8080")
+	defer adapter.Close()
+	mockClient := NewMockRTMClient()
+	adapter.SetClient(mockClient)
+
+	t.Run("authorize", func(t *testing.T) {
+		t.Logf("  > Why it's important: Refusing up front saves the user approving an app that can never finish.")
+		query := url.Values{"client_id": {"client-1"}, "redirect_uri": {"https://claude.ai/api/mcp/auth_callback"}}
+		w := httptest.NewRecorder()
+		adapter.HandleAuthorize(w, httptest.NewRequest("GET", "/oauth/authorize?"+query.Encode(), nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 without a challenge, got %d", w.Code)
+		}
+
+		query.Set("code_challenge", "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM")
+		query.Set("code_challenge_method", "S256")
+		w = httptest.NewRecorder()
+		adapter.HandleAuthorize(w, httptest.NewRequest("GET", "/oauth/authorize?"+query.Encode(), nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected the form with an S256 challenge, got %d", w.Code)
+		}
+		if mockClient.GetFrobCalls != 0 {
+			t.Errorf("Expected no frob requested before the form is submitted, got %d", mockClient.GetFrobCalls)
+		}
+	})
+
+	t.Run("token", func(t *testing.T) {
+		t.Logf("  > Why it's important: Sessions started before PKCE was required must not slip through.")
+		adapter.saveSession(&AuthSession{Code: "no-pkce", Frob: "frob", Token: "rtm-token", CreatedAt: time.Now()})
+		req := httptest.NewRequest("POST", "/oauth/token", strings.NewReader("grant_type=authorization_code&code=no-pkce"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		adapter.HandleToken(w, req)
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "without PKCE") {
+			t.Errorf("Expected the code refused, got %d %s", w.Code, w.Body.String())
+		}
+	})
+}
+
 // TestPollingMechanism tests the polling mechanism for token exchange
 func TestPollingMechanism(t *testing.T) {
 	adapter := NewOAuthAdapter("test-key", "test-secret", "http://localhost:8080")
@@ -395,6 +439,86 @@ func TestSessionCleanup(t *testing.T) {
 	}
 }
 
+// TestSessionExpiry tests that abandoned sessions expire and explain how to restart
+func TestSessionExpiry(t *testing.T) {
+	t.Logf("Importance: Abandoned authorization sessions must not pile up in memory, and users returning to a stale page need to know how to start over.")
+
+	adapter := NewOAuthAdapter("test-key", "test-secret", "http://localhost:8080")
+	defer adapter.Close()
+	adapter.SetClient(NewMockRTMClient())
+	adapter.sessionTTL = time.Hour
+
+	newSession := func(code string, age time.Duration) {
+		adapter.sessions[code] = &AuthSession{
+			Code:          code,
+			Frob:          "frob-" + code,
+			CreatedAt:     time.Now().Add(-age),
+			ClientID:      "client-1",
+			RedirectURI:   "https://claude.ai/api/mcp/auth_callback",
+			State:         "state-1",
+			CodeChallenge: "challenge",
+		}
+	}
+
+	t.Run("check-auth reports expiry with a restart link", func(t *testing.T) {
+		t.Logf("  > Why it's important: The waiting page should tell the user the session ended and offer a fresh one, not keep polling.")
+		newSession("stale", 61*time.Minute)
+
+		req := httptest.NewRequest("GET", "/rtm/check-auth?code=stale", nil)
+		w := httptest.NewRecorder()
+		adapter.HandleCheckAuth(w, req)
+
+		var result map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&result)
+		if w.Code != http.StatusBadRequest || result["error"] != "invalid_grant" {
+			t.Fatalf("Expected invalid_grant, got %d %v", w.Code, result)
+		}
+		if description, _ := result["error_description"].(string); !strings.Contains(description, "expired after 60 minutes") {
+			t.Errorf("Expected expiry explanation, got %q", description)
+		}
+		retry, _ := result["retry_url"].(string)
+		if !strings.HasPrefix(retry, "http://localhost:8080/oauth/authorize?") ||
+			!strings.Contains(retry, "client_id=client-1") || !strings.Contains(retry, "code_challenge=challenge") {
+			t.Errorf("Expected restart link for the same client, got %q", retry)
+		}
+		if adapter.GetSession("stale") != nil {
+			t.Error("Expired session should be removed")
+		}
+	})
+
+	t.Run("token endpoint rejects expired codes", func(t *testing.T) {
+		t.Logf("  > Why it's important: An expired code must fail clearly rather than wait on a frob RTM has already discarded.")
+		newSession("stale-token", 2*time.Hour)
+
+		req := httptest.NewRequest("POST", "/oauth/token", strings.NewReader("code=stale-token&code_verifier=v"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		adapter.HandleToken(w, req)
+
+		var result map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&result)
+		if result["error"] != "invalid_grant" || !strings.Contains(result["error_description"].(string), "expired") {
+			t.Errorf("Expected expired invalid_grant, got %v", result)
+		}
+	})
+
+	t.Run("cleanup removes only expired sessions", func(t *testing.T) {
+		t.Logf("  > Why it's important: Cleanup must free abandoned sessions without cutting off users still authorizing.")
+		newSession("fresh", 5*time.Minute)
+		newSession("abandoned", 90*time.Minute)
+
+		if removed := adapter.removeExpiredSessions(); removed != 1 {
+			t.Errorf("Expected 1 session removed, got %d", removed)
+		}
+		if adapter.GetSession("fresh") == nil {
+			t.Error("Fresh session should be kept")
+		}
+		if adapter.GetSession("abandoned") != nil {
+			t.Error("Abandoned session should be removed")
+		}
+	})
+}
+
 // TestValidateBearer tests bearer token validation
 func TestValidateBearer(t *testing.T) {
 	adapter := NewOAuthAdapter("test-key", "test-secret", "http://localhost:8080")
@@ -418,3 +542,470 @@ func TestValidateBearer(t *testing.T) {
 		t.Error("Should not validate invalid token")
 	}
 }
+
+// TestSessionsSurviveRestart tests that authorization sessions are kept in the OAuth store
+func TestSessionsSurviveRestart(t *testing.T) {
+	t.Logf("Importance: A deploy while someone is authorizing must not make them start over.")
+	t.Setenv("OAUTH_DB_PATH", t.TempDir()+"/oauth.db")
+
+	before := NewOAuthAdapter("test-key", "test-secret", "http://localhost:8080")
+	mockClient := NewMockRTMClient()
+	before.SetClient(mockClient)
+	before.saveSession(&AuthSession{Code: "restart-code", Frob: "restart-frob", CreatedAt: time.Now()})
+	req := httptest.NewRequest("GET", "/rtm/check-auth?code=restart-code", nil)
+	before.HandleCheckAuth(httptest.NewRecorder(), req)
+	if err := before.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	after := NewOAuthAdapter("test-key", "test-secret", "http://localhost:8080")
+	defer after.Close()
+	form := url.Values{"grant_type": {"authorization_code"}, "code": {"restart-code"}}
+	req = httptest.NewRequest("POST", "/oauth/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	after.HandleToken(w, req)
+
+	var response map[string]interface{}
+	json.NewDecoder(w.Body).Decode(&response)
+	if w.Code != http.StatusOK || response["access_token"] != mockClient.TokenValue {
+		t.Fatalf("Expected the token exchanged before the restart, got %d %v", w.Code, response)
+	}
+	if _, ok, _ := after.sessionStore.Get("restart-code"); ok {
+		t.Error("Expected the used session removed from the store")
+	}
+}
+
+// TestRefreshTokenGrant tests renewing access with a refresh token
+func TestRefreshTokenGrant(t *testing.T) {
+	t.Logf("Importance: Clients renew access with the refresh token instead of sending the user through RTM again.")
+	adapter := NewOAuthAdapter("test-key", "test-secret", "http://localhost:8080")
+	defer adapter.Close()
+	mockClient := NewMockRTMClient()
+	adapter.SetClient(mockClient)
+	adapter.saveSession(&AuthSession{Code: "refresh-code", Frob: "refresh-frob", ClientID: "client-a", CreatedAt: time.Now()})
+
+	token := func(form url.Values) (int, map[string]interface{}) {
+		req := httptest.NewRequest("POST", "/oauth/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		adapter.HandleToken(w, req)
+		var response map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&response)
+		return w.Code, response
+	}
+
+	status, first := token(url.Values{"grant_type": {"authorization_code"}, "code": {"refresh-code"}})
+	refreshToken, _ := first["refresh_token"].(string)
+	if status != http.StatusOK || refreshToken == "" {
+		t.Fatalf("Expected a refresh token with the access token, got %d %v", status, first)
+	}
+
+	t.Run("other client", func(t *testing.T) {
+		t.Logf("  > Why it's important: A refresh token leaked to another client must not grant it access.")
+		status, response := token(url.Values{"grant_type": {"refresh_token"}, "refresh_token": {refreshToken}, "client_id": {"client-b"}})
+		if status != http.StatusBadRequest || response["error"] != "invalid_grant" {
+			t.Errorf("Expected invalid_grant, got %d %v", status, response)
+		}
+	})
+
+	// The rejected attempt used up the first refresh token
+	adapter.saveSession(&AuthSession{Code: "refresh-code-2", Frob: "refresh-frob", Token: mockClient.TokenValue, ClientID: "client-a", CreatedAt: time.Now()})
+	_, first = token(url.Values{"grant_type": {"authorization_code"}, "code": {"refresh-code-2"}})
+	refreshToken, _ = first["refresh_token"].(string)
+
+	t.Run("renews", func(t *testing.T) {
+		t.Logf("  > Why it's important: Renewal returns the same RTM token and a new refresh token.")
+		status, response := token(url.Values{"grant_type": {"refresh_token"}, "refresh_token": {refreshToken}, "client_id": {"client-a"}})
+		if status != http.StatusOK || response["access_token"] != mockClient.TokenValue {
+			t.Fatalf("Expected the RTM token again, got %d %v", status, response)
+		}
+		if next, _ := response["refresh_token"].(string); next == "" || next == refreshToken {
+			t.Errorf("Expected a new refresh token, got %q", next)
+		}
+	})
+
+	t.Run("used once", func(t *testing.T) {
+		t.Logf("  > Why it's important: Rotating refresh tokens limits what a stolen one is worth.")
+		status, response := token(url.Values{"grant_type": {"refresh_token"}, "refresh_token": {refreshToken}})
+		if status != http.StatusBadRequest || response["error"] != "invalid_grant" {
+			t.Errorf("Expected a used refresh token rejected, got %d %v", status, response)
+		}
+	})
+}
+
+// TestHandleRevoke tests disconnecting through the revocation endpoint
+func TestHandleRevoke(t *testing.T) {
+	t.Logf("Importance: Users must be able to disconnect; RTM can't invalidate its tokens, so the adapter has to refuse them.")
+	t.Setenv("OAUTH_ACCESS_TOKEN_TTL", "1h")
+
+	post := func(handler http.HandlerFunc, form url.Values) (int, map[string]interface{}) {
+		req := httptest.NewRequest("POST", "/oauth/revoke", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		handler(w, req)
+		var response map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&response)
+		return w.Code, response
+	}
+	connections := 0
+	connect := func(adapter *OAuthAdapter) (map[string]interface{}, string) {
+		mockClient := NewMockRTMClient()
+		adapter.SetClient(mockClient)
+		connections++ // Each authorization gets its own single-use code
+		code := fmt.Sprintf("revoke-code-%d", connections)
+		adapter.saveSession(&AuthSession{Code: code, Frob: "frob", Token: mockClient.TokenValue, CreatedAt: time.Now()})
+		_, response := post(adapter.HandleToken, url.Values{"grant_type": {"authorization_code"}, "code": {code}})
+		refresh, _ := response["refresh_token"].(string)
+		return response, refresh
+	}
+
+	t.Run("access token", func(t *testing.T) {
+		t.Logf("  > Why it's important: The RTM token must stop working, and so must refresh tokens renewing it.")
+		adapter := NewOAuthAdapter("test-key", "test-secret", "http://localhost:8080")
+		defer adapter.Close()
+		response, refresh := connect(adapter)
+		if response["expires_in"] != float64(3600) {
+			t.Errorf("Expected the configured lifetime reported, got %v", response["expires_in"])
+		}
+		token, _ := response["access_token"].(string)
+		if status, _ := post(adapter.HandleRevoke, url.Values{"token": {token}}); status != http.StatusOK {
+			t.Fatalf("Expected 200 from revocation, got %d", status)
+		}
+		if adapter.ValidateBearer(token) {
+			t.Error("Expected the revoked token rejected")
+		}
+		if status, body := post(adapter.HandleToken, url.Values{"grant_type": {"refresh_token"}, "refresh_token": {refresh}}); status != http.StatusBadRequest {
+			t.Errorf("Expected refresh refused after revocation, got %d %v", status, body)
+		}
+
+		// Authorizing again reconnects
+		connect(adapter)
+		if adapter.isRevoked(token) {
+			t.Error("Expected a new authorization to clear the revocation")
+		}
+	})
+
+	t.Run("refresh token", func(t *testing.T) {
+		t.Logf("  > Why it's important: Revoking the refresh token alone must disconnect the integration too.")
+		adapter := NewOAuthAdapter("test-key", "test-secret", "http://localhost:8080")
+		defer adapter.Close()
+		response, refresh := connect(adapter)
+		post(adapter.HandleRevoke, url.Values{"token": {refresh}, "token_type_hint": {"refresh_token"}})
+		if token, _ := response["access_token"].(string); !adapter.isRevoked(token) {
+			t.Error("Expected the RTM token behind the refresh token revoked")
+		}
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		t.Logf("  > Why it's important: Unknown tokens answer 200 without being recorded, so the store can't be filled.")
+		adapter := NewOAuthAdapter("test-key", "test-secret", "http://localhost:8080")
+		defer adapter.Close()
+		if status, _ := post(adapter.HandleRevoke, url.Values{"token": {"never-issued"}}); status != http.StatusOK {
+			t.Errorf("Expected 200, got %d", status)
+		}
+		if keys, _ := adapter.store.Keys(revokedTokenBucket); len(keys) != 0 {
+			t.Errorf("Expected nothing recorded, got %v", keys)
+		}
+	})
+}
+
+// TestTokenScopes tests that granted scopes follow the token through exchange and refresh
+func TestTokenScopes(t *testing.T) {
+	t.Logf("Importance: Scope enforcement is only as good as the record of what each token was granted.")
+	adapter := NewOAuthAdapter("test-key", "test-secret", "http://localhost:8080")
+	defer adapter.Close()
+	mockClient := NewMockRTMClient()
+	adapter.SetClient(mockClient)
+
+	post := func(form url.Values) (int, map[string]interface{}) {
+		req := httptest.NewRequest("POST", "/oauth/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		adapter.HandleToken(w, req)
+		var response map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&response)
+		return w.Code, response
+	}
+
+	cases := map[string]string{
+		"":                        "rtm:read rtm:write", // clients that ask for nothing keep full access
+		"rtm:read":                "rtm:read",
+		"rtm:read claudeai":       "rtm:read", // unknown scopes are ignored
+		"rtm:write rtm:read":      "rtm:write rtm:read",
+		"rtm:read rtm:read other": "rtm:read",
+	}
+	for requested, granted := range cases {
+		if got := grantedScope(requested); got != granted {
+			t.Errorf("grantedScope(%q) = %q, want %q", requested, got, granted)
+		}
+	}
+
+	adapter.saveSession(&AuthSession{Code: "scope-code", Frob: "frob", Token: mockClient.TokenValue, Scope: ScopeRead, CreatedAt: time.Now()})
+	_, response := post(url.Values{"grant_type": {"authorization_code"}, "code": {"scope-code"}})
+	if response["scope"] != ScopeRead {
+		t.Errorf("Expected the granted scope in the token response, got %v", response["scope"])
+	}
+	if scopes := adapter.TokenScopes(mockClient.TokenValue); len(scopes) != 1 || scopes[0] != ScopeRead {
+		t.Errorf("Expected the token limited to rtm:read, got %v", scopes)
+	}
+	if scopes := adapter.TokenScopes("unknown-token"); scopes != nil {
+		t.Errorf("Expected no scopes recorded for an unknown token, got %v", scopes)
+	}
+
+	t.Run("refresh cannot widen", func(t *testing.T) {
+		t.Logf("  > Why it's important: A read-only client must not gain write access by renewing its token.")
+		refresh, _ := response["refresh_token"].(string)
+		status, body := post(url.Values{"grant_type": {"refresh_token"}, "refresh_token": {refresh}, "scope": {"rtm:read rtm:write"}})
+		if status != http.StatusBadRequest || body["error"] != "invalid_scope" {
+			t.Errorf("Expected invalid_scope, got %d %v", status, body)
+		}
+	})
+}
+
+// racingAuthClient hands out a token per frob, keeping the last one on the
+// client as Client does, slowly enough for exchanges to overlap
+type racingAuthClient struct {
+	*MockRTMClient
+	mu    sync.Mutex
+	token string
+}
+
+func (c *racingAuthClient) GetToken(frob string) error {
+	c.mu.Lock()
+	c.token = "token-for-" + frob
+	c.mu.Unlock()
+	time.Sleep(time.Millisecond)
+	return nil
+}
+
+func (c *racingAuthClient) GetAuthToken() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.token
+}
+
+// TestConcurrentSignInsGetTheirOwnTokens tests that frob exchanges on the shared client don't cross
+func TestConcurrentSignInsGetTheirOwnTokens(t *testing.T) {
+	t.Logf("Importance: Two users finishing sign-in together must never be bound to each other's RTM account.")
+	adapter := NewOAuthAdapter("test-key", "test-secret", "http://localhost:8080")
+	defer adapter.Close()
+	adapter.SetClient(&racingAuthClient{MockRTMClient: NewMockRTMClient()})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		frob := fmt.Sprintf("frob-%d", i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := adapter.exchangeFrob(frob)
+			if err != nil || token != "token-for-"+frob {
+				t.Errorf("Expected token-for-%s, got %q (%v)", frob, token, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// TestCheckAuthHammering tests that fast polling and reused codes don't reach RTM or get tokens
+func TestCheckAuthHammering(t *testing.T) {
+	t.Logf("Importance: A client or script polling check-auth in a loop must not turn into a flood of RTM calls, and a code must only ever buy one token.")
+
+	t.Run("poll budget", func(t *testing.T) {
+		t.Logf("  > Why it's important: Each check-auth poll would otherwise be a call to RTM's API on our key.")
+		adapter := NewOAuthAdapter("test-key", "test-secret", "http://localhost:8080")
+		defer adapter.Close()
+		mockClient := NewMockRTMClient()
+		mockClient.ShouldFailGetToken = true
+		adapter.SetClient(mockClient)
+		adapter.saveSession(&AuthSession{Code: "hammered", Frob: "frob", CreatedAt: time.Now()})
+
+		for i := 0; i < 10; i++ {
+			w := httptest.NewRecorder()
+			adapter.HandleCheckAuth(w, httptest.NewRequest("GET", "/rtm/check-auth?code=hammered", nil))
+			var result map[string]interface{}
+			json.NewDecoder(w.Body).Decode(&result)
+			if result["pending"] != true {
+				t.Fatalf("Expected poll %d pending, got %v", i+1, result)
+			}
+		}
+		if mockClient.GetTokenCalls != exchangeBurst {
+			t.Errorf("Expected %d exchanges with RTM, got %d", exchangeBurst, mockClient.GetTokenCalls)
+		}
+	})
+
+	t.Run("rate limit", func(t *testing.T) {
+		t.Logf("  > Why it's important: Polling unknown codes from one IP must be cut off, not just counted.")
+		t.Setenv("OAUTH_RATE_LIMIT", "6")
+		adapter := NewOAuthAdapter("test-key", "test-secret", "http://localhost:8080")
+		defer adapter.Close()
+
+		w := httptest.NewRecorder()
+		for i := 0; i < 3; i++ {
+			w = httptest.NewRecorder()
+			adapter.HandleCheckAuth(w, httptest.NewRequest("GET", "/rtm/check-auth?code=anything", nil))
+		}
+		if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+			t.Errorf("Expected 429 with Retry-After, got %d", w.Code)
+		}
+	})
+
+	t.Run("replayed code", func(t *testing.T) {
+		t.Logf("  > Why it's important: A leaked code used after the real client must be refused and reported.")
+		adapter := NewOAuthAdapter("test-key", "test-secret", "http://localhost:8080")
+		defer adapter.Close()
+		adapter.SetClient(NewMockRTMClient())
+		adapter.saveSession(&AuthSession{Code: "used-code", Frob: "frob", Token: "rtm-token", CreatedAt: time.Now()})
+
+		token := func() (int, map[string]interface{}) {
+			req := httptest.NewRequest("POST", "/oauth/token", strings.NewReader("grant_type=authorization_code&code=used-code"))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			adapter.HandleToken(w, req)
+			var response map[string]interface{}
+			json.NewDecoder(w.Body).Decode(&response)
+			return w.Code, response
+		}
+		status, first := token()
+		if status != http.StatusOK {
+			t.Fatalf("Expected the first exchange to succeed, got %d", status)
+		}
+		status, response := token()
+		if status != http.StatusBadRequest || !strings.Contains(response["error_description"].(string), "already been used") {
+			t.Errorf("Expected the replay refused, got %d %v", status, response)
+		}
+		if adapter.Guard().Metrics().Replays != 1 {
+			t.Errorf("Expected the replay counted, got %d", adapter.Guard().Metrics().Replays)
+		}
+
+		// The token issued from the leaked code is revoked (RFC 6749 4.1.2)
+		if !adapter.isRevoked("rtm-token") {
+			t.Error("Expected the token issued from the replayed code revoked")
+		}
+		refresh, _ := first["refresh_token"].(string)
+		if _, ok, _ := adapter.refresh.Redeem(refresh); ok {
+			t.Error("Expected the refresh token issued from the replayed code revoked")
+		}
+	})
+}
+
+func TestRegisteredClients(t *testing.T) {
+	t.Logf("Importance: Registered clients must be held to their redirect URIs and secrets across the RTM flow.")
+	adapter := NewOAuthAdapter("test-key", "test-secret", "http://localhost:8080")
+	defer adapter.Close()
+	adapter.SetClient(NewMockRTMClient())
+
+	req := httptest.NewRequest("POST", "/oauth/register", strings.NewReader(`{"redirect_uris":["https://claude.ai/api/mcp/auth_callback"]}`))
+	w := httptest.NewRecorder()
+	adapter.HandleRegister(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201 from registration, got %d", w.Code)
+	}
+	var registration map[string]interface{}
+	json.NewDecoder(w.Body).Decode(&registration)
+	clientID, _ := registration["client_id"].(string)
+	secret, _ := registration["client_secret"].(string)
+	if !strings.HasPrefix(clientID, "rtm_") || secret == "" {
+		t.Fatalf("Expected an rtm_ client ID and a secret, got %v", registration)
+	}
+
+	t.Run("authorize", func(t *testing.T) {
+		t.Logf("  > Why it's important: The sign-in form must not be offered for a redirect the client never registered.")
+		query := url.Values{"client_id": {clientID}, "redirect_uri": {"https://evil.example.com/callback"}}
+		w := httptest.NewRecorder()
+		adapter.HandleAuthorize(w, httptest.NewRequest("GET", "/oauth/authorize?"+query.Encode(), nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected an unregistered redirect refused, got %d", w.Code)
+		}
+
+		query.Set("redirect_uri", "https://claude.ai/api/mcp/auth_callback")
+		w = httptest.NewRecorder()
+		adapter.HandleAuthorize(w, httptest.NewRequest("GET", "/oauth/authorize?"+query.Encode(), nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected the form for the registered redirect, got %d", w.Code)
+		}
+	})
+
+	t.Run("token", func(t *testing.T) {
+		t.Logf("  > Why it's important: Only the client a code was issued to may redeem it, and only with its secret.")
+		adapter.saveSession(&AuthSession{Code: "client-code", Frob: "frob", Token: "rtm-token", ClientID: clientID,
+			RedirectURI: "https://claude.ai/api/mcp/auth_callback", CreatedAt: time.Now()})
+		token := func(form url.Values) int {
+			form.Set("grant_type", "authorization_code")
+			form.Set("code", "client-code")
+			req := httptest.NewRequest("POST", "/oauth/token", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			adapter.HandleToken(w, req)
+			return w.Code
+		}
+
+		if status := token(url.Values{"client_id": {clientID}, "client_secret": {"wrong"}}); status != http.StatusUnauthorized {
+			t.Errorf("Expected a wrong secret refused with 401, got %d", status)
+		}
+		if status := token(url.Values{"client_id": {"rtm_other"}}); status != http.StatusBadRequest {
+			t.Errorf("Expected another client refused, got %d", status)
+		}
+		if status := token(url.Values{"client_id": {clientID}, "client_secret": {secret}, "redirect_uri": {"https://evil.example.com/callback"}}); status != http.StatusBadRequest {
+			t.Errorf("Expected a different redirect_uri refused, got %d", status)
+		}
+		if status := token(url.Values{"client_id": {clientID}, "client_secret": {secret}, "redirect_uri": {"https://claude.ai/api/mcp/auth_callback"}}); status != http.StatusOK {
+			t.Errorf("Expected the code redeemed by its client, got %d", status)
+		}
+	})
+}
+
+// TestHandleIntrospect tests token introspection for registered confidential clients
+func TestHandleIntrospect(t *testing.T) {
+	t.Logf("Importance: Services sharing this authorization server check RTM tokens through introspection, with the scopes granted.")
+	adapter := NewOAuthAdapter("test-key", "test-secret", "http://localhost:8080")
+	defer adapter.Close()
+	mockClient := NewMockRTMClient()
+	adapter.SetClient(mockClient)
+
+	w := httptest.NewRecorder()
+	adapter.HandleRegister(w, httptest.NewRequest("POST", "/oauth/register", strings.NewReader(`{"redirect_uris":["https://app.example.com/cb"]}`)))
+	var registration map[string]interface{}
+	json.NewDecoder(w.Body).Decode(&registration)
+	clientID, _ := registration["client_id"].(string)
+	secret, _ := registration["client_secret"].(string)
+
+	adapter.saveSession(&AuthSession{Code: "introspect-code", Frob: "frob", Token: mockClient.TokenValue, ClientID: clientID, Scope: ScopeRead, CreatedAt: time.Now()})
+	form := url.Values{"grant_type": {"authorization_code"}, "code": {"introspect-code"}, "client_id": {clientID}, "client_secret": {secret}}
+	req := httptest.NewRequest("POST", "/oauth/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	adapter.HandleToken(httptest.NewRecorder(), req)
+	// RTM itself isn't reachable here, so answer as it would
+	adapter.validations.Put(mockClient.TokenValue, true)
+	adapter.validations.Put("unknown-token", false)
+
+	introspect := func(token, user, password string) (int, map[string]interface{}) {
+		req := httptest.NewRequest("POST", "/oauth/introspect", strings.NewReader(url.Values{"token": {token}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth(user, password)
+		w := httptest.NewRecorder()
+		adapter.HandleIntrospect(w, req)
+		var response map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&response)
+		return w.Code, response
+	}
+
+	status, response := introspect(mockClient.TokenValue, clientID, secret)
+	if status != http.StatusOK || response["active"] != true || response["scope"] != ScopeRead || response["client_id"] != clientID {
+		t.Errorf("Expected the token active with its scope and client, got %d %v", status, response)
+	}
+	if _, response := introspect("unknown-token", clientID, secret); response["active"] != false {
+		t.Errorf("Expected an unknown token inactive, got %v", response)
+	}
+	if status, _ := introspect(mockClient.TokenValue, clientID, "wrong-secret"); status != http.StatusUnauthorized {
+		t.Errorf("Expected a wrong client secret refused, got %d", status)
+	}
+
+	w = httptest.NewRecorder()
+	adapter.HandleAuthServerMetadata(w, httptest.NewRequest("GET", "/.well-known/oauth-authorization-server", nil))
+	var metadata map[string]interface{}
+	json.NewDecoder(w.Body).Decode(&metadata)
+	if metadata["introspection_endpoint"] != "http://localhost:8080/oauth/introspect" || metadata["scopes_supported"] == nil {
+		t.Errorf("Expected introspection and scopes advertised, got %v", metadata)
+	}
+}
@@ -20,6 +20,18 @@ type SearchParams struct {
 	UseCache         string  `json:"use_cache,omitempty"`
 }
 
+// ExportCSVParams for rtm_export_csv tool
+type ExportCSVParams struct {
+	Query            string `json:"query,omitempty"`
+	IncludeCompleted string `json:"include_completed,omitempty"`
+}
+
+// EstimateReportParams for rtm_estimate_report tool
+type EstimateReportParams struct {
+	Query     string `json:"query,omitempty"`
+	Available string `json:"available,omitempty"`
+}
+
 // QuickAddParams for rtm_quick_add tool
 type QuickAddParams struct {
 	Task      string `json:"task"`
@@ -44,6 +56,16 @@ type UpdateTaskParams struct {
 	Estimate string `json:"estimate,omitempty"`
 	Tags     string `json:"tags,omitempty"`
 	ListName string `json:"list_name,omitempty"`
+	Location string `json:"location,omitempty"`
+	URL      string `json:"url,omitempty"`
+}
+
+// DuplicateTaskParams for rtm_duplicate_task tool
+type DuplicateTaskParams struct {
+	TaskID   string `json:"task_id"`
+	SeriesID string `json:"series_id"`
+	List     string `json:"list,omitempty"`
+	Name     string `json:"name,omitempty"`
 }
 
 // ManageListParams for rtm_manage_list tool
@@ -52,6 +74,12 @@ type ManageListParams struct {
 	Name    string `json:"name,omitempty"`
 	NewName string `json:"new_name,omitempty"`
 	ListID  string `json:"list_id,omitempty"`
+	Filter  string `json:"filter,omitempty"`
+}
+
+// UndoParams for rtm_undo tool
+type UndoParams struct {
+	TransactionID string `json:"transaction_id,omitempty"`
 }
 
 // Helper function to parse params from generic map
@@ -0,0 +1,278 @@
+package rtm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// QueryOptions are the structured parts of an RTM search
+type QueryOptions struct {
+	DueAfter  string
+	DueBefore string
+	List      string
+	Tags      []string
+	Priority  string
+}
+
+// SetupPrompts registers RTM prompts with the MCP server
+func (h *Handler) SetupPrompts(s *server.MCPServer) {
+	s.AddPrompt(mcp.Prompt{
+		Name:        "rtm_query_builder",
+		Description: "Build a correctly formed RTM search query from a date range, list, tags, and priority, ready for rtm_search",
+		Arguments: []mcp.PromptArgument{
+			{Name: "due_after", Description: "Only tasks due after this date (e.g. 'today', '2024-06-01', 'next monday')"},
+			{Name: "due_before", Description: "Only tasks due before this date (e.g. 'tomorrow', 'end of month')"},
+			{Name: "list", Description: "List name"},
+			{Name: "tags", Description: "Comma-separated tags; tasks must have all of them"},
+			{Name: "priority", Description: "Priority: 1 (high), 2 (medium), 3 (low), or N (none)"},
+		},
+	}, h.handleQueryBuilderPrompt)
+
+	s.AddPrompt(mcp.Prompt{
+		Name:        "weekly_review",
+		Description: "Weekly review of live RTM data: tasks completed this week, overdue tasks, and tasks without a due date",
+		Arguments: []mcp.PromptArgument{
+			{Name: "list", Description: "Limit the review to one list name"},
+		},
+	}, h.handleWeeklyReviewPrompt)
+
+	s.AddPrompt(mcp.Prompt{
+		Name:        "daily_agenda",
+		Description: "Morning plan from today's and overdue RTM tasks, sorted by priority",
+		Arguments: []mcp.PromptArgument{
+			{Name: "focus", Description: "What to prioritize today (e.g. 'work', 'errands', 'the Q3 report')"},
+		},
+	}, h.handleDailyAgendaPrompt)
+}
+
+func (h *Handler) handleQueryBuilderPrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	args := request.Params.Arguments
+
+	var tags []string
+	for _, tag := range strings.Split(args["tags"], ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	query, err := BuildSearchQuery(QueryOptions{
+		DueAfter:  args["due_after"],
+		DueBefore: args["due_before"],
+		List:      args["list"],
+		Tags:      tags,
+		Priority:  args["priority"],
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &mcp.GetPromptResult{
+		Description: "RTM search query",
+		Messages: []mcp.PromptMessage{
+			{
+				Role: mcp.RoleUser,
+				Content: mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Search my Remember The Milk tasks by calling rtm_search with exactly this query:\n\n%s\n\nSummarize the matching tasks.", query),
+				},
+			},
+		},
+	}, nil
+}
+
+// maxPromptTasks caps how many tasks a prompt lists per section
+const maxPromptTasks = 50
+
+// Weekly review searches
+const (
+	reviewCompletedFilter = `completedWithin:"1 week of today"`
+	overdueFilter         = "dueBefore:today AND status:incomplete"
+	reviewNoDueFilter     = "due:never AND status:incomplete"
+)
+
+func (h *Handler) handleWeeklyReviewPrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	client := h.ClientFor(ctx)
+	if client.GetAuthToken() == "" {
+		return nil, fmt.Errorf("RTM authentication required. Use rtm_auth_url first")
+	}
+
+	scope := ""
+	if list := strings.TrimSpace(request.Params.Arguments["list"]); list != "" {
+		scope = " AND list:" + quoteSearchValue(list)
+	}
+
+	completed, err := client.GetTasksWithOptions(reviewCompletedFilter+scope, "", TaskListOptions{IncludeCompleted: true})
+	if err != nil {
+		return nil, fmt.Errorf("getting completed tasks: %w", err)
+	}
+	overdue, err := client.GetTasks(overdueFilter+scope, "")
+	if err != nil {
+		return nil, fmt.Errorf("getting overdue tasks: %w", err)
+	}
+	undated, err := client.GetTasks(reviewNoDueFilter+scope, "")
+	if err != nil {
+		return nil, fmt.Errorf("getting tasks without due dates: %w", err)
+	}
+
+	var b strings.Builder
+	b.WriteString("Run my weekly review of my Remember The Milk tasks using this live data.\n")
+	writeTaskSection(&b, "Completed this week", completed)
+	writeTaskSection(&b, "Overdue", overdue)
+	writeTaskSection(&b, "No due date", undated)
+	b.WriteString("\nFor the review:\n")
+	b.WriteString("1. Summarize what got done this week.\n")
+	b.WriteString("2. For each overdue task, suggest rescheduling, delegating, or dropping it.\n")
+	b.WriteString("3. Point out tasks without a due date that should get one.\n")
+	b.WriteString("Ask before changing anything; apply agreed changes with rtm_update using the IDs shown.")
+
+	return &mcp.GetPromptResult{
+		Description: "RTM weekly review",
+		Messages: []mcp.PromptMessage{
+			{
+				Role: mcp.RoleUser,
+				Content: mcp.TextContent{
+					Type: "text",
+					Text: b.String(),
+				},
+			},
+		},
+	}, nil
+}
+
+func (h *Handler) handleDailyAgendaPrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	client := h.ClientFor(ctx)
+	if client.GetAuthToken() == "" {
+		return nil, fmt.Errorf("RTM authentication required. Use rtm_auth_url first")
+	}
+
+	today, stale, err := h.TodayTasks(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting today's tasks: %w", err)
+	}
+	overdue, err := client.GetTasks(overdueFilter, "")
+	if err != nil {
+		return nil, fmt.Errorf("getting overdue tasks: %w", err)
+	}
+	// today may be the shared fallback copy, so sort a copy of it
+	today = append([]Task(nil), today...)
+	sortByPriority(today)
+	sortByPriority(overdue)
+
+	var b strings.Builder
+	b.WriteString("Help me plan my day from my Remember The Milk tasks.\n")
+	if stale != nil {
+		fmt.Fprintf(&b, "\nNote: RTM is unreachable, so today's tasks are a cached copy from %s.\n", stale.FetchedAt.Format(time.RFC3339))
+	}
+	writeTaskSection(&b, "Overdue", overdue)
+	writeTaskSection(&b, "Due today", today)
+	b.WriteString("\nPropose an ordered agenda for today: deal with high-priority overdue tasks first, and suggest new dates for overdue tasks that won't fit.")
+	if focus := strings.TrimSpace(request.Params.Arguments["focus"]); focus != "" {
+		fmt.Fprintf(&b, " My focus today is: %s. Put related tasks first.", focus)
+	}
+	b.WriteString(" Ask before changing anything; apply agreed changes with rtm_update using the IDs shown.")
+
+	return &mcp.GetPromptResult{
+		Description: "RTM daily agenda",
+		Messages: []mcp.PromptMessage{
+			{
+				Role: mcp.RoleUser,
+				Content: mcp.TextContent{
+					Type: "text",
+					Text: b.String(),
+				},
+			},
+		},
+	}, nil
+}
+
+// sortByPriority orders tasks from high priority to none, then by due date.
+// RTM priorities "1", "2", "3" and "N" already sort in that order as strings.
+func sortByPriority(tasks []Task) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		if tasks[i].Priority != tasks[j].Priority {
+			return tasks[i].Priority < tasks[j].Priority
+		}
+		return tasks[i].Due < tasks[j].Due
+	})
+}
+
+// writeTaskSection writes a heading and one line per task, with the IDs
+// rtm_update needs
+func writeTaskSection(b *strings.Builder, title string, tasks []Task) {
+	fmt.Fprintf(b, "\n## %s (%d)\n", title, len(tasks))
+	if len(tasks) == 0 {
+		b.WriteString("None\n")
+		return
+	}
+	for i, task := range tasks {
+		if i == maxPromptTasks {
+			fmt.Fprintf(b, "...and %d more\n", len(tasks)-maxPromptTasks)
+			break
+		}
+		fmt.Fprintf(b, "- %s", task.Name)
+		if task.Due != "" {
+			fmt.Fprintf(b, " (due %s)", task.Due)
+		}
+		if task.Priority != "" && task.Priority != "N" {
+			fmt.Fprintf(b, " !%s", task.Priority)
+		}
+		if len(task.Tags) > 0 {
+			fmt.Fprintf(b, " #%s", strings.Join(task.Tags, " #"))
+		}
+		fmt.Fprintf(b, " [list_id=%s series_id=%s task_id=%s]\n", task.ListID, task.SeriesID, task.ID)
+	}
+}
+
+// BuildSearchQuery combines the options into RTM search syntax joined with AND.
+// Values containing spaces or quotes are quoted. With no options it matches all
+// incomplete tasks.
+func BuildSearchQuery(opts QueryOptions) (string, error) {
+	var terms []string
+
+	if opts.DueAfter != "" {
+		terms = append(terms, "dueAfter:"+quoteSearchValue(opts.DueAfter))
+	}
+	if opts.DueBefore != "" {
+		terms = append(terms, "dueBefore:"+quoteSearchValue(opts.DueBefore))
+	}
+	if opts.List != "" {
+		terms = append(terms, "list:"+quoteSearchValue(opts.List))
+	}
+	for _, tag := range opts.Tags {
+		if strings.ContainsAny(tag, " \"") {
+			return "", fmt.Errorf("invalid tag %q: RTM tags cannot contain spaces or quotes", tag)
+		}
+		terms = append(terms, "tag:"+tag)
+	}
+	if opts.Priority != "" {
+		priority := strings.ToUpper(strings.TrimSpace(opts.Priority))
+		switch priority {
+		case "1", "2", "3":
+			terms = append(terms, "priority:"+priority)
+		case "N", "NONE":
+			terms = append(terms, "priority:none")
+		default:
+			return "", fmt.Errorf("invalid priority %q: use 1, 2, 3, or N", opts.Priority)
+		}
+	}
+
+	if len(terms) == 0 {
+		return "status:incomplete", nil
+	}
+	return strings.Join(terms, " AND "), nil
+}
+
+// quoteSearchValue wraps values that RTM would otherwise split into words
+func quoteSearchValue(value string) string {
+	value = strings.TrimSpace(value)
+	if !strings.ContainsAny(value, " ()\"") {
+		return value
+	}
+	return `"` + strings.ReplaceAll(value, `"`, `'`) + `"`
+}
@@ -0,0 +1,160 @@
+package rtm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestBuildSearchQuery(t *testing.T) {
+	t.Logf("Importance: Malformed search strings make rtm_search return nothing or the wrong tasks; the query builder must emit valid RTM syntax.")
+
+	cases := []struct {
+		name string
+		opts QueryOptions
+		want string
+	}{
+		{"empty", QueryOptions{}, "status:incomplete"},
+		{"date range", QueryOptions{DueAfter: "today", DueBefore: "next friday"}, `dueAfter:today AND dueBefore:"next friday"`},
+		{"list and tags", QueryOptions{List: "Work Projects", Tags: []string{"urgent", "client"}}, `list:"Work Projects" AND tag:urgent AND tag:client`},
+		{"priority", QueryOptions{Priority: "1"}, "priority:1"},
+		{"no priority", QueryOptions{Priority: "n"}, "priority:none"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := BuildSearchQuery(tc.opts)
+			if err != nil {
+				t.Fatalf("BuildSearchQuery failed: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("Expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+
+	t.Run("rejects invalid input", func(t *testing.T) {
+		t.Logf("  > Why it's important: Bad values should be reported to the agent rather than producing a silently wrong query.")
+		if _, err := BuildSearchQuery(QueryOptions{Priority: "urgent"}); err == nil {
+			t.Error("Expected error for invalid priority")
+		}
+		if _, err := BuildSearchQuery(QueryOptions{Tags: []string{"two words"}}); err == nil {
+			t.Error("Expected error for tag with spaces")
+		}
+	})
+}
+
+func TestWeeklyReviewPrompt(t *testing.T) {
+	t.Logf("Importance: The weekly review must be built from live RTM data, with the IDs agents need to act on each task.")
+
+	var filters []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("method") == "rtm.settings.getList" {
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok","settings":{"timezone":"UTC"}}}`)
+			return
+		}
+		filter := query.Get("filter")
+		filters = append(filters, filter)
+
+		task := `{"id":"11","due":"2024-04-29T00:00:00Z","completed":"","deleted":"","priority":"1"}`
+		name := "Renew passport"
+		switch {
+		case strings.HasPrefix(filter, "completedWithin"):
+			task = `{"id":"21","due":"","completed":"2024-05-01T10:00:00Z","deleted":"","priority":"N"}`
+			name = "File taxes"
+		case strings.HasPrefix(filter, "due:never"):
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok","tasks":{}}}`)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"rsp":{"stat":"ok","tasks":{"list":[{"id":"100","taskseries":[{"id":"1","name":%q,"tags":[],"notes":[],"task":[%s]}]}]}}}`, name, task)
+	}))
+	defer server.Close()
+
+	h := &Handler{client: NewClient("key", "secret")}
+	h.client.BaseURL = server.URL
+	h.client.Limiter = nil
+	h.client.AuthToken = "token"
+
+	request := mcp.GetPromptRequest{}
+	request.Params.Arguments = map[string]string{"list": "Personal Admin"}
+	result, err := h.handleWeeklyReviewPrompt(context.Background(), request)
+	if err != nil {
+		t.Fatalf("weekly_review failed: %v", err)
+	}
+
+	text := result.Messages[0].Content.(mcp.TextContent).Text
+	for _, want := range []string{
+		"## Completed this week (1)\n- File taxes",
+		"## Overdue (1)\n- Renew passport (due 2024-04-29T00:00:00Z) !1 [list_id=100 series_id=1 task_id=11]",
+		"## No due date (0)\nNone",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected review to contain %q, got:\n%s", want, text)
+		}
+	}
+	for _, filter := range filters {
+		if !strings.HasSuffix(filter, `AND list:"Personal Admin"`) {
+			t.Errorf("Expected every search to be limited to the list, got %q", filter)
+		}
+	}
+
+	t.Run("requires authentication", func(t *testing.T) {
+		t.Logf("  > Why it's important: Without a token the prompt must explain what to do rather than return an empty review.")
+		h := &Handler{client: NewClient("key", "secret")}
+		if _, err := h.handleWeeklyReviewPrompt(context.Background(), mcp.GetPromptRequest{}); err == nil {
+			t.Error("Expected error without auth token")
+		}
+	})
+}
+
+func TestDailyAgendaPrompt(t *testing.T) {
+	t.Logf("Importance: Morning planning starts from this message; high-priority and overdue work must come first.")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("method") == "rtm.settings.getList" {
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok","settings":{"timezone":"UTC"}}}`)
+			return
+		}
+		if query.Get("filter") == "due:today" {
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok","tasks":{"list":[{"id":"100","taskseries":[
+				{"id":"1","name":"Low task","tags":[],"notes":[],"task":[{"id":"11","due":"2024-05-01T00:00:00Z","completed":"","deleted":"","priority":"3"}]},
+				{"id":"2","name":"Unprioritized","tags":[],"notes":[],"task":[{"id":"21","due":"2024-05-01T00:00:00Z","completed":"","deleted":"","priority":"N"}]},
+				{"id":"3","name":"Urgent task","tags":[],"notes":[],"task":[{"id":"31","due":"2024-05-01T00:00:00Z","completed":"","deleted":"","priority":"1"}]}]}]}}}`)
+			return
+		}
+		_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok","tasks":{"list":[{"id":"100","taskseries":[
+			{"id":"4","name":"Late report","tags":[],"notes":[],"task":[{"id":"41","due":"2024-04-28T00:00:00Z","completed":"","deleted":"","priority":"2"}]}]}]}}}`)
+	}))
+	defer server.Close()
+
+	h := &Handler{client: NewClient("key", "secret")}
+	h.client.BaseURL = server.URL
+	h.client.Limiter = nil
+	h.client.AuthToken = "token"
+
+	request := mcp.GetPromptRequest{}
+	request.Params.Arguments = map[string]string{"focus": "the Q3 report"}
+	result, err := h.handleDailyAgendaPrompt(context.Background(), request)
+	if err != nil {
+		t.Fatalf("daily_agenda failed: %v", err)
+	}
+
+	text := result.Messages[0].Content.(mcp.TextContent).Text
+	overdue := strings.Index(text, "Late report")
+	urgent := strings.Index(text, "Urgent task")
+	low := strings.Index(text, "Low task")
+	none := strings.Index(text, "Unprioritized")
+	if overdue < 0 || !(overdue < urgent && urgent < low && low < none) {
+		t.Errorf("Expected overdue first, then today's tasks by priority, got:\n%s", text)
+	}
+	if !strings.Contains(text, "My focus today is: the Q3 report.") {
+		t.Errorf("Expected focus in agenda, got:\n%s", text)
+	}
+}
@@ -0,0 +1,67 @@
+package rtm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/vcto/mcp-adapters/internal/ratelimit"
+)
+
+func TestClientRateLimiting(t *testing.T) {
+	t.Logf("Importance: RTM throttles at about 1 request/second; every API call must go through the shared limiter so bursts queue instead of failing.")
+
+	t.Run("queues calls beyond the burst", func(t *testing.T) {
+		t.Logf("  > Why it's important: Batch jobs and resource reads share one budget, so excess calls must wait their turn.")
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok"}}`)
+		}))
+		defer server.Close()
+
+		client := NewClient("key", "secret")
+		client.BaseURL = server.URL
+		client.Limiter = ratelimit.New(20, 1) // one token every 50ms
+
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			if _, err := client.Call("rtm.test.echo", nil); err != nil {
+				t.Fatalf("Call failed: %v", err)
+			}
+		}
+
+		if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+			t.Errorf("Expected calls beyond the burst to wait, took only %v", elapsed)
+		}
+		if metrics := client.Limiter.Metrics(); metrics.RequestsTotal != 3 || metrics.RequestsBlocked == 0 {
+			t.Errorf("Expected 3 requests with some blocked, got %+v", metrics)
+		}
+	})
+
+	t.Run("backs off after a 503", func(t *testing.T) {
+		t.Logf("  > Why it's important: When RTM signals throttling, later calls must slow down rather than keep hammering it.")
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		client := NewClient("key", "secret")
+		client.BaseURL = server.URL
+		client.Retry = RetryPolicy{} // inspect the backoff after a single attempt
+
+		if _, err := client.Call("rtm.test.echo", nil); err == nil {
+			t.Fatal("Expected error for 503 response")
+		}
+
+		if metrics := client.Limiter.Metrics(); metrics.Throttled != 1 {
+			t.Errorf("Expected limiter to enter backoff after a 503, got %+v", metrics)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if err := client.Limiter.Wait(ctx); err == nil {
+			t.Error("Expected calls to be held during the backoff")
+		}
+	})
+}
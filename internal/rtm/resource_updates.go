@@ -0,0 +1,267 @@
+package rtm
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/vcto/mcp-adapters/internal/config"
+)
+
+// defaultResourcePollInterval is how often RTM is polled for changes to
+// report as resource updates
+const defaultResourcePollInterval = time.Minute
+
+// taskViewURIs are the resources any task change may alter
+var taskViewURIs = []string{"rtm://today", "rtm://inbox", "rtm://overdue", "rtm://week", "rtm://lists", CalendarURI, MatrixURI}
+
+// Notifier sends a notification to one MCP session; server.MCPServer
+// implements it
+type Notifier interface {
+	SendNotificationToSpecificClient(sessionID, method string, params map[string]any) error
+}
+
+// ResourceWatcher polls RTM for task changes with last_sync and tells each
+// connected session which rtm:// resources changed, with
+// notifications/resources/updated, and when its user's lists changed, with
+// notifications/resources/list_changed. Only sessions with a notification
+// stream are told: stdio, SSE, and streamable HTTP clients holding a GET
+// stream open. Each session hears about its own RTM user's changes only.
+// mcp-go does not route resources/subscribe, so a session is told about
+// every changed resource, not just those it subscribed to.
+type ResourceWatcher struct {
+	handler  *Handler
+	notifier Notifier
+	interval time.Duration
+
+	mu sync.Mutex
+	// sessions maps session IDs to the RTM auth token they connected with
+	sessions map[string]string
+	// users holds poll state by auth token
+	users map[string]*watchState
+}
+
+type watchState struct {
+	since time.Time
+	lists string
+	// reported holds the task URIs and modified times of the last poll, as
+	// the skew window reports recent changes twice
+	reported map[string]time.Time
+}
+
+// NewResourceWatcher creates a watcher polling every interval; Attach it to
+// the server's hooks and Run it
+func NewResourceWatcher(handler *Handler, notifier Notifier, interval time.Duration) *ResourceWatcher {
+	return &ResourceWatcher{
+		handler:  handler,
+		notifier: notifier,
+		interval: interval,
+		sessions: make(map[string]string),
+		users:    make(map[string]*watchState),
+	}
+}
+
+// ResourcePollIntervalFromEnv reads RTM_RESOURCE_POLL_INTERVAL; 0 disables
+// resource update notifications
+func ResourcePollIntervalFromEnv() time.Duration {
+	return config.DurationFromEnv("RTM_RESOURCE_POLL_INTERVAL", defaultResourcePollInterval)
+}
+
+// Attach registers the watcher's session hooks
+func (w *ResourceWatcher) Attach(hooks *server.Hooks) {
+	hooks.AddOnRegisterSession(func(ctx context.Context, session server.ClientSession) {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.sessions[session.SessionID()] = AuthTokenFromContext(ctx)
+	})
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		w.forget(session.SessionID())
+	})
+}
+
+// Run polls until ctx is done. It returns at once when the interval is 0.
+func (w *ResourceWatcher) Run(ctx context.Context) {
+	if w.interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.poll(ctx)
+		}
+	}
+}
+
+// poll checks each connected user's account once and notifies their sessions
+func (w *ResourceWatcher) poll(ctx context.Context) {
+	for token, sessions := range w.sessionsByToken() {
+		uris, listChanged := w.check(ctx, token)
+		for _, sessionID := range sessions {
+			if listChanged {
+				w.send(sessionID, mcp.MethodNotificationResourcesListChanged, nil)
+			}
+			for _, uri := range uris {
+				w.send(sessionID, mcp.MethodNotificationResourceUpdated, map[string]any{"uri": uri})
+			}
+		}
+	}
+}
+
+// check returns the resources changed since the user's last poll, and
+// whether their lists changed. The first poll only records where to start.
+func (w *ResourceWatcher) check(ctx context.Context, token string) ([]string, bool) {
+	timeout := w.interval
+	if timeout <= 0 {
+		timeout = defaultResourcePollInterval
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	client := w.handler.ClientFor(WithAuthToken(ctx, token))
+	if client.GetAuthToken() == "" {
+		return nil, false
+	}
+
+	w.mu.Lock()
+	state := w.users[token]
+	w.mu.Unlock()
+	if state == nil {
+		state = &watchState{since: client.SyncTime()}
+		if lists, err := client.GetLists(); err == nil {
+			state.lists = listsFingerprint(lists)
+		}
+		w.mu.Lock()
+		w.users[token] = state
+		w.mu.Unlock()
+		return nil, false
+	}
+
+	lists, err := client.GetLists()
+	if err != nil {
+		log.Printf("RTM: resource watcher: %v", err)
+		return nil, false
+	}
+	changes, err := client.GetChanges(state.since)
+	if err != nil {
+		log.Printf("RTM: resource watcher: %v", err)
+		return nil, false
+	}
+
+	fingerprint := listsFingerprint(lists)
+	listChanged := fingerprint != state.lists
+	uris, reported := changedURIs(changes, lists, state.reported)
+	if listChanged && len(uris) == 0 {
+		uris = []string{"rtm://lists"}
+	}
+
+	w.mu.Lock()
+	state.since = changes.SyncedAt
+	state.lists = fingerprint
+	state.reported = reported
+	w.mu.Unlock()
+	return uris, listChanged
+}
+
+// changedURIs returns the resources the changes alter, skipping tasks
+// already reported with the same modified time
+func changedURIs(changes *Changes, lists []List, previous map[string]time.Time) ([]string, map[string]time.Time) {
+	names := make(map[string]string, len(lists))
+	var smart []string
+	for _, list := range lists {
+		names[list.ID] = list.Name
+		if list.Smart == "1" {
+			smart = append(smart, "rtm://smart/"+list.Name)
+		}
+	}
+
+	reported := make(map[string]time.Time)
+	seen := make(map[string]bool)
+	var uris []string
+	add := func(uri string) {
+		if !seen[uri] {
+			seen[uri] = true
+			uris = append(uris, uri)
+		}
+	}
+	for _, task := range changes.Tasks {
+		uri := TaskURI(task.SeriesID, task.ID)
+		reported[uri] = task.Modified
+		if at, ok := previous[uri]; ok && at.Equal(task.Modified) {
+			continue
+		}
+		add(uri)
+		if name := names[task.ListID]; name != "" {
+			add("rtm://lists/" + name)
+		}
+	}
+	for _, task := range changes.Deleted {
+		uri := TaskURI(task.SeriesID, task.ID)
+		reported[uri] = time.Time{}
+		if at, ok := previous[uri]; ok && at.IsZero() {
+			continue
+		}
+		add(uri)
+	}
+	if len(uris) == 0 {
+		return nil, reported
+	}
+
+	// Views and smart lists are filters; any change may move a task in or out
+	for _, uri := range taskViewURIs {
+		add(uri)
+	}
+	for _, uri := range smart {
+		add(uri)
+	}
+	sort.Strings(uris)
+	return uris, reported
+}
+
+// listsFingerprint identifies the set of lists and their names
+func listsFingerprint(lists []List) string {
+	entries := make([]string, 0, len(lists))
+	for _, list := range lists {
+		entries = append(entries, strings.Join([]string{list.ID, list.Name, list.Archived, list.Deleted}, "\x00"))
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, "\n")
+}
+
+// sessionsByToken groups connected sessions by their RTM auth token
+func (w *ResourceWatcher) sessionsByToken() map[string][]string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	byToken := make(map[string][]string)
+	for sessionID, token := range w.sessions {
+		byToken[token] = append(byToken[token], sessionID)
+	}
+	for token := range w.users {
+		if _, ok := byToken[token]; !ok {
+			delete(w.users, token)
+		}
+	}
+	return byToken
+}
+
+func (w *ResourceWatcher) send(sessionID, method string, params map[string]any) {
+	err := w.notifier.SendNotificationToSpecificClient(sessionID, method, params)
+	if errors.Is(err, server.ErrSessionNotFound) {
+		w.forget(sessionID)
+	}
+}
+
+func (w *ResourceWatcher) forget(sessionID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.sessions, sessionID)
+}
@@ -0,0 +1,150 @@
+package rtm
+
+import (
+	"context"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// watchedSession is a connected MCP client known only by its ID
+type watchedSession string
+
+func (w watchedSession) Initialize()                                         {}
+func (w watchedSession) Initialized() bool                                   { return true }
+func (w watchedSession) NotificationChannel() chan<- mcp.JSONRPCNotification { return nil }
+func (w watchedSession) SessionID() string                                   { return string(w) }
+
+// recordingNotifier keeps the notifications sent to each session
+type recordingNotifier struct {
+	mu   sync.Mutex
+	sent map[string][]string
+}
+
+func (r *recordingNotifier) SendNotificationToSpecificClient(sessionID, method string, params map[string]any) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry := method
+	if uri, ok := params["uri"].(string); ok {
+		entry += " " + uri
+	}
+	r.sent[sessionID] = append(r.sent[sessionID], entry)
+	return nil
+}
+
+// take returns and clears the notifications sent to a session
+func (r *recordingNotifier) take(sessionID string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sent := r.sent[sessionID]
+	delete(r.sent, sessionID)
+	sort.Strings(sent)
+	return sent
+}
+
+func TestResourceWatcher(t *testing.T) {
+	t.Logf("Importance: Clients showing rtm:// resources should refresh when tasks change in RTM instead of re-reading them every turn.")
+
+	now := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+	fake := &syncingRTM{now: now.Add(-time.Hour), tasks: map[string]*fakeTask{}, lists: []List{
+		{ID: "inbox", Name: "Inbox"},
+		{ID: "urgent", Name: "Urgent", Smart: "1"},
+	}}
+	fake.change("1", func(task *fakeTask) { task.name = "Buy milk" })
+	rtmServer := httptest.NewServer(fake)
+	defer rtmServer.Close()
+
+	h := &Handler{client: NewClient("key", "secret")}
+	h.client.BaseURL = rtmServer.URL
+	h.client.AuthToken = "token"
+	h.client.Limiter = nil
+	h.client.Tasks = NewTaskCache(time.Hour)
+	h.client.Tasks.now = func() time.Time { return now }
+
+	notifier := &recordingNotifier{sent: map[string][]string{}}
+	watcher := NewResourceWatcher(h, notifier, time.Minute)
+	hooks := &server.Hooks{}
+	watcher.Attach(hooks)
+	hooks.RegisterSession(context.Background(), watchedSession("session-1"))
+
+	advance := func(d time.Duration) {
+		now = now.Add(d)
+		fake.mu.Lock()
+		fake.now = now
+		fake.mu.Unlock()
+	}
+
+	watcher.poll(context.Background())
+	if sent := notifier.take("session-1"); len(sent) != 0 {
+		t.Fatalf("Expected the first poll only to record a starting point, got %v", sent)
+	}
+
+	advance(time.Minute)
+	fake.change("1", func(task *fakeTask) { task.name = "Buy oat milk" })
+	watcher.poll(context.Background())
+	sent := map[string]bool{}
+	for _, entry := range notifier.take("session-1") {
+		sent[entry] = true
+	}
+	for _, uri := range []string{TaskURI("1", "t1"), "rtm://lists/Inbox", "rtm://smart/Urgent", "rtm://today", "rtm://inbox"} {
+		if !sent[mcp.MethodNotificationResourceUpdated+" "+uri] {
+			t.Errorf("Expected an update for %s, got %v", uri, sent)
+		}
+	}
+	if sent[mcp.MethodNotificationResourcesListChanged] {
+		t.Errorf("Expected no list_changed without list changes, got %v", sent)
+	}
+
+	t.Run("a change is reported once", func(t *testing.T) {
+		t.Logf("  > Why it's important: The sync window overlaps the previous poll; clients should not refetch for the same edit twice.")
+		advance(10 * time.Second)
+		watcher.poll(context.Background())
+		if sent := notifier.take("session-1"); len(sent) != 0 {
+			t.Errorf("Expected no repeat notifications, got %v", sent)
+		}
+	})
+
+	t.Run("list changes", func(t *testing.T) {
+		t.Logf("  > Why it's important: A new or renamed list changes which rtm://lists/{name} resources exist.")
+		advance(time.Minute)
+		fake.mu.Lock()
+		fake.lists = append(fake.lists, List{ID: "work", Name: "Work"})
+		fake.mu.Unlock()
+		watcher.poll(context.Background())
+		sent := notifier.take("session-1")
+		if len(sent) != 2 || sent[0] != mcp.MethodNotificationResourcesListChanged || sent[1] != mcp.MethodNotificationResourceUpdated+" rtm://lists" {
+			t.Errorf("Expected list_changed and an rtm://lists update, got %v", sent)
+		}
+	})
+
+	t.Run("deleted tasks", func(t *testing.T) {
+		t.Logf("  > Why it's important: A deleted task's detail resource is gone and the views it was in have changed.")
+		advance(time.Minute)
+		fake.change("1", func(task *fakeTask) { task.deleted = true })
+		watcher.poll(context.Background())
+		sent := strings.Join(notifier.take("session-1"), "\n")
+		if !strings.Contains(sent, TaskURI("1", "t1")) {
+			t.Errorf("Expected an update for the deleted task, got:\n%s", sent)
+		}
+	})
+
+	t.Run("disconnected sessions", func(t *testing.T) {
+		t.Logf("  > Why it's important: Nobody is left to notify, so RTM should not be polled for them.")
+		hooks.UnregisterSession(context.Background(), watchedSession("session-1"))
+		advance(time.Minute)
+		fake.change("2", func(task *fakeTask) { task.name = "Call mum" })
+		fake.mu.Lock()
+		fake.calls = nil
+		fake.mu.Unlock()
+		watcher.poll(context.Background())
+		if sent := notifier.take("session-1"); len(sent) != 0 || len(fake.calls) != 0 {
+			t.Errorf("Expected no polling or notifications, got %v and %d calls", sent, len(fake.calls))
+		}
+	})
+}
@@ -0,0 +1,218 @@
+package rtm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vcto/mcp-adapters/internal/health"
+)
+
+// newRetryTestClient returns a client with fast retries and no rate limiting
+func newRetryTestClient(url string) *Client {
+	client := NewClient("key", "secret")
+	client.BaseURL = url
+	client.Limiter = nil
+	client.Retry = RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: 4 * time.Millisecond}
+	return client
+}
+
+func TestClientRetry(t *testing.T) {
+	t.Logf("Importance: RTM has brief outages; retrying transient failures keeps a blip from surfacing as a failed tool call.")
+
+	t.Run("retries server errors until success", func(t *testing.T) {
+		t.Logf("  > Why it's important: A single 502 from RTM's load balancer should not fail the user's request.")
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&calls, 1) < 3 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok"}}`)
+		}))
+		defer server.Close()
+
+		client := newRetryTestClient(server.URL)
+		if _, err := client.Call("rtm.test.echo", nil); err != nil {
+			t.Fatalf("Expected success after retries, got %v", err)
+		}
+		if calls != 3 {
+			t.Errorf("Expected 3 attempts, got %d", calls)
+		}
+		if state := client.Breaker.Snapshot(); state.ConsecutiveFailures != 0 {
+			t.Errorf("Expected retried success not to count against the breaker, got %+v", state)
+		}
+	})
+
+	t.Run("retries service unavailable error", func(t *testing.T) {
+		t.Logf("  > Why it's important: RTM reports maintenance as error 105 inside a 200 response.")
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"fail","err":{"code":"105","msg":"Service currently unavailable"}}}`)
+		}))
+		defer server.Close()
+
+		client := newRetryTestClient(server.URL)
+		_, err := client.Call("rtm.test.echo", nil)
+
+		var rtmErr *RTMError
+		if !errors.As(err, &rtmErr) || rtmErr.Code != 105 {
+			t.Fatalf("Expected RTM error 105, got %v", err)
+		}
+		if !health.IsUpstream(err) {
+			t.Error("Expected error 105 to be reported as an upstream failure")
+		}
+		if calls != 4 {
+			t.Errorf("Expected 1 attempt plus 3 retries, got %d", calls)
+		}
+	})
+
+	t.Run("does not retry client errors", func(t *testing.T) {
+		t.Logf("  > Why it's important: Retrying an invalid request only wastes the rate limit budget.")
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"fail","err":{"code":"340","msg":"Invalid list"}}}`)
+		}))
+		defer server.Close()
+
+		client := newRetryTestClient(server.URL)
+		if _, err := client.Call("rtm.test.echo", nil); err == nil {
+			t.Fatal("Expected error for failed response")
+		}
+		if calls != 1 {
+			t.Errorf("Expected a single attempt, got %d", calls)
+		}
+	})
+
+	t.Run("does not retry writes", func(t *testing.T) {
+		t.Logf("  > Why it's important: A write that timed out after RTM committed it would create a duplicate task if replayed.")
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		client := newRetryTestClient(server.URL)
+		if _, err := client.Call("rtm.tasks.add", nil); err == nil {
+			t.Fatal("Expected the server error reported")
+		}
+		if calls != 1 {
+			t.Errorf("Expected a single attempt, got %d", calls)
+		}
+		for method, want := range map[string]bool{"rtm.tasks.getList": true, "rtm.timelines.create": true, "rtm.auth.checkToken": true, "rtm.tasks.setDueDate": false, "rtm.tasks.complete": false} {
+			if isIdempotent(method) != want {
+				t.Errorf("Expected isIdempotent(%s) = %v", method, want)
+			}
+		}
+	})
+
+	t.Run("retries writes RTM did not act on", func(t *testing.T) {
+		t.Logf("  > Why it's important: Error 105, a 503 and a refused connection all mean the write was not applied, so sending it again is safe.")
+		for name, respond := range map[string]func(w http.ResponseWriter){
+			"error 105": func(w http.ResponseWriter) {
+				_, _ = fmt.Fprint(w, `{"rsp":{"stat":"fail","err":{"code":"105","msg":"Service currently unavailable"}}}`)
+			},
+			"HTTP 503": func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+		} {
+			var calls int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&calls, 1) < 3 {
+					respond(w)
+					return
+				}
+				_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok"}}`)
+			}))
+			client := newRetryTestClient(server.URL)
+			if _, err := client.Call("rtm.tasks.add", nil); err != nil || calls != 3 {
+				t.Errorf("%s: expected the add retried until it succeeded, got %d attempts, %v", name, calls, err)
+			}
+			server.Close()
+		}
+
+		closed := httptest.NewServer(http.NotFoundHandler())
+		closed.Close()
+		client := newRetryTestClient(closed.URL)
+		_, err := client.Call("rtm.tasks.add", nil)
+		if !notSent(err) || !notApplied(err) {
+			t.Errorf("Expected a refused connection reported as never sent, got %v", err)
+		}
+	})
+
+	t.Run("retries network timeouts", func(t *testing.T) {
+		t.Logf("  > Why it's important: A slow response should be retried rather than reported as an outage right away.")
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				time.Sleep(100 * time.Millisecond)
+			}
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok"}}`)
+		}))
+		defer server.Close()
+
+		client := newRetryTestClient(server.URL)
+		client.client.Timeout = 20 * time.Millisecond
+		if _, err := client.Call("rtm.test.echo", nil); err != nil {
+			t.Fatalf("Expected success after timeout retry, got %v", err)
+		}
+		if calls != 2 {
+			t.Errorf("Expected 2 attempts, got %d", calls)
+		}
+	})
+
+	t.Run("stops when the request deadline runs out", func(t *testing.T) {
+		t.Logf("  > Why it's important: A slow search must fail while the client is still waiting, without being blamed on RTM.")
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok"}}`)
+		}))
+		defer server.Close()
+		defer close(release)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		client := newRetryTestClient(server.URL).WithContext(ctx)
+
+		start := time.Now()
+		_, err := client.Call("rtm.tasks.getList", nil)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Expected the deadline error, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Expected the call to stop at the deadline, took %v", elapsed)
+		}
+		if health.IsUpstream(err) {
+			t.Error("Expected a spent budget not to be reported as an upstream failure")
+		}
+		if state := client.Breaker.Snapshot(); state.ConsecutiveFailures != 0 {
+			t.Errorf("Expected a spent budget not to count against the breaker, got %+v", state)
+		}
+	})
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	t.Logf("Importance: Backoff must grow between retries but stay capped so calls don't stall indefinitely.")
+
+	policy := RetryPolicy{MaxRetries: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: 400 * time.Millisecond}
+	for retry, limit := range map[int]time.Duration{1: 100, 2: 200, 3: 400, 4: 400} {
+		limit *= time.Millisecond
+		for i := 0; i < 20; i++ {
+			if delay := policy.backoff(retry); delay < limit/2 || delay > limit {
+				t.Errorf("retry %d: delay %v outside [%v, %v]", retry, delay, limit/2, limit)
+			}
+		}
+	}
+}
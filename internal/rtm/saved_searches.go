@@ -0,0 +1,79 @@
+package rtm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vcto/mcp-adapters/internal/kv"
+	"github.com/vcto/mcp-adapters/internal/residency"
+)
+
+// savedSearchBucket is the kv bucket holding saved search presets
+const savedSearchBucket = "rtm_saved_searches"
+
+// SavedSearch is a named RTM query saved by a user
+type SavedSearch struct {
+	Name    string    `json:"name"`
+	Query   string    `json:"query"`
+	SavedAt time.Time `json:"saved_at"`
+}
+
+// SavedSearches stores search presets per user. Owners are keyed by a hash
+// of the RTM auth token, as in the intent log, so presets follow the user
+// across sessions without tokens being written to disk.
+type SavedSearches struct {
+	searches *kv.Bucket[SavedSearch]
+}
+
+// NewSavedSearches creates saved search storage backed by store
+func NewSavedSearches(store kv.Store) *SavedSearches {
+	return &SavedSearches{searches: kv.NewBucket[SavedSearch](store, savedSearchBucket)}
+}
+
+// Save stores query under name for the owner, replacing any existing preset
+func (s *SavedSearches) Save(owner, name, query string) error {
+	search := SavedSearch{Name: name, Query: query, SavedAt: time.Now().UTC()}
+	if err := s.searches.Put(savedSearchKey(owner, name), search, 0); err != nil {
+		return fmt.Errorf("saving search %q: %w", name, err)
+	}
+	residency.Record(owner, residency.StoreSavedSearches)
+	return nil
+}
+
+// Get returns the owner's preset with the given name
+func (s *SavedSearches) Get(owner, name string) (SavedSearch, bool, error) {
+	return s.searches.Get(savedSearchKey(owner, name))
+}
+
+// List returns the owner's presets sorted by name
+func (s *SavedSearches) List(owner string) ([]SavedSearch, error) {
+	keys, err := s.searches.Keys()
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := savedSearchKey(owner, "")
+	searches := []SavedSearch{}
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		search, ok, err := s.searches.Get(key)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			searches = append(searches, search)
+		}
+	}
+	sort.Slice(searches, func(i, j int) bool { return searches[i].Name < searches[j].Name })
+	return searches, nil
+}
+
+// savedSearchKey scopes a preset name to its owner; owners are fixed-length
+// hex so names containing "/" cannot collide across users
+func savedSearchKey(owner, name string) string {
+	return owner + "/" + name
+}
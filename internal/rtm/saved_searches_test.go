@@ -0,0 +1,110 @@
+package rtm
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/vcto/mcp-adapters/internal/kv"
+)
+
+func TestSavedSearchesSurviveRestart(t *testing.T) {
+	t.Logf("Importance: Saved search presets are only useful if they are still there after a deploy or restart.")
+
+	path := filepath.Join(t.TempDir(), "kv.db")
+	store, err := kv.NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	alice, bob := intentOwner("alice-token"), intentOwner("bob-token")
+
+	searches := NewSavedSearches(store)
+	if err := searches.Save(alice, "urgent", "priority:1"); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := searches.Save(alice, "work/today", "list:Work AND due:today"); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := searches.Save(bob, "urgent", "priority:2"); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	reopened, err := kv.NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer func() {
+		_ = reopened.Close()
+	}()
+	searches = NewSavedSearches(reopened)
+
+	t.Run("presets are read back after reopening", func(t *testing.T) {
+		t.Logf("  > Why it's important: This is the restart the in-memory map could not survive.")
+		saved, ok, err := searches.Get(alice, "urgent")
+		if err != nil || !ok {
+			t.Fatalf("Expected saved search, got ok=%v err=%v", ok, err)
+		}
+		if saved.Query != "priority:1" || saved.SavedAt.IsZero() {
+			t.Errorf("Unexpected saved search %+v", saved)
+		}
+	})
+
+	t.Run("presets are scoped to their owner", func(t *testing.T) {
+		t.Logf("  > Why it's important: One user's presets must never run for another user of a shared server.")
+		saved, _, _ := searches.Get(bob, "urgent")
+		if saved.Query != "priority:2" {
+			t.Errorf("Expected bob's own query, got %q", saved.Query)
+		}
+		list, err := searches.List(alice)
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		if len(list) != 2 || list[0].Name != "urgent" || list[1].Name != "work/today" {
+			t.Errorf("Expected alice's two presets sorted by name, got %+v", list)
+		}
+		if _, ok, _ := searches.Get(intentOwner("carol-token"), "urgent"); ok {
+			t.Error("Expected no presets for a new user")
+		}
+	})
+}
+
+func TestHandleSaveSearch(t *testing.T) {
+	t.Logf("Importance: save_rtm_search_preset is how users create presets, and it must store them per user.")
+
+	h := &Handler{client: NewClient("key", "secret")}
+	eh := NewEnhancedHandler(h)
+	store := kv.NewMemoryStore()
+	eh.SetStore(store)
+
+	save := func(args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, _ := eh.handleSaveSearch(context.Background(), request)
+		return result
+	}
+
+	if result := save(map[string]any{"name": "urgent", "query": "priority:1"}); !result.IsError {
+		t.Error("Expected an error before authentication")
+	}
+
+	h.client.AuthToken = "token"
+	if result := save(map[string]any{"name": " ", "query": "priority:1"}); !result.IsError {
+		t.Error("Expected an error for a blank name")
+	}
+	if result := save(map[string]any{"name": "urgent", "query": "priority:1"}); result.IsError {
+		t.Fatalf("Unexpected error: %v", result.Content)
+	}
+
+	saved, ok, err := NewSavedSearches(store).Get(intentOwner("token"), "urgent")
+	if err != nil || !ok || saved.Query != "priority:1" {
+		t.Errorf("Expected preset in the configured store, got %+v ok=%v err=%v", saved, ok, err)
+	}
+	if message := eh.unknownSavedSearch(intentOwner("token"), "missing"); !strings.Contains(message, "Saved searches: urgent") {
+		t.Errorf("Expected unknown preset message to list saved names, got %q", message)
+	}
+}
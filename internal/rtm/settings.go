@@ -0,0 +1,123 @@
+package rtm
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Settings are the user's Remember The Milk preferences
+type Settings struct {
+	// Timezone is an IANA zone name such as "Europe/London"; empty if unset
+	Timezone string `json:"timezone"`
+	// DateFormat is "0" for European (day first) or "1" for American dates
+	DateFormat string `json:"dateformat"`
+	// TimeFormat is "0" for 12-hour or "1" for 24-hour times
+	TimeFormat  string `json:"timeformat"`
+	DefaultList string `json:"defaultlist"`
+	Language    string `json:"language"`
+}
+
+// GetSettings retrieves the user's preferences
+func (c *Client) GetSettings() (*Settings, error) {
+	resp, err := c.Call("rtm.settings.getList", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Rsp struct {
+			Stat     string   `json:"stat"`
+			Settings Settings `json:"settings"`
+		} `json:"rsp"`
+	}
+
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("parsing settings: %w", err)
+	}
+
+	return &result.Rsp.Settings, nil
+}
+
+// userLocation returns the current user's time zone, fetching their settings
+// on first use. It falls back to UTC without caching when settings can't be
+// read, so a later call can try again.
+func (c *Client) userLocation() *time.Location {
+	if loc, ok := c.zones.get(c.AuthToken); ok {
+		return loc
+	}
+
+	settings, err := c.GetSettings()
+	if err != nil {
+		log.Printf("RTM: Could not read settings, showing due dates in UTC: %v", err)
+		return time.UTC
+	}
+
+	loc := time.UTC
+	if settings.Timezone != "" {
+		if loaded, err := time.LoadLocation(settings.Timezone); err == nil {
+			loc = loaded
+		} else {
+			log.Printf("RTM: Unknown timezone %q, showing due dates in UTC", settings.Timezone)
+		}
+	}
+
+	c.zones.put(c.AuthToken, loc)
+	return loc
+}
+
+// zoneCache holds time zones by auth token. A nil cache stores nothing.
+type zoneCache struct {
+	mu    sync.Mutex
+	zones map[string]*time.Location
+}
+
+func (z *zoneCache) get(token string) (*time.Location, bool) {
+	if z == nil {
+		return nil, false
+	}
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	loc, ok := z.zones[token]
+	return loc, ok
+}
+
+func (z *zoneCache) put(token string, loc *time.Location) {
+	if z == nil {
+		return
+	}
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	if z.zones == nil {
+		z.zones = make(map[string]*time.Location)
+	}
+	z.zones[token] = loc
+}
+
+// localizeTasks rewrites due and start dates as RFC3339 in the user's time zone
+func (c *Client) localizeTasks(tasks []Task) {
+	if len(tasks) == 0 {
+		return
+	}
+
+	loc := c.userLocation()
+	for i := range tasks {
+		tasks[i].Due = normalizeRTMDate(tasks[i].Due, loc)
+		tasks[i].Start = normalizeRTMDate(tasks[i].Start, loc)
+	}
+}
+
+// normalizeRTMDate converts an RTM timestamp (UTC) to RFC3339 with the offset
+// of loc. Empty and unparseable values are returned unchanged.
+func normalizeRTMDate(value string, loc *time.Location) string {
+	if value == "" {
+		return value
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return value
+	}
+	return t.In(loc).Format(time.RFC3339)
+}
@@ -0,0 +1,81 @@
+package rtm
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNormalizeRTMDate(t *testing.T) {
+	t.Logf("Importance: Agents reason about due dates in the user's local time; raw UTC strings put evening tasks on the wrong day.")
+
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	cases := map[string]struct {
+		in, want string
+	}{
+		"utc to local":    {"2024-05-03T17:00:00Z", "2024-05-04T02:00:00+09:00"},
+		"empty":           {"", ""},
+		"not a timestamp": {"someday", "someday"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := normalizeRTMDate(tc.in, tokyo); got != tc.want {
+				t.Errorf("Expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestGetTasksLocalizesDueDates(t *testing.T) {
+	t.Logf("Importance: Tool and resource output must show due dates with the user's offset, using the time zone from their RTM settings.")
+
+	if _, err := time.LoadLocation("America/New_York"); err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	var settingsCalls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("method") {
+		case "rtm.settings.getList":
+			atomic.AddInt32(&settingsCalls, 1)
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok","settings":{"timezone":"America/New_York","dateformat":"1","timeformat":"0","defaultlist":"100","language":"en-US"}}}`)
+		default:
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok","tasks":{"list":[{"id":"100","taskseries":[{"id":"1","name":"Call mom","tags":[],"notes":[],
+				"task":[{"id":"11","due":"2024-01-15T23:30:00Z","has_due_time":"1","completed":"","deleted":"","priority":"N"}]}]}]}}}`)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("key", "secret")
+	client.BaseURL = server.URL
+	client.Limiter = nil
+	client.AuthToken = "token-1"
+
+	for i := 0; i < 2; i++ {
+		tasks, err := client.GetTasks("", "")
+		if err != nil {
+			t.Fatalf("GetTasks failed: %v", err)
+		}
+		if len(tasks) != 1 || tasks[0].Due != "2024-01-15T18:30:00-05:00" {
+			t.Fatalf("Expected due date in New York time, got %+v", tasks)
+		}
+	}
+	if settingsCalls != 1 {
+		t.Errorf("Expected settings to be fetched once per user, got %d calls", settingsCalls)
+	}
+
+	client.AuthToken = "token-2"
+	if _, err := client.GetTasks("", ""); err != nil {
+		t.Fatalf("GetTasks failed: %v", err)
+	}
+	if settingsCalls != 2 {
+		t.Errorf("Expected settings to be fetched for a second user, got %d calls", settingsCalls)
+	}
+}
@@ -0,0 +1,48 @@
+package rtm
+
+import (
+	"time"
+
+	"github.com/vcto/mcp-adapters/internal/health"
+)
+
+// AdapterStatus reports RTM auth, circuit and cache state for adapter_status
+func (h *Handler) AdapterStatus() health.AdapterStatus {
+	status := health.AdapterStatus{
+		Name:          "rtm",
+		Authenticated: h.client.GetAuthToken() != "" || h.registry().Len() > 0,
+	}
+	if !status.Authenticated {
+		status.AuthDetail = "No RTM auth token. Use rtm_auth_url to authenticate."
+	}
+
+	if h.client.Breaker != nil {
+		status.Circuit = h.client.Breaker.Snapshot()
+	}
+
+	var searchUpdated time.Time
+	h.searchMu.Lock()
+	for _, cached := range h.searches {
+		if cached.timestamp.After(searchUpdated) {
+			searchUpdated = cached.timestamp
+		}
+	}
+	h.searchMu.Unlock()
+	status.Caches = []health.CacheState{
+		health.NewCacheState("search_results", searchUpdated),
+		health.NewCacheState("tasks", h.client.Tasks.lastSync()),
+	}
+	if h.fallback != nil {
+		status.Caches = append(status.Caches,
+			h.fallback.CacheState("rtm://today", fallbackKey(h.client, "rtm://today")),
+			h.fallback.CacheState("rtm://lists", fallbackKey(h.client, "rtm://lists")),
+		)
+	}
+
+	return status
+}
+
+// UpstreamBreaker returns the breaker guarding RTM calls, used by simulate_outage
+func (h *Handler) UpstreamBreaker() *health.Breaker {
+	return h.client.Breaker
+}
@@ -0,0 +1,54 @@
+package rtm
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseTagNames(t *testing.T) {
+	t.Logf("Importance: RTM encodes tags in several shapes; misparsing them would hide tags from agents and break tag validation.")
+
+	cases := []struct {
+		name string
+		raw  string
+		want []string
+	}{
+		{"empty array", `[]`, nil},
+		{"missing", ``, nil},
+		{"list of names", `{"tag":["work","home"]}`, []string{"work", "home"}},
+		{"single name", `{"tag":"work"}`, []string{"work"}},
+		{"list of objects", `{"tag":[{"name":"work"},{"name":"errands"}]}`, []string{"work", "errands"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := parseTagNames(json.RawMessage(tc.raw))
+			if !reflect.DeepEqual(got, tc.want) && !(len(got) == 0 && len(tc.want) == 0) {
+				t.Errorf("Expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestTagUsages(t *testing.T) {
+	t.Logf("Importance: Usage counts let smart-create and batch-tag tools prefer tags that are actually in use.")
+
+	tasks := []Task{
+		{ID: "1", Tags: []string{"work", "urgent"}},
+		{ID: "2", Tags: []string{"work"}},
+		{ID: "3", Tags: []string{"someday"}},
+	}
+
+	usage := TagUsages([]string{"work", "urgent", "unused"}, tasks)
+
+	want := []TagUsage{
+		{Name: "work", Count: 2},
+		{Name: "someday", Count: 1},
+		{Name: "urgent", Count: 1},
+		{Name: "unused", Count: 0},
+	}
+	if !reflect.DeepEqual(usage, want) {
+		t.Errorf("Expected %v, got %v", want, usage)
+	}
+}
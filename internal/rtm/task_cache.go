@@ -0,0 +1,229 @@
+package rtm
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultTaskCacheTTL is how long cached task lists are kept up to date with
+// deltas before they are fetched in full again
+const defaultTaskCacheTTL = 15 * time.Minute
+
+// taskSyncSkew is subtracted from each last_sync time so changes RTM stamps
+// slightly before our clock's sync time are not missed. Changes inside the
+// window are merged again, which is harmless.
+const taskSyncSkew = 30 * time.Second
+
+// maxCachedQueries bounds the task lists cached for one user; the least
+// recently used is dropped first
+const maxCachedQueries = 32
+
+// taskQuery identifies one rtm.tasks.getList result
+type taskQuery struct {
+	filter string
+	listID string
+	opts   TaskListOptions
+}
+
+// taskKey identifies one task occurrence
+type taskKey struct {
+	seriesID string
+	taskID   string
+}
+
+type cachedTasks struct {
+	tasks     []Task
+	fetchedAt time.Time
+	syncedAt  time.Time
+	usedAt    time.Time
+}
+
+// TaskCache keeps the results of rtm.tasks.getList per auth token and query.
+// Later reads ask RTM only for tasks changed since the last sync (its
+// last_sync parameter) and merge them in, so repeated reads of rtm://today or
+// rtm://inbox cost one small request while nothing changes. A TTL of 0
+// disables caching.
+type TaskCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	queries map[string]map[taskQuery]*cachedTasks
+	// large holds the tokens of accounts too large to fetch whole
+	large map[string]bool
+	now   func() time.Time
+}
+
+// NewTaskCache creates a cache that keeps task lists for ttl between full
+// fetches
+func NewTaskCache(ttl time.Duration) *TaskCache {
+	return &TaskCache{
+		ttl:     ttl,
+		queries: make(map[string]map[taskQuery]*cachedTasks),
+		large:   make(map[string]bool),
+		now:     time.Now,
+	}
+}
+
+// get returns the token's cached result for query while it is within the TTL
+// and on the same day in loc, since filters such as due:today change meaning
+// at midnight
+func (tc *TaskCache) get(token string, query taskQuery, loc func() *time.Location) (cachedTasks, bool) {
+	if tc == nil || tc.ttl <= 0 || token == "" {
+		return cachedTasks{}, false
+	}
+
+	tc.mu.Lock()
+	cached, ok := tc.queries[token][query]
+	now := tc.now()
+	if ok && now.Sub(cached.fetchedAt) >= tc.ttl {
+		delete(tc.queries[token], query)
+		ok = false
+	}
+	var snapshot cachedTasks
+	if ok {
+		cached.usedAt = now
+		snapshot = *cached
+	}
+	tc.mu.Unlock()
+
+	if !ok {
+		return cachedTasks{}, false
+	}
+	if zone := loc(); !sameDay(snapshot.fetchedAt.In(zone), now.In(zone)) {
+		tc.drop(token, query)
+		return cachedTasks{}, false
+	}
+	return snapshot, true
+}
+
+// put stores tasks for the token's query as synced at syncedAt. A zero
+// fetchedAt marks a full fetch made at syncedAt.
+func (tc *TaskCache) put(token string, query taskQuery, tasks []Task, fetchedAt, syncedAt time.Time) {
+	if tc == nil || tc.ttl <= 0 || token == "" {
+		return
+	}
+	if fetchedAt.IsZero() {
+		fetchedAt = syncedAt
+	}
+
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	queries := tc.queries[token]
+	if queries == nil {
+		queries = make(map[taskQuery]*cachedTasks)
+		tc.queries[token] = queries
+	}
+	if _, ok := queries[query]; !ok && len(queries) >= maxCachedQueries {
+		evictLeastUsed(queries)
+	}
+	queries[query] = &cachedTasks{
+		tasks:     append([]Task(nil), tasks...),
+		fetchedAt: fetchedAt,
+		syncedAt:  syncedAt,
+		usedAt:    tc.now(),
+	}
+}
+
+// drop forgets the token's cached result for query
+func (tc *TaskCache) drop(token string, query taskQuery) {
+	if tc == nil {
+		return
+	}
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	delete(tc.queries[token], query)
+}
+
+// lastSync returns the most recent sync time across all cached task lists,
+// and the zero time when nothing is cached
+func (tc *TaskCache) lastSync() time.Time {
+	if tc == nil {
+		return time.Time{}
+	}
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	var latest time.Time
+	for _, queries := range tc.queries {
+		for _, cached := range queries {
+			if cached.syncedAt.After(latest) {
+				latest = cached.syncedAt
+			}
+		}
+	}
+	return latest
+}
+
+// syncTime returns the time to record for a sync starting now
+// markLarge records that the token's account is fetched one list at a time
+// and drops its cached lists
+func (tc *TaskCache) markLarge(token string) {
+	if tc == nil || token == "" {
+		return
+	}
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.large[token] = true
+	delete(tc.queries, token)
+}
+
+// isLarge reports whether markLarge was called for the token
+func (tc *TaskCache) isLarge(token string) bool {
+	if tc == nil {
+		return false
+	}
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	return tc.large[token]
+}
+
+func (tc *TaskCache) syncTime() time.Time {
+	if tc == nil {
+		return time.Now()
+	}
+	return tc.now()
+}
+
+func evictLeastUsed(queries map[taskQuery]*cachedTasks) {
+	var oldest taskQuery
+	var oldestAt time.Time
+	for query, cached := range queries {
+		if oldestAt.IsZero() || cached.usedAt.Before(oldestAt) {
+			oldest, oldestAt = query, cached.usedAt
+		}
+	}
+	delete(queries, oldest)
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// mergeTasks applies a delta to cached tasks. Every task in changed is
+// removed, then those in matching (the changed tasks that still match the
+// query) are put back: in place when they were cached, appended otherwise.
+func mergeTasks(cached []Task, changed map[taskKey]bool, matching []Task) []Task {
+	updates := make(map[taskKey]Task, len(matching))
+	for _, task := range matching {
+		updates[taskKey{task.SeriesID, task.ID}] = task
+	}
+
+	merged := make([]Task, 0, len(cached)+len(matching))
+	for _, task := range cached {
+		key := taskKey{task.SeriesID, task.ID}
+		if update, ok := updates[key]; ok {
+			merged = append(merged, update)
+			delete(updates, key)
+			continue
+		}
+		if !changed[key] {
+			merged = append(merged, task)
+		}
+	}
+	for _, task := range matching {
+		if _, ok := updates[taskKey{task.SeriesID, task.ID}]; ok {
+			merged = append(merged, task)
+		}
+	}
+	return merged
+}
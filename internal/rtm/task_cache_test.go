@@ -0,0 +1,247 @@
+package rtm
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncingRTM fakes rtm.tasks.getList with last_sync support, and
+// rtm.lists.getList. Only the "list:Inbox" filter and list_id are understood.
+type syncingRTM struct {
+	mu    sync.Mutex
+	now   time.Time
+	tasks map[string]*fakeTask
+	lists []List
+	calls []map[string]string
+}
+
+type fakeTask struct {
+	name      string
+	list      string
+	completed bool
+	deleted   bool
+	modified  time.Time
+}
+
+func (f *syncingRTM) change(id string, apply func(*fakeTask)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	task, ok := f.tasks[id]
+	if !ok {
+		task = &fakeTask{list: "inbox"}
+		f.tasks[id] = task
+	}
+	apply(task)
+	task.modified = f.now
+}
+
+func (f *syncingRTM) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("method") == "rtm.lists.getList" {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(map[string]any{"rsp": map[string]any{"stat": "ok", "lists": map[string]any{"list": f.lists}}})
+		return
+	}
+	if query.Get("method") != "rtm.tasks.getList" {
+		_, _ = w.Write([]byte(`{"rsp":{"stat":"ok","settings":{"timezone":""}}}`))
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, map[string]string{"filter": query.Get("filter"), "last_sync": query.Get("last_sync"), "list_id": query.Get("list_id")})
+
+	var since time.Time
+	if value := query.Get("last_sync"); value != "" {
+		since, _ = time.Parse(time.RFC3339, value)
+	}
+	type task struct {
+		ID        string `json:"id"`
+		Completed string `json:"completed"`
+		Deleted   string `json:"deleted"`
+	}
+	type series struct {
+		ID       string `json:"id"`
+		Name     string `json:"name"`
+		Modified string `json:"modified"`
+		Task     []task `json:"task"`
+	}
+	lists := map[string]map[string][]series{}
+	ids := make([]string, 0, len(f.tasks))
+	for id := range f.tasks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		t := f.tasks[id]
+		if !since.IsZero() && !t.modified.After(since) {
+			continue
+		}
+		if lists[t.list] == nil {
+			lists[t.list] = map[string][]series{}
+		}
+		entry := series{ID: id, Name: t.name, Modified: t.modified.UTC().Format(time.RFC3339), Task: []task{{ID: "t" + id}}}
+		switch {
+		case t.deleted && !since.IsZero():
+			lists[t.list]["deleted"] = append(lists[t.list]["deleted"], entry)
+			continue
+		case t.deleted:
+			continue
+		case query.Get("filter") == "list:Inbox" && t.list != "inbox":
+			continue
+		case query.Get("list_id") != "" && t.list != query.Get("list_id"):
+			continue
+		}
+		if t.completed {
+			entry.Task[0].Completed = "2026-01-01T00:00:00Z"
+		}
+		lists[t.list]["taskseries"] = append(lists[t.list]["taskseries"], entry)
+	}
+
+	var list []map[string]any
+	for id, entries := range lists {
+		item := map[string]any{"id": id, "taskseries": entries["taskseries"]}
+		if deleted := entries["deleted"]; deleted != nil {
+			item["deleted"] = map[string]any{"taskseries": deleted}
+		}
+		list = append(list, item)
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{"rsp": map[string]any{"stat": "ok", "tasks": map[string]any{"list": list}}})
+}
+
+func taskNames(tasks []Task) []string {
+	names := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		names = append(names, task.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestTaskCacheSync(t *testing.T) {
+	t.Logf("Importance: Resources like rtm://inbox are read on every turn; fetching only what changed saves both latency and RTM's small request quota.")
+
+	now := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+	fake := &syncingRTM{now: now.Add(-time.Hour), tasks: map[string]*fakeTask{}}
+	fake.change("1", func(task *fakeTask) { task.name = "Buy milk" })
+	fake.change("2", func(task *fakeTask) { task.name = "Call mum" })
+	fake.change("3", func(task *fakeTask) { task.name = "File taxes"; task.list = "work" })
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client := NewClient("key", "secret")
+	client.BaseURL = server.URL
+	client.AuthToken = "token"
+	client.Limiter = nil
+	client.Tasks = NewTaskCache(time.Hour)
+	client.Tasks.now = func() time.Time { return now }
+
+	advance := func() {
+		now = now.Add(time.Minute)
+		fake.mu.Lock()
+		fake.now = now
+		fake.calls = nil
+		fake.mu.Unlock()
+	}
+	inbox := func(t *testing.T, want ...string) {
+		t.Helper()
+		tasks, err := client.GetTasks("list:Inbox", "")
+		if err != nil {
+			t.Fatalf("GetTasks failed: %v", err)
+		}
+		got := taskNames(tasks)
+		if len(got) != len(want) {
+			t.Fatalf("Expected %v, got %v", want, got)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("Expected %v, got %v", want, got)
+			}
+		}
+	}
+
+	inbox(t, "Buy milk", "Call mum")
+
+	t.Run("unchanged list costs one delta request", func(t *testing.T) {
+		t.Logf("  > Why it's important: The common case, nothing changed, must not download the whole list again.")
+		advance()
+		inbox(t, "Buy milk", "Call mum")
+		if len(fake.calls) != 1 || fake.calls[0]["last_sync"] == "" || fake.calls[0]["filter"] != "" {
+			t.Errorf("Expected a single unfiltered delta request, got %v", fake.calls)
+		}
+	})
+
+	t.Run("changes are merged", func(t *testing.T) {
+		t.Logf("  > Why it's important: Renamed, new, completed and deleted tasks must all show up without a full refetch.")
+		advance()
+		fake.change("1", func(task *fakeTask) { task.name = "Buy oat milk" })
+		fake.change("4", func(task *fakeTask) { task.name = "Book dentist" })
+		fake.change("2", func(task *fakeTask) { task.completed = true })
+		inbox(t, "Book dentist", "Buy oat milk")
+
+		advance()
+		fake.change("4", func(task *fakeTask) { task.deleted = true })
+		inbox(t, "Buy oat milk")
+		if len(fake.calls) != 2 || fake.calls[1]["filter"] != "list:Inbox" || fake.calls[1]["last_sync"] == "" {
+			t.Errorf("Expected a change check then a filtered delta, got %v", fake.calls)
+		}
+	})
+
+	t.Run("tasks that stop matching are removed", func(t *testing.T) {
+		t.Logf("  > Why it's important: A filtered delta alone never mentions a task moved out of the list, so it would linger in the cache.")
+		advance()
+		fake.change("1", func(task *fakeTask) { task.list = "work" })
+		inbox(t)
+	})
+
+	t.Run("full fetch after the TTL", func(t *testing.T) {
+		t.Logf("  > Why it's important: A periodic full fetch bounds how long any missed delta can leave the cache wrong.")
+		fake.change("5", func(task *fakeTask) { task.name = "Water plants" })
+		now = now.Add(2 * time.Hour)
+		fake.mu.Lock()
+		fake.calls = nil
+		fake.mu.Unlock()
+		inbox(t, "Water plants")
+		if len(fake.calls) != 1 || fake.calls[0]["last_sync"] != "" {
+			t.Errorf("Expected one full request, got %v", fake.calls)
+		}
+	})
+
+	t.Run("users do not share results", func(t *testing.T) {
+		t.Logf("  > Why it's important: One user's cached tasks must never answer another user's read.")
+		advance()
+		other := client.ForToken("other")
+		if _, err := other.GetTasks("list:Inbox", ""); err != nil {
+			t.Fatalf("GetTasks failed: %v", err)
+		}
+		if len(fake.calls) != 1 || fake.calls[0]["last_sync"] != "" {
+			t.Errorf("Expected a full request for a new user, got %v", fake.calls)
+		}
+	})
+}
+
+func TestMergeTasks(t *testing.T) {
+	t.Logf("Importance: Positions in task lists come from their order, so a merge must not shuffle tasks that did not change.")
+
+	cached := []Task{{SeriesID: "1", ID: "a", Name: "one"}, {SeriesID: "2", ID: "b", Name: "two"}, {SeriesID: "3", ID: "c", Name: "three"}}
+	changed := map[taskKey]bool{{"1", "a"}: true, {"2", "b"}: true, {"4", "d"}: true}
+	matching := []Task{{SeriesID: "4", ID: "d", Name: "four"}, {SeriesID: "1", ID: "a", Name: "ONE"}}
+
+	got := mergeTasks(cached, changed, matching)
+	want := []string{"ONE", "three", "four"}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %+v", want, got)
+	}
+	for i, task := range got {
+		if task.Name != want[i] {
+			t.Errorf("Expected %v, got %+v", want, got)
+			break
+		}
+	}
+}
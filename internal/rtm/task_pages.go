@@ -0,0 +1,92 @@
+package rtm
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"os"
+	"strconv"
+)
+
+// defaultChunkThreshold is how many tasks an unscoped fetch may return before
+// the account is fetched one list at a time
+const defaultChunkThreshold = 5000
+
+// ChunkThresholdFromEnv reads RTM_TASK_CHUNK_THRESHOLD; 0 never chunks
+func ChunkThresholdFromEnv() int {
+	value := os.Getenv("RTM_TASK_CHUNK_THRESHOLD")
+	if value == "" {
+		return defaultChunkThreshold
+	}
+	threshold, err := strconv.Atoi(value)
+	if err != nil || threshold < 0 {
+		log.Printf("Invalid RTM_TASK_CHUNK_THRESHOLD %q, using default %d", value, defaultChunkThreshold)
+		return defaultChunkThreshold
+	}
+	return threshold
+}
+
+// Chunked reports whether c's account is too large to fetch whole: an
+// unscoped fetch returned more than ChunkAbove tasks or timed out
+func (c *Client) Chunked() bool {
+	return c.ChunkAbove > 0 && c.Tasks.isLarge(c.AuthToken)
+}
+
+// EachTask fetches the tasks matching filter one list at a time and passes
+// them to fn until it returns false. Smart lists are skipped, as their tasks
+// live in other lists. Each list is released before the next is fetched, so
+// only what fn keeps stays in memory.
+func (c *Client) EachTask(filter string, opts TaskListOptions, fn func(Task) bool) error {
+	lists, err := c.GetLists()
+	if err != nil {
+		return err
+	}
+	for _, list := range lists {
+		if list.Smart == "1" || list.Deleted == "1" {
+			continue
+		}
+		if err := c.callContext().Err(); err != nil {
+			return err
+		}
+		tasks, _, err := c.listTasks(taskParams(taskQuery{filter: filter, listID: list.ID}), opts)
+		if err != nil {
+			return err
+		}
+		c.localizeTasks(tasks)
+		for _, task := range tasks {
+			if !fn(task) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// SearchPage returns the limit tasks matching filter from offset on, and how
+// many match in all, keeping only that page in memory. Tasks come in list
+// order, then RTM's order within each list.
+func (c *Client) SearchPage(filter string, opts TaskListOptions, offset, limit int) ([]Task, int, error) {
+	var page []Task
+	total := 0
+	err := c.EachTask(filter, opts, func(task Task) bool {
+		if total >= offset && len(page) < limit {
+			page = append(page, task)
+		}
+		total++
+		return true
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return page, total, nil
+}
+
+// isTimeout reports whether err is a call that ran out of time
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
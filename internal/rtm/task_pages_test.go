@@ -0,0 +1,117 @@
+package rtm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestChunkedTaskFetches(t *testing.T) {
+	t.Logf("Importance: Accounts with tens of thousands of tasks must not be loaded whole, or searches blow memory and time out.")
+
+	now := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+	fake := &syncingRTM{now: now, tasks: map[string]*fakeTask{}, lists: []List{
+		{ID: "inbox", Name: "Inbox"},
+		{ID: "work", Name: "Work"},
+		{ID: "urgent", Name: "Urgent", Smart: "1"},
+		{ID: "old", Name: "Old", Deleted: "1"},
+	}}
+	for i := 1; i <= 5; i++ {
+		fake.change(fmt.Sprint(i), func(task *fakeTask) { task.name = fmt.Sprintf("Task %d", i) })
+	}
+	for i := 6; i <= 9; i++ {
+		fake.change(fmt.Sprint(i), func(task *fakeTask) { task.name = fmt.Sprintf("Task %d", i); task.list = "work" })
+	}
+	rtmServer := httptest.NewServer(fake)
+	defer rtmServer.Close()
+
+	h := &Handler{client: NewClient("key", "secret")}
+	h.client.BaseURL = rtmServer.URL
+	h.client.AuthToken = "token"
+	h.client.Limiter = nil
+	h.client.Tasks = NewTaskCache(time.Hour)
+	h.client.ChunkAbove = 8
+	client := h.ClientFor(context.Background())
+
+	tasks, err := client.GetTasks("", "")
+	if err != nil {
+		t.Fatalf("GetTasks: %v", err)
+	}
+	if len(tasks) != 9 || !client.Chunked() {
+		t.Fatalf("Expected 9 tasks and the account marked as large, got %d tasks, chunked %v", len(tasks), client.Chunked())
+	}
+
+	t.Run("search fetches one list at a time", func(t *testing.T) {
+		t.Logf("  > Why it's important: Each request stays small enough to finish, and only the requested page is kept.")
+		fake.mu.Lock()
+		fake.calls = nil
+		fake.mu.Unlock()
+
+		result, err := callTool(h.handleSearch, map[string]any{
+			"query": "status:incomplete", "page": 2, "page_size": 3,
+		})
+		if err != nil || result.IsError {
+			t.Fatalf("handleSearch failed: %v %v", err, result)
+		}
+		var body struct {
+			TotalFound    int    `json:"total_found"`
+			TotalPages    int    `json:"total_pages"`
+			Tasks         []Task `json:"tasks"`
+			FetchedByList bool   `json:"fetched_by_list"`
+		}
+		if err := json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &body); err != nil {
+			t.Fatalf("decoding result: %v", err)
+		}
+		if body.TotalFound != 9 || body.TotalPages != 3 || !body.FetchedByList {
+			t.Errorf("Expected 9 tasks over 3 pages fetched by list, got %+v", body)
+		}
+		if names := taskNames(body.Tasks); len(names) != 3 || names[0] != "Task 4" || names[2] != "Task 6" {
+			t.Errorf("Expected tasks 4-6 on page 2, got %v", names)
+		}
+
+		var listIDs []string
+		for _, call := range fake.calls {
+			listIDs = append(listIDs, call["list_id"])
+		}
+		if fmt.Sprint(listIDs) != "[inbox work]" {
+			t.Errorf("Expected one fetch each for Inbox and Work, skipping smart and deleted lists, got %v", listIDs)
+		}
+	})
+
+	t.Run("pages past the end show the last page", func(t *testing.T) {
+		t.Logf("  > Why it's important: Matches the unchunked search, so paging behaves the same for every account.")
+		result, _ := callTool(h.handleSearch, map[string]any{
+			"query": "status:incomplete", "page": 7, "page_size": 4,
+		})
+		var body struct {
+			Page  int    `json:"page"`
+			Tasks []Task `json:"tasks"`
+		}
+		_ = json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &body)
+		if body.Page != 3 || len(body.Tasks) != 1 || body.Tasks[0].Name != "Task 9" {
+			t.Errorf("Expected page 3 with Task 9, got page %d with %v", body.Page, taskNames(body.Tasks))
+		}
+	})
+
+	t.Run("lookups stop at the task", func(t *testing.T) {
+		t.Logf("  > Why it's important: Finding one task should not fetch lists after the one holding it.")
+		fake.mu.Lock()
+		fake.calls = nil
+		fake.mu.Unlock()
+		task, err := client.GetTask("3", "t3")
+		if err != nil || task.Name != "Task 3" {
+			t.Fatalf("Expected Task 3, got %v, %v", task, err)
+		}
+		if len(fake.calls) != 1 {
+			t.Errorf("Expected only Inbox to be fetched, got %v", fake.calls)
+		}
+		if _, err := client.GetTask("3", "missing"); err == nil {
+			t.Error("Expected ErrTaskNotFound for an unknown task")
+		}
+	})
+}
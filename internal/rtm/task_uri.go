@@ -0,0 +1,29 @@
+package rtm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TaskURITemplate is the resource template for a single task's details
+const TaskURITemplate = "rtm://task/{series_id}/{task_id}"
+
+const taskURIPrefix = "rtm://task/"
+
+// TaskURI returns the detail resource URI for a task
+func TaskURI(seriesID, taskID string) string {
+	return taskURIPrefix + seriesID + "/" + taskID
+}
+
+// ParseTaskURI extracts the series and task IDs from a task detail URI
+func ParseTaskURI(uri string) (seriesID, taskID string, err error) {
+	rest, ok := strings.CutPrefix(uri, taskURIPrefix)
+	if !ok {
+		return "", "", fmt.Errorf("invalid task URI %q", uri)
+	}
+	parts := strings.Split(rest, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid task URI %q: expected %s", uri, TaskURITemplate)
+	}
+	return parts[0], parts[1], nil
+}
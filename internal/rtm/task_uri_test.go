@@ -0,0 +1,57 @@
+package rtm
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseTaskURI(t *testing.T) {
+	t.Logf("Importance: Clients re-read a task through rtm://task/{series_id}/{task_id}; bad URIs must be rejected, not looked up.")
+
+	seriesID, taskID, err := ParseTaskURI(TaskURI("123", "456"))
+	if err != nil || seriesID != "123" || taskID != "456" {
+		t.Errorf("Expected round trip of 123/456, got %q/%q (err=%v)", seriesID, taskID, err)
+	}
+
+	for _, uri := range []string{"rtm://task/123", "rtm://task/123/", "rtm://task/1/2/3", "rtm://lists/123/456"} {
+		if _, _, err := ParseTaskURI(uri); err == nil {
+			t.Errorf("Expected error for %q", uri)
+		}
+	}
+}
+
+func TestGetTask(t *testing.T) {
+	t.Logf("Importance: The task detail resource must find a task by ID even after it has been completed.")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("method") == "rtm.settings.getList" {
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok","settings":{"timezone":"UTC"}}}`)
+			return
+		}
+		_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok","tasks":{"list":[{"id":"100","taskseries":[
+			{"id":"1","name":"Water plants","tags":{"tag":["home"]},"notes":[],"rrule":{"every":"1","$t":"FREQ=WEEKLY;INTERVAL=1"},
+				"task":[{"id":"11","due":"","completed":"2024-01-10T10:00:00Z","deleted":"","priority":"2","estimate":"PT15M"}]},
+			{"id":"2","name":"Other","tags":[],"notes":[],"task":[{"id":"21","completed":"","deleted":"","priority":"N"}]}]}]}}}`)
+	}))
+	defer server.Close()
+
+	client := NewClient("key", "secret")
+	client.BaseURL = server.URL
+	client.Limiter = nil
+	client.AuthToken = "token"
+
+	task, err := client.GetTask("1", "11")
+	if err != nil {
+		t.Fatalf("GetTask failed: %v", err)
+	}
+	if task.Name != "Water plants" || task.Completed == "" || task.Estimate != "PT15M" || len(task.Tags) != 1 {
+		t.Errorf("Unexpected task details: %+v", task)
+	}
+
+	if _, err := client.GetTask("1", "99"); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Expected ErrTaskNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+package rtm
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// Note is a note attached to an RTM task series
+type Note struct {
+	ID       string    `json:"id"`
+	Title    string    `json:"title,omitempty"`
+	Body     string    `json:"body"`
+	Created  time.Time `json:"created"`
+	Modified time.Time `json:"modified"`
+}
+
+// rtmTaskSeries is a task series as returned by rtm.tasks.getList and the
+// task mutation methods
+type rtmTaskSeries struct {
+	ID         string          `json:"id"`
+	Created    string          `json:"created"`
+	Modified   string          `json:"modified"`
+	Name       string          `json:"name"`
+	Source     string          `json:"source"`
+	URL        string          `json:"url"`
+	LocationID string          `json:"location_id"`
+	Tags       json.RawMessage `json:"tags,omitempty"`
+	Notes      json.RawMessage `json:"notes,omitempty"`
+	RRule      json.RawMessage `json:"rrule,omitempty"`
+	Task       []struct {
+		ID           string `json:"id"`
+		Due          string `json:"due"`
+		HasDueTime   string `json:"has_due_time"`
+		Start        string `json:"start"`
+		HasStartTime string `json:"has_start_time"`
+		Added        string `json:"added"`
+		Completed    string `json:"completed"`
+		Deleted      string `json:"deleted"`
+		Priority     string `json:"priority"`
+		Postponed    string `json:"postponed"`
+		Estimate     string `json:"estimate"`
+	} `json:"task"`
+}
+
+// tasks flattens the series into one Task per occurrence
+func (s rtmTaskSeries) tasks(listID string, opts TaskListOptions) []Task {
+	tags := parseTagNames(s.Tags)
+	notes := parseNotes(s.Notes)
+	repeat := parseRepeat(s.RRule)
+	modified := parseRTMTime(s.Modified)
+
+	var tasks []Task
+	for _, task := range s.Task {
+		if task.Completed != "" && !opts.IncludeCompleted {
+			continue
+		}
+		if task.Deleted != "" && !opts.IncludeDeleted {
+			continue
+		}
+
+		postponed, _ := strconv.Atoi(task.Postponed)
+		tasks = append(tasks, Task{
+			ID:           task.ID,
+			Name:         s.Name,
+			Due:          task.Due,
+			HasDueTime:   task.HasDueTime == "1",
+			Start:        task.Start,
+			HasStartTime: task.HasStartTime == "1",
+			Priority:     task.Priority,
+			Completed:    task.Completed,
+			Deleted:      task.Deleted,
+			Modified:     modified,
+			Added:        parseRTMTime(task.Added),
+			ListID:       listID,
+			SeriesID:     s.ID,
+			URL:          s.URL,
+			LocationID:   s.LocationID,
+			Tags:         tags,
+			Notes:        notes,
+			Estimate:     task.Estimate,
+			Postponed:    postponed,
+			Repeat:       repeat,
+			Source:       s.Source,
+		})
+	}
+	return tasks
+}
+
+// parseRTMTime parses RTM's ISO 8601 timestamps, returning the zero time for
+// empty or malformed values
+func parseRTMTime(value string) time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// parseNotes decodes RTM's note container, which like tags is an empty array
+// when there are none, otherwise {"note": ...} holding one note or a list
+func parseNotes(raw json.RawMessage) []Note {
+	var container struct {
+		Note json.RawMessage `json:"note"`
+	}
+	if len(raw) == 0 || json.Unmarshal(raw, &container) != nil || len(container.Note) == 0 {
+		return nil
+	}
+
+	type rtmNote struct {
+		ID       string `json:"id"`
+		Created  string `json:"created"`
+		Modified string `json:"modified"`
+		Title    string `json:"title"`
+		Body     string `json:"$t"`
+	}
+
+	var entries []rtmNote
+	if json.Unmarshal(container.Note, &entries) != nil {
+		var single rtmNote
+		if json.Unmarshal(container.Note, &single) != nil {
+			return nil
+		}
+		entries = []rtmNote{single}
+	}
+
+	notes := make([]Note, 0, len(entries))
+	for _, n := range entries {
+		notes = append(notes, Note{
+			ID:       n.ID,
+			Title:    n.Title,
+			Body:     n.Body,
+			Created:  parseRTMTime(n.Created),
+			Modified: parseRTMTime(n.Modified),
+		})
+	}
+	return notes
+}
+
+// parseRepeat returns the recurrence rule of a repeating series, or "" when
+// the series does not repeat
+func parseRepeat(raw json.RawMessage) string {
+	var rrule struct {
+		Rule string `json:"$t"`
+	}
+	if len(raw) == 0 || json.Unmarshal(raw, &rrule) != nil {
+		return ""
+	}
+	return rrule.Rule
+}
@@ -0,0 +1,115 @@
+package rtm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const taskListResponse = `{"rsp":{"stat":"ok","tasks":{"list":[{"id":"100","taskseries":[
+	{"id":"1","created":"2024-05-01T09:00:00Z","modified":"2024-05-02T10:30:00Z","name":"Water plants","source":"api","url":"https://example.com/ferns","location_id":"987",
+	 "tags":{"tag":["home"]},
+	 "notes":{"note":{"id":"n1","created":"2024-05-01T09:05:00Z","modified":"2024-05-01T09:05:00Z","title":"Which","$t":"Ferns only"}},
+	 "rrule":{"every":"1","$t":"FREQ=WEEKLY;INTERVAL=1"},
+	 "task":[
+	   {"id":"11","due":"2024-05-03T17:00:00Z","has_due_time":"1","added":"2024-05-01T09:00:00Z","completed":"2024-04-26T17:00:00Z","deleted":"","priority":"N","postponed":"0","estimate":""},
+	   {"id":"12","due":"2024-05-10T17:00:00Z","has_due_time":"1","added":"2024-05-01T09:00:00Z","completed":"","deleted":"","priority":"2","postponed":"3","estimate":"15 min"}
+	 ]},
+	{"id":"2","created":"2024-05-01T09:00:00Z","modified":"2024-05-01T09:00:00Z","name":"Old idea","source":"js","url":"","location_id":"",
+	 "tags":[],"notes":[],"rrule":null,
+	 "task":[{"id":"21","due":"","has_due_time":"0","added":"2024-05-01T09:00:00Z","completed":"","deleted":"2024-05-02T09:00:00Z","priority":"N","postponed":"0","estimate":""}]}
+]}]}}}`
+
+func TestGetTasksWithOptions(t *testing.T) {
+	t.Logf("Importance: Agents reviewing past work need completed and deleted tasks, and every task field RTM returns.")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, taskListResponse)
+	}))
+	defer server.Close()
+
+	client := NewClient("key", "secret")
+	client.BaseURL = server.URL
+	client.Limiter = nil
+
+	t.Run("filters completed and deleted by default", func(t *testing.T) {
+		t.Logf("  > Why it's important: Existing callers expect GetTasks to return only open tasks.")
+		tasks, err := client.GetTasks("", "")
+		if err != nil {
+			t.Fatalf("GetTasks failed: %v", err)
+		}
+		if len(tasks) != 1 || tasks[0].ID != "12" {
+			t.Fatalf("Expected only open task 12, got %+v", tasks)
+		}
+	})
+
+	t.Run("includes completed and deleted on request", func(t *testing.T) {
+		t.Logf("  > Why it's important: Reviews and restores need the tasks the default view hides.")
+		tasks, err := client.GetTasksWithOptions("", "", TaskListOptions{IncludeCompleted: true})
+		if err != nil {
+			t.Fatalf("GetTasksWithOptions failed: %v", err)
+		}
+		if len(tasks) != 2 || tasks[0].ID != "11" || tasks[0].Completed == "" {
+			t.Errorf("Expected completed task 11 and open task 12, got %+v", tasks)
+		}
+
+		tasks, err = client.GetTasksWithOptions("", "", TaskListOptions{IncludeCompleted: true, IncludeDeleted: true})
+		if err != nil {
+			t.Fatalf("GetTasksWithOptions failed: %v", err)
+		}
+		if len(tasks) != 3 || tasks[2].ID != "21" || tasks[2].Deleted == "" {
+			t.Errorf("Expected deleted task 21 as well, got %+v", tasks)
+		}
+	})
+
+	t.Run("parses full task fields", func(t *testing.T) {
+		t.Logf("  > Why it's important: Notes, estimates and due times carry the detail agents need to plan work.")
+		tasks, err := client.GetTasks("", "")
+		if err != nil {
+			t.Fatalf("GetTasks failed: %v", err)
+		}
+		task := tasks[0]
+
+		if !task.HasDueTime || task.Estimate != "15 min" || task.Postponed != 3 || task.Priority != "2" {
+			t.Errorf("Unexpected task fields: %+v", task)
+		}
+		if task.Repeat != "FREQ=WEEKLY;INTERVAL=1" || task.Source != "api" {
+			t.Errorf("Expected repeat rule and source, got %q / %q", task.Repeat, task.Source)
+		}
+		if task.URL != "https://example.com/ferns" || task.LocationID != "987" {
+			t.Errorf("Expected URL and location, got %q / %q", task.URL, task.LocationID)
+		}
+		if task.Added.IsZero() || task.Modified.Format("15:04") != "10:30" {
+			t.Errorf("Expected parsed timestamps, got added=%v modified=%v", task.Added, task.Modified)
+		}
+		if len(task.Tags) != 1 || task.Tags[0] != "home" {
+			t.Errorf("Expected tag home, got %v", task.Tags)
+		}
+		if len(task.Notes) != 1 || task.Notes[0].Title != "Which" || task.Notes[0].Body != "Ferns only" || task.Notes[0].Created.IsZero() {
+			t.Errorf("Expected parsed note, got %+v", task.Notes)
+		}
+	})
+}
+
+func TestParseNotes(t *testing.T) {
+	t.Logf("Importance: RTM encodes notes as an empty array, a single object, or a list; all must parse.")
+
+	cases := map[string]struct {
+		raw  string
+		want int
+	}{
+		"empty array": {`[]`, 0},
+		"missing":     {``, 0},
+		"single note": {`{"note":{"id":"1","title":"","$t":"body"}}`, 1},
+		"note list":   {`{"note":[{"id":"1","$t":"a"},{"id":"2","$t":"b"}]}`, 2},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := parseNotes(json.RawMessage(tc.raw)); len(got) != tc.want {
+				t.Errorf("Expected %d notes, got %+v", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package rtm
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultTimelineTTL is how long a timeline is reused for later mutations
+const defaultTimelineTTL = 10 * time.Minute
+
+// errCodeInvalidTimeline is RTM's error code for an unknown or expired timeline
+const errCodeInvalidTimeline = 300
+
+type cachedTimeline struct {
+	id        string
+	createdAt time.Time
+}
+
+// TimelineCache reuses one RTM timeline per auth token so mutations don't each
+// need an rtm.timelines.create call first. A TTL of 0 disables caching.
+type TimelineCache struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	timelines map[string]cachedTimeline
+	now       func() time.Time
+}
+
+// NewTimelineCache creates a cache that keeps timelines for ttl
+func NewTimelineCache(ttl time.Duration) *TimelineCache {
+	return &TimelineCache{
+		ttl:       ttl,
+		timelines: make(map[string]cachedTimeline),
+		now:       time.Now,
+	}
+}
+
+// Get returns the token's timeline if it is younger than the TTL
+func (tc *TimelineCache) Get(token string) (string, bool) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	cached, ok := tc.timelines[token]
+	if !ok || tc.now().Sub(cached.createdAt) >= tc.ttl {
+		delete(tc.timelines, token)
+		return "", false
+	}
+	return cached.id, true
+}
+
+// Put remembers a freshly created timeline for the token
+func (tc *TimelineCache) Put(token, timeline string) {
+	if tc.ttl <= 0 || timeline == "" {
+		return
+	}
+
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.timelines[token] = cachedTimeline{id: timeline, createdAt: tc.now()}
+}
+
+// Invalidate drops the token's timeline so the next mutation creates a new one
+func (tc *TimelineCache) Invalidate(token string) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	delete(tc.timelines, token)
+}
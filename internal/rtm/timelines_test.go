@@ -0,0 +1,124 @@
+package rtm
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTimelineCache(t *testing.T) {
+	t.Logf("Importance: Reusing timelines halves the API calls per mutation, but a stale or foreign timeline must never be used.")
+
+	t.Run("expires after the TTL", func(t *testing.T) {
+		t.Logf("  > Why it's important: Long-idle timelines are replaced rather than trusted indefinitely.")
+		now := time.Now()
+		cache := NewTimelineCache(time.Minute)
+		cache.now = func() time.Time { return now }
+
+		cache.Put("token-1", "tl-1")
+		if id, ok := cache.Get("token-1"); !ok || id != "tl-1" {
+			t.Fatalf("Expected cached timeline tl-1, got %q (ok=%v)", id, ok)
+		}
+		if _, ok := cache.Get("token-2"); ok {
+			t.Error("Expected no timeline for another user")
+		}
+
+		now = now.Add(time.Minute)
+		if _, ok := cache.Get("token-1"); ok {
+			t.Error("Expected timeline to expire after the TTL")
+		}
+	})
+
+	t.Run("zero TTL disables caching", func(t *testing.T) {
+		t.Logf("  > Why it's important: RTM_TIMELINE_TTL=0 restores one timeline per mutation.")
+		cache := NewTimelineCache(0)
+		cache.Put("token-1", "tl-1")
+		if _, ok := cache.Get("token-1"); ok {
+			t.Error("Expected no caching with a zero TTL")
+		}
+	})
+}
+
+func TestClientReusesTimeline(t *testing.T) {
+	t.Logf("Importance: Mutations should only create a timeline when the user has no usable one.")
+
+	var mu sync.Mutex
+	var created int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		query := r.URL.Query()
+		switch query.Get("method") {
+		case "rtm.timelines.create":
+			created++
+			_, _ = fmt.Fprintf(w, `{"rsp":{"stat":"ok","timeline":"tl-%d"}}`, created)
+		case "rtm.tasks.complete":
+			if query.Get("task_id") == "stale" {
+				_, _ = fmt.Fprint(w, `{"rsp":{"stat":"fail","err":{"code":"300","msg":"Timeline invalid or not provided"}}}`)
+				return
+			}
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok","transaction":{"id":"tx-1","undoable":"1"}}}`)
+		default:
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok"}}`)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("key", "secret")
+	client.BaseURL = server.URL
+	client.Limiter = nil
+	client.AuthToken = "token-1"
+
+	for i := 0; i < 3; i++ {
+		if err := client.CompleteTask("l", "s", "t"); err != nil {
+			t.Fatalf("CompleteTask failed: %v", err)
+		}
+	}
+	if created != 1 {
+		t.Fatalf("Expected one timeline for three mutations, got %d", created)
+	}
+
+	client.AuthToken = "token-2"
+	if err := client.CompleteTask("l", "s", "t"); err != nil {
+		t.Fatalf("CompleteTask failed: %v", err)
+	}
+	if created != 2 {
+		t.Errorf("Expected a separate timeline for another user, got %d created", created)
+	}
+
+	t.Run("undo invalidates the timeline", func(t *testing.T) {
+		t.Logf("  > Why it's important: Changes after an undo should start on a fresh timeline.")
+		before := created
+		tx, _ := client.Transactions.Take("token-2", "")
+		if err := client.UndoTransaction(tx); err != nil {
+			t.Fatalf("UndoTransaction failed: %v", err)
+		}
+		if err := client.CompleteTask("l", "s", "t"); err != nil {
+			t.Fatalf("CompleteTask failed: %v", err)
+		}
+		if created != before+1 {
+			t.Errorf("Expected a new timeline after undo, got %d created (was %d)", created, before)
+		}
+	})
+
+	t.Run("rejected timeline is dropped", func(t *testing.T) {
+		t.Logf("  > Why it's important: If RTM expires a timeline early, the next mutation must not keep failing with it.")
+		before := created
+		if err := client.CompleteTask("l", "s", "stale"); err == nil {
+			t.Fatal("Expected invalid timeline error")
+		}
+		if _, ok := client.Timelines.Get("token-2"); ok {
+			t.Error("Expected rejected timeline to be invalidated")
+		}
+		if err := client.CompleteTask("l", "s", "t"); err != nil {
+			t.Fatalf("CompleteTask failed: %v", err)
+		}
+		if created != before+1 {
+			t.Errorf("Expected a new timeline after rejection, got %d created (was %d)", created, before)
+		}
+	})
+}
@@ -0,0 +1,158 @@
+package rtm
+
+import "github.com/vcto/mcp-adapters/internal/tooldocs"
+
+// taskSchema is the shape of a Task in tool output
+var taskSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"id":        map[string]interface{}{"type": "string"},
+		"series_id": map[string]interface{}{"type": "string"},
+		"list_id":   map[string]interface{}{"type": "string"},
+		"name":      map[string]interface{}{"type": "string"},
+		"due":       map[string]interface{}{"type": "string"},
+		"priority":  map[string]interface{}{"type": "string"},
+		"tags":      map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+	},
+}
+
+// estimateTotalSchema is the shape of an EstimateTotal in tool output
+var estimateTotalSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"name":        map[string]interface{}{"type": "string"},
+		"tasks":       map[string]interface{}{"type": "integer"},
+		"minutes":     map[string]interface{}{"type": "integer"},
+		"total":       map[string]interface{}{"type": "string"},
+		"unestimated": map[string]interface{}{"type": "integer"},
+	},
+}
+
+// ToolDocs returns curated examples and output schemas for the RTM tools,
+// served in their docs://tools/{name} resources
+func ToolDocs() tooldocs.Registry {
+	return tooldocs.Registry{
+		"rtm_whoami": {
+			Examples: []tooldocs.Example{
+				{Description: "Confirm the account before deleting tasks", Arguments: map[string]interface{}{}},
+			},
+			OutputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"user_id":   map[string]interface{}{"type": "string"},
+					"username":  map[string]interface{}{"type": "string"},
+					"full_name": map[string]interface{}{"type": "string"},
+					"perms":     map[string]interface{}{"type": "string", "enum": []string{"read", "write", "delete"}},
+				},
+			},
+		},
+		"rtm_lists": {
+			Examples: []tooldocs.Example{
+				{Description: "List every list, including smart lists", Arguments: map[string]interface{}{}},
+			},
+			OutputSchema: map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"id":       map[string]interface{}{"type": "string"},
+						"name":     map[string]interface{}{"type": "string"},
+						"archived": map[string]interface{}{"type": "string", "description": "\"1\" when archived"},
+						"smart":    map[string]interface{}{"type": "string", "description": "\"1\" for smart lists"},
+					},
+				},
+			},
+		},
+		"rtm_search": {
+			Examples: []tooldocs.Example{
+				{Description: "Work tasks due today or earlier", Arguments: map[string]interface{}{"query": "dueBefore:tomorrow AND tag:work"}},
+				{Description: "Second page of a shopping list", Arguments: map[string]interface{}{"query": "list:Shopping", "page": 2, "page_size": 10}},
+			},
+			OutputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query":       map[string]interface{}{"type": "string"},
+					"total_found": map[string]interface{}{"type": "integer"},
+					"page":        map[string]interface{}{"type": "integer"},
+					"page_size":   map[string]interface{}{"type": "integer"},
+					"total_pages": map[string]interface{}{"type": "integer"},
+					"has_more":    map[string]interface{}{"type": "boolean"},
+					"tasks":       map[string]interface{}{"type": "array", "items": taskSchema},
+					"cache_used":  map[string]interface{}{"type": "boolean"},
+				},
+			},
+		},
+		"rtm_estimate_report": {
+			Examples: []tooldocs.Example{
+				{Description: "Does today's plan fit in a six hour day?", Arguments: map[string]interface{}{"available": "6h"}},
+				{Description: "Work estimated for this week", Arguments: map[string]interface{}{"query": "dueBefore:\"1 week\" AND tag:work"}},
+			},
+			OutputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query":         map[string]interface{}{"type": "string"},
+					"tasks":         map[string]interface{}{"type": "integer"},
+					"estimated":     map[string]interface{}{"type": "integer", "description": "Tasks with a readable estimate"},
+					"minutes":       map[string]interface{}{"type": "integer"},
+					"total":         map[string]interface{}{"type": "string", "description": "e.g. \"2h 30m\""},
+					"available":     map[string]interface{}{"type": "string"},
+					"spare_minutes": map[string]interface{}{"type": "integer", "description": "Negative when the plan runs over"},
+					"fits":          map[string]interface{}{"type": "boolean"},
+					"by_list":       map[string]interface{}{"type": "array", "items": estimateTotalSchema},
+					"by_tag":        map[string]interface{}{"type": "array", "items": estimateTotalSchema},
+					"unestimated":   map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+				},
+			},
+		},
+		"rtm_quick_add": {
+			Examples: []tooldocs.Example{
+				{Description: "Add a task with a due date, priority and tag", Arguments: map[string]interface{}{"task": "Buy milk tomorrow !2 #shopping"}},
+				{Description: "Check how Smart Add reads a task without adding it", Arguments: map[string]interface{}{"task": "Dentist ^next friday 3pm @clinic", "parse_only": "true"}},
+			},
+		},
+		"rtm_update": {
+			Examples: []tooldocs.Example{
+				{Description: "Move a task's due date and raise its priority", Arguments: map[string]interface{}{"task_id": "1001", "series_id": "2001", "list_id": "3001", "due": "friday 5pm", "priority": "1"}},
+				{Description: "Attach a reference link and a saved place", Arguments: map[string]interface{}{"task_id": "1001", "series_id": "2001", "list_id": "3001", "url": "https://example.com/brief", "location": "Office"}},
+			},
+		},
+		"rtm_complete": {
+			Examples: []tooldocs.Example{
+				{Description: "Complete two tasks found by rtm_search", Arguments: map[string]interface{}{"task_id": "1001,1002", "series_id": "2001,2002", "list_id": "3001,3001"}},
+			},
+		},
+		"rtm_duplicate_task": {
+			Examples: []tooldocs.Example{
+				{Description: "Start the next launch from last quarter's checklist task", Arguments: map[string]interface{}{"series_id": "2001", "task_id": "1001", "list": "Work", "name": "Q3 launch checklist"}},
+			},
+		},
+		"rtm_manage_list": {
+			Examples: []tooldocs.Example{
+				{Description: "Create a list", Arguments: map[string]interface{}{"action": "create", "name": "Errands"}},
+				{Description: "Create a Smart List of urgent work", Arguments: map[string]interface{}{"action": "create", "name": "Urgent work", "filter": "priority:1 AND tag:work"}},
+			},
+		},
+		"search_rtm_tasks_smart": {
+			Examples: []tooldocs.Example{
+				{Description: "Number overdue and top-priority undated tasks for batch tools", Arguments: map[string]interface{}{"query": "dueBefore:today OR (priority:1 AND due:never)"}},
+				{Description: "Run a saved search", Arguments: map[string]interface{}{"use_saved": "urgent"}},
+			},
+		},
+		"set_rtm_tasks_due_date": {
+			Examples: []tooldocs.Example{
+				{Description: "Push tasks 1 and 3 of the last search to tomorrow", Arguments: map[string]interface{}{"positions": "1,3", "due_date": "tomorrow"}},
+			},
+		},
+		"delete_rtm_tasks_batch": {
+			Examples: []tooldocs.Example{
+				{Description: "See what would be deleted first", Arguments: map[string]interface{}{"positions": "2,5", "dry_run": true}},
+				{Description: "Then delete exactly those tasks", Arguments: map[string]interface{}{"task_ids": "1002,1005"}},
+			},
+		},
+		"check_rtm_job_status": {
+			Examples: []tooldocs.Example{
+				{Description: "Follow a batch job", Arguments: map[string]interface{}{"job_id": "7f3c9a2e"}},
+			},
+		},
+	}
+}
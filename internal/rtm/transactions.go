@@ -0,0 +1,123 @@
+package rtm
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultUndoHistory is how many undoable transactions are kept per session
+const defaultUndoHistory = 20
+
+// Transaction is an undoable change reported by RTM for a timeline mutation
+type Transaction struct {
+	ID        string    `json:"id"`
+	Timeline  string    `json:"timeline"`
+	Method    string    `json:"method"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TransactionLog keeps the most recent undoable transactions per user. Users
+// are told apart by RTM auth token because the HTTP transport is stateless
+// and a new MCP session is created for every request; the log files them
+// under the token's owner ID so tokens are not held in memory. Users idle
+// for longer than the TTL are forgotten, as ClientRegistry drops their
+// clients.
+type TransactionLog struct {
+	mu      sync.Mutex
+	limit   int
+	idleTTL time.Duration
+	now     func() time.Time
+	entries map[string]*userTransactions
+}
+
+type userTransactions struct {
+	txs      []Transaction
+	lastUsed time.Time
+}
+
+// NewTransactionLog creates a log that keeps up to limit transactions per
+// user, for users active within idleTTL. An idleTTL of 0 keeps them forever.
+func NewTransactionLog(limit int, idleTTL time.Duration) *TransactionLog {
+	if limit <= 0 {
+		limit = defaultUndoHistory
+	}
+	return &TransactionLog{
+		limit:   limit,
+		idleTTL: idleTTL,
+		now:     time.Now,
+		entries: make(map[string]*userTransactions),
+	}
+}
+
+// Record appends a transaction, dropping the oldest when the limit is reached
+func (l *TransactionLog) Record(token string, tx Transaction) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	user := l.user(token, true)
+	user.txs = append(user.txs, tx)
+	if len(user.txs) > l.limit {
+		user.txs = user.txs[len(user.txs)-l.limit:]
+	}
+}
+
+// Recent returns the user's transactions, newest first
+func (l *TransactionLog) Recent(token string) []Transaction {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var entries []Transaction
+	if user := l.user(token, false); user != nil {
+		entries = user.txs
+	}
+	recent := make([]Transaction, len(entries))
+	for i, tx := range entries {
+		recent[len(entries)-1-i] = tx
+	}
+	return recent
+}
+
+// Take removes and returns a transaction by ID, or the newest one if id is empty
+func (l *TransactionLog) Take(token, id string) (Transaction, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	user := l.user(token, false)
+	if user == nil {
+		return Transaction{}, false
+	}
+	for i := len(user.txs) - 1; i >= 0; i-- {
+		if id == "" || user.txs[i].ID == id {
+			tx := user.txs[i]
+			user.txs = append(user.txs[:i:i], user.txs[i+1:]...)
+			return tx, true
+		}
+	}
+	return Transaction{}, false
+}
+
+// user returns the transactions of token's owner, marking them used, after
+// evicting idle users. It creates the entry when create is set, and returns
+// nil for an unknown user otherwise. Callers hold mu.
+func (l *TransactionLog) user(token string, create bool) *userTransactions {
+	now := l.now()
+	if l.idleTTL > 0 {
+		for owner, user := range l.entries {
+			if now.Sub(user.lastUsed) > l.idleTTL {
+				delete(l.entries, owner)
+			}
+		}
+	}
+
+	owner := intentOwner(token)
+	user, ok := l.entries[owner]
+	if !ok {
+		if !create {
+			return nil
+		}
+		user = &userTransactions{}
+		l.entries[owner] = user
+	}
+	user.lastUsed = now
+	return user
+}
@@ -0,0 +1,120 @@
+package rtm
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTransactionLog(t *testing.T) {
+	t.Logf("Importance: The undo history is the only way to revert destructive changes, so it must keep the right transactions in the right order.")
+
+	t.Run("keeps only the most recent transactions", func(t *testing.T) {
+		t.Logf("  > Why it's important: Bounded history prevents unbounded memory growth for long-lived sessions.")
+		log := NewTransactionLog(3, 0)
+		for i := 1; i <= 5; i++ {
+			log.Record("s1", Transaction{ID: fmt.Sprintf("tx%d", i)})
+		}
+
+		recent := log.Recent("s1")
+		if len(recent) != 3 {
+			t.Fatalf("Expected 3 transactions, got %d", len(recent))
+		}
+		if recent[0].ID != "tx5" || recent[2].ID != "tx3" {
+			t.Errorf("Expected newest-first tx5..tx3, got %v", recent)
+		}
+	})
+
+	t.Run("takes newest or specific transaction", func(t *testing.T) {
+		t.Logf("  > Why it's important: rtm_undo defaults to the latest change but must also support targeted undo.")
+		log := NewTransactionLog(10, 0)
+		log.Record("s1", Transaction{ID: "a"})
+		log.Record("s1", Transaction{ID: "b"})
+		log.Record("s1", Transaction{ID: "c"})
+
+		tx, ok := log.Take("s1", "")
+		if !ok || tx.ID != "c" {
+			t.Errorf("Expected newest transaction 'c', got %v (ok=%v)", tx.ID, ok)
+		}
+
+		tx, ok = log.Take("s1", "a")
+		if !ok || tx.ID != "a" {
+			t.Errorf("Expected transaction 'a', got %v (ok=%v)", tx.ID, ok)
+		}
+
+		recent := log.Recent("s1")
+		if len(recent) != 1 || recent[0].ID != "b" {
+			t.Errorf("Expected only 'b' to remain, got %v", recent)
+		}
+
+		if _, ok := log.Take("s2", ""); ok {
+			t.Error("Expected no transactions for another session")
+		}
+	})
+
+	t.Run("forgets idle users", func(t *testing.T) {
+		t.Logf("  > Why it's important: Every token that ever called would otherwise stay in memory for the life of the server.")
+		log := NewTransactionLog(10, time.Hour)
+		now := time.Now()
+		log.now = func() time.Time { return now }
+		log.Record("s1", Transaction{ID: "a"})
+		now = now.Add(30 * time.Minute)
+		log.Record("s2", Transaction{ID: "b"})
+
+		now = now.Add(45 * time.Minute)
+		if recent := log.Recent("s2"); len(recent) != 1 {
+			t.Errorf("Expected the active user's history kept, got %v", recent)
+		}
+		if _, ok := log.entries[intentOwner("s1")]; ok {
+			t.Error("Expected the idle user's history evicted")
+		}
+		if _, ok := log.entries["s2"]; ok {
+			t.Error("Expected histories filed under the owner ID, not the raw token")
+		}
+	})
+}
+
+func TestClientRecordsTransactions(t *testing.T) {
+	t.Logf("Importance: Verifies that timeline mutations are captured from RTM responses so they can later be undone.")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("method") {
+		case "rtm.timelines.create":
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok","timeline":"tl-1"}}`)
+		case "rtm.tasks.complete":
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok","transaction":{"id":"tx-42","undoable":"1"}}}`)
+		case "rtm.transactions.undo":
+			if r.URL.Query().Get("timeline") != "tl-1" || r.URL.Query().Get("transaction_id") != "tx-42" {
+				_, _ = fmt.Fprint(w, `{"rsp":{"stat":"fail","err":{"code":"300","msg":"Invalid transaction"}}}`)
+				return
+			}
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok"}}`)
+		default:
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok"}}`)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("key", "secret")
+	client.BaseURL = server.URL
+	client.AuthToken = "token-1"
+
+	if err := client.CompleteTask("l", "s", "t"); err != nil {
+		t.Fatalf("CompleteTask failed: %v", err)
+	}
+
+	recent := client.Transactions.Recent("token-1")
+	if len(recent) != 1 {
+		t.Fatalf("Expected 1 recorded transaction, got %d", len(recent))
+	}
+	if recent[0].ID != "tx-42" || recent[0].Timeline != "tl-1" || recent[0].Method != "rtm.tasks.complete" {
+		t.Errorf("Unexpected transaction: %+v", recent[0])
+	}
+
+	if err := client.UndoTransaction(recent[0]); err != nil {
+		t.Errorf("UndoTransaction failed: %v", err)
+	}
+}
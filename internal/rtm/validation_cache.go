@@ -0,0 +1,116 @@
+package rtm
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/vcto/mcp-adapters/internal/config"
+)
+
+// defaultValidationTTL is how long a token RTM accepted is trusted without
+// asking RTM again. It is kept short, as a token revoked at RTM stays
+// accepted here for as long as it is cached.
+const defaultValidationTTL = 30 * time.Second
+
+// maxValidationTTL caps RTM_TOKEN_CACHE_TTL, bounding how long a token
+// revoked at RTM can go on working here
+const maxValidationTTL = time.Minute
+
+// rejectionTTL caps how long a token RTM refused stays refused, so a token
+// that starts working is noticed quickly
+const rejectionTTL = 30 * time.Second
+
+// maxCachedValidations bounds the cache; expired answers are dropped once it
+// is full, so requests with made-up tokens can't grow it without limit
+const maxCachedValidations = 10000
+
+type cachedValidation struct {
+	valid     bool
+	checkedAt time.Time
+}
+
+// ValidationCache remembers RTM's answer to whether a bearer token is valid,
+// so each MCP request doesn't cost an rtm.lists.getList call. Accepted
+// tokens are kept for the TTL, refused ones for at most rejectionTTL. A TTL
+// of 0 disables caching.
+type ValidationCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	answers map[string]cachedValidation
+	now     func() time.Time
+}
+
+// NewValidationCache creates a cache that trusts accepted tokens for ttl
+func NewValidationCache(ttl time.Duration) *ValidationCache {
+	return &ValidationCache{
+		ttl:     ttl,
+		answers: make(map[string]cachedValidation),
+		now:     time.Now,
+	}
+}
+
+// ValidationTTLFromEnv reads RTM_TOKEN_CACHE_TTL, defaulting to 30 seconds
+// and capped at maxValidationTTL
+func ValidationTTLFromEnv() time.Duration {
+	ttl := config.DurationFromEnv("RTM_TOKEN_CACHE_TTL", defaultValidationTTL)
+	if ttl < 0 {
+		log.Printf("RTM_TOKEN_CACHE_TTL must not be negative, using %s", defaultValidationTTL)
+		return defaultValidationTTL
+	}
+	if ttl > maxValidationTTL {
+		log.Printf("RTM_TOKEN_CACHE_TTL is capped at %s", maxValidationTTL)
+		return maxValidationTTL
+	}
+	return ttl
+}
+
+// Get returns the cached answer for token, if there is one still fresh
+func (vc *ValidationCache) Get(token string) (valid, ok bool) {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+
+	cached, ok := vc.answers[token]
+	if !ok || vc.expired(cached) {
+		delete(vc.answers, token)
+		return false, false
+	}
+	return cached.valid, true
+}
+
+// Put remembers RTM's answer for token
+func (vc *ValidationCache) Put(token string, valid bool) {
+	if vc.ttl <= 0 {
+		return
+	}
+
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+	if len(vc.answers) >= maxCachedValidations {
+		for key, cached := range vc.answers {
+			if vc.expired(cached) {
+				delete(vc.answers, key)
+			}
+		}
+		if len(vc.answers) >= maxCachedValidations {
+			return
+		}
+	}
+	vc.answers[token] = cachedValidation{valid: valid, checkedAt: vc.now()}
+}
+
+// Invalidate drops the answer for token so the next request asks RTM
+func (vc *ValidationCache) Invalidate(token string) {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+	delete(vc.answers, token)
+}
+
+// expired reports whether an answer has outlived its TTL
+func (vc *ValidationCache) expired(cached cachedValidation) bool {
+	ttl := vc.ttl
+	if !cached.valid && rejectionTTL < ttl {
+		ttl = rejectionTTL
+	}
+	return vc.now().Sub(cached.checkedAt) >= ttl
+}
@@ -0,0 +1,129 @@
+package rtm
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestValidationCache(t *testing.T) {
+	t.Logf("Importance: Cached answers save an RTM call per request, but must not outlive their TTL.")
+
+	now := time.Now()
+	cache := NewValidationCache(time.Minute)
+	cache.now = func() time.Time { return now }
+
+	cache.Put("good", true)
+	cache.Put("bad", false)
+	if valid, ok := cache.Get("good"); !ok || !valid {
+		t.Fatalf("Expected the accepted token cached, got valid=%v ok=%v", valid, ok)
+	}
+	if valid, ok := cache.Get("bad"); !ok || valid {
+		t.Fatalf("Expected the refused token cached, got valid=%v ok=%v", valid, ok)
+	}
+
+	t.Run("refusals expire first", func(t *testing.T) {
+		t.Logf("  > Why it's important: A token refused by mistake should work again within seconds, not minutes.")
+		now = now.Add(rejectionTTL)
+		if _, ok := cache.Get("bad"); ok {
+			t.Error("Expected the refusal to expire")
+		}
+		if _, ok := cache.Get("good"); !ok {
+			t.Error("Expected the accepted token still cached")
+		}
+		now = now.Add(time.Minute)
+		if _, ok := cache.Get("good"); ok {
+			t.Error("Expected the accepted token to expire after the TTL")
+		}
+	})
+
+	t.Run("zero TTL disables caching", func(t *testing.T) {
+		t.Logf("  > Why it's important: RTM_TOKEN_CACHE_TTL=0 restores checking every request.")
+		cache := NewValidationCache(0)
+		cache.Put("good", true)
+		if _, ok := cache.Get("good"); ok {
+			t.Error("Expected no caching with a zero TTL")
+		}
+	})
+
+	t.Run("TTL from the environment", func(t *testing.T) {
+		t.Logf("  > Why it's important: A token revoked at RTM works here for as long as it is cached, so the cache is short but on by default.")
+		t.Setenv("RTM_TOKEN_CACHE_TTL", "")
+		if ttl := ValidationTTLFromEnv(); ttl != defaultValidationTTL || ttl <= 0 {
+			t.Errorf("Expected caching for %s by default, got %s", defaultValidationTTL, ttl)
+		}
+		t.Setenv("RTM_TOKEN_CACHE_TTL", "1h")
+		if ttl := ValidationTTLFromEnv(); ttl != maxValidationTTL {
+			t.Errorf("Expected the TTL capped at %s, got %s", maxValidationTTL, ttl)
+		}
+	})
+}
+
+func TestValidateBearerCaches(t *testing.T) {
+	t.Logf("Importance: Checking every MCP request against RTM adds latency and burns API quota.")
+
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	outage := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		token := r.URL.Query().Get("auth_token")
+		calls[token]++
+		switch {
+		case token == "flaky" && outage:
+			w.WriteHeader(http.StatusBadGateway)
+		case token == "bad":
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"fail","err":{"code":"98","msg":"Login failed / Invalid auth token"}}}`)
+		default:
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok","lists":{"list":[]}}}`)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("key", "secret")
+	client.BaseURL = server.URL
+	client.Limiter = nil
+	adapter := NewOAuthAdapter("key", "secret", "http://localhost:8080")
+	defer adapter.Close()
+	adapter.SetClient(client)
+
+	for i := 0; i < 3; i++ {
+		if !adapter.ValidateBearer("good") {
+			t.Fatal("Expected the valid token accepted")
+		}
+		if adapter.ValidateBearer("bad") {
+			t.Fatal("Expected the invalid token refused")
+		}
+	}
+	if calls["good"] != 1 || calls["bad"] != 1 {
+		t.Errorf("Expected one RTM call per token, got %v", calls)
+	}
+
+	t.Run("outages are not cached", func(t *testing.T) {
+		t.Logf("  > Why it's important: A brief RTM outage must not lock a valid user out after it ends.")
+		adapter.ValidateBearer("flaky")
+		mu.Lock()
+		outage = false
+		mu.Unlock()
+		if !adapter.ValidateBearer("flaky") {
+			t.Error("Expected the token accepted once RTM recovered")
+		}
+	})
+
+	t.Run("revocation invalidates", func(t *testing.T) {
+		t.Logf("  > Why it's important: A revoked token must stop working at once, not when its cache entry expires.")
+		if err := adapter.revokeToken("good"); err != nil {
+			t.Fatalf("revokeToken: %v", err)
+		}
+		if _, ok := adapter.validations.Get("good"); ok {
+			t.Error("Expected the cached answer dropped")
+		}
+		if adapter.ValidateBearer("good") {
+			t.Error("Expected the revoked token refused")
+		}
+	})
+}
@@ -0,0 +1,72 @@
+package rtm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/vcto/mcp-adapters/internal/health"
+)
+
+func TestWhoami(t *testing.T) {
+	t.Logf("Importance: Users confirm which RTM account the server acts on, and with what permission, before destructive operations.")
+
+	t.Run("reports the token's account", func(t *testing.T) {
+		t.Logf("  > Why it's important: The username and permission come from RTM's check of the token, not from local state.")
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if method := r.URL.Query().Get("method"); method != "rtm.auth.checkToken" {
+				t.Errorf("Unexpected call to %s", method)
+			}
+			_, _ = fmt.Fprint(w, `{"rsp":{"stat":"ok","auth":{"token":"token-1","perms":"write","user":{"id":"42","username":"alice","fullname":"Alice Example"}}}}`)
+		}))
+		defer server.Close()
+
+		h := &Handler{client: NewClient("key", "secret")}
+		h.client.BaseURL = server.URL
+		h.client.Limiter = nil
+		h.client.AuthToken = "token-1"
+
+		result, _ := callTool(h.handleWhoami, map[string]any{})
+		if result.IsError {
+			t.Fatalf("rtm_whoami failed: %v", result.Content)
+		}
+		var account Account
+		if err := json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &account); err != nil {
+			t.Fatal(err)
+		}
+		want := Account{UserID: "42", Username: "alice", FullName: "Alice Example", Perms: "write"}
+		if account != want {
+			t.Errorf("Expected %+v, got %+v", want, account)
+		}
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		t.Logf("  > Why it's important: A revoked login must say to reconnect, and not invite blind retries.")
+		fake := NewFakeClient("revoked")
+		fake.Err = &RTMError{Code: errCodeInvalidToken, Msg: "Login failed / Invalid auth token"}
+
+		result, _ := callTool(NewHandlerWithClient(fake).handleWhoami, map[string]any{})
+		if !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "reconnect Remember The Milk") {
+			t.Errorf("Expected advice to reconnect, got %v", result.Content)
+		}
+		if hint, ok := health.GetRetryHint(result); !ok || hint.Retryable {
+			t.Errorf("Expected a non-retryable hint, got %+v", hint)
+		}
+		if !errors.Is(fake.Err, ErrInvalidToken) {
+			t.Error("Expected the fake's error to be ErrInvalidToken")
+		}
+	})
+
+	t.Run("not signed in", func(t *testing.T) {
+		t.Logf("  > Why it's important: Without a token there is no account to report.")
+		result, _ := callTool((&Handler{client: NewClient("key", "secret")}).handleWhoami, map[string]any{})
+		if !result.IsError {
+			t.Error("Expected an error without a token")
+		}
+	})
+}
@@ -0,0 +1,388 @@
+package security
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Scanner defaults
+const (
+	DefaultOSVURL       = "https://api.osv.dev"
+	DefaultScanInterval = 24 * time.Hour
+	// failedScanRetry is how soon a scan that could not reach OSV is retried
+	failedScanRetry = 5 * time.Minute
+	// stdlibModule is OSV's name for the Go standard library
+	stdlibModule = "stdlib"
+)
+
+// Report statuses
+const (
+	StatusOK         = "ok"
+	StatusVulnerable = "vulnerable"
+	// StatusUnknown means OSV could not be reached
+	StatusUnknown = "unknown"
+)
+
+// Vulnerability is a known advisory affecting a compiled-in module
+type Vulnerability struct {
+	ID      string   `json:"id"`
+	Aliases []string `json:"aliases,omitempty"`
+	Summary string   `json:"summary,omitempty"`
+	Module  string   `json:"module"`
+	Version string   `json:"version"`
+	// FixedIn lists versions that fix it, empty when no fix is released
+	FixedIn []string `json:"fixed_in,omitempty"`
+}
+
+// Report is an SBOM with the vulnerabilities found in it
+type Report struct {
+	Status          string          `json:"status"`
+	ScannedAt       time.Time       `json:"scanned_at"`
+	SBOM            SBOM            `json:"sbom"`
+	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+	Error           string          `json:"error,omitempty"`
+}
+
+// Summary is the part of a report safe to show on the public health
+// endpoint: counts, not which advisories apply
+type Summary struct {
+	Status          string    `json:"status"`
+	ScannedAt       time.Time `json:"scanned_at"`
+	Components      int       `json:"components"`
+	Vulnerabilities int       `json:"vulnerabilities"`
+}
+
+// Summary returns the report's public summary
+func (r Report) Summary() Summary {
+	return Summary{
+		Status:          r.Status,
+		ScannedAt:       r.ScannedAt,
+		Components:      len(r.SBOM.Components),
+		Vulnerabilities: len(r.Vulnerabilities),
+	}
+}
+
+// Scanner checks the binary's SBOM against OSV and caches the result, so
+// health checks don't query OSV on every request
+type Scanner struct {
+	baseURL  string
+	interval time.Duration
+	client   *http.Client
+	sbom     func() (SBOM, bool)
+	// onStartup scans as soon as Start is called rather than on first request
+	onStartup bool
+	scans     singleflight.Group
+
+	// mu guards the cached report only; it is never held during a scan
+	mu     sync.Mutex
+	report *Report
+}
+
+// NewScanner creates a scanner querying the OSV API at baseURL and
+// rescanning reports older than interval
+func NewScanner(baseURL string, interval time.Duration) *Scanner {
+	return &Scanner{
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		interval: interval,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		sbom:     BuildSBOM,
+	}
+}
+
+// ScannerFromEnv creates a scanner using OSV_API_URL, for mirrors and
+// air-gapped proxies, and SECURITY_SCAN_INTERVAL. SECURITY_SCAN=off returns
+// nil, as scanning sends the dependency list to OSV; "startup" scans at
+// startup, and the default scans only when a report is asked for.
+func ScannerFromEnv() *Scanner {
+	mode := os.Getenv("SECURITY_SCAN")
+	switch mode {
+	case "off":
+		return nil
+	case "", "on_demand", "startup":
+	default:
+		log.Printf("Invalid SECURITY_SCAN %q, scanning on demand", mode)
+	}
+
+	baseURL := os.Getenv("OSV_API_URL")
+	if baseURL == "" {
+		baseURL = DefaultOSVURL
+	}
+
+	interval := DefaultScanInterval
+	if value := os.Getenv("SECURITY_SCAN_INTERVAL"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			log.Printf("Invalid SECURITY_SCAN_INTERVAL %q, using default %s", value, DefaultScanInterval)
+		} else {
+			interval = d
+		}
+	}
+	scanner := NewScanner(baseURL, interval)
+	scanner.onStartup = mode == "startup"
+	return scanner
+}
+
+// Start runs the first scan in the background when SECURITY_SCAN=startup.
+// A nil scanner does nothing.
+func (s *Scanner) Start() {
+	if s == nil || !s.onStartup {
+		return
+	}
+	go s.Report(context.Background(), false)
+}
+
+// Report returns the latest report, scanning first when there is none, it
+// is older than the scan interval, or refresh is set. A scan that cannot
+// reach OSV still returns the SBOM, with StatusUnknown and the error.
+// Concurrent callers share one scan; a caller whose ctx ends first gets the
+// previous report, or StatusUnknown, while the scan finishes in the
+// background.
+func (s *Scanner) Report(ctx context.Context, refresh bool) Report {
+	cached, fresh := s.cached()
+	if fresh && !refresh {
+		return *cached
+	}
+
+	result := s.scans.DoChan("scan", func() (interface{}, error) {
+		report := s.scan(context.WithoutCancel(ctx))
+		s.mu.Lock()
+		s.report = &report
+		s.mu.Unlock()
+		return report, nil
+	})
+	select {
+	case r := <-result:
+		return r.Val.(Report)
+	case <-ctx.Done():
+		if cached != nil {
+			return *cached
+		}
+		return Report{Status: StatusUnknown, ScannedAt: time.Now().UTC(), Vulnerabilities: []Vulnerability{}, Error: "scan still running: " + ctx.Err().Error()}
+	}
+}
+
+// cached returns the latest report, if any, and whether it is recent enough
+// to serve without scanning
+func (s *Scanner) cached() (*Report, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.report == nil {
+		return nil, false
+	}
+	maxAge := s.interval
+	if s.report.Status == StatusUnknown {
+		maxAge = failedScanRetry
+	}
+	report := *s.report
+	return &report, time.Since(report.ScannedAt) < maxAge
+}
+
+func (s *Scanner) scan(ctx context.Context) Report {
+	report := Report{ScannedAt: time.Now().UTC(), Vulnerabilities: []Vulnerability{}}
+	sbom, ok := s.sbom()
+	if !ok {
+		report.Status = StatusUnknown
+		report.Error = "binary has no embedded module information"
+		return report
+	}
+	report.SBOM = sbom
+
+	vulns, err := s.check(ctx, sbom)
+	if err != nil {
+		log.Printf("Security: OSV scan failed: %v", err)
+		report.Status = StatusUnknown
+		report.Error = err.Error()
+		return report
+	}
+	report.Vulnerabilities = vulns
+	report.Status = StatusOK
+	if len(vulns) > 0 {
+		report.Status = StatusVulnerable
+		log.Printf("Security: %d known vulnerabilities in dependencies", len(vulns))
+	}
+	return report
+}
+
+// osvQuery is one entry of an OSV querybatch request
+type osvQuery struct {
+	Package struct {
+		Name      string `json:"name"`
+		Ecosystem string `json:"ecosystem"`
+	} `json:"package"`
+	Version string `json:"version"`
+}
+
+// osvVuln is the part of an OSV advisory the report uses
+type osvVuln struct {
+	ID       string   `json:"id"`
+	Summary  string   `json:"summary"`
+	Aliases  []string `json:"aliases"`
+	Affected []struct {
+		Package struct {
+			Name      string `json:"name"`
+			Ecosystem string `json:"ecosystem"`
+		} `json:"package"`
+		Ranges []struct {
+			Events []struct {
+				Fixed string `json:"fixed"`
+			} `json:"events"`
+		} `json:"ranges"`
+	} `json:"affected"`
+}
+
+// check looks up every versioned component and the standard library in
+// one batch query, then fetches each matching advisory's details
+func (s *Scanner) check(ctx context.Context, sbom SBOM) ([]Vulnerability, error) {
+	type target struct{ module, version string }
+	var targets []target
+	var queries []osvQuery
+	add := func(module, version, osvVersion string) {
+		var q osvQuery
+		q.Package.Name = module
+		q.Package.Ecosystem = "Go"
+		q.Version = osvVersion
+		queries = append(queries, q)
+		targets = append(targets, target{module, version})
+	}
+	for _, c := range sbom.Components {
+		if path, version := c.built(); version != "" {
+			// OSV lists Go module versions without the leading v
+			add(path, version, strings.TrimPrefix(version, "v"))
+		}
+	}
+	if version := stdlibVersion(sbom.GoVersion); version != "" {
+		add(stdlibModule, sbom.GoVersion, version)
+	}
+	if len(queries) == 0 {
+		return []Vulnerability{}, nil
+	}
+
+	var batch struct {
+		Results []struct {
+			Vulns []struct {
+				ID string `json:"id"`
+			} `json:"vulns"`
+		} `json:"results"`
+	}
+	if err := s.call(ctx, http.MethodPost, "/v1/querybatch", map[string]interface{}{"queries": queries}, &batch); err != nil {
+		return nil, err
+	}
+	if len(batch.Results) != len(queries) {
+		return nil, fmt.Errorf("OSV returned %d results for %d queries", len(batch.Results), len(queries))
+	}
+
+	vulns := []Vulnerability{}
+	details := make(map[string]osvVuln)
+	for i, result := range batch.Results {
+		for _, match := range result.Vulns {
+			detail, ok := details[match.ID]
+			if !ok {
+				if err := s.call(ctx, http.MethodGet, "/v1/vulns/"+url.PathEscape(match.ID), nil, &detail); err != nil {
+					return nil, err
+				}
+				details[match.ID] = detail
+			}
+			vulns = append(vulns, Vulnerability{
+				ID:      match.ID,
+				Aliases: detail.Aliases,
+				Summary: detail.Summary,
+				Module:  targets[i].module,
+				Version: targets[i].version,
+				FixedIn: fixedVersions(detail, targets[i].module),
+			})
+		}
+	}
+	sort.Slice(vulns, func(i, j int) bool {
+		if vulns[i].Module != vulns[j].Module {
+			return vulns[i].Module < vulns[j].Module
+		}
+		return vulns[i].ID < vulns[j].ID
+	})
+	return vulns, nil
+}
+
+// fixedVersions lists the releases fixing an advisory for module, written
+// as Go writes them (v1.2.3, or go1.2.3 for the standard library)
+func fixedVersions(vuln osvVuln, module string) []string {
+	prefix := "v"
+	if module == stdlibModule {
+		prefix = "go"
+	}
+	var fixed []string
+	for _, affected := range vuln.Affected {
+		if affected.Package.Ecosystem != "Go" || affected.Package.Name != module {
+			continue
+		}
+		for _, r := range affected.Ranges {
+			for _, event := range r.Events {
+				if event.Fixed != "" {
+					fixed = append(fixed, prefix+event.Fixed)
+				}
+			}
+		}
+	}
+	return fixed
+}
+
+func (s *Scanner) call(ctx context.Context, method, path string, body, target interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("querying OSV: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("OSV %s returned HTTP %d", path, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("decoding OSV response: %w", err)
+	}
+	return nil
+}
+
+// HealthHandler serves health, or the scanner's summary when the request
+// asks for /health?security=true and scanning is on
+func HealthHandler(s *Scanner, health http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("security") == "true" && s != nil {
+			s.ServeSummary(w, r)
+			return
+		}
+		health(w, r)
+	}
+}
+
+// ServeSummary writes the report summary as JSON, for /health?security=true.
+// Advisory details are left to the admin API.
+func (s *Scanner) ServeSummary(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.Report(r.Context(), false).Summary()); err != nil {
+		log.Printf("Security: failed to write summary: %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+// Package security describes what a running binary is built from and checks
+// those dependencies for known vulnerabilities. The software bill of
+// materials comes from the module information Go embeds in every binary;
+// vulnerabilities come from the OSV database (https://osv.dev).
+package security
+
+import (
+	"runtime/debug"
+	"sort"
+	"strings"
+)
+
+// Component is one module compiled into the binary
+type Component struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	Sum     string `json:"sum,omitempty"`
+	// ReplacedBy is the module actually built, as path@version, when a
+	// replace directive applies
+	ReplacedBy string `json:"replaced_by,omitempty"`
+}
+
+// built returns the module and version actually compiled in, which is the
+// replacement when there is one. The version is empty for a replacement by
+// a local directory.
+func (c Component) built() (path, version string) {
+	if c.ReplacedBy == "" {
+		return c.Path, c.Version
+	}
+	path, version, _ = strings.Cut(c.ReplacedBy, "@")
+	return path, version
+}
+
+// SBOM is the software bill of materials for the running binary
+type SBOM struct {
+	MainModule string      `json:"main_module"`
+	GoVersion  string      `json:"go_version"`
+	Revision   string      `json:"vcs_revision,omitempty"`
+	Components []Component `json:"components"`
+}
+
+// BuildSBOM reads the running binary's embedded module information. It
+// returns false when the binary was built without module support.
+func BuildSBOM() (SBOM, bool) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return SBOM{}, false
+	}
+	return sbomFrom(info), true
+}
+
+func sbomFrom(info *debug.BuildInfo) SBOM {
+	sbom := SBOM{
+		MainModule: info.Main.Path,
+		GoVersion:  info.GoVersion,
+		Components: []Component{},
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			sbom.Revision = setting.Value
+		}
+	}
+
+	for _, dep := range info.Deps {
+		c := Component{Path: dep.Path, Version: dep.Version, Sum: dep.Sum}
+		if r := dep.Replace; r != nil {
+			c.ReplacedBy = r.Path
+			if r.Version != "" {
+				c.ReplacedBy += "@" + r.Version
+			}
+			c.Sum = r.Sum
+		}
+		sbom.Components = append(sbom.Components, c)
+	}
+	sort.Slice(sbom.Components, func(i, j int) bool {
+		return sbom.Components[i].Path < sbom.Components[j].Path
+	})
+	return sbom
+}
+
+// stdlibVersion returns the Go release in OSV's form ("1.23.4"), or "" for
+// development toolchains
+func stdlibVersion(goVersion string) string {
+	version, ok := strings.CutPrefix(goVersion, "go")
+	if !ok || strings.ContainsAny(version, " -") || version == "" {
+		return ""
+	}
+	return version
+}
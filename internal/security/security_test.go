@@ -0,0 +1,234 @@
+package security
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSBOMFromBuildInfo(t *testing.T) {
+	t.Logf("Importance: The SBOM must name the code actually compiled in, including replacements, or the scan checks the wrong modules.")
+
+	sbom := sbomFrom(&debug.BuildInfo{
+		GoVersion: "go1.23.4",
+		Main:      debug.Module{Path: "github.com/vcto/mcp-adapters"},
+		Deps: []*debug.Module{
+			{Path: "golang.org/x/net", Version: "v0.22.0", Sum: "h1:net"},
+			{Path: "github.com/mark3labs/mcp-go", Version: "v0.32.0", Replace: &debug.Module{Path: "github.com/fork/mcp-go", Version: "v0.32.1", Sum: "h1:fork"}},
+			{Path: "example.com/local", Version: "v1.0.0", Replace: &debug.Module{Path: "../local"}},
+		},
+		Settings: []debug.BuildSetting{{Key: "vcs.revision", Value: "abc123"}},
+	})
+
+	if sbom.MainModule != "github.com/vcto/mcp-adapters" || sbom.Revision != "abc123" || len(sbom.Components) != 3 {
+		t.Fatalf("Unexpected SBOM %+v", sbom)
+	}
+	built := make(map[string]string)
+	for _, c := range sbom.Components {
+		path, version := c.built()
+		built[c.Path] = path + "@" + version
+	}
+	want := map[string]string{
+		"golang.org/x/net":            "golang.org/x/net@v0.22.0",
+		"github.com/mark3labs/mcp-go": "github.com/fork/mcp-go@v0.32.1",
+		"example.com/local":           "../local@",
+	}
+	for path, w := range want {
+		if built[path] != w {
+			t.Errorf("Expected %s built as %s, got %s", path, w, built[path])
+		}
+	}
+
+	if v := stdlibVersion("go1.23.4"); v != "1.23.4" {
+		t.Errorf("Expected stdlib version 1.23.4, got %q", v)
+	}
+	if v := stdlibVersion("devel go1.24-abc"); v != "" {
+		t.Errorf("Expected no stdlib version for a dev toolchain, got %q", v)
+	}
+}
+
+// osvServer fakes the OSV API: golang.org/x/net v0.22.0 and Go 1.23.4 are
+// vulnerable, everything else is clean
+type osvServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	batches int
+	fail    bool
+}
+
+func newOSVServer() *osvServer {
+	s := &osvServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		switch {
+		case r.URL.Path == "/v1/querybatch":
+			s.batches++
+			var req struct {
+				Queries []osvQuery `json:"queries"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			var results []string
+			for _, q := range req.Queries {
+				switch q.Package.Name + "@" + q.Version {
+				case "golang.org/x/net@0.22.0":
+					results = append(results, `{"vulns":[{"id":"GO-2024-2687"}]}`)
+				case "stdlib@1.23.4":
+					results = append(results, `{"vulns":[{"id":"GO-2025-3373"}]}`)
+				default:
+					results = append(results, `{}`)
+				}
+			}
+			_, _ = fmt.Fprintf(w, `{"results":[%s]}`, strings.Join(results, ","))
+		case r.URL.Path == "/v1/vulns/GO-2024-2687":
+			_, _ = fmt.Fprint(w, `{"id":"GO-2024-2687","summary":"HTTP/2 CONTINUATION flood in net/http","aliases":["CVE-2023-45288"],
+				"affected":[{"package":{"name":"golang.org/x/net","ecosystem":"Go"},"ranges":[{"events":[{"introduced":"0"},{"fixed":"0.23.0"}]}]},
+				{"package":{"name":"stdlib","ecosystem":"Go"},"ranges":[{"events":[{"introduced":"0"},{"fixed":"1.21.9"}]}]}]}`)
+		case r.URL.Path == "/v1/vulns/GO-2025-3373":
+			_, _ = fmt.Fprint(w, `{"id":"GO-2025-3373","summary":"Usage of IPv6 zone IDs can bypass URI name constraints in crypto/x509",
+				"affected":[{"package":{"name":"stdlib","ecosystem":"Go"},"ranges":[{"events":[{"introduced":"1.23.0-0"},{"fixed":"1.23.5"}]}]}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	return s
+}
+
+func TestScanner(t *testing.T) {
+	t.Logf("Importance: Operators rely on this report to learn a deployed build needs patching before someone exploits it.")
+
+	osv := newOSVServer()
+	defer osv.Close()
+
+	scanner := NewScanner(osv.URL, time.Hour)
+	scanner.sbom = func() (SBOM, bool) {
+		return SBOM{
+			MainModule: "github.com/vcto/mcp-adapters",
+			GoVersion:  "go1.23.4",
+			Components: []Component{
+				{Path: "golang.org/x/net", Version: "v0.22.0"},
+				{Path: "github.com/google/uuid", Version: "v1.6.0"},
+				{Path: "example.com/local", Version: "v1.0.0", ReplacedBy: "../local"},
+			},
+		}, true
+	}
+	ctx := context.Background()
+
+	report := scanner.Report(ctx, false)
+	if report.Status != StatusVulnerable || len(report.Vulnerabilities) != 2 {
+		t.Fatalf("Expected two vulnerabilities, got %+v", report)
+	}
+	net := report.Vulnerabilities[0]
+	if net.Module != "golang.org/x/net" || net.Version != "v0.22.0" || strings.Join(net.FixedIn, ",") != "v0.23.0" || net.Aliases[0] != "CVE-2023-45288" {
+		t.Errorf("Unexpected x/net finding %+v", net)
+	}
+	stdlib := report.Vulnerabilities[1]
+	if stdlib.Module != "stdlib" || stdlib.Version != "go1.23.4" || strings.Join(stdlib.FixedIn, ",") != "go1.23.5" {
+		t.Errorf("Unexpected stdlib finding %+v", stdlib)
+	}
+
+	t.Run("reports are cached", func(t *testing.T) {
+		t.Logf("  > Why it's important: /health is polled constantly; each poll must not query OSV.")
+		scanner.Report(ctx, false)
+		if osv.batches != 1 {
+			t.Errorf("Expected one OSV query, got %d", osv.batches)
+		}
+		scanner.Report(ctx, true)
+		if osv.batches != 2 {
+			t.Errorf("Expected refresh to query OSV again, got %d", osv.batches)
+		}
+	})
+
+	t.Run("health summary hides advisories", func(t *testing.T) {
+		t.Logf("  > Why it's important: /health is public; listing advisory IDs there tells attackers what to exploit.")
+		w := httptest.NewRecorder()
+		scanner.ServeSummary(w, httptest.NewRequest("GET", "/health?security=true", nil))
+		body := w.Body.String()
+		if !strings.Contains(body, `"vulnerabilities":2`) || strings.Contains(body, "GO-2024-2687") {
+			t.Errorf("Expected counts without advisory IDs, got %s", body)
+		}
+	})
+
+	t.Run("a running scan blocks nobody", func(t *testing.T) {
+		t.Logf("  > Why it's important: /health?security=true must answer while OSV is slow, not hang for the whole scan.")
+		osv.mu.Lock()
+		done := make(chan Report, 1)
+		go func() {
+			done <- scanner.Report(ctx, true)
+		}()
+		time.Sleep(10 * time.Millisecond)
+
+		waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		if report := scanner.Report(waitCtx, true); report.Status != StatusVulnerable {
+			t.Errorf("Expected the previous report while the scan runs, got %+v", report)
+		}
+		if report := scanner.Report(ctx, false); report.Status != StatusVulnerable {
+			t.Errorf("Expected the cached report without waiting, got %+v", report)
+		}
+		osv.mu.Unlock()
+		if report := <-done; report.Status != StatusVulnerable {
+			t.Errorf("Expected the scan to finish, got %+v", report)
+		}
+	})
+
+	t.Run("unreachable OSV keeps the SBOM", func(t *testing.T) {
+		t.Logf("  > Why it's important: An outage at OSV must read as unknown, never as a clean bill of health.")
+		osv.mu.Lock()
+		osv.fail = true
+		osv.mu.Unlock()
+
+		report := scanner.Report(ctx, true)
+		if report.Status != StatusUnknown || report.Error == "" || len(report.SBOM.Components) != 3 {
+			t.Errorf("Expected unknown status with SBOM, got %+v", report)
+		}
+		if summary := report.Summary(); summary.Status != StatusUnknown || summary.Components != 3 {
+			t.Errorf("Unexpected summary %+v", summary)
+		}
+	})
+}
+
+func TestScannerFromEnv(t *testing.T) {
+	t.Logf("Importance: A scan sends the full dependency list to OSV, so operators must be able to choose when, or whether, that happens.")
+
+	t.Run("scans on demand by default", func(t *testing.T) {
+		t.Logf("  > Why it's important: Starting a server must not contact OSV unless asked to.")
+		t.Setenv("SECURITY_SCAN", "")
+		if scanner := ScannerFromEnv(); scanner == nil || scanner.onStartup {
+			t.Errorf("Expected an on-demand scanner, got %+v", scanner)
+		}
+		t.Setenv("SECURITY_SCAN", "startup")
+		if scanner := ScannerFromEnv(); scanner == nil || !scanner.onStartup {
+			t.Errorf("Expected a scanner that scans at startup, got %+v", scanner)
+		}
+	})
+
+	t.Run("off disables scanning", func(t *testing.T) {
+		t.Logf("  > Why it's important: Deployments that may not share their dependency list need no scanner at all.")
+		t.Setenv("SECURITY_SCAN", "off")
+		scanner := ScannerFromEnv()
+		if scanner != nil {
+			t.Fatalf("Expected no scanner, got %+v", scanner)
+		}
+		scanner.Start()
+
+		w := httptest.NewRecorder()
+		HealthHandler(scanner, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, "healthy")
+		})(w, httptest.NewRequest("GET", "/health?security=true", nil))
+		if w.Body.String() != "healthy" {
+			t.Errorf("Expected plain health without a scanner, got %s", w.Body.String())
+		}
+	})
+}
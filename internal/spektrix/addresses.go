@@ -0,0 +1,101 @@
+package spektrix
+
+import (
+	"fmt"
+)
+
+// GetCustomerAddresses retrieves a customer's addresses
+func (c *Client) GetCustomerAddresses(customerID string) ([]Address, error) {
+	endpoint := fmt.Sprintf("/customers/%s/addresses", customerID)
+
+	resp, err := c.makeRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var addresses []Address
+	if err := c.handleResponse(resp, &addresses); err != nil {
+		return nil, err
+	}
+
+	return addresses, nil
+}
+
+// UpdateCustomerAddress replaces one of a customer's addresses
+func (c *Client) UpdateCustomerAddress(customerID string, address Address) error {
+	endpoint := fmt.Sprintf("/customers/%s/addresses/%s", customerID, address.ID)
+
+	resp, err := c.makeRequest("PUT", endpoint, address)
+	if err != nil {
+		return err
+	}
+
+	return c.handleResponse(resp, nil)
+}
+
+// AddressChange is the edit spektrix_update_address makes to an address.
+// Empty fields are left as they are.
+type AddressChange struct {
+	Line1, Line2, Town, AdministrativeDivision, Postcode, Country string
+	// DefaultBilling and DefaultDelivery make the address the customer's
+	// only billing or delivery address
+	DefaultBilling, DefaultDelivery bool
+}
+
+// ApplyAddressChange edits the address with the given ID and moves the
+// default billing and delivery flags to it when asked. It returns every
+// address that changed, the edited one first, or an error if the customer
+// has no such address.
+func ApplyAddressChange(addresses []Address, addressID string, change AddressChange) ([]Address, error) {
+	index := -1
+	for i, address := range addresses {
+		if address.ID == addressID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("customer has no address %s", addressID)
+	}
+
+	target := addresses[index]
+	for _, field := range []struct {
+		value string
+		dest  *string
+	}{
+		{change.Line1, &target.Line1},
+		{change.Line2, &target.Line2},
+		{change.Town, &target.Town},
+		{change.AdministrativeDivision, &target.AdministrativeDivision},
+		{change.Postcode, &target.Postcode},
+		{change.Country, &target.Country},
+	} {
+		if field.value != "" {
+			*field.dest = field.value
+		}
+	}
+	if change.DefaultBilling {
+		target.IsBilling = true
+	}
+	if change.DefaultDelivery {
+		target.IsDelivery = true
+	}
+
+	changed := []Address{target}
+	for i, address := range addresses {
+		if i == index {
+			continue
+		}
+		updated := address
+		if change.DefaultBilling {
+			updated.IsBilling = false
+		}
+		if change.DefaultDelivery {
+			updated.IsDelivery = false
+		}
+		if updated != address {
+			changed = append(changed, updated)
+		}
+	}
+	return changed, nil
+}
@@ -0,0 +1,83 @@
+package spektrix
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAddresses(t *testing.T) {
+	t.Logf("Importance: Orders are billed and posted to the default addresses; a customer must end up with exactly the defaults asked for.")
+
+	addresses := []Address{
+		{ID: "a1", Line1: "1 High St", Postcode: "AB1 2CD", Country: "GB", IsBilling: true, IsDelivery: true},
+		{ID: "a2", Line1: "2 Work Rd", Postcode: "EF3 4GH", Country: "GB"},
+		{ID: "a3", Line1: "3 Old Ln", Postcode: "IJ5 6KL", Country: "GB", IsDelivery: true},
+	}
+
+	t.Run("edits only the fields given", func(t *testing.T) {
+		t.Logf("  > Why it's important: Correcting a postcode must not blank the rest of the address.")
+		changed, err := ApplyAddressChange(addresses, "a2", AddressChange{Postcode: "EF3 4GJ"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(changed) != 1 || changed[0].Postcode != "EF3 4GJ" || changed[0].Line1 != "2 Work Rd" || changed[0].IsBilling {
+			t.Errorf("Unexpected change %+v", changed)
+		}
+		if addresses[1].Postcode != "EF3 4GH" {
+			t.Error("Expected the input addresses left unchanged")
+		}
+	})
+
+	t.Run("moves the default delivery address", func(t *testing.T) {
+		t.Logf("  > Why it's important: Only one address may receive tickets, and billing must stay where it was.")
+		changed, err := ApplyAddressChange(addresses, "a2", AddressChange{DefaultDelivery: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(changed) != 3 || changed[0].ID != "a2" || !changed[0].IsDelivery {
+			t.Fatalf("Expected a2 first and delivering, got %+v", changed)
+		}
+		for _, address := range changed[1:] {
+			if address.IsDelivery {
+				t.Errorf("Expected %s no longer a delivery address", address.ID)
+			}
+		}
+		if changed[1].ID != "a1" || !changed[1].IsBilling {
+			t.Errorf("Expected a1 to stay the billing address, got %+v", changed[1])
+		}
+		if _, err := ApplyAddressChange(addresses, "a9", AddressChange{}); err == nil {
+			t.Error("Expected an unknown address to be rejected")
+		}
+	})
+
+	t.Run("lists and updates addresses", func(t *testing.T) {
+		t.Logf("  > Why it's important: Updates must go to the address's own endpoint with its ID.")
+		var updated Address
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.Method == "GET" && r.URL.Path == "/customers/c1/addresses":
+				_ = json.NewEncoder(w).Encode(addresses)
+			case r.Method == "PUT" && r.URL.Path == "/customers/c1/addresses/a2":
+				_ = json.NewDecoder(r.Body).Decode(&updated)
+			default:
+				t.Errorf("Unexpected %s %s", r.Method, r.URL.Path)
+			}
+		}))
+		defer server.Close()
+
+		client := &Client{APIUser: "user", APIKey: "a2V5", BaseURL: server.URL, HTTPClient: server.Client()}
+		got, err := client.GetCustomerAddresses("c1")
+		if err != nil || len(got) != 3 || got[0].ID != "a1" || !got[0].IsBilling {
+			t.Fatalf("Unexpected addresses %+v, %v", got, err)
+		}
+		got[1].Town = "Leeds"
+		if err := client.UpdateCustomerAddress("c1", got[1]); err != nil {
+			t.Fatal(err)
+		}
+		if updated.ID != "a2" || updated.Town != "Leeds" {
+			t.Errorf("Unexpected update %+v", updated)
+		}
+	})
+}
@@ -27,6 +27,7 @@ import (
 	"crypto/md5"
 	"encoding/base64"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 )
@@ -66,9 +67,10 @@ func getAuthorizationHeader(method, url, date, body, apiUser, apiKey string) (st
 	return fmt.Sprintf("SpektrixAPI3 %s:%s", apiUser, encodedSignature), nil
 }
 
-// getDateHeader generates properly formatted date header
-func getDateHeader() string {
-	return time.Now().UTC().Format("Mon, 02 Jan 2006 15:04:05 GMT")
+// formatSignatureDate formats t for the Date header and the string to sign,
+// always in GMT as RFC 7231 requires
+func formatSignatureDate(t time.Time) string {
+	return t.UTC().Format(http.TimeFormat)
 }
 
 // validateCredentials checks if all required Spektrix credentials are present
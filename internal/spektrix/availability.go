@@ -0,0 +1,63 @@
+package spektrix
+
+import (
+	"math"
+	"sort"
+)
+
+// Summarize adds the share of seats sold and whether none are left to counts
+func Summarize(counts SeatCounts) Availability {
+	availability := Availability{
+		SeatCounts: counts,
+		SoldOut:    counts.Capacity > 0 && counts.Available <= 0,
+	}
+	if counts.Capacity > 0 {
+		availability.PercentSold = math.Round(float64(counts.Sold)*1000/float64(counts.Capacity)) / 10
+	}
+	return availability
+}
+
+// AreaAvailabilities summarises each seating plan area of status, in the
+// order Spektrix lists them
+func AreaAvailabilities(status *InstanceStatus) []AreaAvailability {
+	areas := make([]AreaAvailability, 0, len(status.ChildPlans))
+	for _, plan := range status.ChildPlans {
+		areas = append(areas, AreaAvailability{
+			ID:           plan.Plan.ID,
+			Name:         plan.Plan.Name,
+			Availability: Summarize(plan.SeatCounts),
+		})
+	}
+	return areas
+}
+
+// PriceBands groups a price list by price band, in the order bands first
+// appear. Within a band the full price comes first, then concessions.
+func PriceBands(priceList *PriceList) []BandPrices {
+	var bands []BandPrices
+	index := map[string]int{}
+	for _, price := range priceList.Prices {
+		i, ok := index[price.PriceBand.ID]
+		if !ok {
+			i = len(bands)
+			index[price.PriceBand.ID] = i
+			bands = append(bands, BandPrices{PriceBand: price.PriceBand})
+		}
+		bands[i].Prices = append(bands[i].Prices, BandPrice{
+			TicketType: price.TicketType,
+			Amount:     price.Amount,
+			IsBase:     price.IsBase,
+		})
+	}
+
+	for i := range bands {
+		prices := bands[i].Prices
+		sort.SliceStable(prices, func(a, b int) bool {
+			return prices[a].Amount > prices[b].Amount
+		})
+	}
+	if bands == nil {
+		bands = []BandPrices{}
+	}
+	return bands
+}
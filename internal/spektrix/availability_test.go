@@ -0,0 +1,67 @@
+package spektrix
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAvailability(t *testing.T) {
+	t.Logf("Importance: Box-office staff answer \"are there seats?\" from these numbers; a wrong sold-out flag loses sales.")
+
+	t.Run("summarizes seat counts", func(t *testing.T) {
+		t.Logf("  > Why it's important: Percent sold and sold out are what users ask for, not raw counts.")
+		got := Summarize(SeatCounts{Capacity: 300, Available: 0, Sold: 290, Locked: 10})
+		if !got.SoldOut || got.PercentSold != 96.7 {
+			t.Errorf("Expected sold out at 96.7%%, got %+v", got)
+		}
+		if got := Summarize(SeatCounts{}); got.SoldOut || got.PercentSold != 0 {
+			t.Errorf("Expected an instance without capacity not to be sold out, got %+v", got)
+		}
+	})
+
+	t.Run("reads areas from instance status", func(t *testing.T) {
+		t.Logf("  > Why it's important: Area availability must come from the plan's own counts, in plan order.")
+		var query string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			query = r.URL.RawQuery
+			if r.URL.Path != "/instances/1001AHGJK/status" {
+				t.Errorf("Unexpected path %s", r.URL.Path)
+			}
+			_, _ = w.Write([]byte(`{"instance":{"id":"1001AHGJK"},"capacity":300,"available":40,"sold":250,"reserved":10,
+				"childPlans":[{"plan":{"id":"p1","name":"Stalls"},"capacity":200,"available":40,"sold":150,"reserved":10},
+				{"plan":{"id":"p2","name":"Circle"},"capacity":100,"available":0,"sold":100}]}`))
+		}))
+		defer server.Close()
+
+		client := &Client{APIUser: "user", APIKey: "a2V5", BaseURL: server.URL, HTTPClient: server.Client()}
+		status, err := client.GetInstanceStatus("1001AHGJK", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if query != "includeChildPlans=true" {
+			t.Errorf("Expected child plans requested, got %q", query)
+		}
+		areas := AreaAvailabilities(status)
+		if len(areas) != 2 || areas[0].Name != "Stalls" || areas[0].Available != 40 || areas[0].SoldOut || !areas[1].SoldOut {
+			t.Errorf("Unexpected areas %+v", areas)
+		}
+		if total := Summarize(status.SeatCounts); total.Capacity != 300 || total.PercentSold != 83.3 {
+			t.Errorf("Unexpected total %+v", total)
+		}
+	})
+
+	t.Run("groups prices by band", func(t *testing.T) {
+		t.Logf("  > Why it's important: Staff quote by area, so prices must be read per band with full price first.")
+		bands := PriceBands(testPriceList())
+		if len(bands) != 2 || bands[0].PriceBand.ID != "stalls" || bands[1].PriceBand.ID != "circle" {
+			t.Fatalf("Expected stalls then circle, got %+v", bands)
+		}
+		if prices := bands[0].Prices; len(prices) != 2 || prices[0].TicketType.ID != "adult" || prices[1].Amount != 12 {
+			t.Errorf("Expected adult then child in the stalls, got %+v", prices)
+		}
+		if bands := PriceBands(&PriceList{}); bands == nil || len(bands) != 0 {
+			t.Errorf("Expected an empty list for no prices, got %v", bands)
+		}
+	})
+}
@@ -0,0 +1,148 @@
+package spektrix
+
+import (
+	"fmt"
+)
+
+// Basket is an order being assembled through the ECommerce API
+type Basket struct {
+	ID           string              `json:"id"`
+	Tickets      []BasketTicket      `json:"tickets"`
+	Offers       []Offer             `json:"offers,omitempty"`
+	Donations    []Donation          `json:"donations,omitempty"`
+	Memberships  []BasketMembership  `json:"memberships,omitempty"`
+	GiftVouchers []BasketGiftVoucher `json:"giftVouchers,omitempty"`
+	// Redemptions are gift vouchers paying for part of the basket; Total
+	// is what is left to pay
+	Redemptions []VoucherRedemption `json:"redemptions,omitempty"`
+	Customer    *Customer           `json:"customer,omitempty"`
+	Total       float64             `json:"total"`
+}
+
+// BasketTicket is a ticket held in a basket
+type BasketTicket struct {
+	ID         string        `json:"id"`
+	Instance   InstanceRef   `json:"instance"`
+	TicketType TicketTypeRef `json:"ticketType"`
+	PriceBand  PriceBandRef  `json:"band"`
+	Seat       string        `json:"seat,omitempty"`
+	Price      float64       `json:"price"`
+	Discount   float64       `json:"discount,omitempty"`
+}
+
+// Order is a checked-out basket
+type Order struct {
+	ID           string              `json:"id"`
+	Customer     *Customer           `json:"customer,omitempty"`
+	Tickets      []BasketTicket      `json:"tickets"`
+	Donations    []Donation          `json:"donations,omitempty"`
+	GiftVouchers []BasketGiftVoucher `json:"giftVouchers,omitempty"`
+	Total        float64             `json:"total"`
+	Status       string              `json:"status,omitempty"`
+	// Date is when the order was placed
+	Date string `json:"date,omitempty"`
+}
+
+// CreateBasket starts an empty basket
+func (c *Client) CreateBasket() (*Basket, error) {
+	resp, err := c.makeRequest("POST", "/baskets", map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+
+	var basket Basket
+	if err := c.handleResponse(resp, &basket); err != nil {
+		return nil, err
+	}
+
+	return &basket, nil
+}
+
+// GetBasket retrieves a basket with its tickets and total
+func (c *Client) GetBasket(basketID string) (*Basket, error) {
+	endpoint := fmt.Sprintf("/baskets/%s", basketID)
+
+	resp, err := c.makeRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var basket Basket
+	if err := c.handleResponse(resp, &basket); err != nil {
+		return nil, err
+	}
+
+	return &basket, nil
+}
+
+// AddBasketTickets holds tickets in a basket, one request per seat, and
+// returns the updated basket
+func (c *Client) AddBasketTickets(basketID string, tickets []BasketTicketRequest) (*Basket, error) {
+	endpoint := fmt.Sprintf("/baskets/%s/tickets", basketID)
+
+	resp, err := c.makeRequest("POST", endpoint, tickets)
+	if err != nil {
+		return nil, err
+	}
+
+	var basket Basket
+	if err := c.handleResponse(resp, &basket); err != nil {
+		return nil, err
+	}
+
+	return &basket, nil
+}
+
+// ApplyBasketOffer applies an offer to the tickets it covers and returns the
+// updated basket
+func (c *Client) ApplyBasketOffer(basketID, offerID string) (*Basket, error) {
+	endpoint := fmt.Sprintf("/baskets/%s/offers", basketID)
+
+	resp, err := c.makeRequest("POST", endpoint, TagReference{ID: offerID})
+	if err != nil {
+		return nil, err
+	}
+
+	var basket Basket
+	if err := c.handleResponse(resp, &basket); err != nil {
+		return nil, err
+	}
+
+	return &basket, nil
+}
+
+// Checkout completes a basket as an order for a customer
+func (c *Client) Checkout(basketID, customerID string) (*Order, error) {
+	endpoint := fmt.Sprintf("/baskets/%s/checkout", basketID)
+	payload := map[string]interface{}{
+		"customer": TagReference{ID: customerID},
+	}
+
+	resp, err := c.makeRequest("POST", endpoint, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var order Order
+	if err := c.handleResponse(resp, &order); err != nil {
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+// BasketTickets expands quote lines into one basket ticket per seat for an
+// instance, the shape AddBasketTickets takes
+func BasketTickets(instanceID string, lines []QuoteLineRequest) []BasketTicketRequest {
+	var tickets []BasketTicketRequest
+	for _, line := range lines {
+		for i := 0; i < line.Quantity; i++ {
+			tickets = append(tickets, BasketTicketRequest{
+				Instance:   instanceID,
+				TicketType: line.TicketTypeID,
+				Band:       line.PriceBandID,
+			})
+		}
+	}
+	return tickets
+}
@@ -0,0 +1,83 @@
+package spektrix
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBasket(t *testing.T) {
+	t.Logf("Importance: Baskets hold real seats and checkout takes real money; requests must go to the right basket.")
+
+	t.Run("expands lines to one ticket per seat", func(t *testing.T) {
+		t.Logf("  > Why it's important: Spektrix holds one seat per ticket, so a quantity of 3 must become 3 tickets.")
+		tickets := BasketTickets("1001AHGJK", []QuoteLineRequest{
+			{TicketTypeID: "adult", PriceBandID: "stalls", Quantity: 2},
+			{TicketTypeID: "child", Quantity: 1},
+		})
+		if len(tickets) != 3 {
+			t.Fatalf("Expected 3 tickets, got %+v", tickets)
+		}
+		if tickets[0].Instance != "1001AHGJK" || tickets[1].Band != "stalls" || tickets[2].TicketType != "child" || tickets[2].Band != "" {
+			t.Errorf("Unexpected tickets %+v", tickets)
+		}
+	})
+
+	t.Run("adds tickets and checks out", func(t *testing.T) {
+		t.Logf("  > Why it's important: Tickets and the customer must reach the basket's own endpoints.")
+		var added []BasketTicketRequest
+		var checkout map[string]map[string]string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/baskets/b1/tickets":
+				_ = json.NewDecoder(r.Body).Decode(&added)
+				_, _ = w.Write([]byte(`{"id":"b1","tickets":[{"id":"t1","instance":{"id":"1001AHGJK"},"price":25}],"total":25}`))
+			case "/baskets/b1/checkout":
+				_ = json.NewDecoder(r.Body).Decode(&checkout)
+				_, _ = w.Write([]byte(`{"id":"o1","tickets":[{"id":"t1","price":25}],"total":25,"status":"Complete"}`))
+			default:
+				t.Errorf("Unexpected %s %s", r.Method, r.URL.Path)
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer server.Close()
+
+		client := &Client{APIUser: "user", APIKey: "a2V5", BaseURL: server.URL, HTTPClient: server.Client()}
+		basket, err := client.AddBasketTickets("b1", BasketTickets("1001AHGJK", []QuoteLineRequest{{TicketTypeID: "adult", Quantity: 1}}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(added) != 1 || added[0].TicketType != "adult" || basket.Total != 25 || len(basket.Tickets) != 1 {
+			t.Errorf("Unexpected add %+v giving %+v", added, basket)
+		}
+
+		order, err := client.Checkout("b1", "c42")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if checkout["customer"]["id"] != "c42" || order.ID != "o1" || order.Status != "Complete" {
+			t.Errorf("Unexpected checkout %+v giving %+v", checkout, order)
+		}
+	})
+
+	t.Run("remembers one basket per session", func(t *testing.T) {
+		t.Logf("  > Why it's important: Clearing a finished basket must not drop one the session has since replaced.")
+		baskets := newSessionValues[string]()
+		ctx := context.Background()
+		if got, ok := baskets.get(ctx); ok {
+			t.Fatalf("Expected no basket, got %q", got)
+		}
+		baskets.set(ctx, "b1")
+		baskets.set(ctx, "b2")
+		baskets.remove(ctx, func(id string) bool { return id == "b1" })
+		if got, _ := baskets.get(ctx); got != "b2" {
+			t.Errorf("Expected b2 to survive clearing b1, got %q", got)
+		}
+		baskets.remove(ctx, func(id string) bool { return id == "b2" })
+		if got, ok := baskets.get(ctx); ok {
+			t.Errorf("Expected the basket cleared, got %q", got)
+		}
+	})
+}
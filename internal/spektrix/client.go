@@ -2,12 +2,18 @@ package spektrix
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"strings"
+	"sync/atomic"
 	"time"
+
+	"github.com/vcto/mcp-adapters/internal/health"
+	"github.com/vcto/mcp-adapters/internal/ratelimit"
 )
 
 // Client handles Spektrix API requests with HMAC authentication
@@ -17,6 +23,19 @@ type Client struct {
 	APIKey     string
 	BaseURL    string
 	HTTPClient *http.Client
+	// Breaker stops calls to Spektrix while the API is failing
+	Breaker *health.Breaker
+	// Limiter paces requests to SPEKTRIX_RATE_LIMIT a second; nil sends
+	// them unpaced
+	Limiter *ratelimit.Limiter
+	// Retry controls retries of throttled and failed requests
+	Retry RetryPolicy
+
+	// clock replaces time.Now in tests
+	clock func() time.Time
+	// clockOffset is the drift, in nanoseconds, between the local clock and
+	// Spektrix's, added to signing dates
+	clockOffset atomic.Int64
 }
 
 // NewClient creates a new Spektrix API client
@@ -29,42 +48,149 @@ func NewClient() *Client {
 		return nil
 	}
 
-	return &Client{
+	c := &Client{
 		ClientName: clientName,
 		APIUser:    apiUser,
 		APIKey:     apiKey,
 		BaseURL:    getSpektrixAPIBaseURL(clientName),
 		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		Breaker:    health.NewBreaker(0, 0),
+		Limiter:    rateLimiterFromEnv(),
+		Retry:      DefaultRetryPolicy(),
+	}
+	if baseURL := os.Getenv("SPEKTRIX_API_BASE_URL"); baseURL != "" {
+		c.BaseURL = strings.TrimSuffix(baseURL, "/")
 	}
+	return c
 }
 
-// makeRequest performs authenticated API request with HMAC signature
-func (c *Client) makeRequest(method, endpoint string, payload interface{}) (*http.Response, error) {
-	url := c.BaseURL + endpoint
-	date := getDateHeader()
-
-	var bodyBytes []byte
-	var bodyString string
+// clockSkewTolerance is how far the local clock may drift from Spektrix's
+// before a 401 is put down to the drift and retried
+const clockSkewTolerance = time.Minute
 
+// makeRequest performs authenticated API request with HMAC signature. Each
+// attempt waits on the rate limiter. A 429 is retried after the wait
+// Spektrix asks for, and server and network errors are retried with backoff
+// when the method is safe to repeat. A 401 whose Date header shows the
+// local clock has drifted is retried once, signed with the server's time.
+func (c *Client) makeRequest(method, endpoint string, payload interface{}) (*http.Response, error) {
+	var body []byte
 	if payload != nil {
 		var err error
-		bodyBytes, err = json.Marshal(payload)
+		body, err = json.Marshal(payload)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal payload: %w", err)
 		}
-		bodyString = string(bodyBytes)
 	}
 
-	// Generate authorization header
-	authHeader, err := getAuthorizationHeader(method, url, date, bodyString, c.APIUser, c.APIKey)
+	clockCorrected := false
+	for retries := 0; ; {
+		if c.Limiter != nil {
+			if err := c.Limiter.Wait(context.Background()); err != nil {
+				return nil, fmt.Errorf("Spektrix %s %s: waiting for rate limit: %w", method, endpoint, err)
+			}
+		}
+
+		resp, err := c.send(method, endpoint, body)
+		if err == nil && resp.StatusCode == http.StatusUnauthorized && !clockCorrected && c.correctClock(resp.Header.Get("Date")) {
+			_ = resp.Body.Close()
+			clockCorrected = true
+			continue
+		}
+
+		delay, retry := c.retryDelay(method, resp, err, retries)
+		if !retry {
+			return resp, err
+		}
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+		retries++
+		time.Sleep(delay)
+	}
+}
+
+// retryDelay decides whether a request that has been retried retries times
+// should go again, and after how long
+func (c *Client) retryDelay(method string, resp *http.Response, err error, retries int) (time.Duration, bool) {
+	if retries >= c.Retry.MaxRetries {
+		return 0, false
+	}
+
+	switch {
+	case err != nil:
+		// An open circuit is not an upstream failure worth waiting out here
+		if !health.IsUpstream(err) || !isIdempotent(method) {
+			return 0, false
+		}
+	case resp.StatusCode == http.StatusTooManyRequests:
+		delay, ok := retryAfter(resp)
+		if !ok {
+			delay = c.Retry.backoff(retries + 1)
+		}
+		if c.Retry.MaxDelay > 0 && delay > c.Retry.MaxDelay {
+			delay = c.Retry.MaxDelay
+		}
+		if c.Limiter != nil {
+			c.Limiter.Pause(delay)
+			delay = 0
+		}
+		return delay, true
+	case resp.StatusCode >= http.StatusInternalServerError:
+		if !isIdempotent(method) {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	return c.Retry.backoff(retries + 1), true
+}
+
+// send signs and sends one request, through the breaker when there is one
+func (c *Client) send(method, endpoint string, body []byte) (*http.Response, error) {
+	req, err := c.newSignedRequest(method, c.BaseURL+endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Breaker == nil {
+		resp, err := c.HTTPClient.Do(req)
+		return resp, health.Upstream(err)
+	}
+	if err := c.Breaker.Allow(); err != nil {
+		return nil, fmt.Errorf("Spektrix %s %s: %w", method, endpoint, err)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	switch {
+	case err != nil:
+		err = health.Upstream(err)
+		c.Breaker.Failure(err)
+	case resp.StatusCode >= http.StatusInternalServerError:
+		c.Breaker.Failure(fmt.Errorf("%s %s returned HTTP %d", method, endpoint, resp.StatusCode))
+	default:
+		c.Breaker.Success()
+		// 404 is an expected answer for lookups, not an adapter fault
+		if resp.StatusCode >= http.StatusBadRequest && resp.StatusCode != http.StatusNotFound {
+			c.Breaker.NoteError(fmt.Errorf("%s %s returned HTTP %d", method, endpoint, resp.StatusCode))
+		}
+	}
+	return resp, err
+}
+
+// newSignedRequest builds a request carrying the Date and SpektrixAPI3
+// Authorization headers. The body is signed exactly as sent.
+func (c *Client) newSignedRequest(method, url string, body []byte) (*http.Request, error) {
+	date := formatSignatureDate(c.now())
+
+	authHeader, err := getAuthorizationHeader(method, url, date, string(body), c.APIUser, c.APIKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate auth header: %w", err)
 	}
 
-	// Create request
 	var req *http.Request
-	if bodyBytes != nil {
-		req, err = http.NewRequest(method, url, bytes.NewBuffer(bodyBytes))
+	if body != nil {
+		req, err = http.NewRequest(method, url, bytes.NewReader(body))
 	} else {
 		req, err = http.NewRequest(method, url, nil)
 	}
@@ -72,12 +198,36 @@ func (c *Client) makeRequest(method, endpoint string, payload interface{}) (*htt
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	// Set headers
 	req.Header.Set("Date", date)
 	req.Header.Set("Authorization", authHeader)
 	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// now is the time to sign with: the local clock corrected by any drift seen
+// in Spektrix's responses
+func (c *Client) now() time.Time {
+	clock := time.Now
+	if c.clock != nil {
+		clock = c.clock
+	}
+	return clock().Add(time.Duration(c.clockOffset.Load()))
+}
 
-	return c.HTTPClient.Do(req)
+// correctClock compares a response's Date header with the signing clock. If
+// they differ by more than clockSkewTolerance it adopts the server's time
+// for later requests and reports true.
+func (c *Client) correctClock(serverDate string) bool {
+	server, err := http.ParseTime(serverDate)
+	if err != nil {
+		return false
+	}
+	drift := server.Sub(c.now())
+	if drift > -clockSkewTolerance && drift < clockSkewTolerance {
+		return false
+	}
+	c.clockOffset.Add(int64(drift))
+	return true
 }
 
 // handleResponse processes API response and returns parsed data or error
@@ -91,6 +241,9 @@ func (c *Client) handleResponse(resp *http.Response, result interface{}) error {
 		return fmt.Errorf("failed to read response: %w", err)
 	}
 
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return health.Upstream(fmt.Errorf("API error %d: %s", resp.StatusCode, string(body)))
+	}
 	if resp.StatusCode >= 400 {
 		return fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
 	}
@@ -241,3 +394,75 @@ func (c *Client) UpdateCustomerTags(customerID string, tagIDs []string) error {
 
 	return c.handleResponse(resp, nil)
 }
+
+// GetPriceList retrieves the price list for an event instance
+func (c *Client) GetPriceList(instanceID string) (*PriceList, error) {
+	endpoint := fmt.Sprintf("/instances/%s/price-list", instanceID)
+
+	resp, err := c.makeRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var priceList PriceList
+	if err := c.handleResponse(resp, &priceList); err != nil {
+		return nil, err
+	}
+
+	return &priceList, nil
+}
+
+// GetInstanceStatus retrieves seat availability for an event instance. With
+// areas set, the status of each seating plan area is included.
+func (c *Client) GetInstanceStatus(instanceID string, areas bool) (*InstanceStatus, error) {
+	endpoint := fmt.Sprintf("/instances/%s/status", instanceID)
+	if areas {
+		endpoint += "?includeChildPlans=true"
+	}
+
+	resp, err := c.makeRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var status InstanceStatus
+	if err := c.handleResponse(resp, &status); err != nil {
+		return nil, err
+	}
+
+	return &status, nil
+}
+
+// GetInstanceOffers retrieves offers that can be applied to an event instance
+func (c *Client) GetInstanceOffers(instanceID string) ([]Offer, error) {
+	endpoint := fmt.Sprintf("/instances/%s/offers", instanceID)
+
+	resp, err := c.makeRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var offers []Offer
+	if err := c.handleResponse(resp, &offers); err != nil {
+		return nil, err
+	}
+
+	return offers, nil
+}
+
+// Quote prices a ticket selection for an instance from the live price list
+// and offers, without holding seats; see BuildQuote. If offerID is empty the
+// best applicable offer is chosen automatically.
+func (c *Client) Quote(instanceID string, lines []QuoteLineRequest, offerID string) (*Quote, error) {
+	priceList, err := c.GetPriceList(instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price list: %w", err)
+	}
+
+	offers, err := c.GetInstanceOffers(instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get offers: %w", err)
+	}
+
+	return BuildQuote(instanceID, priceList, offers, lines, offerID)
+}
@@ -0,0 +1,66 @@
+package spektrix
+
+import (
+	"time"
+
+	"github.com/vcto/mcp-adapters/internal/health"
+)
+
+// SpektrixClientInterface is every Spektrix call the handler makes. Client
+// implements it over HTTP and FakeClient in memory, so Handler can be tested
+// without either and pointed at another backend, such as a sandbox system.
+type SpektrixClientInterface interface {
+	// UpstreamBreaker is the breaker guarding calls, or nil if there is none
+	UpstreamBreaker() *health.Breaker
+
+	SearchCustomers(email string) ([]Customer, error)
+	FindCustomersByName(firstName, lastName, postcode string) ([]Customer, error)
+	GetCustomer(customerID string) (*Customer, error)
+	GetCustomersModifiedSince(since time.Time) ([]Customer, error)
+	CreateCustomer(customer CreateCustomerRequest) (*Customer, error)
+	FindOrCreateCustomer(email, firstName, lastName string) (*Customer, error)
+	MergeCustomers(keepID, duplicateID string) (*Customer, error)
+
+	GetCustomerAddresses(customerID string) ([]Address, error)
+	AddCustomerAddress(customerID string, address Address) error
+	UpdateCustomerAddress(customerID string, address Address) error
+
+	GetTags() ([]Tag, error)
+	UpdateCustomerTags(customerID string, tagIDs []string) error
+	AddCustomerTags(customerID string, tagIDs []string) error
+	RemoveCustomerTag(customerID, tagID string) error
+
+	GetEvents() ([]Event, error)
+	GetEvent(eventID string) (*Event, error)
+	GetEventInstances(eventID string) ([]Instance, error)
+	GetInstance(instanceID string) (*Instance, error)
+	GetInstanceStatus(instanceID string, areas bool) (*InstanceStatus, error)
+	GetPriceList(instanceID string) (*PriceList, error)
+	GetInstanceOffers(instanceID string) ([]Offer, error)
+	Quote(instanceID string, lines []QuoteLineRequest, offerID string) (*Quote, error)
+
+	GetFunds() ([]Fund, error)
+	GetCustomerMemberships(customerID string) ([]CustomerMembership, error)
+	GetGiftVoucherTypes() ([]GiftVoucherType, error)
+	GetGiftVoucher(code string) (*GiftVoucher, error)
+
+	CreateBasket() (*Basket, error)
+	GetBasket(basketID string) (*Basket, error)
+	AddBasketTickets(basketID string, tickets []BasketTicketRequest) (*Basket, error)
+	ApplyBasketOffer(basketID, offerID string) (*Basket, error)
+	AddBasketDonation(basketID string, donation DonationRequest) (*Basket, error)
+	AddBasketMembershipRenewal(basketID string, membership CustomerMembership) (*Basket, error)
+	AddBasketGiftVoucher(basketID string, voucher GiftVoucherRequest) (*Basket, error)
+	RedeemGiftVoucher(basketID string, redemption VoucherRedemption) (*Basket, error)
+	Checkout(basketID, customerID string) (*Order, error)
+
+	GetCustomerOrders(customerID string) ([]Order, error)
+	GetOrders(from, to time.Time) ([]Order, error)
+}
+
+var _ SpektrixClientInterface = (*Client)(nil)
+
+// UpstreamBreaker returns the client's breaker
+func (c *Client) UpstreamBreaker() *health.Breaker {
+	return c.Breaker
+}
@@ -0,0 +1,100 @@
+package spektrix
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Fund is a donation fund, such as an annual appeal or a capital campaign
+type Fund struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Code        string `json:"code,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Default marks the fund suggested when the donor does not choose one
+	Default bool `json:"default,omitempty"`
+}
+
+// FundRef identifies a fund
+type FundRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+// Donation is a gift to a fund held in a basket or order
+type Donation struct {
+	ID     string  `json:"id"`
+	Fund   FundRef `json:"fund"`
+	Amount float64 `json:"amount"`
+	// GiftAid marks a donation the venue may claim UK Gift Aid on
+	GiftAid bool `json:"giftAid"`
+}
+
+// DonationRequest is the payload shape for adding a donation to a basket
+type DonationRequest struct {
+	Fund    TagReference `json:"fund"`
+	Amount  float64      `json:"amount"`
+	GiftAid bool         `json:"giftAid"`
+}
+
+// GetFunds retrieves the funds donations can be made to
+func (c *Client) GetFunds() ([]Fund, error) {
+	resp, err := c.makeRequest("GET", "/funds", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var funds []Fund
+	if err := c.handleResponse(resp, &funds); err != nil {
+		return nil, err
+	}
+
+	return funds, nil
+}
+
+// AddBasketDonation adds a donation to a basket and returns the updated basket
+func (c *Client) AddBasketDonation(basketID string, donation DonationRequest) (*Basket, error) {
+	endpoint := fmt.Sprintf("/baskets/%s/donations", basketID)
+
+	resp, err := c.makeRequest("POST", endpoint, []DonationRequest{donation})
+	if err != nil {
+		return nil, err
+	}
+
+	var basket Basket
+	if err := c.handleResponse(resp, &basket); err != nil {
+		return nil, err
+	}
+
+	return &basket, nil
+}
+
+// ResolveFund finds the fund named by ref, a fund ID, code or name in any
+// case. An unknown ref is an error listing the funds available.
+func ResolveFund(funds []Fund, ref string) (Fund, error) {
+	for _, fund := range funds {
+		if fund.ID == ref || strings.EqualFold(fund.Code, ref) || strings.EqualFold(fund.Name, ref) {
+			return fund, nil
+		}
+	}
+
+	names := make([]string, len(funds))
+	for i, fund := range funds {
+		names[i] = fund.Name
+	}
+	return Fund{}, fmt.Errorf("unknown fund %s; available funds are %s", ref, strings.Join(names, ", "))
+}
+
+// NewDonation checks a donation amount and builds the request for it,
+// rounding to whole pence
+func NewDonation(fund Fund, amount float64, giftAid bool) (DonationRequest, error) {
+	if amount <= 0 || math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return DonationRequest{}, fmt.Errorf("amount must be greater than zero")
+	}
+	return DonationRequest{
+		Fund:    TagReference{ID: fund.ID},
+		Amount:  math.Round(amount*100) / 100,
+		GiftAid: giftAid,
+	}, nil
+}
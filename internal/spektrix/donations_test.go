@@ -0,0 +1,64 @@
+package spektrix
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDonations(t *testing.T) {
+	t.Logf("Importance: Donations are money given to a named fund; the fund, amount and Gift Aid flag must be exactly what the donor agreed.")
+
+	funds := []Fund{{ID: "f1", Name: "Annual Appeal", Code: "AA24"}, {ID: "f2", Name: "Building Fund"}}
+
+	t.Run("resolves funds by ID, code or name", func(t *testing.T) {
+		t.Logf("  > Why it's important: Staff name funds; the API wants IDs.")
+		for _, ref := range []string{"f1", "aa24", "annual appeal"} {
+			if fund, err := ResolveFund(funds, ref); err != nil || fund.ID != "f1" {
+				t.Errorf("Expected %q to resolve to f1, got %+v, %v", ref, fund, err)
+			}
+		}
+		if _, err := ResolveFund(funds, "Gala"); err == nil || !strings.Contains(err.Error(), "Building Fund") {
+			t.Errorf("Expected an error listing available funds, got %v", err)
+		}
+	})
+
+	t.Run("checks amounts", func(t *testing.T) {
+		t.Logf("  > Why it's important: A zero or negative donation is a mistake, and fractions of a penny cannot be charged.")
+		donation, err := NewDonation(funds[0], 10.005, true)
+		if err != nil || donation.Amount != 10.01 || donation.Fund.ID != "f1" || !donation.GiftAid {
+			t.Errorf("Unexpected donation %+v, %v", donation, err)
+		}
+		if _, err := NewDonation(funds[0], 0, false); err == nil {
+			t.Error("Expected a zero amount to be rejected")
+		}
+	})
+
+	t.Run("adds donations to a basket", func(t *testing.T) {
+		t.Logf("  > Why it's important: The Gift Aid flag must reach Spektrix with the donation or the venue cannot claim it.")
+		var sent []DonationRequest
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != "POST" || r.URL.Path != "/baskets/b1/donations" {
+				t.Errorf("Unexpected %s %s", r.Method, r.URL.Path)
+			}
+			_ = json.NewDecoder(r.Body).Decode(&sent)
+			_, _ = w.Write([]byte(`{"id":"b1","tickets":[],"donations":[{"id":"d1","fund":{"id":"f1"},"amount":50,"giftAid":true}],"total":50}`))
+		}))
+		defer server.Close()
+
+		client := &Client{APIUser: "user", APIKey: "a2V5", BaseURL: server.URL, HTTPClient: server.Client()}
+		donation, _ := NewDonation(funds[0], 50, true)
+		basket, err := client.AddBasketDonation("b1", donation)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sent) != 1 || sent[0].Fund.ID != "f1" || !sent[0].GiftAid {
+			t.Errorf("Unexpected request %+v", sent)
+		}
+		if len(basket.Donations) != 1 || !basket.Donations[0].GiftAid || basket.Total != 50 {
+			t.Errorf("Unexpected basket %+v", basket)
+		}
+	})
+}
@@ -0,0 +1,161 @@
+package spektrix
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// DuplicateMatch is a customer record that may be the same person as another
+type DuplicateMatch struct {
+	Customer Customer `json:"customer"`
+	// Reasons lists what matched: "email", "name" and "postcode"
+	Reasons []string `json:"reasons"`
+	// Confidence is "high" for a matching email or name and postcode, and
+	// "low" for a matching name alone
+	Confidence string `json:"confidence"`
+}
+
+// FindCustomersByName searches for customers by name, optionally narrowed to
+// a postcode
+func (c *Client) FindCustomersByName(firstName, lastName, postcode string) ([]Customer, error) {
+	query := url.Values{}
+	query.Set("lastName", lastName)
+	if firstName != "" {
+		query.Set("firstName", firstName)
+	}
+	if postcode != "" {
+		query.Set("postcode", postcode)
+	}
+
+	resp, err := c.makeRequest("GET", "/customers?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var customers []Customer
+	if err := c.handleResponse(resp, &customers); err != nil {
+		// 404 is normal - no customer has the name
+		if strings.HasPrefix(err.Error(), "API error 404") {
+			return []Customer{}, nil
+		}
+		return nil, err
+	}
+
+	return customers, nil
+}
+
+// MergeCustomers merges the duplicate customer into the one kept, moving its
+// orders, tags and memberships. Spektrix cannot undo a merge.
+func (c *Client) MergeCustomers(keepID, duplicateID string) (*Customer, error) {
+	endpoint := fmt.Sprintf("/customers/%s/merge", keepID)
+	payload := map[string]interface{}{
+		"customer": TagReference{ID: duplicateID},
+	}
+
+	resp, err := c.makeRequest("POST", endpoint, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var customer Customer
+	if err := c.handleResponse(resp, &customer); err != nil {
+		return nil, err
+	}
+
+	return &customer, nil
+}
+
+// FindDuplicates compares target with candidate records, which may include
+// target itself, and returns those that may be the same person, most
+// likely first. Emails and postcodes match ignoring case and spacing, and
+// names ignoring case and punctuation. A shared postcode alone is not a
+// match, since households share one.
+func FindDuplicates(target Customer, candidates []Customer) []DuplicateMatch {
+	var matches []DuplicateMatch
+	seen := map[string]bool{target.ID: true}
+	for _, candidate := range candidates {
+		if seen[candidate.ID] {
+			continue
+		}
+		seen[candidate.ID] = true
+
+		var reasons []string
+		email := normalizeEmail(target.Email)
+		if email != "" && email == normalizeEmail(candidate.Email) {
+			reasons = append(reasons, "email")
+		}
+		name := normalizeName(target.FirstName + " " + target.LastName)
+		sameName := name != "" && name == normalizeName(candidate.FirstName+" "+candidate.LastName)
+		if sameName {
+			reasons = append(reasons, "name")
+		}
+		samePostcode := sharesPostcode(target, candidate)
+		if samePostcode {
+			reasons = append(reasons, "postcode")
+		}
+
+		switch {
+		case len(reasons) == 0 || (len(reasons) == 1 && samePostcode):
+			continue
+		case reasons[0] == "email" || (sameName && samePostcode):
+			matches = append(matches, DuplicateMatch{Customer: candidate, Reasons: reasons, Confidence: "high"})
+		default:
+			matches = append(matches, DuplicateMatch{Customer: candidate, Reasons: reasons, Confidence: "low"})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Confidence != matches[j].Confidence {
+			return matches[i].Confidence == "high"
+		}
+		return len(matches[i].Reasons) > len(matches[j].Reasons)
+	})
+	return matches
+}
+
+// Postcodes returns a customer's distinct postcodes, normalized
+func Postcodes(customer Customer) []string {
+	var postcodes []string
+	seen := make(map[string]bool)
+	for _, address := range customer.Addresses {
+		postcode := normalizePostcode(address.Postcode)
+		if postcode != "" && !seen[postcode] {
+			seen[postcode] = true
+			postcodes = append(postcodes, postcode)
+		}
+	}
+	return postcodes
+}
+
+func sharesPostcode(a, b Customer) bool {
+	for _, pa := range Postcodes(a) {
+		for _, pb := range Postcodes(b) {
+			if pa == pb {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func normalizeName(name string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}), " ")
+}
+
+func normalizePostcode(postcode string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(postcode), ""))
+}
+
+// mergeConfirmation is the phrase spektrix_merge_customers needs to go ahead
+func mergeConfirmation(keepID, duplicateID string) string {
+	return fmt.Sprintf("merge %s into %s", duplicateID, keepID)
+}
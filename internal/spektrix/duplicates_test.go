@@ -0,0 +1,121 @@
+package spektrix
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// callTool runs a Spektrix tool through an MCP server, as a client would
+func callTool(t *testing.T, h *Handler, name string, args map[string]interface{}) *mcp.CallToolResult {
+	t.Helper()
+	s := server.NewMCPServer("test", "1.0.0", server.WithToolCapabilities(false))
+	h.SetupTools(s)
+
+	message, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params":  map[string]interface{}{"name": name, "arguments": args},
+	})
+	resp, ok := s.HandleMessage(context.Background(), message).(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatalf("Expected a result calling %s", name)
+	}
+	result := resp.Result.(mcp.CallToolResult)
+	return &result
+}
+
+func TestDuplicateCustomers(t *testing.T) {
+	t.Logf("Importance: Merging is irreversible; duplicates must be real matches and merges must never happen unconfirmed.")
+
+	jane := Customer{ID: "c1", FirstName: "Jane", LastName: "Doe", Email: "jane@example.com", Addresses: []Address{{Postcode: "sw1a 1aa"}}}
+
+	t.Run("matches on email, name and postcode", func(t *testing.T) {
+		t.Logf("  > Why it's important: Household members share a postcode and common names recur; only strong matches are high confidence.")
+		matches := FindDuplicates(jane, []Customer{
+			jane,
+			{ID: "c2", FirstName: "jane", LastName: "Doe", Addresses: []Address{{Postcode: "SW1A1AA"}}},
+			{ID: "c3", FirstName: "John", LastName: "Doe", Addresses: []Address{{Postcode: "SW1A 1AA"}}},
+			{ID: "c4", FirstName: "Jane", LastName: "Doe"},
+			{ID: "c5", FirstName: "J.", LastName: "Doe", Email: " Jane@Example.com"},
+			{ID: "c2", FirstName: "Jane", LastName: "Doe"},
+		})
+		if len(matches) != 3 {
+			t.Fatalf("Expected 3 matches, got %+v", matches)
+		}
+		if matches[0].Customer.ID != "c2" || matches[0].Confidence != "high" || strings.Join(matches[0].Reasons, ",") != "name,postcode" {
+			t.Errorf("Expected name and postcode match first, got %+v", matches[0])
+		}
+		if matches[1].Customer.ID != "c5" || matches[1].Confidence != "high" {
+			t.Errorf("Expected email match second, got %+v", matches[1])
+		}
+		if matches[2].Customer.ID != "c4" || matches[2].Confidence != "low" {
+			t.Errorf("Expected name-only match last, got %+v", matches[2])
+		}
+	})
+
+	t.Run("searches by name", func(t *testing.T) {
+		t.Logf("  > Why it's important: Names need escaping in the query, and no match is an empty list rather than an error.")
+		var query string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			query = r.URL.RawQuery
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		client := &Client{APIUser: "user", APIKey: "a2V5", BaseURL: server.URL, HTTPClient: server.Client()}
+		customers, err := client.FindCustomersByName("Mary Ann", "O'Neill", "")
+		if err != nil || len(customers) != 0 {
+			t.Errorf("Expected no customers, got %+v, %v", customers, err)
+		}
+		if query != "firstName=Mary+Ann&lastName=O%27Neill" {
+			t.Errorf("Unexpected query %q", query)
+		}
+	})
+
+	t.Run("merges only with confirmation", func(t *testing.T) {
+		t.Logf("  > Why it's important: The first call must only preview, and a wrong phrase must not merge.")
+		merges := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.Method == "POST" && r.URL.Path == "/customers/c1/merge":
+				merges++
+				_ = json.NewEncoder(w).Encode(jane)
+			case r.Method == "GET":
+				_ = json.NewEncoder(w).Encode(Customer{ID: strings.TrimPrefix(r.URL.Path, "/customers/")})
+			default:
+				t.Errorf("Unexpected %s %s", r.Method, r.URL.Path)
+			}
+		}))
+		defer server.Close()
+
+		h := &Handler{
+			client:   &Client{APIUser: "user", APIKey: "a2V5", BaseURL: server.URL, HTTPClient: server.Client()},
+			baskets:  newSessionValues[string](),
+			searches: newSessionValues[CustomerSearch](),
+		}
+		args := map[string]interface{}{"keepCustomerId": "c1", "duplicateCustomerId": "c2"}
+
+		preview := callTool(t, h, "spektrix_merge_customers", args)
+		if text := preview.Content[0].(mcp.TextContent).Text; preview.IsError || !strings.Contains(text, "merge c2 into c1") || merges != 0 {
+			t.Errorf("Expected a preview with the phrase and no merge, got %s", text)
+		}
+
+		args["confirm"] = "merge c1 into c2"
+		if result := callTool(t, h, "spektrix_merge_customers", args); !result.IsError || merges != 0 {
+			t.Errorf("Expected the reversed phrase to be refused, got %+v", result)
+		}
+
+		args["confirm"] = "merge c2 into c1"
+		if result := callTool(t, h, "spektrix_merge_customers", args); result.IsError || merges != 1 {
+			t.Errorf("Expected one merge, got %d and %+v", merges, result)
+		}
+	})
+}
@@ -0,0 +1,192 @@
+package spektrix
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// EventURITemplate is the resource template for one event's details
+const EventURITemplate = "spektrix://events/{event_id}"
+
+// eventInstanceLimit caps how many upcoming instances an event's details
+// include, each costing a status request
+const eventInstanceLimit = 10
+
+// Event is a show or activity with one or more instances
+type Event struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Duration is the running time in minutes
+	Duration int  `json:"duration,omitempty"`
+	IsOnSale bool `json:"isOnSale"`
+}
+
+// Instance is one performance of an event
+type Instance struct {
+	ID string `json:"id"`
+	// Start is the venue's local start time, without a zone
+	Start     string    `json:"start"`
+	IsOnSale  bool      `json:"isOnSale"`
+	Cancelled bool      `json:"cancelled,omitempty"`
+	Event     *EventRef `json:"event,omitempty"`
+}
+
+// InstanceAvailability is an upcoming instance with its seat availability
+type InstanceAvailability struct {
+	Instance
+	Availability *Availability `json:"availability,omitempty"`
+	// Error explains a missing availability
+	Error string `json:"error,omitempty"`
+}
+
+// EventDetails is an event with its next instances and their availability
+type EventDetails struct {
+	Event     Event                  `json:"event"`
+	Instances []InstanceAvailability `json:"instances"`
+	// Upcoming counts every upcoming instance, including those beyond the
+	// ones listed
+	Upcoming int `json:"upcoming"`
+	// Availability totals the seats of the instances listed
+	Availability Availability `json:"availability"`
+}
+
+// GetEvents retrieves every event, on sale or not
+func (c *Client) GetEvents() ([]Event, error) {
+	resp, err := c.makeRequest("GET", "/events", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	if err := c.handleResponse(resp, &events); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// FilterEvents keeps the events whose name contains name, ignoring case,
+// and, if onSaleOnly, that are on sale
+func FilterEvents(events []Event, name string, onSaleOnly bool) []Event {
+	name = strings.ToLower(strings.TrimSpace(name))
+	filtered := []Event{}
+	for _, event := range events {
+		if onSaleOnly && !event.IsOnSale {
+			continue
+		}
+		if name != "" && !strings.Contains(strings.ToLower(event.Name), name) {
+			continue
+		}
+		filtered = append(filtered, event)
+	}
+	return filtered
+}
+
+// GetEvent retrieves an event by ID
+func (c *Client) GetEvent(eventID string) (*Event, error) {
+	endpoint := fmt.Sprintf("/events/%s", eventID)
+
+	resp, err := c.makeRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var event Event
+	if err := c.handleResponse(resp, &event); err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+// GetEventInstances retrieves an event's instances, past and future
+func (c *Client) GetEventInstances(eventID string) ([]Instance, error) {
+	endpoint := fmt.Sprintf("/events/%s/instances", eventID)
+
+	resp, err := c.makeRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var instances []Instance
+	if err := c.handleResponse(resp, &instances); err != nil {
+		return nil, err
+	}
+
+	return instances, nil
+}
+
+// UpcomingInstances returns the instances starting at or after now that are
+// not cancelled, soonest first. Start times are read as the venue's local
+// time in now's location.
+func UpcomingInstances(instances []Instance, now time.Time) []Instance {
+	type dated struct {
+		instance Instance
+		start    time.Time
+	}
+	var upcoming []dated
+	for _, instance := range instances {
+		start, err := time.ParseInLocation("2006-01-02T15:04:05", instance.Start, now.Location())
+		if err != nil || instance.Cancelled || start.Before(now) {
+			continue
+		}
+		upcoming = append(upcoming, dated{instance, start})
+	}
+	sort.SliceStable(upcoming, func(i, j int) bool {
+		return upcoming[i].start.Before(upcoming[j].start)
+	})
+
+	result := make([]Instance, len(upcoming))
+	for i, d := range upcoming {
+		result[i] = d.instance
+	}
+	return result
+}
+
+// EventDetails fetches an event, its next instances and their availability.
+// An instance whose availability cannot be read is listed with the error
+// rather than failing the whole event.
+func (h *Handler) EventDetails(eventID string, now time.Time) (*EventDetails, error) {
+	event, err := h.client.GetEvent(eventID)
+	if err != nil {
+		return nil, err
+	}
+	instances, err := h.client.GetEventInstances(eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	upcoming := UpcomingInstances(instances, now)
+	details := &EventDetails{
+		Event:     *event,
+		Instances: []InstanceAvailability{},
+		Upcoming:  len(upcoming),
+	}
+	if len(upcoming) > eventInstanceLimit {
+		upcoming = upcoming[:eventInstanceLimit]
+	}
+
+	var total SeatCounts
+	for _, instance := range upcoming {
+		entry := InstanceAvailability{Instance: instance}
+		status, err := h.client.GetInstanceStatus(instance.ID, false)
+		if err != nil {
+			entry.Error = err.Error()
+		} else {
+			availability := Summarize(status.SeatCounts)
+			entry.Availability = &availability
+			total.Capacity += status.Capacity
+			total.Available += status.Available
+			total.Sold += status.Sold
+			total.Reserved += status.Reserved
+			total.Locked += status.Locked
+			total.Selected += status.Selected
+		}
+		details.Instances = append(details.Instances, entry)
+	}
+	details.Availability = Summarize(total)
+	return details, nil
+}
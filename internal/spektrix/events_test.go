@@ -0,0 +1,69 @@
+package spektrix
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventDetails(t *testing.T) {
+	t.Logf("Importance: \"When is it on and are there seats?\" is answered from this resource; past or cancelled shows must not appear.")
+
+	london, _ := time.LoadLocation("Europe/London")
+	now := time.Date(2025, 6, 1, 19, 0, 0, 0, london)
+
+	t.Run("lists upcoming instances soonest first", func(t *testing.T) {
+		t.Logf("  > Why it's important: Spektrix start times are venue-local, and a show starting now is still upcoming.")
+		upcoming := UpcomingInstances([]Instance{
+			{ID: "later", Start: "2025-06-03T19:30:00"},
+			{ID: "past", Start: "2025-05-31T19:30:00"},
+			{ID: "now", Start: "2025-06-01T19:00:00"},
+			{ID: "cancelled", Start: "2025-06-02T19:30:00", Cancelled: true},
+			{ID: "undated", Start: ""},
+		}, now)
+		if len(upcoming) != 2 || upcoming[0].ID != "now" || upcoming[1].ID != "later" {
+			t.Errorf("Expected now then later, got %+v", upcoming)
+		}
+	})
+
+	t.Run("adds availability per instance", func(t *testing.T) {
+		t.Logf("  > Why it's important: One failing status call must not hide the event or the other instances.")
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/events/e1":
+				_, _ = w.Write([]byte(`{"id":"e1","name":"Hamlet","duration":180,"isOnSale":true}`))
+			case "/events/e1/instances":
+				_, _ = w.Write([]byte(`[{"id":"i1","start":"2025-06-02T19:30:00","isOnSale":true},
+					{"id":"i2","start":"2025-06-03T19:30:00","isOnSale":true},
+					{"id":"i0","start":"2025-05-01T19:30:00"}]`))
+			case "/instances/i1/status":
+				_, _ = w.Write([]byte(`{"instance":{"id":"i1"},"capacity":100,"available":0,"sold":100}`))
+			case "/instances/i2/status":
+				w.WriteHeader(http.StatusBadRequest)
+			default:
+				t.Errorf("Unexpected %s %s", r.Method, r.URL.Path)
+			}
+		}))
+		defer server.Close()
+
+		h := &Handler{client: &Client{APIUser: "user", APIKey: "a2V5", BaseURL: server.URL, HTTPClient: server.Client()}}
+		details, err := h.EventDetails("e1", now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if details.Event.Name != "Hamlet" || details.Upcoming != 2 || len(details.Instances) != 2 {
+			t.Fatalf("Unexpected details %+v", details)
+		}
+		if first := details.Instances[0]; first.ID != "i1" || first.Availability == nil || !first.Availability.SoldOut {
+			t.Errorf("Expected i1 sold out, got %+v", first)
+		}
+		if second := details.Instances[1]; second.Availability != nil || !strings.Contains(second.Error, "400") {
+			t.Errorf("Expected i2 to carry its error, got %+v", second)
+		}
+		if details.Availability.Capacity != 100 || details.Availability.PercentSold != 100 {
+			t.Errorf("Expected the total of the readable instances, got %+v", details.Availability)
+		}
+	})
+}
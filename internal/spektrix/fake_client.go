@@ -0,0 +1,792 @@
+package spektrix
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vcto/mcp-adapters/internal/health"
+)
+
+// FakeClient is an in-memory SpektrixClientInterface for tests of Handler
+// that need no HTTP:
+//
+//	fake := NewFakeClient()
+//	jane := fake.AddCustomer(Customer{FirstName: "Jane", LastName: "Doe", Email: "jane@example.com"})
+//	h := NewHandlerWithClient(fake)
+//
+// Missing records fail as Spektrix does, with "API error 404" errors, so
+// handler code that checks for them behaves the same against either client.
+// Baskets are priced from PriceLists and gift voucher balances are spent at
+// checkout.
+type FakeClient struct {
+	mu sync.Mutex
+
+	Customers []Customer
+	// CustomerTags holds each customer's tag IDs in the order added
+	CustomerTags map[string][]string
+	Tags         []Tag
+	Events       []Event
+	// Instances holds each event's instances by event ID
+	Instances map[string][]Instance
+	// PriceLists, Offers and Statuses are by instance ID
+	PriceLists map[string]*PriceList
+	Offers     map[string][]Offer
+	Statuses   map[string]*InstanceStatus
+	Funds      []Fund
+	// Memberships holds each customer's memberships by customer ID
+	Memberships map[string][]CustomerMembership
+	// MembershipPrices is what renewing each membership scheme costs
+	MembershipPrices map[string]float64
+	VoucherTypes     []GiftVoucherType
+	Vouchers         []GiftVoucher
+	Baskets          map[string]*Basket
+	Orders           []Order
+	// Err, when set, fails every call that would reach Spektrix
+	Err error
+	// Calls names the methods called, in order
+	Calls []string
+	// Now is the clock for created and updated times and order dates
+	Now func() time.Time
+
+	nextID int
+}
+
+// NewFakeClient creates a fake Spektrix system with no records
+func NewFakeClient() *FakeClient {
+	return &FakeClient{
+		CustomerTags:     make(map[string][]string),
+		Instances:        make(map[string][]Instance),
+		PriceLists:       make(map[string]*PriceList),
+		Offers:           make(map[string][]Offer),
+		Statuses:         make(map[string]*InstanceStatus),
+		Memberships:      make(map[string][]CustomerMembership),
+		MembershipPrices: make(map[string]float64),
+		Baskets:          make(map[string]*Basket),
+		Now:              time.Now,
+	}
+}
+
+// AddCustomer stores a customer, filling in its ID and those of its
+// addresses when missing, and returns it
+func (f *FakeClient) AddCustomer(c Customer) Customer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.addCustomer(c)
+}
+
+// AddTag creates a tag and returns it
+func (f *FakeClient) AddTag(name string) Tag {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	tag := Tag{ID: f.newID("tag"), Name: name}
+	f.Tags = append(f.Tags, tag)
+	return tag
+}
+
+// AddEvent stores an event and its instances, filling in missing IDs, and
+// returns the event
+func (f *FakeClient) AddEvent(e Event, instances ...Instance) Event {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if e.ID == "" {
+		e.ID = f.newID("event")
+	}
+	f.Events = append(f.Events, e)
+	for _, instance := range instances {
+		if instance.ID == "" {
+			instance.ID = f.newID("instance")
+		}
+		instance.Event = &EventRef{ID: e.ID, Name: e.Name}
+		f.Instances[e.ID] = append(f.Instances[e.ID], instance)
+	}
+	return e
+}
+
+// UpstreamBreaker returns nil; the fake never fails unless Err is set
+func (f *FakeClient) UpstreamBreaker() *health.Breaker {
+	return nil
+}
+
+// SearchCustomers returns the customers with email, in any case
+func (f *FakeClient) SearchCustomers(email string) ([]Customer, error) {
+	return fakeRead(f, "SearchCustomers", func() ([]Customer, error) {
+		return f.customersWhere(func(c Customer) bool { return strings.EqualFold(c.Email, email) }), nil
+	})
+}
+
+// FindCustomersByName returns the customers with lastName and, when given,
+// firstName and an address at postcode
+func (f *FakeClient) FindCustomersByName(firstName, lastName, postcode string) ([]Customer, error) {
+	return fakeRead(f, "FindCustomersByName", func() ([]Customer, error) {
+		return f.customersWhere(func(c Customer) bool {
+			return strings.EqualFold(c.LastName, lastName) &&
+				(firstName == "" || strings.EqualFold(c.FirstName, firstName)) &&
+				(postcode == "" || slices.ContainsFunc(c.Addresses, func(a Address) bool { return normalizePostcode(a.Postcode) == normalizePostcode(postcode) }))
+		}), nil
+	})
+}
+
+// GetCustomer returns a customer by ID
+func (f *FakeClient) GetCustomer(customerID string) (*Customer, error) {
+	return fakeRead(f, "GetCustomer", func() (*Customer, error) {
+		c, err := f.customer(customerID)
+		if err != nil {
+			return nil, err
+		}
+		customer := cloneCustomer(*c)
+		return &customer, nil
+	})
+}
+
+// GetCustomersModifiedSince returns the customers updated at or after since
+func (f *FakeClient) GetCustomersModifiedSince(since time.Time) ([]Customer, error) {
+	return fakeRead(f, "GetCustomersModifiedSince", func() ([]Customer, error) {
+		return f.customersWhere(func(c Customer) bool {
+			updated, err := time.Parse(time.RFC3339, c.UpdatedAt)
+			return err == nil && !updated.Before(since)
+		}), nil
+	})
+}
+
+// CreateCustomer adds a customer, failing with a 409 if the email is taken
+func (f *FakeClient) CreateCustomer(req CreateCustomerRequest) (*Customer, error) {
+	return fakeRead(f, "CreateCustomer", func() (*Customer, error) {
+		if req.LastName == "" || req.Email == "" {
+			return nil, fakeAPIError(400, "firstName, lastName and email are required")
+		}
+		if slices.ContainsFunc(f.Customers, func(c Customer) bool { return strings.EqualFold(c.Email, req.Email) }) {
+			return nil, fakeAPIError(409, "A customer with this email already exists")
+		}
+		customer := f.addCustomer(Customer{FirstName: req.FirstName, LastName: req.LastName, Email: req.Email})
+		return &customer, nil
+	})
+}
+
+// FindOrCreateCustomer returns the customer with email, creating one if
+// there is none
+func (f *FakeClient) FindOrCreateCustomer(email, firstName, lastName string) (*Customer, error) {
+	customers, err := f.SearchCustomers(email)
+	if err != nil {
+		return nil, err
+	}
+	if len(customers) > 0 {
+		return &customers[0], nil
+	}
+	return f.CreateCustomer(CreateCustomerRequest{FirstName: firstName, LastName: lastName, Email: email})
+}
+
+// MergeCustomers moves the duplicate's addresses, tags, memberships and
+// orders to the customer kept and deletes the duplicate
+func (f *FakeClient) MergeCustomers(keepID, duplicateID string) (*Customer, error) {
+	return fakeRead(f, "MergeCustomers", func() (*Customer, error) {
+		keep, err := f.customer(keepID)
+		if err != nil {
+			return nil, err
+		}
+		duplicate, err := f.customer(duplicateID)
+		if err != nil || keepID == duplicateID {
+			return nil, fakeAPIError(400, "Invalid customer to merge")
+		}
+		keep.Addresses = append(keep.Addresses, duplicate.Addresses...)
+		for _, tagID := range f.CustomerTags[duplicateID] {
+			if !slices.Contains(f.CustomerTags[keepID], tagID) {
+				f.CustomerTags[keepID] = append(f.CustomerTags[keepID], tagID)
+			}
+		}
+		f.Memberships[keepID] = append(f.Memberships[keepID], f.Memberships[duplicateID]...)
+		for i := range f.Orders {
+			if f.Orders[i].Customer != nil && f.Orders[i].Customer.ID == duplicateID {
+				f.Orders[i].Customer = &Customer{ID: keepID}
+			}
+		}
+		keep.UpdatedAt = f.timestamp()
+		merged := cloneCustomer(*keep)
+
+		delete(f.CustomerTags, duplicateID)
+		delete(f.Memberships, duplicateID)
+		f.Customers = slices.DeleteFunc(f.Customers, func(c Customer) bool { return c.ID == duplicateID })
+		return &merged, nil
+	})
+}
+
+// GetCustomerAddresses returns a customer's addresses
+func (f *FakeClient) GetCustomerAddresses(customerID string) ([]Address, error) {
+	return fakeRead(f, "GetCustomerAddresses", func() ([]Address, error) {
+		c, err := f.customer(customerID)
+		if err != nil {
+			return nil, err
+		}
+		return append([]Address{}, c.Addresses...), nil
+	})
+}
+
+// AddCustomerAddress adds an address, which takes the billing or delivery
+// role from the customer's other addresses when it is marked with one
+func (f *FakeClient) AddCustomerAddress(customerID string, address Address) error {
+	_, err := fakeRead(f, "AddCustomerAddress", func() (struct{}, error) {
+		c, err := f.customer(customerID)
+		if err != nil {
+			return struct{}{}, err
+		}
+		address.ID = f.newID("address")
+		setAddressDefaults(c.Addresses, address)
+		c.Addresses = append(c.Addresses, address)
+		c.UpdatedAt = f.timestamp()
+		return struct{}{}, nil
+	})
+	return err
+}
+
+// UpdateCustomerAddress replaces the address with address.ID
+func (f *FakeClient) UpdateCustomerAddress(customerID string, address Address) error {
+	_, err := fakeRead(f, "UpdateCustomerAddress", func() (struct{}, error) {
+		c, err := f.customer(customerID)
+		if err != nil {
+			return struct{}{}, err
+		}
+		i := slices.IndexFunc(c.Addresses, func(a Address) bool { return a.ID == address.ID })
+		if i < 0 {
+			return struct{}{}, fakeAPIError(404, "Address not found")
+		}
+		setAddressDefaults(c.Addresses, address)
+		c.Addresses[i] = address
+		c.UpdatedAt = f.timestamp()
+		return struct{}{}, nil
+	})
+	return err
+}
+
+// GetTags returns every tag
+func (f *FakeClient) GetTags() ([]Tag, error) {
+	return fakeRead(f, "GetTags", func() ([]Tag, error) {
+		return append([]Tag{}, f.Tags...), nil
+	})
+}
+
+// UpdateCustomerTags replaces a customer's tags
+func (f *FakeClient) UpdateCustomerTags(customerID string, tagIDs []string) error {
+	return f.changeTags("UpdateCustomerTags", customerID, tagIDs, func(current []string) []string {
+		return slices.Clone(tagIDs)
+	})
+}
+
+// AddCustomerTags adds tags a customer does not already have
+func (f *FakeClient) AddCustomerTags(customerID string, tagIDs []string) error {
+	return f.changeTags("AddCustomerTags", customerID, tagIDs, func(current []string) []string {
+		for _, id := range tagIDs {
+			if !slices.Contains(current, id) {
+				current = append(current, id)
+			}
+		}
+		return current
+	})
+}
+
+// RemoveCustomerTag removes a tag, failing with a 404 if the customer does
+// not have it
+func (f *FakeClient) RemoveCustomerTag(customerID, tagID string) error {
+	_, err := fakeRead(f, "RemoveCustomerTag", func() (struct{}, error) {
+		if _, err := f.customer(customerID); err != nil {
+			return struct{}{}, err
+		}
+		i := slices.Index(f.CustomerTags[customerID], tagID)
+		if i < 0 {
+			return struct{}{}, fakeAPIError(404, "The customer does not have this tag")
+		}
+		f.CustomerTags[customerID] = slices.Delete(f.CustomerTags[customerID], i, i+1)
+		return struct{}{}, nil
+	})
+	return err
+}
+
+// GetEvents returns every event
+func (f *FakeClient) GetEvents() ([]Event, error) {
+	return fakeRead(f, "GetEvents", func() ([]Event, error) {
+		return append([]Event{}, f.Events...), nil
+	})
+}
+
+// GetEvent returns an event by ID
+func (f *FakeClient) GetEvent(eventID string) (*Event, error) {
+	return fakeRead(f, "GetEvent", func() (*Event, error) {
+		i := slices.IndexFunc(f.Events, func(e Event) bool { return e.ID == eventID })
+		if i < 0 {
+			return nil, fakeAPIError(404, "Event not found")
+		}
+		event := f.Events[i]
+		return &event, nil
+	})
+}
+
+// GetEventInstances returns an event's instances
+func (f *FakeClient) GetEventInstances(eventID string) ([]Instance, error) {
+	return fakeRead(f, "GetEventInstances", func() ([]Instance, error) {
+		if !slices.ContainsFunc(f.Events, func(e Event) bool { return e.ID == eventID }) {
+			return nil, fakeAPIError(404, "Event not found")
+		}
+		return append([]Instance{}, f.Instances[eventID]...), nil
+	})
+}
+
+// GetInstance returns an instance by ID, with its event
+func (f *FakeClient) GetInstance(instanceID string) (*Instance, error) {
+	return fakeRead(f, "GetInstance", func() (*Instance, error) {
+		for _, instances := range f.Instances {
+			for _, instance := range instances {
+				if instance.ID == instanceID {
+					return &instance, nil
+				}
+			}
+		}
+		return nil, fakeAPIError(404, "Instance not found")
+	})
+}
+
+// GetInstanceStatus returns the seat counts stored in Statuses, with its
+// areas only when areas is set
+func (f *FakeClient) GetInstanceStatus(instanceID string, areas bool) (*InstanceStatus, error) {
+	return fakeRead(f, "GetInstanceStatus", func() (*InstanceStatus, error) {
+		stored, ok := f.Statuses[instanceID]
+		if !ok {
+			return nil, fakeAPIError(404, "Instance not found")
+		}
+		status := *stored
+		status.ChildPlans = nil
+		if areas {
+			status.ChildPlans = slices.Clone(stored.ChildPlans)
+		}
+		return &status, nil
+	})
+}
+
+// GetPriceList returns the price list stored in PriceLists
+func (f *FakeClient) GetPriceList(instanceID string) (*PriceList, error) {
+	return fakeRead(f, "GetPriceList", func() (*PriceList, error) {
+		priceList, ok := f.PriceLists[instanceID]
+		if !ok {
+			return nil, fakeAPIError(404, "Price list not found")
+		}
+		copied := *priceList
+		return &copied, nil
+	})
+}
+
+// GetInstanceOffers returns the offers stored in Offers
+func (f *FakeClient) GetInstanceOffers(instanceID string) ([]Offer, error) {
+	return fakeRead(f, "GetInstanceOffers", func() ([]Offer, error) {
+		return append([]Offer{}, f.Offers[instanceID]...), nil
+	})
+}
+
+// Quote prices tickets from the stored price list and offers, as Client does
+func (f *FakeClient) Quote(instanceID string, lines []QuoteLineRequest, offerID string) (*Quote, error) {
+	priceList, err := f.GetPriceList(instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price list: %w", err)
+	}
+	offers, err := f.GetInstanceOffers(instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get offers: %w", err)
+	}
+	return BuildQuote(instanceID, priceList, offers, lines, offerID)
+}
+
+// GetFunds returns every fund
+func (f *FakeClient) GetFunds() ([]Fund, error) {
+	return fakeRead(f, "GetFunds", func() ([]Fund, error) {
+		return append([]Fund{}, f.Funds...), nil
+	})
+}
+
+// GetCustomerMemberships returns a customer's memberships
+func (f *FakeClient) GetCustomerMemberships(customerID string) ([]CustomerMembership, error) {
+	return fakeRead(f, "GetCustomerMemberships", func() ([]CustomerMembership, error) {
+		if _, err := f.customer(customerID); err != nil {
+			return nil, err
+		}
+		return append([]CustomerMembership{}, f.Memberships[customerID]...), nil
+	})
+}
+
+// GetGiftVoucherTypes returns every gift voucher type
+func (f *FakeClient) GetGiftVoucherTypes() ([]GiftVoucherType, error) {
+	return fakeRead(f, "GetGiftVoucherTypes", func() ([]GiftVoucherType, error) {
+		return append([]GiftVoucherType{}, f.VoucherTypes...), nil
+	})
+}
+
+// GetGiftVoucher returns a gift voucher by code
+func (f *FakeClient) GetGiftVoucher(code string) (*GiftVoucher, error) {
+	return fakeRead(f, "GetGiftVoucher", func() (*GiftVoucher, error) {
+		i := slices.IndexFunc(f.Vouchers, func(v GiftVoucher) bool { return v.Code == code })
+		if i < 0 {
+			return nil, fakeAPIError(404, "Gift voucher not found")
+		}
+		voucher := f.Vouchers[i]
+		return &voucher, nil
+	})
+}
+
+// CreateBasket creates an empty basket
+func (f *FakeClient) CreateBasket() (*Basket, error) {
+	return fakeRead(f, "CreateBasket", func() (*Basket, error) {
+		basket := &Basket{ID: f.newID("basket"), Tickets: []BasketTicket{}}
+		f.Baskets[basket.ID] = basket
+		return cloneBasket(basket), nil
+	})
+}
+
+// GetBasket returns a basket by ID
+func (f *FakeClient) GetBasket(basketID string) (*Basket, error) {
+	return f.changeBasket("GetBasket", basketID, func(*Basket) error { return nil })
+}
+
+// AddBasketTickets adds tickets priced from the instance's price list, at
+// the base band when none is given. A ticket type or band with no price
+// fails the whole request with a 400.
+func (f *FakeClient) AddBasketTickets(basketID string, tickets []BasketTicketRequest) (*Basket, error) {
+	return f.changeBasket("AddBasketTickets", basketID, func(basket *Basket) error {
+		added := make([]BasketTicket, 0, len(tickets))
+		for _, ticket := range tickets {
+			priceList, ok := f.PriceLists[ticket.Instance]
+			if !ok {
+				return fakeAPIError(400, "Unknown instance "+ticket.Instance)
+			}
+			i := slices.IndexFunc(priceList.Prices, func(p Price) bool {
+				return p.TicketType.ID == ticket.TicketType && (p.PriceBand.ID == ticket.Band || ticket.Band == "" && p.IsBase)
+			})
+			if i < 0 {
+				return fakeAPIError(400, "No price for ticket type "+ticket.TicketType)
+			}
+			price := priceList.Prices[i]
+			added = append(added, BasketTicket{
+				ID:         f.newID("ticket"),
+				Instance:   InstanceRef{ID: ticket.Instance},
+				TicketType: price.TicketType,
+				PriceBand:  price.PriceBand,
+				Price:      price.Amount,
+			})
+		}
+		basket.Tickets = append(basket.Tickets, added...)
+		return nil
+	})
+}
+
+// ApplyBasketOffer discounts the basket's tickets an offer for their
+// instance covers
+func (f *FakeClient) ApplyBasketOffer(basketID, offerID string) (*Basket, error) {
+	return f.changeBasket("ApplyBasketOffer", basketID, func(basket *Basket) error {
+		var offer *Offer
+		for i := range basket.Tickets {
+			ticket := &basket.Tickets[i]
+			j := slices.IndexFunc(f.Offers[ticket.Instance.ID], func(o Offer) bool { return o.ID == offerID && o.IsActive })
+			if j < 0 {
+				continue
+			}
+			offer = &f.Offers[ticket.Instance.ID][j]
+			if len(offer.TicketTypes) > 0 && !slices.ContainsFunc(offer.TicketTypes, func(t TicketTypeRef) bool { return t.ID == ticket.TicketType.ID }) {
+				continue
+			}
+			if strings.EqualFold(offer.DiscountType, "Percentage") {
+				ticket.Discount = roundCurrency(ticket.Price * offer.DiscountAmount / 100)
+			} else {
+				ticket.Discount = min(offer.DiscountAmount, ticket.Price)
+			}
+		}
+		if offer == nil {
+			return fakeAPIError(400, "The offer does not apply to this basket")
+		}
+		basket.Offers = append(basket.Offers, *offer)
+		return nil
+	})
+}
+
+// AddBasketDonation adds a donation to a fund
+func (f *FakeClient) AddBasketDonation(basketID string, donation DonationRequest) (*Basket, error) {
+	return f.changeBasket("AddBasketDonation", basketID, func(basket *Basket) error {
+		i := slices.IndexFunc(f.Funds, func(fund Fund) bool { return fund.ID == donation.Fund.ID })
+		if i < 0 || donation.Amount <= 0 {
+			return fakeAPIError(400, "Invalid donation")
+		}
+		basket.Donations = append(basket.Donations, Donation{
+			ID:      f.newID("donation"),
+			Fund:    FundRef{ID: f.Funds[i].ID, Name: f.Funds[i].Name},
+			Amount:  donation.Amount,
+			GiftAid: donation.GiftAid,
+		})
+		return nil
+	})
+}
+
+// AddBasketMembershipRenewal adds a renewal priced from MembershipPrices
+func (f *FakeClient) AddBasketMembershipRenewal(basketID string, membership CustomerMembership) (*Basket, error) {
+	return f.changeBasket("AddBasketMembershipRenewal", basketID, func(basket *Basket) error {
+		basket.Memberships = append(basket.Memberships, BasketMembership{
+			ID:         f.newID("membership"),
+			Membership: membership.Membership,
+			RenewalOf:  &TagReference{ID: membership.ID},
+			Price:      f.MembershipPrices[membership.Membership.ID],
+		})
+		return nil
+	})
+}
+
+// AddBasketGiftVoucher adds a gift voucher of a stored type
+func (f *FakeClient) AddBasketGiftVoucher(basketID string, voucher GiftVoucherRequest) (*Basket, error) {
+	return f.changeBasket("AddBasketGiftVoucher", basketID, func(basket *Basket) error {
+		i := slices.IndexFunc(f.VoucherTypes, func(t GiftVoucherType) bool { return t.ID == voucher.Type.ID })
+		if i < 0 || voucher.Amount <= 0 {
+			return fakeAPIError(400, "Invalid gift voucher")
+		}
+		basket.GiftVouchers = append(basket.GiftVouchers, BasketGiftVoucher{
+			ID:     f.newID("giftvoucher"),
+			Type:   GiftVoucherTypeRef{ID: f.VoucherTypes[i].ID, Name: f.VoucherTypes[i].Name},
+			Amount: voucher.Amount,
+		})
+		return nil
+	})
+}
+
+// RedeemGiftVoucher pays part of the basket with a voucher. The balance is
+// spent at checkout.
+func (f *FakeClient) RedeemGiftVoucher(basketID string, redemption VoucherRedemption) (*Basket, error) {
+	return f.changeBasket("RedeemGiftVoucher", basketID, func(basket *Basket) error {
+		i := slices.IndexFunc(f.Vouchers, func(v GiftVoucher) bool { return v.Code == redemption.Code })
+		if i < 0 {
+			return fakeAPIError(404, "Gift voucher not found")
+		}
+		if redemption.Amount <= 0 || redemption.Amount > f.Vouchers[i].Balance || redemption.Amount > basket.Total {
+			return fakeAPIError(400, "Invalid redemption amount")
+		}
+		basket.Redemptions = append(basket.Redemptions, redemption)
+		return nil
+	})
+}
+
+// Checkout turns a basket into a confirmed order for a customer, issuing
+// any gift vouchers bought and spending any redeemed
+func (f *FakeClient) Checkout(basketID, customerID string) (*Order, error) {
+	return fakeRead(f, "Checkout", func() (*Order, error) {
+		basket, ok := f.Baskets[basketID]
+		if !ok {
+			return nil, fakeAPIError(404, "Basket not found")
+		}
+		customer, err := f.customer(customerID)
+		if err != nil {
+			return nil, err
+		}
+		order := Order{
+			ID:        f.newID("order"),
+			Customer:  &Customer{ID: customer.ID, FirstName: customer.FirstName, LastName: customer.LastName, Email: customer.Email},
+			Tickets:   slices.Clone(basket.Tickets),
+			Donations: slices.Clone(basket.Donations),
+			Total:     basket.Total,
+			Status:    "Confirmed",
+			Date:      f.timestamp(),
+		}
+		for _, bought := range basket.GiftVouchers {
+			bought.Code = strings.ToUpper(f.newID("gv"))
+			f.Vouchers = append(f.Vouchers, GiftVoucher{
+				ID:       f.newID("voucher"),
+				Code:     bought.Code,
+				Type:     &bought.Type,
+				Value:    bought.Amount,
+				Balance:  bought.Amount,
+				Customer: order.Customer,
+			})
+			order.GiftVouchers = append(order.GiftVouchers, bought)
+		}
+		for _, redemption := range basket.Redemptions {
+			i := slices.IndexFunc(f.Vouchers, func(v GiftVoucher) bool { return v.Code == redemption.Code })
+			f.Vouchers[i].Balance = roundCurrency(f.Vouchers[i].Balance - redemption.Amount)
+		}
+		f.Orders = append(f.Orders, order)
+		delete(f.Baskets, basketID)
+		return &order, nil
+	})
+}
+
+// GetCustomerOrders returns a customer's orders
+func (f *FakeClient) GetCustomerOrders(customerID string) ([]Order, error) {
+	return fakeRead(f, "GetCustomerOrders", func() ([]Order, error) {
+		if _, err := f.customer(customerID); err != nil {
+			return nil, err
+		}
+		orders := []Order{}
+		for _, order := range f.Orders {
+			if order.Customer != nil && order.Customer.ID == customerID {
+				orders = append(orders, order)
+			}
+		}
+		return orders, nil
+	})
+}
+
+// GetOrders returns the orders dated from one day to another, inclusive
+func (f *FakeClient) GetOrders(from, to time.Time) ([]Order, error) {
+	return fakeRead(f, "GetOrders", func() ([]Order, error) {
+		first, last := from.Format("2006-01-02"), to.Format("2006-01-02")
+		orders := []Order{}
+		for _, order := range f.Orders {
+			if day := order.Date[:min(len(order.Date), 10)]; day >= first && day <= last {
+				orders = append(orders, order)
+			}
+		}
+		return orders, nil
+	})
+}
+
+// fakeRead records a call and, unless Err is set, runs read with f.mu held
+func fakeRead[T any](f *FakeClient, method string, read func() (T, error)) (T, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Calls = append(f.Calls, method)
+	if f.Err != nil {
+		var zero T
+		return zero, f.Err
+	}
+	return read()
+}
+
+// changeTags sets a customer's tags to change's result, failing with a 400
+// if tagIDs names a tag that does not exist
+func (f *FakeClient) changeTags(method, customerID string, tagIDs []string, change func([]string) []string) error {
+	_, err := fakeRead(f, method, func() (struct{}, error) {
+		if _, err := f.customer(customerID); err != nil {
+			return struct{}{}, err
+		}
+		for _, id := range tagIDs {
+			if !slices.ContainsFunc(f.Tags, func(t Tag) bool { return t.ID == id }) {
+				return struct{}{}, fakeAPIError(400, "Unknown tag "+id)
+			}
+		}
+		f.CustomerTags[customerID] = change(f.CustomerTags[customerID])
+		return struct{}{}, nil
+	})
+	return err
+}
+
+// changeBasket applies change to a basket and returns it with its total
+// worked out again; the basket is left as it was if change fails
+func (f *FakeClient) changeBasket(method, basketID string, change func(*Basket) error) (*Basket, error) {
+	return fakeRead(f, method, func() (*Basket, error) {
+		stored, ok := f.Baskets[basketID]
+		if !ok {
+			return nil, fakeAPIError(404, "Basket not found")
+		}
+		basket := cloneBasket(stored)
+		if err := change(basket); err != nil {
+			return nil, err
+		}
+		var total float64
+		for _, ticket := range basket.Tickets {
+			total += ticket.Price - ticket.Discount
+		}
+		for _, donation := range basket.Donations {
+			total += donation.Amount
+		}
+		for _, membership := range basket.Memberships {
+			total += membership.Price
+		}
+		for _, voucher := range basket.GiftVouchers {
+			total += voucher.Amount
+		}
+		for _, redemption := range basket.Redemptions {
+			total -= redemption.Amount
+		}
+		basket.Total = roundCurrency(total)
+		f.Baskets[basketID] = basket
+		return cloneBasket(basket), nil
+	})
+}
+
+// customer returns the stored customer with id; called with f.mu held
+func (f *FakeClient) customer(id string) (*Customer, error) {
+	i := slices.IndexFunc(f.Customers, func(c Customer) bool { return c.ID == id })
+	if i < 0 {
+		return nil, fakeAPIError(404, "Customer not found")
+	}
+	return &f.Customers[i], nil
+}
+
+// customersWhere returns copies of the customers match accepts; called
+// with f.mu held
+func (f *FakeClient) customersWhere(match func(Customer) bool) []Customer {
+	customers := []Customer{}
+	for _, c := range f.Customers {
+		if match(c) {
+			customers = append(customers, cloneCustomer(c))
+		}
+	}
+	return customers
+}
+
+// addCustomer stores c; called with f.mu held
+func (f *FakeClient) addCustomer(c Customer) Customer {
+	if c.ID == "" {
+		c.ID = f.newID("customer")
+	}
+	if c.CreatedAt == "" {
+		c.CreatedAt = f.timestamp()
+	}
+	if c.UpdatedAt == "" {
+		c.UpdatedAt = c.CreatedAt
+	}
+	c = cloneCustomer(c)
+	for i := range c.Addresses {
+		if c.Addresses[i].ID == "" {
+			c.Addresses[i].ID = f.newID("address")
+		}
+	}
+	f.Customers = append(f.Customers, c)
+	return cloneCustomer(c)
+}
+
+// newID returns a unique ID with prefix; called with f.mu held
+func (f *FakeClient) newID(prefix string) string {
+	f.nextID++
+	return fmt.Sprintf("%s%d", prefix, f.nextID)
+}
+
+func (f *FakeClient) timestamp() string {
+	return f.Now().UTC().Format(time.RFC3339)
+}
+
+// setAddressDefaults clears the billing and delivery flags address takes
+// over from addresses
+func setAddressDefaults(addresses []Address, address Address) {
+	for i := range addresses {
+		if address.IsBilling {
+			addresses[i].IsBilling = false
+		}
+		if address.IsDelivery {
+			addresses[i].IsDelivery = false
+		}
+	}
+}
+
+func cloneCustomer(c Customer) Customer {
+	c.Addresses = slices.Clone(c.Addresses)
+	return c
+}
+
+func cloneBasket(b *Basket) *Basket {
+	copied := *b
+	copied.Tickets = slices.Clone(b.Tickets)
+	copied.Offers = slices.Clone(b.Offers)
+	copied.Donations = slices.Clone(b.Donations)
+	copied.Memberships = slices.Clone(b.Memberships)
+	copied.GiftVouchers = slices.Clone(b.GiftVouchers)
+	copied.Redemptions = slices.Clone(b.Redemptions)
+	return &copied
+}
+
+// fakeAPIError fails the way Client does for an error response
+func fakeAPIError(status int, message string) error {
+	return fmt.Errorf("API error %d: %s", status, message)
+}
+
+var _ SpektrixClientInterface = (*FakeClient)(nil)
@@ -0,0 +1,92 @@
+package spektrix
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestFakeClientHandlers(t *testing.T) {
+	t.Logf("Importance: Tool handlers should be testable against an in-memory Spektrix, without an HTTP server for every test.")
+
+	fake := NewFakeClient()
+	h := NewHandlerWithClient(fake)
+	jane := fake.AddCustomer(Customer{FirstName: "Jane", LastName: "Doe", Email: "jane@example.com"})
+	hamlet := fake.AddEvent(Event{Name: "Hamlet", IsOnSale: true}, Instance{Start: "2030-06-01T19:30:00", IsOnSale: true})
+	instance := fake.Instances[hamlet.ID][0]
+	fake.PriceLists[instance.ID] = &PriceList{ID: "pl1", Prices: []Price{
+		{Amount: 30, IsBase: true, TicketType: TicketTypeRef{ID: "adult", Name: "Adult"}, PriceBand: PriceBandRef{ID: "stalls"}},
+		{Amount: 20, IsBase: true, TicketType: TicketTypeRef{ID: "child", Name: "Child"}, PriceBand: PriceBandRef{ID: "stalls"}},
+	}}
+	fake.Offers[instance.ID] = []Offer{{ID: "o1", Name: "Family", IsActive: true, DiscountType: "Percentage", DiscountAmount: 10}}
+
+	text := func(result *mcp.CallToolResult) string {
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	t.Run("ticket sale", func(t *testing.T) {
+		t.Logf("  > Why it's important: Basket tools must price, discount and check out through the fake as they would through Spektrix.")
+		if result := callTool(t, h, "spektrix_basket_add_tickets", map[string]interface{}{"instanceId": instance.ID, "tickets": "adult:2,child:1"}); result.IsError {
+			t.Fatalf("spektrix_basket_add_tickets failed: %s", text(result))
+		}
+		if result := callTool(t, h, "spektrix_basket_apply_offer", map[string]interface{}{"offerId": "o1"}); result.IsError || !strings.Contains(text(result), `"total": 72`) {
+			t.Fatalf("Expected 80 less 10%%, got %s", text(result))
+		}
+		if result := callTool(t, h, "spektrix_checkout", map[string]interface{}{"customerId": jane.ID}); result.IsError {
+			t.Fatalf("spektrix_checkout failed: %s", text(result))
+		}
+		if len(fake.Orders) != 1 || fake.Orders[0].Total != 72 || len(fake.Orders[0].Tickets) != 3 || len(fake.Baskets) != 0 {
+			t.Errorf("Expected one order of three tickets for 72, got %+v", fake.Orders)
+		}
+		if result := callTool(t, h, "spektrix_customer_orders", map[string]interface{}{"customerId": jane.ID}); !strings.Contains(text(result), "Hamlet") {
+			t.Errorf("Expected the order listed under Hamlet, got %s", text(result))
+		}
+	})
+
+	t.Run("voucher spent at checkout", func(t *testing.T) {
+		t.Logf("  > Why it's important: A redeemed voucher must only lose its balance once the order goes through.")
+		fake.Vouchers = append(fake.Vouchers, GiftVoucher{ID: "v1", Code: "GV-1", Value: 50, Balance: 50})
+		callTool(t, h, "spektrix_basket_add_tickets", map[string]interface{}{"instanceId": instance.ID, "tickets": "child:1"})
+		if result := callTool(t, h, "spektrix_redeem_voucher", map[string]interface{}{"code": "GV-1"}); result.IsError {
+			t.Fatalf("spektrix_redeem_voucher failed: %s", text(result))
+		}
+		if fake.Vouchers[0].Balance != 50 {
+			t.Errorf("Expected the balance untouched before checkout, got %v", fake.Vouchers[0].Balance)
+		}
+		callTool(t, h, "spektrix_checkout", map[string]interface{}{"customerId": jane.ID})
+		if fake.Vouchers[0].Balance != 30 {
+			t.Errorf("Expected 30 left after a 20 ticket, got %v", fake.Vouchers[0].Balance)
+		}
+	})
+
+	t.Run("tagging search results", func(t *testing.T) {
+		t.Logf("  > Why it's important: Bulk tagging reads the session's search and writes tags, both of which the fake must keep.")
+		news := fake.AddTag("Newsletter")
+		john := fake.AddCustomer(Customer{FirstName: "John", LastName: "Doe", Email: "john@example.com"})
+		callTool(t, h, "spektrix_search_customers", map[string]interface{}{"lastName": "doe"})
+		if result := callTool(t, h, "spektrix_add_customer_tags", map[string]interface{}{"tags": "newsletter"}); result.IsError {
+			t.Fatalf("spektrix_add_customer_tags failed: %s", text(result))
+		}
+		for _, id := range []string{jane.ID, john.ID} {
+			if !slices.Equal(fake.CustomerTags[id], []string{news.ID}) {
+				t.Errorf("Expected %s tagged Newsletter, got %v", id, fake.CustomerTags[id])
+			}
+		}
+	})
+
+	t.Run("failing backend", func(t *testing.T) {
+		t.Logf("  > Why it's important: Tools must report a backend failure rather than an empty result.")
+		fake.Err = errors.New("connection refused")
+		defer func() { fake.Err = nil }()
+		calls := len(fake.Calls)
+		if result := callTool(t, h, "spektrix_list_events", map[string]interface{}{}); !result.IsError || !strings.Contains(text(result), "connection refused") {
+			t.Errorf("Expected the failure reported, got %s", text(result))
+		}
+		if got := fake.Calls[calls:]; !slices.Equal(got, []string{"GetEvents"}) {
+			t.Errorf("Expected the call recorded, got %v", got)
+		}
+	})
+}
@@ -5,14 +5,23 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/vcto/mcp-adapters/internal/health"
 )
 
 // Handler manages Spektrix MCP operations
 type Handler struct {
-	client *Client
+	client SpektrixClientInterface
+	// fallback keeps last-known-good resource data for Spektrix outages
+	fallback *health.FallbackCache
+	// baskets tracks the basket each session is assembling
+	baskets *sessionValues[string]
+	// searches keeps each session's last customer search, served at
+	// spektrix://customers/search and used for bulk tagging
+	searches *sessionValues[CustomerSearch]
 }
 
 // NewHandler creates new Spektrix handler
@@ -23,7 +32,21 @@ func NewHandler() *Handler {
 	}
 
 	return &Handler{
-		client: client,
+		client:   client,
+		fallback: health.NewFallbackCache(health.MaxStaleFromEnv("SPEKTRIX_FALLBACK_MAX_STALE", health.DefaultMaxStale)),
+		baskets:  newSessionValues[string](),
+		searches: newSessionValues[CustomerSearch](),
+	}
+}
+
+// NewHandlerWithClient creates a handler whose tools all act through client,
+// such as a FakeClient or a client for another Spektrix system
+func NewHandlerWithClient(client SpektrixClientInterface) *Handler {
+	return &Handler{
+		client:   client,
+		fallback: health.NewFallbackCache(health.DefaultMaxStale),
+		baskets:  newSessionValues[string](),
+		searches: newSessionValues[CustomerSearch](),
 	}
 }
 
@@ -33,7 +56,7 @@ func (h *Handler) IsAuthenticated() bool {
 }
 
 // GetClient returns the Spektrix API client
-func (h *Handler) GetClient() *Client {
+func (h *Handler) GetClient() SpektrixClientInterface {
 	return h.client
 }
 
@@ -43,32 +66,84 @@ func (h *Handler) SetupTools(s *server.MCPServer) {
 	h.setupFindOrCreateCustomer(s)
 	h.setupCreateCustomer(s)
 	h.setupAddAddress(s)
+	h.setupListAddresses(s)
+	h.setupCustomerOrders(s)
+	h.setupUpdateAddress(s)
+	h.setupFindDuplicates(s)
+	h.setupMergeCustomers(s)
 	h.setupUpdateTags(s)
 	h.setupGetTags(s)
+	h.setupAddCustomerTags(s)
+	h.setupRemoveCustomerTags(s)
+	h.setupListEvents(s)
+	h.setupSalesReport(s)
+	h.setupQuote(s)
+	h.setupInstanceAvailability(s)
+	h.setupSeatingPlanStatus(s)
+	h.setupPriceList(s)
+	h.setupBasketCreate(s)
+	h.setupBasketAddTickets(s)
+	h.setupBasketApplyOffer(s)
+	h.setupBasketView(s)
+	h.setupCheckout(s)
+	h.setupListFunds(s)
+	h.setupRecordDonation(s)
+	h.setupVoucherBalance(s)
+	h.setupIssueVoucher(s)
+	h.setupRedeemVoucher(s)
+	h.setupCustomerMemberships(s)
+	h.setupMembershipRenewal(s)
 }
 
 func (h *Handler) setupSearchCustomers(s *server.MCPServer) {
-	s.AddTool(mcp.NewTool("spektrix_search_customers",
-		mcp.WithDescription("Search for customers by email address"),
-		mcp.WithString("email", mcp.Required(), mcp.Description("Customer email to search for")),
+	s.AddTool(mcp.NewTool("spektrix_search_customers", append([]mcp.ToolOption{
+		mcp.WithDescription("Search for customers by email address, or by name for a paged list"),
+		mcp.WithString("email", mcp.Description("Customer email to search for")),
+		mcp.WithString("lastName", mcp.Description("Last name to search for when no email is given")),
+		mcp.WithString("firstName", mcp.Description("First name to narrow a last name search")),
+	}, pagingOptions()...)...,
 	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		args, ok := request.Params.Arguments.(map[string]interface{})
 		if !ok {
 			return mcp.NewToolResultError("invalid arguments format"), nil
 		}
-		email, ok := args["email"].(string)
-		if !ok || email == "" {
-			return mcp.NewToolResultError("email parameter is required"), nil
+		email := getString(args, "email")
+		lastName := getString(args, "lastName")
+		if email == "" && lastName == "" {
+			return mcp.NewToolResultError("email or lastName is required"), nil
+		}
+		paging, err := pageArgs(args)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
 		}
 
-		customers, err := h.client.SearchCustomers(email)
+		var customers []Customer
+		query := map[string]string{"email": email}
+		if email != "" {
+			customers, err = h.client.SearchCustomers(email)
+		} else {
+			firstName := getString(args, "firstName")
+			query = map[string]string{"lastName": lastName}
+			if firstName != "" {
+				query["firstName"] = firstName
+			}
+			customers, err = h.client.FindCustomersByName(firstName, lastName, "")
+		}
 		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Search failed: %v", err)), nil
+			return health.ToolError(fmt.Sprintf("Search failed: %v", err), err), nil
 		}
+		// The whole result is kept, so bulk tagging reaches every page
+		h.searches.set(ctx, CustomerSearch{
+			Query:      query,
+			Customers:  customers,
+			SearchedAt: time.Now(),
+		})
 
+		page, info := paginate(customers, paging)
 		result := map[string]interface{}{
-			"customers": customers,
-			"count":     len(customers),
+			"customers":  page,
+			"count":      len(page),
+			"pagination": info,
 		}
 
 		resultBytes, _ := json.MarshalIndent(result, "", "  ")
@@ -105,7 +180,7 @@ func (h *Handler) setupFindOrCreateCustomer(s *server.MCPServer) {
 
 		customer, err := h.client.FindOrCreateCustomer(email, firstName, lastName)
 		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Find or create failed: %v", err)), nil
+			return health.ToolError(fmt.Sprintf("Find or create failed: %v", err), err), nil
 		}
 
 		result := map[string]interface{}{
@@ -153,7 +228,7 @@ func (h *Handler) setupCreateCustomer(s *server.MCPServer) {
 
 		customer, err := h.client.CreateCustomer(customerReq)
 		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Customer creation failed: %v", err)), nil
+			return health.ToolError(fmt.Sprintf("Customer creation failed: %v", err), err), nil
 		}
 
 		result := map[string]interface{}{
@@ -183,6 +258,8 @@ func (h *Handler) setupAddAddress(s *server.MCPServer) {
 		mcp.WithString("line2", mcp.Description("Address line 2")),
 		mcp.WithString("city", mcp.Description("City")),
 		mcp.WithString("state", mcp.Description("State/province")),
+		mcp.WithBoolean("billing", mcp.Description("Use as a billing address (default: true)")),
+		mcp.WithBoolean("delivery", mcp.Description("Use as a delivery address (default: true)")),
 	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		args, ok := request.Params.Arguments.(map[string]interface{})
 		if !ok {
@@ -192,14 +269,16 @@ func (h *Handler) setupAddAddress(s *server.MCPServer) {
 		customerID, _ := args["customerId"].(string)
 		country, _ := args["country"].(string)
 		postcode, _ := args["postcode"].(string)
+		billing, hasBilling := args["billing"].(bool)
+		delivery, hasDelivery := args["delivery"].(bool)
 
 		if customerID == "" || country == "" || postcode == "" {
 			return mcp.NewToolResultError("customerId, country, and postcode are required"), nil
 		}
 
 		address := Address{
-			IsDelivery:             true,
-			IsBilling:              true,
+			IsDelivery:             delivery || !hasDelivery,
+			IsBilling:              billing || !hasBilling,
 			Country:                country,
 			AdministrativeDivision: getString(args, "state"),
 			Name:                   "", // Will be set by client
@@ -211,7 +290,7 @@ func (h *Handler) setupAddAddress(s *server.MCPServer) {
 
 		err := h.client.AddCustomerAddress(customerID, address)
 		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Address creation failed: %v", err)), nil
+			return health.ToolError(fmt.Sprintf("Address creation failed: %v", err), err), nil
 		}
 
 		result := map[string]interface{}{
@@ -232,6 +311,299 @@ func (h *Handler) setupAddAddress(s *server.MCPServer) {
 	})
 }
 
+func (h *Handler) setupListAddresses(s *server.MCPServer) {
+	s.AddTool(mcp.NewTool("spektrix_list_addresses",
+		mcp.WithDescription("List a customer's addresses, showing which are used for billing and delivery"),
+		mcp.WithString("customerId", mcp.Required(), mcp.Description("Customer ID")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]interface{})
+		if !ok {
+			return mcp.NewToolResultError("invalid arguments format"), nil
+		}
+
+		customerID := getString(args, "customerId")
+		if customerID == "" {
+			return mcp.NewToolResultError("customerId is required"), nil
+		}
+
+		addresses, err := h.client.GetCustomerAddresses(customerID)
+		if err != nil {
+			return health.ToolError(fmt.Sprintf("Failed to get addresses: %v", err), err), nil
+		}
+
+		result := map[string]interface{}{
+			"customerId": customerID,
+			"addresses":  addresses,
+			"count":      len(addresses),
+		}
+
+		resultBytes, _ := json.MarshalIndent(result, "", "  ")
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: string(resultBytes),
+				},
+			},
+		}, nil
+	})
+}
+
+func (h *Handler) setupCustomerOrders(s *server.MCPServer) {
+	s.AddTool(mcp.NewTool("spektrix_customer_orders", append([]mcp.ToolOption{
+		mcp.WithDescription("List a customer's past orders, newest first, with their tickets by event and performance, a page at a time"),
+		mcp.WithString("customerId", mcp.Required(), mcp.Description("Customer ID")),
+	}, pagingOptions()...)...,
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]interface{})
+		if !ok {
+			return mcp.NewToolResultError("invalid arguments format"), nil
+		}
+
+		customerID := getString(args, "customerId")
+		if customerID == "" {
+			return mcp.NewToolResultError("customerId is required"), nil
+		}
+		paging, err := pageArgs(args)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		page, info, err := h.CustomerOrders(customerID, paging)
+		if err != nil {
+			return health.ToolError(fmt.Sprintf("Failed to get orders: %v", err), err), nil
+		}
+
+		result := map[string]interface{}{
+			"customerId": customerID,
+			"orders":     page,
+			"count":      len(page),
+			"pagination": info,
+		}
+
+		resultBytes, _ := json.MarshalIndent(result, "", "  ")
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: string(resultBytes),
+				},
+			},
+		}, nil
+	})
+}
+
+func (h *Handler) setupUpdateAddress(s *server.MCPServer) {
+	s.AddTool(mcp.NewTool("spektrix_update_address",
+		mcp.WithDescription("Update a customer address, or make it the default billing or delivery address. Only the fields given change."),
+		mcp.WithString("customerId", mcp.Required(), mcp.Description("Customer ID")),
+		mcp.WithString("addressId", mcp.Required(), mcp.Description("Address ID from spektrix_list_addresses")),
+		mcp.WithString("line1", mcp.Description("Address line 1")),
+		mcp.WithString("line2", mcp.Description("Address line 2")),
+		mcp.WithString("city", mcp.Description("City")),
+		mcp.WithString("state", mcp.Description("State/province")),
+		mcp.WithString("postcode", mcp.Description("Postal/zip code")),
+		mcp.WithString("country", mcp.Description("Country code (e.g., 'US')")),
+		mcp.WithBoolean("defaultBilling", mcp.Description("Make this the only billing address")),
+		mcp.WithBoolean("defaultDelivery", mcp.Description("Make this the only delivery address")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]interface{})
+		if !ok {
+			return mcp.NewToolResultError("invalid arguments format"), nil
+		}
+
+		customerID := getString(args, "customerId")
+		addressID := getString(args, "addressId")
+		if customerID == "" || addressID == "" {
+			return mcp.NewToolResultError("customerId and addressId are required"), nil
+		}
+
+		change := AddressChange{
+			Line1:                  getString(args, "line1"),
+			Line2:                  getString(args, "line2"),
+			Town:                   getString(args, "city"),
+			AdministrativeDivision: getString(args, "state"),
+			Postcode:               getString(args, "postcode"),
+			Country:                getString(args, "country"),
+		}
+		change.DefaultBilling, _ = args["defaultBilling"].(bool)
+		change.DefaultDelivery, _ = args["defaultDelivery"].(bool)
+
+		addresses, err := h.client.GetCustomerAddresses(customerID)
+		if err != nil {
+			return health.ToolError(fmt.Sprintf("Failed to get addresses: %v", err), err), nil
+		}
+		changed, err := ApplyAddressChange(addresses, addressID, change)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		// The edited address goes first so a failure leaves the old
+		// defaults in place alongside it rather than none at all
+		for _, address := range changed {
+			if err := h.client.UpdateCustomerAddress(customerID, address); err != nil {
+				return health.ToolError(fmt.Sprintf("Failed to update address %s: %v", address.ID, err), err), nil
+			}
+		}
+
+		result := map[string]interface{}{
+			"success":    true,
+			"customerId": customerID,
+			"address":    changed[0],
+			"updated":    len(changed),
+		}
+
+		resultBytes, _ := json.MarshalIndent(result, "", "  ")
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: string(resultBytes),
+				},
+			},
+		}, nil
+	})
+}
+
+func (h *Handler) setupFindDuplicates(s *server.MCPServer) {
+	s.AddTool(mcp.NewTool("spektrix_find_duplicates",
+		mcp.WithDescription("Find customer records that may be the same person, matching on email, name and postcode. Give a customerId, or the details to check before creating a customer."),
+		mcp.WithString("customerId", mcp.Description("Customer ID to find duplicates of")),
+		mcp.WithString("email", mcp.Description("Email address (when no customerId)")),
+		mcp.WithString("firstName", mcp.Description("First name (when no customerId)")),
+		mcp.WithString("lastName", mcp.Description("Last name (when no customerId)")),
+		mcp.WithString("postcode", mcp.Description("Postcode (when no customerId)")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]interface{})
+		if !ok {
+			return mcp.NewToolResultError("invalid arguments format"), nil
+		}
+
+		var target Customer
+		if customerID := getString(args, "customerId"); customerID != "" {
+			customer, err := h.client.GetCustomer(customerID)
+			if err != nil {
+				return health.ToolError(fmt.Sprintf("Failed to get customer %s: %v", customerID, err), err), nil
+			}
+			target = *customer
+		} else {
+			target = Customer{
+				Email:     getString(args, "email"),
+				FirstName: getString(args, "firstName"),
+				LastName:  getString(args, "lastName"),
+			}
+			if postcode := getString(args, "postcode"); postcode != "" {
+				target.Addresses = []Address{{Postcode: postcode}}
+			}
+		}
+		if target.Email == "" && target.LastName == "" {
+			return mcp.NewToolResultError("customerId, email or lastName is required"), nil
+		}
+
+		var candidates []Customer
+		if target.Email != "" {
+			found, err := h.client.SearchCustomers(target.Email)
+			if err != nil {
+				return health.ToolError(fmt.Sprintf("Search failed: %v", err), err), nil
+			}
+			candidates = append(candidates, found...)
+		}
+		if target.LastName != "" {
+			found, err := h.client.FindCustomersByName(target.FirstName, target.LastName, "")
+			if err != nil {
+				return health.ToolError(fmt.Sprintf("Search failed: %v", err), err), nil
+			}
+			candidates = append(candidates, found...)
+		}
+
+		matches := FindDuplicates(target, candidates)
+		result := map[string]interface{}{
+			"customer":   target,
+			"duplicates": matches,
+			"count":      len(matches),
+		}
+		if len(matches) > 0 {
+			result["next"] = "Review each match with the user, then merge with spektrix_merge_customers."
+		}
+
+		resultBytes, _ := json.MarshalIndent(result, "", "  ")
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: string(resultBytes),
+				},
+			},
+		}, nil
+	})
+}
+
+func (h *Handler) setupMergeCustomers(s *server.MCPServer) {
+	s.AddTool(mcp.NewTool("spektrix_merge_customers",
+		mcp.WithDescription("Merge a duplicate customer into the record to keep. This cannot be undone. Call without confirm first to compare both records and get the confirmation phrase, then call again with it once the user agrees."),
+		mcp.WithString("keepCustomerId", mcp.Required(), mcp.Description("Customer ID to keep")),
+		mcp.WithString("duplicateCustomerId", mcp.Required(), mcp.Description("Customer ID to merge away")),
+		mcp.WithString("confirm", mcp.Description("The exact phrase 'merge <duplicateCustomerId> into <keepCustomerId>'; omit to preview")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]interface{})
+		if !ok {
+			return mcp.NewToolResultError("invalid arguments format"), nil
+		}
+
+		keepID := getString(args, "keepCustomerId")
+		duplicateID := getString(args, "duplicateCustomerId")
+		if keepID == "" || duplicateID == "" {
+			return mcp.NewToolResultError("keepCustomerId and duplicateCustomerId are required"), nil
+		}
+		if keepID == duplicateID {
+			return mcp.NewToolResultError("keepCustomerId and duplicateCustomerId must be different customers"), nil
+		}
+
+		phrase := mergeConfirmation(keepID, duplicateID)
+		var result map[string]interface{}
+		switch confirm := strings.TrimSpace(getString(args, "confirm")); {
+		case confirm == "":
+			keep, err := h.client.GetCustomer(keepID)
+			if err != nil {
+				return health.ToolError(fmt.Sprintf("Failed to get customer %s: %v", keepID, err), err), nil
+			}
+			duplicate, err := h.client.GetCustomer(duplicateID)
+			if err != nil {
+				return health.ToolError(fmt.Sprintf("Failed to get customer %s: %v", duplicateID, err), err), nil
+			}
+			result = map[string]interface{}{
+				"preview":   true,
+				"keep":      keep,
+				"duplicate": duplicate,
+				"confirm":   phrase,
+				"next":      fmt.Sprintf("Nothing has been merged. Once the user agrees, call again with confirm '%s'.", phrase),
+			}
+		case confirm != phrase:
+			return mcp.NewToolResultError(fmt.Sprintf("confirm must be exactly '%s'; nothing was merged", phrase)), nil
+		default:
+			merged, err := h.client.MergeCustomers(keepID, duplicateID)
+			if err != nil {
+				return health.ToolError(fmt.Sprintf("Merge failed: %v", err), err), nil
+			}
+			result = map[string]interface{}{
+				"success":  true,
+				"customer": merged,
+				"merged":   duplicateID,
+			}
+		}
+
+		resultBytes, _ := json.MarshalIndent(result, "", "  ")
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: string(resultBytes),
+				},
+			},
+		}, nil
+	})
+}
+
 func (h *Handler) setupUpdateTags(s *server.MCPServer) {
 	s.AddTool(mcp.NewTool("spektrix_update_tags",
 		mcp.WithDescription("Update customer tags (replaces all existing tags)"),
@@ -257,7 +629,7 @@ func (h *Handler) setupUpdateTags(s *server.MCPServer) {
 
 		err := h.client.UpdateCustomerTags(customerID, tagIDs)
 		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Tag update failed: %v", err)), nil
+			return health.ToolError(fmt.Sprintf("Tag update failed: %v", err), err), nil
 		}
 
 		result := map[string]interface{}{
@@ -284,7 +656,7 @@ func (h *Handler) setupGetTags(s *server.MCPServer) {
 	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		tags, err := h.client.GetTags()
 		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to get tags: %v", err)), nil
+			return health.ToolError(fmt.Sprintf("Failed to get tags: %v", err), err), nil
 		}
 
 		result := map[string]interface{}{
@@ -304,24 +676,959 @@ func (h *Handler) setupGetTags(s *server.MCPServer) {
 	})
 }
 
-// Helper functions
-func getString(args map[string]interface{}, key string) string {
-	if val, ok := args[key].(string); ok {
-		return val
-	}
-	return ""
+func (h *Handler) setupAddCustomerTags(s *server.MCPServer) {
+	s.AddTool(mcp.NewTool("spektrix_add_customer_tags",
+		mcp.WithDescription("Attach tags to customers, keeping their existing tags. Tags may be given by ID or name; see the spektrix://tags resource."),
+		mcp.WithString("tags", mcp.Required(), mcp.Description("Comma-separated tag IDs or names")),
+		mcp.WithString("customerIds", mcp.Description("Comma-separated customer IDs (default: the customers found by this conversation's last spektrix_search_customers)")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return h.tagCustomers(ctx, request, "added", h.client.AddCustomerTags), nil
+	})
 }
 
-func splitAndTrim(s, sep string) []string {
-	if s == "" {
-		return []string{}
+func (h *Handler) setupRemoveCustomerTags(s *server.MCPServer) {
+	s.AddTool(mcp.NewTool("spektrix_remove_customer_tags",
+		mcp.WithDescription("Remove tags from customers, keeping their other tags. Tags may be given by ID or name; see the spektrix://tags resource."),
+		mcp.WithString("tags", mcp.Required(), mcp.Description("Comma-separated tag IDs or names")),
+		mcp.WithString("customerIds", mcp.Description("Comma-separated customer IDs (default: the customers found by this conversation's last spektrix_search_customers)")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return h.tagCustomers(ctx, request, "removed", func(customerID string, tagIDs []string) error {
+			for _, tagID := range tagIDs {
+				if err := h.client.RemoveCustomerTag(customerID, tagID); err != nil {
+					return err
+				}
+			}
+			return nil
+		}), nil
+	})
+}
+
+// tagCustomers resolves the tags argument against the cached tag list and
+// applies them to each customer in turn, reporting per-customer results so
+// one failure does not hide the rest
+func (h *Handler) tagCustomers(ctx context.Context, request mcp.CallToolRequest, action string, apply func(customerID string, tagIDs []string) error) *mcp.CallToolResult {
+	args, ok := request.Params.Arguments.(map[string]interface{})
+	if !ok {
+		return mcp.NewToolResultError("invalid arguments format")
 	}
 
-	parts := make([]string, 0)
-	for _, part := range strings.Split(s, sep) {
-		if trimmed := strings.TrimSpace(part); trimmed != "" {
-			parts = append(parts, trimmed)
+	refs := splitAndTrim(getString(args, "tags"), ",")
+	if len(refs) == 0 {
+		return mcp.NewToolResultError("tags is required")
+	}
+
+	customerIDs := splitAndTrim(getString(args, "customerIds"), ",")
+	if len(customerIDs) == 0 {
+		search, _ := h.searches.get(ctx)
+		customerIDs = search.CustomerIDs()
+	}
+	if len(customerIDs) == 0 {
+		return mcp.NewToolResultError("customerIds is required when no earlier spektrix_search_customers found customers")
+	}
+
+	allTags, _, err := h.Tags()
+	if err != nil {
+		return health.ToolError(fmt.Sprintf("Failed to get tags: %v", err), err)
+	}
+	tags, err := ResolveTags(allTags, refs)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error())
+	}
+
+	var results []CustomerTagResult
+	succeeded := 0
+	var lastErr error
+	for _, customerID := range customerIDs {
+		result := CustomerTagResult{CustomerID: customerID, Success: true}
+		if err := apply(customerID, tagIDs(tags)); err != nil {
+			result.Success = false
+			result.Error = err.Error()
+			lastErr = err
+		} else {
+			succeeded++
 		}
+		results = append(results, result)
 	}
-	return parts
+	if succeeded == 0 {
+		return health.ToolError(fmt.Sprintf("No customers were tagged: %v", lastErr), lastErr)
+	}
+
+	result := map[string]interface{}{
+		action:      tags,
+		"customers": results,
+		"succeeded": succeeded,
+		"failed":    len(customerIDs) - succeeded,
+	}
+
+	resultBytes, _ := json.MarshalIndent(result, "", "  ")
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: string(resultBytes),
+			},
+		},
+	}
+}
+
+func (h *Handler) setupListEvents(s *server.MCPServer) {
+	s.AddTool(mcp.NewTool("spektrix_list_events", append([]mcp.ToolOption{
+		mcp.WithDescription("List events, a page at a time; read spektrix://events/{event_id} for an event's instances"),
+		mcp.WithString("name", mcp.Description("Only events whose name contains this text")),
+		mcp.WithBoolean("onSaleOnly", mcp.Description("Only events on sale (default: false)")),
+	}, pagingOptions()...)...,
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]interface{})
+		if !ok {
+			return mcp.NewToolResultError("invalid arguments format"), nil
+		}
+		paging, err := pageArgs(args)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		onSaleOnly, _ := args["onSaleOnly"].(bool)
+
+		events, err := h.client.GetEvents()
+		if err != nil {
+			return health.ToolError(fmt.Sprintf("Failed to get events: %v", err), err), nil
+		}
+
+		page, info := paginate(FilterEvents(events, getString(args, "name"), onSaleOnly), paging)
+		result := map[string]interface{}{
+			"events":     page,
+			"count":      len(page),
+			"pagination": info,
+		}
+
+		resultBytes, _ := json.MarshalIndent(result, "", "  ")
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: string(resultBytes),
+				},
+			},
+		}, nil
+	})
+}
+
+func (h *Handler) setupSalesReport(s *server.MCPServer) {
+	s.AddTool(mcp.NewTool("spektrix_sales_report",
+		mcp.WithDescription("Ticket sales and revenue by event and instance for orders placed over a date range"),
+		mcp.WithString("from", mcp.Required(), mcp.Description("First order date, YYYY-MM-DD")),
+		mcp.WithString("to", mcp.Description("Last order date, YYYY-MM-DD (default: today)")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]interface{})
+		if !ok {
+			return mcp.NewToolResultError("invalid arguments format"), nil
+		}
+
+		from, to, err := salesReportRange(getString(args, "from"), getString(args, "to"), time.Now())
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		report, err := h.SalesReport(from, to)
+		if err != nil {
+			return health.ToolError(fmt.Sprintf("Failed to build sales report: %v", err), err), nil
+		}
+
+		resultBytes, _ := json.MarshalIndent(report, "", "  ")
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: string(resultBytes),
+				},
+			},
+		}, nil
+	})
+}
+
+func (h *Handler) setupQuote(s *server.MCPServer) {
+	s.AddTool(mcp.NewTool("spektrix_quote",
+		mcp.WithDescription("Price tickets for an event instance, applying offers and fees, without holding seats. The quote is an estimate worked out from Spektrix's price list and offers, as Spektrix only prices tickets held in a basket; the basket total is the final price. Returns a quote whose tickets can be used for basket creation."),
+		mcp.WithString("instanceId", mcp.Required(), mcp.Description("Event instance ID")),
+		mcp.WithString("tickets", mcp.Required(), mcp.Description("Comma-separated ticketTypeId:quantity entries (e.g., 'adult:2,child:1'). Use ticketTypeId@priceBandId:quantity to pick a price band.")),
+		mcp.WithString("offerId", mcp.Description("Offer ID to apply (default: best applicable offer)")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]interface{})
+		if !ok {
+			return mcp.NewToolResultError("invalid arguments format"), nil
+		}
+
+		instanceID, _ := args["instanceId"].(string)
+		ticketsStr, _ := args["tickets"].(string)
+
+		if instanceID == "" || ticketsStr == "" {
+			return mcp.NewToolResultError("instanceId and tickets are required"), nil
+		}
+
+		lines, err := ParseQuoteLines(ticketsStr)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid tickets: %v", err)), nil
+		}
+
+		quote, err := h.client.Quote(instanceID, lines, getString(args, "offerId"))
+		if err != nil {
+			return health.ToolError(fmt.Sprintf("Quote failed: %v", err), err), nil
+		}
+
+		result := map[string]interface{}{
+			"quote": quote,
+			"note":  "Estimated from the price list and offers. Pass quote.tickets to basket creation to reserve these tickets; the basket total is what Spektrix charges.",
+		}
+
+		resultBytes, _ := json.MarshalIndent(result, "", "  ")
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: string(resultBytes),
+				},
+			},
+		}, nil
+	})
+}
+
+func (h *Handler) setupInstanceAvailability(s *server.MCPServer) {
+	s.AddTool(mcp.NewTool("spektrix_instance_availability",
+		mcp.WithDescription("Get seat availability for an event instance (performance): capacity, available, sold, reserved and locked seats"),
+		mcp.WithString("instanceId", mcp.Required(), mcp.Description("Event instance ID")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]interface{})
+		if !ok {
+			return mcp.NewToolResultError("invalid arguments format"), nil
+		}
+
+		instanceID := getString(args, "instanceId")
+		if instanceID == "" {
+			return mcp.NewToolResultError("instanceId is required"), nil
+		}
+
+		status, err := h.client.GetInstanceStatus(instanceID, false)
+		if err != nil {
+			return health.ToolError(fmt.Sprintf("Failed to get availability: %v", err), err), nil
+		}
+
+		result := map[string]interface{}{
+			"instanceId":   instanceID,
+			"availability": Summarize(status.SeatCounts),
+		}
+
+		resultBytes, _ := json.MarshalIndent(result, "", "  ")
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: string(resultBytes),
+				},
+			},
+		}, nil
+	})
+}
+
+func (h *Handler) setupSeatingPlanStatus(s *server.MCPServer) {
+	s.AddTool(mcp.NewTool("spektrix_seating_plan_status",
+		mcp.WithDescription("Get seat availability for each area of an event instance's seating plan (e.g. Stalls, Circle), with the instance total"),
+		mcp.WithString("instanceId", mcp.Required(), mcp.Description("Event instance ID")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]interface{})
+		if !ok {
+			return mcp.NewToolResultError("invalid arguments format"), nil
+		}
+
+		instanceID := getString(args, "instanceId")
+		if instanceID == "" {
+			return mcp.NewToolResultError("instanceId is required"), nil
+		}
+
+		status, err := h.client.GetInstanceStatus(instanceID, true)
+		if err != nil {
+			return health.ToolError(fmt.Sprintf("Failed to get seating plan status: %v", err), err), nil
+		}
+
+		areas := AreaAvailabilities(status)
+		result := map[string]interface{}{
+			"instanceId": instanceID,
+			"total":      Summarize(status.SeatCounts),
+			"areas":      areas,
+			"count":      len(areas),
+		}
+
+		resultBytes, _ := json.MarshalIndent(result, "", "  ")
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: string(resultBytes),
+				},
+			},
+		}, nil
+	})
+}
+
+func (h *Handler) setupPriceList(s *server.MCPServer) {
+	s.AddTool(mcp.NewTool("spektrix_price_list",
+		mcp.WithDescription("Get ticket prices for an event instance grouped by price band, with fees. Use the ticket type and band IDs with spektrix_quote."),
+		mcp.WithString("instanceId", mcp.Required(), mcp.Description("Event instance ID")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]interface{})
+		if !ok {
+			return mcp.NewToolResultError("invalid arguments format"), nil
+		}
+
+		instanceID := getString(args, "instanceId")
+		if instanceID == "" {
+			return mcp.NewToolResultError("instanceId is required"), nil
+		}
+
+		priceList, err := h.client.GetPriceList(instanceID)
+		if err != nil {
+			return health.ToolError(fmt.Sprintf("Failed to get price list: %v", err), err), nil
+		}
+
+		fees := priceList.Fees
+		if fees == nil {
+			fees = []Fee{}
+		}
+		result := map[string]interface{}{
+			"instanceId":  instanceID,
+			"priceListId": priceList.ID,
+			"priceBands":  PriceBands(priceList),
+			"fees":        fees,
+		}
+
+		resultBytes, _ := json.MarshalIndent(result, "", "  ")
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: string(resultBytes),
+				},
+			},
+		}, nil
+	})
+}
+
+func (h *Handler) setupBasketCreate(s *server.MCPServer) {
+	s.AddTool(mcp.NewTool("spektrix_basket_create",
+		mcp.WithDescription("Start a new basket for this conversation. Later basket tools use it unless given a basketId."),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		basket, err := h.client.CreateBasket()
+		if err != nil {
+			return health.ToolError(fmt.Sprintf("Failed to create basket: %v", err), err), nil
+		}
+		h.baskets.set(ctx, basket.ID)
+
+		return basketResult(basket, "Add tickets with spektrix_basket_add_tickets."), nil
+	})
+}
+
+func (h *Handler) setupBasketAddTickets(s *server.MCPServer) {
+	s.AddTool(mcp.NewTool("spektrix_basket_add_tickets",
+		mcp.WithDescription("Add tickets for an event instance to the basket, starting one if this conversation has none"),
+		mcp.WithString("instanceId", mcp.Required(), mcp.Description("Event instance ID")),
+		mcp.WithString("tickets", mcp.Required(), mcp.Description("Comma-separated ticketTypeId:quantity entries (e.g., 'adult:2,child:1'). Use ticketTypeId@priceBandId:quantity to pick a price band.")),
+		mcp.WithString("basketId", mcp.Description("Basket ID (default: this conversation's basket)")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]interface{})
+		if !ok {
+			return mcp.NewToolResultError("invalid arguments format"), nil
+		}
+
+		instanceID := getString(args, "instanceId")
+		ticketsStr := getString(args, "tickets")
+		if instanceID == "" || ticketsStr == "" {
+			return mcp.NewToolResultError("instanceId and tickets are required"), nil
+		}
+
+		lines, err := ParseQuoteLines(ticketsStr)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid tickets: %v", err)), nil
+		}
+
+		basketID := h.basketID(ctx, args)
+		if basketID == "" {
+			basket, err := h.client.CreateBasket()
+			if err != nil {
+				return health.ToolError(fmt.Sprintf("Failed to create basket: %v", err), err), nil
+			}
+			basketID = basket.ID
+			h.baskets.set(ctx, basketID)
+		}
+
+		basket, err := h.client.AddBasketTickets(basketID, BasketTickets(instanceID, lines))
+		if err != nil {
+			return health.ToolError(fmt.Sprintf("Failed to add tickets to basket %s: %v", basketID, err), err), nil
+		}
+
+		return basketResult(basket, "Apply an offer with spektrix_basket_apply_offer, or complete the order with spektrix_checkout."), nil
+	})
+}
+
+func (h *Handler) setupBasketApplyOffer(s *server.MCPServer) {
+	s.AddTool(mcp.NewTool("spektrix_basket_apply_offer",
+		mcp.WithDescription("Apply an offer to the basket's tickets. Use spektrix_quote to find the best offer first."),
+		mcp.WithString("offerId", mcp.Required(), mcp.Description("Offer ID")),
+		mcp.WithString("basketId", mcp.Description("Basket ID (default: this conversation's basket)")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]interface{})
+		if !ok {
+			return mcp.NewToolResultError("invalid arguments format"), nil
+		}
+
+		offerID := getString(args, "offerId")
+		if offerID == "" {
+			return mcp.NewToolResultError("offerId is required"), nil
+		}
+		basketID := h.basketID(ctx, args)
+		if basketID == "" {
+			return mcp.NewToolResultError("No basket in progress. Add tickets with spektrix_basket_add_tickets first."), nil
+		}
+
+		basket, err := h.client.ApplyBasketOffer(basketID, offerID)
+		if err != nil {
+			return health.ToolError(fmt.Sprintf("Failed to apply offer %s to basket %s: %v", offerID, basketID, err), err), nil
+		}
+
+		return basketResult(basket, "Complete the order with spektrix_checkout."), nil
+	})
+}
+
+func (h *Handler) setupBasketView(s *server.MCPServer) {
+	s.AddTool(mcp.NewTool("spektrix_basket_view",
+		mcp.WithDescription("Show the basket's tickets, offers and total"),
+		mcp.WithString("basketId", mcp.Description("Basket ID (default: this conversation's basket)")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, _ := request.Params.Arguments.(map[string]interface{})
+
+		basketID := h.basketID(ctx, args)
+		if basketID == "" {
+			return mcp.NewToolResultError("No basket in progress. Add tickets with spektrix_basket_add_tickets first."), nil
+		}
+
+		basket, err := h.client.GetBasket(basketID)
+		if err != nil {
+			return health.ToolError(fmt.Sprintf("Failed to get basket %s: %v", basketID, err), err), nil
+		}
+
+		return basketResult(basket, ""), nil
+	})
+}
+
+func (h *Handler) setupCheckout(s *server.MCPServer) {
+	s.AddTool(mcp.NewTool("spektrix_checkout",
+		mcp.WithDescription("Complete the basket as an order for a customer. Confirm the basket with the customer first; this cannot be undone here."),
+		mcp.WithString("customerId", mcp.Required(), mcp.Description("Customer ID, e.g. from spektrix_find_or_create_customer")),
+		mcp.WithString("basketId", mcp.Description("Basket ID (default: this conversation's basket)")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]interface{})
+		if !ok {
+			return mcp.NewToolResultError("invalid arguments format"), nil
+		}
+
+		customerID := getString(args, "customerId")
+		if customerID == "" {
+			return mcp.NewToolResultError("customerId is required"), nil
+		}
+		basketID := h.basketID(ctx, args)
+		if basketID == "" {
+			return mcp.NewToolResultError("No basket in progress. Add tickets with spektrix_basket_add_tickets first."), nil
+		}
+
+		order, err := h.client.Checkout(basketID, customerID)
+		if err != nil {
+			return health.ToolError(fmt.Sprintf("Checkout of basket %s failed: %v", basketID, err), err), nil
+		}
+		h.baskets.remove(ctx, func(id string) bool { return id == basketID })
+
+		result := map[string]interface{}{
+			"order":    order,
+			"basketId": basketID,
+		}
+
+		resultBytes, _ := json.MarshalIndent(result, "", "  ")
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: string(resultBytes),
+				},
+			},
+		}, nil
+	})
+}
+
+func (h *Handler) setupListFunds(s *server.MCPServer) {
+	s.AddTool(mcp.NewTool("spektrix_list_funds",
+		mcp.WithDescription("List the funds donations can be made to"),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		funds, err := h.client.GetFunds()
+		if err != nil {
+			return health.ToolError(fmt.Sprintf("Failed to get funds: %v", err), err), nil
+		}
+
+		result := map[string]interface{}{
+			"funds": funds,
+			"count": len(funds),
+		}
+
+		resultBytes, _ := json.MarshalIndent(result, "", "  ")
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: string(resultBytes),
+				},
+			},
+		}, nil
+	})
+}
+
+func (h *Handler) setupRecordDonation(s *server.MCPServer) {
+	s.AddTool(mcp.NewTool("spektrix_record_donation",
+		mcp.WithDescription("Record a donation to a fund for a customer, checked out as its own order. Set addToBasket to add it to this conversation's basket instead, to check out with tickets. Confirm the amount with the donor first."),
+		mcp.WithString("fund", mcp.Required(), mcp.Description("Fund ID, code or name from spektrix_list_funds")),
+		mcp.WithNumber("amount", mcp.Required(), mcp.Description("Donation amount in the venue's currency")),
+		mcp.WithString("customerId", mcp.Description("Customer ID; required unless addToBasket is set")),
+		mcp.WithBoolean("giftAid", mcp.Description("Claim UK Gift Aid; only when the donor is a UK taxpayer with a Gift Aid declaration (default: false)")),
+		mcp.WithBoolean("addToBasket", mcp.Description("Add to this conversation's basket instead of checking out now (default: false)")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]interface{})
+		if !ok {
+			return mcp.NewToolResultError("invalid arguments format"), nil
+		}
+
+		fundRef := getString(args, "fund")
+		amount, _ := args["amount"].(float64)
+		customerID := getString(args, "customerId")
+		giftAid, _ := args["giftAid"].(bool)
+		addToBasket, _ := args["addToBasket"].(bool)
+		if fundRef == "" {
+			return mcp.NewToolResultError("fund is required"), nil
+		}
+		if customerID == "" && !addToBasket {
+			return mcp.NewToolResultError("customerId is required unless addToBasket is set"), nil
+		}
+
+		funds, err := h.client.GetFunds()
+		if err != nil {
+			return health.ToolError(fmt.Sprintf("Failed to get funds: %v", err), err), nil
+		}
+		fund, err := ResolveFund(funds, fundRef)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		donation, err := NewDonation(fund, amount, giftAid)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid donation: %v", err)), nil
+		}
+
+		var basketID string
+		if addToBasket {
+			basketID = h.basketID(ctx, args)
+		}
+		if basketID == "" {
+			basket, err := h.client.CreateBasket()
+			if err != nil {
+				return health.ToolError(fmt.Sprintf("Failed to create basket: %v", err), err), nil
+			}
+			basketID = basket.ID
+			if addToBasket {
+				h.baskets.set(ctx, basketID)
+			}
+		}
+
+		basket, err := h.client.AddBasketDonation(basketID, donation)
+		if err != nil {
+			return health.ToolError(fmt.Sprintf("Failed to add donation to basket %s: %v", basketID, err), err), nil
+		}
+		if addToBasket {
+			return basketResult(basket, "Complete the order with spektrix_checkout."), nil
+		}
+
+		order, err := h.client.Checkout(basketID, customerID)
+		if err != nil {
+			return health.ToolError(fmt.Sprintf("Checkout of donation basket %s failed: %v", basketID, err), err), nil
+		}
+
+		result := map[string]interface{}{
+			"order":    order,
+			"fund":     fund,
+			"amount":   donation.Amount,
+			"giftAid":  giftAid,
+			"basketId": basketID,
+		}
+
+		resultBytes, _ := json.MarshalIndent(result, "", "  ")
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: string(resultBytes),
+				},
+			},
+		}, nil
+	})
+}
+
+func (h *Handler) setupVoucherBalance(s *server.MCPServer) {
+	s.AddTool(mcp.NewTool("spektrix_voucher_balance",
+		mcp.WithDescription("Check a gift voucher's balance and expiry, and whether it can be redeemed today"),
+		mcp.WithString("code", mcp.Required(), mcp.Description("Code printed on the voucher")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]interface{})
+		if !ok {
+			return mcp.NewToolResultError("invalid arguments format"), nil
+		}
+
+		code := strings.TrimSpace(getString(args, "code"))
+		if code == "" {
+			return mcp.NewToolResultError("code is required"), nil
+		}
+
+		voucher, err := h.client.GetGiftVoucher(code)
+		if err != nil {
+			if strings.HasPrefix(err.Error(), "API error 404") {
+				return mcp.NewToolResultError(fmt.Sprintf("No gift voucher has the code %s", code)), nil
+			}
+			return health.ToolError(fmt.Sprintf("Failed to get voucher: %v", err), err), nil
+		}
+
+		resultBytes, _ := json.MarshalIndent(CheckVoucher(*voucher, time.Now()), "", "  ")
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: string(resultBytes),
+				},
+			},
+		}, nil
+	})
+}
+
+func (h *Handler) setupIssueVoucher(s *server.MCPServer) {
+	s.AddTool(mcp.NewTool("spektrix_issue_voucher",
+		mcp.WithDescription("Sell a gift voucher to a customer, checked out as its own order; the order carries the voucher's code. Set addToBasket to add it to this conversation's basket instead, to check out with tickets. Confirm the type and amount with the buyer first."),
+		mcp.WithString("type", mcp.Required(), mcp.Description("Gift voucher type ID or name")),
+		mcp.WithNumber("amount", mcp.Description("Voucher value, for open-value types; fixed-value types use their own")),
+		mcp.WithString("customerId", mcp.Description("Buyer's customer ID; required unless addToBasket is set")),
+		mcp.WithBoolean("addToBasket", mcp.Description("Add to this conversation's basket instead of checking out now (default: false)")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]interface{})
+		if !ok {
+			return mcp.NewToolResultError("invalid arguments format"), nil
+		}
+
+		typeRef := getString(args, "type")
+		amount, _ := args["amount"].(float64)
+		customerID := getString(args, "customerId")
+		addToBasket, _ := args["addToBasket"].(bool)
+		if typeRef == "" {
+			return mcp.NewToolResultError("type is required"), nil
+		}
+		if customerID == "" && !addToBasket {
+			return mcp.NewToolResultError("customerId is required unless addToBasket is set"), nil
+		}
+
+		types, err := h.client.GetGiftVoucherTypes()
+		if err != nil {
+			return health.ToolError(fmt.Sprintf("Failed to get voucher types: %v", err), err), nil
+		}
+		voucherType, err := ResolveVoucherType(types, typeRef)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		voucher, err := NewGiftVoucher(voucherType, amount)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid voucher: %v", err)), nil
+		}
+
+		var basketID string
+		if addToBasket {
+			basketID = h.basketID(ctx, args)
+		}
+		if basketID == "" {
+			basket, err := h.client.CreateBasket()
+			if err != nil {
+				return health.ToolError(fmt.Sprintf("Failed to create basket: %v", err), err), nil
+			}
+			basketID = basket.ID
+			if addToBasket {
+				h.baskets.set(ctx, basketID)
+			}
+		}
+
+		basket, err := h.client.AddBasketGiftVoucher(basketID, voucher)
+		if err != nil {
+			return health.ToolError(fmt.Sprintf("Failed to add voucher to basket %s: %v", basketID, err), err), nil
+		}
+		if addToBasket {
+			return basketResult(basket, "Complete the order with spektrix_checkout; the voucher's code is issued then."), nil
+		}
+
+		order, err := h.client.Checkout(basketID, customerID)
+		if err != nil {
+			return health.ToolError(fmt.Sprintf("Checkout of voucher basket %s failed: %v", basketID, err), err), nil
+		}
+
+		result := map[string]interface{}{
+			"order":    order,
+			"type":     voucherType,
+			"amount":   voucher.Amount,
+			"basketId": basketID,
+		}
+
+		resultBytes, _ := json.MarshalIndent(result, "", "  ")
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: string(resultBytes),
+				},
+			},
+		}, nil
+	})
+}
+
+func (h *Handler) setupRedeemVoucher(s *server.MCPServer) {
+	s.AddTool(mcp.NewTool("spektrix_redeem_voucher",
+		mcp.WithDescription("Pay for this conversation's basket with a gift voucher, before spektrix_checkout. Redeems as much of the basket as the voucher covers unless amount is given."),
+		mcp.WithString("code", mcp.Required(), mcp.Description("Code printed on the voucher")),
+		mcp.WithNumber("amount", mcp.Description("Amount to redeem (default: the lesser of the balance and the basket total)")),
+		mcp.WithString("basketId", mcp.Description("Basket ID (default: this conversation's basket)")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]interface{})
+		if !ok {
+			return mcp.NewToolResultError("invalid arguments format"), nil
+		}
+
+		code := strings.TrimSpace(getString(args, "code"))
+		requested, _ := args["amount"].(float64)
+		if code == "" {
+			return mcp.NewToolResultError("code is required"), nil
+		}
+		basketID := h.basketID(ctx, args)
+		if basketID == "" {
+			return mcp.NewToolResultError("No basket yet; add tickets with spektrix_basket_add_tickets first"), nil
+		}
+
+		voucher, err := h.client.GetGiftVoucher(code)
+		if err != nil {
+			if strings.HasPrefix(err.Error(), "API error 404") {
+				return mcp.NewToolResultError(fmt.Sprintf("No gift voucher has the code %s", code)), nil
+			}
+			return health.ToolError(fmt.Sprintf("Failed to get voucher: %v", err), err), nil
+		}
+		if check := CheckVoucher(*voucher, time.Now()); !check.Usable {
+			return mcp.NewToolResultError(fmt.Sprintf("Voucher %s cannot be used: %s", code, check.Reason)), nil
+		}
+
+		basket, err := h.client.GetBasket(basketID)
+		if err != nil {
+			return health.ToolError(fmt.Sprintf("Failed to get basket %s: %v", basketID, err), err), nil
+		}
+		amount, err := RedemptionAmount(*voucher, basket, requested)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Cannot redeem voucher %s: %v", code, err)), nil
+		}
+
+		basket, err = h.client.RedeemGiftVoucher(basketID, VoucherRedemption{Code: code, Amount: amount})
+		if err != nil {
+			return health.ToolError(fmt.Sprintf("Failed to redeem voucher %s: %v", code, err), err), nil
+		}
+
+		next := "Complete the order with spektrix_checkout."
+		if basket.Total > 0 {
+			next = fmt.Sprintf("%.2f is left to pay; complete the order with spektrix_checkout.", basket.Total)
+		}
+		return basketResult(basket, next), nil
+	})
+}
+
+func (h *Handler) setupCustomerMemberships(s *server.MCPServer) {
+	s.AddTool(mcp.NewTool("spektrix_customer_memberships",
+		mcp.WithDescription("List a customer's memberships with their expiry: active, expiring within 30 days, or expired"),
+		mcp.WithString("customerId", mcp.Required(), mcp.Description("Customer ID")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]interface{})
+		if !ok {
+			return mcp.NewToolResultError("invalid arguments format"), nil
+		}
+
+		customerID := getString(args, "customerId")
+		if customerID == "" {
+			return mcp.NewToolResultError("customerId is required"), nil
+		}
+
+		memberships, err := h.client.GetCustomerMemberships(customerID)
+		if err != nil {
+			return health.ToolError(fmt.Sprintf("Failed to get memberships: %v", err), err), nil
+		}
+
+		statuses := MembershipStatuses(memberships, time.Now())
+		expiring := 0
+		for _, status := range statuses {
+			if status.Status == "expiring" {
+				expiring++
+			}
+		}
+
+		result := map[string]interface{}{
+			"customerId":  customerID,
+			"memberships": statuses,
+			"count":       len(statuses),
+			"expiring":    expiring,
+		}
+
+		resultBytes, _ := json.MarshalIndent(result, "", "  ")
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: string(resultBytes),
+				},
+			},
+		}, nil
+	})
+}
+
+func (h *Handler) setupMembershipRenewal(s *server.MCPServer) {
+	s.AddTool(mcp.NewTool("spektrix_membership_renewal",
+		mcp.WithDescription("Add a membership renewal to the basket, starting one if this conversation has none. Renews the customer's soonest-expiring membership unless one is named. Complete it with spektrix_checkout."),
+		mcp.WithString("customerId", mcp.Required(), mcp.Description("Customer ID")),
+		mcp.WithString("customerMembershipId", mcp.Description("Customer membership ID from spektrix_customer_memberships (default: the soonest to expire)")),
+		mcp.WithString("basketId", mcp.Description("Basket ID (default: this conversation's basket)")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]interface{})
+		if !ok {
+			return mcp.NewToolResultError("invalid arguments format"), nil
+		}
+
+		customerID := getString(args, "customerId")
+		if customerID == "" {
+			return mcp.NewToolResultError("customerId is required"), nil
+		}
+
+		memberships, err := h.client.GetCustomerMemberships(customerID)
+		if err != nil {
+			return health.ToolError(fmt.Sprintf("Failed to get memberships: %v", err), err), nil
+		}
+		membership, err := RenewalCandidate(MembershipStatuses(memberships, time.Now()), getString(args, "customerMembershipId"))
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		basketID := h.basketID(ctx, args)
+		if basketID == "" {
+			basket, err := h.client.CreateBasket()
+			if err != nil {
+				return health.ToolError(fmt.Sprintf("Failed to create basket: %v", err), err), nil
+			}
+			basketID = basket.ID
+			h.baskets.set(ctx, basketID)
+		}
+
+		basket, err := h.client.AddBasketMembershipRenewal(basketID, membership)
+		if err != nil {
+			return health.ToolError(fmt.Sprintf("Failed to add renewal to basket %s: %v", basketID, err), err), nil
+		}
+
+		return basketResult(basket, fmt.Sprintf("Complete the renewal of %s with spektrix_checkout for customer %s.", membership.Membership.Name, customerID)), nil
+	})
+}
+
+// basketID returns the basketId argument, or the basket this session is
+// assembling
+func (h *Handler) basketID(ctx context.Context, args map[string]interface{}) string {
+	if id := getString(args, "basketId"); id != "" {
+		return id
+	}
+	id, _ := h.baskets.get(ctx)
+	return id
+}
+
+// basketResult formats a basket with a hint at the next step
+func basketResult(basket *Basket, next string) *mcp.CallToolResult {
+	result := map[string]interface{}{
+		"basket": basket,
+	}
+	if next != "" {
+		result["next"] = next
+	}
+
+	resultBytes, _ := json.MarshalIndent(result, "", "  ")
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: string(resultBytes),
+			},
+		},
+	}
+}
+
+// Helper functions
+func getString(args map[string]interface{}, key string) string {
+	if val, ok := args[key].(string); ok {
+		return val
+	}
+	return ""
+}
+
+func splitAndTrim(s, sep string) []string {
+	if s == "" {
+		return []string{}
+	}
+
+	parts := make([]string, 0)
+	for _, part := range strings.Split(s, sep) {
+		if trimmed := strings.TrimSpace(part); trimmed != "" {
+			parts = append(parts, trimmed)
+		}
+	}
+	return parts
+}
+
+// AdapterStatus reports Spektrix auth and circuit state for adapter_status
+func (h *Handler) AdapterStatus() health.AdapterStatus {
+	status := health.AdapterStatus{
+		Name:          "spektrix",
+		Authenticated: h.IsAuthenticated(),
+		Caches:        []health.CacheState{},
+	}
+	if h.client != nil {
+		if breaker := h.client.UpstreamBreaker(); breaker != nil {
+			status.Circuit = breaker.Snapshot()
+		}
+	}
+	if h.fallback != nil {
+		status.Caches = append(status.Caches, h.fallback.CacheState("spektrix://tags", "spektrix://tags"))
+	}
+	return status
+}
+
+// UpstreamBreaker returns the breaker guarding Spektrix calls, used by simulate_outage
+func (h *Handler) UpstreamBreaker() *health.Breaker {
+	if h.client == nil {
+		return nil
+	}
+	return h.client.UpstreamBreaker()
+}
+
+// Tags returns all tags, falling back to the last-known-good copy
+// (with a non-nil Staleness) while Spektrix is unavailable
+func (h *Handler) Tags() ([]Tag, *health.Staleness, error) {
+	if h.fallback == nil {
+		tags, err := h.client.GetTags()
+		return tags, nil, err
+	}
+	return health.Fetch(h.fallback, "spektrix://tags", h.client.GetTags)
+}
+
+// Warmup validates the API credentials and fills the tags cache
+func (h *Handler) Warmup(ctx context.Context) error {
+	_, _, err := h.Tags()
+	return err
 }
@@ -18,6 +18,9 @@
 // - Ported from sandy project's proven working JavaScript version
 //
 // ⚠️  MODIFYING THIS FILE WILL BREAK ALL SPEKTRIX API CALLS ⚠️
+//
+// hmac_test.go pins this implementation to the RFC 2202 test vectors; run
+// it after any change.
 package spektrix
 
 // hmacSHA1 generates HMAC-SHA1 signature using custom implementation
@@ -72,8 +75,13 @@ func sha1Hash(input string) ([]byte, error) {
 	words := bytesToWords(message)
 	msgLen := len(input) * 8
 
-	// Pre-processing: padding
-	words = append(words, 0x80000000)
+	// Pre-processing: padding. The 0x80 marker byte goes straight after the
+	// message, inside its last word unless the length is a multiple of 4
+	if len(message)%4 == 0 {
+		words = append(words, 0x80000000)
+	} else {
+		words[len(message)/4] |= 0x80 << (24 - uint(len(message)%4)*8)
+	}
 	for len(words)%16 != 14 {
 		words = append(words, 0)
 	}
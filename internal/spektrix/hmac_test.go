@@ -0,0 +1,144 @@
+package spektrix
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSigning(t *testing.T) {
+	t.Logf("Importance: Every Spektrix call is signed; one wrong byte in the signature and the whole adapter gets 401s.")
+
+	t.Run("matches RFC 2202 HMAC-SHA1 vectors", func(t *testing.T) {
+		t.Logf("  > Why it's important: The custom implementation must be plain HMAC-SHA1, including long keys and multi-block data.")
+		vectors := []struct {
+			key, data, digest string
+		}{
+			{strings.Repeat("\x0b", 20), "Hi There", "b617318655057264e28bc0b6fb378c8ef146be00"},
+			{"Jefe", "what do ya want for nothing?", "effcdf6ae5eb2fa2d27416d5f184df9c259a7c79"},
+			{strings.Repeat("\xaa", 20), strings.Repeat("\xdd", 50), "125d7342b9ac11cd91a39af48aa17b4f63f175d3"},
+			{"\x01\x02\x03\x04\x05\x06\x07\x08\x09\x0a\x0b\x0c\x0d\x0e\x0f\x10\x11\x12\x13\x14\x15\x16\x17\x18\x19", strings.Repeat("\xcd", 50), "4c9007f4026250c6bc8414f9bf50c86c2d7235da"},
+			{strings.Repeat("\x0c", 20), "Test With Truncation", "4c1a03424b55e07fe7f27be1d58bb9324a9a5a04"},
+			{strings.Repeat("\xaa", 80), "Test Using Larger Than Block-Size Key - Hash Key First", "aa4ae5e15272d00e95705637ce8a3b55ed402112"},
+			{strings.Repeat("\xaa", 80), "Test Using Larger Than Block-Size Key and Larger Than One Block-Size Data", "e8e99d0f45237d786d6bbaa7965c7808bbff1a91"},
+		}
+		for i, v := range vectors {
+			got, err := hmacSHA1(v.data, v.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hex.EncodeToString(got) != v.digest {
+				t.Errorf("Vector %d: expected %s, got %x", i+1, v.digest, got)
+			}
+		}
+	})
+
+	t.Run("agrees with crypto/hmac at every length", func(t *testing.T) {
+		t.Logf("  > Why it's important: Padding bugs only show at some message lengths, and request lines vary in length.")
+		for _, keyLen := range []int{0, 1, 20, 64, 65, 100} {
+			key := string(bytes.Repeat([]byte{0x9c}, keyLen))
+			for n := 0; n <= 200; n++ {
+				data := strings.Repeat("x", n)
+				got, _ := hmacSHA1(data, key)
+				mac := hmac.New(sha1.New, []byte(key))
+				mac.Write([]byte(data))
+				if !bytes.Equal(got, mac.Sum(nil)) {
+					t.Fatalf("Key length %d, data length %d: signatures differ", keyLen, n)
+				}
+			}
+		}
+	})
+
+	t.Run("signs the canonical request string", func(t *testing.T) {
+		t.Logf("  > Why it's important: Spektrix rebuilds METHOD, URL, Date and body MD5 and compares; each part must match.")
+		key := base64.StdEncoding.EncodeToString([]byte("secret-key"))
+		url := "https://system.spektrix.com/venue/api/v3/customers"
+		date := "Tue, 04 Mar 2025 09:05:07 GMT"
+		body := `{"firstName":"Jane"}`
+
+		bodyHash := md5.Sum([]byte(body))
+		mac := hmac.New(sha1.New, []byte("secret-key"))
+		mac.Write([]byte("POST\n" + url + "\n" + date + "\n" + base64.StdEncoding.EncodeToString(bodyHash[:])))
+		want := "SpektrixAPI3 apiuser:" + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+		got, err := getAuthorizationHeader("post", url, date, body, "apiuser", key)
+		if err != nil || got != want {
+			t.Errorf("Expected %s, got %s (%v)", want, got, err)
+		}
+
+		mac = hmac.New(sha1.New, []byte("secret-key"))
+		mac.Write([]byte("GET\n" + url + "\n" + date))
+		want = "SpektrixAPI3 apiuser:" + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+		if got, _ := getAuthorizationHeader("GET", url, date, "", "apiuser", key); got != want {
+			t.Errorf("Expected a GET without body hash, got %s", got)
+		}
+
+		if _, err := getAuthorizationHeader("GET", url, date, "", "apiuser", "not base64!"); err == nil {
+			t.Error("Expected an invalid API key to be rejected")
+		}
+	})
+
+	t.Run("formats dates in GMT", func(t *testing.T) {
+		t.Logf("  > Why it's important: A local-time or zero-padded-differently date is a different string to sign.")
+		local := time.Date(2025, 3, 4, 10, 5, 7, 0, time.FixedZone("CET", 3600))
+		if got := formatSignatureDate(local); got != "Tue, 04 Mar 2025 09:05:07 GMT" {
+			t.Errorf("Unexpected date %q", got)
+		}
+	})
+
+	t.Run("retries once after clock drift", func(t *testing.T) {
+		t.Logf("  > Why it's important: A drifting server clock would otherwise fail every call until someone fixes NTP.")
+		serverTime := time.Date(2025, 3, 4, 9, 0, 0, 0, time.UTC)
+		var dates []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			dates = append(dates, r.Header.Get("Date"))
+			w.Header().Set("Date", formatSignatureDate(serverTime))
+			signed, _ := http.ParseTime(r.Header.Get("Date"))
+			if d := signed.Sub(serverTime); d > clockSkewTolerance || d < -clockSkewTolerance {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`[]`))
+		}))
+		defer server.Close()
+
+		client := &Client{APIUser: "user", APIKey: "a2V5", BaseURL: server.URL, HTTPClient: server.Client()}
+		client.clock = func() time.Time { return serverTime.Add(-10 * time.Minute) }
+
+		if _, err := client.GetTags(); err != nil {
+			t.Fatalf("Expected the retry to succeed, got %v", err)
+		}
+		if len(dates) != 2 || dates[1] != formatSignatureDate(serverTime) {
+			t.Errorf("Expected a retry signed with the server's time, got %v", dates)
+		}
+
+		dates = nil
+		if _, err := client.GetTags(); err != nil || len(dates) != 1 {
+			t.Errorf("Expected later calls to use the corrected clock, got %v and %v", dates, err)
+		}
+	})
+
+	t.Run("does not retry other 401s", func(t *testing.T) {
+		t.Logf("  > Why it's important: Bad credentials must fail once, not twice.")
+		calls := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.Header().Set("Date", r.Header.Get("Date"))
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer server.Close()
+
+		client := &Client{APIUser: "user", APIKey: "a2V5", BaseURL: server.URL, HTTPClient: server.Client()}
+		if _, err := client.GetTags(); err == nil || calls != 1 {
+			t.Errorf("Expected one failed call, got %d and %v", calls, err)
+		}
+	})
+}
@@ -0,0 +1,49 @@
+package spektrix
+
+import (
+	"github.com/vcto/mcp-adapters/internal/manifest"
+)
+
+// Manifest declares what each Spektrix tool reads and writes in the venue's system
+func Manifest() *manifest.Manifest {
+	return &manifest.Manifest{
+		Adapter: "spektrix",
+		Service: "Spektrix",
+		Account: "The Spektrix system configured by SPEKTRIX_CLIENT_NAME, using the server's API user",
+		Tools: map[string]manifest.ToolAccess{
+			"spektrix_search_customers":        {Reads: []string{"customers"}},
+			"spektrix_find_or_create_customer": {Reads: []string{"customers"}, Writes: []string{"customers"}, Idempotent: true},
+			"spektrix_create_customer":         {Writes: []string{"customers"}},
+			"spektrix_add_address":             {Writes: []string{"customer addresses"}},
+			"spektrix_update_tags":             {Writes: []string{"customer tags"}, Idempotent: true},
+			"spektrix_get_tags":                {Reads: []string{"tags"}},
+			"spektrix_add_customer_tags":       {Reads: []string{"tags"}, Writes: []string{"customer tags"}, Idempotent: true},
+			"spektrix_remove_customer_tags":    {Reads: []string{"tags"}, Writes: []string{"customer tags"}, Idempotent: true},
+			"spektrix_quote":                   {Reads: []string{"prices", "offers"}},
+			"spektrix_instance_availability":   {Reads: []string{"seat availability"}},
+			"spektrix_seating_plan_status":     {Reads: []string{"seat availability"}},
+			"spektrix_price_list":              {Reads: []string{"prices"}},
+			"spektrix_basket_create":           {Writes: []string{"baskets"}},
+			"spektrix_basket_add_tickets":      {Writes: []string{"baskets", "seat holds"}},
+			"spektrix_basket_apply_offer":      {Writes: []string{"baskets"}, Idempotent: true},
+			"spektrix_basket_view":             {Reads: []string{"baskets"}},
+			"spektrix_checkout":                {Reads: []string{"baskets"}, Writes: []string{"orders"}},
+			"spektrix_list_funds":              {Reads: []string{"funds"}},
+			"spektrix_record_donation":         {Reads: []string{"funds"}, Writes: []string{"baskets", "orders", "donations"}},
+			"spektrix_voucher_balance":         {Reads: []string{"gift vouchers"}},
+			"spektrix_issue_voucher":           {Reads: []string{"gift voucher types"}, Writes: []string{"baskets", "orders", "gift vouchers"}},
+			"spektrix_redeem_voucher":          {Reads: []string{"gift vouchers", "baskets"}, Writes: []string{"baskets"}},
+			"spektrix_customer_memberships":    {Reads: []string{"memberships"}},
+			"spektrix_membership_renewal":      {Reads: []string{"memberships"}, Writes: []string{"baskets"}},
+			"spektrix_find_duplicates":         {Reads: []string{"customers"}},
+			"spektrix_merge_customers":         {Reads: []string{"customers"}, Writes: []string{"customers"}},
+			"spektrix_list_addresses":          {Reads: []string{"customer addresses"}},
+			"spektrix_customer_orders":         {Reads: []string{"orders"}},
+			"spektrix_sales_report":            {Reads: []string{"orders", "events"}},
+			"spektrix_list_events":             {Reads: []string{"events"}},
+			"spektrix_update_address":          {Reads: []string{"customer addresses"}, Writes: []string{"customer addresses"}, Idempotent: true},
+			"adapter_status":                   {Group: manifest.GroupAdmin},
+			"simulate_outage":                  {Group: manifest.GroupAdmin},
+		},
+	}
+}
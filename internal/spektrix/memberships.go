@@ -0,0 +1,163 @@
+package spektrix
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// membershipRenewalWindow is how long before expiry a membership counts as
+// due for renewal
+const membershipRenewalWindow = 30 * 24 * time.Hour
+
+// spektrixDateLayouts are the date formats Spektrix returns, which carry no
+// time zone; they are read as the venue's local dates
+var spektrixDateLayouts = []string{"2006-01-02T15:04:05", "2006-01-02T15:04:05Z07:00", "2006-01-02"}
+
+// MembershipRef identifies a membership scheme, such as "Friends"
+type MembershipRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+// CustomerMembership is a customer's membership of a scheme
+type CustomerMembership struct {
+	ID         string        `json:"id"`
+	Membership MembershipRef `json:"membership"`
+	StartDate  string        `json:"startDate"`
+	ExpiryDate string        `json:"expiryDate"`
+	AutoRenew  bool          `json:"autoRenew"`
+}
+
+// MembershipStatus is a customer membership with its standing on a given day
+type MembershipStatus struct {
+	CustomerMembership
+	// Status is "active", "expiring" within the renewal window, or "expired"
+	Status string `json:"status"`
+	// DaysLeft is negative once the membership has expired
+	DaysLeft int `json:"daysLeft"`
+}
+
+// BasketMembership is a membership purchase or renewal held in a basket
+type BasketMembership struct {
+	ID         string        `json:"id"`
+	Membership MembershipRef `json:"membership"`
+	// RenewalOf is the customer membership being renewed, if any
+	RenewalOf *TagReference `json:"renewalOf,omitempty"`
+	Price     float64       `json:"price"`
+}
+
+// MembershipRenewalRequest is the payload shape for renewing a customer
+// membership in a basket
+type MembershipRenewalRequest struct {
+	Membership TagReference `json:"membership"`
+	RenewalOf  TagReference `json:"renewalOf"`
+}
+
+// GetCustomerMemberships retrieves a customer's memberships, current and past
+func (c *Client) GetCustomerMemberships(customerID string) ([]CustomerMembership, error) {
+	endpoint := fmt.Sprintf("/customers/%s/memberships", customerID)
+
+	resp, err := c.makeRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var memberships []CustomerMembership
+	if err := c.handleResponse(resp, &memberships); err != nil {
+		return nil, err
+	}
+
+	return memberships, nil
+}
+
+// AddBasketMembershipRenewal adds the renewal of a customer membership to a
+// basket and returns the updated basket
+func (c *Client) AddBasketMembershipRenewal(basketID string, membership CustomerMembership) (*Basket, error) {
+	endpoint := fmt.Sprintf("/baskets/%s/memberships", basketID)
+	payload := []MembershipRenewalRequest{{
+		Membership: TagReference{ID: membership.Membership.ID},
+		RenewalOf:  TagReference{ID: membership.ID},
+	}}
+
+	resp, err := c.makeRequest("POST", endpoint, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var basket Basket
+	if err := c.handleResponse(resp, &basket); err != nil {
+		return nil, err
+	}
+
+	return &basket, nil
+}
+
+// MembershipStatuses works out where each membership stands on now's date,
+// soonest expiry first. Memberships with an unreadable expiry date are left
+// out.
+func MembershipStatuses(memberships []CustomerMembership, now time.Time) []MembershipStatus {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	statuses := make([]MembershipStatus, 0, len(memberships))
+	for _, membership := range memberships {
+		expiry, ok := parseSpektrixDate(membership.ExpiryDate)
+		if !ok {
+			continue
+		}
+		daysLeft := int(math.Round(expiry.Sub(today).Hours() / 24))
+		status := "active"
+		switch {
+		case daysLeft < 0:
+			status = "expired"
+		case time.Duration(daysLeft)*24*time.Hour <= membershipRenewalWindow:
+			status = "expiring"
+		}
+		statuses = append(statuses, MembershipStatus{CustomerMembership: membership, Status: status, DaysLeft: daysLeft})
+	}
+	sort.SliceStable(statuses, func(i, j int) bool {
+		return statuses[i].DaysLeft < statuses[j].DaysLeft
+	})
+	return statuses
+}
+
+// RenewalCandidate picks the membership to renew: the one with the given ID,
+// or when id is empty the one expiring soonest, looking only at the latest
+// membership of each scheme
+func RenewalCandidate(statuses []MembershipStatus, id string) (CustomerMembership, error) {
+	if id != "" {
+		for _, status := range statuses {
+			if status.ID == id {
+				return status.CustomerMembership, nil
+			}
+		}
+		return CustomerMembership{}, fmt.Errorf("customer has no membership %s", id)
+	}
+
+	// A scheme renewed early has a newer membership; only its latest counts
+	latest := make(map[string]MembershipStatus)
+	for _, status := range statuses {
+		if current, ok := latest[status.Membership.ID]; !ok || status.DaysLeft > current.DaysLeft {
+			latest[status.Membership.ID] = status
+		}
+	}
+	var candidate *MembershipStatus
+	for _, status := range latest {
+		if candidate == nil || status.DaysLeft < candidate.DaysLeft {
+			candidate = &status
+		}
+	}
+	if candidate == nil {
+		return CustomerMembership{}, fmt.Errorf("customer has no memberships to renew")
+	}
+	return candidate.CustomerMembership, nil
+}
+
+func parseSpektrixDate(value string) (time.Time, bool) {
+	for _, layout := range spektrixDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), true
+		}
+	}
+	return time.Time{}, false
+}
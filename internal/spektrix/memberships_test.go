@@ -0,0 +1,88 @@
+package spektrix
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMemberships(t *testing.T) {
+	t.Logf("Importance: The membership desk renews on the expiry shown; a wrong date or the wrong membership renewed costs a member's benefits.")
+
+	now := time.Date(2025, 3, 10, 15, 0, 0, 0, time.UTC)
+	memberships := []CustomerMembership{
+		{ID: "cm1", Membership: MembershipRef{ID: "friends", Name: "Friends"}, ExpiryDate: "2024-03-31T00:00:00"},
+		{ID: "cm2", Membership: MembershipRef{ID: "friends", Name: "Friends"}, ExpiryDate: "2025-03-31T00:00:00"},
+		{ID: "cm3", Membership: MembershipRef{ID: "patrons", Name: "Patrons"}, ExpiryDate: "2025-12-31"},
+		{ID: "cm4", Membership: MembershipRef{ID: "patrons", Name: "Patrons"}, ExpiryDate: "someday"},
+	}
+
+	t.Run("works out expiry status", func(t *testing.T) {
+		t.Logf("  > Why it's important: Members within 30 days of expiry are the ones to offer renewal to.")
+		statuses := MembershipStatuses(memberships, now)
+		if len(statuses) != 3 {
+			t.Fatalf("Expected the unreadable date left out, got %+v", statuses)
+		}
+		want := []struct {
+			id, status string
+			daysLeft   int
+		}{{"cm1", "expired", -344}, {"cm2", "expiring", 21}, {"cm3", "active", 296}}
+		for i, w := range want {
+			if got := statuses[i]; got.ID != w.id || got.Status != w.status || got.DaysLeft != w.daysLeft {
+				t.Errorf("Expected %s %s with %d days left, got %+v", w.id, w.status, w.daysLeft, got)
+			}
+		}
+	})
+
+	t.Run("picks the membership to renew", func(t *testing.T) {
+		t.Logf("  > Why it's important: A lapsed membership already renewed must not be renewed again.")
+		statuses := MembershipStatuses(memberships, now)
+		if got, err := RenewalCandidate(statuses, ""); err != nil || got.ID != "cm2" {
+			t.Errorf("Expected cm2, got %+v, %v", got, err)
+		}
+		if got, err := RenewalCandidate(statuses, "cm3"); err != nil || got.ID != "cm3" {
+			t.Errorf("Expected the named membership, got %+v, %v", got, err)
+		}
+		if _, err := RenewalCandidate(statuses, "cm9"); err == nil {
+			t.Error("Expected an unknown membership to be rejected")
+		}
+		if _, err := RenewalCandidate(nil, ""); err == nil {
+			t.Error("Expected an error for a customer without memberships")
+		}
+	})
+
+	t.Run("adds renewals to a basket", func(t *testing.T) {
+		t.Logf("  > Why it's important: The renewal must name the membership it extends, or Spektrix sells a new one.")
+		var sent []MembershipRenewalRequest
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/customers/c1/memberships":
+				_ = json.NewEncoder(w).Encode(memberships[:2])
+			case "/baskets/b1/memberships":
+				_ = json.NewDecoder(r.Body).Decode(&sent)
+				_, _ = w.Write([]byte(`{"id":"b1","tickets":[],"memberships":[{"id":"bm1","membership":{"id":"friends"},"renewalOf":{"id":"cm2"},"price":40}],"total":40}`))
+			default:
+				t.Errorf("Unexpected %s %s", r.Method, r.URL.Path)
+			}
+		}))
+		defer server.Close()
+
+		client := &Client{APIUser: "user", APIKey: "a2V5", BaseURL: server.URL, HTTPClient: server.Client()}
+		got, err := client.GetCustomerMemberships("c1")
+		if err != nil || len(got) != 2 {
+			t.Fatalf("Expected two memberships, got %+v, %v", got, err)
+		}
+		basket, err := client.AddBasketMembershipRenewal("b1", got[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sent) != 1 || sent[0].Membership.ID != "friends" || sent[0].RenewalOf.ID != "cm2" {
+			t.Errorf("Unexpected request %+v", sent)
+		}
+		if len(basket.Memberships) != 1 || basket.Total != 40 {
+			t.Errorf("Unexpected basket %+v", basket)
+		}
+	})
+}
@@ -0,0 +1,183 @@
+package spektrix
+
+import (
+	"fmt"
+	"sort"
+)
+
+// CustomerOrdersURITemplate is the resource template for a customer's
+// recent orders
+const CustomerOrdersURITemplate = "spektrix://customers/{customer_id}/orders"
+
+// OrderSummary is an order with its tickets grouped by instance
+type OrderSummary struct {
+	ID        string      `json:"id"`
+	Date      string      `json:"date,omitempty"`
+	Status    string      `json:"status,omitempty"`
+	Total     float64     `json:"total"`
+	Tickets   int         `json:"tickets"`
+	Lines     []OrderLine `json:"lines"`
+	Donations []Donation  `json:"donations,omitempty"`
+}
+
+// OrderLine is the tickets an order holds for one instance
+type OrderLine struct {
+	InstanceID string        `json:"instanceId"`
+	Event      string        `json:"event,omitempty"`
+	Start      string        `json:"start,omitempty"`
+	Tickets    []OrderTicket `json:"tickets"`
+	Subtotal   float64       `json:"subtotal"`
+	// Error explains a line whose instance could not be looked up
+	Error string `json:"error,omitempty"`
+}
+
+// OrderTicket is one ticket in an order line
+type OrderTicket struct {
+	TicketType string  `json:"ticketType,omitempty"`
+	PriceBand  string  `json:"priceBand,omitempty"`
+	Seat       string  `json:"seat,omitempty"`
+	Price      float64 `json:"price"`
+	Discount   float64 `json:"discount,omitempty"`
+}
+
+// GetCustomerOrders retrieves a customer's orders
+func (c *Client) GetCustomerOrders(customerID string) ([]Order, error) {
+	endpoint := fmt.Sprintf("/customers/%s/orders", customerID)
+
+	resp, err := c.makeRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var orders []Order
+	if err := c.handleResponse(resp, &orders); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// instanceLookup finds the start and event of instances, fetching each
+// instance and event at most once
+type instanceLookup struct {
+	client    SpektrixClientInterface
+	instances map[string]instanceResult
+	events    map[string]string
+}
+
+type instanceResult struct {
+	start string
+	event EventRef
+	err   error
+}
+
+func newInstanceLookup(client SpektrixClientInterface) *instanceLookup {
+	return &instanceLookup{
+		client:    client,
+		instances: map[string]instanceResult{},
+		events:    map[string]string{},
+	}
+}
+
+// describe returns an instance's start and event. The event's name is
+// empty if it could not be read.
+func (l *instanceLookup) describe(instanceID string) (string, EventRef, error) {
+	if result, ok := l.instances[instanceID]; ok {
+		return result.start, result.event, result.err
+	}
+
+	var result instanceResult
+	instance, err := l.client.GetInstance(instanceID)
+	if err != nil {
+		result.err = err
+	} else {
+		result.start = instance.Start
+		if instance.Event != nil {
+			result.event = *instance.Event
+		}
+	}
+	if result.event.ID != "" && result.event.Name == "" {
+		if _, ok := l.events[result.event.ID]; !ok {
+			if event, err := l.client.GetEvent(result.event.ID); err == nil {
+				l.events[result.event.ID] = event.Name
+			}
+		}
+		result.event.Name = l.events[result.event.ID]
+	}
+	l.instances[instanceID] = result
+	return result.start, result.event, result.err
+}
+
+// SortOrdersNewestFirst sorts orders by date, newest first; undated orders
+// go last
+func SortOrdersNewestFirst(orders []Order) {
+	sort.SliceStable(orders, func(i, j int) bool {
+		if orders[i].Date == "" || orders[j].Date == "" {
+			return orders[j].Date == "" && orders[i].Date != ""
+		}
+		return orders[i].Date > orders[j].Date
+	})
+}
+
+// summarizeOrder groups an order's tickets by instance, in the order
+// instances first appear, naming each instance's event
+func summarizeOrder(order Order, lookup *instanceLookup) OrderSummary {
+	summary := OrderSummary{
+		ID:        order.ID,
+		Date:      order.Date,
+		Status:    order.Status,
+		Total:     order.Total,
+		Tickets:   len(order.Tickets),
+		Lines:     []OrderLine{},
+		Donations: order.Donations,
+	}
+	index := map[string]int{}
+	for _, ticket := range order.Tickets {
+		i, ok := index[ticket.Instance.ID]
+		if !ok {
+			i = len(summary.Lines)
+			index[ticket.Instance.ID] = i
+			line := OrderLine{InstanceID: ticket.Instance.ID}
+			start, event, err := lookup.describe(ticket.Instance.ID)
+			if err != nil {
+				line.Error = err.Error()
+			}
+			line.Start, line.Event = start, event.Name
+			summary.Lines = append(summary.Lines, line)
+		}
+		line := &summary.Lines[i]
+		line.Tickets = append(line.Tickets, OrderTicket{
+			TicketType: ticket.TicketType.Name,
+			PriceBand:  ticket.PriceBand.Name,
+			Seat:       ticket.Seat,
+			Price:      ticket.Price,
+			Discount:   ticket.Discount,
+		})
+		line.Subtotal = roundCurrency(line.Subtotal + ticket.Price)
+	}
+	return summary
+}
+
+// CustomerOrders returns a page of a customer's orders, newest first, with
+// their tickets grouped by instance and event
+func (h *Handler) CustomerOrders(customerID string, req pageRequest) ([]OrderSummary, PageInfo, error) {
+	orders, err := h.client.GetCustomerOrders(customerID)
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+	SortOrdersNewestFirst(orders)
+
+	page, info := paginate(orders, req)
+	lookup := newInstanceLookup(h.client)
+	summaries := make([]OrderSummary, 0, len(page))
+	for _, order := range page {
+		summaries = append(summaries, summarizeOrder(order, lookup))
+	}
+	return summaries, info, nil
+}
+
+// RecentOrders returns the first page of a customer's orders, as the
+// customer orders resource shows them
+func (h *Handler) RecentOrders(customerID string) ([]OrderSummary, PageInfo, error) {
+	return h.CustomerOrders(customerID, pageRequest{size: defaultPageSize})
+}
@@ -0,0 +1,73 @@
+package spektrix
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCustomerOrders(t *testing.T) {
+	t.Logf("Importance: \"What did I book?\" is answered from order history; tickets must appear under the right show and date.")
+
+	t.Run("sorts newest first", func(t *testing.T) {
+		t.Logf("  > Why it's important: The first page is the recent orders a customer asks about.")
+		orders := []Order{{ID: "old", Date: "2024-01-05T10:00:00"}, {ID: "undated"}, {ID: "new", Date: "2025-03-01T09:00:00"}}
+		SortOrdersNewestFirst(orders)
+		if orders[0].ID != "new" || orders[1].ID != "old" || orders[2].ID != "undated" {
+			t.Errorf("Expected new, old, undated, got %+v", orders)
+		}
+	})
+
+	t.Run("groups tickets by instance", func(t *testing.T) {
+		t.Logf("  > Why it's important: Each instance is looked up once however many orders and tickets mention it, and a failed lookup keeps the tickets.")
+		lookups := map[string]int{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lookups[r.URL.Path]++
+			switch r.URL.Path {
+			case "/customers/c1/orders":
+				_, _ = w.Write([]byte(`[
+					{"id":"o1","date":"2025-05-01T12:00:00","total":40,"tickets":[
+						{"instance":{"id":"i1"},"ticketType":{"id":"t1","name":"Adult"},"band":{"id":"b1","name":"Stalls"},"seat":"A1","price":20},
+						{"instance":{"id":"i1"},"ticketType":{"id":"t2","name":"Child"},"band":{"id":"b1","name":"Stalls"},"seat":"A2","price":10,"discount":2},
+						{"instance":{"id":"i9"},"ticketType":{"id":"t1","name":"Adult"},"price":10}]},
+					{"id":"o2","date":"2025-06-01T12:00:00","total":20,"tickets":[{"instance":{"id":"i1"},"price":20}]}]`))
+			case "/instances/i1":
+				_, _ = w.Write([]byte(`{"id":"i1","start":"2025-07-02T19:30:00","event":{"id":"e1"}}`))
+			case "/instances/i9":
+				w.WriteHeader(http.StatusNotFound)
+			case "/events/e1":
+				_, _ = w.Write([]byte(`{"id":"e1","name":"Hamlet"}`))
+			default:
+				t.Errorf("Unexpected %s %s", r.Method, r.URL.Path)
+			}
+		}))
+		defer server.Close()
+
+		h := &Handler{client: &Client{APIUser: "user", APIKey: "a2V5", BaseURL: server.URL, HTTPClient: server.Client()}}
+		orders, info, err := h.RecentOrders("c1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(orders) != 2 || info.Total != 2 || orders[0].ID != "o2" {
+			t.Fatalf("Expected o2 then o1, got %+v", orders)
+		}
+		o1 := orders[1]
+		if o1.Tickets != 3 || len(o1.Lines) != 2 {
+			t.Fatalf("Expected 3 tickets on 2 lines, got %+v", o1)
+		}
+		hamlet := o1.Lines[0]
+		if hamlet.Event != "Hamlet" || hamlet.Start != "2025-07-02T19:30:00" || len(hamlet.Tickets) != 2 || hamlet.Subtotal != 30 {
+			t.Errorf("Expected two Hamlet tickets totalling 30, got %+v", hamlet)
+		}
+		if ticket := hamlet.Tickets[1]; ticket.TicketType != "Child" || ticket.PriceBand != "Stalls" || ticket.Seat != "A2" || ticket.Discount != 2 {
+			t.Errorf("Unexpected ticket %+v", ticket)
+		}
+		if lost := o1.Lines[1]; len(lost.Tickets) != 1 || !strings.Contains(lost.Error, "404") {
+			t.Errorf("Expected i9 kept with its error, got %+v", lost)
+		}
+		if lookups["/instances/i1"] != 1 || lookups["/events/e1"] != 1 {
+			t.Errorf("Expected one lookup each, got %v", lookups)
+		}
+	})
+}
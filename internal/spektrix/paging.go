@@ -0,0 +1,116 @@
+package spektrix
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	defaultPageSize = 25
+	maxPageSize     = 100
+	// maxPageBytes keeps a page of results well under the message sizes MCP
+	// clients handle comfortably; a page that would be larger ends early
+	maxPageBytes = 100 << 10
+)
+
+// PageInfo describes one page of a list tool's results
+type PageInfo struct {
+	Page       int  `json:"page"`
+	PageSize   int  `json:"pageSize"`
+	Total      int  `json:"total"`
+	TotalPages int  `json:"totalPages"`
+	HasMore    bool `json:"hasMore"`
+	// NextCursor fetches the following page; it is empty on the last page
+	NextCursor string `json:"nextCursor,omitempty"`
+	// Truncated reports a page cut short to stay under the size limit
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// pageRequest is where a page starts and how many items it holds
+type pageRequest struct {
+	offset, size int
+}
+
+// pagingOptions are the arguments every paged list tool takes
+func pagingOptions() []mcp.ToolOption {
+	return []mcp.ToolOption{
+		mcp.WithNumber("page", mcp.Description("Page number, starting at 1 (default: 1)")),
+		mcp.WithNumber("pageSize", mcp.Description(fmt.Sprintf("Results per page (default: %d, max: %d)", defaultPageSize, maxPageSize))),
+		mcp.WithString("cursor", mcp.Description("nextCursor from the previous page; overrides page and pageSize")),
+	}
+}
+
+// pageArgs reads the cursor, page and pageSize arguments. A cursor from an
+// earlier page wins over page numbers.
+func pageArgs(args map[string]interface{}) (pageRequest, error) {
+	if cursor := getString(args, "cursor"); cursor != "" {
+		return decodeCursor(cursor)
+	}
+
+	req := pageRequest{size: defaultPageSize}
+	if size, ok := args["pageSize"].(float64); ok && size > 0 {
+		req.size = min(int(size), maxPageSize)
+	}
+	if page, ok := args["page"].(float64); ok && page > 1 {
+		req.offset = (int(page) - 1) * req.size
+	}
+	return req, nil
+}
+
+// encodeCursor makes the opaque cursor for a page
+func encodeCursor(req pageRequest) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%d", req.offset, req.size)))
+}
+
+func decodeCursor(cursor string) (pageRequest, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return pageRequest{}, fmt.Errorf("invalid cursor")
+	}
+	offsetPart, sizePart, ok := strings.Cut(string(raw), ":")
+	offset, offsetErr := strconv.Atoi(offsetPart)
+	size, sizeErr := strconv.Atoi(sizePart)
+	if !ok || offsetErr != nil || sizeErr != nil || offset < 0 || size < 1 || size > maxPageSize {
+		return pageRequest{}, fmt.Errorf("invalid cursor")
+	}
+	return pageRequest{offset: offset, size: size}, nil
+}
+
+// paginate returns the requested page of items. The page ends early, though
+// never before its first item, if its JSON would pass maxPageBytes; the next
+// cursor then starts at the first item left out.
+func paginate[T any](items []T, req pageRequest) ([]T, PageInfo) {
+	total := len(items)
+	info := PageInfo{
+		Page:       req.offset/req.size + 1,
+		PageSize:   req.size,
+		Total:      total,
+		TotalPages: (total + req.size - 1) / req.size,
+	}
+	if req.offset >= total {
+		return []T{}, info
+	}
+
+	end := min(req.offset+req.size, total)
+	bytes := 0
+	for i := req.offset; i < end; i++ {
+		data, _ := json.Marshal(items[i])
+		bytes += len(data) + 1
+		if bytes > maxPageBytes && i > req.offset {
+			end = i
+			info.Truncated = true
+			break
+		}
+	}
+
+	if end < total {
+		info.HasMore = true
+		info.NextCursor = encodeCursor(pageRequest{offset: end, size: req.size})
+	}
+	return items[req.offset:end], info
+}
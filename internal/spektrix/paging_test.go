@@ -0,0 +1,132 @@
+package spektrix
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestPagination(t *testing.T) {
+	t.Logf("Importance: Event and customer lists run to thousands of records; one response with all of them overwhelms the client.")
+
+	numbers := make([]int, 60)
+	for i := range numbers {
+		numbers[i] = i
+	}
+
+	t.Run("pages by number", func(t *testing.T) {
+		t.Logf("  > Why it's important: page and pageSize must pick the same items every time, with totals to show how far there is to go.")
+		req, err := pageArgs(map[string]interface{}{"page": float64(3), "pageSize": float64(25)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		page, info := paginate(numbers, req)
+		if len(page) != 10 || page[0] != 50 || info.Page != 3 || info.TotalPages != 3 || info.HasMore || info.NextCursor != "" {
+			t.Errorf("Expected the last 10 numbers on page 3 of 3, got %v and %+v", page, info)
+		}
+
+		req, _ = pageArgs(map[string]interface{}{"pageSize": float64(1000)})
+		if req.size != maxPageSize {
+			t.Errorf("Expected the page size capped at %d, got %d", maxPageSize, req.size)
+		}
+		if page, info := paginate(numbers, pageRequest{offset: 100, size: 25}); len(page) != 0 || info.Total != 60 {
+			t.Errorf("Expected an empty page past the end, got %v and %+v", page, info)
+		}
+	})
+
+	t.Run("follows cursors to the end", func(t *testing.T) {
+		t.Logf("  > Why it's important: Walking nextCursor must visit every item once.")
+		var seen []int
+		args := map[string]interface{}{"pageSize": float64(25)}
+		for pages := 0; pages < 10; pages++ {
+			req, err := pageArgs(args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			page, info := paginate(numbers, req)
+			seen = append(seen, page...)
+			if !info.HasMore {
+				break
+			}
+			args = map[string]interface{}{"cursor": info.NextCursor, "page": float64(1)}
+		}
+		if len(seen) != 60 || seen[59] != 59 {
+			t.Errorf("Expected all 60 numbers in order, got %v", seen)
+		}
+
+		for _, cursor := range []string{"not a cursor!", encodeCursor(pageRequest{offset: 0, size: 500})} {
+			if _, err := pageArgs(map[string]interface{}{"cursor": cursor}); err == nil {
+				t.Errorf("Expected cursor %q to be rejected", cursor)
+			}
+		}
+	})
+
+	t.Run("ends a page early at the size limit", func(t *testing.T) {
+		t.Logf("  > Why it's important: A page of large records must still fit, and the next page must pick up where it stopped.")
+		big := make([]string, 5)
+		for i := range big {
+			big[i] = strings.Repeat("x", maxPageBytes/3)
+		}
+		page, info := paginate(big, pageRequest{size: 5})
+		if len(page) != 2 || !info.Truncated || !info.HasMore {
+			t.Fatalf("Expected 2 items and a truncated page, got %d and %+v", len(page), info)
+		}
+		next, _ := decodeCursor(info.NextCursor)
+		if next.offset != 2 {
+			t.Errorf("Expected the next page to start at 2, got %d", next.offset)
+		}
+
+		huge := []string{strings.Repeat("x", maxPageBytes*2)}
+		if page, _ := paginate(huge, pageRequest{size: 5}); len(page) != 1 {
+			t.Error("Expected an oversized item to still make a page of its own")
+		}
+	})
+
+	t.Run("pages list tools", func(t *testing.T) {
+		t.Logf("  > Why it's important: The events and orders tools fetch once and return only the page asked for.")
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/events":
+				events := make([]Event, 30)
+				for i := range events {
+					events[i] = Event{ID: fmt.Sprintf("e%d", i), Name: fmt.Sprintf("Show %d", i), IsOnSale: i%2 == 0}
+				}
+				_ = json.NewEncoder(w).Encode(events)
+			case "/customers/c1/orders":
+				_, _ = w.Write([]byte(`[{"id":"o1","tickets":[],"total":10},{"id":"o2","tickets":[],"total":20}]`))
+			default:
+				t.Errorf("Unexpected %s %s", r.Method, r.URL.Path)
+			}
+		}))
+		defer server.Close()
+
+		h := &Handler{
+			client:   &Client{APIUser: "user", APIKey: "a2V5", BaseURL: server.URL, HTTPClient: server.Client()},
+			baskets:  newSessionValues[string](),
+			searches: newSessionValues[CustomerSearch](),
+		}
+
+		var events struct {
+			Events     []Event  `json:"events"`
+			Pagination PageInfo `json:"pagination"`
+		}
+		result := callTool(t, h, "spektrix_list_events", map[string]interface{}{"onSaleOnly": true, "pageSize": float64(10)})
+		if err := json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &events); err != nil {
+			t.Fatal(err)
+		}
+		if len(events.Events) != 10 || events.Events[1].ID != "e2" || events.Pagination.Total != 15 || !events.Pagination.HasMore {
+			t.Errorf("Expected the first 10 of 15 events on sale, got %+v", events)
+		}
+
+		result = callTool(t, h, "spektrix_customer_orders", map[string]interface{}{"customerId": "c1", "page": float64(2), "pageSize": float64(1)})
+		text := result.Content[0].(mcp.TextContent).Text
+		if result.IsError || !strings.Contains(text, `"o2"`) || strings.Contains(text, `"o1"`) {
+			t.Errorf("Expected only o2 on page 2, got %s", text)
+		}
+	})
+}
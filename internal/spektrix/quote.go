@@ -0,0 +1,237 @@
+package spektrix
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// BuildQuote prices the requested lines against a price list and set of offers.
+// When offerID is set only that offer is considered; otherwise the offer giving
+// the largest discount is applied. Fees are added after discounts.
+//
+// Spektrix has no endpoint that prices tickets on their own: it only works
+// out discounts and fees for tickets held in a basket, which takes seats
+// out of sale. Quotes are therefore estimated here from the price list and
+// offers the API returns, and the basket total stays the authoritative price.
+func BuildQuote(instanceID string, priceList *PriceList, offers []Offer, lines []QuoteLineRequest, offerID string) (*Quote, error) {
+	if priceList == nil {
+		return nil, fmt.Errorf("price list is required")
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("at least one ticket line is required")
+	}
+
+	quote := &Quote{
+		InstanceID: instanceID,
+		Lines:      make([]QuoteLine, 0, len(lines)),
+		Fees:       []QuoteFee{},
+		Tickets:    []BasketTicketRequest{},
+	}
+
+	totalTickets := 0
+	for _, req := range lines {
+		if req.Quantity <= 0 {
+			return nil, fmt.Errorf("quantity for ticket type %s must be positive", req.TicketTypeID)
+		}
+
+		price := findPrice(priceList, req.TicketTypeID, req.PriceBandID)
+		if price == nil {
+			if req.PriceBandID != "" {
+				return nil, fmt.Errorf("no price for ticket type %s in price band %s", req.TicketTypeID, req.PriceBandID)
+			}
+			return nil, fmt.Errorf("no price for ticket type %s", req.TicketTypeID)
+		}
+
+		lineTotal := roundCurrency(price.Amount * float64(req.Quantity))
+		quote.Lines = append(quote.Lines, QuoteLine{
+			TicketType: price.TicketType,
+			PriceBand:  price.PriceBand,
+			Quantity:   req.Quantity,
+			UnitPrice:  price.Amount,
+			LineTotal:  lineTotal,
+		})
+		quote.Subtotal += lineTotal
+		totalTickets += req.Quantity
+	}
+	quote.Subtotal = roundCurrency(quote.Subtotal)
+
+	// Pick the offer to apply
+	var candidates []Offer
+	if offerID != "" {
+		for _, offer := range offers {
+			if offer.ID == offerID {
+				candidates = append(candidates, offer)
+				break
+			}
+		}
+		if len(candidates) == 0 {
+			return nil, fmt.Errorf("offer %s is not available for instance %s", offerID, instanceID)
+		}
+	} else {
+		candidates = offers
+	}
+
+	var bestOffer *Offer
+	var bestDiscounts []float64
+	bestTotal := 0.0
+	for i := range candidates {
+		offer := candidates[i]
+		if !offer.IsActive || totalTickets < offer.MinimumTickets {
+			continue
+		}
+		discounts, total := offerDiscounts(offer, quote.Lines)
+		if total > bestTotal {
+			bestOffer = &offer
+			bestDiscounts = discounts
+			bestTotal = total
+		}
+	}
+
+	if offerID != "" && bestOffer == nil {
+		return nil, fmt.Errorf("offer %s does not apply to the requested tickets", offerID)
+	}
+
+	if bestOffer != nil {
+		quote.AppliedOffer = bestOffer
+		quote.Discount = roundCurrency(bestTotal)
+		for i := range quote.Lines {
+			quote.Lines[i].Discount = bestDiscounts[i]
+			quote.Lines[i].LineTotal = roundCurrency(quote.Lines[i].LineTotal - bestDiscounts[i])
+		}
+	}
+
+	// Fees are charged per ticket or once per order
+	for _, fee := range priceList.Fees {
+		amount := fee.Amount
+		if fee.PerTicket {
+			amount = fee.Amount * float64(totalTickets)
+		}
+		amount = roundCurrency(amount)
+		quote.Fees = append(quote.Fees, QuoteFee{Fee: fee, Amount: amount})
+		quote.FeesTotal += amount
+	}
+	quote.FeesTotal = roundCurrency(quote.FeesTotal)
+	quote.Total = roundCurrency(quote.Subtotal - quote.Discount + quote.FeesTotal)
+
+	// Expand lines into one basket ticket per seat
+	for _, line := range quote.Lines {
+		for i := 0; i < line.Quantity; i++ {
+			ticket := BasketTicketRequest{
+				Instance:   instanceID,
+				TicketType: line.TicketType.ID,
+				Band:       line.PriceBand.ID,
+			}
+			if quote.AppliedOffer != nil && line.Discount > 0 {
+				ticket.Offer = quote.AppliedOffer.ID
+			}
+			quote.Tickets = append(quote.Tickets, ticket)
+		}
+	}
+
+	return quote, nil
+}
+
+// ParseQuoteLines parses "ticketTypeId:quantity" entries separated by commas.
+// A price band may be given as "ticketTypeId@priceBandId:quantity".
+func ParseQuoteLines(s string) ([]QuoteLineRequest, error) {
+	entries := splitAndTrim(s, ",")
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no tickets specified")
+	}
+
+	lines := make([]QuoteLineRequest, 0, len(entries))
+	for _, entry := range entries {
+		idPart, qtyPart, found := strings.Cut(entry, ":")
+		if !found {
+			return nil, fmt.Errorf("invalid ticket entry %q (expected ticketTypeId:quantity)", entry)
+		}
+
+		quantity, err := strconv.Atoi(strings.TrimSpace(qtyPart))
+		if err != nil || quantity <= 0 {
+			return nil, fmt.Errorf("invalid quantity in %q", entry)
+		}
+
+		ticketTypeID, priceBandID, _ := strings.Cut(strings.TrimSpace(idPart), "@")
+		if ticketTypeID == "" {
+			return nil, fmt.Errorf("missing ticket type in %q", entry)
+		}
+
+		lines = append(lines, QuoteLineRequest{
+			TicketTypeID: ticketTypeID,
+			PriceBandID:  priceBandID,
+			Quantity:     quantity,
+		})
+	}
+
+	return lines, nil
+}
+
+// findPrice locates the price for a ticket type, preferring the requested band
+// and falling back to the base band
+func findPrice(priceList *PriceList, ticketTypeID, priceBandID string) *Price {
+	var fallback *Price
+	for i := range priceList.Prices {
+		price := &priceList.Prices[i]
+		if price.TicketType.ID != ticketTypeID {
+			continue
+		}
+		if priceBandID != "" {
+			if price.PriceBand.ID == priceBandID {
+				return price
+			}
+			continue
+		}
+		if price.IsBase {
+			return price
+		}
+		if fallback == nil {
+			fallback = price
+		}
+	}
+	return fallback
+}
+
+// offerDiscounts returns the per-line discount and total discount an offer gives
+func offerDiscounts(offer Offer, lines []QuoteLine) ([]float64, float64) {
+	discounts := make([]float64, len(lines))
+	total := 0.0
+
+	for i, line := range lines {
+		if !offerCoversTicketType(offer, line.TicketType.ID) {
+			continue
+		}
+
+		var discount float64
+		switch strings.ToLower(offer.DiscountType) {
+		case "percentage":
+			discount = line.LineTotal * offer.DiscountAmount / 100
+		case "fixed":
+			discount = offer.DiscountAmount * float64(line.Quantity)
+		}
+		discount = math.Min(roundCurrency(discount), line.LineTotal)
+
+		discounts[i] = discount
+		total += discount
+	}
+
+	return discounts, total
+}
+
+func offerCoversTicketType(offer Offer, ticketTypeID string) bool {
+	if len(offer.TicketTypes) == 0 {
+		return true
+	}
+	for _, tt := range offer.TicketTypes {
+		if tt.ID == ticketTypeID {
+			return true
+		}
+	}
+	return false
+}
+
+// roundCurrency rounds to two decimal places
+func roundCurrency(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,128 @@
+package spektrix
+
+import (
+	"testing"
+)
+
+func testPriceList() *PriceList {
+	return &PriceList{
+		ID: "pl-1",
+		Prices: []Price{
+			{Amount: 20, IsBase: true, TicketType: TicketTypeRef{ID: "adult", Name: "Adult"}, PriceBand: PriceBandRef{ID: "stalls", Name: "Stalls"}},
+			{Amount: 15, TicketType: TicketTypeRef{ID: "adult", Name: "Adult"}, PriceBand: PriceBandRef{ID: "circle", Name: "Circle"}},
+			{Amount: 12, IsBase: true, TicketType: TicketTypeRef{ID: "child", Name: "Child"}, PriceBand: PriceBandRef{ID: "stalls", Name: "Stalls"}},
+		},
+		Fees: []Fee{
+			{ID: "booking", Name: "Booking fee", Amount: 1.5},
+			{ID: "restoration", Name: "Restoration levy", Amount: 0.5, PerTicket: true},
+		},
+	}
+}
+
+func TestBuildQuote(t *testing.T) {
+	t.Logf("Importance: Quotes are fed directly into basket creation, so incorrect totals or ticket expansion would mislead customers about what they will pay.")
+
+	t.Run("prices lines and adds fees without offers", func(t *testing.T) {
+		t.Logf("  > Why it's important: Verifies the base arithmetic of subtotal, per-order and per-ticket fees.")
+		lines := []QuoteLineRequest{{TicketTypeID: "adult", Quantity: 2}, {TicketTypeID: "child", Quantity: 1}}
+
+		quote, err := BuildQuote("inst-1", testPriceList(), nil, lines, "")
+		if err != nil {
+			t.Fatalf("BuildQuote failed: %v", err)
+		}
+
+		if quote.Subtotal != 52 {
+			t.Errorf("Expected subtotal 52, got %v", quote.Subtotal)
+		}
+		if quote.FeesTotal != 3 {
+			t.Errorf("Expected fees total 3, got %v", quote.FeesTotal)
+		}
+		if quote.Total != 55 {
+			t.Errorf("Expected total 55, got %v", quote.Total)
+		}
+		if len(quote.Tickets) != 3 {
+			t.Errorf("Expected 3 basket tickets, got %d", len(quote.Tickets))
+		}
+	})
+
+	t.Run("uses the requested price band", func(t *testing.T) {
+		t.Logf("  > Why it's important: Customers choosing cheaper seats must be quoted the band they asked for, not the base band.")
+		lines := []QuoteLineRequest{{TicketTypeID: "adult", PriceBandID: "circle", Quantity: 1}}
+
+		quote, err := BuildQuote("inst-1", testPriceList(), nil, lines, "")
+		if err != nil {
+			t.Fatalf("BuildQuote failed: %v", err)
+		}
+
+		if quote.Lines[0].UnitPrice != 15 {
+			t.Errorf("Expected circle unit price 15, got %v", quote.Lines[0].UnitPrice)
+		}
+		if quote.Tickets[0].Band != "circle" {
+			t.Errorf("Expected basket ticket band 'circle', got %q", quote.Tickets[0].Band)
+		}
+	})
+
+	t.Run("applies the best applicable offer", func(t *testing.T) {
+		t.Logf("  > Why it's important: Automatic offer selection should give the customer the largest valid discount.")
+		offers := []Offer{
+			{ID: "small", Name: "10% off", IsActive: true, DiscountType: "Percentage", DiscountAmount: 10},
+			{ID: "big", Name: "£5 off adults", IsActive: true, DiscountType: "Fixed", DiscountAmount: 5, TicketTypes: []TicketTypeRef{{ID: "adult"}}},
+			{ID: "inactive", Name: "Half price", IsActive: false, DiscountType: "Percentage", DiscountAmount: 50},
+		}
+		lines := []QuoteLineRequest{{TicketTypeID: "adult", Quantity: 2}, {TicketTypeID: "child", Quantity: 1}}
+
+		quote, err := BuildQuote("inst-1", testPriceList(), offers, lines, "")
+		if err != nil {
+			t.Fatalf("BuildQuote failed: %v", err)
+		}
+
+		if quote.AppliedOffer == nil || quote.AppliedOffer.ID != "big" {
+			t.Fatalf("Expected offer 'big' to be applied, got %+v", quote.AppliedOffer)
+		}
+		if quote.Discount != 10 {
+			t.Errorf("Expected discount 10, got %v", quote.Discount)
+		}
+		if quote.Total != 45 {
+			t.Errorf("Expected total 45, got %v", quote.Total)
+		}
+		for _, ticket := range quote.Tickets {
+			if ticket.TicketType == "adult" && ticket.Offer != "big" {
+				t.Errorf("Expected adult basket tickets to carry the offer")
+			}
+			if ticket.TicketType == "child" && ticket.Offer != "" {
+				t.Errorf("Expected child basket tickets to carry no offer")
+			}
+		}
+	})
+
+	t.Run("rejects unknown ticket types and offers", func(t *testing.T) {
+		t.Logf("  > Why it's important: Invalid input must fail loudly rather than produce a partial quote.")
+		if _, err := BuildQuote("inst-1", testPriceList(), nil, []QuoteLineRequest{{TicketTypeID: "senior", Quantity: 1}}, ""); err == nil {
+			t.Error("Expected error for unknown ticket type")
+		}
+		if _, err := BuildQuote("inst-1", testPriceList(), nil, []QuoteLineRequest{{TicketTypeID: "adult", Quantity: 1}}, "missing"); err == nil {
+			t.Error("Expected error for unknown offer")
+		}
+	})
+}
+
+func TestParseQuoteLines(t *testing.T) {
+	t.Logf("Importance: Tool arguments arrive as strings; parsing errors must be reported clearly to the agent.")
+
+	lines, err := ParseQuoteLines("adult:2, child@stalls:1")
+	if err != nil {
+		t.Fatalf("ParseQuoteLines failed: %v", err)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d", len(lines))
+	}
+	if lines[1].TicketTypeID != "child" || lines[1].PriceBandID != "stalls" || lines[1].Quantity != 1 {
+		t.Errorf("Unexpected second line: %+v", lines[1])
+	}
+
+	for _, bad := range []string{"", "adult", "adult:0", "adult:x", ":2"} {
+		if _, err := ParseQuoteLines(bad); err == nil {
+			t.Errorf("Expected error for %q", bad)
+		}
+	}
+}
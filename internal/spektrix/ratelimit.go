@@ -0,0 +1,97 @@
+package spektrix
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/vcto/mcp-adapters/internal/ratelimit"
+)
+
+// defaultRateLimit is how many requests per second the client sends by
+// default, a conservative pace for one API user
+const defaultRateLimit = 5
+
+// rateLimiterFromEnv builds the limiter SPEKTRIX_RATE_LIMIT asks for; 0
+// turns limiting off
+func rateLimiterFromEnv() *ratelimit.Limiter {
+	perSecond := float64(defaultRateLimit)
+	if v := os.Getenv("SPEKTRIX_RATE_LIMIT"); v != "" {
+		if n, err := strconv.ParseFloat(v, 64); err == nil && n >= 0 {
+			perSecond = n
+		}
+	}
+	if perSecond == 0 {
+		return nil
+	}
+	// Bursts of up to a second's worth of requests
+	return ratelimit.New(perSecond, perSecond)
+}
+
+// RetryPolicy controls how the client retries throttled and failed requests
+type RetryPolicy struct {
+	// MaxRetries is how many times a request is retried; 0 disables retries
+	MaxRetries int
+	// BaseDelay is the wait before the first retry, doubled for each later one
+	BaseDelay time.Duration
+	// MaxDelay caps the wait between retries, including one asked for by
+	// a Retry-After header
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy retries three times over a few seconds, or the number
+// of times SPEKTRIX_MAX_RETRIES gives
+func DefaultRetryPolicy() RetryPolicy {
+	policy := RetryPolicy{
+		MaxRetries: 3,
+		BaseDelay:  500 * time.Millisecond,
+		MaxDelay:   10 * time.Second,
+	}
+	if v := os.Getenv("SPEKTRIX_MAX_RETRIES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			policy.MaxRetries = n
+		}
+	}
+	return policy
+}
+
+// backoff returns the wait before the given retry (1-based)
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < retry && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// retryAfter reads a Retry-After header in seconds or as an HTTP date
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return time.Until(at), true
+	}
+	return 0, false
+}
+
+// isIdempotent reports whether a request may be repeated after a failure
+// that leaves unclear whether Spektrix acted on it. POSTs create baskets,
+// orders and customers, so only a 429, which Spektrix rejects unprocessed,
+// retries them.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
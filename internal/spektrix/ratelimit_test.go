@@ -0,0 +1,92 @@
+package spektrix
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/vcto/mcp-adapters/internal/ratelimit"
+)
+
+func TestRateLimitAndRetry(t *testing.T) {
+	t.Logf("Importance: Batch customer operations run many calls in a row; throttling must slow them down, not fail them halfway.")
+
+	newClient := func(url string) *Client {
+		client := &Client{APIUser: "user", APIKey: "a2V5", BaseURL: url, HTTPClient: http.DefaultClient}
+		client.Retry = RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+		return client
+	}
+
+	t.Run("retries 429 after Retry-After", func(t *testing.T) {
+		t.Logf("  > Why it's important: Spektrix says when to come back; POSTs rejected with 429 were not processed and are safe to resend.")
+		calls := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls == 1 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			_, _ = w.Write([]byte(`{"id":"b1","tickets":[]}`))
+		}))
+		defer server.Close()
+
+		client := newClient(server.URL)
+		client.Limiter = ratelimit.New(100, 100)
+		basket, err := client.CreateBasket()
+		if err != nil || basket.ID != "b1" || calls != 2 {
+			t.Errorf("Expected one retry, got %d calls, %+v, %v", calls, basket, err)
+		}
+	})
+
+	t.Run("retries server errors on reads only", func(t *testing.T) {
+		t.Logf("  > Why it's important: A failed POST may still have created an order; repeating it could charge twice.")
+		calls := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		client := newClient(server.URL)
+		if _, err := client.GetTags(); err == nil || calls != 3 {
+			t.Errorf("Expected a GET tried 3 times, got %d, %v", calls, err)
+		}
+
+		calls = 0
+		if _, err := client.Checkout("b1", "c1"); err == nil || calls != 1 {
+			t.Errorf("Expected a POST tried once, got %d, %v", calls, err)
+		}
+	})
+
+	t.Run("does not retry client errors", func(t *testing.T) {
+		t.Logf("  > Why it's important: A bad request fails the same way every time.")
+		calls := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer server.Close()
+
+		if _, err := newClient(server.URL).GetTags(); err == nil || calls != 1 {
+			t.Errorf("Expected one call, got %d, %v", calls, err)
+		}
+	})
+
+	t.Run("reads limits from the environment", func(t *testing.T) {
+		t.Logf("  > Why it's important: Venues on a shared API user need to slow the adapter down without a rebuild.")
+		t.Setenv("SPEKTRIX_RATE_LIMIT", "0")
+		t.Setenv("SPEKTRIX_MAX_RETRIES", "1")
+		if rateLimiterFromEnv() != nil {
+			t.Error("Expected 0 to turn pacing off")
+		}
+		if got := DefaultRetryPolicy().MaxRetries; got != 1 {
+			t.Errorf("Expected 1 retry, got %d", got)
+		}
+		t.Setenv("SPEKTRIX_RATE_LIMIT", "2.5")
+		if limiter := rateLimiterFromEnv(); limiter == nil || limiter.Rate() != 2.5 {
+			t.Errorf("Expected 2.5 a second, got %+v", limiter)
+		}
+	})
+}
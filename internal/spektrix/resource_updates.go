@@ -0,0 +1,267 @@
+package spektrix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/vcto/mcp-adapters/internal/config"
+)
+
+const (
+	// defaultResourcePollInterval is how often Spektrix is polled for
+	// changes to report as resource updates
+	defaultResourcePollInterval = time.Minute
+	// pollOverlap re-reads changes this far before the previous poll, so a
+	// change saved while a poll ran is not missed; ones already reported are
+	// skipped
+	pollOverlap = time.Minute
+)
+
+// Notifier sends a notification to one MCP session; server.MCPServer
+// implements it
+type Notifier interface {
+	SendNotificationToSpecificClient(sessionID, method string, params map[string]any) error
+}
+
+// ResourceWatcher polls Spektrix for new and updated customers and orders
+// and tells each connected session which spektrix:// resources changed,
+// with notifications/resources/updated: spektrix://customers/{id} for a
+// changed customer, spektrix://customers/{id}/orders for a new or changed
+// order, and spektrix://customers/search for a session whose last search
+// found a changed customer. Every session shares the server's Spektrix
+// account, so one poll serves them all. Only sessions with a notification
+// stream are told, and mcp-go does not route resources/subscribe, so a
+// session hears about every changed resource, not just those it subscribed
+// to.
+type ResourceWatcher struct {
+	handler  *Handler
+	notifier Notifier
+	interval time.Duration
+
+	mu       sync.Mutex
+	sessions map[string]bool
+	// since is where the next poll reads from; zero until the first poll
+	since time.Time
+	// customers and orders hold what the last poll reported, as the
+	// overlap reads recent changes twice
+	customers map[string]string
+	orders    map[string]string
+}
+
+// NewResourceWatcher creates a watcher polling every interval; Attach it to
+// the server's hooks and Run it
+func NewResourceWatcher(handler *Handler, notifier Notifier, interval time.Duration) *ResourceWatcher {
+	return &ResourceWatcher{
+		handler:  handler,
+		notifier: notifier,
+		interval: interval,
+		sessions: make(map[string]bool),
+	}
+}
+
+// ResourcePollIntervalFromEnv reads SPEKTRIX_RESOURCE_POLL_INTERVAL; 0
+// disables resource update notifications
+func ResourcePollIntervalFromEnv() time.Duration {
+	return config.DurationFromEnv("SPEKTRIX_RESOURCE_POLL_INTERVAL", defaultResourcePollInterval)
+}
+
+// Attach registers the watcher's session hooks
+func (w *ResourceWatcher) Attach(hooks *server.Hooks) {
+	hooks.AddOnRegisterSession(func(ctx context.Context, session server.ClientSession) {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.sessions[session.SessionID()] = true
+	})
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		w.forget(session.SessionID())
+	})
+}
+
+// Run polls until ctx is done. It returns at once when the interval is 0.
+func (w *ResourceWatcher) Run(ctx context.Context) {
+	if w.interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.poll(time.Now())
+		}
+	}
+}
+
+// poll checks Spektrix once and notifies every connected session. Nothing
+// is polled while no session is connected.
+func (w *ResourceWatcher) poll(now time.Time) {
+	sessions := w.connected()
+	if len(sessions) == 0 {
+		return
+	}
+	changed, customers := w.check(now)
+	for _, sessionID := range sessions {
+		uris := changed
+		if w.searchChanged(sessionID, customers) {
+			uris = append(append([]string(nil), changed...), CustomerSearchURI)
+		}
+		for _, uri := range uris {
+			w.send(sessionID, mcp.MethodNotificationResourceUpdated, map[string]any{"uri": uri})
+		}
+	}
+}
+
+// check returns the resources changed since the last poll and the IDs of
+// the customers changed. The first poll only records where to start.
+func (w *ResourceWatcher) check(now time.Time) ([]string, map[string]bool) {
+	w.mu.Lock()
+	since := w.since
+	w.mu.Unlock()
+	if since.IsZero() {
+		w.mu.Lock()
+		w.since = now
+		w.mu.Unlock()
+		return nil, nil
+	}
+
+	client := w.handler.GetClient()
+	customers, err := client.GetCustomersModifiedSince(since.Add(-pollOverlap))
+	if err != nil {
+		log.Printf("Spektrix: resource watcher: %v", err)
+		return nil, nil
+	}
+	orders, err := client.GetOrders(since.Add(-pollOverlap), now)
+	if err != nil {
+		log.Printf("Spektrix: resource watcher: %v", err)
+		return nil, nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	uris, changedCustomers, reportedCustomers, reportedOrders := changedResources(customers, orders, w.customers, w.orders)
+	w.since = now
+	w.customers = reportedCustomers
+	w.orders = reportedOrders
+	return uris, changedCustomers
+}
+
+// changedResources returns the resources customers and orders alter and
+// the customers changed, skipping customers and orders already reported in
+// the same state
+func changedResources(customers []Customer, orders []Order, previousCustomers, previousOrders map[string]string) ([]string, map[string]bool, map[string]string, map[string]string) {
+	seen := make(map[string]bool)
+	var uris []string
+	add := func(uri string) {
+		if !seen[uri] {
+			seen[uri] = true
+			uris = append(uris, uri)
+		}
+	}
+
+	changed := make(map[string]bool)
+	reportedCustomers := make(map[string]string)
+	for _, customer := range customers {
+		reportedCustomers[customer.ID] = customer.UpdatedAt
+		if at, ok := previousCustomers[customer.ID]; ok && at == customer.UpdatedAt {
+			continue
+		}
+		changed[customer.ID] = true
+		add(customerURI(customer.ID))
+	}
+
+	reportedOrders := make(map[string]string)
+	for _, order := range orders {
+		state := order.Status + "\x00" + fmt.Sprint(order.Total)
+		reportedOrders[order.ID] = state
+		if previous, ok := previousOrders[order.ID]; ok && previous == state {
+			continue
+		}
+		if order.Customer != nil && order.Customer.ID != "" {
+			add(customerURI(order.Customer.ID) + "/orders")
+		}
+	}
+
+	sort.Strings(uris)
+	return uris, changed, reportedCustomers, reportedOrders
+}
+
+// searchChanged reports whether the session's last customer search found
+// one of the changed customers
+func (w *ResourceWatcher) searchChanged(sessionID string, changed map[string]bool) bool {
+	if len(changed) == 0 {
+		return false
+	}
+	search, ok := w.handler.searches.lookup(sessionID)
+	if !ok {
+		return false
+	}
+	for _, id := range search.CustomerIDs() {
+		if changed[id] {
+			return true
+		}
+	}
+	return false
+}
+
+// GetCustomersModifiedSince retrieves the customers created or updated
+// since a time
+func (c *Client) GetCustomersModifiedSince(since time.Time) ([]Customer, error) {
+	query := url.Values{}
+	query.Set("modifiedSince", since.UTC().Format("2006-01-02T15:04:05Z"))
+
+	resp, err := c.makeRequest("GET", "/customers?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var customers []Customer
+	if err := c.handleResponse(resp, &customers); err != nil {
+		// 404 is normal - nobody changed
+		if strings.HasPrefix(err.Error(), "API error 404") {
+			return []Customer{}, nil
+		}
+		return nil, err
+	}
+
+	return customers, nil
+}
+
+func customerURI(customerID string) string {
+	return "spektrix://customers/" + customerID
+}
+
+func (w *ResourceWatcher) connected() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	sessions := make([]string, 0, len(w.sessions))
+	for sessionID := range w.sessions {
+		sessions = append(sessions, sessionID)
+	}
+	sort.Strings(sessions)
+	return sessions
+}
+
+func (w *ResourceWatcher) send(sessionID, method string, params map[string]any) {
+	err := w.notifier.SendNotificationToSpecificClient(sessionID, method, params)
+	if errors.Is(err, server.ErrSessionNotFound) {
+		w.forget(sessionID)
+	}
+}
+
+func (w *ResourceWatcher) forget(sessionID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.sessions, sessionID)
+}
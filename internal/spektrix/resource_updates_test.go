@@ -0,0 +1,136 @@
+package spektrix
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// watchedSession is a connected MCP client known only by its ID
+type watchedSession string
+
+func (w watchedSession) Initialize()                                         {}
+func (w watchedSession) Initialized() bool                                   { return true }
+func (w watchedSession) NotificationChannel() chan<- mcp.JSONRPCNotification { return nil }
+func (w watchedSession) SessionID() string                                   { return string(w) }
+
+// recordingNotifier keeps the resource URIs sent to each session
+type recordingNotifier struct {
+	mu   sync.Mutex
+	sent map[string][]string
+}
+
+func (r *recordingNotifier) SendNotificationToSpecificClient(sessionID, method string, params map[string]any) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent[sessionID] = append(r.sent[sessionID], params["uri"].(string))
+	return nil
+}
+
+// take returns and clears the URIs sent to a session
+func (r *recordingNotifier) take(sessionID string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sent := r.sent[sessionID]
+	delete(r.sent, sessionID)
+	sort.Strings(sent)
+	return strings.Join(sent, " ")
+}
+
+func TestResourceWatcher(t *testing.T) {
+	t.Logf("Importance: Clients showing spektrix:// resources should refresh when customers or orders change instead of re-reading them every turn.")
+
+	var mu sync.Mutex
+	customers := []Customer{}
+	orders := []Order{}
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/customers":
+			if r.URL.Query().Get("modifiedSince") == "" {
+				t.Errorf("Expected modifiedSince, got %s", r.URL.RawQuery)
+			}
+			if len(customers) == 0 {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(customers)
+		case "/orders":
+			_ = json.NewEncoder(w).Encode(orders)
+		default:
+			t.Errorf("Unexpected %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer api.Close()
+
+	h := &Handler{
+		client:   &Client{APIUser: "user", APIKey: "a2V5", BaseURL: api.URL, HTTPClient: api.Client()},
+		baskets:  newSessionValues[string](),
+		searches: newSessionValues[CustomerSearch](),
+	}
+	notifier := &recordingNotifier{sent: map[string][]string{}}
+	watcher := NewResourceWatcher(h, notifier, time.Minute)
+	hooks := &server.Hooks{}
+	watcher.Attach(hooks)
+	hooks.RegisterSession(context.Background(), watchedSession("s1"))
+	hooks.RegisterSession(context.Background(), watchedSession("s2"))
+	h.searches.values["s2"] = CustomerSearch{Customers: []Customer{{ID: "c1"}}}
+
+	now := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+	watcher.poll(now)
+
+	t.Run("reports changed customers and orders", func(t *testing.T) {
+		t.Logf("  > Why it's important: The first poll only sets a starting point; later changes reach every session, and the search resource only where it holds the customer.")
+		mu.Lock()
+		customers = []Customer{{ID: "c1", UpdatedAt: "2026-03-04T12:00:30"}}
+		orders = []Order{{ID: "o1", Customer: &Customer{ID: "c2"}, Total: 20}}
+		mu.Unlock()
+		watcher.poll(now.Add(time.Minute))
+		if got := notifier.take("s1"); got != "spektrix://customers/c1 spektrix://customers/c2/orders" {
+			t.Errorf("Unexpected notifications for s1: %s", got)
+		}
+		if got := notifier.take("s2"); got != "spektrix://customers/c1 spektrix://customers/c2/orders spektrix://customers/search" {
+			t.Errorf("Unexpected notifications for s2: %s", got)
+		}
+	})
+
+	t.Run("skips changes already reported", func(t *testing.T) {
+		t.Logf("  > Why it's important: The overlap between polls must not repeat notifications; a changed order status must still be reported.")
+		watcher.poll(now.Add(2 * time.Minute))
+		if got := notifier.take("s1"); got != "" {
+			t.Errorf("Expected nothing new, got %s", got)
+		}
+		mu.Lock()
+		orders[0].Status = "Cancelled"
+		mu.Unlock()
+		watcher.poll(now.Add(3 * time.Minute))
+		for _, session := range []string{"s1", "s2"} {
+			if got := notifier.take(session); got != "spektrix://customers/c2/orders" {
+				t.Errorf("Expected the cancelled order reported to %s, got %s", session, got)
+			}
+		}
+	})
+
+	t.Run("stops polling without sessions", func(t *testing.T) {
+		t.Logf("  > Why it's important: Polling a shared account for nobody wastes its rate limit.")
+		hooks.UnregisterSession(context.Background(), watchedSession("s1"))
+		hooks.UnregisterSession(context.Background(), watchedSession("s2"))
+		mu.Lock()
+		customers[0].UpdatedAt = "2026-03-04T12:04:00"
+		mu.Unlock()
+		watcher.poll(now.Add(4 * time.Minute))
+		if len(notifier.sent) != 0 {
+			t.Errorf("Expected no notifications, got %v", notifier.sent)
+		}
+	})
+}
@@ -0,0 +1,193 @@
+package spektrix
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// salesReportMaxDays bounds a sales report's date range; each instance sold
+// costs a lookup
+const salesReportMaxDays = 366
+
+// EventRef identifies the event an instance belongs to
+type EventRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+// InstanceSales totals the tickets sold for one instance
+type InstanceSales struct {
+	InstanceID string  `json:"instanceId"`
+	Start      string  `json:"start,omitempty"`
+	Tickets    int     `json:"tickets"`
+	Revenue    float64 `json:"revenue"`
+	Discounts  float64 `json:"discounts,omitempty"`
+	// Error explains an instance whose event could not be looked up
+	Error string `json:"error,omitempty"`
+}
+
+// EventSales totals the tickets sold for one event, by instance
+type EventSales struct {
+	EventID   string          `json:"eventId,omitempty"`
+	Name      string          `json:"name"`
+	Tickets   int             `json:"tickets"`
+	Revenue   float64         `json:"revenue"`
+	Discounts float64         `json:"discounts,omitempty"`
+	Instances []InstanceSales `json:"instances"`
+}
+
+// SalesReport is ticket sales over a range of order dates, by event, highest
+// revenue first
+type SalesReport struct {
+	From      string       `json:"from"`
+	To        string       `json:"to"`
+	Orders    int          `json:"orders"`
+	Tickets   int          `json:"tickets"`
+	Revenue   float64      `json:"revenue"`
+	Discounts float64      `json:"discounts,omitempty"`
+	Events    []EventSales `json:"events"`
+}
+
+// GetOrders retrieves the orders placed between from and to, inclusive
+func (c *Client) GetOrders(from, to time.Time) ([]Order, error) {
+	query := url.Values{}
+	query.Set("dateFrom", from.Format("2006-01-02"))
+	query.Set("dateTo", to.Format("2006-01-02"))
+
+	resp, err := c.makeRequest("GET", "/orders?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var orders []Order
+	if err := c.handleResponse(resp, &orders); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// GetInstance retrieves an instance by ID, with its event
+func (c *Client) GetInstance(instanceID string) (*Instance, error) {
+	endpoint := fmt.Sprintf("/instances/%s", instanceID)
+
+	resp, err := c.makeRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var instance Instance
+	if err := c.handleResponse(resp, &instance); err != nil {
+		return nil, err
+	}
+
+	return &instance, nil
+}
+
+// SalesByInstance totals the tickets in orders by instance, in the order
+// instances first appear. Cancelled orders are left out. Revenue is the
+// price paid; discounts are reported separately.
+func SalesByInstance(orders []Order) ([]InstanceSales, int) {
+	var sales []InstanceSales
+	index := map[string]int{}
+	counted := 0
+	for _, order := range orders {
+		if strings.EqualFold(order.Status, "cancelled") {
+			continue
+		}
+		counted++
+		for _, ticket := range order.Tickets {
+			i, ok := index[ticket.Instance.ID]
+			if !ok {
+				i = len(sales)
+				index[ticket.Instance.ID] = i
+				sales = append(sales, InstanceSales{InstanceID: ticket.Instance.ID})
+			}
+			sales[i].Tickets++
+			sales[i].Revenue = roundCurrency(sales[i].Revenue + ticket.Price)
+			sales[i].Discounts = roundCurrency(sales[i].Discounts + ticket.Discount)
+		}
+	}
+	return sales, counted
+}
+
+// SalesReport totals the ticket sales in orders placed from from to to,
+// inclusive, by event and instance. Instances whose event cannot be looked
+// up are reported under an unknown event with the error.
+func (h *Handler) SalesReport(from, to time.Time) (*SalesReport, error) {
+	orders, err := h.client.GetOrders(from, to)
+	if err != nil {
+		return nil, err
+	}
+	instances, counted := SalesByInstance(orders)
+
+	report := &SalesReport{
+		From:   from.Format("2006-01-02"),
+		To:     to.Format("2006-01-02"),
+		Orders: counted,
+		Events: []EventSales{},
+	}
+	index := map[string]int{}
+	lookup := newInstanceLookup(h.client)
+	for _, sales := range instances {
+		start, ref, err := lookup.describe(sales.InstanceID)
+		if err != nil {
+			sales.Error = err.Error()
+		}
+		sales.Start = start
+		if ref.Name == "" {
+			ref.Name = "Unknown event"
+		}
+
+		i, ok := index[ref.ID]
+		if !ok {
+			i = len(report.Events)
+			index[ref.ID] = i
+			report.Events = append(report.Events, EventSales{EventID: ref.ID, Name: ref.Name})
+		}
+		event := &report.Events[i]
+		event.Instances = append(event.Instances, sales)
+		event.Tickets += sales.Tickets
+		event.Revenue = roundCurrency(event.Revenue + sales.Revenue)
+		event.Discounts = roundCurrency(event.Discounts + sales.Discounts)
+		report.Tickets += sales.Tickets
+		report.Revenue = roundCurrency(report.Revenue + sales.Revenue)
+		report.Discounts = roundCurrency(report.Discounts + sales.Discounts)
+	}
+
+	sort.SliceStable(report.Events, func(i, j int) bool {
+		return report.Events[i].Revenue > report.Events[j].Revenue
+	})
+	for _, event := range report.Events {
+		instances := event.Instances
+		sort.SliceStable(instances, func(i, j int) bool {
+			return instances[i].Start < instances[j].Start
+		})
+	}
+	return report, nil
+}
+
+// salesReportRange parses a report's from and to dates. to defaults to
+// today and may not be before from or more than salesReportMaxDays after it.
+func salesReportRange(from, to string, today time.Time) (time.Time, time.Time, error) {
+	start, err := time.Parse("2006-01-02", from)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("from must be a date like 2025-06-01")
+	}
+	end := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	if to != "" {
+		if end, err = time.Parse("2006-01-02", to); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("to must be a date like 2025-06-30")
+		}
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("to must not be before from")
+	}
+	if days := int(end.Sub(start).Hours()/24) + 1; days > salesReportMaxDays {
+		return time.Time{}, time.Time{}, fmt.Errorf("the range covers %d days; the most is %d", days, salesReportMaxDays)
+	}
+	return start, end, nil
+}
@@ -0,0 +1,74 @@
+package spektrix
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSalesReport(t *testing.T) {
+	t.Logf("Importance: Box office figures from this report get repeated to staff and boards; totals must add up and leave nothing out.")
+
+	t.Run("checks the date range", func(t *testing.T) {
+		t.Logf("  > Why it's important: A typo in a date must not turn into a year-long report or an empty one.")
+		today := time.Date(2025, 6, 15, 10, 0, 0, 0, time.UTC)
+		from, to, err := salesReportRange("2025-06-01", "", today)
+		if err != nil || from.Day() != 1 || to.Day() != 15 {
+			t.Errorf("Expected June 1 to today, got %v to %v (%v)", from, to, err)
+		}
+		for _, dates := range [][2]string{{"June 1", ""}, {"2025-06-10", "2025-06-01"}, {"2024-01-01", "2025-06-01"}} {
+			if _, _, err := salesReportRange(dates[0], dates[1], today); err == nil {
+				t.Errorf("Expected %v to be rejected", dates)
+			}
+		}
+	})
+
+	t.Run("totals by event and instance", func(t *testing.T) {
+		t.Logf("  > Why it's important: Cancelled orders are not sales, and an instance that cannot be looked up still counts.")
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/orders":
+				if r.URL.Query().Get("dateFrom") != "2025-06-01" || r.URL.Query().Get("dateTo") != "2025-06-30" {
+					t.Errorf("Unexpected range %s", r.URL.RawQuery)
+				}
+				_, _ = w.Write([]byte(`[
+					{"id":"o1","tickets":[{"instance":{"id":"i2"},"price":20},{"instance":{"id":"i1"},"price":15.5,"discount":4.5}]},
+					{"id":"o2","tickets":[{"instance":{"id":"i1"},"price":20}]},
+					{"id":"o3","status":"Cancelled","tickets":[{"instance":{"id":"i1"},"price":20}]},
+					{"id":"o4","tickets":[{"instance":{"id":"i3"},"price":8}]}]`))
+			case "/instances/i1":
+				_, _ = w.Write([]byte(`{"id":"i1","start":"2025-07-02T19:30:00","event":{"id":"e1"}}`))
+			case "/instances/i2":
+				_, _ = w.Write([]byte(`{"id":"i2","start":"2025-07-01T19:30:00","event":{"id":"e1"}}`))
+			case "/instances/i3":
+				w.WriteHeader(http.StatusNotFound)
+			case "/events/e1":
+				_, _ = w.Write([]byte(`{"id":"e1","name":"Hamlet"}`))
+			default:
+				t.Errorf("Unexpected %s %s", r.Method, r.URL.Path)
+			}
+		}))
+		defer server.Close()
+
+		h := &Handler{client: &Client{APIUser: "user", APIKey: "a2V5", BaseURL: server.URL, HTTPClient: server.Client()}}
+		report, err := h.SalesReport(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Orders != 3 || report.Tickets != 4 || report.Revenue != 63.5 || report.Discounts != 4.5 {
+			t.Errorf("Unexpected totals %+v", report)
+		}
+		if len(report.Events) != 2 {
+			t.Fatalf("Expected Hamlet and an unknown event, got %+v", report.Events)
+		}
+		hamlet := report.Events[0]
+		if hamlet.Name != "Hamlet" || hamlet.Tickets != 3 || hamlet.Revenue != 55.5 || hamlet.Instances[0].InstanceID != "i2" {
+			t.Errorf("Expected Hamlet first with i2 then i1, got %+v", hamlet)
+		}
+		if unknown := report.Events[1]; unknown.Revenue != 8 || !strings.Contains(unknown.Instances[0].Error, "404") {
+			t.Errorf("Expected i3 reported with its error, got %+v", unknown)
+		}
+	})
+}
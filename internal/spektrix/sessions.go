@@ -0,0 +1,94 @@
+package spektrix
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/vcto/mcp-adapters/internal/longrunning"
+)
+
+// sessionValues remembers one value per MCP session, such as the basket it
+// is assembling, so tools can pick up where the session left off. Requests
+// outside a session share one value.
+type sessionValues[T any] struct {
+	mu     sync.Mutex
+	values map[string]T
+}
+
+func newSessionValues[T any]() *sessionValues[T] {
+	return &sessionValues[T]{values: make(map[string]T)}
+}
+
+func (s *sessionValues[T]) get(ctx context.Context) (T, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.values[longrunning.SessionID(ctx)]
+	return value, ok
+}
+
+// lookup returns the value of the session with sessionID
+func (s *sessionValues[T]) lookup(sessionID string) (T, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.values[sessionID]
+	return value, ok
+}
+
+func (s *sessionValues[T]) set(ctx context.Context, value T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[longrunning.SessionID(ctx)] = value
+}
+
+// remove forgets the session's value if match reports true for it
+func (s *sessionValues[T]) remove(ctx context.Context, match func(T) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session := longrunning.SessionID(ctx)
+	if value, ok := s.values[session]; ok && match(value) {
+		delete(s.values, session)
+	}
+}
+
+func (s *sessionValues[T]) forget(sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.values, sessionID)
+}
+
+// CustomerSearchURI is the resource serving a session's last customer search
+const CustomerSearchURI = "spektrix://customers/search"
+
+// CustomerSearch is a customer search and the customers it found
+type CustomerSearch struct {
+	// Query holds the search fields, e.g. {"email": "jane@example.com"}
+	Query      map[string]string `json:"query"`
+	Customers  []Customer        `json:"customers"`
+	SearchedAt time.Time         `json:"searched_at"`
+}
+
+// CustomerIDs returns the IDs of the customers found
+func (s CustomerSearch) CustomerIDs() []string {
+	ids := make([]string, len(s.Customers))
+	for i, customer := range s.Customers {
+		ids[i] = customer.ID
+	}
+	return ids
+}
+
+// LastSearch returns the session's most recent spektrix_search_customers
+// results, if it has searched
+func (h *Handler) LastSearch(ctx context.Context) (CustomerSearch, bool) {
+	return h.searches.get(ctx)
+}
+
+// AttachSessions forgets each session's basket and last customer search when
+// the session ends
+func (h *Handler) AttachSessions(hooks *server.Hooks) {
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		h.baskets.forget(session.SessionID())
+		h.searches.forget(session.SessionID())
+	})
+}
@@ -0,0 +1,55 @@
+package spektrix
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLastCustomerSearch(t *testing.T) {
+	t.Logf("Importance: spektrix://customers/search and bulk tagging act on the last search; it must be the real one, with its query.")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("email") == "jane@example.com" {
+			_, _ = w.Write([]byte(`{"id":"c1","firstName":"Jane","email":"jane@example.com"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	h := &Handler{
+		client:   &Client{APIUser: "user", APIKey: "a2V5", BaseURL: server.URL, HTTPClient: server.Client()},
+		baskets:  newSessionValues[string](),
+		searches: newSessionValues[CustomerSearch](),
+	}
+
+	t.Run("records the search", func(t *testing.T) {
+		t.Logf("  > Why it's important: The resource shows what was searched for and when, not a placeholder.")
+		if _, ok := h.LastSearch(context.Background()); ok {
+			t.Fatal("Expected no search before the tool runs")
+		}
+		before := time.Now()
+		if result := callTool(t, h, "spektrix_search_customers", map[string]interface{}{"email": "jane@example.com"}); result.IsError {
+			t.Fatalf("Search failed: %+v", result)
+		}
+		search, ok := h.LastSearch(context.Background())
+		if !ok || search.Query["email"] != "jane@example.com" || search.SearchedAt.Before(before) {
+			t.Fatalf("Unexpected search %+v", search)
+		}
+		if ids := search.CustomerIDs(); len(ids) != 1 || ids[0] != "c1" {
+			t.Errorf("Expected c1, got %v", ids)
+		}
+	})
+
+	t.Run("an empty search replaces the last one", func(t *testing.T) {
+		t.Logf("  > Why it's important: Bulk tagging after a search that found nobody must not tag the previous results.")
+		callTool(t, h, "spektrix_search_customers", map[string]interface{}{"email": "nobody@example.com"})
+		search, ok := h.LastSearch(context.Background())
+		if !ok || search.Query["email"] != "nobody@example.com" || len(search.Customers) != 0 {
+			t.Errorf("Expected an empty search recorded, got %+v", search)
+		}
+	})
+}
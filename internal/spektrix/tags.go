@@ -0,0 +1,91 @@
+package spektrix
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// AddCustomerTags attaches tags to a customer, keeping the tags it already has
+func (c *Client) AddCustomerTags(customerID string, tagIDs []string) error {
+	endpoint := fmt.Sprintf("/customers/%s/tags", customerID)
+
+	tags := make([]TagReference, len(tagIDs))
+	for i, id := range tagIDs {
+		tags[i] = TagReference{ID: id}
+	}
+
+	resp, err := c.makeRequest("POST", endpoint, tags)
+	if err != nil {
+		return err
+	}
+
+	return c.handleResponse(resp, nil)
+}
+
+// RemoveCustomerTag removes one tag from a customer
+func (c *Client) RemoveCustomerTag(customerID, tagID string) error {
+	endpoint := fmt.Sprintf("/customers/%s/tags/%s", customerID, tagID)
+
+	resp, err := c.makeRequest("DELETE", endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	return c.handleResponse(resp, nil)
+}
+
+// ResolveTags finds the tags named by refs, each a tag ID or a tag name in
+// any case. Unknown refs are an error listing the tag names available.
+func ResolveTags(tags []Tag, refs []string) ([]Tag, error) {
+	byID := make(map[string]Tag, len(tags))
+	byName := make(map[string]Tag, len(tags))
+	for _, tag := range tags {
+		byID[tag.ID] = tag
+		byName[strings.ToLower(tag.Name)] = tag
+	}
+
+	var resolved []Tag
+	var unknown []string
+	seen := make(map[string]bool)
+	for _, ref := range refs {
+		tag, ok := byID[ref]
+		if !ok {
+			tag, ok = byName[strings.ToLower(ref)]
+		}
+		switch {
+		case !ok:
+			unknown = append(unknown, ref)
+		case !seen[tag.ID]:
+			seen[tag.ID] = true
+			resolved = append(resolved, tag)
+		}
+	}
+
+	if len(unknown) > 0 {
+		names := make([]string, len(tags))
+		for i, tag := range tags {
+			names[i] = tag.Name
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown tags %s; available tags are %s",
+			strings.Join(unknown, ", "), strings.Join(names, ", "))
+	}
+	return resolved, nil
+}
+
+// tagIDs returns the IDs of tags
+func tagIDs(tags []Tag) []string {
+	ids := make([]string, len(tags))
+	for i, tag := range tags {
+		ids[i] = tag.ID
+	}
+	return ids
+}
+
+// CustomerTagResult reports tagging one customer
+type CustomerTagResult struct {
+	CustomerID string `json:"customerId"`
+	Success    bool   `json:"success"`
+	Error      string `json:"error,omitempty"`
+}
@@ -0,0 +1,92 @@
+package spektrix
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestCustomerTags(t *testing.T) {
+	t.Logf("Importance: Tags drive mailing lists and memberships; the wrong tag on the wrong customer is visible to them.")
+
+	tags := []Tag{{ID: "t1", Name: "Newsletter"}, {ID: "t2", Name: "Member"}}
+
+	t.Run("resolves tags by ID or name", func(t *testing.T) {
+		t.Logf("  > Why it's important: Users name tags, the API wants IDs.")
+		got, err := ResolveTags(tags, []string{"newsletter", "t2", "Member"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 2 || got[0].ID != "t1" || got[1].ID != "t2" {
+			t.Errorf("Expected t1 and t2 once each, got %+v", got)
+		}
+		if _, err := ResolveTags(tags, []string{"Donor"}); err == nil || !strings.Contains(err.Error(), "Member, Newsletter") {
+			t.Errorf("Expected an error listing available tags, got %v", err)
+		}
+	})
+
+	t.Run("bulk tags the last search", func(t *testing.T) {
+		t.Logf("  > Why it's important: Tagging after a search must reach every customer found, and report each one.")
+		var calls []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.URL.Path == "/tags":
+				_ = json.NewEncoder(w).Encode(tags)
+			case r.URL.Path == "/customers":
+				_, _ = w.Write([]byte(`{"id":"c1","email":"jane@example.com"}`))
+			case r.Method == "POST" && strings.HasSuffix(r.URL.Path, "/tags"):
+				var refs []TagReference
+				_ = json.NewDecoder(r.Body).Decode(&refs)
+				calls = append(calls, r.URL.Path+":"+refs[0].ID)
+			case r.Method == "DELETE":
+				if strings.HasPrefix(r.URL.Path, "/customers/c2/") {
+					w.WriteHeader(http.StatusNotFound)
+					_, _ = w.Write([]byte(`{"message":"no such customer"}`))
+					return
+				}
+				calls = append(calls, "DELETE "+r.URL.Path)
+			default:
+				t.Errorf("Unexpected %s %s", r.Method, r.URL.Path)
+			}
+		}))
+		defer server.Close()
+
+		handler := &Handler{
+			client:   &Client{APIUser: "user", APIKey: "a2V5", BaseURL: server.URL, HTTPClient: server.Client()},
+			baskets:  newSessionValues[string](),
+			searches: newSessionValues[CustomerSearch](),
+		}
+		ctx := context.Background()
+
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"tags": "Newsletter"}
+		if result := handler.tagCustomers(ctx, request, "added", handler.client.AddCustomerTags); !result.IsError {
+			t.Fatalf("Expected an error without customers, got %+v", result)
+		}
+
+		customers, err := handler.client.SearchCustomers("jane@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		handler.searches.set(ctx, CustomerSearch{Customers: customers})
+		result := handler.tagCustomers(ctx, request, "added", handler.client.AddCustomerTags)
+		if result.IsError || len(calls) != 1 || calls[0] != "/customers/c1/tags:t1" {
+			t.Fatalf("Expected c1 tagged with t1, got %v and %+v", calls, result)
+		}
+
+		calls = nil
+		request.Params.Arguments = map[string]interface{}{"tags": "member", "customerIds": "c1,c2"}
+		result = handler.tagCustomers(ctx, request, "removed", func(customerID string, tagIDs []string) error {
+			return handler.client.RemoveCustomerTag(customerID, tagIDs[0])
+		})
+		text := result.Content[0].(mcp.TextContent).Text
+		if result.IsError || len(calls) != 1 || calls[0] != "DELETE /customers/c1/tags/t2" || !strings.Contains(text, `"failed": 1`) {
+			t.Errorf("Expected c1 untagged and c2 reported failed, got %v and %s", calls, text)
+		}
+	})
+}
@@ -0,0 +1,243 @@
+package spektrix
+
+import "github.com/vcto/mcp-adapters/internal/tooldocs"
+
+// ToolDocs returns curated examples and output schemas for the Spektrix
+// tools, served in their docs://tools/{name} resources
+func ToolDocs() tooldocs.Registry {
+	return tooldocs.Registry{
+		"spektrix_search_customers": {
+			Examples: []tooldocs.Example{
+				{Description: "Look up a customer before creating one", Arguments: map[string]interface{}{"email": "jane@example.com"}},
+				{Description: "Second page of everyone named Smith", Arguments: map[string]interface{}{"lastName": "Smith", "page": 2}},
+			},
+			OutputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"customers":  map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "object"}},
+					"count":      map[string]interface{}{"type": "integer"},
+					"pagination": paginationSchema,
+				},
+			},
+		},
+		"spektrix_list_events": {
+			Examples: []tooldocs.Example{
+				{Description: "Find an event by name", Arguments: map[string]interface{}{"name": "hamlet", "onSaleOnly": true}},
+				{Description: "Continue from the previous page", Arguments: map[string]interface{}{"cursor": "MjU6MjU"}},
+			},
+			OutputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"events":     map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "object"}},
+					"count":      map[string]interface{}{"type": "integer"},
+					"pagination": paginationSchema,
+				},
+			},
+		},
+		"spektrix_sales_report": {
+			Examples: []tooldocs.Example{
+				{Description: "Sales for June", Arguments: map[string]interface{}{"from": "2025-06-01", "to": "2025-06-30"}},
+			},
+			OutputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"from":      map[string]interface{}{"type": "string"},
+					"to":        map[string]interface{}{"type": "string"},
+					"orders":    map[string]interface{}{"type": "integer"},
+					"tickets":   map[string]interface{}{"type": "integer"},
+					"revenue":   map[string]interface{}{"type": "number"},
+					"discounts": map[string]interface{}{"type": "number"},
+					"events": map[string]interface{}{"type": "array", "items": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"eventId":   map[string]interface{}{"type": "string"},
+							"name":      map[string]interface{}{"type": "string"},
+							"tickets":   map[string]interface{}{"type": "integer"},
+							"revenue":   map[string]interface{}{"type": "number"},
+							"instances": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "object"}},
+						},
+					}},
+				},
+			},
+		},
+		"spektrix_customer_orders": {
+			Examples: []tooldocs.Example{
+				{Description: "A customer's recent orders, ten at a time", Arguments: map[string]interface{}{"customerId": "I-AB12-CD34", "pageSize": 10}},
+			},
+			OutputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"customerId": map[string]interface{}{"type": "string"},
+					"orders":     map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "object"}},
+					"count":      map[string]interface{}{"type": "integer"},
+					"pagination": paginationSchema,
+				},
+			},
+		},
+		"spektrix_find_or_create_customer": {
+			Examples: []tooldocs.Example{
+				{Description: "Get a customer record for a booking", Arguments: map[string]interface{}{"email": "jane@example.com", "firstName": "Jane", "lastName": "Doe"}},
+			},
+		},
+		"spektrix_add_address": {
+			Examples: []tooldocs.Example{
+				{Description: "Add a US address to a new customer", Arguments: map[string]interface{}{"customerId": "I-AB12-CD34", "country": "US", "postcode": "10001", "line1": "1 Main St", "city": "New York", "state": "NY"}},
+			},
+		},
+		"spektrix_add_customer_tags": {
+			Examples: []tooldocs.Example{
+				{Description: "Tag the customer just found by email", Arguments: map[string]interface{}{"tags": "Newsletter"}},
+				{Description: "Tag several customers at once", Arguments: map[string]interface{}{"tags": "Member,Donor", "customerIds": "I-AB12-CD34,I-EF56-GH78"}},
+			},
+		},
+		"spektrix_remove_customer_tags": {
+			Examples: []tooldocs.Example{
+				{Description: "Take a customer off the newsletter", Arguments: map[string]interface{}{"tags": "Newsletter", "customerIds": "I-AB12-CD34"}},
+			},
+		},
+		"spektrix_update_address": {
+			Examples: []tooldocs.Example{
+				{Description: "Correct a postcode", Arguments: map[string]interface{}{"customerId": "I-AB12-CD34", "addressId": "A-1", "postcode": "SW1A 1AA"}},
+				{Description: "Send tickets to a work address", Arguments: map[string]interface{}{"customerId": "I-AB12-CD34", "addressId": "A-2", "defaultDelivery": true}},
+			},
+		},
+		"spektrix_find_duplicates": {
+			Examples: []tooldocs.Example{
+				{Description: "Check a customer for duplicate records", Arguments: map[string]interface{}{"customerId": "I-AB12-CD34"}},
+				{Description: "Check before creating a customer", Arguments: map[string]interface{}{"firstName": "Jane", "lastName": "Doe", "postcode": "SW1A 1AA"}},
+			},
+		},
+		"spektrix_merge_customers": {
+			Examples: []tooldocs.Example{
+				{Description: "Preview a merge", Arguments: map[string]interface{}{"keepCustomerId": "I-AB12-CD34", "duplicateCustomerId": "I-EF56-GH78"}},
+				{Description: "Merge once the user agrees", Arguments: map[string]interface{}{"keepCustomerId": "I-AB12-CD34", "duplicateCustomerId": "I-EF56-GH78", "confirm": "merge I-EF56-GH78 into I-AB12-CD34"}},
+			},
+		},
+		"spektrix_quote": {
+			Examples: []tooldocs.Example{
+				{Description: "Price two adult and one child ticket", Arguments: map[string]interface{}{"instanceId": "1001AHGJK", "tickets": "adult:2,child:1"}},
+			},
+		},
+		"spektrix_instance_availability": {
+			Examples: []tooldocs.Example{
+				{Description: "How many seats are left for tonight's performance?", Arguments: map[string]interface{}{"instanceId": "1001AHGJK"}},
+			},
+			OutputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"instanceId":   map[string]interface{}{"type": "string"},
+					"availability": availabilitySchema,
+				},
+			},
+		},
+		"spektrix_seating_plan_status": {
+			Examples: []tooldocs.Example{
+				{Description: "Which areas still have seats?", Arguments: map[string]interface{}{"instanceId": "1001AHGJK"}},
+			},
+			OutputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"instanceId": map[string]interface{}{"type": "string"},
+					"total":      availabilitySchema,
+					"areas":      map[string]interface{}{"type": "array", "items": availabilitySchema},
+					"count":      map[string]interface{}{"type": "integer"},
+				},
+			},
+		},
+		"spektrix_record_donation": {
+			Examples: []tooldocs.Example{
+				{Description: "Record a Gift Aided gift to the annual appeal", Arguments: map[string]interface{}{"customerId": "I-AB12-CD34", "fund": "Annual Appeal", "amount": 50, "giftAid": true}},
+				{Description: "Add a donation to the tickets being booked", Arguments: map[string]interface{}{"fund": "Annual Appeal", "amount": 5, "addToBasket": true}},
+			},
+		},
+		"spektrix_voucher_balance": {
+			Examples: []tooldocs.Example{
+				{Description: "How much is left on a voucher?", Arguments: map[string]interface{}{"code": "GV-7K2M-91QX"}},
+			},
+			OutputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"voucher": map[string]interface{}{"type": "object"},
+					"usable":  map[string]interface{}{"type": "boolean"},
+					"reason":  map[string]interface{}{"type": "string"},
+				},
+			},
+		},
+		"spektrix_issue_voucher": {
+			Examples: []tooldocs.Example{
+				{Description: "Sell a £25 open-value voucher", Arguments: map[string]interface{}{"customerId": "I-AB12-CD34", "type": "Gift Voucher", "amount": 25}},
+				{Description: "Add a fixed-value voucher to the tickets being booked", Arguments: map[string]interface{}{"type": "Dinner and Show", "addToBasket": true}},
+			},
+		},
+		"spektrix_redeem_voucher": {
+			Examples: []tooldocs.Example{
+				{Description: "Pay for the basket with a voucher", Arguments: map[string]interface{}{"code": "GV-7K2M-91QX"}},
+				{Description: "Use only part of a voucher", Arguments: map[string]interface{}{"code": "GV-7K2M-91QX", "amount": 10}},
+			},
+		},
+		"spektrix_customer_memberships": {
+			Examples: []tooldocs.Example{
+				{Description: "Is this member due to renew?", Arguments: map[string]interface{}{"customerId": "I-AB12-CD34"}},
+			},
+			OutputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"customerId": map[string]interface{}{"type": "string"},
+					"memberships": map[string]interface{}{"type": "array", "items": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"id":         map[string]interface{}{"type": "string"},
+							"membership": map[string]interface{}{"type": "object"},
+							"expiryDate": map[string]interface{}{"type": "string"},
+							"autoRenew":  map[string]interface{}{"type": "boolean"},
+							"status":     map[string]interface{}{"type": "string", "enum": []string{"active", "expiring", "expired"}},
+							"daysLeft":   map[string]interface{}{"type": "integer"},
+						},
+					}},
+					"count":    map[string]interface{}{"type": "integer"},
+					"expiring": map[string]interface{}{"type": "integer"},
+				},
+			},
+		},
+		"spektrix_membership_renewal": {
+			Examples: []tooldocs.Example{
+				{Description: "Renew the membership that is about to expire", Arguments: map[string]interface{}{"customerId": "I-AB12-CD34"}},
+			},
+		},
+		"spektrix_price_list": {
+			Examples: []tooldocs.Example{
+				{Description: "Prices before quoting tickets", Arguments: map[string]interface{}{"instanceId": "1001AHGJK"}},
+			},
+		},
+	}
+}
+
+// availabilitySchema is the shape of an Availability in tool output; areas
+// add their id and name
+var availabilitySchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"capacity":    map[string]interface{}{"type": "integer"},
+		"available":   map[string]interface{}{"type": "integer"},
+		"sold":        map[string]interface{}{"type": "integer"},
+		"reserved":    map[string]interface{}{"type": "integer"},
+		"locked":      map[string]interface{}{"type": "integer"},
+		"selected":    map[string]interface{}{"type": "integer"},
+		"percentSold": map[string]interface{}{"type": "number"},
+		"soldOut":     map[string]interface{}{"type": "boolean"},
+	},
+}
+
+// paginationSchema is the shape of a PageInfo in paged tool output
+var paginationSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"page":       map[string]interface{}{"type": "integer"},
+		"pageSize":   map[string]interface{}{"type": "integer"},
+		"total":      map[string]interface{}{"type": "integer"},
+		"totalPages": map[string]interface{}{"type": "integer"},
+		"hasMore":    map[string]interface{}{"type": "boolean"},
+		"nextCursor": map[string]interface{}{"type": "string"},
+		"truncated":  map[string]interface{}{"type": "boolean"},
+	},
+}
@@ -4,12 +4,13 @@ import "time"
 
 // Customer represents a Spektrix customer
 type Customer struct {
-	ID        string `json:"id"`
-	FirstName string `json:"firstName"`
-	LastName  string `json:"lastName"`
-	Email     string `json:"email"`
-	CreatedAt string `json:"createdAt,omitempty"`
-	UpdatedAt string `json:"updatedAt,omitempty"`
+	ID        string    `json:"id"`
+	FirstName string    `json:"firstName"`
+	LastName  string    `json:"lastName"`
+	Email     string    `json:"email"`
+	Addresses []Address `json:"addresses,omitempty"`
+	CreatedAt string    `json:"createdAt,omitempty"`
+	UpdatedAt string    `json:"updatedAt,omitempty"`
 }
 
 // CreateCustomerRequest for creating new customers
@@ -21,6 +22,7 @@ type CreateCustomerRequest struct {
 
 // Address represents a customer address (Spektrix format)
 type Address struct {
+	ID                     string `json:"id,omitempty"`
 	IsDelivery             bool   `json:"isDelivery"`
 	IsBilling              bool   `json:"isBilling"`
 	Country                string `json:"country"`
@@ -56,3 +58,157 @@ type APIError struct {
 func (e APIError) Error() string {
 	return e.Message
 }
+
+// PriceList represents the ticket prices available for an event instance
+type PriceList struct {
+	ID     string  `json:"id"`
+	Prices []Price `json:"prices"`
+	Fees   []Fee   `json:"fees,omitempty"`
+}
+
+// Price is a single ticket type / price band combination in a price list
+type Price struct {
+	Amount     float64       `json:"amount"`
+	IsBase     bool          `json:"isBase"`
+	PriceBand  PriceBandRef  `json:"priceBand"`
+	TicketType TicketTypeRef `json:"ticketType"`
+}
+
+// TicketTypeRef identifies a ticket type (e.g. Adult, Concession)
+type TicketTypeRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+// PriceBandRef identifies a price band (e.g. Stalls, Circle)
+type PriceBandRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+// Fee represents a booking or per-ticket fee attached to a price list
+type Fee struct {
+	ID        string  `json:"id"`
+	Name      string  `json:"name"`
+	Amount    float64 `json:"amount"`
+	PerTicket bool    `json:"perTicket"`
+}
+
+// Offer represents a discount that can be applied to tickets for an instance
+type Offer struct {
+	ID             string          `json:"id"`
+	Name           string          `json:"name"`
+	Description    string          `json:"description,omitempty"`
+	IsActive       bool            `json:"isActive"`
+	DiscountType   string          `json:"discountType"` // "Percentage" or "Fixed"
+	DiscountAmount float64         `json:"discountAmount"`
+	MinimumTickets int             `json:"minimumTickets,omitempty"`
+	TicketTypes    []TicketTypeRef `json:"ticketTypes,omitempty"` // Empty means all ticket types
+}
+
+// QuoteLineRequest asks for a quantity of one ticket type
+type QuoteLineRequest struct {
+	TicketTypeID string
+	PriceBandID  string // Optional - base price band used when empty
+	Quantity     int
+}
+
+// QuoteLine is a priced line in a quote
+type QuoteLine struct {
+	TicketType TicketTypeRef `json:"ticketType"`
+	PriceBand  PriceBandRef  `json:"priceBand"`
+	Quantity   int           `json:"quantity"`
+	UnitPrice  float64       `json:"unitPrice"`
+	Discount   float64       `json:"discount"`
+	LineTotal  float64       `json:"lineTotal"`
+}
+
+// QuoteFee is a fee charged on a quote
+type QuoteFee struct {
+	Fee    Fee     `json:"fee"`
+	Amount float64 `json:"amount"`
+}
+
+// BasketTicketRequest is the payload shape for adding tickets to a basket
+type BasketTicketRequest struct {
+	Instance   string `json:"instance"`
+	TicketType string `json:"ticketType"`
+	Band       string `json:"band,omitempty"`
+	Offer      string `json:"offer,omitempty"`
+}
+
+// Quote is a priced ticket selection for an instance
+type Quote struct {
+	InstanceID   string                `json:"instanceId"`
+	Lines        []QuoteLine           `json:"lines"`
+	Subtotal     float64               `json:"subtotal"`
+	Discount     float64               `json:"discount"`
+	AppliedOffer *Offer                `json:"appliedOffer,omitempty"`
+	Fees         []QuoteFee            `json:"fees"`
+	FeesTotal    float64               `json:"feesTotal"`
+	Total        float64               `json:"total"`
+	Tickets      []BasketTicketRequest `json:"tickets"` // Ready to pass to basket creation
+}
+
+// InstanceRef identifies an event instance (a performance)
+type InstanceRef struct {
+	ID string `json:"id"`
+}
+
+// PlanRef identifies a seating plan or one of its areas
+type PlanRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+// SeatCounts are the states of the seats in an instance or seating plan area
+type SeatCounts struct {
+	Capacity  int `json:"capacity"`
+	Available int `json:"available"`
+	Sold      int `json:"sold"`
+	Reserved  int `json:"reserved"`
+	Locked    int `json:"locked"`
+	Selected  int `json:"selected"`
+}
+
+// PlanStatus is the seat availability of one area of a seating plan
+type PlanStatus struct {
+	Plan PlanRef `json:"plan"`
+	SeatCounts
+}
+
+// InstanceStatus is the seat availability of an event instance, as returned
+// by /instances/{id}/status
+type InstanceStatus struct {
+	Instance InstanceRef `json:"instance"`
+	SeatCounts
+	// ChildPlans are the plan's areas, returned with includeChildPlans=true
+	ChildPlans []PlanStatus `json:"childPlans,omitempty"`
+}
+
+// Availability summarises seat counts for box-office use
+type Availability struct {
+	SeatCounts
+	PercentSold float64 `json:"percentSold"`
+	SoldOut     bool    `json:"soldOut"`
+}
+
+// AreaAvailability is the availability of one seating plan area
+type AreaAvailability struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Availability
+}
+
+// BandPrices are the ticket prices in one price band
+type BandPrices struct {
+	PriceBand PriceBandRef `json:"priceBand"`
+	Prices    []BandPrice  `json:"prices"`
+}
+
+// BandPrice is the price of one ticket type in a band
+type BandPrice struct {
+	TicketType TicketTypeRef `json:"ticketType"`
+	Amount     float64       `json:"amount"`
+	IsBase     bool          `json:"isBase"`
+}
@@ -0,0 +1,216 @@
+package spektrix
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// GiftVoucherType is a kind of gift voucher the venue sells. A type with
+// no Value is open-value: the buyer chooses the amount.
+type GiftVoucherType struct {
+	ID    string  `json:"id"`
+	Name  string  `json:"name"`
+	Value float64 `json:"value,omitempty"`
+}
+
+// GiftVoucher is an issued gift voucher
+type GiftVoucher struct {
+	ID         string              `json:"id"`
+	Code       string              `json:"code"`
+	Type       *GiftVoucherTypeRef `json:"type,omitempty"`
+	Value      float64             `json:"value"`
+	Balance    float64             `json:"balance"`
+	ExpiryDate string              `json:"expiryDate,omitempty"`
+	Status     string              `json:"status,omitempty"`
+	Customer   *Customer           `json:"customer,omitempty"`
+}
+
+// GiftVoucherTypeRef identifies a gift voucher type
+type GiftVoucherTypeRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+// BasketGiftVoucher is a gift voucher being bought in a basket
+type BasketGiftVoucher struct {
+	ID     string             `json:"id"`
+	Type   GiftVoucherTypeRef `json:"type"`
+	Amount float64            `json:"amount"`
+	// Code is set once the order is checked out
+	Code string `json:"code,omitempty"`
+}
+
+// GiftVoucherRequest is the payload shape for adding a gift voucher to a
+// basket
+type GiftVoucherRequest struct {
+	Type   TagReference `json:"type"`
+	Amount float64      `json:"amount"`
+}
+
+// VoucherRedemption is a gift voucher used to pay for part of a basket
+type VoucherRedemption struct {
+	Code   string  `json:"code"`
+	Amount float64 `json:"amount"`
+}
+
+// VoucherCheck is a gift voucher with whether it can be redeemed today
+type VoucherCheck struct {
+	Voucher GiftVoucher `json:"voucher"`
+	Usable  bool        `json:"usable"`
+	// Reason explains why an unusable voucher cannot be redeemed
+	Reason string `json:"reason,omitempty"`
+}
+
+// GetGiftVoucherTypes retrieves the gift voucher types on sale
+func (c *Client) GetGiftVoucherTypes() ([]GiftVoucherType, error) {
+	resp, err := c.makeRequest("GET", "/gift-voucher-types", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var types []GiftVoucherType
+	if err := c.handleResponse(resp, &types); err != nil {
+		return nil, err
+	}
+
+	return types, nil
+}
+
+// GetGiftVoucher retrieves a gift voucher by the code printed on it
+func (c *Client) GetGiftVoucher(code string) (*GiftVoucher, error) {
+	endpoint := fmt.Sprintf("/gift-vouchers/%s", url.PathEscape(code))
+
+	resp, err := c.makeRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var voucher GiftVoucher
+	if err := c.handleResponse(resp, &voucher); err != nil {
+		return nil, err
+	}
+
+	return &voucher, nil
+}
+
+// AddBasketGiftVoucher adds a gift voucher purchase to a basket and returns
+// the updated basket
+func (c *Client) AddBasketGiftVoucher(basketID string, voucher GiftVoucherRequest) (*Basket, error) {
+	endpoint := fmt.Sprintf("/baskets/%s/gift-vouchers", basketID)
+
+	resp, err := c.makeRequest("POST", endpoint, []GiftVoucherRequest{voucher})
+	if err != nil {
+		return nil, err
+	}
+
+	var basket Basket
+	if err := c.handleResponse(resp, &basket); err != nil {
+		return nil, err
+	}
+
+	return &basket, nil
+}
+
+// RedeemGiftVoucher pays for part of a basket with a gift voucher and
+// returns the updated basket
+func (c *Client) RedeemGiftVoucher(basketID string, redemption VoucherRedemption) (*Basket, error) {
+	endpoint := fmt.Sprintf("/baskets/%s/gift-voucher-redemptions", basketID)
+
+	resp, err := c.makeRequest("POST", endpoint, redemption)
+	if err != nil {
+		return nil, err
+	}
+
+	var basket Basket
+	if err := c.handleResponse(resp, &basket); err != nil {
+		return nil, err
+	}
+
+	return &basket, nil
+}
+
+// CheckVoucher reports whether a voucher can be redeemed on today's date:
+// it must have a balance, be neither cancelled nor expired
+func CheckVoucher(voucher GiftVoucher, now time.Time) VoucherCheck {
+	check := VoucherCheck{Voucher: voucher}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	switch {
+	case strings.EqualFold(voucher.Status, "cancelled"):
+		check.Reason = "the voucher has been cancelled"
+	case voucher.Balance <= 0:
+		check.Reason = "the voucher has no balance left"
+	default:
+		if expiry, ok := parseSpektrixDate(voucher.ExpiryDate); ok && expiry.Before(today) {
+			check.Reason = fmt.Sprintf("the voucher expired on %s", expiry.Format("2 January 2006"))
+		} else {
+			check.Usable = true
+		}
+	}
+	return check
+}
+
+// ResolveVoucherType finds the type named by ref, a type ID or name in any
+// case. An unknown ref is an error listing the types available.
+func ResolveVoucherType(types []GiftVoucherType, ref string) (GiftVoucherType, error) {
+	for _, voucherType := range types {
+		if voucherType.ID == ref || strings.EqualFold(voucherType.Name, ref) {
+			return voucherType, nil
+		}
+	}
+
+	names := make([]string, len(types))
+	for i, voucherType := range types {
+		names[i] = voucherType.Name
+	}
+	return GiftVoucherType{}, fmt.Errorf("unknown voucher type %s; available types are %s", ref, strings.Join(names, ", "))
+}
+
+// NewGiftVoucher builds the request for a voucher of voucherType. A
+// fixed-value type takes its own value, and amount, if given, must match
+// it; an open-value type needs an amount, rounded to whole pence.
+func NewGiftVoucher(voucherType GiftVoucherType, amount float64) (GiftVoucherRequest, error) {
+	if math.IsNaN(amount) || math.IsInf(amount, 0) || amount < 0 {
+		return GiftVoucherRequest{}, fmt.Errorf("amount must be greater than zero")
+	}
+	if voucherType.Value > 0 {
+		if amount != 0 && roundCurrency(amount) != voucherType.Value {
+			return GiftVoucherRequest{}, fmt.Errorf("%s vouchers are worth %.2f; leave amount out or use that value", voucherType.Name, voucherType.Value)
+		}
+		amount = voucherType.Value
+	}
+	if amount <= 0 {
+		return GiftVoucherRequest{}, fmt.Errorf("%s vouchers need an amount", voucherType.Name)
+	}
+	return GiftVoucherRequest{
+		Type:   TagReference{ID: voucherType.ID},
+		Amount: roundCurrency(amount),
+	}, nil
+}
+
+// RedemptionAmount is how much of a voucher to redeem against a basket:
+// the amount asked for, or by default as much of the basket as the balance
+// covers. Asking for more than the balance or the basket's total is an
+// error.
+func RedemptionAmount(voucher GiftVoucher, basket *Basket, requested float64) (float64, error) {
+	if math.IsNaN(requested) || math.IsInf(requested, 0) || requested < 0 {
+		return 0, fmt.Errorf("amount must be greater than zero")
+	}
+	if requested == 0 {
+		amount := math.Min(voucher.Balance, basket.Total)
+		if amount <= 0 {
+			return 0, fmt.Errorf("the basket has nothing left to pay")
+		}
+		return roundCurrency(amount), nil
+	}
+	requested = roundCurrency(requested)
+	if requested > voucher.Balance {
+		return 0, fmt.Errorf("the voucher's balance is %.2f, less than %.2f", voucher.Balance, requested)
+	}
+	if requested > basket.Total {
+		return 0, fmt.Errorf("the basket's total is %.2f, less than %.2f", basket.Total, requested)
+	}
+	return requested, nil
+}
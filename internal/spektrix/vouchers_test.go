@@ -0,0 +1,115 @@
+package spektrix
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestGiftVouchers(t *testing.T) {
+	t.Logf("Importance: Vouchers are money; front of house must not take an expired one, overspend one, or sell one at the wrong value.")
+
+	now := time.Date(2025, 6, 1, 15, 0, 0, 0, time.UTC)
+
+	t.Run("checks a voucher can be used", func(t *testing.T) {
+		t.Logf("  > Why it's important: A voucher expiring today is still good; spent, cancelled and expired ones are not.")
+		for _, tc := range []struct {
+			voucher GiftVoucher
+			reason  string
+		}{
+			{GiftVoucher{Balance: 10, ExpiryDate: "2025-06-01"}, ""},
+			{GiftVoucher{Balance: 10}, ""},
+			{GiftVoucher{Balance: 0, ExpiryDate: "2026-01-01"}, "no balance"},
+			{GiftVoucher{Balance: 10, Status: "Cancelled"}, "cancelled"},
+			{GiftVoucher{Balance: 10, ExpiryDate: "2025-05-31T00:00:00"}, "expired on 31 May 2025"},
+		} {
+			check := CheckVoucher(tc.voucher, now)
+			if check.Usable != (tc.reason == "") || !strings.Contains(check.Reason, tc.reason) {
+				t.Errorf("%+v: expected %q, got %+v", tc.voucher, tc.reason, check)
+			}
+		}
+	})
+
+	t.Run("values new vouchers", func(t *testing.T) {
+		t.Logf("  > Why it's important: Fixed-value vouchers sell at their price; open-value ones need an amount.")
+		fixed := GiftVoucherType{ID: "t1", Name: "Dinner and Show", Value: 60}
+		open := GiftVoucherType{ID: "t2", Name: "Gift Voucher"}
+		if req, err := NewGiftVoucher(fixed, 0); err != nil || req.Amount != 60 {
+			t.Errorf("Expected 60, got %+v, %v", req, err)
+		}
+		if _, err := NewGiftVoucher(fixed, 50); err == nil {
+			t.Error("Expected a different amount for a fixed-value voucher to be rejected")
+		}
+		if req, err := NewGiftVoucher(open, 25.004); err != nil || req.Amount != 25 || req.Type.ID != "t2" {
+			t.Errorf("Expected 25, got %+v, %v", req, err)
+		}
+		if _, err := NewGiftVoucher(open, 0); err == nil {
+			t.Error("Expected an open-value voucher without an amount to be rejected")
+		}
+		if _, err := ResolveVoucherType([]GiftVoucherType{fixed, open}, "gift card"); err == nil || !strings.Contains(err.Error(), "Dinner and Show, Gift Voucher") {
+			t.Errorf("Expected an error listing the types, got %v", err)
+		}
+	})
+
+	t.Run("redeems no more than the balance or the basket", func(t *testing.T) {
+		t.Logf("  > Why it's important: Redeeming more than is owed would leave a credit; more than the balance is a rejected payment.")
+		voucher := GiftVoucher{Balance: 30}
+		if amount, _ := RedemptionAmount(voucher, &Basket{Total: 45}, 0); amount != 30 {
+			t.Errorf("Expected the whole balance, got %v", amount)
+		}
+		if amount, _ := RedemptionAmount(voucher, &Basket{Total: 12.5}, 0); amount != 12.5 {
+			t.Errorf("Expected the basket total, got %v", amount)
+		}
+		for _, requested := range []float64{40, 20} {
+			if _, err := RedemptionAmount(voucher, &Basket{Total: 15}, requested); err == nil {
+				t.Errorf("Expected %v to be rejected", requested)
+			}
+		}
+	})
+
+	t.Run("redeems against the session basket", func(t *testing.T) {
+		t.Logf("  > Why it's important: The redemption must go to the conversation's basket, checked before any money moves.")
+		var redeemed VoucherRedemption
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.URL.Path == "/gift-vouchers/GV-1":
+				_, _ = w.Write([]byte(`{"id":"v1","code":"GV-1","value":50,"balance":30}`))
+			case r.URL.Path == "/gift-vouchers/GV-OLD":
+				_, _ = w.Write([]byte(`{"id":"v2","code":"GV-OLD","value":50,"balance":50,"expiryDate":"2020-01-01"}`))
+			case r.URL.Path == "/baskets/b1" && r.Method == "GET":
+				_, _ = w.Write([]byte(`{"id":"b1","tickets":[],"total":45}`))
+			case r.URL.Path == "/baskets/b1/gift-voucher-redemptions" && r.Method == "POST":
+				_ = json.NewDecoder(r.Body).Decode(&redeemed)
+				_, _ = w.Write([]byte(`{"id":"b1","tickets":[],"redemptions":[{"code":"GV-1","amount":30}],"total":15}`))
+			default:
+				t.Errorf("Unexpected %s %s", r.Method, r.URL.Path)
+			}
+		}))
+		defer server.Close()
+
+		h := &Handler{
+			client:   &Client{APIUser: "user", APIKey: "a2V5", BaseURL: server.URL, HTTPClient: server.Client()},
+			baskets:  newSessionValues[string](),
+			searches: newSessionValues[CustomerSearch](),
+		}
+		if result := callTool(t, h, "spektrix_redeem_voucher", map[string]interface{}{"code": "GV-1"}); !result.IsError {
+			t.Fatalf("Expected an error without a basket, got %+v", result)
+		}
+		h.baskets.set(context.Background(), "b1")
+		if result := callTool(t, h, "spektrix_redeem_voucher", map[string]interface{}{"code": "GV-OLD"}); !result.IsError {
+			t.Errorf("Expected an expired voucher to be refused, got %+v", result)
+		}
+
+		result := callTool(t, h, "spektrix_redeem_voucher", map[string]interface{}{"code": " GV-1 "})
+		text := result.Content[0].(mcp.TextContent).Text
+		if result.IsError || redeemed.Code != "GV-1" || redeemed.Amount != 30 || !strings.Contains(text, "15.00 is left to pay") {
+			t.Errorf("Expected 30 redeemed with 15 left, got %+v and %s", redeemed, text)
+		}
+	})
+}
@@ -0,0 +1,118 @@
+package tooldocs
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// statsWindow is how many recent calls per tool latency figures cover
+const statsWindow = 100
+
+// Latency summarises a tool's recent calls
+type Latency struct {
+	// Calls and Errors count every call since startup
+	Calls  int64 `json:"calls"`
+	Errors int64 `json:"errors"`
+	// Window is how many recent calls the percentiles cover
+	Window int        `json:"window"`
+	P50Ms  float64    `json:"p50_ms"`
+	P95Ms  float64    `json:"p95_ms"`
+	MaxMs  float64    `json:"max_ms"`
+	LastAt *time.Time `json:"last_called_at,omitempty"`
+}
+
+// Stats records how long each tool's calls take
+type Stats struct {
+	mu    sync.Mutex
+	tools map[string]*toolStats
+}
+
+type toolStats struct {
+	calls, errors int64
+	recent        []time.Duration
+	next          int
+	lastAt        time.Time
+}
+
+// NewStats creates an empty latency recorder
+func NewStats() *Stats {
+	return &Stats{tools: make(map[string]*toolStats)}
+}
+
+// Middleware times every tool call. Register it early so the figures
+// include the other middleware.
+func (s *Stats) Middleware() server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			start := time.Now()
+			result, err := next(ctx, request)
+			s.record(request.Params.Name, time.Since(start), err != nil || (result != nil && result.IsError))
+			return result, err
+		}
+	}
+}
+
+func (s *Stats) record(tool string, d time.Duration, failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats, ok := s.tools[tool]
+	if !ok {
+		stats = &toolStats{}
+		s.tools[tool] = stats
+	}
+	stats.calls++
+	if failed {
+		stats.errors++
+	}
+	if len(stats.recent) < statsWindow {
+		stats.recent = append(stats.recent, d)
+	} else {
+		stats.recent[stats.next] = d
+		stats.next = (stats.next + 1) % statsWindow
+	}
+	stats.lastAt = time.Now()
+}
+
+// Latency returns the tool's figures, and false when it has not been called
+func (s *Stats) Latency(tool string) (Latency, bool) {
+	if s == nil {
+		return Latency{}, false
+	}
+	s.mu.Lock()
+	stats, ok := s.tools[tool]
+	if !ok {
+		s.mu.Unlock()
+		return Latency{}, false
+	}
+	recent := append([]time.Duration(nil), stats.recent...)
+	lastAt := stats.lastAt
+	latency := Latency{Calls: stats.calls, Errors: stats.errors, Window: len(recent), LastAt: &lastAt}
+	s.mu.Unlock()
+
+	sort.Slice(recent, func(i, j int) bool { return recent[i] < recent[j] })
+	latency.P50Ms = millis(percentile(recent, 0.50))
+	latency.P95Ms = millis(percentile(recent, 0.95))
+	latency.MaxMs = millis(recent[len(recent)-1])
+	return latency, true
+}
+
+// percentile picks the nearest-rank value from sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(p*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,205 @@
+// Package tooldocs serves a documentation resource for every registered tool,
+// docs://tools/{name}, so clients can learn how to call a tool without
+// external docs. Each document holds the tool's input schema and
+// annotations as tools/list reports them, the permission descriptor and group
+// from the adapter manifest, curated examples and output schemas from the
+// adapter's Registry, and latency figures for recent calls.
+package tooldocs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/vcto/mcp-adapters/internal/manifest"
+)
+
+// URITemplate is the resource template for one tool's documentation
+const URITemplate = "docs://tools/{name}"
+
+// IndexURI is the resource listing every documented tool
+const IndexURI = "docs://tools"
+
+const uriPrefix = IndexURI + "/"
+
+// Example is a sample call of a tool
+type Example struct {
+	Description string                 `json:"description"`
+	Arguments   map[string]interface{} `json:"arguments"`
+}
+
+// Doc is the curated documentation for one tool
+type Doc struct {
+	Examples []Example
+	// OutputSchema is a JSON Schema for the JSON text the tool returns; mcp-go
+	// tools cannot declare one themselves
+	OutputSchema map[string]interface{}
+}
+
+// Registry maps tool names to curated documentation
+type Registry map[string]Doc
+
+// Merge combines the registries of several adapters
+func Merge(registries ...Registry) Registry {
+	merged := Registry{}
+	for _, registry := range registries {
+		for name, doc := range registry {
+			merged[name] = doc
+		}
+	}
+	return merged
+}
+
+// ToolDoc is the docs://tools/{name} resource
+type ToolDoc struct {
+	Name         string                 `json:"name"`
+	URI          string                 `json:"uri"`
+	Description  string                 `json:"description"`
+	Group        string                 `json:"group,omitempty"`
+	InputSchema  json.RawMessage        `json:"input_schema"`
+	OutputSchema map[string]interface{} `json:"output_schema,omitempty"`
+	Annotations  json.RawMessage        `json:"annotations,omitempty"`
+	Permission   *manifest.Permission   `json:"permission,omitempty"`
+	Examples     []Example              `json:"examples"`
+	Latency      *Latency               `json:"latency,omitempty"`
+}
+
+// URI returns the documentation resource URI for a tool
+func URI(tool string) string {
+	return uriPrefix + tool
+}
+
+// Setup registers docs://tools and docs://tools/{name}. Documents are built
+// when read, so they cover every tool the server lists at that moment.
+func Setup(s *server.MCPServer, stats *Stats, docs Registry, manifests ...*manifest.Manifest) {
+	s.AddResource(mcp.NewResource(IndexURI,
+		"Tool Documentation Index",
+		mcp.WithResourceDescription("Every tool with a one-line summary and the docs://tools/{name} resource documenting it."),
+		mcp.WithMIMEType("application/json"),
+	), func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		tools, err := listTools(ctx, s)
+		if err != nil {
+			return nil, err
+		}
+		index := make([]map[string]string, 0, len(tools))
+		for _, tool := range tools {
+			index = append(index, map[string]string{"name": tool.Name, "uri": URI(tool.Name), "summary": summary(tool.Description)})
+		}
+		return jsonContents(IndexURI, map[string]interface{}{"tools": index, "count": len(index)})
+	})
+
+	s.AddResourceTemplate(mcp.NewResourceTemplate(URITemplate,
+		"Tool Documentation",
+		mcp.WithTemplateDescription("One tool's input and output schemas, annotations, permissions, examples and recent latency. List names with docs://tools."),
+		mcp.WithTemplateMIMEType("application/json"),
+	), func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		name, ok := strings.CutPrefix(request.Params.URI, uriPrefix)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid tool documentation URI %q", request.Params.URI)
+		}
+		tools, err := listTools(ctx, s)
+		if err != nil {
+			return nil, err
+		}
+		for _, tool := range tools {
+			if tool.Name == name {
+				return jsonContents(request.Params.URI, document(tool, stats, docs, manifests))
+			}
+		}
+		return nil, fmt.Errorf("unknown tool %q; read %s for the list", name, IndexURI)
+	})
+}
+
+// listedTool is a tool as tools/list reports it
+type listedTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	InputSchema json.RawMessage `json:"inputSchema"`
+	Annotations json.RawMessage `json:"annotations"`
+}
+
+func document(tool listedTool, stats *Stats, docs Registry, manifests []*manifest.Manifest) ToolDoc {
+	doc := ToolDoc{
+		Name:        tool.Name,
+		URI:         URI(tool.Name),
+		Description: tool.Description,
+		InputSchema: tool.InputSchema,
+		Annotations: tool.Annotations,
+		Examples:    []Example{},
+	}
+	if curated, ok := docs[tool.Name]; ok {
+		doc.OutputSchema = curated.OutputSchema
+		if curated.Examples != nil {
+			doc.Examples = curated.Examples
+		}
+	}
+	for _, m := range manifests {
+		if _, ok := m.Tools[tool.Name]; ok {
+			permission := m.Permissions()[tool.Name]
+			doc.Permission = &permission
+			doc.Group = m.GroupOf(tool.Name)
+			break
+		}
+	}
+	if latency, ok := stats.Latency(tool.Name); ok {
+		doc.Latency = &latency
+	}
+	return doc
+}
+
+// listTools asks the server for tools/list, as a client would see it
+func listTools(ctx context.Context, s *server.MCPServer) ([]listedTool, error) {
+	request, err := json.Marshal(map[string]interface{}{"jsonrpc": mcp.JSONRPC_VERSION, "id": 1, "method": mcp.MethodToolsList})
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(s.HandleMessage(ctx, request))
+	if err != nil {
+		return nil, fmt.Errorf("encoding tools/list response: %w", err)
+	}
+
+	var response struct {
+		Result struct {
+			Tools []listedTool `json:"tools"`
+		} `json:"result"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("decoding tools/list response: %w", err)
+	}
+	if response.Error != nil {
+		return nil, fmt.Errorf("listing tools: %s", response.Error.Message)
+	}
+	tools := response.Result.Tools
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name < tools[j].Name })
+	return tools, nil
+}
+
+// summary returns the first sentence of a description
+func summary(description string) string {
+	if i := strings.Index(description, ". "); i >= 0 {
+		return description[:i+1]
+	}
+	return description
+}
+
+func jsonContents(uri string, v interface{}) ([]mcp.ResourceContents, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      uri,
+			MIMEType: "application/json",
+			Text:     string(data),
+		},
+	}, nil
+}
@@ -0,0 +1,165 @@
+package tooldocs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/vcto/mcp-adapters/internal/manifest"
+)
+
+// read sends resources/read to the server and returns the text or the error
+func read(t *testing.T, s *server.MCPServer, uri string) (string, string) {
+	t.Helper()
+	request, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0", "id": 1, "method": "resources/read",
+		"params": map[string]interface{}{"uri": uri},
+	})
+	data, _ := json.Marshal(s.HandleMessage(context.Background(), request))
+	var response struct {
+		Result struct {
+			Contents []struct {
+				Text string `json:"text"`
+			} `json:"contents"`
+		} `json:"result"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Error != nil {
+		return "", response.Error.Message
+	}
+	if len(response.Result.Contents) != 1 {
+		t.Fatalf("Expected one content, got %s", data)
+	}
+	return response.Result.Contents[0].Text, ""
+}
+
+func TestToolDocs(t *testing.T) {
+	t.Logf("Importance: Clients learn how to call a tool from its docs resource instead of external documentation.")
+
+	stats := NewStats()
+	s := server.NewMCPServer("test", "1.0.0",
+		server.WithResourceCapabilities(true, true),
+		server.WithToolHandlerMiddleware(stats.Middleware()),
+	)
+	s.AddTool(mcp.NewTool("echo",
+		mcp.WithDescription("Echo a message. Returns it unchanged."),
+		mcp.WithString("message", mcp.Required(), mcp.Description("Text to echo")),
+		mcp.WithReadOnlyHintAnnotation(true),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ok"), nil
+	})
+
+	m := &manifest.Manifest{Adapter: "test", Tools: map[string]manifest.ToolAccess{"echo": {}}}
+	Setup(s, stats, Registry{"echo": {
+		Examples:     []Example{{Description: "Say hello", Arguments: map[string]interface{}{"message": "hello"}}},
+		OutputSchema: map[string]interface{}{"type": "string"},
+	}}, m)
+
+	text, errMsg := read(t, s, URI("echo"))
+	if errMsg != "" {
+		t.Fatalf("Expected the echo docs, got %s", errMsg)
+	}
+	var doc ToolDoc
+	if err := json.Unmarshal([]byte(text), &doc); err != nil {
+		t.Fatalf("Failed to decode docs: %v", err)
+	}
+	var schema struct {
+		Required []string `json:"required"`
+	}
+	_ = json.Unmarshal(doc.InputSchema, &schema)
+	if len(schema.Required) != 1 || schema.Required[0] != "message" {
+		t.Errorf("Expected the input schema from tools/list, got %s", doc.InputSchema)
+	}
+	if len(doc.Examples) != 1 || doc.OutputSchema["type"] != "string" || len(doc.Annotations) == 0 {
+		t.Errorf("Expected examples, output schema and annotations, got %+v", doc)
+	}
+	if doc.Permission == nil || doc.Permission.Adapter != "test" {
+		t.Errorf("Expected the manifest permission, got %+v", doc.Permission)
+	}
+	if doc.Latency != nil {
+		t.Errorf("Expected no latency before any call, got %+v", doc.Latency)
+	}
+
+	t.Run("latency after calls", func(t *testing.T) {
+		t.Logf("  > Why it's important: Recent latency tells clients whether a tool is slow enough to need progress or a longer deadline.")
+		call, _ := json.Marshal(map[string]interface{}{
+			"jsonrpc": "2.0", "id": 2, "method": "tools/call",
+			"params": map[string]interface{}{"name": "echo", "arguments": map[string]interface{}{"message": "hi"}},
+		})
+		s.HandleMessage(context.Background(), call)
+
+		text, _ := read(t, s, URI("echo"))
+		var doc ToolDoc
+		_ = json.Unmarshal([]byte(text), &doc)
+		if doc.Latency == nil || doc.Latency.Calls != 1 || doc.Latency.Errors != 0 {
+			t.Errorf("Expected one successful call, got %+v", doc.Latency)
+		}
+	})
+
+	t.Run("index and unknown tools", func(t *testing.T) {
+		t.Logf("  > Why it's important: Clients find the docs through the index and get a pointer back to it for a bad name.")
+		text, _ := read(t, s, IndexURI)
+		var index struct {
+			Tools []map[string]string `json:"tools"`
+		}
+		_ = json.Unmarshal([]byte(text), &index)
+		if len(index.Tools) != 1 || index.Tools[0]["summary"] != "Echo a message." || index.Tools[0]["uri"] != "docs://tools/echo" {
+			t.Errorf("Expected echo in the index, got %s", text)
+		}
+		if _, errMsg := read(t, s, URI("missing")); errMsg == "" {
+			t.Error("Expected an error for an unknown tool")
+		}
+	})
+}
+
+func TestStats(t *testing.T) {
+	t.Logf("Importance: Latency figures must reflect recent calls and count failures.")
+	stats := NewStats()
+	for i := 1; i <= 150; i++ {
+		stats.record("tool", time.Duration(i)*time.Millisecond, i%50 == 0)
+	}
+
+	latency, ok := stats.Latency("tool")
+	if !ok {
+		t.Fatal("Expected figures for a called tool")
+	}
+	// The window holds calls 51-150
+	if latency.Calls != 150 || latency.Errors != 3 || latency.Window != statsWindow {
+		t.Errorf("Expected 150 calls, 3 errors over a window of %d, got %+v", statsWindow, latency)
+	}
+	if latency.P50Ms != 100 || latency.P95Ms != 145 || latency.MaxMs != 150 {
+		t.Errorf("Expected p50 100ms, p95 145ms, max 150ms, got %+v", latency)
+	}
+
+	t.Run("tool errors count", func(t *testing.T) {
+		t.Logf("  > Why it's important: Most failures are error results, not Go errors.")
+		handler := stats.Middleware()(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if request.Params.Name == "broken" {
+				return nil, errors.New("boom")
+			}
+			return mcp.NewToolResultError("bad input"), nil
+		})
+		for _, name := range []string{"failing", "broken"} {
+			request := mcp.CallToolRequest{}
+			request.Params.Name = name
+			_, _ = handler(context.Background(), request)
+			if latency, _ := stats.Latency(name); latency.Errors != 1 {
+				t.Errorf("Expected %s to count an error, got %+v", name, latency)
+			}
+		}
+	})
+
+	if _, ok := (*Stats)(nil).Latency("tool"); ok {
+		t.Error("Expected no figures from nil stats")
+	}
+}
@@ -0,0 +1,168 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// placeholder matches {{path}} and {{path || fallback}} in templates
+var placeholder = regexp.MustCompile(`\{\{\s*([^}|]+?)\s*(?:\|\|\s*([^}]*?)\s*)?\}\}`)
+
+// Render fills the placeholders in a template value from payload. Strings
+// may hold {{path}} references, where path is a dot-separated walk through
+// the payload with numeric segments indexing arrays (issue.labels.0.name),
+// and {{path || fallback}} supplies text for a missing or empty value. A
+// string that is exactly one placeholder keeps the value's JSON type. Maps
+// and arrays are rendered recursively; other values pass through.
+func Render(template interface{}, payload interface{}) (interface{}, error) {
+	switch t := template.(type) {
+	case string:
+		return renderString(t, payload)
+	case map[string]interface{}:
+		rendered := make(map[string]interface{}, len(t))
+		for key, value := range t {
+			v, err := Render(value, payload)
+			if err != nil {
+				return nil, err
+			}
+			rendered[key] = v
+		}
+		return rendered, nil
+	case []interface{}:
+		rendered := make([]interface{}, len(t))
+		for i, value := range t {
+			v, err := Render(value, payload)
+			if err != nil {
+				return nil, err
+			}
+			rendered[i] = v
+		}
+		return rendered, nil
+	default:
+		return template, nil
+	}
+}
+
+func renderString(template string, payload interface{}) (interface{}, error) {
+	// A lone placeholder keeps numbers, booleans and objects intact
+	if m := placeholder.FindStringSubmatchIndex(template); m != nil && m[0] == 0 && m[1] == len(template) {
+		path, fallback, hasFallback := placeholderParts(template, m)
+		value, ok := Lookup(payload, path)
+		if ok && !isEmpty(value) {
+			return value, nil
+		}
+		if hasFallback {
+			return fallback, nil
+		}
+		return nil, fmt.Errorf("payload has no value at %q", path)
+	}
+
+	var renderErr error
+	rendered := placeholder.ReplaceAllStringFunc(template, func(match string) string {
+		m := placeholder.FindStringSubmatchIndex(match)
+		path, fallback, hasFallback := placeholderParts(match, m)
+		value, ok := Lookup(payload, path)
+		if ok && !isEmpty(value) {
+			return stringify(value)
+		}
+		if !hasFallback && renderErr == nil {
+			renderErr = fmt.Errorf("payload has no value at %q", path)
+		}
+		return fallback
+	})
+	if renderErr != nil {
+		return nil, renderErr
+	}
+	return rendered, nil
+}
+
+func placeholderParts(s string, m []int) (path, fallback string, hasFallback bool) {
+	path = s[m[2]:m[3]]
+	if m[4] >= 0 {
+		return path, s[m[4]:m[5]], true
+	}
+	return path, "", false
+}
+
+// Lookup walks a dot-separated path through decoded JSON
+func Lookup(payload interface{}, path string) (interface{}, bool) {
+	current := payload
+	for _, segment := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[segment]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []interface{}:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			current = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// Matches reports whether a delivery satisfies every condition in match.
+// Keys are payload paths, or header:Name for request headers. Values list
+// accepted alternatives separated by "|"; "*" accepts any non-empty value.
+func Matches(match map[string]string, payload interface{}, header http.Header) bool {
+	for key, want := range match {
+		var got string
+		if name, ok := strings.CutPrefix(key, "header:"); ok {
+			got = header.Get(name)
+		} else if value, ok := Lookup(payload, key); ok && !isEmpty(value) {
+			got = stringify(value)
+		}
+
+		if want == "*" {
+			if got == "" {
+				return false
+			}
+			continue
+		}
+		accepted := false
+		for _, alternative := range strings.Split(want, "|") {
+			if got == strings.TrimSpace(alternative) {
+				accepted = true
+				break
+			}
+		}
+		if !accepted {
+			return false
+		}
+	}
+	return true
+}
+
+func isEmpty(value interface{}) bool {
+	return value == nil || value == ""
+}
+
+// stringify renders a JSON value as text, writing numbers without an
+// exponent and objects as JSON
+func stringify(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	default:
+		return fmt.Sprint(v)
+	}
+}
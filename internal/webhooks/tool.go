@@ -0,0 +1,46 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// AuditToolName is the admin tool listing recent webhook deliveries
+const AuditToolName = "webhook_audit"
+
+// SetupAuditTool registers the webhook_audit admin tool for the registry
+func SetupAuditTool(s *server.MCPServer, r *Registry) {
+	s.AddTool(mcp.NewTool(AuditToolName,
+		mcp.WithDescription("Admin report of recent inbound webhook deliveries: whether each was applied, ignored, failed or rejected, and which tools or resources it triggered."),
+		mcp.WithString("hook", mcp.Description("Limit the report to one webhook name")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		names := r.Names()
+		if hook := request.GetString("hook", ""); hook != "" {
+			if _, ok := r.hook(hook); !ok {
+				return mcp.NewToolResultError(fmt.Sprintf("Unknown webhook %q. Configured: %s", hook, strings.Join(names, ", "))), nil
+			}
+			names = []string{hook}
+		}
+
+		hooks := make(map[string][]Delivery, len(names))
+		for _, name := range names {
+			hooks[name], _ = r.Audit(name)
+		}
+
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"hooks":        hooks,
+			"generated_at": time.Now().UTC(),
+		}, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError("Failed to format webhook audit"), nil
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	})
+}
@@ -0,0 +1,435 @@
+// Package webhooks receives inbound webhooks from other services and turns
+// them into tool calls or resource updates. Operators define named hooks in
+// a JSON file; each is served at /hooks/{name}, verified with a shared
+// secret, and keeps an audit log of its recent deliveries.
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// PathPrefix is where hooks are served
+const PathPrefix = "/hooks/"
+
+// Verification schemes
+const (
+	// VerifyHMAC expects a hex HMAC-SHA256 of the body, optionally prefixed
+	// "sha256=" as GitHub sends it
+	VerifyHMAC = "hmac-sha256"
+	// VerifyToken expects the secret itself in a header
+	VerifyToken = "token"
+)
+
+// Limits
+const (
+	maxBodyBytes    = 1 << 20
+	maxAuditEntries = 100
+	// callTimeout bounds the tool calls one delivery may make
+	callTimeout = 30 * time.Second
+)
+
+// Config is the file named by WEBHOOKS_CONFIG
+type Config struct {
+	Hooks []HookConfig `json:"hooks"`
+}
+
+// HookConfig defines one named webhook
+type HookConfig struct {
+	Name string `json:"name"`
+	// SecretEnv names the environment variable holding the shared secret,
+	// so secrets stay out of the config file
+	SecretEnv string `json:"secret_env"`
+	// Verify is VerifyHMAC (default) or VerifyToken
+	Verify string `json:"verify,omitempty"`
+	// Header carries the signature or token. Defaults to X-Hub-Signature-256
+	// for HMAC and X-Webhook-Token for tokens.
+	Header string `json:"header,omitempty"`
+	// Rules are applied in order; every rule whose Match holds runs
+	Rules []Rule `json:"rules"`
+}
+
+// Rule maps matching deliveries to a tool call or resource update
+type Rule struct {
+	// Match conditions, see Matches
+	Match map[string]string `json:"match,omitempty"`
+	// Tool is called with Arguments rendered from the payload, see Render
+	Tool      string                 `json:"tool,omitempty"`
+	Arguments map[string]interface{} `json:"arguments,omitempty"`
+	// Resource is a resource URI reported to subscribers as updated
+	Resource string `json:"resource,omitempty"`
+}
+
+// Delivery is the audit entry for one request to a hook
+type Delivery struct {
+	Time       time.Time `json:"time"`
+	Hook       string    `json:"hook"`
+	DeliveryID string    `json:"delivery_id,omitempty"`
+	Status     int       `json:"status"`
+	// Outcome is "applied", "ignored", "failed" or "rejected"
+	Outcome string   `json:"outcome"`
+	Actions []string `json:"actions,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// deliveryHeaders carry the sender's delivery ID, for matching audit
+// entries with the sender's own logs
+var deliveryHeaders = []string{"X-GitHub-Delivery", "X-Request-ID", "X-Webhook-ID"}
+
+// hook is a configured webhook with its secret and audit log
+type hook struct {
+	HookConfig
+	secret []byte
+
+	mu    sync.Mutex
+	audit []Delivery
+}
+
+// Registry serves the configured hooks
+type Registry struct {
+	server *server.MCPServer
+	// path is the config file, for Reload; empty when built with New
+	path string
+
+	mu    sync.RWMutex
+	hooks map[string]*hook
+}
+
+// LoadFromEnv reads hooks from the file named by WEBHOOKS_CONFIG. It returns
+// nil when the variable is unset, so no hooks are served.
+func LoadFromEnv(s *server.MCPServer) (*Registry, error) {
+	path := os.Getenv("WEBHOOKS_CONFIG")
+	if path == "" {
+		return nil, nil
+	}
+
+	config, err := readConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := New(s, config)
+	if err != nil {
+		return nil, err
+	}
+	r.path = path
+	return r, nil
+}
+
+func readConfig(path string) (Config, error) {
+	var config Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, fmt.Errorf("reading WEBHOOKS_CONFIG: %w", err)
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("parsing WEBHOOKS_CONFIG %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// Reload re-reads the config file and replaces the served hooks, so hooks
+// and secrets can change without a restart. Hooks that keep their name keep
+// their audit log. On error the current hooks stay in place.
+func (r *Registry) Reload() error {
+	if r.path == "" {
+		return fmt.Errorf("webhooks were not loaded from WEBHOOKS_CONFIG")
+	}
+	config, err := readConfig(r.path)
+	if err != nil {
+		return err
+	}
+	next, err := New(r.server, config)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for name, h := range next.hooks {
+		if previous, ok := r.hooks[name]; ok {
+			previous.mu.Lock()
+			h.audit = previous.audit
+			previous.mu.Unlock()
+		}
+	}
+	r.hooks = next.hooks
+	log.Printf("Webhooks: Reloaded %d hooks from %s", len(r.hooks), r.path)
+	return nil
+}
+
+// New validates config and creates a registry calling tools on s
+func New(s *server.MCPServer, config Config) (*Registry, error) {
+	r := &Registry{server: s, hooks: make(map[string]*hook)}
+	for _, hc := range config.Hooks {
+		if hc.Name == "" || strings.ContainsAny(hc.Name, "/?#") {
+			return nil, fmt.Errorf("webhook name %q must be non-empty and contain no '/', '?' or '#'", hc.Name)
+		}
+		if _, exists := r.hooks[hc.Name]; exists {
+			return nil, fmt.Errorf("webhook %q is defined twice", hc.Name)
+		}
+
+		switch hc.Verify {
+		case "", VerifyHMAC:
+			hc.Verify = VerifyHMAC
+			if hc.Header == "" {
+				hc.Header = "X-Hub-Signature-256"
+			}
+		case VerifyToken:
+			if hc.Header == "" {
+				hc.Header = "X-Webhook-Token"
+			}
+		default:
+			return nil, fmt.Errorf("webhook %q: unknown verify %q, use %s or %s", hc.Name, hc.Verify, VerifyHMAC, VerifyToken)
+		}
+
+		if hc.SecretEnv == "" {
+			return nil, fmt.Errorf("webhook %q: secret_env is required", hc.Name)
+		}
+		secret := os.Getenv(hc.SecretEnv)
+		if secret == "" {
+			return nil, fmt.Errorf("webhook %q: %s is not set", hc.Name, hc.SecretEnv)
+		}
+
+		if len(hc.Rules) == 0 {
+			return nil, fmt.Errorf("webhook %q has no rules", hc.Name)
+		}
+		for i, rule := range hc.Rules {
+			if (rule.Tool == "") == (rule.Resource == "") {
+				return nil, fmt.Errorf("webhook %q rule %d must set exactly one of tool or resource", hc.Name, i+1)
+			}
+		}
+
+		r.hooks[hc.Name] = &hook{HookConfig: hc, secret: []byte(secret)}
+	}
+	return r, nil
+}
+
+// Names lists the configured hooks
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.hooks))
+	for name := range r.hooks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Audit returns a hook's recent deliveries, oldest first
+func (r *Registry) Audit(name string) ([]Delivery, bool) {
+	h, ok := r.hook(name)
+	if !ok {
+		return nil, false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]Delivery{}, h.audit...), true
+}
+
+// ServeHTTP handles POST /hooks/{name}
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	name := strings.TrimPrefix(req.URL.Path, PathPrefix)
+	h, ok := r.hook(name)
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+
+	delivery := Delivery{Time: time.Now().UTC(), Hook: name}
+	for _, header := range deliveryHeaders {
+		if id := req.Header.Get(header); id != "" {
+			delivery.DeliveryID = id
+			break
+		}
+	}
+	respond := func(status int, outcome, message string) {
+		delivery.Status = status
+		delivery.Outcome = outcome
+		if status >= http.StatusBadRequest {
+			delivery.Error = message
+		}
+		h.record(delivery)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{
+			"outcome": outcome,
+			"actions": delivery.Actions,
+			"message": message,
+		}); err != nil {
+			log.Printf("Webhooks: failed to write response: %v", err)
+		}
+	}
+
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		respond(http.StatusMethodNotAllowed, "rejected", "webhooks must be POSTed")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, maxBodyBytes))
+	if err != nil {
+		respond(http.StatusRequestEntityTooLarge, "rejected", "body too large")
+		return
+	}
+	if !h.verify(req.Header.Get(h.Header), body) {
+		respond(http.StatusUnauthorized, "rejected", "signature verification failed")
+		return
+	}
+
+	var payload interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		respond(http.StatusBadRequest, "rejected", "body is not JSON")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), callTimeout)
+	defer cancel()
+
+	var failures []string
+	for i, rule := range h.Rules {
+		if !Matches(rule.Match, payload, req.Header) {
+			continue
+		}
+		action, err := r.apply(ctx, rule, payload)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("rule %d: %v", i+1, err))
+			continue
+		}
+		delivery.Actions = append(delivery.Actions, action)
+	}
+
+	switch {
+	case len(failures) > 0:
+		respond(http.StatusBadGateway, "failed", strings.Join(failures, "; "))
+	case len(delivery.Actions) == 0:
+		respond(http.StatusOK, "ignored", "no rule matched")
+	default:
+		respond(http.StatusOK, "applied", "")
+	}
+}
+
+func (r *Registry) hook(name string) (*hook, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	h, ok := r.hooks[name]
+	return h, ok
+}
+
+// apply runs one matching rule and describes what it did
+func (r *Registry) apply(ctx context.Context, rule Rule, payload interface{}) (string, error) {
+	if rule.Resource != "" {
+		uri, err := renderString(rule.Resource, payload)
+		if err != nil {
+			return "", err
+		}
+		r.server.SendNotificationToAllClients(mcp.MethodNotificationResourceUpdated, map[string]any{"uri": uri})
+		return fmt.Sprintf("resource %v", uri), nil
+	}
+
+	arguments, err := Render(rule.Arguments, payload)
+	if err != nil {
+		return "", err
+	}
+	if arguments == nil {
+		arguments = map[string]interface{}{}
+	}
+	result, err := callTool(ctx, r.server, rule.Tool, arguments)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", rule.Tool, err)
+	}
+	if result.IsError {
+		return "", fmt.Errorf("%s: %s", rule.Tool, resultText(result))
+	}
+	return "tool " + rule.Tool, nil
+}
+
+// verify checks the delivery's signature or token against the secret
+func (h *hook) verify(value string, body []byte) bool {
+	if value == "" {
+		return false
+	}
+	if h.Verify == VerifyToken {
+		return subtle.ConstantTimeCompare([]byte(value), h.secret) == 1
+	}
+
+	signature, err := hex.DecodeString(strings.TrimPrefix(value, "sha256="))
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, h.secret)
+	mac.Write(body)
+	return hmac.Equal(signature, mac.Sum(nil))
+}
+
+// record keeps a delivery in the hook's audit log and writes an audit line
+func (h *hook) record(delivery Delivery) {
+	h.mu.Lock()
+	h.audit = append(h.audit, delivery)
+	if len(h.audit) > maxAuditEntries {
+		h.audit = h.audit[len(h.audit)-maxAuditEntries:]
+	}
+	h.mu.Unlock()
+
+	if data, err := json.Marshal(delivery); err == nil {
+		log.Printf("[AUDIT] webhook %s", data)
+	}
+}
+
+// callTool runs a tool through the server so middleware and hooks still apply
+func callTool(ctx context.Context, s *server.MCPServer, name string, arguments interface{}) (*mcp.CallToolResult, error) {
+	message, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": mcp.JSONRPC_VERSION,
+		"id":      "webhook",
+		"method":  mcp.MethodToolsCall,
+		"params":  map[string]interface{}{"name": name, "arguments": arguments},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(s.HandleMessage(ctx, message))
+	if err != nil {
+		return nil, err
+	}
+	var response struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, err
+	}
+	if response.Error != nil {
+		return nil, fmt.Errorf("%s", response.Error.Message)
+	}
+	return mcp.ParseCallToolResult(&response.Result)
+}
+
+func resultText(result *mcp.CallToolResult) string {
+	var parts []string
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			parts = append(parts, text.Text)
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,332 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const issuePayload = `{
+	"action": "opened",
+	"issue": {"number": 42, "title": "Login fails", "html_url": "https://github.com/acme/app/issues/42", "labels": [{"name": "bug"}]},
+	"repository": {"full_name": "acme/app"}
+}`
+
+func TestRender(t *testing.T) {
+	t.Logf("Importance: The mapping DSL decides what every webhook-created task contains; a wrong lookup silently creates garbage tasks.")
+
+	var payload interface{}
+	if err := json.Unmarshal([]byte(issuePayload), &payload); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		template interface{}
+		want     interface{}
+	}{
+		{"text", "Fix {{issue.title}} #{{repository.full_name}}", "Fix Login fails #acme/app"},
+		{"array index", "{{issue.labels.0.name}}", "bug"},
+		{"lone placeholder keeps type", "{{issue.number}}", float64(42)},
+		{"number in text", "Issue {{issue.number}}", "Issue 42"},
+		{"fallback", "{{issue.assignee.login || nobody}}", "nobody"},
+		{"nested", map[string]interface{}{"a": []interface{}{"{{action}}"}}, map[string]interface{}{"a": []interface{}{"opened"}}},
+		{"non-string", true, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Render(tc.template, payload)
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			gotJSON, _ := json.Marshal(got)
+			wantJSON, _ := json.Marshal(tc.want)
+			if string(gotJSON) != string(wantJSON) {
+				t.Errorf("Expected %s, got %s", wantJSON, gotJSON)
+			}
+		})
+	}
+
+	t.Run("missing value without fallback", func(t *testing.T) {
+		t.Logf("  > Why it's important: A payload that lacks a mapped field should fail the rule rather than create a task with a blank in it.")
+		if _, err := Render("{{issue.milestone.title}}", payload); err == nil {
+			t.Error("Expected error for missing path")
+		}
+	})
+}
+
+func TestMatches(t *testing.T) {
+	t.Logf("Importance: Match rules keep unrelated events, such as closed issues or pushes, from triggering tool calls.")
+
+	var payload interface{}
+	if err := json.Unmarshal([]byte(issuePayload), &payload); err != nil {
+		t.Fatal(err)
+	}
+	header := http.Header{}
+	header.Set("X-GitHub-Event", "issues")
+
+	cases := []struct {
+		name  string
+		match map[string]string
+		want  bool
+	}{
+		{"no conditions", nil, true},
+		{"equal", map[string]string{"action": "opened"}, true},
+		{"alternatives", map[string]string{"action": "reopened|opened"}, true},
+		{"mismatch", map[string]string{"action": "closed"}, false},
+		{"present", map[string]string{"issue.title": "*"}, true},
+		{"absent", map[string]string{"issue.assignee": "*"}, false},
+		{"header", map[string]string{"header:X-GitHub-Event": "issues", "action": "opened"}, true},
+		{"header mismatch", map[string]string{"header:X-GitHub-Event": "push"}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Matches(tc.match, payload, header); got != tc.want {
+				t.Errorf("Expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+// newTestRegistry serves one HMAC hook creating tasks from GitHub issues
+// and one token hook refreshing a resource, over a server whose tool
+// records its arguments
+func newTestRegistry(t *testing.T) (*Registry, func() []map[string]interface{}) {
+	t.Helper()
+	t.Setenv("TEST_GITHUB_SECRET", "s3cret")
+	t.Setenv("TEST_TOKEN_SECRET", "t0ken")
+
+	var mu sync.Mutex
+	var calls []map[string]interface{}
+	s := server.NewMCPServer("test", "1.0.0", server.WithToolCapabilities(false))
+	s.AddTool(mcp.NewTool("rtm_quick_add", mcp.WithString("task")), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, _ := request.Params.Arguments.(map[string]interface{})
+		if args["task"] == "fail" {
+			return mcp.NewToolResultError("RTM unavailable"), nil
+		}
+		mu.Lock()
+		calls = append(calls, args)
+		mu.Unlock()
+		return mcp.NewToolResultText("added"), nil
+	})
+
+	r, err := New(s, Config{Hooks: []HookConfig{
+		{
+			Name:      "github",
+			SecretEnv: "TEST_GITHUB_SECRET",
+			Rules: []Rule{{
+				Match:     map[string]string{"header:X-GitHub-Event": "issues", "action": "opened"},
+				Tool:      "rtm_quick_add",
+				Arguments: map[string]interface{}{"task": "{{issue.title}} #github {{issue.html_url}}"},
+			}},
+		},
+		{
+			Name:      "refresh",
+			SecretEnv: "TEST_TOKEN_SECRET",
+			Verify:    VerifyToken,
+			Rules: []Rule{
+				{Resource: "rtm://today"},
+				{Match: map[string]string{"task": "*"}, Tool: "rtm_quick_add", Arguments: map[string]interface{}{"task": "{{task}}"}},
+			},
+		},
+	}})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return r, func() []map[string]interface{} {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]map[string]interface{}{}, calls...)
+	}
+}
+
+func sign(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func deliver(r *Registry, path, body string, headers map[string]string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	req := httptest.NewRequest("POST", path, strings.NewReader(body))
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var response map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &response)
+	return w, response
+}
+
+func TestServeHTTP(t *testing.T) {
+	t.Logf("Importance: Webhooks act on a user's account without OAuth, so only correctly signed, matching deliveries may call tools.")
+
+	r, calls := newTestRegistry(t)
+	githubHeaders := map[string]string{
+		"X-GitHub-Event":      "issues",
+		"X-GitHub-Delivery":   "delivery-1",
+		"X-Hub-Signature-256": sign("s3cret", issuePayload),
+	}
+
+	t.Run("applies a signed delivery", func(t *testing.T) {
+		t.Logf("  > Why it's important: This is the path that turns a GitHub issue into an RTM task.")
+		w, response := deliver(r, "/hooks/github", issuePayload, githubHeaders)
+		if w.Code != http.StatusOK || response["outcome"] != "applied" {
+			t.Fatalf("Expected applied, got %d %v", w.Code, response)
+		}
+		got := calls()
+		if len(got) != 1 || got[0]["task"] != "Login fails #github https://github.com/acme/app/issues/42" {
+			t.Errorf("Expected one rendered task, got %v", got)
+		}
+	})
+
+	t.Run("rejects a bad signature", func(t *testing.T) {
+		t.Logf("  > Why it's important: Anyone can reach /hooks; the secret is the only thing stopping forged deliveries.")
+		headers := map[string]string{"X-GitHub-Event": "issues", "X-Hub-Signature-256": sign("wrong", issuePayload)}
+		if w, _ := deliver(r, "/hooks/github", issuePayload, headers); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected 401, got %d", w.Code)
+		}
+		if w, _ := deliver(r, "/hooks/github", issuePayload, map[string]string{"X-GitHub-Event": "issues"}); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected 401 without signature, got %d", w.Code)
+		}
+		if len(calls()) != 1 {
+			t.Errorf("Expected no tool calls from rejected deliveries, got %v", calls())
+		}
+	})
+
+	t.Run("ignores unmatched events", func(t *testing.T) {
+		t.Logf("  > Why it's important: Senders deliver many event types; only the configured ones should act.")
+		body := strings.Replace(issuePayload, `"opened"`, `"closed"`, 1)
+		headers := map[string]string{"X-GitHub-Event": "issues", "X-Hub-Signature-256": sign("s3cret", body)}
+		w, response := deliver(r, "/hooks/github", body, headers)
+		if w.Code != http.StatusOK || response["outcome"] != "ignored" {
+			t.Errorf("Expected ignored, got %d %v", w.Code, response)
+		}
+	})
+
+	t.Run("token hooks and tool failures", func(t *testing.T) {
+		t.Logf("  > Why it's important: A failed tool call must be reported to the sender so it can redeliver.")
+		w, response := deliver(r, "/hooks/refresh", `{"task": "fail"}`, map[string]string{"X-Webhook-Token": "t0ken"})
+		if w.Code != http.StatusBadGateway || response["outcome"] != "failed" {
+			t.Errorf("Expected failed, got %d %v", w.Code, response)
+		}
+		if !strings.Contains(response["message"].(string), "RTM unavailable") {
+			t.Errorf("Expected tool error in message, got %v", response["message"])
+		}
+
+		w, response = deliver(r, "/hooks/refresh", `{}`, map[string]string{"X-Webhook-Token": "t0ken"})
+		if w.Code != http.StatusOK || response["outcome"] != "applied" {
+			t.Errorf("Expected resource update applied, got %d %v", w.Code, response)
+		}
+	})
+
+	t.Run("unknown hooks and methods", func(t *testing.T) {
+		t.Logf("  > Why it's important: Probing for hook names should reveal nothing, and only POST deliveries are accepted.")
+		if w, _ := deliver(r, "/hooks/missing", `{}`, nil); w.Code != http.StatusNotFound {
+			t.Errorf("Expected 404, got %d", w.Code)
+		}
+		req := httptest.NewRequest("GET", "/hooks/github", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("Expected 405, got %d", w.Code)
+		}
+	})
+
+	t.Run("audit log", func(t *testing.T) {
+		t.Logf("  > Why it's important: Operators need each hook's history to debug mappings and spot forged deliveries.")
+		audit, ok := r.Audit("github")
+		if !ok {
+			t.Fatal("Expected audit for github hook")
+		}
+		outcomes := make([]string, len(audit))
+		for i, d := range audit {
+			outcomes[i] = d.Outcome
+		}
+		if strings.Join(outcomes, ",") != "applied,rejected,rejected,ignored,rejected" {
+			t.Errorf("Unexpected outcomes %v", outcomes)
+		}
+		if audit[0].DeliveryID != "delivery-1" || len(audit[0].Actions) != 1 {
+			t.Errorf("Expected delivery ID and action recorded, got %+v", audit[0])
+		}
+	})
+}
+
+func TestNewValidatesConfig(t *testing.T) {
+	t.Logf("Importance: A misconfigured hook should stop startup instead of silently accepting unsigned deliveries.")
+	t.Setenv("TEST_SECRET", "x")
+	s := server.NewMCPServer("test", "1.0.0")
+	rule := []Rule{{Tool: "rtm_quick_add"}}
+
+	cases := map[string]HookConfig{
+		"missing secret env": {Name: "a", Rules: rule},
+		"unset secret":       {Name: "a", SecretEnv: "TEST_UNSET_SECRET", Rules: rule},
+		"bad name":           {Name: "a/b", SecretEnv: "TEST_SECRET", Rules: rule},
+		"unknown verify":     {Name: "a", SecretEnv: "TEST_SECRET", Verify: "none", Rules: rule},
+		"no rules":           {Name: "a", SecretEnv: "TEST_SECRET"},
+		"tool and resource":  {Name: "a", SecretEnv: "TEST_SECRET", Rules: []Rule{{Tool: "x", Resource: "y"}}},
+	}
+	for name, hc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := New(s, Config{Hooks: []HookConfig{hc}}); err == nil {
+				t.Error("Expected config error")
+			}
+		})
+	}
+}
+
+func TestReload(t *testing.T) {
+	t.Logf("Importance: Operators add hooks and rotate secrets through the control plane; a bad edit must not take working hooks down.")
+	t.Setenv("TEST_SECRET", "x")
+	path := filepath.Join(t.TempDir(), "webhooks.json")
+	write := func(config string) {
+		if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`{"hooks": [{"name": "a", "secret_env": "TEST_SECRET", "verify": "token", "rules": [{"resource": "rtm://today"}]}]}`)
+	t.Setenv("WEBHOOKS_CONFIG", path)
+
+	r, err := LoadFromEnv(server.NewMCPServer("test", "1.0.0"))
+	if err != nil {
+		t.Fatalf("LoadFromEnv failed: %v", err)
+	}
+	deliver(r, "/hooks/a", `{}`, map[string]string{"X-Webhook-Token": "x"})
+
+	write(`{"hooks": [
+		{"name": "a", "secret_env": "TEST_SECRET", "verify": "token", "rules": [{"resource": "rtm://today"}]},
+		{"name": "b", "secret_env": "TEST_SECRET", "rules": [{"resource": "rtm://inbox"}]}
+	]}`)
+	if err := r.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if names := strings.Join(r.Names(), ","); names != "a,b" {
+		t.Errorf("Expected hooks a,b after reload, got %s", names)
+	}
+	if audit, _ := r.Audit("a"); len(audit) != 1 {
+		t.Errorf("Expected hook a to keep its audit log, got %v", audit)
+	}
+
+	t.Run("invalid config keeps current hooks", func(t *testing.T) {
+		write(`{"hooks": [{"name": "a", "rules": []}]}`)
+		if err := r.Reload(); err == nil {
+			t.Error("Expected reload error")
+		}
+		if names := strings.Join(r.Names(), ","); names != "a,b" {
+			t.Errorf("Expected hooks unchanged, got %s", names)
+		}
+	})
+}
@@ -23,7 +23,7 @@ type MockRTMClient struct {
 	mu             *sync.Mutex
 }
 
-var _ rtm.RTMClientInterface = (*MockRTMClient)(nil) // Verify interface compliance
+var _ rtm.AuthClient = (*MockRTMClient)(nil) // Verify interface compliance
 
 func (m *MockRTMClient) GetFrob() (string, error) {
 	m.mu.Lock()
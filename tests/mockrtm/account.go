@@ -0,0 +1,670 @@
+package mockrtm
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/vcto/mcp-adapters/internal/rtm"
+)
+
+// Errors for requests the mock rejects beyond the RTM codes in mockrtm.go
+const (
+	ErrInvalidTransaction = 350
+	ErrInvalidLastSync    = 4000
+	ErrInvalidTaskName    = 4010
+)
+
+// account is the one RTM account every token acts for
+type account struct {
+	lists  []*list
+	series []*series
+	// undo restores the state before each undoable transaction
+	undo map[string]func()
+}
+
+type list struct {
+	ID       string
+	Name     string
+	Filter   string
+	Locked   bool
+	Archived bool
+	Deleted  bool
+}
+
+type series struct {
+	ID         string
+	ListID     string
+	Name       string
+	URL        string
+	LocationID string
+	Tags       []string
+	Notes      []note
+	Created    time.Time
+	Modified   time.Time
+	// Task is the series' only occurrence; the mock does not repeat tasks
+	Task task
+}
+
+type note struct {
+	ID      string
+	Title   string
+	Text    string
+	Created time.Time
+}
+
+type task struct {
+	ID         string
+	Due        string
+	HasDueTime bool
+	Priority   string
+	Estimate   string
+	Added      time.Time
+	Completed  time.Time
+	Deleted    time.Time
+}
+
+func newAccount() account {
+	return account{undo: make(map[string]func())}
+}
+
+// AddList creates a list and returns its ID. RTM's own Inbox and Sent lists
+// are locked.
+func (s *Server) AddList(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l := &list{ID: s.newID("list"), Name: name, Locked: name == "Inbox" || name == "Sent"}
+	s.lists = append(s.lists, l)
+	return l.ID
+}
+
+// AddSmartList creates a Smart List showing the tasks matching filter
+func (s *Server) AddSmartList(name, filter string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l := &list{ID: s.newID("list"), Name: name, Filter: filter}
+	s.lists = append(s.lists, l)
+	return l.ID
+}
+
+// AddTask creates an incomplete task in the list and returns its series and
+// task IDs
+func (s *Server) AddTask(listID, name string) (seriesID, taskID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	se := s.newSeries(listID, name)
+	return se.ID, se.Task.ID
+}
+
+// Task returns the task as rtm.Client would read it, and false if there is
+// no such task
+func (s *Server) Task(seriesID, taskID string) (rtm.Task, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, se := range s.series {
+		if se.ID == seriesID && se.Task.ID == taskID {
+			return rtm.Task{
+				ID:         se.Task.ID,
+				SeriesID:   se.ID,
+				ListID:     se.ListID,
+				Name:       se.Name,
+				Due:        se.Task.Due,
+				HasDueTime: se.Task.HasDueTime,
+				Priority:   se.Task.Priority,
+				Estimate:   se.Task.Estimate,
+				Completed:  formatTime(se.Task.Completed),
+				Deleted:    formatTime(se.Task.Deleted),
+				URL:        se.URL,
+				LocationID: se.LocationID,
+				Tags:       append([]string(nil), se.Tags...),
+			}, true
+		}
+	}
+	return rtm.Task{}, false
+}
+
+// SetClock replaces the clock used for task timestamps
+func (s *Server) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+// newSeries adds a task; called with s.mu held
+func (s *Server) newSeries(listID, name string) *series {
+	now := s.now().UTC().Truncate(time.Second)
+	se := &series{
+		ID:       s.newID("series"),
+		ListID:   listID,
+		Name:     name,
+		Created:  now,
+		Modified: now,
+		Task:     task{ID: s.newID("task"), Priority: "N", Added: now},
+	}
+	s.series = append(s.series, se)
+	return se
+}
+
+func (s *Server) findList(id string) *list {
+	for _, l := range s.lists {
+		if l.ID == id && !l.Deleted {
+			return l
+		}
+	}
+	return nil
+}
+
+func (s *Server) getLists(map[string]string) (map[string]any, *rtm.RTMError) {
+	lists := make([]map[string]string, 0, len(s.lists))
+	for i, l := range s.lists {
+		lists = append(lists, l.json(i+1))
+	}
+	return map[string]any{"lists": map[string]any{"list": lists}}, nil
+}
+
+func (s *Server) addList(params map[string]string) (map[string]any, *rtm.RTMError) {
+	if err := s.checkTimeline(params); err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(params["name"])
+	if name == "" || name == "Inbox" || name == "Sent" {
+		return nil, rtmError(ErrInvalidListName, "List name provided is invalid")
+	}
+	l := &list{ID: s.newID("list"), Name: name, Filter: params["filter"]}
+	s.lists = append(s.lists, l)
+	rsp := s.transaction(func() { l.Deleted = true })
+	rsp["list"] = l.json(len(s.lists))
+	return rsp, nil
+}
+
+func (s *Server) renameList(params map[string]string) (map[string]any, *rtm.RTMError) {
+	return s.changeList(params, func(l *list) (func(), *rtm.RTMError) {
+		name := strings.TrimSpace(params["name"])
+		if name == "" || l.Locked {
+			return nil, rtmError(ErrInvalidListName, "List name provided is invalid")
+		}
+		l.Name = name
+		return nil, nil
+	})
+}
+
+func (s *Server) archiveList(params map[string]string) (map[string]any, *rtm.RTMError) {
+	return s.changeList(params, func(l *list) (func(), *rtm.RTMError) {
+		l.Archived = true
+		return nil, nil
+	})
+}
+
+func (s *Server) unarchiveList(params map[string]string) (map[string]any, *rtm.RTMError) {
+	return s.changeList(params, func(l *list) (func(), *rtm.RTMError) {
+		l.Archived = false
+		return nil, nil
+	})
+}
+
+// deleteList deletes the list and, as RTM does, moves its tasks to the Inbox
+func (s *Server) deleteList(params map[string]string) (map[string]any, *rtm.RTMError) {
+	return s.changeList(params, func(l *list) (func(), *rtm.RTMError) {
+		if l.Locked {
+			return nil, rtmError(ErrInvalidList, "list_id invalid or not provided")
+		}
+		l.Deleted = true
+		var moved []*series
+		for _, se := range s.series {
+			if se.ListID == l.ID {
+				se.ListID = s.lists[0].ID
+				moved = append(moved, se)
+			}
+		}
+		return func() {
+			for _, se := range moved {
+				se.ListID = l.ID
+			}
+		}, nil
+	})
+}
+
+// changeList applies change to the list in params as an undoable
+// transaction. change may return how to undo anything it did beyond the list.
+func (s *Server) changeList(params map[string]string, change func(*list) (func(), *rtm.RTMError)) (map[string]any, *rtm.RTMError) {
+	if err := s.checkTimeline(params); err != nil {
+		return nil, err
+	}
+	l := s.findList(params["list_id"])
+	if l == nil {
+		return nil, rtmError(ErrInvalidList, "list_id invalid or not provided")
+	}
+	before := *l
+	undo, err := change(l)
+	if err != nil {
+		*l = before
+		return nil, err
+	}
+	rsp := s.transaction(func() {
+		*l = before
+		if undo != nil {
+			undo()
+		}
+	})
+	for i, each := range s.lists {
+		if each == l {
+			rsp["list"] = l.json(i + 1)
+		}
+	}
+	return rsp, nil
+}
+
+// getTasks answers rtm.tasks.getList. With last_sync it returns only tasks
+// changed since, and reports deleted ones separately, as RTM does.
+func (s *Server) getTasks(params map[string]string) (map[string]any, *rtm.RTMError) {
+	var since time.Time
+	if value := params["last_sync"]; value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, rtmError(ErrInvalidLastSync, "last_sync invalid")
+		}
+		since = parsed
+	}
+	var scope *list
+	if id := params["list_id"]; id != "" {
+		if scope = s.findList(id); scope == nil {
+			return nil, rtmError(ErrInvalidList, "list_id invalid or not provided")
+		}
+	}
+
+	type group struct {
+		taskseries []map[string]any
+		deleted    []map[string]any
+	}
+	groups := make(map[string]*group)
+	var order []string
+	for _, se := range s.series {
+		if !since.IsZero() && !se.Modified.After(since) {
+			continue
+		}
+		deleted := !se.Task.Deleted.IsZero()
+		if deleted && since.IsZero() {
+			continue
+		}
+		if !deleted {
+			if scope != nil && !s.inList(se, scope) {
+				continue
+			}
+			if !s.matches(se, params["filter"]) {
+				continue
+			}
+		}
+		g, ok := groups[se.ListID]
+		if !ok {
+			g = &group{}
+			groups[se.ListID] = g
+			order = append(order, se.ListID)
+		}
+		if deleted {
+			g.deleted = append(g.deleted, se.json())
+		} else {
+			g.taskseries = append(g.taskseries, se.json())
+		}
+	}
+
+	lists := make([]map[string]any, 0, len(order))
+	for _, id := range order {
+		entry := map[string]any{"id": id, "taskseries": groups[id].taskseries}
+		if deleted := groups[id].deleted; deleted != nil {
+			entry["deleted"] = map[string]any{"taskseries": deleted}
+		}
+		lists = append(lists, entry)
+	}
+	return map[string]any{"tasks": map[string]any{"rev": s.newID("rev"), "list": lists}}, nil
+}
+
+func (s *Server) inList(se *series, l *list) bool {
+	if l.Filter != "" {
+		return s.matches(se, l.Filter)
+	}
+	return se.ListID == l.ID
+}
+
+// matches applies the subset of RTM's search syntax described in the
+// package doc
+func (s *Server) matches(se *series, filter string) bool {
+	if filter == "" || strings.Contains(filter, " OR ") {
+		return true
+	}
+	for _, term := range strings.Split(filter, " AND ") {
+		term = strings.Trim(strings.TrimSpace(term), "()")
+		key, value, ok := strings.Cut(term, ":")
+		if !ok {
+			continue
+		}
+		value = strings.ToLower(strings.Trim(value, `"`))
+		switch strings.ToLower(key) {
+		case "list":
+			name := ""
+			for _, l := range s.lists {
+				if l.ID == se.ListID {
+					name = l.Name
+				}
+			}
+			if strings.ToLower(name) != value {
+				return false
+			}
+		case "tag":
+			if !slices.Contains(se.Tags, value) {
+				return false
+			}
+		case "name":
+			if !strings.Contains(strings.ToLower(se.Name), value) {
+				return false
+			}
+		case "status":
+			if (value == "completed") == se.Task.Completed.IsZero() {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (s *Server) addTask(params map[string]string) (map[string]any, *rtm.RTMError) {
+	if err := s.checkTimeline(params); err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(params["name"])
+	if name == "" {
+		return nil, rtmError(ErrInvalidTaskName, "Task name provided is invalid")
+	}
+	listID := s.lists[0].ID
+	if id := params["list_id"]; id != "" {
+		l := s.findList(id)
+		if l == nil || l.Filter != "" {
+			return nil, rtmError(ErrInvalidList, "list_id invalid or not provided")
+		}
+		listID = l.ID
+	}
+	se := s.newSeries(listID, name)
+	return s.taskResponse(se, func() { se.Task.Deleted = se.Modified }), nil
+}
+
+func (s *Server) completeTask(params map[string]string) (map[string]any, *rtm.RTMError) {
+	return s.changeTask(params, func(se *series, now time.Time) *rtm.RTMError {
+		se.Task.Completed = now
+		return nil
+	})
+}
+
+func (s *Server) uncompleteTask(params map[string]string) (map[string]any, *rtm.RTMError) {
+	return s.changeTask(params, func(se *series, now time.Time) *rtm.RTMError {
+		se.Task.Completed = time.Time{}
+		return nil
+	})
+}
+
+func (s *Server) deleteTask(params map[string]string) (map[string]any, *rtm.RTMError) {
+	return s.changeTask(params, func(se *series, now time.Time) *rtm.RTMError {
+		se.Task.Deleted = now
+		return nil
+	})
+}
+
+func (s *Server) setName(params map[string]string) (map[string]any, *rtm.RTMError) {
+	return s.changeTask(params, func(se *series, now time.Time) *rtm.RTMError {
+		name := strings.TrimSpace(params["name"])
+		if name == "" {
+			return rtmError(ErrInvalidTaskName, "Task name provided is invalid")
+		}
+		se.Name = name
+		return nil
+	})
+}
+
+// setDueDate stores ISO dates as given; the mock does not parse natural
+// language dates
+func (s *Server) setDueDate(params map[string]string) (map[string]any, *rtm.RTMError) {
+	return s.changeTask(params, func(se *series, now time.Time) *rtm.RTMError {
+		se.Task.Due = params["due"]
+		if day, err := time.Parse("2006-01-02", params["due"]); err == nil {
+			se.Task.Due = formatTime(day)
+		}
+		se.Task.HasDueTime = params["has_due_time"] == "1"
+		return nil
+	})
+}
+
+func (s *Server) setPriority(params map[string]string) (map[string]any, *rtm.RTMError) {
+	return s.changeTask(params, func(se *series, now time.Time) *rtm.RTMError {
+		switch priority := params["priority"]; priority {
+		case "1", "2", "3":
+			se.Task.Priority = priority
+		default:
+			se.Task.Priority = "N"
+		}
+		return nil
+	})
+}
+
+func (s *Server) setEstimate(params map[string]string) (map[string]any, *rtm.RTMError) {
+	return s.changeTask(params, func(se *series, now time.Time) *rtm.RTMError {
+		se.Task.Estimate = params["estimate"]
+		return nil
+	})
+}
+
+func (s *Server) setTags(params map[string]string) (map[string]any, *rtm.RTMError) {
+	return s.changeTask(params, func(se *series, now time.Time) *rtm.RTMError {
+		se.Tags = mergeTags(nil, params["tags"])
+		return nil
+	})
+}
+
+func (s *Server) addTags(params map[string]string) (map[string]any, *rtm.RTMError) {
+	return s.changeTask(params, func(se *series, now time.Time) *rtm.RTMError {
+		se.Tags = mergeTags(se.Tags, params["tags"])
+		return nil
+	})
+}
+
+func (s *Server) setURL(params map[string]string) (map[string]any, *rtm.RTMError) {
+	return s.changeTask(params, func(se *series, now time.Time) *rtm.RTMError {
+		se.URL = params["url"]
+		return nil
+	})
+}
+
+func (s *Server) setLocation(params map[string]string) (map[string]any, *rtm.RTMError) {
+	return s.changeTask(params, func(se *series, now time.Time) *rtm.RTMError {
+		se.LocationID = params["location_id"]
+		return nil
+	})
+}
+
+// moveTo names the current list from_list_id, unlike the other methods
+func (s *Server) moveTo(params map[string]string) (map[string]any, *rtm.RTMError) {
+	scoped := make(map[string]string, len(params))
+	for k, v := range params {
+		scoped[k] = v
+	}
+	scoped["list_id"] = params["from_list_id"]
+	return s.changeTask(scoped, func(se *series, now time.Time) *rtm.RTMError {
+		to := s.findList(params["to_list_id"])
+		if to == nil || to.Filter != "" {
+			return rtmError(ErrInvalidList, "list_id invalid or not provided")
+		}
+		se.ListID = to.ID
+		return nil
+	})
+}
+
+func (s *Server) addNote(params map[string]string) (map[string]any, *rtm.RTMError) {
+	var added note
+	rsp, err := s.changeTask(params, func(se *series, now time.Time) *rtm.RTMError {
+		added = note{ID: s.newID("note"), Title: params["note_title"], Text: params["note_text"], Created: now}
+		se.Notes = append(se.Notes, added)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	delete(rsp, "list")
+	rsp["note"] = added.json()
+	return rsp, nil
+}
+
+func (s *Server) undoTransaction(params map[string]string) (map[string]any, *rtm.RTMError) {
+	if err := s.checkTimeline(params); err != nil {
+		return nil, err
+	}
+	restore, ok := s.undo[params["transaction_id"]]
+	if !ok {
+		return nil, rtmError(ErrInvalidTransaction, "transaction_id invalid or not provided")
+	}
+	delete(s.undo, params["transaction_id"])
+	restore()
+	return nil, nil
+}
+
+// changeTask applies change to the task named in params as an undoable
+// transaction, checking the IDs the way RTM does
+func (s *Server) changeTask(params map[string]string, change func(*series, time.Time) *rtm.RTMError) (map[string]any, *rtm.RTMError) {
+	if err := s.checkTimeline(params); err != nil {
+		return nil, err
+	}
+	if s.findList(params["list_id"]) == nil {
+		return nil, rtmError(ErrInvalidList, "list_id invalid or not provided")
+	}
+	var se *series
+	for _, each := range s.series {
+		if each.ID == params["taskseries_id"] && each.ListID == params["list_id"] {
+			se = each
+		}
+	}
+	if se == nil {
+		return nil, rtmError(ErrInvalidSeries, "taskseries_id invalid or not provided")
+	}
+	if se.Task.ID != params["task_id"] {
+		return nil, rtmError(ErrInvalidTask, "task_id invalid or not provided")
+	}
+
+	before := se.clone()
+	now := s.now().UTC().Truncate(time.Second)
+	if err := change(se, now); err != nil {
+		*se = before
+		return nil, err
+	}
+	se.Modified = now
+	return s.taskResponse(se, func() {
+		*se = before
+		se.Modified = s.now().UTC().Truncate(time.Second)
+	}), nil
+}
+
+// taskResponse is RTM's answer to a task mutation, undone by restore
+func (s *Server) taskResponse(se *series, restore func()) map[string]any {
+	rsp := s.transaction(restore)
+	rsp["list"] = map[string]any{"id": se.ListID, "taskseries": []map[string]any{se.json()}}
+	return rsp
+}
+
+// transaction records an undoable change and returns the response holding it
+func (s *Server) transaction(restore func()) map[string]any {
+	id := s.newID("tx")
+	s.undo[id] = restore
+	return map[string]any{"transaction": map[string]string{"id": id, "undoable": "1"}}
+}
+
+func (se *series) clone() series {
+	c := *se
+	c.Tags = append([]string(nil), se.Tags...)
+	c.Notes = append([]note(nil), se.Notes...)
+	return c
+}
+
+// json renders the series as rtm.tasks.getList does. RTM sends empty tag and
+// note containers as [].
+func (se *series) json() map[string]any {
+	var tags any = []any{}
+	if len(se.Tags) > 0 {
+		tags = map[string]any{"tag": se.Tags}
+	}
+	var notes any = []any{}
+	if len(se.Notes) > 0 {
+		entries := make([]map[string]string, 0, len(se.Notes))
+		for _, n := range se.Notes {
+			entries = append(entries, n.json())
+		}
+		notes = map[string]any{"note": entries}
+	}
+	hasDueTime := "0"
+	if se.Task.HasDueTime {
+		hasDueTime = "1"
+	}
+	return map[string]any{
+		"id":           se.ID,
+		"created":      formatTime(se.Created),
+		"modified":     formatTime(se.Modified),
+		"name":         se.Name,
+		"source":       "api",
+		"url":          se.URL,
+		"location_id":  se.LocationID,
+		"tags":         tags,
+		"notes":        notes,
+		"participants": []any{},
+		"task": []map[string]string{{
+			"id":           se.Task.ID,
+			"due":          se.Task.Due,
+			"has_due_time": hasDueTime,
+			"added":        formatTime(se.Task.Added),
+			"completed":    formatTime(se.Task.Completed),
+			"deleted":      formatTime(se.Task.Deleted),
+			"priority":     se.Task.Priority,
+			"postponed":    "0",
+			"estimate":     se.Task.Estimate,
+		}},
+	}
+}
+
+func (n note) json() map[string]string {
+	return map[string]string{"id": n.ID, "created": formatTime(n.Created), "modified": formatTime(n.Created), "title": n.Title, "$t": n.Text}
+}
+
+func (l *list) json(position int) map[string]string {
+	entry := map[string]string{
+		"id":       l.ID,
+		"name":     l.Name,
+		"deleted":  flag(l.Deleted),
+		"locked":   flag(l.Locked),
+		"archived": flag(l.Archived),
+		"position": fmt.Sprint(position),
+		"smart":    flag(l.Filter != ""),
+	}
+	if l.Filter != "" {
+		entry["filter"] = l.Filter
+	}
+	return entry
+}
+
+// mergeTags adds the comma-separated tags, lower-cased as RTM stores them
+func mergeTags(tags []string, added string) []string {
+	for _, tag := range strings.Split(added, ",") {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func flag(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
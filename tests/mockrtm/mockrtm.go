@@ -0,0 +1,350 @@
+// Package mockrtm is an in-process fake of the Remember The Milk REST API for
+// hermetic tests. It emulates the rtm.auth.*, rtm.lists.*, rtm.tasks.* and
+// rtm.timelines.create methods the adapters use, verifying api_key, api_sig
+// and auth_token the way RTM does, and serves the /services/auth/ page where
+// a user grants access to a frob.
+//
+//	mock := mockrtm.New("key", "secret")
+//	defer mock.Close()
+//	client := mock.Client(mock.IssueToken())
+//
+// Task filters understand list:, tag:, name: and status: terms joined by AND;
+// other operators are ignored, as is any filter containing OR.
+package mockrtm
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vcto/mcp-adapters/internal/rtm"
+)
+
+// RTM error codes returned by the mock
+const (
+	ErrInvalidSignature = 96
+	ErrMissingSignature = 97
+	ErrLoginFailed      = 98
+	ErrInvalidAPIKey    = 100
+	ErrInvalidFrob      = 101
+	ErrMethodNotFound   = 112
+	ErrInvalidTimeline  = 300
+	ErrInvalidSeries    = 320
+	ErrInvalidTask      = 330
+	ErrInvalidList      = 340
+	ErrInvalidListName  = 3000
+)
+
+// Call is one request the mock answered
+type Call struct {
+	Method string
+	Params map[string]string
+}
+
+// Server is a running fake RTM API
+type Server struct {
+	APIKey string
+	Secret string
+	// Username is the account name rtm.auth.getToken reports
+	Username string
+
+	srv *httptest.Server
+
+	mu sync.Mutex
+	// frobs maps issued frobs to the token granted for them, "" until approved
+	frobs     map[string]string
+	tokens    map[string]bool
+	timelines map[string]bool
+	calls     []Call
+	nextID    int
+	now       func() time.Time
+	account
+}
+
+// New starts a fake RTM API accepting apiKey and requests signed with
+// secret. The account starts with RTM's Inbox and Sent lists.
+func New(apiKey, secret string) *Server {
+	s := &Server{
+		APIKey:    apiKey,
+		Secret:    secret,
+		Username:  "mockuser",
+		frobs:     make(map[string]string),
+		tokens:    make(map[string]bool),
+		timelines: make(map[string]bool),
+		now:       time.Now,
+		account:   newAccount(),
+	}
+	s.AddList("Inbox")
+	s.AddList("Sent")
+	mux := http.NewServeMux()
+	mux.HandleFunc("/services/rest/", s.handleREST)
+	mux.HandleFunc("/services/auth/", s.handleAuth)
+	s.srv = httptest.NewServer(mux)
+	return s
+}
+
+// Close shuts the server down
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// URL is the REST endpoint, for rtm.Client.BaseURL
+func (s *Server) URL() string {
+	return s.srv.URL + "/services/rest/"
+}
+
+// AuthURL is the page granting access, for rtm.Client.AuthEndpoint
+func (s *Server) AuthURL() string {
+	return s.srv.URL + "/services/auth/"
+}
+
+// Client returns an RTM client for the mock acting with token, which may be
+// empty for the auth flow. It does not rate limit or retry.
+func (s *Server) Client(token string) *rtm.Client {
+	client := rtm.NewClient(s.APIKey, s.Secret)
+	client.BaseURL = s.URL()
+	client.AuthEndpoint = s.AuthURL()
+	client.AuthToken = token
+	client.Limiter = nil
+	client.Retry = rtm.RetryPolicy{}
+	return client
+}
+
+// IssueToken returns a new valid auth token without the frob flow
+func (s *Server) IssueToken() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token := s.newID("token")
+	s.tokens[token] = true
+	return token
+}
+
+// RevokeToken makes token invalid, as when a user revokes access
+func (s *Server) RevokeToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tokens, token)
+}
+
+// Approve grants access to frob, as the user does on RTM's auth page
+func (s *Server) Approve(frob string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	granted, ok := s.frobs[frob]
+	if !ok {
+		return fmt.Errorf("unknown frob %q", frob)
+	}
+	if granted == "" {
+		token := s.newID("token")
+		s.tokens[token] = true
+		s.frobs[frob] = token
+	}
+	return nil
+}
+
+// Calls returns the requests answered so far
+func (s *Server) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Call(nil), s.calls...)
+}
+
+// CallsTo counts the requests for method
+func (s *Server) CallsTo(method string) int {
+	count := 0
+	for _, call := range s.Calls() {
+		if call.Method == method {
+			count++
+		}
+	}
+	return count
+}
+
+// handleAuth approves the frob in the query when the api_sig is valid, as if
+// the user clicked "OK, I'll allow it"
+func (s *Server) handleAuth(w http.ResponseWriter, r *http.Request) {
+	params := queryParams(r)
+	if params["api_key"] != s.APIKey || params["api_sig"] != s.sign(params) {
+		http.Error(w, "Invalid API key or signature", http.StatusBadRequest)
+		return
+	}
+	if err := s.Approve(params["frob"]); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_, _ = fmt.Fprintln(w, "Application authorized. You may close this window.")
+}
+
+func (s *Server) handleREST(w http.ResponseWriter, r *http.Request) {
+	params := queryParams(r)
+	method := params["method"]
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, Call{Method: method, Params: params})
+
+	rsp, err := s.dispatch(method, params)
+	if err != nil {
+		writeJSON(w, map[string]any{"stat": "fail", "err": map[string]string{"code": fmt.Sprint(err.Code), "msg": err.Msg}})
+		return
+	}
+	if rsp == nil {
+		rsp = map[string]any{}
+	}
+	rsp["stat"] = "ok"
+	writeJSON(w, rsp)
+}
+
+// handlerFunc answers one RTM method; called with s.mu held
+type handlerFunc func(s *Server, params map[string]string) (map[string]any, *rtm.RTMError)
+
+// methods maps RTM methods to their handlers and whether they need a token
+var methods = map[string]struct {
+	handler handlerFunc
+	authed  bool
+}{
+	"rtm.auth.getFrob":      {(*Server).getFrob, false},
+	"rtm.auth.getToken":     {(*Server).getToken, false},
+	"rtm.auth.checkToken":   {(*Server).checkToken, false},
+	"rtm.timelines.create":  {(*Server).createTimeline, true},
+	"rtm.settings.getList":  {(*Server).getSettings, true},
+	"rtm.lists.getList":     {(*Server).getLists, true},
+	"rtm.lists.add":         {(*Server).addList, true},
+	"rtm.lists.setName":     {(*Server).renameList, true},
+	"rtm.lists.archive":     {(*Server).archiveList, true},
+	"rtm.lists.unarchive":   {(*Server).unarchiveList, true},
+	"rtm.lists.delete":      {(*Server).deleteList, true},
+	"rtm.tasks.getList":     {(*Server).getTasks, true},
+	"rtm.tasks.add":         {(*Server).addTask, true},
+	"rtm.tasks.complete":    {(*Server).completeTask, true},
+	"rtm.tasks.uncomplete":  {(*Server).uncompleteTask, true},
+	"rtm.tasks.delete":      {(*Server).deleteTask, true},
+	"rtm.tasks.setName":     {(*Server).setName, true},
+	"rtm.tasks.setDueDate":  {(*Server).setDueDate, true},
+	"rtm.tasks.setPriority": {(*Server).setPriority, true},
+	"rtm.tasks.setEstimate": {(*Server).setEstimate, true},
+	"rtm.tasks.setTags":     {(*Server).setTags, true},
+	"rtm.tasks.addTags":     {(*Server).addTags, true},
+	"rtm.tasks.setURL":      {(*Server).setURL, true},
+	"rtm.tasks.setLocation": {(*Server).setLocation, true},
+	"rtm.tasks.moveTo":      {(*Server).moveTo, true},
+	"rtm.tasks.notes.add":   {(*Server).addNote, true},
+	"rtm.transactions.undo": {(*Server).undoTransaction, true},
+}
+
+// dispatch checks the request the way RTM does, then answers it
+func (s *Server) dispatch(method string, params map[string]string) (map[string]any, *rtm.RTMError) {
+	if params["api_key"] != s.APIKey {
+		return nil, rtmError(ErrInvalidAPIKey, "Invalid API Key")
+	}
+	if params["api_sig"] == "" {
+		return nil, rtmError(ErrMissingSignature, "Missing signature")
+	}
+	if params["api_sig"] != s.sign(params) {
+		return nil, rtmError(ErrInvalidSignature, "Invalid signature")
+	}
+	m, ok := methods[method]
+	if !ok {
+		return nil, rtmError(ErrMethodNotFound, fmt.Sprintf("Method %q not found", method))
+	}
+	if m.authed && !s.tokens[params["auth_token"]] {
+		return nil, rtmError(ErrLoginFailed, "Login failed / Invalid auth token")
+	}
+	return m.handler(s, params)
+}
+
+func (s *Server) getFrob(map[string]string) (map[string]any, *rtm.RTMError) {
+	frob := s.newID("frob")
+	s.frobs[frob] = ""
+	return map[string]any{"frob": frob}, nil
+}
+
+func (s *Server) getToken(params map[string]string) (map[string]any, *rtm.RTMError) {
+	token := s.frobs[params["frob"]]
+	if token == "" {
+		return nil, rtmError(ErrInvalidFrob, "Invalid frob - did you authenticate?")
+	}
+	// A frob is good for one token
+	delete(s.frobs, params["frob"])
+	return s.auth(token), nil
+}
+
+func (s *Server) checkToken(params map[string]string) (map[string]any, *rtm.RTMError) {
+	if !s.tokens[params["auth_token"]] {
+		return nil, rtmError(ErrLoginFailed, "Login failed / Invalid auth token")
+	}
+	return s.auth(params["auth_token"]), nil
+}
+
+func (s *Server) auth(token string) map[string]any {
+	return map[string]any{"auth": map[string]any{
+		"token": token,
+		"perms": "delete",
+		"user":  map[string]string{"id": "1", "username": s.Username, "fullname": s.Username},
+	}}
+}
+
+func (s *Server) createTimeline(map[string]string) (map[string]any, *rtm.RTMError) {
+	timeline := s.newID("timeline")
+	s.timelines[timeline] = true
+	return map[string]any{"timeline": timeline}, nil
+}
+
+func (s *Server) getSettings(map[string]string) (map[string]any, *rtm.RTMError) {
+	return map[string]any{"settings": map[string]string{"timezone": "", "dateformat": "0", "timeformat": "0", "defaultlist": s.lists[0].ID}}, nil
+}
+
+// checkTimeline rejects mutations without a timeline from rtm.timelines.create
+func (s *Server) checkTimeline(params map[string]string) *rtm.RTMError {
+	if !s.timelines[params["timeline"]] {
+		return rtmError(ErrInvalidTimeline, "Timeline invalid or not provided")
+	}
+	return nil
+}
+
+// sign computes RTM's api_sig: the MD5 of the secret followed by every
+// parameter but api_sig, sorted by name
+func (s *Server) sign(params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		if k != "api_sig" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString(s.Secret)
+	for _, k := range keys {
+		b.WriteString(k + params[k])
+	}
+	return fmt.Sprintf("%x", md5.Sum([]byte(b.String())))
+}
+
+// newID returns a unique ID with prefix; called with s.mu held
+func (s *Server) newID(prefix string) string {
+	s.nextID++
+	return fmt.Sprintf("%s%d", prefix, s.nextID)
+}
+
+func queryParams(r *http.Request) map[string]string {
+	params := make(map[string]string)
+	for k, v := range r.URL.Query() {
+		params[k] = v[0]
+	}
+	return params
+}
+
+func rtmError(code int, msg string) *rtm.RTMError {
+	return &rtm.RTMError{Code: code, Msg: msg}
+}
+
+func writeJSON(w http.ResponseWriter, rsp map[string]any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"rsp": rsp})
+}
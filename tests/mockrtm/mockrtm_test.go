@@ -0,0 +1,205 @@
+package mockrtm
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/vcto/mcp-adapters/internal/rtm"
+)
+
+func TestMockRTM(t *testing.T) {
+	t.Logf("Importance: Handler and client tests need an RTM that behaves like the real one without credentials or network access.")
+
+	mock := New("key", "secret")
+	defer mock.Close()
+	client := mock.Client(mock.IssueToken())
+
+	lists, err := client.GetLists()
+	if err != nil {
+		t.Fatalf("GetLists: %v", err)
+	}
+	if len(lists) != 2 || lists[0].Name != "Inbox" || lists[0].Locked != "1" {
+		t.Fatalf("Expected the locked Inbox and Sent lists, got %+v", lists)
+	}
+	work, err := client.CreateList("Work", "")
+	if err != nil {
+		t.Fatalf("CreateList: %v", err)
+	}
+
+	task, err := client.AddTask("Write report", work.ID)
+	if err != nil {
+		t.Fatalf("AddTask: %v", err)
+	}
+	if task.ListID != work.ID || task.Priority != "N" {
+		t.Errorf("Expected a new task in Work without priority, got %+v", task)
+	}
+	err = client.UpdateTask(task.ListID, task.SeriesID, task.ID, map[string]string{"priority": "1", "tags": "Urgent,q3", "due": "2026-05-01"})
+	if err != nil {
+		t.Fatalf("UpdateTask: %v", err)
+	}
+	stored, _ := mock.Task(task.SeriesID, task.ID)
+	if stored.Priority != "1" || strings.Join(stored.Tags, ",") != "urgent,q3" || stored.Due != "2026-05-01T00:00:00Z" {
+		t.Errorf("Expected the updates to be stored, got %+v", stored)
+	}
+
+	t.Run("task filters", func(t *testing.T) {
+		t.Logf("  > Why it's important: Searches and list resources must see the tasks a real account would return.")
+		if _, err := client.AddTask("Buy milk", ""); err != nil {
+			t.Fatalf("AddTask: %v", err)
+		}
+		for filter, want := range map[string]string{
+			"":                                 "Write report,Buy milk",
+			"list:Work":                        "Write report",
+			`list:"Inbox" AND name:milk`:       "Buy milk",
+			"tag:urgent AND status:incomplete": "Write report",
+		} {
+			tasks, err := mock.Client(client.AuthToken).GetTasks(filter, "")
+			if err != nil {
+				t.Fatalf("GetTasks(%q): %v", filter, err)
+			}
+			var names []string
+			for _, task := range tasks {
+				names = append(names, task.Name)
+			}
+			if got := strings.Join(names, ","); got != want {
+				t.Errorf("GetTasks(%q) = %s, want %s", filter, got, want)
+			}
+		}
+
+		mock.AddSmartList("Urgent", "tag:urgent")
+		smart, _ := client.GetLists()
+		tasks, err := mock.Client(client.AuthToken).GetTasks("", smart[len(smart)-1].ID)
+		if err != nil || len(tasks) != 1 || tasks[0].Name != "Write report" {
+			t.Errorf("Expected the Smart List to show Write report, got %v, %v", tasks, err)
+		}
+	})
+
+	t.Run("complete and undo", func(t *testing.T) {
+		t.Logf("  > Why it's important: rtm_undo relies on RTM reverting a transaction to the state before it.")
+		if err := client.CompleteTask(task.ListID, task.SeriesID, task.ID); err != nil {
+			t.Fatalf("CompleteTask: %v", err)
+		}
+		if stored, _ := mock.Task(task.SeriesID, task.ID); stored.Completed == "" {
+			t.Fatal("Expected the task to be completed")
+		}
+		tx, ok := client.Transactions.Take(client.AuthToken, "")
+		if !ok {
+			t.Fatal("Expected the completion to be recorded as undoable")
+		}
+		if err := client.UndoTransaction(tx); err != nil {
+			t.Fatalf("UndoTransaction: %v", err)
+		}
+		if stored, _ := mock.Task(task.SeriesID, task.ID); stored.Completed != "" {
+			t.Error("Expected undo to reopen the task")
+		}
+	})
+
+	t.Run("rejected requests", func(t *testing.T) {
+		t.Logf("  > Why it's important: Error handling is only tested if the mock fails the way RTM does.")
+		wrongSecret := mock.Client(client.AuthToken)
+		wrongSecret.Secret = "guess"
+		revoked := mock.IssueToken()
+		mock.RevokeToken(revoked)
+
+		for name, tc := range map[string]struct {
+			call func() error
+			code int
+		}{
+			"bad signature": {func() error { _, err := wrongSecret.GetLists(); return err }, ErrInvalidSignature},
+			"revoked token": {func() error { _, err := mock.Client(revoked).GetLists(); return err }, ErrLoginFailed},
+			"unknown list":  {func() error { _, err := client.AddTask("Lost", "nope"); return err }, ErrInvalidList},
+			"wrong series": {func() error {
+				return client.CompleteTask(task.ListID, "nope", task.ID)
+			}, ErrInvalidSeries},
+		} {
+			var rtmErr *rtm.RTMError
+			if err := tc.call(); !errors.As(err, &rtmErr) || rtmErr.Code != tc.code {
+				t.Errorf("%s: expected RTM error %d, got %v", name, tc.code, err)
+			}
+		}
+	})
+}
+
+func TestMockOAuthFlow(t *testing.T) {
+	t.Logf("Importance: The OAuth adapter's frob exchange can be tested end to end without a real RTM account.")
+	// Ask RTM on every check, so a revocation at RTM is seen at once
+	t.Setenv("RTM_TOKEN_CACHE_TTL", "0")
+
+	mock := New("key", "secret")
+	defer mock.Close()
+	adapter := rtm.NewOAuthAdapter("key", "secret", "http://localhost:8080")
+	defer func() { _ = adapter.Close() }()
+	adapter.SetClient(mock.Client(""))
+
+	// Show the form to get a CSRF token, then submit it
+	w := httptest.NewRecorder()
+	adapter.HandleAuthorize(w, httptest.NewRequest("GET", "/oauth/authorize?client_id=test&state=xyz&redirect_uri=http://localhost:3000/callback", nil))
+	var csrf *http.Cookie
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == "csrf_token" {
+			csrf = cookie
+		}
+	}
+	if csrf == nil {
+		t.Fatal("CSRF cookie not set")
+	}
+	form := url.Values{"client_id": {"test"}, "state": {"xyz"}, "redirect_uri": {"http://localhost:3000/callback"}, "csrf_state": {csrf.Value}}
+	req := httptest.NewRequest("POST", "/oauth/authorize", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(csrf)
+	w = httptest.NewRecorder()
+	adapter.HandleAuthorize(w, req)
+	page := w.Body.String()
+
+	rtmURL := regexp.MustCompile(`href="(` + regexp.QuoteMeta(mock.AuthURL()) + `[^"]+)"`).FindStringSubmatch(page)
+	code := regexp.MustCompile(`check-auth\?code=([^']+)`).FindStringSubmatch(page)
+	if rtmURL == nil || code == nil {
+		t.Fatalf("Expected a link to the mock's auth page and a check-auth code, got:\n%s", page)
+	}
+
+	checkAuth := func() map[string]any {
+		w := httptest.NewRecorder()
+		adapter.HandleCheckAuth(w, httptest.NewRequest("GET", "/rtm/check-auth?code="+code[1], nil))
+		var result map[string]any
+		_ = json.NewDecoder(w.Body).Decode(&result)
+		return result
+	}
+	if result := checkAuth(); result["authorized"] == true {
+		t.Fatalf("Expected no token before the user approves, got %v", result)
+	}
+
+	// The user opens RTM's page and allows access
+	resp, err := http.Get(rtmURL[1])
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("Approving on the auth page failed: %v %v", resp, err)
+	}
+	_ = resp.Body.Close()
+	if result := checkAuth(); result["authorized"] != true {
+		t.Fatalf("Expected authorization after approval, got %v", result)
+	}
+
+	req = httptest.NewRequest("POST", "/oauth/token", strings.NewReader(url.Values{"grant_type": {"authorization_code"}, "code": {code[1]}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	adapter.HandleToken(w, req)
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&token); err != nil || token.AccessToken == "" {
+		t.Fatalf("Expected an access token, got %d: %v", w.Code, err)
+	}
+
+	if !adapter.ValidateBearer(token.AccessToken) {
+		t.Error("Expected the issued token to validate against the mock")
+	}
+	mock.RevokeToken(token.AccessToken)
+	if adapter.ValidateBearer(token.AccessToken) {
+		t.Error("Expected a revoked token to be rejected")
+	}
+}
@@ -0,0 +1,241 @@
+// Package mockspektrix is an in-process fake of the Spektrix API v3 for
+// hermetic tests. It emulates the customer, address, tag and event endpoints
+// the adapter uses and checks every request's SpektrixAPI3 signature and
+// Date header the way Spektrix does, so signing bugs fail here too.
+//
+//	mock := mockspektrix.New("apiuser", base64.StdEncoding.EncodeToString([]byte("secret")))
+//	defer mock.Close()
+//	client := mock.Client()
+//
+// To run the adapter or the server binary against the mock, set the
+// variables from mock.Env().
+package mockspektrix
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vcto/mcp-adapters/internal/spektrix"
+)
+
+// basePath is where the API lives on the mock, as /{client}/api/v3 does on
+// system.spektrix.com
+const basePath = "/mock/api/v3"
+
+// DateTolerance is how far a request's Date header may be from the mock's
+// clock before it is rejected with 401
+const DateTolerance = 15 * time.Minute
+
+// Call is one request the mock answered
+type Call struct {
+	Method string
+	// Path is relative to the API root, with any query
+	Path   string
+	Status int
+}
+
+// Server is a running fake Spektrix API
+type Server struct {
+	APIUser string
+	// APIKey is base64, as Spektrix issues it
+	APIKey string
+
+	srv *httptest.Server
+
+	mu    sync.Mutex
+	calls []Call
+	now   func() time.Time
+	// failures queues status codes to answer the next requests with
+	failures []int
+	nextID   int
+	store
+}
+
+// New starts a fake Spektrix API accepting requests from apiUser signed
+// with apiKey, a base64 key. It starts with no customers, tags or events.
+func New(apiUser, apiKey string) *Server {
+	s := &Server{
+		APIUser: apiUser,
+		APIKey:  apiKey,
+		now:     time.Now,
+	}
+	mux := http.NewServeMux()
+	s.routes(mux)
+	s.srv = httptest.NewServer(s.authenticate(mux))
+	return s
+}
+
+// Close shuts the server down
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// URL is the API root, for spektrix.Client.BaseURL or SPEKTRIX_API_BASE_URL
+func (s *Server) URL() string {
+	return s.srv.URL + basePath
+}
+
+// Client returns a Spektrix client for the mock. It does not rate limit or
+// retry.
+func (s *Server) Client() *spektrix.Client {
+	return &spektrix.Client{
+		ClientName: "mock",
+		APIUser:    s.APIUser,
+		APIKey:     s.APIKey,
+		BaseURL:    s.URL(),
+		HTTPClient: s.srv.Client(),
+	}
+}
+
+// Env returns the environment that points spektrix.NewClient, and so the
+// server binary, at the mock
+func (s *Server) Env() map[string]string {
+	return map[string]string{
+		"SPEKTRIX_CLIENT_NAME":  "mock",
+		"SPEKTRIX_API_USER":     s.APIUser,
+		"SPEKTRIX_API_KEY":      s.APIKey,
+		"SPEKTRIX_API_BASE_URL": s.URL(),
+		"SPEKTRIX_RATE_LIMIT":   "0",
+		"SPEKTRIX_MAX_RETRIES":  "0",
+	}
+}
+
+// SetClock replaces the mock's clock, used to check Date headers, to
+// simulate a client whose clock has drifted
+func (s *Server) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+// FailNext answers the next len(statuses) authenticated requests with the
+// given status codes, in order, without acting on them
+func (s *Server) FailNext(statuses ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, statuses...)
+}
+
+// Calls returns the requests answered so far
+func (s *Server) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Call(nil), s.calls...)
+}
+
+// CallsTo counts the requests with method whose path, without its query,
+// is path
+func (s *Server) CallsTo(method, path string) int {
+	count := 0
+	for _, call := range s.Calls() {
+		if p, _, _ := strings.Cut(call.Path, "?"); call.Method == method && p == path {
+			count++
+		}
+	}
+	return count
+}
+
+// authenticate checks the Date and Authorization headers, records the call
+// and answers any queued failure before passing the request on. Handlers
+// run with s.mu held.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(strings.NewReader(string(body)))
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			s.calls = append(s.calls, Call{Method: r.Method, Path: strings.TrimPrefix(r.URL.RequestURI(), basePath), Status: rec.status})
+		}()
+
+		now := s.now()
+		rec.Header().Set("Date", now.UTC().Format(http.TimeFormat))
+		if err := s.verify(r, body, now); err != nil {
+			writeError(rec, http.StatusUnauthorized, err.Error())
+			return
+		}
+		if len(s.failures) > 0 {
+			status := s.failures[0]
+			s.failures = s.failures[1:]
+			writeError(rec, status, "Simulated failure")
+			return
+		}
+		next.ServeHTTP(rec, r)
+	})
+}
+
+// verify checks a request the way Spektrix does: a Date within
+// DateTolerance, and an HMAC-SHA1 with the decoded key over the method,
+// full URL, Date and, when there is a body, its base64 MD5
+func (s *Server) verify(r *http.Request, body []byte, now time.Time) error {
+	date := r.Header.Get("Date")
+	signed, err := http.ParseTime(date)
+	if err != nil {
+		return fmt.Errorf("Missing or invalid Date header")
+	}
+	if d := signed.Sub(now); d > DateTolerance || d < -DateTolerance {
+		return fmt.Errorf("Date header is too far from the server time")
+	}
+
+	user, signature, ok := strings.Cut(strings.TrimPrefix(r.Header.Get("Authorization"), "SpektrixAPI3 "), ":")
+	if !ok || user != s.APIUser {
+		return fmt.Errorf("Invalid API user")
+	}
+	if signature != s.sign(r.Method, s.srv.URL+r.URL.RequestURI(), date, body) {
+		return fmt.Errorf("Invalid signature")
+	}
+	return nil
+}
+
+// sign computes the signature for a request using crypto/hmac, independent
+// of the adapter's own HMAC implementation
+func (s *Server) sign(method, url, date string, body []byte) string {
+	stringToSign := method + "\n" + url + "\n" + date
+	if len(body) > 0 {
+		bodyHash := md5.Sum(body)
+		stringToSign += "\n" + base64.StdEncoding.EncodeToString(bodyHash[:])
+	}
+	key, _ := base64.StdEncoding.DecodeString(s.APIKey)
+	mac := hmac.New(sha1.New, key)
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// newID returns a unique ID with prefix; called with s.mu held
+func (s *Server) newID(prefix string) string {
+	s.nextID++
+	return fmt.Sprintf("%s%d", prefix, s.nextID)
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError answers with Spektrix's error body
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]any{"message": message, "code": status})
+}
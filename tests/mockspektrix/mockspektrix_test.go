@@ -0,0 +1,185 @@
+package mockspektrix
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/vcto/mcp-adapters/internal/spektrix"
+)
+
+var testKey = base64.StdEncoding.EncodeToString([]byte("secret-key"))
+
+func TestMockSpektrix(t *testing.T) {
+	t.Logf("Importance: Spektrix client and handler tests need an API that checks signatures like the real one, without credentials or network access.")
+
+	mock := New("apiuser", testKey)
+	defer mock.Close()
+	client := mock.Client()
+
+	created, err := client.CreateCustomer(spektrix.CreateCustomerRequest{FirstName: "Jane", LastName: "Doe", Email: "jane@example.com"})
+	if err != nil {
+		t.Fatalf("CreateCustomer: %v", err)
+	}
+	found, err := client.SearchCustomers("jane@example.com")
+	if err != nil || len(found) != 1 || found[0].ID != created.ID {
+		t.Fatalf("Expected to find the new customer, got %+v, %v", found, err)
+	}
+	if found, _ := client.SearchCustomers("nobody@example.com"); len(found) != 0 {
+		t.Errorf("Expected no customer for an unknown email, got %+v", found)
+	}
+
+	t.Run("addresses", func(t *testing.T) {
+		t.Logf("  > Why it's important: Signed POST and PUT bodies must verify, and a new default moves from the old address.")
+		if err := client.AddCustomerAddress(created.ID, spektrix.Address{Line1: "1 High St", Postcode: "SW1A 1AA", Country: "GB", IsBilling: true, IsDelivery: true}); err != nil {
+			t.Fatalf("AddCustomerAddress: %v", err)
+		}
+		if err := client.AddCustomerAddress(created.ID, spektrix.Address{Line1: "2 Low Rd", Postcode: "E1 6AN", Country: "GB", IsBilling: true}); err != nil {
+			t.Fatalf("AddCustomerAddress: %v", err)
+		}
+		addresses, err := client.GetCustomerAddresses(created.ID)
+		if err != nil || len(addresses) != 2 || addresses[0].IsBilling || !addresses[0].IsDelivery || !addresses[1].IsBilling {
+			t.Fatalf("Expected billing to move to the second address, got %+v, %v", addresses, err)
+		}
+		addresses[0].IsBilling = true
+		if err := client.UpdateCustomerAddress(created.ID, addresses[0]); err != nil {
+			t.Fatalf("UpdateCustomerAddress: %v", err)
+		}
+		if stored, _, _ := mock.Customer(created.ID); !stored.Addresses[0].IsBilling || stored.Addresses[1].IsBilling {
+			t.Errorf("Expected billing back on the first address, got %+v", stored.Addresses)
+		}
+		byName, err := client.FindCustomersByName("", "doe", "e16an")
+		if err != nil || len(byName) != 1 {
+			t.Errorf("Expected a name and postcode match, got %+v, %v", byName, err)
+		}
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		t.Logf("  > Why it's important: Tagging tools are checked against the tags the mock stores, not just the calls made.")
+		news := mock.AddTag("Newsletter")
+		member := mock.AddTag("Member")
+		if err := client.AddCustomerTags(created.ID, []string{news.ID, member.ID}); err != nil {
+			t.Fatalf("AddCustomerTags: %v", err)
+		}
+		if err := client.RemoveCustomerTag(created.ID, news.ID); err != nil {
+			t.Fatalf("RemoveCustomerTag: %v", err)
+		}
+		if _, tags, _ := mock.Customer(created.ID); len(tags) != 1 || tags[0] != member.ID {
+			t.Errorf("Expected only Member left, got %v", tags)
+		}
+		if err := client.RemoveCustomerTag(created.ID, news.ID); err == nil || !strings.Contains(err.Error(), "404") {
+			t.Errorf("Expected removing a missing tag to be a 404, got %v", err)
+		}
+	})
+
+	t.Run("events", func(t *testing.T) {
+		t.Logf("  > Why it's important: The events resource reads an event and its instances.")
+		hamlet := mock.AddEvent(spektrix.Event{Name: "Hamlet", IsOnSale: true}, spektrix.Instance{Start: "2030-06-01T19:30:00", IsOnSale: true})
+		event, err := client.GetEvent(hamlet.ID)
+		if err != nil || event.Name != "Hamlet" {
+			t.Fatalf("GetEvent: %+v, %v", event, err)
+		}
+		instances, err := client.GetEventInstances(hamlet.ID)
+		if err != nil || len(instances) != 1 || instances[0].ID == "" {
+			t.Errorf("Expected one instance with an ID, got %+v, %v", instances, err)
+		}
+		if _, err := client.GetEvent("nope"); err == nil || !strings.Contains(err.Error(), "404") {
+			t.Errorf("Expected an unknown event to be a 404, got %v", err)
+		}
+	})
+
+	t.Run("rejected requests", func(t *testing.T) {
+		t.Logf("  > Why it's important: Signing bugs and bad credentials must fail against the mock as they would against Spektrix.")
+		wrongKey := mock.Client()
+		wrongKey.APIKey = base64.StdEncoding.EncodeToString([]byte("guess"))
+		wrongUser := mock.Client()
+		wrongUser.APIUser = "someone"
+		for name, c := range map[string]*spektrix.Client{"wrong key": wrongKey, "wrong user": wrongUser} {
+			if _, err := c.GetTags(); err == nil || !strings.Contains(err.Error(), "401") {
+				t.Errorf("%s: expected a 401, got %v", name, err)
+			}
+		}
+
+		mock.FailNext(http.StatusServiceUnavailable)
+		if _, err := client.GetTags(); err == nil || !strings.Contains(err.Error(), "503") {
+			t.Errorf("Expected the queued failure, got %v", err)
+		}
+		if mock.CallsTo("GET", "/tags") != 3 {
+			t.Errorf("Expected every call recorded, got %+v", mock.Calls())
+		}
+	})
+
+	t.Run("clock drift", func(t *testing.T) {
+		t.Logf("  > Why it's important: The client must recover from a Date the server rejects by signing with the server's time.")
+		mock.SetClock(func() time.Time { return time.Now().Add(time.Hour) })
+		defer mock.SetClock(time.Now)
+		calls := len(mock.Calls())
+		if _, err := client.GetTags(); err != nil {
+			t.Fatalf("Expected the client to correct its clock, got %v", err)
+		}
+		if got := mock.Calls()[calls:]; len(got) != 2 || got[0].Status != http.StatusUnauthorized || got[1].Status != http.StatusOK {
+			t.Errorf("Expected a 401 then a success, got %+v", got)
+		}
+	})
+}
+
+func TestHandlerAgainstMock(t *testing.T) {
+	t.Logf("Importance: The server binary configures itself from the environment; pointed at the mock, its tools run end to end.")
+
+	mock := New("apiuser", testKey)
+	defer mock.Close()
+	for k, v := range mock.Env() {
+		t.Setenv(k, v)
+	}
+	news := mock.AddTag("Newsletter")
+	var smiths []spektrix.Customer
+	for _, name := range []string{"Ann", "Bob", "Cat"} {
+		smiths = append(smiths, mock.AddCustomer(spektrix.Customer{FirstName: name, LastName: "Smith", Email: strings.ToLower(name) + "@example.com"}))
+	}
+	mock.AddEvent(spektrix.Event{Name: "Hamlet", IsOnSale: true})
+
+	handler := spektrix.NewHandler()
+	if handler == nil {
+		t.Fatal("Expected NewHandler to configure itself from the mock's environment")
+	}
+	s := server.NewMCPServer("test", "1.0.0", server.WithToolCapabilities(false))
+	handler.SetupTools(s)
+	call := func(name string, args map[string]interface{}) string {
+		t.Helper()
+		message, _ := json.Marshal(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"method":  "tools/call",
+			"params":  map[string]interface{}{"name": name, "arguments": args},
+		})
+		resp, ok := s.HandleMessage(context.Background(), message).(mcp.JSONRPCResponse)
+		if !ok {
+			t.Fatalf("Expected a result calling %s", name)
+		}
+		result := resp.Result.(mcp.CallToolResult)
+		text := result.Content[0].(mcp.TextContent).Text
+		if result.IsError {
+			t.Fatalf("%s failed: %s", name, text)
+		}
+		return text
+	}
+
+	if text := call("spektrix_search_customers", map[string]interface{}{"lastName": "smith", "pageSize": float64(2)}); !strings.Contains(text, `"total": 3`) {
+		t.Errorf("Expected 3 Smiths over two pages, got %s", text)
+	}
+	call("spektrix_add_customer_tags", map[string]interface{}{"tags": "newsletter"})
+	for _, c := range smiths {
+		if _, tags, _ := mock.Customer(c.ID); len(tags) != 1 || tags[0] != news.ID {
+			t.Errorf("Expected every Smith tagged, %s has %v", c.FirstName, tags)
+		}
+	}
+	if text := call("spektrix_list_events", map[string]interface{}{"name": "ham"}); !strings.Contains(text, "Hamlet") {
+		t.Errorf("Expected Hamlet listed, got %s", text)
+	}
+}
@@ -0,0 +1,373 @@
+package mockspektrix
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/vcto/mcp-adapters/internal/spektrix"
+)
+
+// store is the one Spektrix system every request acts on
+type store struct {
+	customers []*customer
+	tags      []spektrix.Tag
+	events    []*event
+}
+
+type customer struct {
+	spektrix.Customer
+	// TagIDs are the customer's tags in the order they were added
+	TagIDs []string
+}
+
+type event struct {
+	spektrix.Event
+	Instances []spektrix.Instance
+}
+
+// AddCustomer stores a customer and returns it with its ID, a new one if it
+// has none. Address IDs are filled in the same way.
+func (s *Server) AddCustomer(c spektrix.Customer) spektrix.Customer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addCustomer(c)
+}
+
+// addCustomer stores c; called with s.mu held
+func (s *Server) addCustomer(c spektrix.Customer) spektrix.Customer {
+	if c.ID == "" {
+		c.ID = s.newID("customer")
+	}
+	c.Addresses = slices.Clone(c.Addresses)
+	for i := range c.Addresses {
+		if c.Addresses[i].ID == "" {
+			c.Addresses[i].ID = s.newID("address")
+		}
+	}
+	s.customers = append(s.customers, &customer{Customer: c})
+	return c
+}
+
+// Customer returns the stored customer with id and its tag IDs
+func (s *Server) Customer(id string) (spektrix.Customer, []string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.findCustomer(id)
+	if c == nil {
+		return spektrix.Customer{}, nil, false
+	}
+	stored := c.Customer
+	stored.Addresses = slices.Clone(c.Addresses)
+	return stored, slices.Clone(c.TagIDs), true
+}
+
+// AddTag creates a tag and returns it
+func (s *Server) AddTag(name string) spektrix.Tag {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tag := spektrix.Tag{ID: s.newID("tag"), Name: name}
+	s.tags = append(s.tags, tag)
+	return tag
+}
+
+// AddEvent stores an event and its instances, filling in missing IDs, and
+// returns the event
+func (s *Server) AddEvent(e spektrix.Event, instances ...spektrix.Instance) spektrix.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e.ID == "" {
+		e.ID = s.newID("event")
+	}
+	instances = slices.Clone(instances)
+	for i := range instances {
+		if instances[i].ID == "" {
+			instances[i].ID = s.newID("instance")
+		}
+	}
+	s.events = append(s.events, &event{Event: e, Instances: instances})
+	return e
+}
+
+func (s *Server) routes(mux *http.ServeMux) {
+	handle := func(pattern string, h func(w http.ResponseWriter, r *http.Request)) {
+		method, path, _ := strings.Cut(pattern, " ")
+		mux.HandleFunc(method+" "+basePath+path, h)
+	}
+	handle("GET /customers", s.searchCustomers)
+	handle("POST /customers", s.createCustomer)
+	handle("GET /customers/{id}", s.getCustomer)
+	handle("GET /customers/{id}/addresses", s.getAddresses)
+	handle("POST /customers/{id}/addresses", s.addAddresses)
+	handle("PUT /customers/{id}/addresses/{addressId}", s.updateAddress)
+	handle("GET /customers/{id}/tags", s.getCustomerTags)
+	handle("POST /customers/{id}/tags", s.addCustomerTags)
+	handle("PUT /customers/{id}/tags", s.setCustomerTags)
+	handle("DELETE /customers/{id}/tags/{tagId}", s.removeCustomerTag)
+	handle("GET /tags", s.getTags)
+	handle("GET /events", s.getEvents)
+	handle("GET /events/{id}", s.getEvent)
+	handle("GET /events/{id}/instances", s.getInstances)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "No such endpoint")
+	})
+}
+
+// searchCustomers answers an email search with the one customer found and
+// a name search with every match; both are 404 when nobody matches
+func (s *Server) searchCustomers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if email := query.Get("email"); email != "" {
+		for _, c := range s.customers {
+			if strings.EqualFold(c.Email, email) {
+				writeJSON(w, http.StatusOK, c.Customer)
+				return
+			}
+		}
+		writeError(w, http.StatusNotFound, "Customer not found")
+		return
+	}
+
+	lastName := query.Get("lastName")
+	if lastName == "" {
+		writeError(w, http.StatusBadRequest, "email or lastName is required")
+		return
+	}
+	matches := []spektrix.Customer{}
+	for _, c := range s.customers {
+		if !strings.EqualFold(c.LastName, lastName) {
+			continue
+		}
+		if first := query.Get("firstName"); first != "" && !strings.EqualFold(c.FirstName, first) {
+			continue
+		}
+		if postcode := query.Get("postcode"); postcode != "" && !hasPostcode(c.Customer, postcode) {
+			continue
+		}
+		matches = append(matches, c.Customer)
+	}
+	if len(matches) == 0 {
+		writeError(w, http.StatusNotFound, "Customer not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, matches)
+}
+
+func (s *Server) createCustomer(w http.ResponseWriter, r *http.Request) {
+	var req spektrix.CreateCustomerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Email == "" || req.LastName == "" {
+		writeError(w, http.StatusBadRequest, "firstName, lastName and email are required")
+		return
+	}
+	for _, c := range s.customers {
+		if strings.EqualFold(c.Email, req.Email) {
+			writeError(w, http.StatusConflict, "A customer with this email already exists")
+			return
+		}
+	}
+	writeJSON(w, http.StatusCreated, s.addCustomer(spektrix.Customer{FirstName: req.FirstName, LastName: req.LastName, Email: req.Email}))
+}
+
+func (s *Server) getCustomer(w http.ResponseWriter, r *http.Request) {
+	if c := s.customerFor(w, r); c != nil {
+		writeJSON(w, http.StatusOK, c.Customer)
+	}
+}
+
+func (s *Server) getAddresses(w http.ResponseWriter, r *http.Request) {
+	if c := s.customerFor(w, r); c != nil {
+		writeJSON(w, http.StatusOK, append([]spektrix.Address{}, c.Addresses...))
+	}
+}
+
+// addAddresses stores new addresses; one marked billing or delivery takes
+// that role from the customer's other addresses
+func (s *Server) addAddresses(w http.ResponseWriter, r *http.Request) {
+	c := s.customerFor(w, r)
+	if c == nil {
+		return
+	}
+	var addresses []spektrix.Address
+	if err := json.NewDecoder(r.Body).Decode(&addresses); err != nil {
+		writeError(w, http.StatusBadRequest, "Expected an array of addresses")
+		return
+	}
+	for _, address := range addresses {
+		address.ID = s.newID("address")
+		c.setDefaults(address)
+		c.Addresses = append(c.Addresses, address)
+	}
+	writeJSON(w, http.StatusOK, c.Addresses)
+}
+
+func (s *Server) updateAddress(w http.ResponseWriter, r *http.Request) {
+	c := s.customerFor(w, r)
+	if c == nil {
+		return
+	}
+	i := slices.IndexFunc(c.Addresses, func(a spektrix.Address) bool { return a.ID == r.PathValue("addressId") })
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "Address not found")
+		return
+	}
+	var address spektrix.Address
+	if err := json.NewDecoder(r.Body).Decode(&address); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid address")
+		return
+	}
+	address.ID = c.Addresses[i].ID
+	c.setDefaults(address)
+	c.Addresses[i] = address
+	writeJSON(w, http.StatusOK, address)
+}
+
+// setDefaults clears the billing and delivery flags address takes over
+func (c *customer) setDefaults(address spektrix.Address) {
+	for i := range c.Addresses {
+		if address.IsBilling {
+			c.Addresses[i].IsBilling = false
+		}
+		if address.IsDelivery {
+			c.Addresses[i].IsDelivery = false
+		}
+	}
+}
+
+func (s *Server) getCustomerTags(w http.ResponseWriter, r *http.Request) {
+	c := s.customerFor(w, r)
+	if c == nil {
+		return
+	}
+	tags := []spektrix.Tag{}
+	for _, id := range c.TagIDs {
+		tags = append(tags, s.tags[slices.IndexFunc(s.tags, func(t spektrix.Tag) bool { return t.ID == id })])
+	}
+	writeJSON(w, http.StatusOK, tags)
+}
+
+func (s *Server) addCustomerTags(w http.ResponseWriter, r *http.Request) {
+	c := s.customerFor(w, r)
+	if c == nil {
+		return
+	}
+	ids, ok := s.tagRefs(w, r)
+	if !ok {
+		return
+	}
+	for _, id := range ids {
+		if !slices.Contains(c.TagIDs, id) {
+			c.TagIDs = append(c.TagIDs, id)
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) setCustomerTags(w http.ResponseWriter, r *http.Request) {
+	c := s.customerFor(w, r)
+	if c == nil {
+		return
+	}
+	ids, ok := s.tagRefs(w, r)
+	if !ok {
+		return
+	}
+	c.TagIDs = ids
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) removeCustomerTag(w http.ResponseWriter, r *http.Request) {
+	c := s.customerFor(w, r)
+	if c == nil {
+		return
+	}
+	i := slices.Index(c.TagIDs, r.PathValue("tagId"))
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "The customer does not have this tag")
+		return
+	}
+	c.TagIDs = slices.Delete(c.TagIDs, i, i+1)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) getTags(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, append([]spektrix.Tag{}, s.tags...))
+}
+
+func (s *Server) getEvents(w http.ResponseWriter, r *http.Request) {
+	events := []spektrix.Event{}
+	for _, e := range s.events {
+		events = append(events, e.Event)
+	}
+	writeJSON(w, http.StatusOK, events)
+}
+
+func (s *Server) getEvent(w http.ResponseWriter, r *http.Request) {
+	if e := s.eventFor(w, r); e != nil {
+		writeJSON(w, http.StatusOK, e.Event)
+	}
+}
+
+func (s *Server) getInstances(w http.ResponseWriter, r *http.Request) {
+	if e := s.eventFor(w, r); e != nil {
+		writeJSON(w, http.StatusOK, append([]spektrix.Instance{}, e.Instances...))
+	}
+}
+
+// customerFor returns the customer in the path, answering 404 if there is
+// none
+func (s *Server) customerFor(w http.ResponseWriter, r *http.Request) *customer {
+	c := s.findCustomer(r.PathValue("id"))
+	if c == nil {
+		writeError(w, http.StatusNotFound, "Customer not found")
+	}
+	return c
+}
+
+func (s *Server) findCustomer(id string) *customer {
+	for _, c := range s.customers {
+		if c.ID == id {
+			return c
+		}
+	}
+	return nil
+}
+
+func (s *Server) eventFor(w http.ResponseWriter, r *http.Request) *event {
+	for _, e := range s.events {
+		if e.ID == r.PathValue("id") {
+			return e
+		}
+	}
+	writeError(w, http.StatusNotFound, "Event not found")
+	return nil
+}
+
+// tagRefs reads a body of tag references, answering 400 if one names no
+// tag
+func (s *Server) tagRefs(w http.ResponseWriter, r *http.Request) ([]string, bool) {
+	var refs []spektrix.TagReference
+	if err := json.NewDecoder(r.Body).Decode(&refs); err != nil {
+		writeError(w, http.StatusBadRequest, "Expected an array of tag references")
+		return nil, false
+	}
+	ids := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		if !slices.ContainsFunc(s.tags, func(t spektrix.Tag) bool { return t.ID == ref.ID }) {
+			writeError(w, http.StatusBadRequest, "Unknown tag "+ref.ID)
+			return nil, false
+		}
+		ids = append(ids, ref.ID)
+	}
+	return ids, true
+}
+
+func hasPostcode(c spektrix.Customer, postcode string) bool {
+	normalize := func(p string) string { return strings.ToUpper(strings.ReplaceAll(p, " ", "")) }
+	for _, address := range c.Addresses {
+		if normalize(address.Postcode) == normalize(postcode) {
+			return true
+		}
+	}
+	return false
+}